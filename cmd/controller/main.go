@@ -17,11 +17,111 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+
 	"knative.dev/eventing-ceph/pkg/reconciler/ceph"
+	"knative.dev/eventing-ceph/pkg/reconciler/migration"
+	"knative.dev/eventing-ceph/pkg/version"
 
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/injection"
 	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/leaderelection"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+	"knative.dev/pkg/profiling"
+	"knative.dev/pkg/signals"
 )
 
+const component = "ceph-controller"
+
 func main() {
-	sharedmain.Main("ceph-controller", ceph.NewController)
+	disableHighAvailability := flag.Bool("disable-ha", false,
+		"Whether to disable high-availability functionality for this component.")
+
+	// This parses flags, so the above is set once this runs.
+	cfg := injection.ParseAndGetRESTConfigOrDie()
+
+	ctx := signals.NewContext()
+	if *disableHighAvailability {
+		ctx = sharedmain.WithHADisabled(ctx)
+	}
+	mainWithConfig(ctx, cfg, ceph.NewController, migration.NewController)
+}
+
+// mainWithConfig runs the controllers like sharedmain.MainWithConfig, also
+// serving the version of the controller on the profiling server, so that no
+// port of its own can clash.
+func mainWithConfig(ctx context.Context, cfg *rest.Config, ctors ...injection.ControllerConstructor) {
+	metrics.MemStatsOrDie(ctx)
+
+	if cfg.QPS == 0 {
+		cfg.QPS = float32(len(ctors)) * rest.DefaultQPS
+	}
+	if cfg.Burst == 0 {
+		cfg.Burst = len(ctors) * rest.DefaultBurst
+	}
+
+	ctx, startInformers := injection.EnableInjectionOrDie(ctx, cfg)
+
+	logger, atomicLevel := sharedmain.SetupLoggerOrDie(ctx, component)
+	defer func() {
+		logger.Sync()
+		metrics.FlushExporter()
+	}()
+	ctx = logging.WithLogger(ctx, logger)
+	rest.SetDefaultWarningHandler(&logging.WarningHandler{Logger: logger})
+
+	profilingHandler := profiling.NewHandler(logger, false)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", version.Handler)
+	mux.Handle("/", profilingHandler)
+	profilingServer := profiling.NewServer(mux)
+
+	sharedmain.CheckK8sClientMinimumVersionOrDie(ctx, logger)
+	cmw := sharedmain.SetupConfigMapWatchOrDie(ctx, logger)
+
+	leaderElectionConfig, err := sharedmain.GetLeaderElectionConfig(ctx)
+	if err != nil {
+		logger.Fatalw("Error loading leader election configuration", zap.Error(err))
+	}
+	if !sharedmain.IsHADisabled(ctx) {
+		ctx = leaderelection.WithDynamicLeaderElectorBuilder(ctx, kubeclient.Get(ctx),
+			leaderElectionConfig.GetComponentConfig(component))
+	}
+
+	controllers, _ := sharedmain.ControllersAndWebhooksFromCtors(ctx, cmw, ctors...)
+	sharedmain.WatchLoggingConfigOrDie(ctx, cmw, logger, atomicLevel, component)
+	sharedmain.WatchObservabilityConfigOrDie(ctx, cmw, profilingHandler, logger, component)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- profilingServer.ListenAndServe()
+	}()
+
+	logger.Info("Starting configuration manager...")
+	if err := cmw.Start(ctx.Done()); err != nil {
+		logger.Fatalw("Failed to start configuration manager", zap.Error(err))
+	}
+	startInformers()
+
+	logger.Info("Starting controllers...")
+	go controller.StartAll(ctx, controllers...)
+
+	// Block until either a signal arrives or the profiling server fails.
+	select {
+	case <-ctx.Done():
+		profilingServer.Shutdown(context.Background())
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorw("Error while running server", zap.Error(err))
+		}
+	}
 }
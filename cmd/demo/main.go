@@ -0,0 +1,222 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command demo runs a mock RGW pushing bucket notifications to the receive
+// adapter, which sends their events to an in-process event display, to see
+// the events of a CephSource locally before deploying it:
+//
+//	go run ./cmd/demo
+//
+// The adapter is configured from the environment like in its pod, e.g.
+// PROFILE=aws-s3 go run ./cmd/demo, the demo setting its PORT, K_SINK,
+// NAMESPACE and NAME.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	cephadapter "knative.dev/eventing-ceph/pkg/adapter"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+var (
+	port     = flag.Int("port", 8080, "Port of the receive adapter")
+	sinkPort = flag.Int("sink-port", 8081, "Port of the event display")
+	interval = flag.Duration("interval", 2*time.Second, "Interval between two notifications")
+	count    = flag.Int("count", 0, "Number of notifications to push, 0 for no limit")
+	verbose  = flag.Bool("verbose", false, "Log the adapter")
+)
+
+// operations are the object operations the mock RGW notifies in turn.
+var operations = []struct {
+	eventName string
+	key       string
+}{
+	{"s3:ObjectCreated:Put", "photos/fish.jpg"},
+	{"s3:ObjectCreated:Copy", "photos/fish-copy.jpg"},
+	{"s3:ObjectRemoved:Delete", "photos/fish.jpg"},
+}
+
+func main() {
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	logger := zap.NewNop().Sugar()
+	if *verbose {
+		l, err := zap.NewDevelopment()
+		if err != nil {
+			log.Fatal(err)
+		}
+		logger = l.Sugar()
+	}
+	ctx = logging.WithLogger(ctx, logger)
+
+	sinkURL := fmt.Sprintf("http://127.0.0.1:%d", *sinkPort)
+	adapterURL := fmt.Sprintf("http://127.0.0.1:%d/", *port)
+
+	display, err := cloudevents.NewClientHTTP(cloudevents.WithPort(*sinkPort))
+	if err != nil {
+		log.Fatalf("Failed to create the event display: %v", err)
+	}
+	go func() {
+		if err := display.StartReceiver(ctx, func(event cloudevents.Event) {
+			fmt.Printf("☁️  cloudevents.Event\n%s\n", event)
+		}); err != nil {
+			log.Fatalf("Failed to start the event display: %v", err)
+		}
+	}()
+
+	for name, value := range map[string]string{
+		"PORT":      fmt.Sprint(*port),
+		"K_SINK":    sinkURL,
+		"NAMESPACE": "demo",
+		"NAME":      "demo",
+	} {
+		os.Setenv(name, value)
+	}
+	env := cephadapter.NewEnvConfig()
+	if err := envconfig.Process("", env); err != nil {
+		log.Fatalf("Invalid adapter settings: %v", err)
+	}
+	client, err := cloudevents.NewClientHTTP(cloudevents.WithTarget(sinkURL))
+	if err != nil {
+		log.Fatalf("Failed to create the sink client: %v", err)
+	}
+	adapterDone := make(chan error, 1)
+	go func() {
+		adapterDone <- cephadapter.NewAdapter(ctx, env, client).Start(ctx)
+	}()
+	if err := waitListening(ctx, fmt.Sprint(*port)); err != nil {
+		log.Fatalf("The adapter did not start: %v", err)
+	}
+	log.Printf("Pushing notifications to the adapter at %s, events are displayed below. Press Ctrl+C to stop.", adapterURL)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+pushing:
+	for i := 0; *count == 0 || i < *count; i++ {
+		if err := push(ctx, adapterURL, notification(i)); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to push notification %d: %v", i, err)
+		}
+		select {
+		case <-ctx.Done():
+			break pushing
+		case <-ticker.C:
+		}
+	}
+	cancel()
+	if err := <-adapterDone; err != nil {
+		log.Fatalf("The adapter failed: %v", err)
+	}
+}
+
+// notification returns the i-th notification of the mock RGW.
+func notification(i int) []byte {
+	op := operations[i%len(operations)]
+	record := ceph.BucketNotification{
+		EventVersion: "2.2",
+		EventSource:  "ceph:s3",
+		AwsRegion:    "default",
+		EventTime:    time.Now().UTC().Format(time.RFC3339Nano),
+		EventName:    op.eventName,
+		UserIdentity: ceph.UserIdentitySpec{PrincipalID: "demo"},
+		ResponseElements: ceph.ResponseElementsSpec{
+			XAmzRequestID: fmt.Sprintf("demo.%d", i),
+			XAmzID2:       "demo",
+		},
+		S3: ceph.S3Spec{
+			S3SchemaVersion: "1.0",
+			ConfigurationID: "demo",
+			Bucket: ceph.BucketSpec{
+				Name:          "fishbucket",
+				OwnerIdentity: ceph.OwnerIdentitySpec{PrincipalID: "demo"},
+				Arn:           "arn:aws:s3:::fishbucket",
+			},
+			Object: ceph.ObjectSpec{
+				Key:       op.key,
+				Sequencer: fmt.Sprintf("%016X", i),
+			},
+		},
+		EventID: fmt.Sprintf("demo.%d", i),
+	}
+	if op.eventName != "s3:ObjectRemoved:Delete" {
+		record.S3.Object.Size = 1024
+		record.S3.Object.ETag = "37b51d194a7513e45b56f6524f2d51f2"
+	}
+	body, _ := json.Marshal(ceph.BucketNotifications{Records: []ceph.BucketNotification{record}})
+	return body
+}
+
+// push pushes a notification to the adapter, as the RGW does.
+func push(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("the adapter responded %s", resp.Status)
+	}
+	return nil
+}
+
+// waitListening waits for the adapter to listen on port.
+func waitListening(ctx context.Context, port string) error {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", port))
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			return err
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -17,11 +17,32 @@ limitations under the License.
 package main
 
 import (
+	"log"
+	"net/http"
+
 	"knative.dev/eventing/pkg/adapter/v2"
 
 	cephadapter "knative.dev/eventing-ceph/pkg/adapter"
 )
 
+// hooks are the hooks the adapter calls along the handling of the records,
+// where downstream builds register theirs, e.g. tenant lookups or billing
+// counters.
+var hooks []cephadapter.Hook
+
 func main() {
-	adapter.Main("cephsource", cephadapter.NewEnvConfig, cephadapter.NewAdapter)
+	// The settings of the configuration file, if any, are set as env vars
+	// before any is read.
+	if err := cephadapter.LoadConfigFile(); err != nil {
+		log.Fatalf("Invalid configuration file: %v", err)
+	}
+	// The sink client sends through the default transport, resolving the
+	// sink hostnames and restricting its TLS as configured, and wrapped for
+	// the adapter to see the Retry-After of the sink responses.
+	transport, err := cephadapter.NewSinkTransport(http.DefaultTransport)
+	if err != nil {
+		log.Fatalf("Invalid sink transport settings: %v", err)
+	}
+	http.DefaultTransport = cephadapter.NewRetryAfterTransport(transport)
+	adapter.Main("cephsource", cephadapter.NewEnvConfig, cephadapter.NewAdapterWithHooks(hooks...))
 }
@@ -32,7 +32,10 @@ import (
 	"knative.dev/pkg/webhook/resourcesemantics/defaulting"
 	"knative.dev/pkg/webhook/resourcesemantics/validation"
 
+	"knative.dev/eventing-ceph/pkg/apis/config"
 	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	cephclient "knative.dev/eventing-ceph/pkg/client/injection/client"
+	"knative.dev/eventing-ceph/pkg/webhook/quota"
 )
 
 var types = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
@@ -40,12 +43,14 @@ var types = map[schema.GroupVersionKind]resourcesemantics.GenericCRD{
 	v1alpha1.SchemeGroupVersion.WithKind("CephSource"): &v1alpha1.CephSource{},
 }
 
-var callbacks = map[schema.GroupVersionKind]validation.Callback{}
-
 const admissionWebhookName = "ceph-webhook"
 
 // NewDefaultingAdmissionController sets up mutating webhook.
 func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	// Watch the defaults of CephSources.
+	store := config.NewStore(logging.FromContext(ctx).Named("config-store"))
+	store.WatchConfigs(cmw)
+
 	return defaulting.NewAdmissionController(ctx,
 
 		// Name of the resource webhook.
@@ -58,11 +63,7 @@ func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher
 		types,
 
 		// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
-		func(ctx context.Context) context.Context {
-			// Here is where you would infuse the context with state
-			// (e.g. attach a store with configmap data)
-			return ctx
-		},
+		store.ToContext,
 
 		// Whether to disallow unknown fields.
 		true,
@@ -71,6 +72,14 @@ func NewDefaultingAdmissionController(ctx context.Context, cmw configmap.Watcher
 
 // NewValidationAdmissionController sets up validation webhook.
 func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
+	// Watch the quotas of CephSources.
+	store := config.NewStore(logging.FromContext(ctx).Named("config-store"))
+	store.WatchConfigs(cmw)
+
+	callbacks := map[schema.GroupVersionKind]validation.Callback{
+		v1alpha1.SchemeGroupVersion.WithKind("CephSource"): quota.NewCallback(cephclient.Get(ctx)),
+	}
+
 	return validation.NewAdmissionController(ctx,
 
 		// Name of the resource webhook.
@@ -83,11 +92,7 @@ func NewValidationAdmissionController(ctx context.Context, cmw configmap.Watcher
 		types,
 
 		// A function that infuses the context passed to Validate/SetDefaults with custom metadata.
-		func(ctx context.Context) context.Context {
-			// Here is where you would infuse the context with state
-			// (e.g. attach a store with configmap data)
-			return ctx
-		},
+		store.ToContext,
 
 		// Whether to disallow unknown fields.
 		true,
@@ -109,8 +114,11 @@ func NewConfigValidationController(ctx context.Context, cmw configmap.Watcher) *
 
 		// The configmaps to validate.
 		configmap.Constructors{
-			logging.ConfigMapName(): logging.NewConfigFromConfigMap,
-			metrics.ConfigMapName(): metrics.NewObservabilityConfigFromConfigMap,
+			logging.ConfigMapName():   logging.NewConfigFromConfigMap,
+			metrics.ConfigMapName():   metrics.NewObservabilityConfigFromConfigMap,
+			config.DefaultsConfigName: config.NewDefaultsConfigFromConfigMap,
+			config.QuotasConfigName:   config.NewQuotasConfigFromConfigMap,
+			config.AdapterConfigName:  config.NewAdapterConfigFromConfigMap,
 		},
 	)
 }
@@ -5,15 +5,20 @@ go 1.15
 require (
 	github.com/cloudevents/sdk-go/v2 v2.4.1
 	github.com/google/go-cmp v0.5.6
+	github.com/google/uuid v1.3.0
 	github.com/influxdata/tdigest v0.0.1 // indirect
 	github.com/kelseyhightower/envconfig v1.4.0
+	github.com/robfig/cron/v3 v3.0.1
+	go.opencensus.io v0.23.0
 	go.uber.org/zap v1.19.1
+	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
 	k8s.io/api v0.21.4
 	k8s.io/apimachinery v0.21.4
 	k8s.io/client-go v0.21.4
 	knative.dev/eventing v0.27.0
 	knative.dev/hack v0.0.0-20211101195839-11d193bf617b
 	knative.dev/pkg v0.0.0-20211101212339-96c0204a70dc
+	sigs.k8s.io/yaml v1.3.0
 )
 
 replace (
@@ -18,38 +18,469 @@ package adapter
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.opencensus.io/trace"
 	"go.uber.org/zap"
 	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+	"knative.dev/eventing-ceph/pkg/otlp"
+	"knative.dev/eventing-ceph/pkg/projection"
+	"knative.dev/eventing-ceph/pkg/tlspolicy"
+	"knative.dev/eventing-ceph/pkg/transform"
+	"knative.dev/eventing-ceph/pkg/version"
 	"knative.dev/eventing/pkg/adapter/v2"
 	"knative.dev/pkg/logging"
 )
 
 const (
 	resourceGroup = "cephsources.sources.knative.dev"
+
+	// shutdownTimeout bounds the time given to pending requests on shutdown.
+	shutdownTimeout = 10 * time.Second
+
+	// sendSpanName is the name of the span of the delivery of an event.
+	sendSpanName = "cephsource.send"
 )
 
+// envConfig is the configuration of the adapter, read from its environment.
+// The controller sets it from the spec of the CephSource, but the adapter
+// also runs from a Deployment of its own, e.g. bound to its sink by a
+// SinkBinding. The embedded adapter.EnvConfig holds the environment of all
+// Knative sources, which the adapter honors:
+//
+//   - K_SINK is the URI the events are sent to, unless routed elsewhere by
+//     the features sending to other sinks, e.g. the dead letter sink.
+//   - K_CE_OVERRIDES is the JSON of the CloudEvent overrides, whose
+//     extensions are set on every event sent, including to the other sinks,
+//     replacing the ones the adapter sets.
+//   - K_SINK_TIMEOUT bounds in seconds each request to the sinks.
+//   - NAMESPACE and NAME identify the source, in the source of the events
+//     the adapter makes itself and in the tags of the metrics.
+//
+// A SinkBinding injects K_SINK and K_CE_OVERRIDES into the pods of the
+// Deployment, restarting them when the sink or the overrides change.
 type envConfig struct {
 	adapter.EnvConfig
 
-	// Port to listen incoming connections
+	// Port to listen incoming connections, an ephemeral one if "0"
 	Port string `envconfig:"PORT"`
+
+	// PortFallback listens on an ephemeral port when the port of a listener
+	// is in use, e.g. to run several adapters in a pod or test process, the
+	// ports bound being served on /ready and sent in the lifecycle events
+	PortFallback bool `envconfig:"PORT_FALLBACK"`
+
+	// Listeners is the JSON array of the listeners added to the one of Port,
+	// each with its own TLS, auth and filter.
+	Listeners string `envconfig:"LISTENERS"`
+
+	// TLSMinVersion and TLSCipherSuites restrict the TLS of the listeners,
+	// as parsed by tlspolicy.Parse. NewSinkTransport applies them to the
+	// sink client.
+	TLSMinVersion   string   `envconfig:"TLS_MIN_VERSION"`
+	TLSCipherSuites []string `envconfig:"TLS_CIPHER_SUITES"`
+
+	// BindAddress is the address to listen on, e.g. "127.0.0.1" behind a
+	// service mesh sidecar. All addresses if unset.
+	BindAddress string `envconfig:"BIND_ADDRESS"`
+
+	// IdleTimeout, if set, shuts the adapter down once no notification has
+	// been received for that long, e.g. for it to be scaled to zero with
+	// its spools flushed
+	IdleTimeout time.Duration `envconfig:"IDLE_TIMEOUT"`
+
+	// AdminPort is the port the admin endpoints are served on, rather than
+	// on Port
+	AdminPort string `envconfig:"ADMIN_PORT"`
+
+	// AdminToken is the token required by the admin verbs, rather than the
+	// auth tokens
+	AdminToken string `envconfig:"ADMIN_TOKEN"`
+
+	// Profile selects how notifications are mapped to CloudEvents
+	Profile string `envconfig:"PROFILE" default:"ceph"`
+
+	// TypePrefix replaces the type prefix of the profile
+	TypePrefix string `envconfig:"TYPE_PREFIX"`
+
+	// Payload selects the shape of the event data
+	Payload string `envconfig:"PAYLOAD" default:"record"`
+
+	// SpecVersion is the CloudEvents version of the events sent to the
+	// sink, and ContentMode their content mode, "binary" or "structured".
+	// The SDK defaults, 1.0 events in binary mode, are used if unset.
+	SpecVersion string `envconfig:"SPEC_VERSION"`
+	ContentMode string `envconfig:"CONTENT_MODE"`
+
+	// DataSchema is the URI of the JSON schema of the event data, set as
+	// the dataschema attribute of the events. Not set if empty.
+	DataSchema string `envconfig:"DATA_SCHEMA"`
+
+	// Format selects how pushed notifications are parsed
+	Format string `envconfig:"FORMAT" default:"ceph"`
+
+	// AuthPaths and AuthHosts list the request paths and hosts requiring a
+	// token, the token of the i-th path and host being read from the
+	// AUTH_TOKEN_<i> variable
+	AuthPaths []string `envconfig:"AUTH_PATHS"`
+	AuthHosts []string `envconfig:"AUTH_HOSTS"`
+
+	// SigV4AccessKeyIDs lists the access keys the notifications must be
+	// signed with, the secret of the i-th being read from the
+	// SIGV4_SECRET_ACCESS_KEY_<i> variable, signed at most SigV4MaxSkew away
+	SigV4AccessKeyIDs         []string      `envconfig:"SIGV4_ACCESS_KEY_IDS"`
+	SigV4MaxSkew              time.Duration `envconfig:"SIGV4_MAX_SKEW" default:"5m"`
+	SigV4AllowUnsignedPayload bool          `envconfig:"SIGV4_ALLOW_UNSIGNED_PAYLOAD"`
+
+	// Backpressure reports sink overload to Ceph as 503
+	Backpressure bool `envconfig:"BACKPRESSURE"`
+
+	// MaxConcurrency bounds the concurrent sends to the sink, the actual
+	// limit adapting to the sink's throughput. 0 means unbounded.
+	MaxConcurrency int `envconfig:"MAX_CONCURRENCY"`
+
+	// DecodeKeys URL-decodes object keys
+	DecodeKeys bool `envconfig:"DECODE_KEYS"`
+
+	// CopySource resolves the copy source of copied objects
+	CopySource bool `envconfig:"COPY_SOURCE"`
+
+	// SenderEventSources, SenderRegions and SenderAddresses restrict the
+	// event sources, regions and addresses (IPs or CIDRs) notifications are
+	// accepted from
+	SenderEventSources []string `envconfig:"SENDER_EVENT_SOURCES"`
+	SenderRegions      []string `envconfig:"SENDER_REGIONS"`
+	SenderAddresses    []string `envconfig:"SENDER_ADDRESSES"`
+
+	// DebugLogSamplingInitial and DebugLogSamplingThereafter sample the
+	// per-event debug logs, logging the first entries of each second then
+	// every Thereafter-th one. 0 disables sampling.
+	DebugLogSamplingInitial    int `envconfig:"DEBUG_LOG_SAMPLING_INITIAL"`
+	DebugLogSamplingThereafter int `envconfig:"DEBUG_LOG_SAMPLING_THEREAFTER"`
+
+	// OTLPMetricsEndpoint is the OTLP/HTTP endpoint metrics are pushed to,
+	// e.g. "http://otel-collector:4318/v1/metrics", along with the headers
+	// in OTLPHeaders every OTLPExportInterval milliseconds. Metrics are not
+	// pushed if unset.
+	OTLPMetricsEndpoint string `envconfig:"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"`
+	OTLPHeaders         string `envconfig:"OTEL_EXPORTER_OTLP_HEADERS"`
+	OTLPExportInterval  int    `envconfig:"OTEL_METRIC_EXPORT_INTERVAL" default:"60000"`
+
+	// Verify is the S3 API of the RGW the eTag of created objects is
+	// verified against. Not verified if unset.
+	Verify rgwEnvConfig `envconfig:"VERIFY"`
+
+	// Usage is the RGW whose usage of UsageUsers, all if empty, is polled
+	// every UsageInterval. Not polled if unset.
+	Usage         rgwEnvConfig  `envconfig:"USAGE"`
+	UsageUsers    []string      `envconfig:"USAGE_USERS"`
+	UsageInterval time.Duration `envconfig:"USAGE_INTERVAL" default:"1h"`
+
+	// ExpiryPreview is the S3 API of the RGW whose ExpiryPreviewBuckets are
+	// inspected every ExpiryPreviewInterval for the objects expiring within
+	// ExpiryPreviewDays. Not inspected if unset.
+	ExpiryPreview         rgwEnvConfig  `envconfig:"EXPIRY_PREVIEW"`
+	ExpiryPreviewBuckets  []string      `envconfig:"EXPIRY_PREVIEW_BUCKETS"`
+	ExpiryPreviewDays     int           `envconfig:"EXPIRY_PREVIEW_DAYS" default:"7"`
+	ExpiryPreviewInterval time.Duration `envconfig:"EXPIRY_PREVIEW_INTERVAL" default:"24h"`
+
+	// Audit is the S3 API of the RGW the events emitted are mirrored to, to
+	// daily objects of AuditBucket prefixed with AuditPrefix, every
+	// AuditFlushInterval or once AuditMaxBatch events are pending. Not
+	// mirrored if unset.
+	Audit              rgwEnvConfig  `envconfig:"AUDIT"`
+	AuditBucket        string        `envconfig:"AUDIT_BUCKET"`
+	AuditPrefix        string        `envconfig:"AUDIT_PREFIX"`
+	AuditFlushInterval time.Duration `envconfig:"AUDIT_FLUSH_INTERVAL" default:"10s"`
+	AuditMaxBatch      int           `envconfig:"AUDIT_MAX_BATCH" default:"1000"`
+
+	// CredentialsDir, if set, is the directory the RGW credentials of the
+	// features above are read from, written by an external secret manager,
+	// rather than from their env vars.
+	CredentialsDir string `envconfig:"CREDENTIALS_DIR"`
+
+	// RGWCABundle and RGWPinnedKeys, if set, restrict the certificates
+	// accepted from the RGW endpoints above to the ones signed by the PEM
+	// certificate authorities of RGWCABundle and whose chain contains one
+	// of the base64 SHA-256 subject public key info hashes of
+	// RGWPinnedKeys.
+	RGWCABundle   string   `envconfig:"RGW_CA_BUNDLE"`
+	RGWPinnedKeys []string `envconfig:"RGW_PINNED_KEYS"`
+
+	// FilterEventNames, FilterVersioned, FilterDeleteMarkers,
+	// FilterEncryption and FilterStorageClasses select the notifications
+	// sent to the sink
+	FilterEventNames     []string `envconfig:"FILTER_EVENT_NAMES"`
+	FilterVersioned      *bool    `envconfig:"FILTER_VERSIONED"`
+	FilterDeleteMarkers  string   `envconfig:"FILTER_DELETE_MARKERS"`
+	FilterEncryption     string   `envconfig:"FILTER_ENCRYPTION"`
+	FilterStorageClasses []string `envconfig:"FILTER_STORAGE_CLASSES"`
+
+	// RetentionDir is the directory the events that could not be delivered
+	// are retained in, up to RetentionMaxEvents of them, to be re-driven to
+	// the sink on demand. Not retained if unset.
+	RetentionDir       string `envconfig:"RETENTION_DIR"`
+	RetentionMaxEvents int    `envconfig:"RETENTION_MAX_EVENTS" default:"1000"`
+
+	// SpoolStore and DedupStore are the names of the stores the events are
+	// retained in and the notifications remembered in, as registered with
+	// RegisterSpoolStore and RegisterDedupStore.
+	SpoolStore string `envconfig:"SPOOL_STORE" default:"file"`
+	DedupStore string `envconfig:"DEDUP_STORE" default:"memory"`
+
+	// RateLimits lists the rate limits of buckets, in the
+	// "bucket=eventsPerSecond:burst" format, "*" applying to each bucket
+	// without a limit of its own
+	RateLimits []string `envconfig:"RATE_LIMITS"`
+
+	// EventTimePolicy is applied to the events whose time is more than
+	// EventTimeMaxFuture in the future, or EventTimeMaxAge in the past if
+	// set. Event times are not checked if unset.
+	EventTimePolicy    string        `envconfig:"EVENT_TIME_POLICY"`
+	EventTimeMaxFuture time.Duration `envconfig:"EVENT_TIME_MAX_FUTURE" default:"1m"`
+	EventTimeMaxAge    time.Duration `envconfig:"EVENT_TIME_MAX_AGE"`
+
+	// AgeSLOThreshold, if set, is the age the notifications are to be
+	// received within, tripping the objective when exceeded for
+	// AgeSLOPeriod
+	AgeSLOThreshold time.Duration `envconfig:"AGE_SLO_THRESHOLD"`
+	AgeSLOPeriod    time.Duration `envconfig:"AGE_SLO_PERIOD"`
+
+	// TrafficExpectations is the JSON array of the minimum numbers of
+	// notifications of some event names expected per period
+	TrafficExpectations string `envconfig:"TRAFFIC_EXPECTATIONS"`
+
+	// MaintenanceWindows is the JSON array of the windows during which
+	// events are spooled or dropped rather than delivered
+	MaintenanceWindows string `envconfig:"MAINTENANCE_WINDOWS"`
+
+	// DeliveryRetry is the number of retries of the events the sink failed
+	// to accept with a retryable failure, DeliveryBackoffDelay the wait
+	// before the first one, and DeadLetterSink where the events are sent
+	// once they failed for good. Not retried nor sent if unset.
+	DeliveryRetry        int           `envconfig:"DELIVERY_RETRY"`
+	DeliveryBackoffDelay time.Duration `envconfig:"DELIVERY_BACKOFF_DELAY" default:"1s"`
+	DeadLetterSink       string        `envconfig:"DEAD_LETTER_SINK"`
+
+	// FailureSink is where the events that failed to be delivered for good
+	// are reported. Not reported if unset.
+	FailureSink string `envconfig:"FAILURE_SINK"`
+
+	// FailoverSinks is the JSON array of the sinks the events fail over to,
+	// in order, while the sink is down, skipping a sink deemed down for
+	// FailoverCooldown.
+	FailoverSinks    string        `envconfig:"FAILOVER_SINKS"`
+	FailoverCooldown time.Duration `envconfig:"FAILOVER_COOLDOWN" default:"30s"`
+
+	// LoadSheddingThreshold, if set, is the time the sink may take to
+	// respond, the notifications of low priority of the JSON array of
+	// classes LoadSheddingPriorities being shed once exceeded for
+	// LoadSheddingPeriod
+	LoadSheddingThreshold  time.Duration `envconfig:"LOAD_SHEDDING_THRESHOLD"`
+	LoadSheddingPeriod     time.Duration `envconfig:"LOAD_SHEDDING_PERIOD"`
+	LoadSheddingPriorities string        `envconfig:"LOAD_SHEDDING_PRIORITIES"`
+
+	// LifecycleEvents sends an event when the adapter starts and stops, to
+	// LifecycleSink if set or to the sink.
+	LifecycleEvents bool   `envconfig:"LIFECYCLE_EVENTS"`
+	LifecycleSink   string `envconfig:"LIFECYCLE_SINK"`
+
+	// SinkHeaders lists the headers added to the requests to the sink, the
+	// value of the i-th header being read from the SINK_HEADER_<i> variable
+	SinkHeaders []string `envconfig:"SINK_HEADERS"`
+
+	// MaxEventSize is the maximum size of an event, the larger ones being
+	// handled by EventSizePolicy. Not bounded if 0.
+	MaxEventSize    int    `envconfig:"MAX_EVENT_SIZE"`
+	EventSizePolicy string `envconfig:"EVENT_SIZE_POLICY" default:"truncate"`
+
+	// Role is "receiver" to queue the events in QueueDir rather than
+	// sending them, or "dispatcher" to send the events queued there every
+	// DispatchInterval instead of receiving notifications. The adapter
+	// both receives and sends if unset.
+	Role             string        `envconfig:"ROLE"`
+	QueueDir         string        `envconfig:"QUEUE_DIR"`
+	QueueMaxEvents   int           `envconfig:"QUEUE_MAX_EVENTS" default:"10000"`
+	DispatchInterval time.Duration `envconfig:"DISPATCH_INTERVAL" default:"1s"`
+
+	// RoutesFile is the YAML routing table sending the events of the keys
+	// with a given prefix to the sink of that prefix, reloaded on change.
+	// Not routed if unset.
+	RoutesFile string `envconfig:"ROUTES_FILE"`
+
+	// DuplicateWindow is the number of the last notifications remembered
+	// to count those RGW pushes again. Not counted if 0.
+	DuplicateWindow int `envconfig:"DUPLICATE_WINDOW" default:"10000"`
+
+	// SkipDuplicates acknowledges the notifications of the window that were
+	// already delivered without delivering them again.
+	SkipDuplicates bool `envconfig:"SKIP_DUPLICATES"`
+
+	// SuccessStatus is the status code responded to the notifications
+	// handled successfully: 200, 202 or 204.
+	SuccessStatus int `envconfig:"SUCCESS_STATUS" default:"200"`
+
+	// EmptyNotifications is what to do with the notifications holding no
+	// record
+	EmptyNotifications string `envconfig:"EMPTY_NOTIFICATIONS" default:"ignore"`
+
+	// CheckpointDir is where the last notifications processed per bucket
+	// and key prefix of CheckpointPrefixDepth segments are persisted, every
+	// CheckpointInterval, to detect the notifications RGW pushes again
+	// after a restart, skipped if CheckpointSkip. Not checkpointed if unset.
+	CheckpointDir         string        `envconfig:"CHECKPOINT_DIR"`
+	CheckpointPrefixDepth int           `envconfig:"CHECKPOINT_PREFIX_DEPTH" default:"1"`
+	CheckpointSkip        bool          `envconfig:"CHECKPOINT_SKIP"`
+	CheckpointInterval    time.Duration `envconfig:"CHECKPOINT_INTERVAL" default:"5s"`
+
+	// MetadataExtensions lists the user metadata of objects promoted to
+	// CloudEvent extensions
+	MetadataExtensions []string `envconfig:"METADATA_EXTENSIONS"`
+
+	// SubjectEscape percent-encodes the characters of subjects that are not
+	// URL-safe
+	SubjectEscape bool `envconfig:"SUBJECT_ESCAPE"`
+
+	// SubjectMaxLength bounds the length of subjects, if positive
+	SubjectMaxLength int `envconfig:"SUBJECT_MAX_LENGTH"`
+
+	// SubjectOverflow selects how subjects longer than SubjectMaxLength are
+	// shortened
+	SubjectOverflow string `envconfig:"SUBJECT_OVERFLOW"`
+
+	// DataProjection is the JSON array of the JSONPath expressions selecting
+	// the fields of the event data that are sent
+	DataProjection string `envconfig:"DATA_PROJECTION"`
+
+	// DataTemplate is the Go template rendering the event data from the
+	// projected record
+	DataTemplate string `envconfig:"DATA_TEMPLATE"`
+
+	// BucketSources is the JSON object of the event sources overriding the
+	// source of the events of the buckets it is keyed by
+	BucketSources string `envconfig:"BUCKET_SOURCES"`
+
+	// SourceScheme selects the scheme of the event sources, with
+	// SourceCluster the cluster of the "ceph" scheme
+	SourceScheme  string `envconfig:"SOURCE_SCHEME"`
+	SourceCluster string `envconfig:"SOURCE_CLUSTER"`
+}
+
+// rgwEnvConfig is the RGW a feature sends requests to, e.g. VERIFY_ENDPOINT
+// for the feature of prefix VERIFY, with the credentials of AccessKeyID and
+// SecretAccessKey signed for Region.
+type rgwEnvConfig struct {
+	Endpoint        string `envconfig:"ENDPOINT"`
+	Region          string `envconfig:"REGION"`
+	AccessKeyID     string `envconfig:"ACCESS_KEY_ID"`
+	SecretAccessKey string `envconfig:"SECRET_ACCESS_KEY"`
+	// NextAccessKeyID and NextSecretAccessKey are the credentials of the
+	// next key, if rotated.
+	NextAccessKeyID     string `envconfig:"NEXT_ACCESS_KEY_ID"`
+	NextSecretAccessKey string `envconfig:"NEXT_SECRET_ACCESS_KEY"`
+}
+
+// rgwFeatures returns the RGW settings of the features sending requests to
+// the RGW, by name.
+func (env *envConfig) rgwFeatures() map[string]*rgwEnvConfig {
+	return map[string]*rgwEnvConfig{
+		"verify":         &env.Verify,
+		"usage":          &env.Usage,
+		"expiry-preview": &env.ExpiryPreview,
+		"audit":          &env.Audit,
+	}
 }
 
 // cephReceiveAdapter converts incoming Ceph notifications to
 // CloudEvents and then sends them to the specified Sink
 type cephReceiveAdapter struct {
-	logger    *zap.SugaredLogger
-	client    cloudevents.Client
-	port      string
-	name      string
-	namespace string
+	logger        *zap.SugaredLogger
+	client        cloudevents.Client
+	port          string
+	bind          string
+	adminPort     string
+	adminToken    string
+	name          string
+	namespace     string
+	format        string
+	converter     ceph2ce.Converter
+	filters       []ceph2ce.Filter
+	tokens        map[string]string
+	signatures    *signatureVerifier
+	senders       *senderVerifier
+	verifier      *etagVerifier
+	usage         *usagePoller
+	expiryPreview *expiryPreviewer
+	audit         *auditor
+	retention     SpoolStore
+	// rotatedCredentials are the RGW credentials of the features having
+	// a next key.
+	rotatedCredentials map[string]*s3Credentials
+	ledger             *spoolLedger
+	rates              *bucketLimiter
+	eventTime          *eventTimeChecker
+	ageSLO             *ageSLO
+	traffic            []*trafficExpectation
+	lifecycle          *adapterLifecycle
+	tlsPolicy          *tlspolicy.Policy
+	listeners          []*listener
+	portFallback       bool
+	bound              *boundAddrs
+
+	maintenance    []maintenanceWindow
+	seen           DedupStore
+	skipDuplicates bool
+	routes         *routingTable
+	successStatus  int
+	emptyPolicy    string
+	idle           *idleTracker
+
+	checkpoints        *checkpointStore
+	checkpointInterval time.Duration
+	skipRedelivered    bool
+
+	// raw is set when no feature processes the records, for them to take
+	// the fast path of handleRaw.
+	raw bool
+
+	role             string
+	queue            *failedEventStore
+	dispatchInterval time.Duration
+
+	delivery    deliverySettings
+	failover    *sinkFailover
+	shedder     *loadShedder
+	specVersion string
+	contentMode string
+	sinkHeaders http.Header
+
+	maxEventSize int
+	sizePolicy   string
+
+	backpressure bool
+	limiter      *aimdLimiter
+
+	otlp         *otlp.Exporter
+	otlpInterval time.Duration
+
+	hooks []Hook
+
+	// reloaded is closed for the adapter to stop, letting the pending
+	// requests complete, when it is replaced by the adapter of the reloaded
+	// configuration file. The latter takes over its state and has resumed
+	// set.
+	reloaded chan struct{}
+	resumed  bool
 }
 
 // NewEnvConfig function reads env variables defined in envConfig structure and
@@ -60,107 +491,726 @@ func NewEnvConfig() adapter.EnvConfigAccessor {
 
 // NewAdapter returns the instance of cephReceiveAdapter that implements adapter.Adapter interface
 func NewAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
+	return newConfiguredAdapter(ctx, processed, ceClient, nil)
+}
+
+// NewAdapterWithHooks returns the constructor of the adapters calling the
+// given hooks, in order, for downstream builds to pass to adapter.Main.
+func NewAdapterWithHooks(hooks ...Hook) adapter.AdapterConstructor {
+	return func(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client) adapter.Adapter {
+		return newConfiguredAdapter(ctx, processed, ceClient, hooks)
+	}
+}
+
+func newAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client, hooks []Hook) adapter.Adapter {
 	logger := logging.FromContext(ctx)
 	env := processed.(*envConfig)
+	if env.Sink == "" {
+		logger.Warn("No sink to send the events to, set K_SINK or bind the adapter with a SinkBinding")
+	}
+
+	var limiter *aimdLimiter
+	if env.MaxConcurrency > 0 {
+		limiter = newAIMDLimiter(env.MaxConcurrency)
+	}
+
+	logger = logger.With(zap.String("namespace", env.Namespace), zap.String("name", env.Name))
+	if env.DebugLogSamplingInitial > 0 {
+		logger = sampleDebug(logger, env.DebugLogSamplingInitial, env.DebugLogSamplingThereafter)
+	}
 
-	return &cephReceiveAdapter{
-		logger:    logger,
-		client:    ceClient,
-		port:      env.Port,
-		name:      env.Name,
-		namespace: env.Namespace,
+	var exporter *otlp.Exporter
+	if env.OTLPMetricsEndpoint != "" {
+		headers, err := otlp.ParseHeaders(env.OTLPHeaders)
+		if err != nil {
+			logger.Errorw("Ignoring invalid OTLP headers", zap.Error(err))
+		}
+		exporter = otlp.NewExporter(env.OTLPMetricsEndpoint, headers)
+	}
+
+	rgwTransport, err := newRGWTransport(env.RGWCABundle, env.RGWPinnedKeys)
+	if err != nil {
+		// Rather than connecting to the RGW without authenticating it.
+		logger.Errorw("Invalid RGW TLS settings, not connecting to the RGW", zap.Error(err))
+		for _, rgw := range env.rgwFeatures() {
+			rgw.Endpoint = ""
+		}
+	}
+
+	if err := env.readExternalCredentials(); err != nil {
+		logger.Errorw("Failed to read the RGW credentials", zap.Error(err))
+	}
+
+	var verifier *etagVerifier
+	if env.Verify.Endpoint != "" {
+		var err error
+		if verifier, err = newETagVerifier(env.Verify.Endpoint, env.Verify.Region, env.Verify.AccessKeyID, env.Verify.SecretAccessKey); err != nil {
+			logger.Errorw("Invalid verify endpoint, not verifying objects", zap.Error(err))
+		} else {
+			verifier.client.Transport = rgwTransport
+		}
+	}
+
+	var usage *usagePoller
+	if env.Usage.Endpoint != "" {
+		var err error
+		if usage, err = newUsagePoller(env.Usage.Endpoint, env.Usage.Region, env.Usage.AccessKeyID, env.Usage.SecretAccessKey, env.UsageUsers, env.UsageInterval); err != nil {
+			logger.Errorw("Invalid usage endpoint, not reporting the usage", zap.Error(err))
+		} else {
+			usage.rgw.client.Transport = rgwTransport
+		}
+	}
+
+	var expiryPreview *expiryPreviewer
+	if env.ExpiryPreview.Endpoint != "" {
+		var err error
+		if expiryPreview, err = newExpiryPreviewer(env.ExpiryPreview.Endpoint, env.ExpiryPreview.Region, env.ExpiryPreview.AccessKeyID, env.ExpiryPreview.SecretAccessKey,
+			env.ExpiryPreviewBuckets, env.ExpiryPreviewDays, env.ExpiryPreviewInterval); err != nil {
+			logger.Errorw("Invalid expiry preview endpoint, not previewing expirations", zap.Error(err))
+		} else {
+			expiryPreview.rgw.client.Transport = rgwTransport
+		}
 	}
+
+	var audit *auditor
+	if env.Audit.Endpoint != "" {
+		var err error
+		if audit, err = newAuditor(env.Audit.Endpoint, env.Audit.Region, env.Audit.AccessKeyID, env.Audit.SecretAccessKey,
+			env.AuditBucket, env.AuditPrefix, env.AuditFlushInterval, env.AuditMaxBatch); err != nil {
+			logger.Errorw("Invalid audit endpoint, not mirroring events", zap.Error(err))
+		} else {
+			audit.rgw.client.Transport = rgwTransport
+		}
+	}
+
+	var retention SpoolStore
+	if env.RetentionDir != "" {
+		var err error
+		if retention, err = newSpoolStore(env.SpoolStore, StoreOptions{Dir: env.RetentionDir, MaxEvents: env.RetentionMaxEvents}); err != nil {
+			logger.Errorw("Failed to create the spool store, not retaining events", zap.String("store", env.SpoolStore), zap.Error(err))
+		}
+	}
+	var ledger *spoolLedger
+	if retention != nil {
+		var err error
+		if ledger, err = newSpoolLedger(env.RetentionDir); err != nil {
+			logger.Errorw("Failed to load the ledger of the spooled events, not tracking them", zap.Error(err))
+		}
+	}
+
+	rates, err := newBucketLimiter(env.RateLimits)
+	if err != nil {
+		logger.Errorw("Invalid rate limits, not limiting buckets", zap.Error(err))
+	}
+
+	var eventTime *eventTimeChecker
+	if env.EventTimePolicy != "" {
+		eventTime = &eventTimeChecker{
+			policy:    env.EventTimePolicy,
+			maxFuture: env.EventTimeMaxFuture,
+			maxAge:    env.EventTimeMaxAge,
+		}
+	}
+
+	traffic, err := parseTrafficExpectations(env.TrafficExpectations)
+	if err != nil {
+		logger.Errorw("Invalid traffic expectations, ignoring them", zap.Error(err))
+	}
+	maintenance, err := parseMaintenanceWindows(env.MaintenanceWindows)
+	if err != nil {
+		logger.Errorw("Invalid maintenance windows, ignoring them", zap.Error(err))
+	}
+	for _, w := range maintenance {
+		if w.action == v1alpha1.MaintenanceSpool && retention == nil {
+			logger.Error("Spooling events requires their retention, ignoring the maintenance windows")
+			maintenance = nil
+			break
+		}
+	}
+
+	var queue *failedEventStore
+	if env.Role == roleReceiver || env.Role == roleDispatcher {
+		if queue, err = newFailedEventStore(env.QueueDir, env.QueueMaxEvents); err != nil {
+			logger.Errorw("Failed to open the dispatch queue", zap.Error(err))
+		}
+	}
+
+	var seen DedupStore
+	if env.DuplicateWindow > 0 {
+		if seen, err = newDedupStore(env.DedupStore, StoreOptions{Dir: env.RetentionDir, Window: env.DuplicateWindow}); err != nil {
+			logger.Errorw("Failed to create the dedup store, not detecting duplicate notifications", zap.String("store", env.DedupStore), zap.Error(err))
+		}
+	}
+
+	var checkpoints *checkpointStore
+	if env.CheckpointDir != "" && env.Role != roleDispatcher {
+		if checkpoints, err = newCheckpointStore(env.CheckpointDir, env.CheckpointPrefixDepth); err != nil {
+			logger.Errorw("Failed to load the checkpoints, not checkpointing notifications", zap.Error(err))
+		}
+	}
+
+	var routes *routingTable
+	if env.RoutesFile != "" {
+		routes = newRoutingTable(logger, env.RoutesFile)
+	}
+
+	var sources map[string]string
+	if env.BucketSources != "" {
+		if err := json.Unmarshal([]byte(env.BucketSources), &sources); err != nil {
+			logger.Errorw("Invalid bucket sources, ignoring them", zap.Error(err))
+		}
+	}
+
+	var dataProjection *projection.Projection
+	if env.DataProjection != "" {
+		var exprs []string
+		if err := json.Unmarshal([]byte(env.DataProjection), &exprs); err != nil {
+			logger.Errorw("Invalid data projection, not projecting the event data", zap.Error(err))
+		} else if dataProjection, err = projection.Parse(exprs); err != nil {
+			logger.Errorw("Invalid data projection, not projecting the event data", zap.Error(err))
+		}
+	}
+
+	var dataTransform *transform.Transform
+	if env.DataTemplate != "" {
+		var err error
+		if dataTransform, err = transform.Parse(env.DataTemplate); err != nil {
+			logger.Errorw("Invalid data template, not transforming the event data", zap.Error(err))
+		}
+	}
+
+	ca := &cephReceiveAdapter{
+		logger:     logger,
+		client:     ceClient,
+		port:       env.Port,
+		bind:       env.BindAddress,
+		adminPort:  env.AdminPort,
+		adminToken: env.AdminToken,
+		name:       env.Name,
+		namespace:  env.Namespace,
+		format:     env.Format,
+		converter: ceph2ce.Converter{
+			Profile:    env.Profile,
+			TypePrefix: env.TypePrefix,
+			Payload:    env.Payload,
+			DecodeKeys: env.DecodeKeys,
+			CopySource: env.CopySource,
+			Sources:    sources,
+			DataSchema: env.DataSchema,
+			Projection: dataProjection,
+			Transform:  dataTransform,
+
+			SourceScheme: env.SourceScheme,
+			Cluster:      env.SourceCluster,
+
+			EscapeSubject:    env.SubjectEscape,
+			MaxSubjectLength: env.SubjectMaxLength,
+			SubjectOverflow:  env.SubjectOverflow,
+
+			MetadataExtensions: env.MetadataExtensions,
+		},
+		filters: makeFilters(v1alpha1.CephSourceFilter{
+			EventNames:     env.FilterEventNames,
+			Versioned:      env.FilterVersioned,
+			DeleteMarkers:  env.FilterDeleteMarkers,
+			Encryption:     env.FilterEncryption,
+			StorageClasses: env.FilterStorageClasses,
+		}),
+		tokens:        authTokens("AUTH_TOKEN_", env.AuthPaths, env.AuthHosts),
+		signatures:    newSignatureVerifier("SIGV4_SECRET_ACCESS_KEY_", env.SigV4AccessKeyIDs, env.SigV4MaxSkew, env.SigV4AllowUnsignedPayload),
+		senders:       newSenderVerifier(logger, env.SenderEventSources, env.SenderRegions, env.SenderAddresses),
+		verifier:      verifier,
+		usage:         usage,
+		expiryPreview: expiryPreview,
+		audit:         audit,
+		retention:     retention,
+		ledger:        ledger,
+		rates:         rates,
+		eventTime:     eventTime,
+		traffic:       traffic,
+
+		maintenance:    maintenance,
+		seen:           seen,
+		skipDuplicates: env.SkipDuplicates,
+		routes:         routes,
+		successStatus:  env.SuccessStatus,
+		emptyPolicy:    env.EmptyNotifications,
+
+		checkpoints:        checkpoints,
+		checkpointInterval: env.CheckpointInterval,
+		skipRedelivered:    env.CheckpointSkip,
+
+		role:             env.Role,
+		queue:            queue,
+		dispatchInterval: env.DispatchInterval,
+
+		delivery: deliverySettings{
+			retry:          env.DeliveryRetry,
+			backoffDelay:   env.DeliveryBackoffDelay,
+			deadLetterSink: env.DeadLetterSink,
+			failureSink:    env.FailureSink,
+		},
+		specVersion: env.SpecVersion,
+		contentMode: env.ContentMode,
+		sinkHeaders: sinkHeaders(env.SinkHeaders),
+
+		maxEventSize: env.MaxEventSize,
+		sizePolicy:   env.EventSizePolicy,
+
+		backpressure: env.Backpressure,
+		limiter:      limiter,
+
+		otlp:         exporter,
+		otlpInterval: time.Duration(env.OTLPExportInterval) * time.Millisecond,
+
+		hooks: hooks,
+
+		portFallback: env.PortFallback,
+		bound:        &boundAddrs{},
+
+		reloaded: make(chan struct{}),
+	}
+	if env.LifecycleEvents {
+		ca.lifecycle = newLifecycle(env.LifecycleSink)
+	}
+	if env.FailoverSinks != "" {
+		var sinks []string
+		if err := json.Unmarshal([]byte(env.FailoverSinks), &sinks); err != nil {
+			logger.Errorw("Invalid failover sinks, not failing over", zap.Error(err))
+		} else if len(sinks) > 0 {
+			ca.failover = newSinkFailover(sinks, env.FailoverCooldown)
+		}
+	}
+	if env.LoadSheddingThreshold > 0 {
+		classes, err := parsePriorityClasses(env.LoadSheddingPriorities)
+		if err != nil {
+			logger.Errorw("Invalid priority classes, not shedding notifications", zap.Error(err))
+		} else {
+			ca.shedder = newLoadShedder(env.LoadSheddingThreshold, env.LoadSheddingPeriod, classes)
+		}
+	}
+	if env.AgeSLOThreshold > 0 {
+		ca.ageSLO = newAgeSLO(env.AgeSLOThreshold, env.AgeSLOPeriod)
+	}
+	if env.IdleTimeout > 0 {
+		ca.idle = newIdleTracker(env.IdleTimeout)
+	}
+	if verifier != nil {
+		ca.rotateCredentials("verify", verifier.creds, env.Verify.NextAccessKeyID, env.Verify.NextSecretAccessKey)
+	}
+	if usage != nil {
+		ca.rotateCredentials("usage", usage.rgw.creds, env.Usage.NextAccessKeyID, env.Usage.NextSecretAccessKey)
+	}
+	if expiryPreview != nil {
+		ca.rotateCredentials("expiry-preview", expiryPreview.rgw.creds, env.ExpiryPreview.NextAccessKeyID, env.ExpiryPreview.NextSecretAccessKey)
+	}
+	if audit != nil {
+		ca.rotateCredentials("audit", audit.rgw.creds, env.Audit.NextAccessKeyID, env.Audit.NextSecretAccessKey)
+	}
+	ca.raw = ca.rawRecords()
+	if ca.tlsPolicy, err = tlspolicy.Parse(env.TLSMinVersion, env.TLSCipherSuites); err != nil {
+		// Rather than serving TLS out of policy.
+		logger.Errorw("Invalid TLS policy, ignoring the listeners", zap.Error(err))
+	} else if ca.listeners, err = ca.makeListeners(env.Listeners); err != nil {
+		logger.Errorw("Invalid listeners, ignoring them", zap.Error(err))
+	}
+	return ca
 }
 
 // Start the ceph bucket notifications to knative adapter
 func (ca *cephReceiveAdapter) Start(ctx context.Context) error {
-	return ca.start(ctx.Done())
+	if err := version.RecordBuildInfo(ctx); err != nil {
+		ca.logger.Warnw("Failed to record build info", zap.Error(err))
+	}
+	if err := registerLatencyViews(); err != nil {
+		ca.logger.Warnw("Failed to register the event latency metrics", zap.Error(err))
+	}
+	if err := registerMaintenanceViews(); err != nil {
+		ca.logger.Warnw("Failed to register the maintenance metrics", zap.Error(err))
+	}
+	if err := registerDeliveryViews(); err != nil {
+		ca.logger.Warnw("Failed to register the delivery metrics", zap.Error(err))
+	}
+	if err := registerFailoverViews(); err != nil {
+		ca.logger.Warnw("Failed to register the failover metrics", zap.Error(err))
+	}
+	if err := registerAuditViews(); err != nil {
+		ca.logger.Warnw("Failed to register the audit metrics", zap.Error(err))
+	}
+	if err := registerSheddingViews(); err != nil {
+		ca.logger.Warnw("Failed to register the load shedding metrics", zap.Error(err))
+	}
+	if err := registerDuplicatesViews(); err != nil {
+		ca.logger.Warnw("Failed to register the duplicate notification metrics", zap.Error(err))
+	}
+	if err := registerSizeViews(); err != nil {
+		ca.logger.Warnw("Failed to register the event size metrics", zap.Error(err))
+	}
+	if err := registerCheckpointViews(); err != nil {
+		ca.logger.Warnw("Failed to register the checkpoint metrics", zap.Error(err))
+	}
+	if err := registerEmptyViews(); err != nil {
+		ca.logger.Warnw("Failed to register the empty notification metrics", zap.Error(err))
+	}
+	if err := registerAgeViews(); err != nil {
+		ca.logger.Warnw("Failed to register the notification age metrics", zap.Error(err))
+	}
+	if err := registerTrafficViews(); err != nil {
+		ca.logger.Warnw("Failed to register the traffic metrics", zap.Error(err))
+	}
+	if err := registerLedgerViews(); err != nil {
+		ca.logger.Warnw("Failed to register the spooled events metrics", zap.Error(err))
+	}
+	if err := registerCredentialsViews(); err != nil {
+		ca.logger.Warnw("Failed to register the RGW credentials metrics", zap.Error(err))
+	}
+	for feature, creds := range ca.rotatedCredentials {
+		ca.recordCredentials(ctx, feature, creds.nextActive())
+	}
+
+	// The tasks of the adapter stop with it, e.g. once replaced on reload,
+	// while the requests being served keep the context of the process.
+	serveCtx := ctx
+	ctx, stopTasks := context.WithCancel(ctx)
+	defer stopTasks()
+	if ca.role != roleDispatcher {
+		for _, e := range ca.traffic {
+			go ca.watchTraffic(ctx, e)
+		}
+	}
+	if len(ca.maintenance) > 0 && ca.retention != nil {
+		go ca.redriveAfterMaintenance(ctx)
+	}
+	if ca.routes != nil {
+		go ca.routes.watch(ctx, routesReloadInterval)
+	}
+	if ca.checkpoints != nil {
+		go ca.checkpoints.persist(ctx, ca.checkpointInterval)
+	}
+	if ca.usage != nil && ca.role != roleDispatcher {
+		go ca.pollUsage(ctx)
+	}
+	if ca.expiryPreview != nil && ca.role != roleDispatcher {
+		go ca.previewExpiry(ctx)
+	}
+	if ca.audit != nil {
+		go ca.runAudit(ctx)
+		defer ca.flushAudit()
+	}
+	if ca.otlp != nil {
+		stop, err := otlp.Start(ca.otlp, ca.otlpInterval)
+		if err != nil {
+			ca.logger.Errorw("Failed to start the OTLP metrics export", zap.Error(err))
+		} else {
+			defer stop()
+		}
+	}
+	if ca.role == roleDispatcher {
+		if ca.queue == nil {
+			return errors.New("dispatching requires the dispatch queue")
+		}
+		return ca.dispatch(serveCtx, ca.dispatchInterval)
+	}
+	return ca.start(serveCtx)
 }
 
-func (ca *cephReceiveAdapter) start(stopCh <-chan struct{}) error {
-	http.HandleFunc("/", ca.postHandler)
-	go http.ListenAndServe(":"+ca.port, nil)
-	ca.logger.Info("Ceph to Knative adapter spawned HTTP server on port: " + ca.port)
-	<-stopCh
+// start serves notifications until ctx is done, or the adapter is replaced
+// on reload. The requests being served derive their context from ctx, so
+// that pending sends are aborted on shutdown, but complete on reload.
+func (ca *cephReceiveAdapter) start(ctx context.Context) error {
+	if ca.bound == nil {
+		ca.bound = &boundAddrs{}
+	}
+	// The notifications are handled until the server is shut down, rather
+	// than until ctx is done, for the pending requests to get a response.
+	receiveCtx, stopReceiving := context.WithCancel(context.Background())
+	defer stopReceiving()
+	notifications, err := ca.notificationHandler(receiveCtx)
+	if err != nil {
+		return fmt.Errorf("failed to create the notification receiver: %w", err)
+	}
 
+	mux := http.NewServeMux()
+	mux.Handle("/", notifications)
+	mux.HandleFunc(ceph2ce.SchemaPath, ca.schemaHandler)
+	admin := mux
+	if ca.adminPort != "" {
+		admin = http.NewServeMux()
+	}
+	admin.HandleFunc("/version", version.Handler)
+	admin.HandleFunc("/ready", ca.readyHandler)
+	admin.HandleFunc("/admin/failed-events", ca.redriveHandler)
+	admin.HandleFunc("/admin/spooled-events", ca.spoolReportHandler)
+	listeners := append([]*listener{{
+		name:    "default",
+		addr:    net.JoinHostPort(ca.bind, ca.port),
+		handler: mux,
+	}}, ca.listeners...)
+	if ca.adminPort != "" {
+		// The admin port is kept out of service meshes, as the metrics
+		// port, so it listens on all addresses.
+		listeners = append(listeners, &listener{
+			name:    "admin",
+			addr:    net.JoinHostPort("", ca.adminPort),
+			handler: admin,
+		})
+	}
+
+	// Listening first surfaces bind errors, e.g. of a port in use, rather
+	// than reporting a server that serves nothing.
+	servers := make([]*http.Server, 0, len(listeners))
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		handler := l.handler
+		if handler == nil {
+			// The additional listeners serve notifications only.
+			if handler, err = l.adapter.notificationHandler(receiveCtx); err != nil {
+				ca.shutdown(servers)
+				return fmt.Errorf("failed to create the notification receiver of listener %s: %w", l.name, err)
+			}
+		}
+		server := &http.Server{
+			Addr:        l.addr,
+			Handler:     handler,
+			BaseContext: func(net.Listener) context.Context { return ctx },
+		}
+		nl, err := ca.listen(l.name, server.Addr)
+		if err != nil {
+			ca.shutdown(servers)
+			return fmt.Errorf("failed to listen on %s: %w", server.Addr, err)
+		}
+		ca.bound.set(l.name, nl.Addr().String())
+		if l.tls != nil {
+			nl = tls.NewListener(nl, l.tls)
+		}
+		servers = append(servers, server)
+		go func() {
+			errCh <- server.Serve(nl)
+		}()
+		ca.logger.Infof("Ceph to Knative adapter spawned HTTP server of listener %s on %s", l.name, nl.Addr())
+	}
+	ca.bound.setReady(true)
+	defer ca.bound.setReady(false)
+	if !ca.resumed {
+		// Not sent again when the configuration file is reloaded.
+		ca.sendLifecycle(lifecycleStarted)
+	}
+
+	var idle <-chan struct{}
+	if ca.idle != nil {
+		idleCtx, stopIdle := context.WithCancel(ctx)
+		defer stopIdle()
+		idle = ca.idle.idle(idleCtx)
+	}
+	select {
+	case err := <-errCh:
+		ca.shutdown(servers)
+		return fmt.Errorf("HTTP server failed: %w", err)
+	case <-idle:
+		ca.logger.Infow("Shutting down the idle adapter", zap.Duration("idleTimeout", ca.idle.timeout))
+		ca.shutdown(servers)
+		ca.flushIdle()
+	case <-ca.reloaded:
+		ca.logger.Info("Handing over to the adapter of the reloaded configuration file")
+		ca.shutdown(servers)
+	case <-ctx.Done():
+		ca.shutdown(servers)
+	}
+	select {
+	case <-ca.reloaded:
+	default:
+		// Sent once the pending requests are served, for its counts to be
+		// final.
+		ca.sendLifecycle(lifecycleStopping)
+	}
+
+	if ca.checkpoints != nil {
+		// Persist the checkpoints of the requests served until shutdown.
+		if err := ca.checkpoints.flush(); err != nil {
+			ca.logger.Warnw("Failed to persist the checkpoints", zap.Error(err))
+		}
+	}
 	ca.logger.Info("Ceph to Knative adapter terminated")
 	return nil
 }
 
+// shutdown shuts the servers down, letting them finish serving the pending
+// requests.
+func (ca *cephReceiveAdapter) shutdown(servers []*http.Server) {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			ca.logger.Warnw("Failed to shut down the HTTP server gracefully", zap.String("addr", server.Addr), zap.Error(err))
+		}
+	}
+}
+
 // postMessage convert bucket notifications to knative events and sent them to knative
-func (ca *cephReceiveAdapter) postMessage(notification ceph.BucketNotification) error {
-	eventTime, err := time.Parse(time.RFC3339, notification.EventTime)
+func (ca *cephReceiveAdapter) postMessage(ctx context.Context, notification ceph.BucketNotification) error {
+	return ca.postRecord(ctx, ceph2ce.RawRecord{BucketNotification: notification})
+}
+
+// postRecord posts a record like postMessage, keeping the fields of its JSON
+// unknown to the model in the event data.
+func (ca *cephReceiveAdapter) postRecord(ctx context.Context, record ceph2ce.RawRecord) error {
+	notification := record.BucketNotification
+	ctx, err := ca.onReceive(ctx, notification)
 	if err != nil {
-		ca.logger.Infof("Failed to parse event timestamp, using local time. Error: %s", err.Error())
-		eventTime = time.Now()
+		return err
+	}
+	ca.recordNotification(ctx, notification)
+	ca.observeAge(ctx, notification, time.Now())
+	ca.countTraffic(notification)
+	if ca.alreadyDelivered(notification) {
+		logging.FromContext(ctx).Debug("Skipping notification already delivered")
+		return nil
+	}
+	if ca.redelivered(ctx, notification) {
+		logging.FromContext(ctx).Debug("Skipping notification older than its checkpoint")
+		return nil
+	}
+	if ca.verifier != nil {
+		notification = ca.detectDeleteMarker(ctx, notification)
+		if ca.converter.CopySource {
+			notification = ca.resolveCopySource(ctx, notification)
+		}
+		if len(ca.converter.MetadataExtensions) > 0 {
+			notification = ca.resolveMetadata(ctx, notification)
+		}
+		notification = ca.resolveObject(ctx, notification)
+	}
+	if !ceph2ce.Match(notification, ca.filters...) {
+		logging.FromContext(ctx).Debug("Dropping filtered out notification")
+		return nil
+	}
+	if ca.rates != nil && !ca.rates.allow(notification.S3.Bucket.Name) {
+		logging.FromContext(ctx).Debug("Rejecting rate limited notification")
+		return errRateLimited
+	}
+	if ca.shed(ctx, notification) {
+		logging.FromContext(ctx).Debug("Shedding notification of low priority")
+		return nil
 	}
 
-	event := cloudevents.NewEvent()
-	event.SetID(notification.ResponseElements.XAmzRequestID + notification.ResponseElements.XAmzID2)
-	event.SetSource(notification.EventSource + "." + notification.AwsRegion + "." + notification.S3.Bucket.Name)
-	event.SetType("com.amazonaws." + notification.EventName)
-	event.SetSubject(notification.S3.Object.Key)
-	event.SetTime(eventTime)
-	err = event.SetData(cloudevents.ApplicationJSON, notification)
+	converter := ca.converter
+	converter.Logger = logging.FromContext(ctx)
+	event, err := converter.ToCloudEventKeepUnknown(ceph2ce.RawRecord{BucketNotification: notification, JSON: record.JSON})
 	if err != nil {
-		return fmt.Errorf("failed to marshal event data: %w", err)
+		return err
 	}
-	ctx := context.Background()
-	metricTag := &adapter.MetricTag{
-		Namespace:     ca.namespace,
-		Name:          ca.name,
-		ResourceGroup: resourceGroup,
+	if id := requestIDFrom(ctx); id != "" {
+		event.SetExtension(requestIDExtension, id)
 	}
-	ctx = adapter.ContextWithMetricTag(ctx, metricTag)
-
-	return ca.sendCloudEvent(ctx, event)
+	if err := ca.onConvert(ctx, notification, &event); err != nil {
+		return err
+	}
+	if ca.eventTime != nil {
+		if err := ca.eventTime.check(&event, time.Now()); err != nil {
+			logging.FromContext(ctx).Infow("Rejecting notification", zap.Error(err))
+			return err
+		}
+	}
+	if ca.verifier != nil && strings.Contains(notification.EventName, "ObjectCreated") {
+		object := notification.S3.Object
+		verified, err := ca.verifier.verify(ctx, notification.S3.Bucket.Name, ca.converter.ObjectKey(notification), object.VersionID, object.ETag)
+		if err != nil {
+			logging.FromContext(ctx).Warnw("Failed to verify object, marking it unverified", zap.Error(err))
+		}
+		event.SetExtension(verifiedExtension, verified)
+	}
+	if err := ca.forward(withSpoolRecord(ctx, notification), event); err != nil {
+		return err
+	}
+	ca.checkpoint(notification)
+	ca.delivered(notification)
+	return nil
 }
 
-// sendCloudEvent sends a cloudevent for a ceph notification.
-func (ca *cephReceiveAdapter) sendCloudEvent(ctx context.Context, event cloudevents.Event) error {
-	source := event.Context.GetSource()
-	subject := event.Context.GetSubject()
-	ca.logger.Debugf("sending cloudevent id: %s, source: %s, subject: %s", event.ID(), source, subject)
+// forward delivers an event, spooling or dropping it during maintenance
+// windows, or queues it for the dispatcher, once bounded in size.
+func (ca *cephReceiveAdapter) forward(ctx context.Context, event cloudevents.Event) error {
+	limited, err := ca.limitSize(ctx, event)
+	if limited == nil {
+		return err
+	}
+	event = *limited
 
-	if result := ca.client.Send(ctx, event); !cloudevents.IsACK(result) {
-		ca.logger.Errorw("failed to send cloudevent", zap.Error(result), zap.String("source", source),
-			zap.String("subject", subject), zap.String("id", event.ID()))
-		return result
+	if action, active := activeMaintenance(ca.maintenance, time.Now()); active {
+		ca.recordSuppressed(ctx, action)
+		if action == v1alpha1.MaintenanceDrop {
+			logging.FromContext(ctx).Debug("Dropping event during maintenance")
+			return nil
+		}
+		logging.FromContext(ctx).Debug("Spooling event during maintenance")
+		return ca.spool(ctx, event, spoolReasonMaintenance)
+	}
+	if ca.role == roleReceiver && ca.queue != nil {
+		return ca.enqueue(ctx, event)
+	}
+	ctx = adapter.ContextWithMetricTag(ctx, ca.metricTag())
+
+	if err := ca.deliver(ctx, event); err != nil && !ca.retain(ctx, event, err) {
+		return err
 	}
-	ca.logger.Debugf("cloudevent sent id: %s, source: %s, subject: %s", event.ID(), source, subject)
 	return nil
 }
 
-// postHandler handles incoming bucket notifications from ceph
-func (ca *cephReceiveAdapter) postHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Allow", "POST")
-	if r.Method != "POST" {
-		ca.logger.Infof("%s method not allowed", r.Method)
-		http.Error(w, "405 Method Not Allowed", http.StatusBadRequest)
-		return
+// metricTag returns the metric tag attributing a record to the CephSource owning it.
+func (ca *cephReceiveAdapter) metricTag() *adapter.MetricTag {
+	return &adapter.MetricTag{
+		Namespace:     ca.namespace,
+		Name:          ca.name,
+		ResourceGroup: resourceGroup,
 	}
+}
 
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		ca.logger.Infof("Error reading message body: %s", err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+// sourceURI returns the source of the events the adapter makes itself, of
+// the CephSource owning it.
+func (ca *cephReceiveAdapter) sourceURI() string {
+	return fmt.Sprintf("/apis/v1/namespaces/%s/cephsources/%s", ca.namespace, ca.name)
+}
 
-	var notifications ceph.BucketNotifications
-	err = json.Unmarshal(body, &notifications)
+// sendCloudEvent sends a cloudevent for a ceph notification.
+func (ca *cephReceiveAdapter) sendCloudEvent(ctx context.Context, event cloudevents.Event) error {
+	logger := logging.FromContext(ctx).With(zap.String("id", event.ID()))
+	logger.Debug("Sending cloudevent")
 
-	if err != nil {
-		ca.logger.Infof("Failed to parse JSON: %s", err.Error())
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	if ca.routes != nil {
+		if sink := ca.routes.sink(event.Subject()); sink != "" {
+			ctx = cloudevents.ContextWithTarget(ctx, sink)
+		}
 	}
-	ca.logger.Debugf("%d events found in message", len(notifications.Records))
-	for _, notification := range notifications.Records {
-		ca.logger.Debugf("Received Ceph bucket notification: %+v", notification)
-		if err := ca.postMessage(notification); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+	if err := ca.onBeforeSend(ctx, &event); err != nil {
+		logger.Errorw("Failed to send cloudevent", zap.Error(err))
+		return err
+	}
+	ctx, event = ca.encode(ca.withSinkHeaders(ctx), event)
+	if ca.limiter != nil {
+		if err := ca.limiter.acquire(ctx); err != nil {
+			return err
 		}
 	}
+	// The span of the delivery, parent of the ones of the requests to the
+	// sinks, is the exemplar of its latencies.
+	sendCtx, span := trace.StartSpan(ctx, sendSpanName, trace.WithSpanKind(trace.SpanKindClient))
+	start := time.Now()
+	result := ca.send(sendCtx, event)
+	ca.observeSinkLatency(sendCtx, time.Since(start))
+	if !cloudevents.IsACK(result) {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnavailable, Message: result.Error()})
+	}
+	span.End()
+	if ca.limiter != nil {
+		ca.limiter.release(congested(result))
+	}
+	ca.lifecycle.eventSent(cloudevents.IsACK(result))
+	if !cloudevents.IsACK(result) {
+		logger.Errorw("Failed to send cloudevent", zap.Error(result))
+		return result
+	}
+	ca.recordLatency(sendCtx, event.Time(), time.Now())
+	ca.mirror(ctx, event)
+	logger.Debug("Cloudevent sent")
+	return nil
 }
@@ -21,8 +21,10 @@ import (
 	"context"
 	"encoding/json"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync"
 	"testing"
 	"time"
@@ -169,3 +171,76 @@ func newTestAdapter(t *testing.T, ce cloudevents.Client, sinkServerURL string) *
 
 	return NewAdapter(ctx, &env, ce).(*cephReceiveAdapter)
 }
+
+func TestStartListenFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	testCases := map[string]struct {
+		bind string
+		port string
+	}{
+		"port in use":            {port: port},
+		"invalid port":           {port: "99999"},
+		"non-local bind address": {bind: "192.0.2.1", port: "0"},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), bind: tc.bind, port: tc.port}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- ca.start(context.Background())
+			}()
+			select {
+			case err := <-errCh:
+				if err == nil {
+					t.Error("Start should fail")
+				}
+			case <-time.After(5 * time.Second):
+				t.Error("Start should fail rather than serve nothing")
+			}
+		})
+	}
+}
+
+func TestPostHandlerAbortsOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer sink.Close()
+	defer close(release)
+
+	client, err := cloudevents.NewClientHTTP(cloudevents.WithTarget(sink.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), client: client}
+
+	body, err := json.Marshal(ceph.BucketNotifications{Records: []ceph.BucketNotification{notification1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)).WithContext(ctx)
+
+	done := make(chan struct{})
+	w := httptest.NewRecorder()
+	go func() {
+		serveNotification(t, ca, w, req)
+		close(done)
+	}()
+	select {
+	case <-done:
+		if w.Code == http.StatusOK {
+			t.Error("Canceled request should fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Canceled request should abort the pending send")
+	}
+}
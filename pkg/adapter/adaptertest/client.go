@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package adaptertest provides an in-memory sink for testing the delivery of
+// CloudEvents, e.g. by the CephSource receive adapter or by programs built on
+// the ceph2ce package. Its Client records the events it is sent and can be
+// programmed to acknowledge or fail them selectively.
+package adaptertest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+// Responder returns the result of sending an event, nil acknowledging it.
+type Responder func(ctx context.Context, event cloudevents.Event) protocol.Result
+
+// ACK returns the result of a sink accepting an event.
+func ACK() protocol.Result {
+	return cehttp.NewResult(http.StatusOK, "%w", protocol.ResultACK)
+}
+
+// NACK returns the result of a sink rejecting an event with the given HTTP
+// status code, e.g. http.StatusServiceUnavailable.
+func NACK(statusCode int) protocol.Result {
+	return cehttp.NewResult(statusCode, "%w", protocol.ResultNACK)
+}
+
+// FailIf returns a Responder rejecting the events matching match with the
+// given HTTP status code, and acknowledging the others.
+func FailIf(match func(cloudevents.Event) bool, statusCode int) Responder {
+	return func(_ context.Context, event cloudevents.Event) protocol.Result {
+		if match(event) {
+			return NACK(statusCode)
+		}
+		return ACK()
+	}
+}
+
+// OfType returns a matcher of the events of the given types, for FailIf.
+func OfType(types ...string) func(cloudevents.Event) bool {
+	return func(event cloudevents.Event) bool {
+		for _, t := range types {
+			if event.Type() == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WithSubject returns a matcher of the events of the given subjects, e.g.
+// object keys, for FailIf.
+func WithSubject(subjects ...string) func(cloudevents.Event) bool {
+	return func(event cloudevents.Event) bool {
+		for _, s := range subjects {
+			if event.Subject() == s {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Client is an in-memory CloudEvents client. By default, it acknowledges all
+// the events it is sent, unless their context is done.
+type Client struct {
+	mu        sync.Mutex
+	respond   Responder
+	results   []protocol.Result
+	attempted []cloudevents.Event
+	sent      []cloudevents.Event
+}
+
+var _ cloudevents.Client = (*Client)(nil)
+
+// NewClient returns a Client acknowledging all the events.
+func NewClient() *Client {
+	return &Client{}
+}
+
+// RespondWith sets the Responder of the events sent once the enqueued
+// results are exhausted.
+func (c *Client) RespondWith(r Responder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.respond = r
+}
+
+// Enqueue sets the results of the next sends, in order, before falling back
+// to the Responder. A nil result acknowledges the event.
+func (c *Client) Enqueue(results ...protocol.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, results...)
+}
+
+// Send records an event and returns its programmed result.
+func (c *Client) Send(ctx context.Context, event cloudevents.Event) protocol.Result {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.attempted = append(c.attempted, event)
+	var result protocol.Result
+	switch {
+	case len(c.results) > 0:
+		result = c.results[0]
+		c.results = c.results[1:]
+	case c.respond != nil:
+		respond := c.respond
+		c.mu.Unlock()
+		result = respond(ctx, event)
+		c.mu.Lock()
+	}
+	if result == nil {
+		result = ACK()
+	}
+	if cloudevents.IsACK(result) {
+		c.sent = append(c.sent, event)
+	}
+	c.mu.Unlock()
+	return result
+}
+
+// Request sends an event like Send, without response event.
+func (c *Client) Request(ctx context.Context, event cloudevents.Event) (*cloudevents.Event, protocol.Result) {
+	return nil, c.Send(ctx, event)
+}
+
+// StartReceiver is not supported, the Client being a sender only.
+func (c *Client) StartReceiver(context.Context, interface{}) error {
+	return errors.New("adaptertest: receiving is not supported")
+}
+
+// Sent returns the events acknowledged, in order.
+func (c *Client) Sent() []cloudevents.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]cloudevents.Event(nil), c.sent...)
+}
+
+// Attempted returns all the events sent, including the failed ones, in
+// order.
+func (c *Client) Attempted() []cloudevents.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]cloudevents.Event(nil), c.attempted...)
+}
+
+// Reset forgets the events sent and the enqueued results.
+func (c *Client) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = nil
+	c.attempted = nil
+	c.sent = nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adaptertest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+func testEvent(typ, subject string) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID(subject)
+	event.SetSource("ceph:s3.tenantA.fishbucket")
+	event.SetType(typ)
+	event.SetSubject(subject)
+	return event
+}
+
+func TestClient(t *testing.T) {
+	c := NewClient()
+	c.Enqueue(NACK(http.StatusServiceUnavailable), nil)
+	c.RespondWith(FailIf(WithSubject("fish9.jpg"), http.StatusBadRequest))
+
+	testCases := []struct {
+		subject string
+		want    int
+	}{
+		{subject: "fish1.jpg", want: http.StatusServiceUnavailable},
+		{subject: "fish1.jpg", want: http.StatusOK},
+		{subject: "fish2.jpg", want: http.StatusOK},
+		{subject: "fish9.jpg", want: http.StatusBadRequest},
+	}
+	for i, tc := range testCases {
+		result := c.Send(context.Background(), testEvent("com.amazonaws.s3:ObjectCreated:Put", tc.subject))
+		var httpResult *cehttp.Result
+		if !cloudevents.ResultAs(result, &httpResult) || httpResult.StatusCode != tc.want {
+			t.Errorf("%d: unexpected result: got %v, want %d", i, result, tc.want)
+		}
+	}
+
+	if got := len(c.Attempted()); got != 4 {
+		t.Errorf("Unexpected number of attempted events: got %d, want 4", got)
+	}
+	if got := len(c.Sent()); got != 2 {
+		t.Errorf("Unexpected number of sent events: got %d, want 2", got)
+	}
+
+	c.Reset()
+	if len(c.Attempted()) != 0 || len(c.Sent()) != 0 {
+		t.Error("Reset should forget the events")
+	}
+}
+
+func TestClientCanceled(t *testing.T) {
+	c := NewClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := c.Send(ctx, testEvent("com.amazonaws.s3:ObjectCreated:Put", "fish1.jpg")); cloudevents.IsACK(result) {
+		t.Error("Canceled send should fail")
+	}
+	if len(c.Attempted()) != 0 {
+		t.Error("Canceled send should not be recorded")
+	}
+}
+
+func TestFailIfOfType(t *testing.T) {
+	respond := FailIf(OfType("com.amazonaws.s3:ObjectRemoved:Delete"), http.StatusInternalServerError)
+	if result := respond(context.Background(), testEvent("com.amazonaws.s3:ObjectRemoved:Delete", "fish1.jpg")); cloudevents.IsACK(result) {
+		t.Error("Event of failed type should be rejected")
+	}
+	if result := respond(context.Background(), testEvent("com.amazonaws.s3:ObjectCreated:Put", "fish1.jpg")); !cloudevents.IsACK(result) {
+		t.Error("Event of other type should be acknowledged")
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/metrics"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+const defaultAgeSLOPeriod = 5 * time.Minute
+
+var (
+	// notificationAgeM is the time from the occurrence of an event, as
+	// stamped by Ceph, to the receipt of its notification, and
+	// ageSLOBreachedM whether it exceeded the threshold of the objective
+	// for the period.
+	notificationAgeM = stats.Float64(
+		"notification_age",
+		"The time from the occurrence of an event to the receipt of its notification",
+		stats.UnitMilliseconds,
+	)
+	ageSLOBreachedM = stats.Int64(
+		"notification_age_slo_breached",
+		"Whether the age of the notifications received exceeds the objective",
+		stats.UnitDimensionless,
+	)
+
+	registerAgeOnce sync.Once
+)
+
+func registerAgeViews() error {
+	var err error
+	registerAgeOnce.Do(func() {
+		tagKeys := []tag.Key{namespaceKey, nameKey, resourceGroupKey}
+		err = view.Register(&view.View{
+			Description: notificationAgeM.Description(),
+			Measure:     notificationAgeM,
+			Aggregation: view.Distribution(10, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 300000, 900000, 3600000),
+			TagKeys:     tagKeys,
+		}, &view.View{
+			Description: ageSLOBreachedM.Description(),
+			Measure:     ageSLOBreachedM,
+			Aggregation: view.LastValue(),
+			TagKeys:     tagKeys,
+		})
+	})
+	return err
+}
+
+// ageSLO tracks whether the age of the notifications received exceeds a
+// threshold for a sustained period.
+type ageSLO struct {
+	threshold time.Duration
+	period    time.Duration
+
+	mu       sync.Mutex
+	breached bool
+	// since is when the notifications started exceeding the threshold
+	// while not breached, or being within it while breached.
+	since time.Time
+}
+
+func newAgeSLO(threshold, period time.Duration) *ageSLO {
+	if period <= 0 {
+		period = defaultAgeSLOPeriod
+	}
+	return &ageSLO{threshold: threshold, period: period}
+}
+
+// observe records the age of a notification received at now, returning
+// whether the objective is breached and whether that changed.
+func (s *ageSLO) observe(age time.Duration, now time.Time) (breached, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if (age > s.threshold) == s.breached {
+		s.since = time.Time{}
+		return s.breached, false
+	}
+	if s.since.IsZero() {
+		s.since = now
+	}
+	if now.Sub(s.since) < s.period {
+		return s.breached, false
+	}
+	s.breached, s.since = !s.breached, time.Time{}
+	return s.breached, true
+}
+
+// observeAge records the age of a notification received at now, against
+// the objective if any.
+func (ca *cephReceiveAdapter) observeAge(ctx context.Context, notification ceph.BucketNotification, now time.Time) {
+	if ca.ageSLO == nil {
+		return
+	}
+	eventTime, err := time.Parse(time.RFC3339, notification.EventTime)
+	if err != nil {
+		return
+	}
+	age := now.Sub(eventTime)
+	if age < 0 {
+		age = 0
+	}
+	ctx, err = tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, notificationAgeM.M(float64(age)/float64(time.Millisecond)))
+
+	breached, changed := ca.ageSLO.observe(age, now)
+	if !changed {
+		return
+	}
+	fields := []interface{}{zap.Duration("threshold", ca.ageSLO.threshold), zap.Duration("period", ca.ageSLO.period)}
+	if breached {
+		metrics.Record(ctx, ageSLOBreachedM.M(1))
+		ca.logger.Warnw("The notifications are received later than the age objective, check the backlog of the RGW and the network to the adapter", fields...)
+	} else {
+		metrics.Record(ctx, ageSLOBreachedM.M(0))
+		ca.logger.Infow("The notifications are received within the age objective again", fields...)
+	}
+}
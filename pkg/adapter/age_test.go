@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgeSLO(t *testing.T) {
+	slo := newAgeSLO(time.Minute, 5*time.Minute)
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		after    time.Duration
+		age      time.Duration
+		breached bool
+		changed  bool
+	}{
+		{after: 0, age: time.Second},
+		// A late notification alone does not trip the objective.
+		{after: time.Minute, age: 2 * time.Minute},
+		{after: 2 * time.Minute, age: time.Second},
+		{after: 3 * time.Minute, age: 2 * time.Minute},
+		{after: 7 * time.Minute, age: 2 * time.Minute},
+		// Late for the whole period.
+		{after: 8 * time.Minute, age: 2 * time.Minute, breached: true, changed: true},
+		{after: 9 * time.Minute, age: 2 * time.Minute, breached: true},
+		{after: 10 * time.Minute, age: time.Second, breached: true},
+		{after: 14 * time.Minute, age: time.Second, breached: true},
+		// Within the threshold for the whole period.
+		{after: 15 * time.Minute, age: time.Second, breached: false, changed: true},
+	}
+	for i, s := range steps {
+		breached, changed := slo.observe(s.age, start.Add(s.after))
+		if breached != s.breached || changed != s.changed {
+			t.Errorf("Step %d: got breached %v, changed %v, want %v, %v", i, breached, changed, s.breached, s.changed)
+		}
+	}
+}
+
+func TestAgeSLODefaultPeriod(t *testing.T) {
+	if got := newAgeSLO(time.Minute, 0).period; got != defaultAgeSLOPeriod {
+		t.Errorf("Unexpected period: got %v, want %v", got, defaultAgeSLOPeriod)
+	}
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	defaultAuditFlushInterval = 10 * time.Second
+	defaultAuditMaxBatch      = 1000
+
+	// auditPendingBatches bounds the events pending while the audit bucket
+	// is unavailable, in batches, past which the oldest are dropped.
+	auditPendingBatches = 10
+
+	// auditDayFormat is the format of the day of the audit objects.
+	auditDayFormat = "2006-01-02"
+)
+
+var (
+	// auditDroppedM counts the events dropped without being mirrored to
+	// the audit bucket, which failed to be written for too long.
+	auditDroppedM = stats.Int64(
+		"audit_dropped_count",
+		"Number of events dropped without being mirrored to the audit bucket",
+		stats.UnitDimensionless,
+	)
+
+	registerAuditOnce sync.Once
+)
+
+func registerAuditViews() error {
+	var err error
+	registerAuditOnce.Do(func() {
+		err = view.Register(&view.View{
+			Description: auditDroppedM.Description(),
+			Measure:     auditDroppedM,
+			Aggregation: view.Sum(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey},
+		})
+	})
+	return err
+}
+
+// auditRecord is an event pending to be mirrored to the object of its day.
+type auditRecord struct {
+	day  string
+	line []byte
+}
+
+// auditor mirrors the events emitted to daily NDJSON objects of an audit
+// bucket, in batches. The objects are appendable objects of the RGW, one per
+// adapter instance so that their writes do not conflict.
+type auditor struct {
+	rgw      *rgwClient
+	bucket   string
+	prefix   string
+	instance string
+	interval time.Duration
+	maxBatch int
+
+	// full is signaled once a batch is pending.
+	full chan struct{}
+
+	mu      sync.Mutex
+	pending []auditRecord
+
+	// flushMu serializes the writes, and guards positions, the lengths of
+	// the objects being appended to.
+	flushMu   sync.Mutex
+	positions map[string]int64
+}
+
+func newAuditor(endpoint, region, accessKeyID, secretAccessKey, bucket, prefix string, interval time.Duration, maxBatch int) (*auditor, error) {
+	rgw, err := newRGWClient(endpoint, region, accessKeyID, secretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = defaultAuditFlushInterval
+	}
+	if maxBatch <= 0 {
+		maxBatch = defaultAuditMaxBatch
+	}
+	instance, _ := os.Hostname()
+	return &auditor{
+		rgw:       rgw,
+		bucket:    bucket,
+		prefix:    prefix,
+		instance:  instance,
+		interval:  interval,
+		maxBatch:  maxBatch,
+		full:      make(chan struct{}, 1),
+		positions: make(map[string]int64),
+	}, nil
+}
+
+// key returns the key of the object of a day.
+func (a *auditor) key(day string) string {
+	return a.prefix + day + "/" + a.instance + ".ndjson"
+}
+
+// record queues an event emitted at now to be mirrored, returning the number
+// of pending events dropped to make room for it.
+func (a *auditor) record(event cloudevents.Event, now time.Time) (int, error) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending = append(a.pending, auditRecord{
+		day:  now.UTC().Format(auditDayFormat),
+		line: append(line, '\n'),
+	})
+	dropped := len(a.pending) - a.maxBatch*auditPendingBatches
+	if dropped > 0 {
+		a.pending = append([]auditRecord(nil), a.pending[dropped:]...)
+	} else {
+		dropped = 0
+	}
+	if len(a.pending) >= a.maxBatch {
+		select {
+		case a.full <- struct{}{}:
+		default:
+		}
+	}
+	return dropped, nil
+}
+
+// flush writes the pending events, keeping those failing to be written
+// pending.
+func (a *auditor) flush(ctx context.Context) error {
+	a.flushMu.Lock()
+	defer a.flushMu.Unlock()
+
+	a.mu.Lock()
+	records := a.pending
+	a.pending = nil
+	a.mu.Unlock()
+
+	for len(records) > 0 {
+		day := records[0].day
+		var body []byte
+		n := 0
+		for ; n < len(records) && records[n].day == day; n++ {
+			body = append(body, records[n].line...)
+		}
+		if err := a.append(ctx, a.key(day), body); err != nil {
+			a.mu.Lock()
+			a.pending = append(records, a.pending...)
+			a.mu.Unlock()
+			return err
+		}
+		records = records[n:]
+	}
+	return nil
+}
+
+// append appends body to the object of the given key, created if missing.
+func (a *auditor) append(ctx context.Context, key string, body []byte) error {
+	path := "/" + a.bucket + "/" + key
+	position, ok := a.positions[key]
+	if !ok {
+		resp, _, err := a.rgw.do(ctx, http.MethodHead, path, nil, nil)
+		switch {
+		case err != nil:
+			return err
+		case resp.StatusCode == http.StatusNotFound:
+		case resp.StatusCode/100 != 2:
+			return fmt.Errorf("HEAD %s responded %s", path, resp.Status)
+		default:
+			position = resp.ContentLength
+		}
+	}
+
+	resp, _, err := a.rgw.do(ctx, http.MethodPut, path, url.Values{
+		"append":   {""},
+		"position": {strconv.FormatInt(position, 10)},
+	}, body)
+	if err != nil {
+		delete(a.positions, key)
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		// The length of the object is read again on the next write, e.g.
+		// once it was appended to without response.
+		delete(a.positions, key)
+		return fmt.Errorf("PUT %s responded %s", path, resp.Status)
+	}
+	// Only the object of the current day is appended to from now on.
+	for k := range a.positions {
+		if k != key {
+			delete(a.positions, k)
+		}
+	}
+	a.positions[key] = position + int64(len(body))
+	return nil
+}
+
+// mirror queues an event emitted to be mirrored to the audit bucket, if
+// any.
+func (ca *cephReceiveAdapter) mirror(ctx context.Context, event cloudevents.Event) {
+	if ca.audit == nil {
+		return
+	}
+	dropped, err := ca.audit.record(event, time.Now())
+	if err != nil {
+		logging.FromContext(ctx).Errorw("Failed to mirror event to the audit bucket", zap.String("id", event.ID()), zap.Error(err))
+		return
+	}
+	if dropped > 0 {
+		ca.recordAuditDropped(ctx, dropped)
+	}
+}
+
+// runAudit writes the events emitted to the audit bucket every flush
+// interval, or once a batch is pending, until ctx is done.
+func (ca *cephReceiveAdapter) runAudit(ctx context.Context) {
+	a := ca.audit
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-a.full:
+		}
+		if err := a.flush(ctx); err != nil {
+			ca.logger.Warnw("Failed to mirror events to the audit bucket, retrying on the next flush", zap.Error(err))
+		}
+	}
+}
+
+// flushAudit writes the events still pending before the adapter stops.
+func (ca *cephReceiveAdapter) flushAudit() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := ca.audit.flush(ctx); err != nil {
+		ca.logger.Errorw("Failed to mirror the pending events to the audit bucket before shutting down", zap.Error(err))
+	}
+}
+
+// recordAuditDropped records events dropped without being mirrored.
+func (ca *cephReceiveAdapter) recordAuditDropped(ctx context.Context, n int) {
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, auditDroppedM.M(int64(n)))
+}
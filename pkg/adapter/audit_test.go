@@ -0,0 +1,210 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// appendableStore is an in-memory S3 API of appendable objects.
+type appendableStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	fail    bool
+}
+
+func (s *appendableStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	object, ok := s.objects[r.URL.Path]
+	switch r.Method {
+	case http.MethodHead:
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+	case http.MethodPut:
+		if _, ok := r.URL.Query()["append"]; !ok {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		if r.URL.Query().Get("position") != strconv.Itoa(len(object)) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		s.objects[r.URL.Path] = append(object, body...)
+	}
+}
+
+func (s *appendableStore) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+func (s *appendableStore) lines(t *testing.T, path string) []cloudevents.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var events []cloudevents.Event
+	scanner := bufio.NewScanner(bytes.NewReader(s.objects[path]))
+	for scanner.Scan() {
+		var event cloudevents.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func auditLine(t *testing.T, id string) []byte {
+	line, err := json.Marshal(testEvent(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(line, '\n')
+}
+
+func newTestAuditor(t *testing.T, store *appendableStore, maxBatch int) *auditor {
+	srv := httptest.NewServer(store)
+	t.Cleanup(srv.Close)
+	a, err := newAuditor(srv.URL, "", "access", "secret", "audit", "events/", time.Hour, maxBatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.instance = "adapter-0"
+	return a
+}
+
+func TestAuditor(t *testing.T) {
+	store := &appendableStore{objects: map[string][]byte{}}
+	a := newTestAuditor(t, store, 10)
+	day1 := time.Date(2021, 6, 1, 23, 59, 0, 0, time.UTC)
+	day2 := day1.Add(time.Minute)
+	ctx := context.Background()
+
+	for _, id := range []string{"1", "2"} {
+		if _, err := a.record(testEvent(id), day1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	a.record(testEvent("3"), day1)
+	a.record(testEvent("4"), day2)
+	if err := a.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.lines(t, "/audit/events/2021-06-01/adapter-0.ndjson"); len(got) != 3 || got[0].ID() != "1" || got[2].ID() != "3" {
+		t.Errorf("Unexpected events of the first day: %v", got)
+	}
+	if got := store.lines(t, "/audit/events/2021-06-02/adapter-0.ndjson"); len(got) != 1 || got[0].ID() != "4" {
+		t.Errorf("Unexpected events of the second day: %v", got)
+	}
+}
+
+func TestAuditorRetry(t *testing.T) {
+	store := &appendableStore{objects: map[string][]byte{
+		// Written by a previous instance of the same name.
+		"/audit/events/2021-06-01/adapter-0.ndjson": auditLine(t, "0"),
+	}}
+	a := newTestAuditor(t, store, 10)
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	store.setFail(true)
+	a.record(testEvent("1"), now)
+	if err := a.flush(ctx); err == nil {
+		t.Fatal("Flush should have failed")
+	}
+	store.setFail(false)
+	a.record(testEvent("2"), now)
+	if err := a.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Appended to concurrently.
+	key := "/audit/events/2021-06-01/adapter-0.ndjson"
+	store.mu.Lock()
+	store.objects[key] = append(store.objects[key], auditLine(t, "other")...)
+	store.mu.Unlock()
+	a.record(testEvent("3"), now)
+	if err := a.flush(ctx); err == nil {
+		t.Fatal("Flush should have conflicted")
+	}
+	if err := a.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for _, event := range store.lines(t, key) {
+		ids = append(ids, event.ID())
+	}
+	if want := []string{"0", "1", "2", "other", "3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Unexpected events: got %q, want %q", ids, want)
+	}
+}
+
+func TestAuditorBounded(t *testing.T) {
+	store := &appendableStore{objects: map[string][]byte{}}
+	a := newTestAuditor(t, store, 1)
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	dropped := 0
+	for i := 0; i < auditPendingBatches+2; i++ {
+		n, err := a.record(testEvent(strconv.Itoa(i)), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dropped += n
+	}
+	if dropped != 2 {
+		t.Errorf("Unexpected number of dropped events: got %d, want 2", dropped)
+	}
+	select {
+	case <-a.full:
+	default:
+		t.Error("A full batch should have been signaled")
+	}
+	if err := a.flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.lines(t, "/audit/events/2021-06-01/adapter-0.ndjson"); len(got) != auditPendingBatches || got[0].ID() != "2" {
+		t.Errorf("The oldest events should have been dropped: %v", got)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// authTokens returns the expected token per request host and path, reading
+// the token of the i-th host and path from the <prefix><i> environment
+// variable, e.g. AUTH_TOKEN_0. Either the host or the path of an entry may
+// be empty.
+func authTokens(prefix string, paths, hosts []string) map[string]string {
+	n := len(paths)
+	if len(hosts) > n {
+		n = len(hosts)
+	}
+	if n == 0 {
+		return nil
+	}
+	tokens := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		var host, path string
+		if i < len(hosts) {
+			host = strings.ToLower(hosts[i])
+		}
+		if i < len(paths) {
+			path = paths[i]
+		}
+		// Hosts hold no "/" and paths start with one, so that the key is
+		// unambiguous.
+		tokens[host+path] = os.Getenv(fmt.Sprintf("%s%d", prefix, i))
+	}
+	return tokens
+}
+
+// authorized returns true if the request carries the token expected on its
+// host and path, on its host, or on its path, the first configured, either
+// as bearer token or as basic auth password. All requests are authorized
+// when no tokens are configured.
+func (ca *cephReceiveAdapter) authorized(r *http.Request) bool {
+	if ca.tokens == nil {
+		return true
+	}
+	host := requestHost(r)
+	for _, key := range []string{host + r.URL.Path, host, r.URL.Path} {
+		if expected, ok := ca.tokens[key]; ok && key != "" {
+			token := requestToken(r)
+			return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+		}
+	}
+	return false
+}
+
+// requestHost returns the lowercased host a request was sent to, without
+// port.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// authorizedAdmin returns true if the request carries the admin token, if
+// configured, or else any of the configured tokens. When no tokens are
+// configured, requests are only authorized on the admin port, which is kept
+// private, and denied on the port of the notifications.
+func (ca *cephReceiveAdapter) authorizedAdmin(r *http.Request) bool {
+	if ca.adminToken != "" {
+		return subtle.ConstantTimeCompare([]byte(requestToken(r)), []byte(ca.adminToken)) == 1
+	}
+	if ca.tokens == nil {
+		return ca.adminPort != ""
+	}
+	token := requestToken(r)
+	authorized := false
+	for _, expected := range ca.tokens {
+		if expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
+
+// requestToken returns the token of a request, either its bearer token or its
+// basic auth password.
+func requestToken(r *http.Request) string {
+	if _, password, ok := r.BasicAuth(); ok {
+		return password
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestAuthorized(t *testing.T) {
+	tokens := map[string]string{
+		"/cluster-a": "token-a",
+		"/cluster-b": "token-b",
+	}
+	testCases := map[string]struct {
+		tokens   map[string]string
+		path     string
+		bearer   string
+		password string
+		want     bool
+	}{
+		"no tokens configured": {
+			path: "/",
+			want: true,
+		},
+		"bearer token": {
+			tokens: tokens,
+			path:   "/cluster-a",
+			bearer: "token-a",
+			want:   true,
+		},
+		"basic auth password": {
+			tokens:   tokens,
+			path:     "/cluster-b",
+			password: "token-b",
+			want:     true,
+		},
+		"token of another path": {
+			tokens: tokens,
+			path:   "/cluster-a",
+			bearer: "token-b",
+		},
+		"missing token": {
+			tokens: tokens,
+			path:   "/cluster-a",
+		},
+		"unknown path": {
+			tokens: tokens,
+			path:   "/cluster-c",
+			bearer: "token-a",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tc.path, nil)
+			if tc.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.bearer)
+			}
+			if tc.password != "" {
+				r.SetBasicAuth("rgw", tc.password)
+			}
+			ca := &cephReceiveAdapter{tokens: tc.tokens}
+			if got := ca.authorized(r); got != tc.want {
+				t.Errorf("Unexpected authorization: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizedByHost(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		os.Setenv(fmt.Sprintf("AUTH_TOKEN_%d", i), fmt.Sprintf("token-%d", i))
+		defer os.Unsetenv(fmt.Sprintf("AUTH_TOKEN_%d", i))
+	}
+	ca := &cephReceiveAdapter{tokens: authTokens("AUTH_TOKEN_",
+		[]string{"", "/cluster-b", "/cluster-c"},
+		[]string{"Cluster-A.example.com", "", "c.example.com"},
+	)}
+
+	testCases := map[string]struct {
+		host   string
+		path   string
+		bearer string
+		want   bool
+	}{
+		"host token": {
+			host:   "cluster-a.example.com:8443",
+			path:   "/mangled/path",
+			bearer: "token-0",
+			want:   true,
+		},
+		"path token on any host": {
+			host:   "adapter.example.com",
+			path:   "/cluster-b",
+			bearer: "token-1",
+			want:   true,
+		},
+		"host and path token": {
+			host:   "c.example.com",
+			path:   "/cluster-c",
+			bearer: "token-2",
+			want:   true,
+		},
+		"host and path token on another path": {
+			host:   "c.example.com",
+			path:   "/cluster-b",
+			bearer: "token-1",
+			want:   true,
+		},
+		"host and path token on another host": {
+			host:   "adapter.example.com",
+			path:   "/cluster-c",
+			bearer: "token-2",
+		},
+		"token of another host": {
+			host:   "cluster-a.example.com",
+			path:   "/",
+			bearer: "token-1",
+		},
+		"unknown host": {
+			host:   "cluster-d.example.com",
+			path:   "/",
+			bearer: "token-0",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tc.path, nil)
+			r.Host = tc.host
+			r.Header.Set("Authorization", "Bearer "+tc.bearer)
+			if got := ca.authorized(r); got != tc.want {
+				t.Errorf("Unexpected authorization: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizedAdmin(t *testing.T) {
+	tokens := map[string]string{"/cluster-a": "token-a"}
+	testCases := map[string]struct {
+		tokens     map[string]string
+		adminToken string
+		adminPort  string
+		bearer     string
+		want       bool
+	}{
+		"no tokens on the notification port": {},
+		"no tokens on the admin port": {
+			adminPort: "9998",
+			want:      true,
+		},
+		"admin token": {
+			tokens:     tokens,
+			adminToken: "admin",
+			bearer:     "admin",
+			want:       true,
+		},
+		"auth token instead of the admin token": {
+			tokens:     tokens,
+			adminToken: "admin",
+			adminPort:  "9998",
+			bearer:     "token-a",
+		},
+		"auth token": {
+			tokens: tokens,
+			bearer: "token-a",
+			want:   true,
+		},
+		"missing auth token": {
+			tokens:    tokens,
+			adminPort: "9998",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/admin/failed-events", nil)
+			if tc.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tc.bearer)
+			}
+			ca := &cephReceiveAdapter{tokens: tc.tokens, adminToken: tc.adminToken, adminPort: tc.adminPort}
+			if got := ca.authorizedAdmin(r); got != tc.want {
+				t.Errorf("Unexpected authorization: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// checkpointFile is the file of the checkpoints in their directory.
+	checkpointFile = "checkpoints.json"
+
+	// checkpointWindow is the number of notifications remembered per shard,
+	// those of the lowest sequencers being forgotten beyond it.
+	checkpointWindow = 1000
+)
+
+var (
+	// redeliveredM counts the notifications already processed according to
+	// the checkpoint of their shard, per bucket.
+	redeliveredM = stats.Int64(
+		"redelivered_notification_count",
+		"Number of notifications already processed according to the checkpoint of their bucket and key prefix",
+		stats.UnitDimensionless,
+	)
+
+	registerCheckpointOnce sync.Once
+)
+
+func registerCheckpointViews() error {
+	var err error
+	registerCheckpointOnce.Do(func() {
+		err = view.Register(&view.View{
+			Description: redeliveredM.Description(),
+			Measure:     redeliveredM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey, bucketKey},
+		})
+	})
+	return err
+}
+
+// checkpointStore keeps the keys and sequencers of the last notifications
+// processed per bucket and key prefix shard, persisted in a directory to
+// survive restarts of the adapter. As RGW may push the notifications out of
+// order, e.g. retrying a failed one after later ones succeeded, the
+// notifications processed are kept rather than the highest sequencer.
+type checkpointStore struct {
+	path  string
+	depth int
+
+	mu sync.Mutex
+	// processed holds the notifications processed per shard, ordered by
+	// sequencer, up to checkpointWindow.
+	processed map[string][]checkpointEntry
+	dirty     bool
+}
+
+// checkpointEntry identifies a processed notification in its shard.
+type checkpointEntry struct {
+	Key       string `json:"key"`
+	Sequencer string `json:"sequencer"`
+}
+
+// less orders entries by sequencer, then key.
+func (e checkpointEntry) less(o checkpointEntry) bool {
+	if c := compareSequencers(e.Sequencer, o.Sequencer); c != 0 {
+		return c < 0
+	}
+	return e.Key < o.Key
+}
+
+// newCheckpointStore returns the store of the checkpoints of dir, loading
+// those persisted, with shards of the first depth segments of the keys.
+func newCheckpointStore(dir string, depth int) (*checkpointStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &checkpointStore{
+		path:      filepath.Join(dir, checkpointFile),
+		depth:     depth,
+		processed: make(map[string][]checkpointEntry),
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.processed); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// shard returns the checkpoint key of a notification: its bucket and the
+// first depth "/" delimited prefixes of its object key.
+func (s *checkpointStore) shard(notification ceph.BucketNotification) string {
+	prefix := ""
+	if s.depth > 0 {
+		// All but the last segment end with the delimiter.
+		segments := strings.SplitAfterN(notification.S3.Object.Key, "/", s.depth+1)
+		prefix = strings.Join(segments[:len(segments)-1], "")
+	}
+	return notification.S3.Bucket.Name + "/" + prefix
+}
+
+// search returns the index of a notification among the processed ones of
+// its shard, or where to insert it, and whether it was found.
+func search(entries []checkpointEntry, e checkpointEntry) (int, bool) {
+	i := sort.Search(len(entries), func(i int) bool { return !entries[i].less(e) })
+	return i, i < len(entries) && !e.less(entries[i])
+}
+
+// redelivered reports whether a notification is among the processed ones
+// of its shard. Notifications without sequencer never are.
+func (s *checkpointStore) redelivered(notification ceph.BucketNotification) bool {
+	sequencer := notification.S3.Object.Sequencer
+	if sequencer == "" {
+		return false
+	}
+	e := checkpointEntry{Key: notification.S3.Object.Key, Sequencer: sequencer}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := search(s.processed[s.shard(notification)], e)
+	return ok
+}
+
+// advance adds a processed notification to the checkpoint of its shard,
+// forgetting the lowest sequencers beyond checkpointWindow.
+func (s *checkpointStore) advance(notification ceph.BucketNotification) {
+	sequencer := notification.S3.Object.Sequencer
+	if sequencer == "" {
+		return
+	}
+	e := checkpointEntry{Key: notification.S3.Object.Key, Sequencer: sequencer}
+	shard := s.shard(notification)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := s.processed[shard]
+	i, ok := search(entries, e)
+	if ok {
+		return
+	}
+	entries = append(entries, checkpointEntry{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = e
+	if len(entries) > checkpointWindow {
+		entries = append([]checkpointEntry(nil), entries[len(entries)-checkpointWindow:]...)
+	}
+	s.processed[shard] = entries
+	s.dirty = true
+}
+
+// flush persists the checkpoints advanced since the last flush.
+func (s *checkpointStore) flush() error {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(s.processed)
+	s.dirty = false
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(filepath.Dir(s.path), "."+checkpointFile)
+	if err := ioutil.WriteFile(tmp, data, 0o600); err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		// Persist them again on the next flush.
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// persist flushes the checkpoints every interval until ctx is done.
+func (s *checkpointStore) persist(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.flush(); err != nil {
+				logging.FromContext(ctx).Warnw("Failed to persist the checkpoints", zap.Error(err))
+			}
+		}
+	}
+}
+
+// compareSequencers compares the hexadecimal sequencers of two object
+// changes, the shorter one being padded with leading zeros as per S3.
+func compareSequencers(a, b string) int {
+	a, b = strings.ToUpper(a), strings.ToUpper(b)
+	if len(a) < len(b) {
+		a = strings.Repeat("0", len(b)-len(a)) + a
+	} else if len(b) < len(a) {
+		b = strings.Repeat("0", len(a)-len(b)) + b
+	}
+	return strings.Compare(a, b)
+}
+
+// redelivered counts a notification already processed according to the
+// checkpoint of its shard, reporting whether it is to be skipped.
+func (ca *cephReceiveAdapter) redelivered(ctx context.Context, notification ceph.BucketNotification) bool {
+	if ca.checkpoints == nil || !ca.checkpoints.redelivered(notification) {
+		return false
+	}
+	if ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(bucketKey, notification.S3.Bucket.Name)); err == nil {
+		metrics.Record(ctx, redeliveredM.M(1))
+	}
+	return ca.skipRedelivered
+}
+
+// checkpoint adds a processed notification to its checkpoint, if enabled.
+func (ca *cephReceiveAdapter) checkpoint(notification ceph.BucketNotification) {
+	if ca.checkpoints != nil {
+		ca.checkpoints.advance(notification)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+)
+
+func TestCompareSequencers(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want int
+	}{
+		{"F7E6D75DC742D108", "F7E6D75DC742D108", 0},
+		{"F7E6D75DC742D108", "F7E6D75DC742D109", -1},
+		{"f7e6d75dc742d10a", "F7E6D75DC742D109", 1},
+		// Padded with leading zeros.
+		{"0F", "F", 0},
+		{"FF", "100", -1},
+	}
+	for _, tc := range testCases {
+		if got := compareSequencers(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareSequencers(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestCheckpointShard(t *testing.T) {
+	testCases := []struct {
+		depth int
+		key   string
+		want  string
+	}{
+		{0, "images/2021/cat.jpg", "fish/"},
+		{1, "images/2021/cat.jpg", "fish/images/"},
+		{2, "images/2021/cat.jpg", "fish/images/2021/"},
+		{2, "images/cat.jpg", "fish/images/"},
+		{1, "cat.jpg", "fish/"},
+	}
+	for _, tc := range testCases {
+		s := &checkpointStore{depth: tc.depth}
+		if got := s.shard(pushedNotification("fish", tc.key, "1", "req-1")); got != tc.want {
+			t.Errorf("shard of %q with depth %d = %q, want %q", tc.key, tc.depth, got, tc.want)
+		}
+	}
+}
+
+func TestCheckpointsSurviveRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := newCheckpointStore(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.advance(pushedNotification("fish", "images/a.jpg", "0A", "req-1"))
+	s.advance(pushedNotification("fish", "images/b.jpg", "05", "req-2"))
+	s.advance(pushedNotification("fish", "docs/a.txt", "03", "req-3"))
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := newCheckpointStore(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		bucket, key, sequencer string
+		want                   bool
+	}{
+		{"fish", "images/a.jpg", "0A", true},
+		{"fish", "images/b.jpg", "5", true},
+		{"fish", "images/c.jpg", "09", false},
+		{"fish", "images/a.jpg", "0B", false},
+		{"fish", "images/a.jpg", "09", false},
+		{"fish", "docs/b.txt", "04", false},
+		{"chips", "images/a.jpg", "01", false},
+		{"fish", "images/a.jpg", "", false},
+	}
+	for _, tc := range testCases {
+		n := pushedNotification(tc.bucket, tc.key, tc.sequencer, "req")
+		if got := restarted.redelivered(n); got != tc.want {
+			t.Errorf("redelivered(%s/%s@%s) = %t, want %t", tc.bucket, tc.key, tc.sequencer, got, tc.want)
+		}
+	}
+}
+
+func TestCheckpointOutOfOrder(t *testing.T) {
+	s, err := newCheckpointStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := pushedNotification("fish", "a.jpg", "0A", "req-1")
+	succeeded := pushedNotification("fish", "b.jpg", "0B", "req-2")
+	s.advance(succeeded)
+
+	// The retry of the failed notification, older than the one that
+	// succeeded, is not a redelivery.
+	if s.redelivered(failed) {
+		t.Error("Notification that failed should not be redelivered")
+	}
+	s.advance(failed)
+	if !s.redelivered(failed) || !s.redelivered(succeeded) {
+		t.Error("Processed notifications should be redelivered")
+	}
+}
+
+func TestCheckpointWindow(t *testing.T) {
+	s, err := newCheckpointStore(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := checkpointWindow + 10; i > 0; i-- {
+		s.advance(pushedNotification("fish", "a.jpg", fmt.Sprintf("%X", i), "req"))
+	}
+	if n := len(s.processed["fish/"]); n != checkpointWindow {
+		t.Errorf("Unexpected number of notifications kept: got %d, want %d", n, checkpointWindow)
+	}
+	if s.redelivered(pushedNotification("fish", "a.jpg", "A", "req")) {
+		t.Error("Lowest sequencers should be forgotten beyond the window")
+	}
+	if !s.redelivered(pushedNotification("fish", "a.jpg", fmt.Sprintf("%X", checkpointWindow+10), "req")) {
+		t.Error("Highest sequencer should be kept")
+	}
+}
+
+func TestSkipRedelivered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client := adaptertest.NewClient()
+	newAdapter := func() *cephReceiveAdapter {
+		checkpoints, err := newCheckpointStore(dir, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &cephReceiveAdapter{
+			logger:          zap.NewNop().Sugar(),
+			client:          client,
+			checkpoints:     checkpoints,
+			skipRedelivered: true,
+		}
+	}
+
+	ca := newAdapter()
+	if err := ca.postMessage(context.Background(), notification1); err != nil {
+		t.Fatal(err)
+	}
+	if err := ca.checkpoints.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pushed again to the restarted adapter.
+	ca = newAdapter()
+	if err := ca.postMessage(context.Background(), notification1); err != nil {
+		t.Fatal(err)
+	}
+	if sent := client.Sent(); len(sent) != 1 {
+		t.Errorf("Unexpected number of events sent: got %d, want 1", len(sent))
+	}
+}
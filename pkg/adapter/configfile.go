@@ -0,0 +1,414 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// configFileEnv is the env var of the path of the configuration file.
+	configFileEnv = "CONFIG_FILE"
+
+	// configFileReloadInterval is how often the configuration file is
+	// checked for changes, ConfigMap volumes being updated in place.
+	configFileReloadInterval = 10 * time.Second
+)
+
+var (
+	// settingTypes maps the env vars of the settings of the adapter to the
+	// types of their fields, the ones a configuration file can set.
+	settingTypes = envSettings(reflect.TypeOf(envConfig{}))
+
+	durationType = reflect.TypeOf(time.Duration(0))
+
+	// loadedConfigFile is the configuration file loaded by LoadConfigFile.
+	loadedConfigFile *configFile
+)
+
+// envSettings returns the env vars of the fields of a struct, including the
+// ones of its struct fields prefixed with theirs, e.g. VERIFY_ENDPOINT, but
+// the ones of the embedded structs.
+func envSettings(t reflect.Type) map[string]reflect.Type {
+	settings := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("envconfig")
+		switch {
+		case name == "" || f.Anonymous:
+		case f.Type.Kind() == reflect.Struct:
+			for inner, t := range envSettings(f.Type) {
+				settings[name+"_"+inner] = t
+			}
+		default:
+			settings[name] = f.Type
+		}
+	}
+	return settings
+}
+
+// parseConfigFile parses a YAML or JSON configuration file into the env
+// vars of its settings. Its keys are either the env vars themselves, e.g.
+// "FILTER_EVENT_NAMES", or sections and settings in camel case whose path
+// names the env var, e.g. "eventNames" of the section "filter". Lists of
+// scalars are joined with commas for the list settings, and lists and
+// objects JSON-encoded for the others, e.g. "maintenanceWindows".
+func parseConfigFile(data []byte) (map[string]string, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	settings := make(map[string]string)
+	var errs []string
+	flattenSettings("", doc, settings, &errs)
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("invalid configuration file: %s", strings.Join(errs, "; "))
+	}
+	return settings, nil
+}
+
+func flattenSettings(prefix string, section map[string]interface{}, settings map[string]string, errs *[]string) {
+	for key, value := range section {
+		if value == nil {
+			continue
+		}
+		name := prefix + envName(key)
+		t, ok := settingTypes[name]
+		if !ok {
+			if sub, isSection := value.(map[string]interface{}); isSection {
+				flattenSettings(name+"_", sub, settings, errs)
+			} else {
+				*errs = append(*errs, fmt.Sprintf("unknown setting %s", name))
+			}
+			continue
+		}
+		if _, dup := settings[name]; dup {
+			*errs = append(*errs, fmt.Sprintf("duplicate setting %s", name))
+			continue
+		}
+		s, err := settingValue(t, value)
+		if err != nil {
+			*errs = append(*errs, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		settings[name] = s
+	}
+}
+
+// envName returns the env var of a key, converting camel case to upper
+// snake case, e.g. "accessKeyId" to "ACCESS_KEY_ID".
+func envName(key string) string {
+	if strings.ToUpper(key) == key {
+		return key
+	}
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) &&
+			(!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// settingValue returns the env var value of a setting of type t.
+func settingValue(t reflect.Type, value interface{}) (string, error) {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case bool:
+		s = strconv.FormatBool(v)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			b, err := json.Marshal(v)
+			return string(b), err
+		}
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case []interface{}, map[string]interface{}:
+				return "", fmt.Errorf("not a list of scalars")
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		s = strings.Join(items, ",")
+	default:
+		if t.Kind() != reflect.String {
+			return "", fmt.Errorf("unexpected object")
+		}
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+	return s, checkSetting(t, s)
+}
+
+// checkSetting checks that envconfig can parse value into a field of type t.
+func checkSetting(t reflect.Type, value string) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var err error
+	switch {
+	case t == durationType:
+		_, err = time.ParseDuration(value)
+	case t.Kind() == reflect.Bool:
+		_, err = strconv.ParseBool(value)
+	case t.Kind() == reflect.Int:
+		_, err = strconv.ParseInt(value, 0, 0)
+	}
+	return err
+}
+
+// configFile holds the settings of a configuration file applied as env
+// vars, the ones of the environment of the process taking precedence.
+type configFile struct {
+	path string
+
+	mu   sync.Mutex
+	data []byte
+	// applied are the settings of the file set as env vars.
+	applied map[string]string
+}
+
+// LoadConfigFile sets the settings of the configuration file of
+// CONFIG_FILE, if any, as env vars, unless set already. It is to be called
+// before the env vars are processed, e.g. by adapter.Main, and its
+// adapters reload the file on change.
+func LoadConfigFile() error {
+	path := os.Getenv(configFileEnv)
+	if path == "" {
+		return nil
+	}
+	f := &configFile{path: path}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	settings, err := parseConfigFile(data)
+	if err != nil {
+		return err
+	}
+	f.apply(data, settings)
+	loadedConfigFile = f
+	return nil
+}
+
+// apply sets the env vars of settings, and unsets the ones the file no
+// longer sets.
+func (f *configFile) apply(data []byte, settings map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	applied := make(map[string]string, len(settings))
+	for name := range f.applied {
+		if _, ok := settings[name]; !ok {
+			os.Unsetenv(name)
+		}
+	}
+	for name, value := range settings {
+		if _, ours := f.applied[name]; !ours {
+			if _, set := os.LookupEnv(name); set {
+				continue
+			}
+		}
+		os.Setenv(name, value)
+		applied[name] = value
+	}
+	f.data, f.applied = data, applied
+}
+
+// reload applies the settings of the file again if it changed, returning
+// the configuration of the adapter they result in, nil if the file did not
+// change or is invalid, in which case the current settings are kept.
+func (f *configFile) reload(logger *zap.SugaredLogger) *envConfig {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		logger.Errorw("Failed to read the configuration file", zap.Error(err))
+		return nil
+	}
+	f.mu.Lock()
+	previous, applied := f.data, f.applied
+	f.mu.Unlock()
+	if bytes.Equal(data, previous) {
+		return nil
+	}
+	settings, err := parseConfigFile(data)
+	if err != nil {
+		logger.Errorw("Invalid configuration file, keeping the current configuration", zap.Error(err))
+		return nil
+	}
+	f.apply(data, settings)
+	env := &envConfig{}
+	if err := envconfig.Process("", env); err != nil {
+		logger.Errorw("Invalid configuration file, keeping the current configuration", zap.Error(err))
+		f.apply(previous, applied)
+		return nil
+	}
+	return env
+}
+
+// reloadingAdapter runs the adapter of the settings of a configuration
+// file, replacing it with a new one whenever the file changes.
+type reloadingAdapter struct {
+	ctx      context.Context
+	file     *configFile
+	ceClient cloudevents.Client
+	hooks    []Hook
+	interval time.Duration
+	env      *envConfig
+	current  *cephReceiveAdapter
+}
+
+// newConfiguredAdapter returns the adapter of processed, reloaded on change
+// of the configuration file loaded by LoadConfigFile, if any.
+func newConfiguredAdapter(ctx context.Context, processed adapter.EnvConfigAccessor, ceClient cloudevents.Client, hooks []Hook) adapter.Adapter {
+	ca := newAdapter(ctx, processed, ceClient, hooks)
+	if loadedConfigFile == nil {
+		return ca
+	}
+	return &reloadingAdapter{
+		ctx:      ctx,
+		file:     loadedConfigFile,
+		ceClient: ceClient,
+		hooks:    hooks,
+		interval: configFileReloadInterval,
+		env:      processed.(*envConfig),
+		current:  ca.(*cephReceiveAdapter),
+	}
+}
+
+// Start runs the current adapter until ctx is done, replacing it with the
+// adapter of the new settings whenever the configuration file changes. The
+// new adapter takes over the state of the current one, which stops once
+// its pending requests complete. The sink and its transport, set up once by
+// adapter.Main, are kept.
+func (r *reloadingAdapter) Start(ctx context.Context) error {
+	logger := logging.FromContext(r.ctx)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		done := make(chan error, 1)
+		go func(ca *cephReceiveAdapter) {
+			done <- ca.Start(ctx)
+		}(r.current)
+
+		var env *envConfig
+		for env == nil {
+			select {
+			case err := <-done:
+				return err
+			case <-ticker.C:
+				env = r.file.reload(logger)
+			}
+		}
+		logger.Info("Reloaded the configuration file, replacing the adapter")
+		next := newAdapter(r.ctx, env, r.ceClient, r.hooks).(*cephReceiveAdapter)
+		close(r.current.reloaded)
+		if err := <-done; err != nil {
+			return err
+		}
+		next.takeOver(r.current, r.env, env)
+		r.current, r.env = next, env
+	}
+}
+
+// takeOver hands the state of the adapter replaced on reload over to ca, the
+// adapter of the new settings: the stores of the spooled and queued events,
+// of the notifications seen and of the checkpoints, as long as they are
+// enabled, and the lifecycle counts. The changes of the settings of the
+// stores apply on restart. The state of the rate limits, concurrency
+// limiter, failover, load shedding, age SLO, idle timeout, traffic
+// expectations and RGW keys is kept unless their settings changed.
+func (ca *cephReceiveAdapter) takeOver(old *cephReceiveAdapter, oldEnv, env *envConfig) {
+	if ca.retention != nil && old.retention != nil {
+		ca.retention, ca.ledger = old.retention, old.ledger
+	}
+	if ca.seen != nil && old.seen != nil {
+		ca.seen = old.seen
+	}
+	if ca.queue != nil && old.queue != nil {
+		ca.queue = old.queue
+	}
+	if ca.checkpoints != nil && old.checkpoints != nil {
+		ca.checkpoints = old.checkpoints
+	}
+	if ca.lifecycle != nil && old.lifecycle != nil {
+		old.lifecycle.sink = ca.lifecycle.sink
+		ca.lifecycle = old.lifecycle
+	}
+	if reflect.DeepEqual(env.RateLimits, oldEnv.RateLimits) {
+		ca.rates = old.rates
+	}
+	if env.MaxConcurrency == oldEnv.MaxConcurrency {
+		ca.limiter = old.limiter
+	}
+	if env.FailoverSinks == oldEnv.FailoverSinks && env.FailoverCooldown == oldEnv.FailoverCooldown {
+		ca.failover = old.failover
+	}
+	if env.LoadSheddingThreshold == oldEnv.LoadSheddingThreshold && env.LoadSheddingPeriod == oldEnv.LoadSheddingPeriod &&
+		env.LoadSheddingPriorities == oldEnv.LoadSheddingPriorities {
+		ca.shedder = old.shedder
+	}
+	if env.AgeSLOThreshold == oldEnv.AgeSLOThreshold && env.AgeSLOPeriod == oldEnv.AgeSLOPeriod {
+		ca.ageSLO = old.ageSLO
+	}
+	if env.IdleTimeout == oldEnv.IdleTimeout {
+		ca.idle = old.idle
+	}
+	if env.TrafficExpectations == oldEnv.TrafficExpectations {
+		ca.traffic = old.traffic
+	}
+	for feature, creds := range ca.rotatedCredentials {
+		if prev, ok := old.rotatedCredentials[feature]; ok {
+			creds.resume(prev)
+		}
+	}
+	ca.resumed = true
+
+	// The additional listeners serve notifications with the state of ca.
+	for _, l := range ca.listeners {
+		a := *ca
+		a.listeners = nil
+		a.tokens, a.filters = l.adapter.tokens, l.adapter.filters
+		a.raw = a.rawRecords()
+		l.adapter = &a
+	}
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func TestEnvName(t *testing.T) {
+	for key, want := range map[string]string{
+		"eventNames":         "EVENT_NAMES",
+		"accessKeyId":        "ACCESS_KEY_ID",
+		"accessKeyID":        "ACCESS_KEY_ID",
+		"retry":              "RETRY",
+		"FILTER_EVENT_NAMES": "FILTER_EVENT_NAMES",
+	} {
+		if got := envName(key); got != want {
+			t.Errorf("envName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	settings, err := parseConfigFile([]byte(`
+filter:
+  eventNames:
+    - s3:ObjectCreated:*
+    - s3:ObjectRemoved:*
+  versioned: true
+delivery:
+  retry: 5
+  backoffDelay: 2s
+audit:
+  endpoint: https://rgw.rook-ceph.svc
+  maxBatch: null
+MAINTENANCE_WINDOWS:
+  - schedule: "0 2 * * 6"
+    duration: 2h
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"FILTER_EVENT_NAMES":     "s3:ObjectCreated:*,s3:ObjectRemoved:*",
+		"FILTER_VERSIONED":       "true",
+		"DELIVERY_RETRY":         "5",
+		"DELIVERY_BACKOFF_DELAY": "2s",
+		"AUDIT_ENDPOINT":         "https://rgw.rook-ceph.svc",
+		"MAINTENANCE_WINDOWS":    `[{"duration":"2h","schedule":"0 2 * * 6"}]`,
+	}
+	if !reflect.DeepEqual(settings, want) {
+		t.Errorf("Unexpected settings: %v", settings)
+	}
+
+	for name, data := range map[string]string{
+		"unknown setting":  "filter:\n  colors: [red]\n",
+		"invalid int":      "delivery:\n  retry: many\n",
+		"invalid duration": "delivery:\n  backoffDelay: 2\n",
+		"invalid bool":     "FILTER_VERSIONED: maybe\n",
+		"nested list":      "filter:\n  eventNames: [[a]]\n",
+		"duplicate":        "FILTER_VERSIONED: true\nfilter:\n  versioned: false\n",
+		"not an object":    "- FILTER_VERSIONED\n",
+	} {
+		if _, err := parseConfigFile([]byte(data)); err == nil {
+			t.Errorf("Expected the %s to be rejected", name)
+		}
+	}
+}
+
+func TestConfigFileReload(t *testing.T) {
+	os.Setenv("DELIVERY_RETRY", "1")
+	defer os.Unsetenv("DELIVERY_RETRY")
+	defer os.Unsetenv("FILTER_VERSIONED")
+	defer os.Unsetenv("SUCCESS_STATUS")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(data string) {
+		t.Helper()
+		if err := ioutil.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger := zap.NewNop().Sugar()
+	f := &configFile{path: path}
+
+	write("delivery:\n  retry: 5\nfilter:\n  versioned: true\n")
+	env := f.reload(logger)
+	if env == nil {
+		t.Fatal("The configuration file was not loaded")
+	}
+	// The env vars of the process take precedence.
+	if env.DeliveryRetry != 1 || env.FilterVersioned == nil || !*env.FilterVersioned {
+		t.Errorf("Unexpected settings: retry %d, versioned %v", env.DeliveryRetry, env.FilterVersioned)
+	}
+	if env := f.reload(logger); env != nil {
+		t.Error("Unexpected reload of an unchanged file")
+	}
+
+	write("SUCCESS_STATUS: 202\n")
+	if env = f.reload(logger); env == nil {
+		t.Fatal("The configuration file was not reloaded")
+	}
+	// The settings removed from the file are unset.
+	if env.SuccessStatus != 202 || env.FilterVersioned != nil {
+		t.Errorf("Unexpected settings: success status %d, versioned %v", env.SuccessStatus, env.FilterVersioned)
+	}
+
+	write("SUCCESS_STATUS: none\n")
+	if env := f.reload(logger); env != nil {
+		t.Error("Unexpected reload of an invalid file")
+	}
+	if got := os.Getenv("SUCCESS_STATUS"); got != "202" {
+		t.Errorf("Unexpected settings kept: %q", got)
+	}
+}
+
+func TestReloadingAdapter(t *testing.T) {
+	port := freePort(t)
+	for name, value := range map[string]string{
+		"PORT":             port,
+		"BIND_ADDRESS":     "127.0.0.1",
+		"RETENTION_DIR":    t.TempDir(),
+		"SPOOL_STORE":      StoreMemory,
+		"LIFECYCLE_EVENTS": "true",
+	} {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+	defer os.Unsetenv("SUCCESS_STATUS")
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(data string) {
+		t.Helper()
+		if err := ioutil.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger := zap.NewNop().Sugar()
+	f := &configFile{path: path}
+	write("successStatus: 200\n")
+	env := f.reload(logger)
+	if env == nil {
+		t.Fatal("The configuration file was not loaded")
+	}
+
+	// The sink holds the events until released.
+	client := adaptertest.NewClient()
+	inFlight := make(chan struct{}, 1)
+	release := make(chan struct{})
+	client.RespondWith(func(ctx context.Context, event cloudevents.Event) protocol.Result {
+		if event.Type() == v1alpha1.LifecycleEventType {
+			return nil
+		}
+		select {
+		case inFlight <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	ctx, cancel := context.WithCancel(logging.WithLogger(context.Background(), logger))
+	defer cancel()
+	ca := newAdapter(ctx, env, client, nil).(*cephReceiveAdapter)
+	if err := ca.retention.Store(testEvent("spooled")); err != nil {
+		t.Fatal(err)
+	}
+	r := &reloadingAdapter{
+		ctx:      ctx,
+		file:     f,
+		ceClient: client,
+		interval: 10 * time.Millisecond,
+		env:      env,
+		current:  ca,
+	}
+	done := make(chan error, 1)
+	go func() { done <- r.Start(ctx) }()
+
+	body, err := json.Marshal(ceph.BucketNotifications{Records: []ceph.BucketNotification{notification1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := func() (int, error) {
+		resp, err := http.Post("http://127.0.0.1:"+port+"/", "application/json", bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+	// postUntil posts notifications until the adapter responds with want.
+	postUntil := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			code, err := post()
+			if err == nil && code == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Unexpected response: got %d, %v, want %d", code, err, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// The first notification waits for the sink while the adapter is
+	// replaced.
+	close(release)
+	postUntil(http.StatusOK)
+	release = make(chan struct{})
+	pending := make(chan int, 1)
+	go func() {
+		code, err := post()
+		if err != nil {
+			t.Error("Failed to post the pending notification:", err)
+		}
+		pending <- code
+	}()
+	select {
+	case <-inFlight:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The event was not sent to the sink")
+	}
+
+	write("successStatus: 202\n")
+	select {
+	case <-ca.reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The adapter was not replaced")
+	}
+	close(release)
+	if code := <-pending; code != http.StatusOK {
+		t.Errorf("The pending send should complete on reload, got status %d", code)
+	}
+	postUntil(http.StatusAccepted)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if r.current == ca {
+		t.Fatal("The adapter was not replaced")
+	}
+	if r.current.retention != ca.retention {
+		t.Error("The spool store should be taken over")
+	}
+	if n, _ := r.current.retention.Count(); n != 1 {
+		t.Errorf("Unexpected spooled events after reload: got %d, want 1", n)
+	}
+	var phases []string
+	for _, event := range client.Sent() {
+		if event.Type() == v1alpha1.LifecycleEventType {
+			var data lifecycleEvent
+			if err := event.DataAs(&data); err != nil {
+				t.Fatal(err)
+			}
+			phases = append(phases, data.Phase)
+		}
+	}
+	if want := []string{lifecycleStarted, lifecycleStopping}; !reflect.DeepEqual(phases, want) {
+		t.Errorf("Unexpected lifecycle events: got %v, want %v", phases, want)
+	}
+}
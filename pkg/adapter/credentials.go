@@ -0,0 +1,259 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/metrics"
+
+	"knative.dev/eventing-ceph/pkg/sigv4"
+)
+
+const (
+	// accessKeyIDFile and secretAccessKeyFile are the files of the
+	// credentials directory holding the RGW credentials of all the
+	// features, or of one when prefixed with its name and "-". The ones of
+	// the next keys are further prefixed with nextCredentialsPrefix.
+	accessKeyIDFile       = "access-key-id"
+	secretAccessKeyFile   = "secret-access-key"
+	nextCredentialsPrefix = "next-"
+)
+
+var (
+	// credentialsNextActiveM records whether the next key of the
+	// credentials of a feature is the active one.
+	credentialsNextActiveM = stats.Int64(
+		"rgw_credentials_next_active",
+		"Whether the next key of the RGW credentials of a feature is the active one",
+		stats.UnitDimensionless,
+	)
+
+	featureKey = tag.MustNewKey("feature")
+
+	registerCredentialsOnce sync.Once
+)
+
+func registerCredentialsViews() error {
+	var err error
+	registerCredentialsOnce.Do(func() {
+		err = view.Register(&view.View{
+			Description: credentialsNextActiveM.Description(),
+			Measure:     credentialsNextActiveM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey, featureKey},
+		})
+	})
+	return err
+}
+
+// readCredentials reads the RGW credentials of a feature from dir, its own
+// if any, else the ones of all the features.
+func readCredentials(dir, feature string) (accessKeyID, secretAccessKey string, err error) {
+	accessKeyID, secretAccessKey, err = readKey(dir, feature+"-", "")
+	if err == nil && accessKeyID == "" {
+		err = fmt.Errorf("no credentials of %s in %s", feature, dir)
+	}
+	return accessKeyID, secretAccessKey, err
+}
+
+// readNextCredentials reads the RGW credentials of the next key of a feature
+// from dir like readCredentials, empty if there are none.
+func readNextCredentials(dir, feature string) (accessKeyID, secretAccessKey string, err error) {
+	return readKey(dir, nextCredentialsPrefix+feature+"-", nextCredentialsPrefix)
+}
+
+// readKey reads the credentials of the first of the given file prefixes
+// having some, empty if none has.
+func readKey(dir string, prefixes ...string) (accessKeyID, secretAccessKey string, err error) {
+	for _, prefix := range prefixes {
+		id, err := ioutil.ReadFile(filepath.Join(dir, prefix+accessKeyIDFile))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", "", err
+		}
+		secret, err := ioutil.ReadFile(filepath.Join(dir, prefix+secretAccessKeyFile))
+		if err != nil {
+			return "", "", err
+		}
+		return strings.TrimSpace(string(id)), strings.TrimSpace(string(secret)), nil
+	}
+	return "", "", nil
+}
+
+// readExternalCredentials sets the RGW credentials of the enabled features,
+// and of their next keys if any, from the files of CredentialsDir, written
+// by an external secret manager.
+func (env *envConfig) readExternalCredentials() error {
+	if env.CredentialsDir == "" {
+		return nil
+	}
+	for name, rgw := range env.rgwFeatures() {
+		if rgw.Endpoint == "" {
+			continue
+		}
+		var err error
+		if rgw.AccessKeyID, rgw.SecretAccessKey, err = readCredentials(env.CredentialsDir, name); err != nil {
+			return err
+		}
+		if rgw.NextAccessKeyID, rgw.NextSecretAccessKey, err = readNextCredentials(env.CredentialsDir, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// s3Credentials are the RGW credentials requests are signed with: a current
+// key and, while it is rotated, a next one. Requests are signed with the
+// active key, the current one at first, and again with the other one when
+// the RGW rejects them, which becomes the active key if the RGW accepts it.
+type s3Credentials struct {
+	// keys are the current key and the next one, if any. They are not
+	// modified once requests are sent.
+	keys []sigv4.Credentials
+
+	mu     sync.Mutex
+	active int
+
+	// switched, if set, is called with whether the next key is active
+	// whenever the active key changes.
+	switched func(next bool)
+}
+
+func newS3Credentials(accessKeyID, secretAccessKey string) *s3Credentials {
+	return &s3Credentials{
+		keys: []sigv4.Credentials{{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: secretAccessKey,
+		}},
+	}
+}
+
+// setNext sets the next key.
+func (c *s3Credentials) setNext(accessKeyID, secretAccessKey string) {
+	c.keys = append(c.keys[:1], sigv4.Credentials{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+	})
+}
+
+// nextActive reports whether the next key is the active one.
+func (c *s3Credentials) nextActive() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.active == 1
+}
+
+// resume makes the key active in prev the active one, if both have the same
+// keys, e.g. for the adapter of a reloaded configuration file to keep using
+// the key the RGW accepted.
+func (c *s3Credentials) resume(prev *s3Credentials) {
+	if !reflect.DeepEqual(c.keys, prev.keys) {
+		return
+	}
+	prev.mu.Lock()
+	active := prev.active
+	prev.mu.Unlock()
+	c.mu.Lock()
+	c.active = active
+	c.mu.Unlock()
+}
+
+// send sends a request signed by sign with the active key, and again with
+// the other key if the RGW rejects it, returning the last response of the
+// RGW, or the error of the last request.
+func (c *s3Credentials) send(sign func(creds sigv4.Credentials) (*http.Response, error)) (*http.Response, error) {
+	c.mu.Lock()
+	active := c.active
+	c.mu.Unlock()
+	resp, err := sign(c.keys[active])
+	if err != nil || resp.StatusCode != http.StatusForbidden || len(c.keys) < 2 {
+		return resp, err
+	}
+	resp.Body.Close()
+	other := 1 - active
+	retry, err := sign(c.keys[other])
+	if err != nil {
+		return nil, err
+	}
+	if retry.StatusCode == http.StatusForbidden {
+		return retry, nil
+	}
+	c.mu.Lock()
+	// Unless a concurrent request switched over already.
+	switched := c.active == active
+	if switched {
+		c.active = other
+	}
+	c.mu.Unlock()
+	if switched && c.switched != nil {
+		c.switched(other == 1)
+	}
+	return retry, nil
+}
+
+// rotateCredentials sets the next key of the credentials of a feature, if
+// any, recording and logging their switchovers.
+func (ca *cephReceiveAdapter) rotateCredentials(feature string, creds *s3Credentials, accessKeyID, secretAccessKey string) {
+	if accessKeyID == "" {
+		return
+	}
+	creds.setNext(accessKeyID, secretAccessKey)
+	creds.switched = func(next bool) {
+		if next {
+			ca.logger.Warnw("The RGW rejected the current key, switched over to the next one", zap.String("feature", feature))
+		} else {
+			ca.logger.Warnw("The RGW rejected the next key, switched back to the current one", zap.String("feature", feature))
+		}
+		ca.recordCredentials(context.Background(), feature, next)
+	}
+	if ca.rotatedCredentials == nil {
+		ca.rotatedCredentials = make(map[string]*s3Credentials)
+	}
+	ca.rotatedCredentials[feature] = creds
+}
+
+// recordCredentials records whether the next key of the credentials of a
+// feature is the active one.
+func (ca *cephReceiveAdapter) recordCredentials(ctx context.Context, feature string, next bool) {
+	value := int64(0)
+	if next {
+		value = 1
+	}
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(featureKey, feature))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, credentialsNextActiveM.M(value))
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/sigv4"
+)
+
+func writeCredentials(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadExternalCredentials(t *testing.T) {
+	dir := t.TempDir()
+	writeCredentials(t, dir, map[string]string{
+		"access-key-id":           "shared-id\n",
+		"secret-access-key":       "shared-secret\n",
+		"audit-access-key-id":     "audit-id",
+		"audit-secret-access-key": "audit-secret",
+		"next-access-key-id":      "next-id",
+		"next-secret-access-key":  "next-secret",
+	})
+	env := &envConfig{
+		CredentialsDir: dir,
+		Verify:         rgwEnvConfig{Endpoint: "http://rgw.rook-ceph.svc"},
+		Audit:          rgwEnvConfig{Endpoint: "http://rgw.rook-ceph.svc"},
+	}
+	if err := env.readExternalCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if env.Verify.AccessKeyID != "shared-id" || env.Verify.SecretAccessKey != "shared-secret" {
+		t.Errorf("Unexpected verify credentials: %q, %q", env.Verify.AccessKeyID, env.Verify.SecretAccessKey)
+	}
+	if env.Audit.AccessKeyID != "audit-id" || env.Audit.SecretAccessKey != "audit-secret" {
+		t.Errorf("Unexpected audit credentials: %q, %q", env.Audit.AccessKeyID, env.Audit.SecretAccessKey)
+	}
+	if env.Verify.NextAccessKeyID != "next-id" || env.Audit.NextSecretAccessKey != "next-secret" {
+		t.Errorf("Unexpected next credentials: %q, %q", env.Verify.NextAccessKeyID, env.Audit.NextSecretAccessKey)
+	}
+	// Features not enabled are left alone.
+	if env.Usage.AccessKeyID != "" {
+		t.Errorf("Unexpected usage credentials: %q", env.Usage.AccessKeyID)
+	}
+}
+
+func TestReadExternalCredentialsMissing(t *testing.T) {
+	dir := t.TempDir()
+	writeCredentials(t, dir, map[string]string{
+		"usage-access-key-id": "usage-id",
+	})
+	for name, env := range map[string]*envConfig{
+		"no credentials": {CredentialsDir: dir, Verify: rgwEnvConfig{Endpoint: "http://rgw.rook-ceph.svc"}},
+		"no secret":      {CredentialsDir: dir, Usage: rgwEnvConfig{Endpoint: "http://rgw.rook-ceph.svc"}},
+	} {
+		if err := env.readExternalCredentials(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRotateCredentials(t *testing.T) {
+	accepted := "current"
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(strings.Fields(r.Header.Get("Authorization"))[1], "Credential=")
+		id = strings.Split(id, "/")[0]
+		requests = append(requests, id)
+		if id != accepted {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	rgw, err := newRGWClient(srv.URL, "", "current", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar()}
+	ca.rotateCredentials("usage", rgw.creds, "next", "next-secret")
+	var switches []bool
+	switched := rgw.creds.switched
+	rgw.creds.switched = func(next bool) {
+		switches = append(switches, next)
+		switched(next)
+	}
+
+	get := func() {
+		t.Helper()
+		requests = nil
+		if _, err := rgw.get(context.Background(), "/", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get()
+	if got := strings.Join(requests, ","); got != "current" {
+		t.Errorf("Unexpected keys before the rotation: %s", got)
+	}
+
+	// The RGW rejects the current key once the next one replaces it.
+	accepted = "next"
+	get()
+	if got := strings.Join(requests, ","); got != "current,next" {
+		t.Errorf("Unexpected keys on the switchover: %s", got)
+	}
+	if !rgw.creds.nextActive() {
+		t.Error("The next key is not active")
+	}
+	get()
+	if got := strings.Join(requests, ","); got != "next" {
+		t.Errorf("Unexpected keys after the switchover: %s", got)
+	}
+
+	// Neither key is switched to when both are rejected.
+	accepted = ""
+	requests = nil
+	if _, err := rgw.get(context.Background(), "/", nil); err == nil {
+		t.Error("Expected the rejection of both keys to fail")
+	}
+	if got := strings.Join(requests, ","); got != "next,current" {
+		t.Errorf("Unexpected keys when both are rejected: %s", got)
+	}
+	if !rgw.creds.nextActive() {
+		t.Error("The next key is no longer active")
+	}
+	if len(switches) != 1 || !switches[0] {
+		t.Errorf("Unexpected switchovers: %v", switches)
+	}
+}
+
+func TestCredentialsWithoutNextKey(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	v, err := newETagVerifier(srv.URL, "", "access", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := v.head(context.Background(), "bucket", "key", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusForbidden || requests != 1 {
+		t.Errorf("Unexpected response %d after %d requests", resp.StatusCode, requests)
+	}
+}
+
+// closeRecorder records whether the body of a response is closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestCredentialsRetryFailure(t *testing.T) {
+	creds := newS3Credentials("current", "secret")
+	creds.setNext("next", "next-secret")
+	rejected := &closeRecorder{Reader: strings.NewReader("AccessDenied")}
+	sendErr := errors.New("connection refused")
+	resp, err := creds.send(func(key sigv4.Credentials) (*http.Response, error) {
+		if key.AccessKeyID == "current" {
+			return &http.Response{StatusCode: http.StatusForbidden, Body: rejected}, nil
+		}
+		return nil, sendErr
+	})
+	if resp != nil || !errors.Is(err, sendErr) {
+		t.Errorf("Unexpected result of the retry: %v, %v", resp, err)
+	}
+	if !rejected.closed {
+		t.Error("The response rejecting the current key was not closed")
+	}
+	if creds.nextActive() {
+		t.Error("Unexpected switchover to the next key")
+	}
+}
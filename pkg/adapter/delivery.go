@@ -0,0 +1,341 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/google/uuid"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// failureRetryable and failureTerminal are the classes of the failures
+	// to deliver an event to the sink.
+	failureRetryable = "retryable"
+	failureTerminal  = "terminal"
+
+	// maxRetryAfter caps the wait asked by the Retry-After header of a sink
+	// response, Ceph waiting for the notification to be acknowledged.
+	maxRetryAfter = time.Minute
+
+	// errorCodeExtension carries the status code of the last sink response
+	// of the events sent to the dead letter sink, as with Knative Eventing.
+	errorCodeExtension = "knativeerrorcode"
+
+	// deliveryFailedType is the type of the events reporting the events that
+	// failed to be delivered for good to the failure sink.
+	deliveryFailedType = "org.ceph.source.delivery-failed"
+)
+
+var (
+	// sinkFailureM counts the failures to deliver an event to the sink, by
+	// class and response code.
+	sinkFailureM = stats.Int64(
+		"sink_failure_count",
+		"Number of failures to deliver an event to the sink",
+		stats.UnitDimensionless,
+	)
+
+	failureClassKey = tag.MustNewKey("failure_class")
+	responseCodeKey = tag.MustNewKey("response_code")
+
+	registerDeliveryOnce sync.Once
+)
+
+func registerDeliveryViews() error {
+	var err error
+	registerDeliveryOnce.Do(func() {
+		err = view.Register(&view.View{
+			Description: sinkFailureM.Description(),
+			Measure:     sinkFailureM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey, failureClassKey, responseCodeKey},
+		})
+	})
+	return err
+}
+
+// deliverySettings are the retries and dead letter sink of the events, and
+// the sink their failures are reported to.
+type deliverySettings struct {
+	retry          int
+	backoffDelay   time.Duration
+	deadLetterSink string
+	failureSink    string
+}
+
+// deliveryFailure is the data of the events reporting an event that failed
+// to be delivered for good.
+type deliveryFailure struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Source       string `json:"source"`
+	Subject      string `json:"subject,omitempty"`
+	Attempts     int    `json:"attempts"`
+	ResponseCode int    `json:"responseCode,omitempty"`
+	Error        string `json:"error"`
+	DeadLettered bool   `json:"deadLettered"`
+}
+
+// backoff returns the wait before the retry following the given attempt.
+func (d deliverySettings) backoff(attempt int) time.Duration {
+	return d.backoffDelay * time.Duration(1<<uint(attempt))
+}
+
+// failureClass returns whether a failure to deliver an event is worth
+// retrying, as per the delivery spec of Knative Eventing.
+func failureClass(err error) string {
+	switch code := sinkStatusCode(err); {
+	case code == 0,
+		code == http.StatusNotFound,
+		code == http.StatusRequestTimeout,
+		code == http.StatusConflict,
+		code == http.StatusTooManyRequests,
+		code >= 500:
+		return failureRetryable
+	default:
+		return failureTerminal
+	}
+}
+
+// deliver sends an event to the sink, retrying its retryable failures, and
+// sends it to the dead letter sink if any once it failed for good, reporting
+// the failure to the failure sink if any.
+func (ca *cephReceiveAdapter) deliver(ctx context.Context, event cloudevents.Event) error {
+	var (
+		err     error
+		attempt int
+	)
+	for ; ; attempt++ {
+		retryAfter := &retryAfter{}
+		if err = ca.sendCloudEvent(withRetryAfter(ctx, retryAfter), event); err == nil {
+			return nil
+		}
+		class := failureClass(err)
+		ca.recordFailure(ctx, class, sinkStatusCode(err))
+		if class == failureTerminal || attempt >= ca.delivery.retry {
+			break
+		}
+
+		wait := ca.delivery.backoff(attempt)
+		if d := retryAfter.get(); d > wait {
+			wait = d
+			if wait > maxRetryAfter {
+				wait = maxRetryAfter
+			}
+		}
+		logging.FromContext(ctx).Debugw("Retrying event", zap.String("id", event.ID()), zap.Duration("wait", wait))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+	result := err
+	if ca.delivery.deadLetterSink != "" {
+		result = ca.sendDeadLetter(ctx, event, err)
+	}
+	if ca.delivery.failureSink != "" {
+		ca.reportFailure(ctx, event, attempt+1, err, result == nil)
+	}
+	return result
+}
+
+// reportFailure sends the event reporting an event that failed to be
+// delivered with err after the given attempts to the failure sink.
+func (ca *cephReceiveAdapter) reportFailure(ctx context.Context, event cloudevents.Event, attempts int, err error, deadLettered bool) {
+	logger := logging.FromContext(ctx).With(zap.String("id", event.ID()))
+	report := cloudevents.NewEvent()
+	report.SetID(uuid.New().String())
+	report.SetType(deliveryFailedType)
+	report.SetSource(ca.sourceURI())
+	report.SetSubject(event.ID())
+	report.SetTime(time.Now())
+	if err := report.SetData(cloudevents.ApplicationJSON, deliveryFailure{
+		ID:           event.ID(),
+		Type:         event.Type(),
+		Source:       event.Source(),
+		Subject:      event.Subject(),
+		Attempts:     attempts,
+		ResponseCode: sinkStatusCode(err),
+		Error:        err.Error(),
+		DeadLettered: deadLettered,
+	}); err != nil {
+		logger.Errorw("Failed to create the delivery failure report", zap.Error(err))
+		return
+	}
+	ctx, report = ca.encode(cloudevents.ContextWithTarget(ctx, ca.delivery.failureSink), report)
+	if result := ca.client.Send(ctx, report); !cloudevents.IsACK(result) {
+		logger.Errorw("Failed to report the delivery failure", zap.Error(result))
+	}
+}
+
+// sendDeadLetter sends an event that failed to be delivered with err to the
+// dead letter sink.
+func (ca *cephReceiveAdapter) sendDeadLetter(ctx context.Context, event cloudevents.Event, err error) error {
+	logger := logging.FromContext(ctx).With(zap.String("id", event.ID()))
+	event = event.Clone()
+	if code := sinkStatusCode(err); code != 0 {
+		event.SetExtension(errorCodeExtension, strconv.Itoa(code))
+	}
+	ctx, event = ca.encode(cloudevents.ContextWithTarget(ctx, ca.delivery.deadLetterSink), event)
+	result := ca.client.Send(ctx, event)
+	if !cloudevents.IsACK(result) {
+		logger.Errorw("Failed to send event to the dead letter sink", zap.NamedError("sinkError", err), zap.Error(result))
+		return err
+	}
+	ca.mirror(ctx, event)
+	logger.Warnw("Sent undelivered event to the dead letter sink", zap.Error(err))
+	return nil
+}
+
+// recordFailure records a failure of the given class to deliver an event.
+func (ca *cephReceiveAdapter) recordFailure(ctx context.Context, class string, code int) {
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(failureClassKey, class),
+		tag.Insert(responseCodeKey, strconv.Itoa(code)))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, sinkFailureM.M(1))
+}
+
+type retryAfterKey struct{}
+
+// retryAfter holds the wait asked by the Retry-After header of the last
+// sink response to a send.
+type retryAfter struct {
+	mu   sync.Mutex
+	wait time.Duration
+}
+
+func (r *retryAfter) get() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.wait
+}
+
+func (r *retryAfter) set(wait time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.wait = wait
+}
+
+func withRetryAfter(ctx context.Context, r *retryAfter) context.Context {
+	return context.WithValue(ctx, retryAfterKey{}, r)
+}
+
+// NewRetryAfterTransport wraps the transport of the sink client, for the
+// adapter to honor the Retry-After header of the sink responses, which the
+// CloudEvents client does not report.
+func NewRetryAfterTransport(base http.RoundTripper) http.RoundTripper {
+	return &retryAfterTransport{base: base}
+}
+
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if r, ok := req.Context().Value(retryAfterKey{}).(*retryAfter); ok {
+		r.set(parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	}
+	return resp, nil
+}
+
+// parseRetryAfter returns the wait of a Retry-After header, either a number
+// of seconds or an HTTP date, 0 if unset or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// sinkStatusCode returns the HTTP status code the sink responded with, or 0
+// if the error does not originate from a sink response.
+func sinkStatusCode(err error) int {
+	var result *cehttp.Result
+	if cloudevents.ResultAs(err, &result) {
+		return result.StatusCode
+	}
+	return 0
+}
+
+// failureStatusCode returns the status code to respond to Ceph with when a
+// notification could not be delivered. With backpressure enabled, sink
+// overload is reported as 503 so that Ceph retries the notification later
+// from its persistent topic. Rate limited notifications are always reported
+// as 503.
+func (ca *cephReceiveAdapter) failureStatusCode(err error) int {
+	if errors.Is(err, errRateLimited) || errors.Is(err, errQueueFull) {
+		return http.StatusServiceUnavailable
+	}
+	if ca.backpressure {
+		switch sinkStatusCode(err) {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return http.StatusServiceUnavailable
+		}
+	}
+	return http.StatusBadRequest
+}
+
+// congested returns true if the result of a send indicates that the sink is
+// overloaded or unreachable.
+func congested(result error) bool {
+	if cloudevents.IsACK(result) {
+		return false
+	}
+	switch sinkStatusCode(result) {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case 0:
+		return cloudevents.IsUndelivered(result)
+	}
+	return false
+}
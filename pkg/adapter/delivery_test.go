@@ -0,0 +1,325 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+)
+
+func TestFailureStatusCode(t *testing.T) {
+	testCases := map[string]struct {
+		backpressure bool
+		err          error
+		want         int
+	}{
+		"sink overloaded without backpressure": {
+			err:  cehttp.NewResult(http.StatusTooManyRequests, "too many requests"),
+			want: http.StatusBadRequest,
+		},
+		"sink throttling with backpressure": {
+			backpressure: true,
+			err:          cloudevents.NewReceipt(false, "%w", cehttp.NewResult(http.StatusTooManyRequests, "too many requests")),
+			want:         http.StatusServiceUnavailable,
+		},
+		"sink unavailable with backpressure": {
+			backpressure: true,
+			err:          cehttp.NewResult(http.StatusServiceUnavailable, "unavailable"),
+			want:         http.StatusServiceUnavailable,
+		},
+		"sink rejection with backpressure": {
+			backpressure: true,
+			err:          cehttp.NewResult(http.StatusBadRequest, "bad request"),
+			want:         http.StatusBadRequest,
+		},
+		"rate limited without backpressure": {
+			err:  errRateLimited,
+			want: http.StatusServiceUnavailable,
+		},
+		"non sink error with backpressure": {
+			backpressure: true,
+			err:          errors.New("failed to marshal event data"),
+			want:         http.StatusBadRequest,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			ca := &cephReceiveAdapter{backpressure: tc.backpressure}
+			if got := ca.failureStatusCode(tc.err); got != tc.want {
+				t.Errorf("Unexpected status code: got %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFailureClass(t *testing.T) {
+	testCases := map[string]struct {
+		err  error
+		want string
+	}{
+		"no response": {
+			err:  errors.New("connection refused"),
+			want: failureRetryable,
+		},
+		"bad request": {
+			err:  cehttp.NewResult(http.StatusBadRequest, "bad request"),
+			want: failureTerminal,
+		},
+		"not found": {
+			err:  cehttp.NewResult(http.StatusNotFound, "not found"),
+			want: failureRetryable,
+		},
+		"too many requests": {
+			err:  cloudevents.NewReceipt(false, "%w", cehttp.NewResult(http.StatusTooManyRequests, "too many requests")),
+			want: failureRetryable,
+		},
+		"unprocessable": {
+			err:  cehttp.NewResult(http.StatusUnprocessableEntity, "unprocessable"),
+			want: failureTerminal,
+		},
+		"server error": {
+			err:  cehttp.NewResult(http.StatusBadGateway, "bad gateway"),
+			want: failureRetryable,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if got := failureClass(tc.err); got != tc.want {
+				t.Errorf("Unexpected failure class: got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		value string
+		want  time.Duration
+	}{
+		"unset":    {},
+		"seconds":  {value: "120", want: 2 * time.Minute},
+		"negative": {value: "-1"},
+		"date":     {value: "Tue, 01 Jun 2021 12:00:30 GMT", want: 30 * time.Second},
+		"past":     {value: "Tue, 01 Jun 2021 11:00:00 GMT"},
+		"invalid":  {value: "soon"},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if got := parseRetryAfter(tc.value, now); got != tc.want {
+				t.Errorf("Unexpected wait: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterTransport(t *testing.T) {
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer sink.Close()
+
+	r := &retryAfter{}
+	req, err := http.NewRequestWithContext(withRetryAfter(context.Background(), r), "POST", sink.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := NewRetryAfterTransport(http.DefaultTransport).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := r.get(); got != 3*time.Second {
+		t.Errorf("Unexpected Retry-After: got %v, want 3s", got)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	testCases := map[string]struct {
+		results        []int
+		retry          int
+		deadLetterSink string
+		wantErr        bool
+		wantAttempts   int
+		wantDeadLetter bool
+	}{
+		"retryable failure then success": {
+			results:      []int{http.StatusServiceUnavailable, http.StatusTooManyRequests},
+			retry:        2,
+			wantAttempts: 3,
+		},
+		"retries exhausted": {
+			results:      []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			retry:        1,
+			wantErr:      true,
+			wantAttempts: 2,
+		},
+		"terminal failure not retried": {
+			results:      []int{http.StatusBadRequest},
+			retry:        3,
+			wantErr:      true,
+			wantAttempts: 1,
+		},
+		"terminal failure dead lettered": {
+			results:        []int{http.StatusBadRequest},
+			retry:          3,
+			deadLetterSink: "http://dls.example.com",
+			wantAttempts:   2,
+			wantDeadLetter: true,
+		},
+		"retries exhausted dead lettered": {
+			results:        []int{http.StatusInternalServerError, http.StatusInternalServerError},
+			retry:          1,
+			deadLetterSink: "http://dls.example.com",
+			wantAttempts:   3,
+			wantDeadLetter: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			client := adaptertest.NewClient()
+			for _, code := range tc.results {
+				client.Enqueue(adaptertest.NACK(code))
+			}
+			ca := &cephReceiveAdapter{
+				logger: zap.NewNop().Sugar(),
+				client: client,
+				delivery: deliverySettings{
+					retry:          tc.retry,
+					backoffDelay:   time.Millisecond,
+					deadLetterSink: tc.deadLetterSink,
+				},
+			}
+
+			event := cloudevents.NewEvent()
+			event.SetID("1")
+			event.SetType("com.amazonaws.ObjectCreated:Put")
+			event.SetSource("ceph:s3.us-east-1.fish")
+			err := ca.deliver(context.Background(), event)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			attempted := client.Attempted()
+			if len(attempted) != tc.wantAttempts {
+				t.Fatalf("Unexpected number of attempts: got %d, want %d", len(attempted), tc.wantAttempts)
+			}
+			if tc.wantDeadLetter {
+				want := strconv.Itoa(tc.results[len(tc.results)-1])
+				if got := attempted[len(attempted)-1].Extensions()[errorCodeExtension]; got != want {
+					t.Errorf("Unexpected error code extension: got %v, want %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReportFailure(t *testing.T) {
+	testCases := map[string]struct {
+		results        []int
+		deadLetterSink string
+		wantReport     bool
+		wantDeadLetter bool
+	}{
+		"delivered": {
+			results: []int{http.StatusServiceUnavailable},
+		},
+		"retries exhausted": {
+			results:    []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			wantReport: true,
+		},
+		"dead lettered": {
+			results:        []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			deadLetterSink: "http://dls.example.com",
+			wantReport:     true,
+			wantDeadLetter: true,
+		},
+		"dead letter failed": {
+			results:        []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusBadRequest},
+			deadLetterSink: "http://dls.example.com",
+			wantReport:     true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			client := adaptertest.NewClient()
+			for _, code := range tc.results {
+				client.Enqueue(adaptertest.NACK(code))
+			}
+			ca := &cephReceiveAdapter{
+				logger:    zap.NewNop().Sugar(),
+				client:    client,
+				namespace: "ns",
+				name:      "source",
+				delivery: deliverySettings{
+					retry:          1,
+					backoffDelay:   time.Millisecond,
+					deadLetterSink: tc.deadLetterSink,
+					failureSink:    "http://alerts.example.com",
+				},
+			}
+
+			event := cloudevents.NewEvent()
+			event.SetID("1")
+			event.SetType("com.amazonaws.ObjectCreated:Put")
+			event.SetSource("ceph:s3.us-east-1.fish")
+			ca.deliver(context.Background(), event)
+
+			attempted := client.Attempted()
+			report := attempted[len(attempted)-1]
+			if report.Type() != deliveryFailedType {
+				if tc.wantReport {
+					t.Fatalf("Unexpected last event type: got %s, want %s", report.Type(), deliveryFailedType)
+				}
+				return
+			}
+			if !tc.wantReport {
+				t.Fatal("Unexpected delivery failure report")
+			}
+			if want := "/apis/v1/namespaces/ns/cephsources/source"; report.Source() != want {
+				t.Errorf("Unexpected source: got %s, want %s", report.Source(), want)
+			}
+			var got deliveryFailure
+			if err := report.DataAs(&got); err != nil {
+				t.Fatal(err)
+			}
+			want := deliveryFailure{
+				ID:           "1",
+				Type:         "com.amazonaws.ObjectCreated:Put",
+				Source:       "ceph:s3.us-east-1.fish",
+				Attempts:     2,
+				ResponseCode: http.StatusServiceUnavailable,
+				Error:        got.Error,
+				DeadLettered: tc.wantDeadLetter,
+			}
+			if got != want {
+				t.Errorf("Unexpected report: got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
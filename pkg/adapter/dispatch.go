@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// roleReceiver queues the events of the notifications it receives,
+	// acknowledging them once queued.
+	roleReceiver = "receiver"
+
+	// roleDispatcher delivers the queued events to the sink.
+	roleDispatcher = "dispatcher"
+
+	// claimPrefix prefixes the events being delivered by a dispatcher.
+	claimPrefix = ".claimed-"
+
+	// corruptPrefix prefixes the queued events that cannot be decoded, set
+	// aside for inspection rather than sent.
+	corruptPrefix = ".corrupt-"
+
+	// claimTimeout is after how long the claim of an event is released,
+	// its dispatcher being assumed gone.
+	claimTimeout = 5 * time.Minute
+)
+
+// errQueueFull is returned for notifications received while the queue of
+// the dispatcher is full, for Ceph to retry them later.
+var errQueueFull = errors.New("dispatch queue is full")
+
+// droppedEventError is returned by the senders of claimEach for the events
+// that failed for good, to be dropped rather than sent again.
+type droppedEventError struct {
+	err error
+}
+
+func (e *droppedEventError) Error() string {
+	return e.err.Error()
+}
+
+func (e *droppedEventError) Unwrap() error {
+	return e.err
+}
+
+// enqueue queues an event for the dispatcher, unless the queue is full.
+func (ca *cephReceiveAdapter) enqueue(ctx context.Context, event cloudevents.Event) error {
+	logging.FromContext(ctx).Debugw("Queuing event", zap.String("id", event.ID()))
+	err := ca.queue.StoreUnlessFull(event)
+	if err == errQueueFull {
+		logging.FromContext(ctx).Warnw("Rejecting notification, the dispatch queue is full", zap.Int("max", ca.queue.max))
+	}
+	return err
+}
+
+// dispatch delivers the queued events to the sink every interval until ctx
+// is done, or the adapter is replaced on reload. Undelivered events are
+// retained if enabled, else left queued until the next attempt.
+func (ca *cephReceiveAdapter) dispatch(ctx context.Context, interval time.Duration) error {
+	ca.logger.Infow("Dispatching queued events", zap.String("dir", ca.queue.dir))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := ca.queue.releaseClaims(claimTimeout); err != nil {
+			ca.logger.Warnw("Failed to release stale claims", zap.Error(err))
+		}
+		ctx := adapter.ContextWithMetricTag(logging.WithLogger(ctx, ca.logger), ca.metricTag())
+		n, err := ca.queue.claimEach(ctx, func(ctx context.Context, event cloudevents.Event) error {
+			if err := ca.deliver(ctx, event); err != nil && !ca.retain(ctx, event, err) {
+				if failureClass(err) == failureTerminal && ca.delivery.deadLetterSink == "" {
+					// The sink will never accept it.
+					return &droppedEventError{err: err}
+				}
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			ca.logger.Warnw("Failed to dispatch queued events", zap.Int("dispatched", n), zap.Error(err))
+		} else if n > 0 {
+			ca.logger.Debugw("Dispatched queued events", zap.Int("count", n))
+		}
+
+		select {
+		case <-ctx.Done():
+			ca.logger.Info("Ceph dispatcher terminated")
+			return nil
+		case <-ca.reloaded:
+			ca.logger.Info("Handing over to the dispatcher of the reloaded configuration file")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimEach sends the stored events, oldest first, removing the sent ones.
+// Each event is claimed before being sent, so that the dispatchers sharing
+// the directory send it once. The events failing with a droppedEventError
+// are removed, and the ones that cannot be decoded set aside, the next
+// events being sent regardless. It stops at any other failure, releasing
+// the claim of the event.
+func (s *failedEventStore) claimEach(ctx context.Context, send func(context.Context, cloudevents.Event) error) (int, error) {
+	s.mu.Lock()
+	names, err := s.names()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		path := filepath.Join(s.dir, name)
+		claimed := filepath.Join(s.dir, claimPrefix+name)
+		if err := os.Rename(path, claimed); os.IsNotExist(err) {
+			// Claimed by another dispatcher, or dropped meanwhile.
+			continue
+		} else if err != nil {
+			return sent, err
+		}
+		now := time.Now()
+		if err := os.Chtimes(claimed, now, now); err != nil {
+			return sent, err
+		}
+
+		data, err := ioutil.ReadFile(claimed)
+		if err != nil {
+			return sent, err
+		}
+		var event cloudevents.Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			logging.FromContext(ctx).Errorw("Setting aside queued event that cannot be decoded", zap.String("file", corruptPrefix+name), zap.Error(err))
+			if rerr := os.Rename(claimed, filepath.Join(s.dir, corruptPrefix+name)); rerr != nil {
+				return sent, fmt.Errorf("failed to set aside queued event %s: %w", name, rerr)
+			}
+			continue
+		}
+		var dropped *droppedEventError
+		if err := send(ctx, event); errors.As(err, &dropped) {
+			logging.FromContext(ctx).Errorw("Dropping queued event failed for good", zap.String("id", event.ID()), zap.Error(dropped.err))
+		} else if err != nil {
+			if rerr := os.Rename(claimed, path); rerr != nil {
+				return sent, rerr
+			}
+			return sent, err
+		}
+		if err := os.Remove(claimed); err != nil && !os.IsNotExist(err) {
+			return sent, err
+		}
+		if dropped == nil {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// releaseClaims releases the claims older than timeout, e.g. of dispatchers
+// killed while sending, for the events to be sent again.
+func (s *failedEventStore) releaseClaims(timeout time.Duration) error {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), claimPrefix) || time.Since(e.ModTime()) < timeout {
+			continue
+		}
+		name := strings.TrimPrefix(e.Name(), claimPrefix)
+		if err := os.Rename(filepath.Join(s.dir, e.Name()), filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+)
+
+func TestReceiverQueuesEvents(t *testing.T) {
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{
+		logger: zap.NewNop().Sugar(),
+		client: client,
+		role:   roleReceiver,
+		queue:  newTestStore(t, 1),
+	}
+	if err := ca.postMessage(context.Background(), notification1); err != nil {
+		t.Fatal("Failed to queue event:", err)
+	}
+	if sent := client.Sent(); len(sent) != 0 {
+		t.Errorf("Unexpected events sent by the receiver: %d", len(sent))
+	}
+	if n, _ := ca.queue.Count(); n != 1 {
+		t.Errorf("Unexpected queued events: got %d, want 1", n)
+	}
+
+	err := ca.postMessage(context.Background(), notification1)
+	if !errors.Is(err, errQueueFull) {
+		t.Fatalf("Unexpected error of a full queue: %v", err)
+	}
+	if code := ca.failureStatusCode(err); code != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected status of a full queue: %d", code)
+	}
+}
+
+func TestConcurrentEnqueue(t *testing.T) {
+	ca := &cephReceiveAdapter{
+		logger: zap.NewNop().Sugar(),
+		queue:  newTestStore(t, 5),
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- ca.enqueue(context.Background(), testEvent(strconv.Itoa(i)))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	queued, full := 0, 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			queued++
+		case errors.Is(err, errQueueFull):
+			full++
+		default:
+			t.Error("Unexpected error:", err)
+		}
+	}
+	// Every event acknowledged as queued is kept, none being dropped to
+	// make room for others.
+	if n, _ := ca.queue.Count(); queued != 5 || full != 15 || n != queued {
+		t.Errorf("Unexpected queue: %d queued, %d rejected, %d stored", queued, full, n)
+	}
+}
+
+func TestClaimEach(t *testing.T) {
+	store := newTestStore(t, 10)
+	for _, id := range []string{"1", "2", "3"} {
+		if err := store.Store(testEvent(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Another dispatcher claimed the first event.
+	names, _ := store.names()
+	if err := os.Rename(filepath.Join(store.dir, names[0]), filepath.Join(store.dir, claimPrefix+names[0])); err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	sent, err := store.claimEach(context.Background(), func(_ context.Context, event cloudevents.Event) error {
+		if event.ID() == "3" {
+			return errors.New("sink down")
+		}
+		ids = append(ids, event.ID())
+		return nil
+	})
+	if err == nil || sent != 1 || len(ids) != 1 || ids[0] != "2" {
+		t.Errorf("Unexpected dispatch: sent %v, error %v", ids, err)
+	}
+	// The failed event is released for the next attempt.
+	if n, _ := store.Count(); n != 1 {
+		t.Errorf("Unexpected queued events: got %d, want 1", n)
+	}
+
+	if err := store.releaseClaims(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := store.Count(); n != 1 {
+		t.Errorf("Recent claim should not be released, got %d queued events", n)
+	}
+	if err := store.releaseClaims(0); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := store.Count(); n != 2 {
+		t.Errorf("Stale claim should be released, got %d queued events", n)
+	}
+
+	// An event that cannot be decoded and one failed for good do not block
+	// the next ones.
+	store = newTestStore(t, 10)
+	for _, id := range []string{"1", "2", "3"} {
+		if err := store.Store(testEvent(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, _ = store.names()
+	if err := ioutil.WriteFile(filepath.Join(store.dir, names[0]), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ids = nil
+	sent, err = store.claimEach(context.Background(), func(_ context.Context, event cloudevents.Event) error {
+		if event.ID() == "2" {
+			return &droppedEventError{err: errors.New("400 Bad Request")}
+		}
+		ids = append(ids, event.ID())
+		return nil
+	})
+	if err != nil || sent != 1 || len(ids) != 1 || ids[0] != "3" {
+		t.Errorf("Unexpected dispatch: sent %v, error %v", ids, err)
+	}
+	if n, _ := store.Count(); n != 0 {
+		t.Errorf("Unexpected queued events: got %d, want 0", n)
+	}
+	if _, err := os.Stat(filepath.Join(store.dir, corruptPrefix+names[0])); err != nil {
+		t.Error("Event that cannot be decoded should be set aside:", err)
+	}
+	if err := store.releaseClaims(0); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := store.Count(); n != 0 {
+		t.Errorf("Event set aside should not be queued again, got %d queued events", n)
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{
+		logger: zap.NewNop().Sugar(),
+		client: client,
+		role:   roleDispatcher,
+		queue:  newTestStore(t, 10),
+	}
+	if err := ca.queue.Store(testEvent("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ca.dispatch(ctx, 10*time.Millisecond) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(client.Sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if sent := client.Sent(); len(sent) != 1 || sent[0].ID() != "1" {
+		t.Errorf("Unexpected events sent: %v", sent)
+	}
+	if n, _ := ca.queue.Count(); n != 0 {
+		t.Errorf("Unexpected queued events: got %d, want 0", n)
+	}
+}
+
+func TestDispatchDropsRejectedEvents(t *testing.T) {
+	client := adaptertest.NewClient()
+	client.RespondWith(adaptertest.FailIf(func(event cloudevents.Event) bool {
+		return event.ID() == "1"
+	}, http.StatusBadRequest))
+	ca := &cephReceiveAdapter{
+		logger: zap.NewNop().Sugar(),
+		client: client,
+		role:   roleDispatcher,
+		queue:  newTestStore(t, 10),
+	}
+	for _, id := range []string{"1", "2"} {
+		if err := ca.queue.Store(testEvent(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ca.dispatch(ctx, 10*time.Millisecond) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(client.Sent()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if sent := client.Sent(); len(sent) != 1 || sent[0].ID() != "2" {
+		t.Errorf("Unexpected events sent: %v", sent)
+	}
+	if n, _ := ca.queue.Count(); n != 0 {
+		t.Errorf("Rejected event should be dropped, got %d queued events", n)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+
+	"knative.dev/eventing-ceph/pkg/tlspolicy"
+)
+
+// dnsConfig are the settings of the resolution of the sink hostnames and of
+// the TLS of the sink client, read before the sink client is created.
+type dnsConfig struct {
+	// CacheTTL is how long the addresses of a host are cached. Not cached
+	// if 0.
+	CacheTTL time.Duration `envconfig:"DNS_CACHE_TTL"`
+
+	// Nameservers lists the DNS servers, "host" or "host:port", queried in
+	// turn instead of the ones of the pod.
+	Nameservers []string `envconfig:"DNS_NAMESERVERS"`
+
+	// TLSMinVersion and TLSCipherSuites restrict the TLS of the sink client,
+	// as parsed by tlspolicy.Parse.
+	TLSMinVersion   string   `envconfig:"TLS_MIN_VERSION"`
+	TLSCipherSuites []string `envconfig:"TLS_CIPHER_SUITES"`
+}
+
+// NewSinkTransport returns the transport of the sink client, caching the
+// addresses of the sink hostnames, querying custom DNS servers and
+// restricting its TLS as set in the environment, or base as is when none is
+// set.
+func NewSinkTransport(base http.RoundTripper) (http.RoundTripper, error) {
+	var config dnsConfig
+	if err := envconfig.Process("", &config); err != nil {
+		return nil, err
+	}
+	policy, err := tlspolicy.Parse(config.TLSMinVersion, config.TLSCipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return base, nil
+	}
+	if config.TLSMinVersion != "" || len(config.TLSCipherSuites) > 0 {
+		transport = transport.Clone()
+		transport.TLSClientConfig = policy.Apply(transport.TLSClientConfig)
+	}
+	if config.CacheTTL <= 0 && len(config.Nameservers) == 0 {
+		return transport, nil
+	}
+
+	resolver := net.DefaultResolver
+	if len(config.Nameservers) > 0 {
+		resolver = newNameserverResolver(config.Nameservers)
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Resolver:  resolver,
+	}
+	transport = transport.Clone()
+	transport.DialContext = dialer.DialContext
+	if config.CacheTTL > 0 {
+		cache := newDNSCache(resolver.LookupHost, config.CacheTTL)
+		transport.DialContext = cache.dialContext(dialer)
+	}
+	return transport, nil
+}
+
+// newNameserverResolver returns a resolver querying the given DNS servers in
+// turn, on port 53 unless set.
+func newNameserverResolver(nameservers []string) *net.Resolver {
+	addresses := make([]string, len(nameservers))
+	for i, ns := range nameservers {
+		if _, _, err := net.SplitHostPort(ns); err != nil {
+			ns = net.JoinHostPort(ns, "53")
+		}
+		addresses[i] = ns
+	}
+	var next uint32
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			i := atomic.AddUint32(&next, 1)
+			var d net.Dialer
+			return d.DialContext(ctx, network, addresses[int(i)%len(addresses)])
+		},
+	}
+}
+
+// dnsEntry holds the addresses of a host until they expire.
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsCache caches the addresses of hosts for a TTL, sparing the cluster DNS
+// a query per connection at high event rates.
+type dnsCache struct {
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+func newDNSCache(lookupHost func(ctx context.Context, host string) ([]string, error), ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		lookupHost: lookupHost,
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    make(map[string]dnsEntry),
+	}
+}
+
+// lookup returns the addresses of a host, resolving them once expired. The
+// expired addresses are returned when their resolution fails, rather than
+// failing the sends on a DNS hiccup.
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := c.lookupHost(ctx, host)
+	if err != nil {
+		if ok {
+			return entry.addrs, nil
+		}
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: c.now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// dialContext returns the dial function of a transport connecting with
+// dialer to the cached addresses of the hosts, trying each in turn.
+func (c *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		addrs, err := c.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) == 0 {
+			return nil, &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		for _, ip := range addrs {
+			var conn net.Conn
+			if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
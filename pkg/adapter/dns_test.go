@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDNSCache(t *testing.T) {
+	lookups := 0
+	var lookupErr error
+	cache := newDNSCache(func(_ context.Context, host string) ([]string, error) {
+		lookups++
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		return []string{"10.0.0.1"}, nil
+	}, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	steps := []struct {
+		elapsed     time.Duration
+		err         error
+		wantLookups int
+		wantErr     bool
+	}{
+		{wantLookups: 1},
+		{elapsed: 30 * time.Second, wantLookups: 1},
+		{elapsed: 30 * time.Second, wantLookups: 2},
+		// The expired addresses are kept on failure.
+		{elapsed: time.Minute, err: errors.New("timeout"), wantLookups: 3},
+		{err: errors.New("timeout"), wantLookups: 4},
+	}
+	for i, step := range steps {
+		now = now.Add(step.elapsed)
+		lookupErr = step.err
+		addrs, err := cache.lookup(context.Background(), "sink.example.com")
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if len(addrs) != 1 || addrs[0] != "10.0.0.1" {
+			t.Errorf("step %d: unexpected addresses %v", i, addrs)
+		}
+		if lookups != step.wantLookups {
+			t.Errorf("step %d: got %d lookups, want %d", i, lookups, step.wantLookups)
+		}
+	}
+
+	if _, err := cache.lookup(context.Background(), "other.example.com"); err == nil {
+		t.Error("Expected the failed lookup of an uncached host to fail")
+	}
+}
+
+func TestDNSCacheDial(t *testing.T) {
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer sink.Close()
+	u, err := url.Parse(sink.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := newDNSCache(func(_ context.Context, host string) ([]string, error) {
+		if host != "sink.example.com" {
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		// The first address refuses connections.
+		return []string{"192.0.2.1", u.Hostname()}, nil
+	}, time.Minute)
+	transport := &http.Transport{DialContext: cache.dialContext(&net.Dialer{Timeout: 100 * time.Millisecond})}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get("http://sink.example.com:" + u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Unexpected status: got %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+}
+
+func TestNewSinkTransportTLSPolicy(t *testing.T) {
+	os.Setenv("TLS_MIN_VERSION", "1.3")
+	defer os.Unsetenv("TLS_MIN_VERSION")
+
+	base := &http.Transport{TLSClientConfig: &tls.Config{ServerName: "sink"}}
+	rt, err := NewSinkTransport(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		t.Fatalf("Unexpected transport: %T", rt)
+	}
+	if got := transport.TLSClientConfig; got.MinVersion != tls.VersionTLS13 || got.ServerName != "sink" {
+		t.Errorf("Unexpected TLS config: %+v", got)
+	}
+	if base.TLSClientConfig.MinVersion != 0 {
+		t.Error("The base transport was modified")
+	}
+
+	os.Setenv("TLS_MIN_VERSION", "1.0")
+	if _, err := NewSinkTransport(base); err == nil {
+		t.Error("Expected an error for an invalid TLS policy")
+	}
+}
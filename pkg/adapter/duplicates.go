@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+var (
+	// notificationM counts the notifications received, and duplicateM those
+	// RGW pushed again, per bucket, their ratio being the redelivery rate.
+	notificationM = stats.Int64(
+		"notification_count",
+		"Number of notifications received",
+		stats.UnitDimensionless,
+	)
+	duplicateM = stats.Int64(
+		"duplicate_notification_count",
+		"Number of notifications received again",
+		stats.UnitDimensionless,
+	)
+
+	bucketKey = tag.MustNewKey("bucket")
+
+	registerDuplicatesOnce sync.Once
+)
+
+func registerDuplicatesViews() error {
+	var err error
+	registerDuplicatesOnce.Do(func() {
+		for _, m := range []*stats.Int64Measure{notificationM, duplicateM} {
+			if err = view.Register(&view.View{
+				Description: m.Description(),
+				Measure:     m,
+				Aggregation: view.Count(),
+				TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey, bucketKey},
+			}); err != nil {
+				return
+			}
+		}
+	})
+	return err
+}
+
+// seenNotifications remembers the last notifications received, up to size,
+// to detect those RGW pushes again, e.g. when retrying a persistent topic,
+// and whether they were delivered. It is the "memory" DedupStore.
+type seenNotifications struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	keys  map[string]*list.Element
+}
+
+// seenNotification is a notification remembered by seenNotifications.
+type seenNotification struct {
+	key       string
+	delivered bool
+}
+
+func newSeenNotifications(size int) *seenNotifications {
+	return &seenNotifications{
+		size:  size,
+		order: list.New(),
+		keys:  make(map[string]*list.Element, size),
+	}
+}
+
+var _ DedupStore = (*seenNotifications)(nil)
+
+// Seen implements DedupStore.
+func (s *seenNotifications) Seen(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.keys[key]; ok {
+		s.order.MoveToFront(e)
+		return true, nil
+	}
+	s.add(key)
+	return false, nil
+}
+
+// add remembers a notification, forgetting the oldest one beyond size.
+func (s *seenNotifications) add(key string) *seenNotification {
+	n := &seenNotification{key: key}
+	s.keys[key] = s.order.PushFront(n)
+	if s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.keys, oldest.Value.(*seenNotification).key)
+	}
+	return n
+}
+
+// Delivered implements DedupStore.
+func (s *seenNotifications) Delivered(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.keys[key]
+	return ok && e.Value.(*seenNotification).delivered, nil
+}
+
+// MarkDelivered implements DedupStore.
+func (s *seenNotifications) MarkDelivered(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.keys[key]; ok {
+		e.Value.(*seenNotification).delivered = true
+		return nil
+	}
+	s.add(key).delivered = true
+	return nil
+}
+
+// notificationKey identifies a notification across pushes: by the sequencer
+// of the object change, else by the request it was made by.
+func notificationKey(notification ceph.BucketNotification) string {
+	id := notification.S3.Object.Sequencer
+	if id == "" {
+		id = notification.ResponseElements.XAmzRequestID + "." + notification.ResponseElements.XAmzID2
+	}
+	return strings.Join([]string{
+		notification.S3.Bucket.Name,
+		notification.S3.Object.Key,
+		notification.EventName,
+		id,
+	}, "\x00")
+}
+
+// recordNotification counts a notification received for its bucket, and
+// whether it is a duplicate.
+func (ca *cephReceiveAdapter) recordNotification(ctx context.Context, notification ceph.BucketNotification) {
+	ca.lifecycle.notificationReceived()
+	if ca.seen == nil {
+		return
+	}
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(bucketKey, notification.S3.Bucket.Name))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, notificationM.M(1))
+	seen, err := ca.seen.Seen(notificationKey(notification))
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to record notification", zap.Error(err))
+		return
+	}
+	if seen {
+		metrics.Record(ctx, duplicateM.M(1))
+	}
+}
+
+// alreadyDelivered reports whether a notification pushed again is to be
+// acknowledged without being processed, having been delivered already.
+func (ca *cephReceiveAdapter) alreadyDelivered(notification ceph.BucketNotification) bool {
+	if !ca.skipDuplicates || ca.seen == nil {
+		return false
+	}
+	delivered, err := ca.seen.Delivered(notificationKey(notification))
+	if err != nil {
+		ca.logger.Warnw("Failed to look up whether the notification was delivered, processing it", zap.Error(err))
+	}
+	return delivered
+}
+
+// delivered records that a notification was delivered, for it to be
+// acknowledged without side effects when RGW pushes it again, if duplicates
+// are skipped.
+func (ca *cephReceiveAdapter) delivered(notification ceph.BucketNotification) {
+	if !ca.skipDuplicates || ca.seen == nil {
+		return
+	}
+	if err := ca.seen.MarkDelivered(notificationKey(notification)); err != nil {
+		ca.logger.Warnw("Failed to record the delivery of the notification", zap.Error(err))
+	}
+}
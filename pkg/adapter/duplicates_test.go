@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+	"knative.dev/pkg/metrics"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+func pushedNotification(bucket, key, sequencer, requestID string) ceph.BucketNotification {
+	n := ceph.BucketNotification{EventName: "ObjectCreated:Put"}
+	n.S3.Bucket.Name = bucket
+	n.S3.Object.Key = key
+	n.S3.Object.Sequencer = sequencer
+	n.ResponseElements.XAmzRequestID = requestID
+	return n
+}
+
+func TestSeenNotifications(t *testing.T) {
+	s := newSeenNotifications(2)
+	steps := []struct {
+		notification ceph.BucketNotification
+		want         bool
+	}{
+		{pushedNotification("fish", "a", "1", "req-1"), false},
+		{pushedNotification("fish", "a", "1", "req-1"), true},
+		// Same request, another object change.
+		{pushedNotification("fish", "a", "2", "req-1"), false},
+		// Identified by the request ID without sequencer.
+		{pushedNotification("fish", "b", "", "req-2"), false},
+		{pushedNotification("fish", "b", "", "req-2"), true},
+		// Evicted by the last two.
+		{pushedNotification("fish", "a", "1", "req-1"), false},
+		{pushedNotification("chips", "a", "1", "req-1"), false},
+	}
+	for i, step := range steps {
+		got, err := s.Seen(notificationKey(step.notification))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != step.want {
+			t.Errorf("step %d: got duplicate %t, want %t", i, got, step.want)
+		}
+	}
+}
+
+func TestRecordNotification(t *testing.T) {
+	metrics.InitForTesting()
+	if err := registerDuplicatesViews(); err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{namespace: "duplicates-test", name: "source", seen: newSeenNotifications(10)}
+	for _, n := range []ceph.BucketNotification{
+		pushedNotification("fish", "a", "1", "req-1"),
+		pushedNotification("fish", "a", "1", "req-1"),
+		pushedNotification("fish", "a", "1", "req-1"),
+		pushedNotification("chips", "a", "1", "req-1"),
+	} {
+		ca.recordNotification(context.Background(), n)
+	}
+
+	counts := func(m string) map[string]int64 {
+		rows, err := view.RetrieveData(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := make(map[string]int64)
+		for _, row := range rows {
+			for _, tag := range row.Tags {
+				if tag.Key == bucketKey {
+					counts[tag.Value] = row.Data.(*view.CountData).Value
+				}
+			}
+		}
+		return counts
+	}
+	if got := counts(notificationM.Name()); got["fish"] != 3 || got["chips"] != 1 {
+		t.Errorf("Unexpected notification counts: %v", got)
+	}
+	if got := counts(duplicateM.Name()); got["fish"] != 2 || got["chips"] != 0 {
+		t.Errorf("Unexpected duplicate counts: %v", got)
+	}
+}
+
+func TestSkipDuplicates(t *testing.T) {
+	client := adaptertest.NewClient()
+	client.Enqueue(adaptertest.NACK(http.StatusServiceUnavailable))
+	ca := &cephReceiveAdapter{
+		logger:         zap.NewNop().Sugar(),
+		client:         client,
+		seen:           newSeenNotifications(10),
+		skipDuplicates: true,
+	}
+
+	// Failed, then pushed again and delivered, then acknowledged.
+	if err := ca.postMessage(context.Background(), notification1); err == nil {
+		t.Fatal("Expected the first delivery to fail")
+	}
+	for i := 0; i < 2; i++ {
+		if err := ca.postMessage(context.Background(), notification1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if attempted := client.Attempted(); len(attempted) != 2 {
+		t.Errorf("Unexpected number of events attempted: got %d, want 2", len(attempted))
+	}
+	if sent := client.Sent(); len(sent) != 1 {
+		t.Errorf("Unexpected number of events sent: got %d, want 1", len(sent))
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+var (
+	// emptyNotificationM counts the notifications holding no record.
+	emptyNotificationM = stats.Int64(
+		"empty_notification_count",
+		"Number of notifications holding no record",
+		stats.UnitDimensionless,
+	)
+
+	registerEmptyOnce sync.Once
+)
+
+func registerEmptyViews() error {
+	var err error
+	registerEmptyOnce.Do(func() {
+		err = view.Register(&view.View{
+			Description: emptyNotificationM.Description(),
+			Measure:     emptyNotificationM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey},
+		})
+	})
+	return err
+}
+
+// emptyNotification is the data of the events reporting a notification
+// holding no record.
+type emptyNotification struct {
+	RequestID string `json:"requestId,omitempty"`
+	Size      int    `json:"size"`
+}
+
+// handleEmpty applies the empty notifications policy to a notification of
+// the given body holding no record, returning the status to respond.
+func (ca *cephReceiveAdapter) handleEmpty(ctx context.Context, body []byte) (int, error) {
+	ca.recordEmpty(ctx)
+	logger := logging.FromContext(ctx)
+	switch ca.emptyPolicy {
+	case v1alpha1.EmptyNotificationsWarn:
+		logger.Warn("Received a notification holding no record, check the configuration of the topic")
+	case v1alpha1.EmptyNotificationsEvent:
+		id := requestIDFrom(ctx)
+		event := cloudevents.NewEvent()
+		if id != "" {
+			event.SetID(id)
+			event.SetExtension(requestIDExtension, id)
+		} else {
+			event.SetID(uuid.New().String())
+		}
+		event.SetType(v1alpha1.EmptyNotificationEventType)
+		event.SetSource(ca.sourceURI())
+		event.SetTime(time.Now())
+		if err := event.SetData(cloudevents.ApplicationJSON, emptyNotification{RequestID: id, Size: len(body)}); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if err := ca.forward(ctx, event); err != nil {
+			return ca.failureStatusCode(err), err
+		}
+	default:
+		logger.Debug("Ignoring a notification holding no record")
+	}
+	return http.StatusOK, nil
+}
+
+// recordEmpty records a notification holding no record.
+func (ca *cephReceiveAdapter) recordEmpty(ctx context.Context) {
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, emptyNotificationM.M(1))
+}
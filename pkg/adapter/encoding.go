@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+// encode converts the event to the CloudEvents version sent to the sink, and
+// returns the context selecting the content mode of its request.
+func (ca *cephReceiveAdapter) encode(ctx context.Context, event cloudevents.Event) (context.Context, cloudevents.Event) {
+	if ca.specVersion != "" && ca.specVersion != event.SpecVersion() {
+		// SetSpecVersion replaces the context of the event rather than
+		// modifying it, so the event of the caller is left as is.
+		event.SetSpecVersion(ca.specVersion)
+	}
+	switch ca.contentMode {
+	case v1alpha1.ContentModeStructured:
+		ctx = cloudevents.WithEncodingStructured(ctx)
+	case v1alpha1.ContentModeBinary:
+		ctx = cloudevents.WithEncodingBinary(ctx)
+	}
+	return ctx, event
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func TestEncoding(t *testing.T) {
+	testCases := map[string]struct {
+		specVersion string
+		contentMode string
+		structured  bool
+		want        string
+	}{
+		"defaults": {
+			want: cloudevents.VersionV1,
+		},
+		"binary 0.3": {
+			specVersion: v1alpha1.SpecVersionV03,
+			contentMode: v1alpha1.ContentModeBinary,
+			want:        cloudevents.VersionV03,
+		},
+		"structured 1.0": {
+			specVersion: v1alpha1.SpecVersionV1,
+			contentMode: v1alpha1.ContentModeStructured,
+			structured:  true,
+			want:        cloudevents.VersionV1,
+		},
+		"structured 0.3": {
+			specVersion: v1alpha1.SpecVersionV03,
+			contentMode: v1alpha1.ContentModeStructured,
+			structured:  true,
+			want:        cloudevents.VersionV03,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			requests := make(chan *http.Request, 1)
+			bodies := make(chan []byte, 1)
+			sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				requests <- r
+				bodies <- body
+			}))
+			defer sink.Close()
+
+			client, err := cloudevents.NewClientHTTP(cloudevents.WithTarget(sink.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ca := &cephReceiveAdapter{
+				logger:      zap.NewNop().Sugar(),
+				client:      client,
+				specVersion: tc.specVersion,
+				contentMode: tc.contentMode,
+			}
+
+			event := cloudevents.NewEvent()
+			event.SetID("1")
+			event.SetSource("ceph:s3.tenantA.fishbucket")
+			event.SetType("com.amazonaws.s3:ObjectCreated:Put")
+			if err := event.SetData(cloudevents.ApplicationJSON, map[string]string{"key": "fish9.jpg"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := ca.sendCloudEvent(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+			if event.SpecVersion() != cloudevents.VersionV1 {
+				t.Errorf("The event of the caller was converted to %s", event.SpecVersion())
+			}
+
+			r, body := <-requests, <-bodies
+			contentType := r.Header.Get("Content-Type")
+			if !tc.structured {
+				if contentType != cloudevents.ApplicationJSON {
+					t.Errorf("Unexpected content type: got %q, want %q", contentType, cloudevents.ApplicationJSON)
+				}
+				if got := r.Header.Get("Ce-Specversion"); got != tc.want {
+					t.Errorf("Unexpected spec version: got %q, want %q", got, tc.want)
+				}
+				return
+			}
+			if contentType != cloudevents.ApplicationCloudEventsJSON {
+				t.Errorf("Unexpected content type: got %q, want %q", contentType, cloudevents.ApplicationCloudEventsJSON)
+			}
+			var got struct {
+				SpecVersion string            `json:"specversion"`
+				Data        map[string]string `json:"data"`
+			}
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.SpecVersion != tc.want {
+				t.Errorf("Unexpected spec version: got %q, want %q", got.SpecVersion, tc.want)
+			}
+			if got.Data["key"] != "fish9.jpg" {
+				t.Errorf("Unexpected data: %v", got.Data)
+			}
+		})
+	}
+}
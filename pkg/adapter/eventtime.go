@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	// timeSkewExtension is the CloudEvent extension flagging events whose
+	// time is "future" or "past" beyond the tolerated times.
+	timeSkewExtension = "timeskew"
+
+	timeSkewFuture = "future"
+	timeSkewPast   = "past"
+)
+
+// eventTimeChecker applies the policy of the events whose time is beyond
+// maxFuture, or older than maxAge if not 0.
+type eventTimeChecker struct {
+	policy    string
+	maxFuture time.Duration
+	maxAge    time.Duration
+}
+
+// check applies the policy to an event received at now, returning an error
+// if the event is rejected.
+func (c *eventTimeChecker) check(event *cloudevents.Event, now time.Time) error {
+	eventTime := event.Time()
+	if eventTime.IsZero() {
+		return nil
+	}
+
+	var skew string
+	switch {
+	case eventTime.Sub(now) > c.maxFuture:
+		skew = timeSkewFuture
+	case c.maxAge > 0 && now.Sub(eventTime) > c.maxAge:
+		skew = timeSkewPast
+	default:
+		return nil
+	}
+
+	switch c.policy {
+	case v1alpha1.EventTimeReject:
+		return fmt.Errorf("event time %s is too far in the %s", eventTime.Format(time.RFC3339), skew)
+	case v1alpha1.EventTimeClamp:
+		event.SetTime(now)
+	}
+	event.SetExtension(timeSkewExtension, skew)
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func TestEventTimeChecker(t *testing.T) {
+	now := time.Date(2021, 11, 2, 12, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		policy    string
+		eventTime time.Time
+		wantErr   bool
+		wantTime  time.Time
+		wantSkew  interface{}
+	}{
+		"within tolerance": {
+			policy:    v1alpha1.EventTimeFlag,
+			eventTime: now.Add(30 * time.Second),
+			wantTime:  now.Add(30 * time.Second),
+		},
+		"flagged future": {
+			policy:    v1alpha1.EventTimeFlag,
+			eventTime: now.Add(time.Hour),
+			wantTime:  now.Add(time.Hour),
+			wantSkew:  timeSkewFuture,
+		},
+		"clamped past": {
+			policy:    v1alpha1.EventTimeClamp,
+			eventTime: now.Add(-48 * time.Hour),
+			wantTime:  now,
+			wantSkew:  timeSkewPast,
+		},
+		"rejected future": {
+			policy:    v1alpha1.EventTimeReject,
+			eventTime: now.Add(time.Hour),
+			wantErr:   true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			c := &eventTimeChecker{policy: tc.policy, maxFuture: time.Minute, maxAge: 24 * time.Hour}
+			event := cloudevents.NewEvent()
+			event.SetTime(tc.eventTime)
+
+			err := c.check(&event, now)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !event.Time().Equal(tc.wantTime) {
+				t.Errorf("Unexpected time: got %s, want %s", event.Time(), tc.wantTime)
+			}
+			if got := event.Extensions()[timeSkewExtension]; got != tc.wantSkew {
+				t.Errorf("Unexpected time skew: got %v, want %v", got, tc.wantSkew)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	defaultExpiryPreviewDays     = 7
+	defaultExpiryPreviewInterval = 24 * time.Hour
+
+	day = 24 * time.Hour
+)
+
+// lifecycleConfiguration is the lifecycle configuration of a bucket.
+type lifecycleConfiguration struct {
+	Rules []lifecycleRule `xml:"Rule"`
+}
+
+// lifecycleRule is a rule of a lifecycle configuration, of which only the
+// expiration of current versions is previewed.
+type lifecycleRule struct {
+	ID     string `xml:"ID"`
+	Status string `xml:"Status"`
+	// Prefix is the filter of the rules of the original schema.
+	Prefix string `xml:"Prefix"`
+	Filter *struct {
+		Prefix string    `xml:"Prefix"`
+		Tag    *struct{} `xml:"Tag"`
+		And    *struct {
+			Prefix string     `xml:"Prefix"`
+			Tags   []struct{} `xml:"Tag"`
+		} `xml:"And"`
+	} `xml:"Filter"`
+	Expiration *lifecycleExpiration `xml:"Expiration"`
+}
+
+// lifecycleExpiration is when a rule expires the current version of objects.
+type lifecycleExpiration struct {
+	Days int    `xml:"Days"`
+	Date string `xml:"Date"`
+}
+
+// prefix returns the key prefix of the objects the rule applies to, and
+// whether it can be evaluated from the listing of the objects, which rules
+// filtering on tags cannot.
+func (r lifecycleRule) prefix() (string, bool) {
+	f := r.Filter
+	switch {
+	case f == nil:
+		return r.Prefix, true
+	case f.Tag != nil:
+		return "", false
+	case f.And != nil:
+		return f.And.Prefix, len(f.And.Tags) == 0
+	default:
+		return f.Prefix, true
+	}
+}
+
+// expiration returns when the rule expires an object last modified at the
+// given time: the date of the rule, or its number of days after the
+// modification rounded up to the next midnight UTC, as S3 does.
+func (r lifecycleRule) expiration(lastModified time.Time) (time.Time, bool) {
+	e := r.Expiration
+	switch {
+	case e == nil:
+		return time.Time{}, false
+	case e.Date != "":
+		t, err := time.Parse(time.RFC3339, e.Date)
+		return t, err == nil
+	case e.Days > 0:
+		t := lastModified.UTC().Add(time.Duration(e.Days) * day)
+		if midnight := t.Truncate(day); midnight.Before(t) {
+			t = midnight.Add(day)
+		}
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// listBucketResult is a page of the listing of the objects of a bucket.
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// expiringObject is the data of the events previewing the expiration of an
+// object.
+type expiringObject struct {
+	Bucket       string    `json:"bucket"`
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"eTag,omitempty"`
+	LastModified time.Time `json:"lastModified"`
+	Expires      time.Time `json:"expires"`
+	Rule         string    `json:"rule,omitempty"`
+}
+
+// expiryPreviewer previews the objects of buckets due to be expired by their
+// lifecycle rules.
+type expiryPreviewer struct {
+	rgw      *rgwClient
+	buckets  []string
+	ahead    time.Duration
+	interval time.Duration
+}
+
+func newExpiryPreviewer(endpoint, region, accessKeyID, secretAccessKey string, buckets []string, days int, interval time.Duration) (*expiryPreviewer, error) {
+	rgw, err := newRGWClient(endpoint, region, accessKeyID, secretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	if days <= 0 {
+		days = defaultExpiryPreviewDays
+	}
+	if interval <= 0 {
+		interval = defaultExpiryPreviewInterval
+	}
+	return &expiryPreviewer{
+		rgw:      rgw,
+		buckets:  buckets,
+		ahead:    time.Duration(days) * day,
+		interval: interval,
+	}, nil
+}
+
+// expiring returns the objects of a bucket expiring after from and until
+// to, at the earliest expiration of the enabled rules applying to them.
+func (p *expiryPreviewer) expiring(ctx context.Context, bucket string, from, to time.Time) ([]expiringObject, error) {
+	body, err := p.rgw.get(ctx, "/"+bucket, url.Values{"lifecycle": {""}})
+	if err != nil {
+		return nil, err
+	}
+	var config lifecycleConfiguration
+	if err := xml.Unmarshal(body, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode the lifecycle configuration of %s: %w", bucket, err)
+	}
+
+	expiring := make(map[string]expiringObject)
+	for _, rule := range config.Rules {
+		prefix, ok := rule.prefix()
+		if !ok || rule.Status != "Enabled" || rule.Expiration == nil {
+			continue
+		}
+		if err := p.list(ctx, bucket, prefix, func(object expiringObject) {
+			expires, ok := rule.expiration(object.LastModified)
+			if !ok || !expires.After(from) || expires.After(to) {
+				return
+			}
+			if e, ok := expiring[object.Key]; ok && !expires.Before(e.Expires) {
+				return
+			}
+			object.Expires, object.Rule = expires, rule.ID
+			expiring[object.Key] = object
+		}); err != nil {
+			return nil, err
+		}
+	}
+	objects := make([]expiringObject, 0, len(expiring))
+	for _, object := range expiring {
+		objects = append(objects, object)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// list calls f with the objects of a bucket with the given key prefix.
+func (p *expiryPreviewer) list(ctx context.Context, bucket, prefix string, f func(expiringObject)) error {
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		body, err := p.rgw.get(ctx, "/"+bucket, query)
+		if err != nil {
+			return err
+		}
+		var page listBucketResult
+		if err := xml.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("failed to decode the objects of %s: %w", bucket, err)
+		}
+		for _, c := range page.Contents {
+			f(expiringObject{
+				Bucket:       bucket,
+				Key:          c.Key,
+				Size:         c.Size,
+				ETag:         trimETag(c.ETag),
+				LastModified: c.LastModified,
+			})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+
+// previewExpiry previews, every interval until ctx is done, the objects
+// whose expiration entered the preview window since the last inspection.
+func (ca *cephReceiveAdapter) previewExpiry(ctx context.Context) {
+	p := ca.expiryPreview
+	last := time.Now().Add(-p.interval)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		if err := ca.reportExpiring(ctx, last.Add(p.ahead), now.Add(p.ahead)); err != nil {
+			logging.FromContext(ctx).Warnw("Failed to preview the expiration of objects", zap.Error(err))
+		} else {
+			last = now
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reportExpiring sends an event per object expiring after from and until
+// to. Their IDs identify the expiration of the object, for the consumers to
+// tell those reported again.
+func (ca *cephReceiveAdapter) reportExpiring(ctx context.Context, from, to time.Time) error {
+	for _, bucket := range ca.expiryPreview.buckets {
+		objects, err := ca.expiryPreview.expiring(ctx, bucket, from, to)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			event := cloudevents.NewEvent()
+			event.SetID(fmt.Sprintf("%s/%s/%d", object.Bucket, object.Key, object.Expires.Unix()))
+			event.SetType(v1alpha1.ExpiryPreviewEventType)
+			event.SetSource(ca.sourceURI())
+			event.SetSubject(object.Key)
+			event.SetTime(time.Now())
+			if err := event.SetData(cloudevents.ApplicationJSON, object); err != nil {
+				return err
+			}
+			if err := ca.forward(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
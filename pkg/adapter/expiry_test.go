@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const lifecycle = `<LifecycleConfiguration>
+  <Rule>
+    <ID>logs</ID>
+    <Status>Enabled</Status>
+    <Filter><Prefix>logs/</Prefix></Filter>
+    <Expiration><Days>30</Days></Expiration>
+  </Rule>
+  <Rule>
+    <ID>tagged</ID>
+    <Status>Enabled</Status>
+    <Filter><Tag><Key>temp</Key><Value>true</Value></Tag></Filter>
+    <Expiration><Days>1</Days></Expiration>
+  </Rule>
+  <Rule>
+    <ID>disabled</ID>
+    <Status>Disabled</Status>
+    <Prefix></Prefix>
+    <Expiration><Days>1</Days></Expiration>
+  </Rule>
+</LifecycleConfiguration>`
+
+const (
+	objectsPage1 = `<ListBucketResult>
+  <Contents><Key>logs/a.log</Key><LastModified>2021-05-01T10:00:00.000Z</LastModified><ETag>"a"</ETag><Size>10</Size></Contents>
+  <IsTruncated>true</IsTruncated>
+  <NextContinuationToken>page2</NextContinuationToken>
+</ListBucketResult>`
+	objectsPage2 = `<ListBucketResult>
+  <Contents><Key>logs/b.log</Key><LastModified>2021-05-05T10:00:00.000Z</LastModified><ETag>"b"</ETag><Size>20</Size></Contents>
+  <IsTruncated>false</IsTruncated>
+</ListBucketResult>`
+)
+
+func TestLifecycleRuleExpiration(t *testing.T) {
+	lastModified := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	days := lifecycleRule{Expiration: &lifecycleExpiration{Days: 30}}
+	got, ok := days.expiration(lastModified)
+	if want := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("Unexpected expiration after days: got %s, want %s", got, want)
+	}
+
+	date := lifecycleRule{Expiration: &lifecycleExpiration{Date: "2021-07-01T00:00:00Z"}}
+	got, ok = date.expiration(lastModified)
+	if want := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("Unexpected expiration at date: got %s, want %s", got, want)
+	}
+}
+
+func TestReportExpiring(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		_, lifecycleRequest := query["lifecycle"]
+		switch {
+		case r.URL.Path != "/logbucket":
+			t.Errorf("Unexpected request: %s", r.URL)
+		case lifecycleRequest:
+			w.Write([]byte(lifecycle))
+		case query.Get("prefix") != "logs/":
+			t.Errorf("Unexpected listing: %s", r.URL)
+		case query.Get("continuation-token") == "page2":
+			w.Write([]byte(objectsPage2))
+		default:
+			w.Write([]byte(objectsPage1))
+		}
+	}))
+	defer srv.Close()
+
+	preview, err := newExpiryPreviewer(srv.URL, "", "access", "secret", []string{"logbucket"}, 7, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), client: client, expiryPreview: preview}
+
+	// Previewed a week ahead on May 25th, logs/a.log expires on June 1st
+	// and logs/b.log on June 5th.
+	now := time.Date(2021, 5, 25, 12, 0, 0, 0, time.UTC)
+	if err := ca.reportExpiring(context.Background(), now.Add(preview.ahead-preview.interval), now.Add(preview.ahead)); err != nil {
+		t.Fatal(err)
+	}
+	sent := client.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("Unexpected number of events sent: got %d, want 1", len(sent))
+	}
+	event := sent[0]
+	if event.Type() != v1alpha1.ExpiryPreviewEventType || event.Subject() != "logs/a.log" {
+		t.Errorf("Unexpected event: %s", event)
+	}
+	var object expiringObject
+	if err := event.DataAs(&object); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC); !object.Expires.Equal(want) || object.Rule != "logs" || object.ETag != "a" {
+		t.Errorf("Unexpected expiring object: %+v", object)
+	}
+}
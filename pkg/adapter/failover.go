@@ -0,0 +1,171 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+var (
+	// failoverM counts the events delivered to a failover sink, by index
+	// of the sink in the chain, the sink of the source being 0.
+	failoverM = stats.Int64(
+		"sink_failover_count",
+		"Number of events delivered to a failover sink",
+		stats.UnitDimensionless,
+	)
+
+	sinkIndexKey = tag.MustNewKey("sink_index")
+
+	registerFailoverOnce sync.Once
+)
+
+func registerFailoverViews() error {
+	var err error
+	registerFailoverOnce.Do(func() {
+		err = view.Register(&view.View{
+			Description: failoverM.Description(),
+			Measure:     failoverM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey, sinkIndexKey},
+		})
+	})
+	return err
+}
+
+// sinkFailover tracks the health of a chain of sinks, the first one being
+// the sink of the source, for the events to fail over along it while sinks
+// are down.
+type sinkFailover struct {
+	// sinks are the targets of the chain, empty for the sink of the source.
+	sinks    []string
+	cooldown time.Duration
+
+	mu sync.Mutex
+	// downUntil is until when each sink is skipped, zero while up.
+	downUntil []time.Time
+}
+
+func newSinkFailover(sinks []string, cooldown time.Duration) *sinkFailover {
+	return &sinkFailover{
+		sinks:     append([]string{""}, sinks...),
+		cooldown:  cooldown,
+		downUntil: make([]time.Time, len(sinks)+1),
+	}
+}
+
+// candidates returns the indexes of the sinks to try at now, in order: the
+// ones up or whose cooldown is over, or all of them if all are down.
+func (f *sinkFailover) candidates(now time.Time) []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var up, all []int
+	for i, until := range f.downUntil {
+		all = append(all, i)
+		if !now.Before(until) {
+			up = append(up, i)
+		}
+	}
+	if len(up) == 0 {
+		return all
+	}
+	return up
+}
+
+// markDown deems a sink down from now, returning whether it was up.
+func (f *sinkFailover) markDown(i int, now time.Time) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	wasUp := f.downUntil[i].IsZero()
+	f.downUntil[i] = now.Add(f.cooldown)
+	return wasUp
+}
+
+// markUp deems a sink up, returning whether it was down.
+func (f *sinkFailover) markUp(i int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	wasDown := !f.downUntil[i].IsZero()
+	f.downUntil[i] = time.Time{}
+	return wasDown
+}
+
+// sinkDown returns whether the failure to send an event hints that the sink
+// is down, rather than rejecting the event.
+func sinkDown(err error) bool {
+	code := sinkStatusCode(err)
+	return code == 0 || code >= 500
+}
+
+// send sends an event to the sink, failing over along the chain of sinks if
+// any, unless a route targets it elsewhere.
+func (ca *cephReceiveAdapter) send(ctx context.Context, event cloudevents.Event) protocol.Result {
+	if ca.failover == nil || cloudevents.TargetFromContext(ctx) != nil {
+		return ca.client.Send(ctx, event)
+	}
+	logger := logging.FromContext(ctx).With(zap.String("id", event.ID()))
+	var result protocol.Result
+	for _, i := range ca.failover.candidates(time.Now()) {
+		sctx := ctx
+		if sink := ca.failover.sinks[i]; sink != "" {
+			sctx = cloudevents.ContextWithTarget(ctx, sink)
+		}
+		result = ca.client.Send(sctx, event)
+		if cloudevents.IsACK(result) {
+			if ca.failover.markUp(i) {
+				logger.Infow("Sink recovered", zap.Int("sink", i))
+			}
+			if i > 0 {
+				ca.recordFailover(ctx, i)
+			}
+			return result
+		}
+		if ctx.Err() != nil || !sinkDown(result) {
+			return result
+		}
+		if ca.failover.markDown(i, time.Now()) {
+			logger.Warnw("Sink down, failing over to the next one", zap.Int("sink", i), zap.Duration("cooldown", ca.failover.cooldown), zap.Error(result))
+		}
+	}
+	return result
+}
+
+// recordFailover records the delivery of an event to the failover sink of
+// the given index.
+func (ca *cephReceiveAdapter) recordFailover(ctx context.Context, i int) {
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(sinkIndexKey, strconv.Itoa(i)))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, failoverM.M(1))
+}
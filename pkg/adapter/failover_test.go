@@ -0,0 +1,162 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"go.uber.org/zap"
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+)
+
+func TestSinkFailoverCandidates(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	f := newSinkFailover([]string{"http://backup-1", "http://backup-2"}, time.Minute)
+	if got := f.candidates(now); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Errorf("Unexpected candidates: %v", got)
+	}
+
+	if !f.markDown(0, now) {
+		t.Error("The sink should have been up")
+	}
+	if f.markDown(0, now) {
+		t.Error("The sink should have been down already")
+	}
+	if got := f.candidates(now.Add(30 * time.Second)); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("Unexpected candidates during the cooldown: %v", got)
+	}
+	if got := f.candidates(now.Add(time.Minute)); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Errorf("Unexpected candidates after the cooldown: %v", got)
+	}
+
+	f.markDown(1, now)
+	f.markDown(2, now)
+	if got := f.candidates(now); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Errorf("All the sinks should be tried while all are down: %v", got)
+	}
+	if !f.markUp(0) || f.markUp(0) {
+		t.Error("The sink should have been down then up")
+	}
+}
+
+// targetResponder responds to the sends with the status codes of their
+// target, empty for the sink of the source, acknowledging them if unset.
+func targetResponder(codes map[string]int) adaptertest.Responder {
+	return func(ctx context.Context, _ cloudevents.Event) protocol.Result {
+		var target string
+		if u := cloudevents.TargetFromContext(ctx); u != nil {
+			target = u.String()
+		}
+		if code, ok := codes[target]; ok {
+			return adaptertest.NACK(code)
+		}
+		return adaptertest.ACK()
+	}
+}
+
+func TestSendFailover(t *testing.T) {
+	testCases := map[string]struct {
+		codes        map[string]int
+		target       string
+		wantErr      bool
+		wantAttempts []int
+	}{
+		"sink up": {
+			wantAttempts: []int{1, 1},
+		},
+		"sink down": {
+			codes:        map[string]int{"": http.StatusServiceUnavailable},
+			wantAttempts: []int{2, 1},
+		},
+		"sink and first failover down": {
+			codes:        map[string]int{"": http.StatusBadGateway, "http://backup-1": http.StatusServiceUnavailable},
+			wantAttempts: []int{3, 1},
+		},
+		"all down": {
+			codes: map[string]int{
+				"":                http.StatusServiceUnavailable,
+				"http://backup-1": http.StatusServiceUnavailable,
+				"http://backup-2": http.StatusServiceUnavailable,
+			},
+			wantErr:      true,
+			wantAttempts: []int{3, 3},
+		},
+		"rejected": {
+			codes:        map[string]int{"": http.StatusBadRequest},
+			wantErr:      true,
+			wantAttempts: []int{1, 1},
+		},
+		"routed": {
+			codes:        map[string]int{"http://route": http.StatusServiceUnavailable},
+			target:       "http://route",
+			wantErr:      true,
+			wantAttempts: []int{1, 1},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			client := adaptertest.NewClient()
+			client.RespondWith(targetResponder(tc.codes))
+			ca := &cephReceiveAdapter{
+				logger:   zap.NewNop().Sugar(),
+				client:   client,
+				failover: newSinkFailover([]string{"http://backup-1", "http://backup-2"}, time.Minute),
+			}
+			ctx := context.Background()
+			if tc.target != "" {
+				ctx = cloudevents.ContextWithTarget(ctx, tc.target)
+			}
+
+			// The second event skips the sinks found down by the first.
+			for i, want := range tc.wantAttempts {
+				client.Reset()
+				result := ca.send(ctx, testEvent("1"))
+				if cloudevents.IsACK(result) == tc.wantErr {
+					t.Errorf("Unexpected result of event %d: %v", i, result)
+				}
+				if got := len(client.Attempted()); got != want {
+					t.Errorf("Unexpected number of attempts of event %d: got %d, want %d", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewAdapterFailoverSinks(t *testing.T) {
+	env := &envConfig{
+		EnvConfig:     adapter.EnvConfig{Namespace: "default"},
+		Port:          "28080",
+		FailoverSinks: `["http://backup-1?tags=a,b","http://backup-2"]`,
+	}
+	ctx := logging.WithLogger(context.Background(), zap.NewNop().Sugar())
+	ca := NewAdapter(ctx, env, adaptertest.NewClient()).(*cephReceiveAdapter)
+
+	// A comma of the query of a sink does not split it.
+	want := []string{"", "http://backup-1?tags=a,b", "http://backup-2"}
+	if ca.failover == nil || !reflect.DeepEqual(ca.failover.sinks, want) {
+		t.Errorf("Unexpected failover sinks: %+v", ca.failover)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+)
+
+// rawRecords reports whether no feature processes the records of the
+// notifications, so that they are converted with their JSON as pushed.
+func (ca *cephReceiveAdapter) rawRecords() bool {
+	return (ca.format == "" || ca.format == ceph2ce.FormatCeph) &&
+		ca.converter.Raw() &&
+		len(ca.filters) == 0 &&
+		ca.senders == nil &&
+		ca.verifier == nil &&
+		ca.rates == nil &&
+		ca.shedder == nil &&
+		ca.eventTime == nil &&
+		len(ca.maintenance) == 0 &&
+		len(ca.hooks) == 0
+}
+
+// handleRaw is the fast path of handleMessage, converting the records of a
+// Ceph notification without marshaling them again nor a logger per record.
+func (ca *cephReceiveAdapter) handleRaw(ctx context.Context, body []byte) (int, error) {
+	logger := logging.FromContext(ctx)
+	records, err := ceph2ce.ParseRawNotifications(body)
+	if err != nil {
+		logger.Infof("Failed to parse JSON: %s", err.Error())
+		return http.StatusBadRequest, err
+	}
+	logger.Debugw("Received bucket notifications", zap.Int("records", len(records)))
+	if len(records) == 0 {
+		return ca.handleEmpty(ctx, body)
+	}
+
+	converter := ca.converter
+	converter.Logger = logger
+	id := requestIDFrom(ctx)
+	for _, record := range records {
+		ca.recordNotification(ctx, record.BucketNotification)
+		ca.observeAge(ctx, record.BucketNotification, time.Now())
+		ca.countTraffic(record.BucketNotification)
+		if ca.alreadyDelivered(record.BucketNotification) {
+			logger.Debug("Skipping notification already delivered")
+			continue
+		}
+		if ca.redelivered(ctx, record.BucketNotification) {
+			logger.Debug("Skipping notification older than its checkpoint")
+			continue
+		}
+		event, err := converter.ToCloudEventRaw(record)
+		if err != nil {
+			return ca.failureStatusCode(err), err
+		}
+		if id != "" {
+			event.SetExtension(requestIDExtension, id)
+		}
+		if err := ca.forward(withSpoolRecord(ctx, record.BucketNotification), event); err != nil {
+			return ca.failureStatusCode(err), err
+		}
+		ca.checkpoint(record.BucketNotification)
+		ca.delivered(record.BucketNotification)
+	}
+	return http.StatusOK, nil
+}
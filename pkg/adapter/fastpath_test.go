@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+)
+
+func TestRawRecords(t *testing.T) {
+	testCases := map[string]struct {
+		ca   *cephReceiveAdapter
+		want bool
+	}{
+		"defaults": {
+			ca:   &cephReceiveAdapter{},
+			want: true,
+		},
+		"bucket sources": {
+			ca:   &cephReceiveAdapter{converter: ceph2ce.Converter{Sources: map[string]string{"fish": "fish"}}},
+			want: true,
+		},
+		"minio format": {
+			ca: &cephReceiveAdapter{format: ceph2ce.FormatMinIO},
+		},
+		"decoded keys": {
+			ca: &cephReceiveAdapter{converter: ceph2ce.Converter{DecodeKeys: true}},
+		},
+		"filters": {
+			ca: &cephReceiveAdapter{filters: makeFilters(v1alpha1.CephSourceFilter{EventNames: []string{"s3:ObjectCreated:*"}})},
+		},
+		"rate limits": {
+			ca: &cephReceiveAdapter{rates: &bucketLimiter{}},
+		},
+		"load shedding": {
+			ca: &cephReceiveAdapter{shedder: newLoadShedder(time.Second, time.Minute, nil)},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if got := tc.ca.rawRecords(); got != tc.want {
+				t.Errorf("rawRecords() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandleRaw(t *testing.T) {
+	body, err := json.Marshal(jsonData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func(raw bool) []byte {
+		client := adaptertest.NewClient()
+		ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), client: client, raw: raw}
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		r.Header.Set(requestIDHeader, "rgw-push-1")
+		w := httptest.NewRecorder()
+		serveNotification(t, ca, w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Unexpected status: %d", w.Code)
+		}
+		sent := client.Sent()
+		if len(sent) != 1 {
+			t.Fatalf("Unexpected number of events sent: %d", len(sent))
+		}
+		event, err := json.Marshal(sent[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return event
+	}
+
+	var want, got map[string]interface{}
+	if err := json.Unmarshal(send(false), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(send(true), &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected event of the fast path (-want, +got): %s", diff)
+	}
+}
+
+func BenchmarkHandleNotification(b *testing.B) {
+	body, err := json.Marshal(jsonData)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, raw := range []bool{false, true} {
+		name := "processed"
+		if raw {
+			name = "raw"
+		}
+		b.Run(name, func(b *testing.B) {
+			client := adaptertest.NewClient()
+			ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), client: client, raw: raw}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			h, err := ca.notificationHandler(ctx)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+				if w.Code != http.StatusOK {
+					b.Fatalf("Unexpected status: %d", w.Code)
+				}
+				client.Reset()
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+)
+
+// makeFilters returns the filters selecting the notifications sent to the
+// sink, per the filter of the CephSource.
+func makeFilters(f v1alpha1.CephSourceFilter) []ceph2ce.Filter {
+	var filters []ceph2ce.Filter
+	if len(f.EventNames) > 0 {
+		filters = append(filters, ceph2ce.EventNames(f.EventNames...))
+	}
+	if f.Versioned != nil {
+		if *f.Versioned {
+			filters = append(filters, ceph2ce.Versioned)
+		} else {
+			filters = append(filters, ceph2ce.Not(ceph2ce.Versioned))
+		}
+	}
+	switch f.DeleteMarkers {
+	case v1alpha1.DeleteMarkersExclude:
+		filters = append(filters, ceph2ce.Not(ceph2ce.IsDeleteMarker))
+	case v1alpha1.DeleteMarkersOnly:
+		filters = append(filters, ceph2ce.IsDeleteMarker)
+	}
+	switch f.Encryption {
+	case v1alpha1.EncryptionEncrypted:
+		filters = append(filters, ceph2ce.Encrypted)
+	case v1alpha1.EncryptionUnencrypted:
+		filters = append(filters, ceph2ce.Unencrypted)
+	}
+	if len(f.StorageClasses) > 0 {
+		filters = append(filters, ceph2ce.StorageClasses(f.StorageClasses...))
+	}
+	return filters
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"testing"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+)
+
+func TestMakeFilters(t *testing.T) {
+	yes, no := true, false
+	created := notification1
+	created.S3.Object.Encryption = &ceph.EncryptionSpec{Type: ceph2ce.EncryptionSSES3}
+	created.S3.Object.StorageClass = "GLACIER"
+	deleteMarker := notification1
+	deleteMarker.EventName = "s3:ObjectRemoved:DeleteMarkerCreated"
+	deleteMarker.S3.Object.VersionID = "RZmvPzs4EWyD4AGmUw-2akPe5bBRQE5"
+	deleteMarker.S3.Object.Encryption = &ceph.EncryptionSpec{Type: ceph2ce.EncryptionNone}
+
+	testCases := map[string]struct {
+		filter      v1alpha1.CephSourceFilter
+		wantCreated bool
+		wantMarker  bool
+	}{
+		"no filter": {
+			wantCreated: true,
+			wantMarker:  true,
+		},
+		"event names": {
+			filter:      v1alpha1.CephSourceFilter{EventNames: []string{"s3:ObjectCreated:*"}},
+			wantCreated: true,
+		},
+		"versioned": {
+			filter:     v1alpha1.CephSourceFilter{Versioned: &yes},
+			wantMarker: true,
+		},
+		"unversioned": {
+			filter:      v1alpha1.CephSourceFilter{Versioned: &no},
+			wantCreated: true,
+		},
+		"exclude delete markers": {
+			filter:      v1alpha1.CephSourceFilter{DeleteMarkers: v1alpha1.DeleteMarkersExclude},
+			wantCreated: true,
+		},
+		"only delete markers": {
+			filter:     v1alpha1.CephSourceFilter{DeleteMarkers: v1alpha1.DeleteMarkersOnly},
+			wantMarker: true,
+		},
+		"encrypted": {
+			filter:      v1alpha1.CephSourceFilter{Encryption: v1alpha1.EncryptionEncrypted},
+			wantCreated: true,
+		},
+		"unencrypted": {
+			filter:     v1alpha1.CephSourceFilter{Encryption: v1alpha1.EncryptionUnencrypted},
+			wantMarker: true,
+		},
+		"storage classes": {
+			filter:      v1alpha1.CephSourceFilter{StorageClasses: []string{"GLACIER"}},
+			wantCreated: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			filters := makeFilters(tc.filter)
+			if got := ceph2ce.Match(created, filters...); got != tc.wantCreated {
+				t.Errorf("Unexpected match of created object: got %v, want %v", got, tc.wantCreated)
+			}
+			if got := ceph2ce.Match(deleteMarker, filters...); got != tc.wantMarker {
+				t.Errorf("Unexpected match of delete marker: got %v, want %v", got, tc.wantMarker)
+			}
+		})
+	}
+}
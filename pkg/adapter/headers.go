@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+// sinkHeaders returns the headers added to the requests to the sink, reading
+// the value of the i-th header from the SINK_HEADER_<i> environment variable.
+func sinkHeaders(names []string) http.Header {
+	if len(names) == 0 {
+		return nil
+	}
+	header := make(http.Header, len(names))
+	for i, name := range names {
+		header.Set(name, os.Getenv(fmt.Sprintf("SINK_HEADER_%d", i)))
+	}
+	return header
+}
+
+// withSinkHeaders returns the context adding the sink headers to the request
+// of an event.
+func (ca *cephReceiveAdapter) withSinkHeaders(ctx context.Context) context.Context {
+	if ca.sinkHeaders == nil {
+		return ctx
+	}
+	// The HTTP protocol uses the header of the context as the header of the
+	// request, and sets the attributes of the event in it.
+	header := cehttp.HeaderFrom(ctx).Clone()
+	for name, values := range ca.sinkHeaders {
+		header[name] = values
+	}
+	return cehttp.WithCustomHeader(ctx, header)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+)
+
+func TestSinkHeaders(t *testing.T) {
+	os.Setenv("SINK_HEADER_0", "s3cr3t")
+	os.Setenv("SINK_HEADER_1", "tenant-a")
+	defer os.Unsetenv("SINK_HEADER_0")
+	defer os.Unsetenv("SINK_HEADER_1")
+
+	headers := make(chan http.Header, 2)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+	}))
+	defer sink.Close()
+
+	client, err := cloudevents.NewClientHTTP(cloudevents.WithTarget(sink.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{
+		logger:      zap.NewNop().Sugar(),
+		client:      client,
+		sinkHeaders: sinkHeaders([]string{"X-Api-Key", "x-tenant"}),
+	}
+
+	for i := 0; i < 2; i++ {
+		event := cloudevents.NewEvent()
+		event.SetID(strconv.Itoa(i))
+		event.SetSource("ceph:s3.tenantA.fishbucket")
+		event.SetType("com.amazonaws.s3:ObjectCreated:Put")
+		if err := ca.sendCloudEvent(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+
+		header := <-headers
+		if got := header.Get("X-Api-Key"); got != "s3cr3t" {
+			t.Errorf("Unexpected X-Api-Key header: got %q, want %q", got, "s3cr3t")
+		}
+		if got := header.Get("X-Tenant"); got != "tenant-a" {
+			t.Errorf("Unexpected X-Tenant header: got %q, want %q", got, "tenant-a")
+		}
+		if got := header.Values("Ce-Id"); len(got) != 1 || got[0] != event.ID() {
+			t.Errorf("Unexpected Ce-Id header: got %q, want %q", got, event.ID())
+		}
+	}
+	if len(ca.sinkHeaders) != 2 {
+		t.Errorf("The sink headers were modified: %v", ca.sinkHeaders)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+// Hook is called by the adapter along the handling of the records, for
+// downstream builds to add behaviors, e.g. tenant lookups or billing
+// counters, without patching the adapter. Hooks are passed to
+// NewAdapterWithHooks, and embed NopHook to implement only some of the
+// methods. They are called concurrently for the records of different
+// notifications.
+type Hook interface {
+	// OnReceive is called with each record received, before it is
+	// deduplicated, filtered and converted, returning the context its
+	// handling continues with. An error fails the notification.
+	OnReceive(ctx context.Context, record ceph.BucketNotification) (context.Context, error)
+
+	// OnConvert is called with the event converted from a record, before
+	// it is forwarded, and may modify it. An error fails the notification.
+	OnConvert(ctx context.Context, record ceph.BucketNotification, event *cloudevents.Event) error
+
+	// OnBeforeSend is called before each send of an event to the sink,
+	// including the re-drives and retries, and may modify it. An error
+	// fails the send.
+	OnBeforeSend(ctx context.Context, event *cloudevents.Event) error
+}
+
+// NopHook implements the methods of Hook doing nothing.
+type NopHook struct{}
+
+var _ Hook = NopHook{}
+
+// OnReceive implements Hook.
+func (NopHook) OnReceive(ctx context.Context, _ ceph.BucketNotification) (context.Context, error) {
+	return ctx, nil
+}
+
+// OnConvert implements Hook.
+func (NopHook) OnConvert(context.Context, ceph.BucketNotification, *cloudevents.Event) error {
+	return nil
+}
+
+// OnBeforeSend implements Hook.
+func (NopHook) OnBeforeSend(context.Context, *cloudevents.Event) error {
+	return nil
+}
+
+// onReceive calls the OnReceive hooks, in order.
+func (ca *cephReceiveAdapter) onReceive(ctx context.Context, record ceph.BucketNotification) (context.Context, error) {
+	for _, h := range ca.hooks {
+		var err error
+		if ctx, err = h.OnReceive(ctx, record); err != nil {
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+// onConvert calls the OnConvert hooks, in order.
+func (ca *cephReceiveAdapter) onConvert(ctx context.Context, record ceph.BucketNotification, event *cloudevents.Event) error {
+	for _, h := range ca.hooks {
+		if err := h.OnConvert(ctx, record, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onBeforeSend calls the OnBeforeSend hooks, in order.
+func (ca *cephReceiveAdapter) onBeforeSend(ctx context.Context, event *cloudevents.Event) error {
+	for _, h := range ca.hooks {
+		if err := h.OnBeforeSend(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
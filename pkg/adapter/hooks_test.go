@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+type tenantKey struct{}
+
+// tenantHook looks the tenant of the buckets up, and sets it in an
+// extension of their events.
+type tenantHook struct {
+	NopHook
+	tenants map[string]string
+	sent    int
+}
+
+func (h *tenantHook) OnReceive(ctx context.Context, record ceph.BucketNotification) (context.Context, error) {
+	tenant, ok := h.tenants[record.S3.Bucket.Name]
+	if !ok {
+		return ctx, errors.New("unknown tenant")
+	}
+	return context.WithValue(ctx, tenantKey{}, tenant), nil
+}
+
+func (h *tenantHook) OnConvert(ctx context.Context, _ ceph.BucketNotification, event *cloudevents.Event) error {
+	event.SetExtension("tenant", ctx.Value(tenantKey{}))
+	return nil
+}
+
+func (h *tenantHook) OnBeforeSend(context.Context, *cloudevents.Event) error {
+	h.sent++
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	hook := &tenantHook{tenants: map[string]string{notification1.S3.Bucket.Name: "team-a"}}
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), client: client, hooks: []Hook{hook}}
+	if ca.rawRecords() {
+		t.Error("The records of an adapter with hooks cannot take the fast path")
+	}
+
+	if err := ca.postMessage(context.Background(), notification1); err != nil {
+		t.Fatal(err)
+	}
+	sent := client.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("Unexpected number of events sent: %d", len(sent))
+	}
+	if tenant := sent[0].Extensions()["tenant"]; tenant != "team-a" {
+		t.Errorf("Unexpected tenant: %v", tenant)
+	}
+	if hook.sent != 1 {
+		t.Errorf("Unexpected number of sends seen by the hook: %d", hook.sent)
+	}
+
+	unknown := notification1
+	unknown.S3.Bucket.Name = "otherbucket"
+	if err := ca.postMessage(context.Background(), unknown); err == nil {
+		t.Error("Expected the hook to fail the notification")
+	}
+	if sent := client.Sent(); len(sent) != 1 {
+		t.Errorf("Unexpected number of events sent: %d", len(sent))
+	}
+}
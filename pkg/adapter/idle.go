@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"knative.dev/eventing/pkg/adapter/v2"
+)
+
+// idleTracker tracks the notifications being handled, for the adapter to
+// shut itself down once idle.
+type idleTracker struct {
+	timeout time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	last     time.Time
+}
+
+func newIdleTracker(timeout time.Duration) *idleTracker {
+	return &idleTracker{timeout: timeout, last: time.Now()}
+}
+
+// begin records the start of the handling of a notification, returning the
+// function recording its end.
+func (t *idleTracker) begin() func() {
+	t.mu.Lock()
+	t.inFlight++
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.last = time.Now()
+		t.mu.Unlock()
+	}
+}
+
+// idleFor returns for how long no notification has been handled, zero while
+// some are.
+func (t *idleTracker) idleFor(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.inFlight > 0 {
+		return 0
+	}
+	return now.Sub(t.last)
+}
+
+// idle returns a channel closed once no notification has been handled for
+// the timeout, checked until ctx is done.
+func (t *idleTracker) idle(ctx context.Context) <-chan struct{} {
+	ch := make(chan struct{})
+	go func() {
+		interval := t.timeout / 10
+		if interval < time.Second {
+			interval = time.Second
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if t.idleFor(now) >= t.timeout {
+					close(ch)
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// flushIdle re-drives the events retained by the adapter before it shuts
+// itself down for being idle, for scaling it to zero not to strand them.
+func (ca *cephReceiveAdapter) flushIdle() {
+	if ca.retention == nil {
+		return
+	}
+	if _, active := activeMaintenance(ca.maintenance, time.Now()); active {
+		// The events spooled during maintenance are re-driven after it.
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	ctx = adapter.ContextWithMetricTag(ctx, ca.metricTag())
+	n, err := ca.retention.Redrive(ctx, ca.redriveSpooled)
+	if err != nil {
+		ca.logger.Warnw("Failed to re-drive the retained events before shutting down", zap.Int("count", n), zap.Error(err))
+		return
+	}
+	ca.logger.Infow("Re-drove the retained events before shutting down", zap.Int("count", n))
+}
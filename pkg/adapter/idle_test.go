@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+)
+
+func TestIdleTracker(t *testing.T) {
+	tracker := newIdleTracker(time.Minute)
+	now := time.Now()
+	end := tracker.begin()
+	if idle := tracker.idleFor(now.Add(time.Hour)); idle != 0 {
+		t.Errorf("Unexpected idle time while handling a notification: %s", idle)
+	}
+	end()
+	if idle := tracker.idleFor(time.Now().Add(time.Hour)); idle < time.Hour {
+		t.Errorf("Unexpected idle time: %s", idle)
+	}
+}
+
+func TestIdleShutdown(t *testing.T) {
+	retention := newTestStore(t, 10)
+	if err := retention.Store(testEvent("retained")); err != nil {
+		t.Fatal(err)
+	}
+
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{
+		logger:    zap.NewNop().Sugar(),
+		client:    client,
+		bind:      "127.0.0.1",
+		port:      freePort(t),
+		retention: retention,
+		idle:      newIdleTracker(time.Second),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := ca.start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("The adapter did not shut down once idle")
+	}
+	if sent := client.Sent(); len(sent) != 1 || sent[0].ID() != "retained" {
+		t.Errorf("Unexpected events re-driven before shutting down: %v", sent)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+	"knative.dev/pkg/metrics"
+)
+
+// maxFutureEventTime is how far in the future the time of an event may be,
+// as seen by the adapter, for its latency to be recorded, unless event times
+// are checked with another tolerance. Smaller offsets are put down to clock
+// drift and recorded as no latency.
+const maxFutureEventTime = time.Minute
+
+var (
+	// eventLatencyM is the time from the occurrence of an event, as stamped
+	// by Ceph, to its acknowledgement by the sink.
+	eventLatencyM = stats.Float64(
+		"event_latencies",
+		"The time from the occurrence of an event to its acknowledgement by the sink",
+		stats.UnitMilliseconds,
+	)
+
+	// eventDispatchLatencyM is the time the sink takes to respond to the
+	// delivery of an event.
+	eventDispatchLatencyM = stats.Float64(
+		"event_dispatch_latencies",
+		"The time the sink took to respond to the delivery of an event",
+		stats.UnitMilliseconds,
+	)
+
+	// eventTimeSkewedM counts the events whose latency is not recorded
+	// because they occurred too far in the future.
+	eventTimeSkewedM = stats.Int64(
+		"event_time_skewed_count",
+		"Number of events whose time is too far in the future to record their latency",
+		stats.UnitDimensionless,
+	)
+
+	namespaceKey     = tag.MustNewKey("namespace_name")
+	nameKey          = tag.MustNewKey("name")
+	resourceGroupKey = tag.MustNewKey("resource_group")
+
+	registerLatencyOnce sync.Once
+)
+
+func registerLatencyViews() error {
+	var err error
+	registerLatencyOnce.Do(func() {
+		tagKeys := []tag.Key{namespaceKey, nameKey, resourceGroupKey}
+		err = view.Register(&view.View{
+			Description: eventLatencyM.Description(),
+			Measure:     eventLatencyM,
+			Aggregation: view.Distribution(10, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 300000, 900000, 3600000),
+			TagKeys:     tagKeys,
+		}, &view.View{
+			Description: eventDispatchLatencyM.Description(),
+			Measure:     eventDispatchLatencyM,
+			Aggregation: view.Distribution(5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000),
+			TagKeys:     tagKeys,
+		}, &view.View{
+			Description: eventTimeSkewedM.Description(),
+			Measure:     eventTimeSkewedM,
+			Aggregation: view.Count(),
+			TagKeys:     tagKeys,
+		})
+	})
+	return err
+}
+
+// recordLatency records the latency of an event of the given time
+// acknowledged by the sink at now, with the trace of its delivery as
+// exemplar.
+func (ca *cephReceiveAdapter) recordLatency(ctx context.Context, eventTime, now time.Time) {
+	if eventTime.IsZero() {
+		return
+	}
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup))
+	if err != nil {
+		return
+	}
+
+	maxFuture := maxFutureEventTime
+	if ca.eventTime != nil {
+		maxFuture = ca.eventTime.maxFuture
+	}
+	latency := now.Sub(eventTime)
+	switch {
+	case latency < -maxFuture:
+		metrics.Record(ctx, eventTimeSkewedM.M(1))
+		return
+	case latency < 0:
+		latency = 0
+	}
+	metrics.Record(ctx, eventLatencyM.M(float64(latency)/float64(time.Millisecond)), exemplar(ctx)...)
+}
+
+// recordDispatchLatency records the time the sink took to respond to the
+// delivery of an event, with the trace of the delivery as exemplar.
+func (ca *cephReceiveAdapter) recordDispatchLatency(ctx context.Context, latency time.Duration) {
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, eventDispatchLatencyM.M(float64(latency)/float64(time.Millisecond)), exemplar(ctx)...)
+}
+
+// exemplar returns the option attaching the span of ctx, if sampled, to a
+// measurement, for the histogram bucket it falls in to link to its trace.
+func exemplar(ctx context.Context) []stats.Options {
+	span := trace.FromContext(ctx)
+	if span == nil || !span.SpanContext().IsSampled() {
+		return nil
+	}
+	return []stats.Options{stats.WithAttachments(metricdata.Attachments{
+		metricdata.AttachmentKeySpanContext: span.SpanContext(),
+	})}
+}
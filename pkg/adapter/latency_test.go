@@ -0,0 +1,154 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+	"knative.dev/pkg/metrics"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+)
+
+func TestRecordLatency(t *testing.T) {
+	metrics.InitForTesting()
+	if err := registerLatencyViews(); err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{namespace: "latency-test", name: "source"}
+	now := time.Now()
+
+	ca.recordLatency(context.Background(), now.Add(-2*time.Second), now)
+	ca.recordLatency(context.Background(), now.Add(10*time.Second), now)
+	ca.recordLatency(context.Background(), now.Add(time.Hour), now)
+	ca.recordLatency(context.Background(), time.Time{}, now)
+
+	rows, err := view.RetrieveData(eventLatencyM.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var latencies *view.DistributionData
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == namespaceKey && tag.Value == "latency-test" {
+				latencies = row.Data.(*view.DistributionData)
+			}
+		}
+	}
+	if latencies == nil || latencies.Count != 2 || latencies.Min != 0 || latencies.Max != 2000 {
+		t.Errorf("Unexpected latencies: %+v", latencies)
+	}
+
+	rows, err = view.RetrieveData(eventTimeSkewedM.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Data.(*view.CountData).Value != 1 {
+		t.Errorf("Unexpected skewed events: %+v", rows)
+	}
+}
+
+func TestRecordLatencyExemplar(t *testing.T) {
+	metrics.InitForTesting()
+	if err := registerLatencyViews(); err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{namespace: "exemplar-test", name: "source"}
+	now := time.Now()
+
+	ctx, sampled := trace.StartSpan(context.Background(), sendSpanName, trace.WithSampler(trace.AlwaysSample()))
+	ca.recordLatency(ctx, now.Add(-2*time.Second), now)
+	sampled.End()
+	ctx, unsampled := trace.StartSpan(context.Background(), sendSpanName, trace.WithSampler(trace.NeverSample()))
+	ca.recordLatency(ctx, now.Add(-20*time.Second), now)
+	unsampled.End()
+
+	rows, err := view.RetrieveData(eventLatencyM.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exemplars []*metricdata.Exemplar
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == namespaceKey && tag.Value == "exemplar-test" {
+				for _, e := range row.Data.(*view.DistributionData).ExemplarsPerBucket {
+					if e != nil {
+						exemplars = append(exemplars, e)
+					}
+				}
+			}
+		}
+	}
+	if len(exemplars) != 1 || exemplars[0].Value != 2000 {
+		t.Fatalf("Unexpected exemplars: %+v", exemplars)
+	}
+	if got := exemplars[0].Attachments[metricdata.AttachmentKeySpanContext]; got != sampled.SpanContext() {
+		t.Errorf("Unexpected span of the exemplar: %+v", got)
+	}
+}
+
+func TestDispatchLatencyExemplar(t *testing.T) {
+	metrics.InitForTesting()
+	if err := registerLatencyViews(); err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{
+		logger:    zap.NewNop().Sugar(),
+		client:    adaptertest.NewClient(),
+		namespace: "dispatch-exemplar-test",
+		name:      "source",
+	}
+
+	// The delivery is traced within the sampled trace of its notification.
+	ctx, parent := trace.StartSpan(context.Background(), "notification", trace.WithSampler(trace.AlwaysSample()))
+	if err := ca.sendCloudEvent(ctx, testEvent("1")); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	rows, err := view.RetrieveData(eventDispatchLatencyM.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exemplars []*metricdata.Exemplar
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == namespaceKey && tag.Value == "dispatch-exemplar-test" {
+				for _, e := range row.Data.(*view.DistributionData).ExemplarsPerBucket {
+					if e != nil {
+						exemplars = append(exemplars, e)
+					}
+				}
+			}
+		}
+	}
+	if len(exemplars) != 1 {
+		t.Fatalf("Unexpected exemplars: %+v", exemplars)
+	}
+	// The exemplar is the span of the delivery, not the one of the
+	// notification.
+	got, ok := exemplars[0].Attachments[metricdata.AttachmentKeySpanContext].(trace.SpanContext)
+	if !ok || got.TraceID != parent.SpanContext().TraceID || got.SpanID == parent.SpanContext().SpanID {
+		t.Errorf("Unexpected span of the exemplar: %+v", exemplars[0].Attachments)
+	}
+}
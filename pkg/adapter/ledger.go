@@ -0,0 +1,432 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+// spoolLedgerFile is the file of the ledger in the retention directory,
+// without the suffix of the retained events.
+const spoolLedgerFile = "ledger"
+
+// The reasons events are spooled for.
+const (
+	spoolReasonFailure     = "failure"
+	spoolReasonMaintenance = "maintenance"
+)
+
+var (
+	// spooledUndeliveredM is the number of events acknowledged to Ceph but
+	// spooled rather than delivered, per bucket, and spooledDroppedM counts
+	// those dropped from the spool to make room for newer events before
+	// being delivered.
+	spooledUndeliveredM = stats.Int64(
+		"spooled_undelivered_count",
+		"Number of events acknowledged to Ceph but not delivered to the sink yet",
+		stats.UnitDimensionless,
+	)
+	spooledDroppedM = stats.Int64(
+		"spooled_dropped_count",
+		"Number of events acknowledged to Ceph but dropped from the spool before being delivered",
+		stats.UnitDimensionless,
+	)
+
+	registerLedgerOnce sync.Once
+)
+
+func registerLedgerViews() error {
+	var err error
+	registerLedgerOnce.Do(func() {
+		tagKeys := []tag.Key{namespaceKey, nameKey, resourceGroupKey, bucketKey}
+		err = view.Register(&view.View{
+			Description: spooledUndeliveredM.Description(),
+			Measure:     spooledUndeliveredM,
+			Aggregation: view.LastValue(),
+			TagKeys:     tagKeys,
+		}, &view.View{
+			Description: spooledDroppedM.Description(),
+			Measure:     spooledDroppedM,
+			Aggregation: view.Count(),
+			TagKeys:     tagKeys,
+		})
+	})
+	return err
+}
+
+type spoolRecordKey struct{}
+
+// withSpoolRecord returns a copy of the context carrying the record the
+// events forwarded with it originate from.
+func withSpoolRecord(ctx context.Context, notification ceph.BucketNotification) context.Context {
+	return context.WithValue(ctx, spoolRecordKey{}, notification)
+}
+
+// spoolEntry describes an event acknowledged to Ceph but spooled rather than
+// delivered.
+type spoolEntry struct {
+	Bucket    string    `json:"bucket,omitempty"`
+	Key       string    `json:"key,omitempty"`
+	Sequencer string    `json:"sequencer,omitempty"`
+	Reason    string    `json:"reason"`
+	SpooledAt time.Time `json:"spooledAt"`
+	// Dropped is set once the event was dropped from the spool to make
+	// room for newer events, it being lost.
+	Dropped bool `json:"dropped,omitempty"`
+}
+
+// spoolLedger tracks the spooled events by ID until they are delivered,
+// persisted next to them to survive restarts of the adapter.
+type spoolLedger struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]spoolEntry
+}
+
+// newSpoolLedger returns the ledger of the events spooled in dir, loading
+// the one persisted.
+func newSpoolLedger(dir string) (*spoolLedger, error) {
+	l := &spoolLedger{
+		path:    filepath.Join(dir, spoolLedgerFile),
+		entries: make(map[string]spoolEntry),
+	}
+	data, err := ioutil.ReadFile(l.path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &l.entries); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// add records a spooled event.
+func (l *spoolLedger) add(id string, entry spoolEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[id] = entry
+	return l.persist()
+}
+
+// complete records the delivery of a spooled event, returning its entry.
+func (l *spoolLedger) complete(id string) (spoolEntry, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.entries[id]
+	if !ok {
+		return entry, false, nil
+	}
+	delete(l.entries, id)
+	return entry, true, l.persist()
+}
+
+// reconcile marks dropped the undelivered events no longer spooled, given
+// the IDs of those still spooled, sanitized by the file store, returning
+// their entries.
+func (l *spoolLedger) reconcile(spooled map[string]struct{}) ([]spoolEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var dropped []spoolEntry
+	for id, entry := range l.entries {
+		if entry.Dropped {
+			continue
+		}
+		_, ok := spooled[id]
+		if !ok {
+			_, ok = spooled[sanitizeFileName(id)]
+		}
+		if !ok {
+			entry.Dropped = true
+			l.entries[id] = entry
+			dropped = append(dropped, entry)
+		}
+	}
+	if len(dropped) == 0 {
+		return nil, nil
+	}
+	return dropped, l.persist()
+}
+
+// forgetDropped removes the dropped events, once acknowledged by an
+// operator, returning their number.
+func (l *spoolLedger) forgetDropped() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for id, entry := range l.entries {
+		if entry.Dropped {
+			delete(l.entries, id)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, l.persist()
+}
+
+// undelivered returns the number of events of a bucket still spooled.
+func (l *spoolLedger) undelivered(bucket string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for _, entry := range l.entries {
+		if entry.Bucket == bucket && !entry.Dropped {
+			n++
+		}
+	}
+	return n
+}
+
+// persist writes the ledger, with l.mu held.
+func (l *spoolLedger) persist() error {
+	data, err := json.Marshal(l.entries)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(filepath.Dir(l.path), "."+spoolLedgerFile)
+	if err := ioutil.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// spoolReport is the reconciliation report of the spooled events.
+type spoolReport struct {
+	Undelivered int                 `json:"undelivered"`
+	Dropped     int                 `json:"dropped"`
+	Oldest      *time.Time          `json:"oldest,omitempty"`
+	Buckets     []spoolBucketReport `json:"buckets"`
+	Events      []spoolReportEvent  `json:"events"`
+}
+
+// spoolBucketReport summarizes the spooled events of a bucket, with the
+// range of their sequencers.
+type spoolBucketReport struct {
+	Bucket         string    `json:"bucket"`
+	Undelivered    int       `json:"undelivered"`
+	Dropped        int       `json:"dropped"`
+	Oldest         time.Time `json:"oldest"`
+	FirstSequencer string    `json:"firstSequencer,omitempty"`
+	LastSequencer  string    `json:"lastSequencer,omitempty"`
+}
+
+type spoolReportEvent struct {
+	ID string `json:"id"`
+	spoolEntry
+}
+
+// report returns the reconciliation report of the spooled events, oldest
+// first.
+func (l *spoolLedger) report() spoolReport {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	report := spoolReport{Buckets: []spoolBucketReport{}, Events: make([]spoolReportEvent, 0, len(l.entries))}
+	buckets := make(map[string]*spoolBucketReport)
+	for id, entry := range l.entries {
+		report.Events = append(report.Events, spoolReportEvent{ID: id, spoolEntry: entry})
+		b, ok := buckets[entry.Bucket]
+		if !ok {
+			b = &spoolBucketReport{Bucket: entry.Bucket, Oldest: entry.SpooledAt}
+			buckets[entry.Bucket] = b
+		}
+		if entry.Dropped {
+			report.Dropped++
+			b.Dropped++
+		} else {
+			report.Undelivered++
+			b.Undelivered++
+		}
+		if entry.SpooledAt.Before(b.Oldest) {
+			b.Oldest = entry.SpooledAt
+		}
+		if report.Oldest == nil || entry.SpooledAt.Before(*report.Oldest) {
+			oldest := entry.SpooledAt
+			report.Oldest = &oldest
+		}
+		if s := entry.Sequencer; s != "" {
+			if b.FirstSequencer == "" || compareSequencers(s, b.FirstSequencer) < 0 {
+				b.FirstSequencer = s
+			}
+			if b.LastSequencer == "" || compareSequencers(s, b.LastSequencer) > 0 {
+				b.LastSequencer = s
+			}
+		}
+	}
+	sort.Slice(report.Events, func(i, j int) bool {
+		return report.Events[i].SpooledAt.Before(report.Events[j].SpooledAt)
+	})
+	for _, b := range buckets {
+		report.Buckets = append(report.Buckets, *b)
+	}
+	sort.Slice(report.Buckets, func(i, j int) bool {
+		return report.Buckets[i].Bucket < report.Buckets[j].Bucket
+	})
+	return report
+}
+
+// IDs implements SpoolStore. The IDs are sanitized, as in the file names.
+func (s *failedEventStore) IDs() (map[string]struct{}, error) {
+	s.mu.Lock()
+	names, err := s.names()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		// Names are the failure time, "-" and the event ID.
+		if i := strings.IndexByte(name, '-'); i >= 0 {
+			ids[strings.TrimSuffix(name[i+1:], failedEventSuffix)] = struct{}{}
+		}
+	}
+	return ids, nil
+}
+
+// spool retains an event acknowledged to Ceph but not delivered, recording
+// it in the ledger.
+func (ca *cephReceiveAdapter) spool(ctx context.Context, event cloudevents.Event, reason string) error {
+	if err := ca.retention.Store(event); err != nil {
+		return err
+	}
+	if ca.ledger == nil {
+		return nil
+	}
+	entry := spoolEntry{Reason: reason, SpooledAt: time.Now()}
+	if notification, ok := ctx.Value(spoolRecordKey{}).(ceph.BucketNotification); ok {
+		entry.Bucket = notification.S3.Bucket.Name
+		entry.Key = notification.S3.Object.Key
+		entry.Sequencer = notification.S3.Object.Sequencer
+	}
+	logger := logging.FromContext(ctx)
+	if err := ca.ledger.add(event.ID(), entry); err != nil {
+		logger.Warnw("Failed to record spooled event", zap.String("id", event.ID()), zap.Error(err))
+		return nil
+	}
+	ca.recordSpooled(ctx, entry.Bucket)
+	ca.reconcileSpool(ctx)
+	return nil
+}
+
+// redriveSpooled sends a spooled event, recording its delivery in the
+// ledger.
+func (ca *cephReceiveAdapter) redriveSpooled(ctx context.Context, event cloudevents.Event) error {
+	if err := ca.sendCloudEvent(ctx, event); err != nil {
+		return err
+	}
+	if ca.ledger == nil {
+		return nil
+	}
+	entry, ok, err := ca.ledger.complete(event.ID())
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to record delivered spooled event", zap.String("id", event.ID()), zap.Error(err))
+	}
+	if ok {
+		ca.recordSpooled(ctx, entry.Bucket)
+	}
+	return nil
+}
+
+// reconcileSpool marks dropped the events of the ledger no longer spooled.
+func (ca *cephReceiveAdapter) reconcileSpool(ctx context.Context) {
+	ids, err := ca.retention.IDs()
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to list spooled events", zap.Error(err))
+		return
+	}
+	dropped, err := ca.ledger.reconcile(ids)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to record dropped spooled events", zap.Error(err))
+	}
+	for _, entry := range dropped {
+		logging.FromContext(ctx).Warnw("Dropped spooled event acknowledged to Ceph before delivering it",
+			zap.String("bucket", entry.Bucket), zap.String("key", entry.Key), zap.String("sequencer", entry.Sequencer))
+		if ctx, err := ca.spoolTags(ctx, entry.Bucket); err == nil {
+			metrics.Record(ctx, spooledDroppedM.M(1))
+		}
+		ca.recordSpooled(ctx, entry.Bucket)
+	}
+}
+
+// recordSpooled records the number of undelivered events of a bucket.
+func (ca *cephReceiveAdapter) recordSpooled(ctx context.Context, bucket string) {
+	if ctx, err := ca.spoolTags(ctx, bucket); err == nil {
+		metrics.Record(ctx, spooledUndeliveredM.M(int64(ca.ledger.undelivered(bucket))))
+	}
+}
+
+func (ca *cephReceiveAdapter) spoolTags(ctx context.Context, bucket string) (context.Context, error) {
+	return tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(bucketKey, bucket))
+}
+
+// spoolReportHandler serves the reconciliation report of the spooled events
+// on GET, and forgets the dropped ones on DELETE, once acknowledged.
+func (ca *cephReceiveAdapter) spoolReportHandler(w http.ResponseWriter, r *http.Request) {
+	if !ca.authorizedAdmin(r) {
+		http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if ca.ledger == nil {
+		http.Error(w, "failed event retention is not enabled", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		ca.reconcileSpool(r.Context())
+	case http.MethodDelete:
+		n, err := ca.ledger.forgetDropped()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		ca.logger.Infow("Forgot dropped spooled events", zap.Int("count", n))
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "405 Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ca.ledger.report())
+}
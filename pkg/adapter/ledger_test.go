@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+func TestSpoolLedger(t *testing.T) {
+	retention := newTestStore(t, 2)
+	ledger, err := newSpoolLedger(retention.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{
+		logger:     zap.NewNop().Sugar(),
+		client:     client,
+		retention:  retention,
+		ledger:     ledger,
+		adminToken: "admin",
+	}
+
+	for i := 1; i <= 3; i++ {
+		var notification ceph.BucketNotification
+		notification.S3.Bucket.Name = "fishbucket"
+		notification.S3.Object.Key = "fish" + strconv.Itoa(i)
+		notification.S3.Object.Sequencer = "0000000" + strconv.Itoa(i)
+		ctx := withSpoolRecord(context.Background(), notification)
+		if err := ca.spool(ctx, testEvent(strconv.Itoa(i)), spoolReasonFailure); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := func(method string) spoolReport {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/admin/spooled-events", nil)
+		r.Header.Set("Authorization", "Bearer admin")
+		ca.spoolReportHandler(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Unexpected status: %d", w.Code)
+		}
+		var report spoolReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	got := report(http.MethodGet)
+	if got.Undelivered != 2 || got.Dropped != 1 {
+		t.Errorf("Unexpected counts: %d undelivered, %d dropped", got.Undelivered, got.Dropped)
+	}
+	if len(got.Buckets) != 1 || got.Buckets[0].FirstSequencer != "00000001" || got.Buckets[0].LastSequencer != "00000003" {
+		t.Errorf("Unexpected buckets: %+v", got.Buckets)
+	}
+	if len(got.Events) != 3 || !got.Events[0].Dropped || got.Events[0].Key != "fish1" {
+		t.Errorf("Unexpected events: %+v", got.Events)
+	}
+
+	// The ledger survives restarts.
+	if reloaded, err := newSpoolLedger(retention.dir); err != nil {
+		t.Fatal(err)
+	} else if n := len(reloaded.entries); n != 3 {
+		t.Errorf("Unexpected reloaded entries: %d", n)
+	}
+
+	if _, err := retention.Redrive(context.Background(), ca.redriveSpooled); err != nil {
+		t.Fatal(err)
+	}
+	if got := report(http.MethodGet); got.Undelivered != 0 || got.Dropped != 1 {
+		t.Errorf("Unexpected counts after re-drive: %d undelivered, %d dropped", got.Undelivered, got.Dropped)
+	}
+	if got := report(http.MethodDelete); len(got.Events) != 0 {
+		t.Errorf("Unexpected events after forgetting the dropped ones: %+v", got.Events)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"knative.dev/eventing/pkg/adapter/v2"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	// lifecycleStarted and lifecycleStopping are the phases of the
+	// lifecycle events.
+	lifecycleStarted  = "started"
+	lifecycleStopping = "stopping"
+)
+
+// adapterLifecycle counts the notifications received and events sent by the
+// adapter, reported in the events sent when it starts and stops.
+type adapterLifecycle struct {
+	sink      string
+	instance  string
+	startTime time.Time
+
+	notifications uint64
+	events        uint64
+	failures      uint64
+}
+
+// lifecycleEvent is the data of the lifecycle events.
+type lifecycleEvent struct {
+	Phase         string    `json:"phase"`
+	Instance      string    `json:"instance"`
+	StartTime     time.Time `json:"startTime"`
+	Notifications uint64    `json:"notifications"`
+	Events        uint64    `json:"events"`
+	Failures      uint64    `json:"failures"`
+	// Listeners are the addresses the listeners of the adapter are bound
+	// to, by listener name.
+	Listeners map[string]string `json:"listeners,omitempty"`
+}
+
+// newLifecycle returns the lifecycle of the adapter, reported to sink, or to
+// the sink of the adapter if empty. The instance is named after the pod.
+func newLifecycle(sink string) *adapterLifecycle {
+	instance, _ := os.Hostname()
+	return &adapterLifecycle{sink: sink, instance: instance, startTime: time.Now()}
+}
+
+// notificationReceived counts a notification received, if l is not nil.
+func (l *adapterLifecycle) notificationReceived() {
+	if l != nil {
+		atomic.AddUint64(&l.notifications, 1)
+	}
+}
+
+// eventSent counts an event sent, or failed to be, if l is not nil.
+func (l *adapterLifecycle) eventSent(ok bool) {
+	switch {
+	case l == nil:
+	case ok:
+		atomic.AddUint64(&l.events, 1)
+	default:
+		atomic.AddUint64(&l.failures, 1)
+	}
+}
+
+// data returns the data of the lifecycle event of the given phase.
+func (l *adapterLifecycle) data(phase string) lifecycleEvent {
+	return lifecycleEvent{
+		Phase:         phase,
+		Instance:      l.instance,
+		StartTime:     l.startTime,
+		Notifications: atomic.LoadUint64(&l.notifications),
+		Events:        atomic.LoadUint64(&l.events),
+		Failures:      atomic.LoadUint64(&l.failures),
+	}
+}
+
+// sendLifecycle sends the lifecycle event of the given phase, if enabled.
+// It is sent once, without retry, failures being logged only, so as not to
+// hold the start or shutdown of the adapter.
+func (ca *cephReceiveAdapter) sendLifecycle(phase string) {
+	if ca.lifecycle == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	ctx = adapter.ContextWithMetricTag(ctx, ca.metricTag())
+	if ca.lifecycle.sink != "" {
+		ctx = cloudevents.ContextWithTarget(ctx, ca.lifecycle.sink)
+	} else {
+		// The headers of the sink are not sent to another one.
+		ctx = ca.withSinkHeaders(ctx)
+	}
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.New().String())
+	event.SetType(v1alpha1.LifecycleEventType)
+	event.SetSource(ca.sourceURI())
+	event.SetSubject(ca.lifecycle.instance)
+	event.SetTime(time.Now())
+	data := ca.lifecycle.data(phase)
+	if ca.bound != nil {
+		data.Listeners, _, _ = ca.bound.get()
+	}
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		ca.logger.Errorw("Failed to create the lifecycle event", zap.Error(err))
+		return
+	}
+	ctx, event = ca.encode(ctx, event)
+	if result := ca.client.Send(ctx, event); !cloudevents.IsACK(result) {
+		ca.logger.Warnw("Failed to send the lifecycle event", zap.String("phase", phase), zap.Error(result))
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func TestLifecycleEvents(t *testing.T) {
+	client := adaptertest.NewTestClient()
+	ca := &cephReceiveAdapter{
+		logger:    zap.NewNop().Sugar(),
+		client:    client,
+		bind:      "127.0.0.1",
+		port:      freePort(t),
+		namespace: "default",
+		name:      "ceph",
+		lifecycle: newLifecycle(""),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ca.start(ctx)
+	}()
+	for i := 0; i < 50 && len(client.Sent()) == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	ca.recordNotification(ctx, notification1)
+	if err := ca.sendCloudEvent(ctx, testEvent("1")); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatal("Unexpected error:", err)
+	}
+
+	sent := client.Sent()
+	if len(sent) != 3 {
+		t.Fatalf("Unexpected number of events sent: got %d, want 3", len(sent))
+	}
+	want := []lifecycleEvent{
+		{Phase: lifecycleStarted},
+		{Phase: lifecycleStopping, Notifications: 1, Events: 1},
+	}
+	for i, event := range []cloudevents.Event{sent[0], sent[2]} {
+		if event.Type() != v1alpha1.LifecycleEventType {
+			t.Errorf("Unexpected type of event %d: %s", i, event.Type())
+		}
+		if event.Source() != "/apis/v1/namespaces/default/cephsources/ceph" {
+			t.Errorf("Unexpected source of event %d: %s", i, event.Source())
+		}
+		var got lifecycleEvent
+		if err := event.DataAs(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Phase != want[i].Phase || got.Notifications != want[i].Notifications ||
+			got.Events != want[i].Events || got.Failures != want[i].Failures {
+			t.Errorf("Unexpected data of event %d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+}
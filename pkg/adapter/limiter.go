@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+// aimdLimiter bounds the number of concurrent sends to the sink. The limit
+// grows additively, by about one per round of successful sends, and is halved
+// whenever the sink signals congestion, which lets it settle around the
+// throughput the sink can sustain.
+type aimdLimiter struct {
+	mu       sync.Mutex
+	limit    float64
+	max      float64
+	inflight int
+
+	// released is signalled whenever a slot is released.
+	released chan struct{}
+}
+
+func newAIMDLimiter(max int) *aimdLimiter {
+	return &aimdLimiter{
+		limit:    float64(max),
+		max:      float64(max),
+		released: make(chan struct{}, 1),
+	}
+}
+
+// acquire blocks until a send slot is available or the context is done.
+func (l *aimdLimiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.inflight < int(l.limit) {
+			l.inflight++
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.released:
+		}
+	}
+}
+
+// release frees a send slot and adjusts the limit depending on whether the
+// send hit congestion.
+func (l *aimdLimiter) release(congested bool) {
+	l.mu.Lock()
+	l.inflight--
+	if congested {
+		l.limit = math.Max(1, l.limit/2)
+	} else {
+		l.limit = math.Min(l.max, l.limit+1/l.limit)
+	}
+	l.mu.Unlock()
+
+	select {
+	case l.released <- struct{}{}:
+	default:
+	}
+}
+
+// currentLimit returns the current concurrency limit.
+func (l *aimdLimiter) currentLimit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
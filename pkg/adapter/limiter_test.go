@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAIMDLimiter(t *testing.T) {
+	l := newAIMDLimiter(4)
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		if err := l.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The limit is reached, acquiring must block until the context is done.
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(timeout); err == nil {
+		t.Fatal("Expected acquire to block when the limit is reached")
+	}
+
+	l.release(true)
+	if got := l.currentLimit(); got != 2 {
+		t.Fatalf("Unexpected limit after congestion: got %d, want 2", got)
+	}
+	l.release(true)
+	l.release(true)
+	l.release(true)
+	if got := l.currentLimit(); got != 1 {
+		t.Fatalf("Unexpected limit after repeated congestion: got %d, want 1", got)
+	}
+
+	// Recovering from a limit of 1 to 4 takes a few rounds of successful sends.
+	for i := 0; i < 10; i++ {
+		if err := l.acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+		l.release(false)
+	}
+	if got := l.currentLimit(); got != 4 {
+		t.Fatalf("Unexpected limit after recovery: got %d, want 4", got)
+	}
+}
+
+func TestAIMDLimiterWakesWaiters(t *testing.T) {
+	l := newAIMDLimiter(1)
+	ctx := context.Background()
+	if err := l.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- l.acquire(ctx)
+	}()
+
+	l.release(false)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waiter was not woken up on release")
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+// listenerSpec is the JSON form of the additional listeners passed to the
+// adapter.
+type listenerSpec struct {
+	Name                 string   `json:"name"`
+	Port                 string   `json:"port"`
+	BindAddress          string   `json:"bindAddress,omitempty"`
+	TLSDir               string   `json:"tlsDir,omitempty"`
+	ClientAuth           bool     `json:"clientAuth,omitempty"`
+	AuthPaths            []string `json:"authPaths,omitempty"`
+	AuthHosts            []string `json:"authHosts,omitempty"`
+	FilterEventNames     []string `json:"filterEventNames,omitempty"`
+	FilterVersioned      *bool    `json:"filterVersioned,omitempty"`
+	FilterDeleteMarkers  string   `json:"filterDeleteMarkers,omitempty"`
+	FilterEncryption     string   `json:"filterEncryption,omitempty"`
+	FilterStorageClasses []string `json:"filterStorageClasses,omitempty"`
+}
+
+// listener is an additional listener of the adapter, serving notifications
+// with its own TLS, auth and filter, or a listener of the adapter serving
+// handler.
+type listener struct {
+	name    string
+	addr    string
+	tls     *tls.Config
+	adapter *cephReceiveAdapter
+	handler http.Handler
+}
+
+// makeListeners returns the listeners of a JSON array of listenerSpec, each
+// handling the notifications with a copy of ca with its own auth and filter.
+// The token of the j-th path and host of the i-th listener is read from the
+// LISTENER_<i>_AUTH_TOKEN_<j> environment variable.
+func (ca *cephReceiveAdapter) makeListeners(s string) ([]*listener, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var specs []listenerSpec
+	if err := json.Unmarshal([]byte(s), &specs); err != nil {
+		return nil, err
+	}
+	listeners := make([]*listener, 0, len(specs))
+	for i, spec := range specs {
+		l := &listener{
+			name: spec.Name,
+			addr: net.JoinHostPort(spec.BindAddress, spec.Port),
+		}
+		if spec.TLSDir != "" {
+			var err error
+			if l.tls, err = listenerTLS(spec.TLSDir, spec.ClientAuth); err != nil {
+				return nil, fmt.Errorf("listener %s: %w", spec.Name, err)
+			}
+			if ca.tlsPolicy != nil {
+				l.tls = ca.tlsPolicy.Apply(l.tls)
+			}
+		}
+		a := *ca
+		a.listeners = nil
+		a.tokens = authTokens(fmt.Sprintf("LISTENER_%d_AUTH_TOKEN_", i), spec.AuthPaths, spec.AuthHosts)
+		a.filters = makeFilters(v1alpha1.CephSourceFilter{
+			EventNames:     spec.FilterEventNames,
+			Versioned:      spec.FilterVersioned,
+			DeleteMarkers:  spec.FilterDeleteMarkers,
+			Encryption:     spec.FilterEncryption,
+			StorageClasses: spec.FilterStorageClasses,
+		})
+		a.raw = a.rawRecords()
+		l.adapter = &a
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listenerTLS returns the TLS configuration of a listener serving the
+// certificate of the kubernetes.io/tls Secret mounted in dir, requiring the
+// clients to present a certificate signed by its "ca.crt" with clientAuth.
+func listenerTLS(dir string, clientAuth bool) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientAuth {
+		ca, err := ioutil.ReadFile(filepath.Join(dir, "ca.crt"))
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificate in ca.crt")
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
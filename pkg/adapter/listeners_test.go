@@ -0,0 +1,309 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 to dir,
+// as the tls.crt, tls.key and ca.crt of a kubernetes.io/tls Secret.
+func writeCertificate(t *testing.T, dir string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ceph-source"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	for name, data := range map[string][]byte{"tls.crt": certPEM, "tls.key": keyPEM, "ca.crt": certPEM} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// freePort returns a port nothing listens on.
+func freePort(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
+
+func TestMakeListeners(t *testing.T) {
+	os.Setenv("LISTENER_1_AUTH_TOKEN_0", "debug-token")
+	defer os.Unsetenv("LISTENER_1_AUTH_TOKEN_0")
+
+	ca := &cephReceiveAdapter{
+		logger:  zap.NewNop().Sugar(),
+		filters: makeFilters(v1alpha1.CephSourceFilter{EventNames: []string{"s3:ObjectRemoved:*"}}),
+		tokens:  map[string]string{"/": "token"},
+	}
+	listeners, err := ca.makeListeners(`[
+		{"name":"plain","port":"9998"},
+		{"name":"debug","port":"9997","bindAddress":"127.0.0.1","authPaths":["/debug"],"filterEventNames":["s3:ObjectCreated:*"]}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listeners) != 2 {
+		t.Fatalf("Unexpected number of listeners: %d", len(listeners))
+	}
+
+	plain, debug := listeners[0], listeners[1]
+	if plain.addr != ":9998" || plain.adapter.tokens != nil || len(plain.adapter.filters) != 0 || !plain.adapter.raw {
+		t.Errorf("Unexpected plain listener: %+v", plain)
+	}
+	if debug.addr != "127.0.0.1:9997" || debug.adapter.tokens["/debug"] != "debug-token" || len(debug.adapter.filters) != 1 {
+		t.Errorf("Unexpected debug listener: %+v", debug)
+	}
+	// The adapter keeps its own auth and filter.
+	if ca.tokens["/"] != "token" || len(ca.filters) != 1 {
+		t.Error("Listeners changed the adapter")
+	}
+
+	if _, err := ca.makeListeners(`[{"name":"tls","port":"9443","tlsDir":"/nonexistent"}]`); err == nil {
+		t.Error("Expected an error for a missing certificate")
+	}
+}
+
+func TestListeners(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listener-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cert := writeCertificate(t, dir)
+
+	os.Setenv("LISTENER_0_AUTH_TOKEN_0", "secret")
+	defer os.Unsetenv("LISTENER_0_AUTH_TOKEN_0")
+
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), client: client, bind: "127.0.0.1", port: "0"}
+	tlsPort, plainPort := freePort(t), freePort(t)
+	specs, err := json.Marshal([]listenerSpec{{
+		Name:        "production",
+		Port:        tlsPort,
+		BindAddress: "127.0.0.1",
+		TLSDir:      dir,
+		ClientAuth:  true,
+		AuthPaths:   []string{"/"},
+	}, {
+		Name:             "debug",
+		Port:             plainPort,
+		BindAddress:      "127.0.0.1",
+		FilterEventNames: []string{"s3:ObjectRemoved:*"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca.listeners, err = ca.makeListeners(string(specs)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ca.start(ctx)
+	}()
+	defer func() {
+		cancel()
+		if err := <-errCh; err != nil {
+			t.Error("Unexpected error:", err)
+		}
+	}()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	mtls := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{cert},
+	}}}
+	noClientCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	body, err := json.Marshal(ceph.BucketNotifications{Records: []ceph.BucketNotification{notification1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := func(c *http.Client, url, token string) (int, error) {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	tlsURL := fmt.Sprintf("https://127.0.0.1:%s/", tlsPort)
+	plainURL := fmt.Sprintf("http://127.0.0.1:%s/", plainPort)
+	// Waits for the listeners to be served.
+	var status int
+	for i := 0; i < 50; i++ {
+		if status, err = post(http.DefaultClient, plainURL, ""); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Unexpected response of the debug listener: %d, %v", status, err)
+	}
+	if sent := client.Sent(); len(sent) != 0 {
+		t.Errorf("The debug listener should filter out the notification, sent %d", len(sent))
+	}
+
+	if status, err := post(mtls, tlsURL, ""); err != nil || status != http.StatusUnauthorized {
+		t.Errorf("Unexpected response without token: %d, %v", status, err)
+	}
+	if _, err := post(noClientCert, tlsURL, "secret"); err == nil {
+		t.Error("Expected the TLS listener to require a client certificate")
+	}
+	if status, err := post(mtls, tlsURL, "secret"); err != nil || status != http.StatusOK {
+		t.Errorf("Unexpected response: %d, %v", status, err)
+	}
+	if sent := client.Sent(); len(sent) != 1 {
+		t.Errorf("Unexpected number of events sent: got %d, want 1", len(sent))
+	}
+}
+
+func TestAdminPort(t *testing.T) {
+	port, adminPort := freePort(t), freePort(t)
+	ca := &cephReceiveAdapter{
+		logger:     zap.NewNop().Sugar(),
+		client:     adaptertest.NewClient(),
+		bind:       "127.0.0.1",
+		port:       port,
+		adminPort:  adminPort,
+		adminToken: "admin",
+		tokens:     map[string]string{"/": "token"},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ca.start(ctx)
+	}()
+	defer func() {
+		cancel()
+		if err := <-errCh; err != nil {
+			t.Error("Unexpected error:", err)
+		}
+	}()
+
+	get := func(url, token string) (int, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return 0, err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	adminURL := fmt.Sprintf("http://127.0.0.1:%s", adminPort)
+	// Waits for the admin port to be served.
+	var status int
+	var err error
+	for i := 0; i < 50; i++ {
+		if status, err = get(adminURL+"/version", ""); err == nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Unexpected response of the version endpoint: %d, %v", status, err)
+	}
+	if status, err := get(adminURL+"/admin/failed-events", "token"); err != nil || status != http.StatusUnauthorized {
+		t.Errorf("Unexpected response with a notification token: %d, %v", status, err)
+	}
+	// The retention of failed events is not enabled.
+	if status, err := get(adminURL+"/admin/failed-events", "admin"); err != nil || status != http.StatusNotFound {
+		t.Errorf("Unexpected response with the admin token: %d, %v", status, err)
+	}
+	// The notifications port no longer serves the admin endpoints, the
+	// request being handled as a notification.
+	if status, err := get(fmt.Sprintf("http://127.0.0.1:%s/admin/failed-events", port), "admin"); err != nil || status != http.StatusBadRequest {
+		t.Errorf("Unexpected response of the notifications port: %d, %v", status, err)
+	}
+}
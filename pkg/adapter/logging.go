@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+// recordFields returns the structured log fields identifying a notification
+// record.
+func recordFields(notification ceph.BucketNotification) []interface{} {
+	return []interface{}{
+		zap.String("bucket", notification.S3.Bucket.Name),
+		zap.String("key", notification.S3.Object.Key),
+		zap.String("eventName", notification.EventName),
+	}
+}
+
+// sampleDebug returns a logger sampling its debug entries: every second, the
+// first initial entries with a given message are logged, then every
+// thereafter-th one, 0 dropping them. Entries of other levels are always
+// logged.
+func sampleDebug(logger *zap.SugaredLogger, initial, thereafter int) *zap.SugaredLogger {
+	if thereafter < 1 {
+		thereafter = math.MaxInt32
+	}
+	return logger.Desugar().WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &debugSampler{
+			Core:    core,
+			sampled: zapcore.NewSamplerWithOptions(core, time.Second, initial, thereafter),
+		}
+	})).Sugar()
+}
+
+// debugSampler routes debug entries through a sampling core.
+type debugSampler struct {
+	zapcore.Core
+	sampled zapcore.Core
+}
+
+func (s *debugSampler) With(fields []zapcore.Field) zapcore.Core {
+	return &debugSampler{
+		Core:    s.Core.With(fields),
+		sampled: s.sampled.With(fields),
+	}
+}
+
+func (s *debugSampler) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level == zapcore.DebugLevel {
+		return s.sampled.Check(ent, ce)
+	}
+	return s.Core.Check(ent, ce)
+}
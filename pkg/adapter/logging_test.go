@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func bufferLogger(buf *bytes.Buffer) *zap.SugaredLogger {
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	return zap.New(zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.DebugLevel)).Sugar()
+}
+
+func TestSampleDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := sampleDebug(bufferLogger(&buf), 2, 0)
+
+	for i := 0; i < 10; i++ {
+		// Derived loggers share the sampling counts.
+		record := logger.With(zap.Int("record", i))
+		record.Debug("Received Ceph bucket notification")
+		record.Info("Rejecting notifications")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	debug, info := 0, 0
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, `"level":"debug"`):
+			debug++
+		case strings.Contains(line, `"level":"info"`):
+			info++
+		}
+	}
+	if debug != 2 {
+		t.Errorf("Expected 2 debug entries, got %d", debug)
+	}
+	if info != 10 {
+		t.Errorf("Expected 10 info entries, got %d", info)
+	}
+}
+
+func TestRecordFields(t *testing.T) {
+	var buf bytes.Buffer
+	notification := notification1
+
+	bufferLogger(&buf).With(recordFields(notification)...).Debug("Received Ceph bucket notification")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"bucket":    notification.S3.Bucket.Name,
+		"key":       notification.S3.Object.Key,
+		"eventName": notification.EventName,
+	}
+	for field, value := range want {
+		if entry[field] != value {
+			t.Errorf("Expected %s %q, got %v", field, value, entry[field])
+		}
+	}
+}
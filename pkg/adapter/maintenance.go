@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+// maintenanceCheckInterval is how often the end of maintenance windows is
+// checked for, to re-drive the events spooled during them.
+const maintenanceCheckInterval = 10 * time.Second
+
+var (
+	// eventSuppressedM counts the events not delivered because of a
+	// maintenance window.
+	eventSuppressedM = stats.Int64(
+		"event_suppressed_count",
+		"Number of events not delivered during maintenance windows",
+		stats.UnitDimensionless,
+	)
+
+	actionKey = tag.MustNewKey("action")
+
+	registerMaintenanceOnce sync.Once
+)
+
+func registerMaintenanceViews() error {
+	var err error
+	registerMaintenanceOnce.Do(func() {
+		err = view.Register(&view.View{
+			Description: eventSuppressedM.Description(),
+			Measure:     eventSuppressedM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey, actionKey},
+		})
+	})
+	return err
+}
+
+// maintenanceWindow is a window starting on schedule and lasting duration,
+// during which events are handled according to action.
+type maintenanceWindow struct {
+	schedule cron.Schedule
+	duration time.Duration
+	action   string
+}
+
+// maintenanceWindowSpec is the JSON form of the windows passed to the
+// adapter.
+type maintenanceWindowSpec struct {
+	Schedule string `json:"schedule"`
+	Duration string `json:"duration"`
+	Action   string `json:"action,omitempty"`
+}
+
+// parseMaintenanceWindows parses windows from a JSON array of
+// maintenanceWindowSpec.
+func parseMaintenanceWindows(s string) ([]maintenanceWindow, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var specs []maintenanceWindowSpec
+	if err := json.Unmarshal([]byte(s), &specs); err != nil {
+		return nil, err
+	}
+	windows := make([]maintenanceWindow, 0, len(specs))
+	for _, spec := range specs {
+		schedule, err := cron.ParseStandard(spec.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec.Schedule, err)
+		}
+		duration, err := time.ParseDuration(spec.Duration)
+		if err != nil {
+			return nil, err
+		}
+		action := spec.Action
+		if action == "" {
+			action = v1alpha1.MaintenanceSpool
+		}
+		windows = append(windows, maintenanceWindow{schedule: schedule, duration: duration, action: action})
+	}
+	return windows, nil
+}
+
+// active reports whether t falls in the window, which is when the window
+// started within duration before t.
+func (w maintenanceWindow) active(t time.Time) bool {
+	return !w.schedule.Next(t.Add(-w.duration)).After(t)
+}
+
+// activeMaintenance returns the action of the first window t falls in, if
+// any.
+func activeMaintenance(windows []maintenanceWindow, t time.Time) (string, bool) {
+	for _, w := range windows {
+		if w.active(t) {
+			return w.action, true
+		}
+	}
+	return "", false
+}
+
+// recordSuppressed counts an event not delivered because of a window.
+func (ca *cephReceiveAdapter) recordSuppressed(ctx context.Context, action string) {
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(actionKey, action))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, eventSuppressedM.M(1))
+}
+
+// redriveAfterMaintenance re-drives the retained events when a window in
+// which events are spooled ends, until ctx is done.
+func (ca *cephReceiveAdapter) redriveAfterMaintenance(ctx context.Context) {
+	ticker := time.NewTicker(maintenanceCheckInterval)
+	defer ticker.Stop()
+
+	spooling := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			action, active := activeMaintenance(ca.maintenance, now)
+			if active {
+				spooling = spooling || action == v1alpha1.MaintenanceSpool
+				continue
+			}
+			if !spooling {
+				continue
+			}
+			spooling = false
+			redriveCtx := adapter.ContextWithMetricTag(logging.WithLogger(ctx, ca.logger), ca.metricTag())
+			n, err := ca.retention.Redrive(redriveCtx, ca.redriveSpooled)
+			if err != nil {
+				ca.logger.Errorw("Failed to re-drive the events spooled during maintenance", zap.Int("count", n), zap.Error(err))
+			} else {
+				ca.logger.Infow("Re-drove the events spooled during maintenance", zap.Int("count", n))
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+)
+
+func TestMaintenanceWindowActive(t *testing.T) {
+	windows, err := parseMaintenanceWindows(`[{"schedule":"0 2 * * 6","duration":"2h"},{"schedule":"30 1 1 * *","duration":"1h","action":"drop"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := map[string]struct {
+		time       time.Time
+		wantAction string
+		wantActive bool
+	}{
+		"before the window": {
+			time: time.Date(2021, 11, 6, 1, 59, 0, 0, time.UTC),
+		},
+		"window start": {
+			time:       time.Date(2021, 11, 6, 2, 0, 0, 0, time.UTC),
+			wantAction: "spool",
+			wantActive: true,
+		},
+		"during the window": {
+			time:       time.Date(2021, 11, 6, 3, 59, 0, 0, time.UTC),
+			wantAction: "spool",
+			wantActive: true,
+		},
+		"window end": {
+			time: time.Date(2021, 11, 6, 4, 0, 0, 0, time.UTC),
+		},
+		"second window": {
+			time:       time.Date(2021, 12, 1, 2, 0, 0, 0, time.UTC),
+			wantAction: "drop",
+			wantActive: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			action, active := activeMaintenance(windows, tc.time)
+			if action != tc.wantAction || active != tc.wantActive {
+				t.Errorf("Unexpected maintenance: got %q %v, want %q %v", action, active, tc.wantAction, tc.wantActive)
+			}
+		})
+	}
+}
+
+func TestParseMaintenanceWindowsInvalid(t *testing.T) {
+	for _, s := range []string{`{}`, `[{"schedule":"daily","duration":"1h"}]`, `[{"schedule":"@daily","duration":"1 hour"}]`} {
+		if _, err := parseMaintenanceWindows(s); err == nil {
+			t.Errorf("Maintenance windows %s should be invalid", s)
+		}
+	}
+}
+
+func TestSpoolDuringMaintenance(t *testing.T) {
+	windows, err := parseMaintenanceWindows(`[{"schedule":"* * * * *","duration":"1h"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := adaptertest.NewTestClient()
+	ca := &cephReceiveAdapter{
+		logger:      zap.NewNop().Sugar(),
+		client:      client,
+		retention:   newTestStore(t, 10),
+		maintenance: windows,
+	}
+	if err := ca.postMessage(context.Background(), notification1); err != nil {
+		t.Fatal(err)
+	}
+	if sent := client.Sent(); len(sent) != 0 {
+		t.Errorf("Unexpected events sent during maintenance: %d", len(sent))
+	}
+	if n, _ := ca.retention.Count(); n != 1 {
+		t.Errorf("Unexpected spooled events: got %d, want 1", n)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+
+	"go.uber.org/zap"
+	"knative.dev/eventing/pkg/adapter/v2"
+)
+
+// boundAddrs are the addresses the listeners of the adapter are bound to,
+// which differ from the configured ones for ephemeral ports.
+type boundAddrs struct {
+	mu    sync.Mutex
+	addrs map[string]string
+	ready bool
+	// fallback are the listeners bound to an ephemeral port as theirs was
+	// in use, which the clients of the configured ports do not reach.
+	fallback []string
+}
+
+func (b *boundAddrs) set(name, addr string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.addrs == nil {
+		b.addrs = make(map[string]string)
+	}
+	b.addrs[name] = addr
+}
+
+func (b *boundAddrs) setFallback(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fallback = append(b.fallback, name)
+}
+
+func (b *boundAddrs) setReady(ready bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ready = ready
+	if !ready {
+		b.fallback = nil
+	}
+}
+
+// get returns a copy of the addresses, whether all the listeners are bound,
+// and the ones bound to a fallback port.
+func (b *boundAddrs) get() (map[string]string, bool, []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addrs := make(map[string]string, len(b.addrs))
+	for name, addr := range b.addrs {
+		addrs[name] = addr
+	}
+	return addrs, b.ready, append([]string(nil), b.fallback...)
+}
+
+// Addrs returns the addresses the listeners of an adapter returned by
+// NewAdapter are bound to, keyed by listener name, e.g. to push to an
+// adapter listening on an ephemeral port. It is nil until the adapter
+// listens.
+func Addrs(a adapter.Adapter) map[string]string {
+	ca, ok := a.(*cephReceiveAdapter)
+	if !ok {
+		return nil
+	}
+	addrs, ready, _ := ca.bound.get()
+	if !ready {
+		return nil
+	}
+	return addrs
+}
+
+// listen listens on the address of a listener, on an ephemeral port of its
+// host instead if its port is in use and the fallback is enabled. The
+// adapter is then not ready, as the clients of the port do not reach it.
+func (ca *cephReceiveAdapter) listen(name, addr string) (net.Listener, error) {
+	nl, err := net.Listen("tcp", addr)
+	if err != nil && ca.portFallback && errors.Is(err, syscall.EADDRINUSE) {
+		host, _, _ := net.SplitHostPort(addr)
+		if nl, err = net.Listen("tcp", net.JoinHostPort(host, "0")); err == nil {
+			ca.logger.Warnw("The port of the listener is in use, listening on an ephemeral port",
+				zap.String("listener", name), zap.String("addr", addr), zap.String("boundAddr", nl.Addr().String()))
+			ca.bound.setFallback(name)
+		}
+	}
+	return nl, err
+}
+
+// readyResult is the response of the readiness endpoint.
+type readyResult struct {
+	Ready     bool              `json:"ready"`
+	Listeners map[string]string `json:"listeners"`
+	Fallback  []string          `json:"fallback,omitempty"`
+}
+
+// readyHandler serves whether the adapter listens on all its listeners, on
+// their configured ports, with the addresses they are bound to.
+func (ca *cephReceiveAdapter) readyHandler(w http.ResponseWriter, r *http.Request) {
+	addrs, ready, fallback := ca.bound.get()
+	ready = ready && len(fallback) == 0
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(readyResult{Ready: ready, Listeners: addrs, Fallback: fallback})
+}
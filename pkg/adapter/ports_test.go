@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestPortFallback(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	inUse := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	testCases := map[string]struct {
+		port     string
+		fallback bool
+		ready    bool
+	}{
+		"ephemeral port": {port: "0", ready: true},
+		// The clients of the port in use do not reach the adapter.
+		"port in use": {port: inUse, fallback: true},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			ca := &cephReceiveAdapter{
+				logger:       zap.NewNop().Sugar(),
+				bind:         "127.0.0.1",
+				port:         tc.port,
+				portFallback: tc.fallback,
+				bound:        &boundAddrs{},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- ca.start(ctx)
+			}()
+			defer func() {
+				cancel()
+				if err := <-errCh; err != nil {
+					t.Error(err)
+				}
+			}()
+
+			var addr string
+			for deadline := time.Now().Add(5 * time.Second); addr == ""; {
+				if time.Now().After(deadline) {
+					t.Fatal("The adapter did not listen")
+				}
+				addr = Addrs(ca)["default"]
+				time.Sleep(10 * time.Millisecond)
+			}
+			if _, port, _ := net.SplitHostPort(addr); port == "0" || port == inUse {
+				t.Fatalf("Unexpected bound address: %s", addr)
+			}
+
+			resp, err := http.Get("http://" + addr + "/ready")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var result readyResult
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			status := http.StatusOK
+			var fallback []string
+			if !tc.ready {
+				status = http.StatusServiceUnavailable
+				fallback = []string{"default"}
+			}
+			if resp.StatusCode != status || result.Ready != tc.ready || result.Listeners["default"] != addr ||
+				!reflect.DeepEqual(result.Fallback, fallback) {
+				t.Errorf("Unexpected readiness: %d %+v", resp.StatusCode, result)
+			}
+		})
+	}
+}
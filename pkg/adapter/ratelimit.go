@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// anyBucket is the bucket of the limit applying to each bucket without a
+// limit of its own.
+const anyBucket = "*"
+
+// errRateLimited is returned for notifications beyond the rate limit of
+// their bucket.
+var errRateLimited = errors.New("bucket rate limit exceeded")
+
+type bucketLimit struct {
+	eventsPerSecond rate.Limit
+	burst           int
+}
+
+// bucketLimiter limits the rate of notifications per bucket.
+type bucketLimiter struct {
+	limits map[string]bucketLimit
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// newBucketLimiter parses limits in the "bucket=eventsPerSecond:burst"
+// format, returning nil if there are none.
+func newBucketLimiter(limits []string) (*bucketLimiter, error) {
+	if len(limits) == 0 {
+		return nil, nil
+	}
+	l := &bucketLimiter{
+		limits:   make(map[string]bucketLimit, len(limits)),
+		limiters: make(map[string]*rate.Limiter),
+	}
+	for _, limit := range limits {
+		bucket, value := limit, ""
+		if i := strings.LastIndex(limit, "="); i >= 0 {
+			bucket, value = limit[:i], limit[i+1:]
+		}
+		parts := strings.SplitN(value, ":", 2)
+		eventsPerSecond, err := strconv.Atoi(parts[0])
+		if err != nil || eventsPerSecond < 1 {
+			return nil, fmt.Errorf("invalid rate limit %q", limit)
+		}
+		burst := eventsPerSecond
+		if len(parts) == 2 {
+			if burst, err = strconv.Atoi(parts[1]); err != nil || burst < 1 {
+				return nil, fmt.Errorf("invalid rate limit %q", limit)
+			}
+		}
+		l.limits[bucket] = bucketLimit{eventsPerSecond: rate.Limit(eventsPerSecond), burst: burst}
+	}
+	return l, nil
+}
+
+// allow reports whether a notification of the given bucket is within its
+// limit. Buckets without a limit are not limited.
+func (l *bucketLimiter) allow(bucket string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.limiters[bucket]
+	if !ok {
+		limit, ok := l.limits[bucket]
+		if !ok {
+			if limit, ok = l.limits[anyBucket]; !ok {
+				return true
+			}
+		}
+		limiter = rate.NewLimiter(limit.eventsPerSecond, limit.burst)
+		l.limiters[bucket] = limiter
+	}
+	return limiter.Allow()
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"testing"
+)
+
+func TestBucketLimiter(t *testing.T) {
+	l, err := newBucketLimiter([]string{"fishbucket=1:2", "*=1:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		bucket string
+		want   bool
+	}{
+		{bucket: "fishbucket", want: true},
+		{bucket: "fishbucket", want: true},
+		{bucket: "fishbucket", want: false},
+		{bucket: "catbucket", want: true},
+		{bucket: "catbucket", want: false},
+		{bucket: "dogbucket", want: true},
+	}
+	for i, tc := range testCases {
+		if got := l.allow(tc.bucket); got != tc.want {
+			t.Errorf("%d: unexpected allow of %s: got %v, want %v", i, tc.bucket, got, tc.want)
+		}
+	}
+}
+
+func TestBucketLimiterUnlimited(t *testing.T) {
+	l, err := newBucketLimiter([]string{"fishbucket=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if !l.allow("catbucket") {
+			t.Fatal("Bucket without limit should not be limited")
+		}
+	}
+}
+
+func TestNewBucketLimiterInvalid(t *testing.T) {
+	for _, limit := range []string{"fishbucket", "fishbucket=0", "fishbucket=10:x"} {
+		if _, err := newBucketLimiter([]string{limit}); err == nil {
+			t.Errorf("Rate limit %q should be invalid", limit)
+		}
+	}
+}
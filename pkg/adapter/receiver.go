@@ -0,0 +1,218 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+)
+
+// notificationHandler returns the handler of the pushed notifications: the
+// CloudEvents SDK HTTP protocol, wrapped with the middlewares of the
+// adapter, whose messages are decoded and handled until ctx is done.
+func (ca *cephReceiveAdapter) notificationHandler(ctx context.Context) (http.Handler, error) {
+	p, err := cehttp.New()
+	if err != nil {
+		return nil, err
+	}
+	go ca.receive(ctx, p)
+
+	var h http.Handler = p
+	middlewares := ca.middlewares()
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h, nil
+}
+
+// middlewares returns the middlewares the requests go through, in order,
+// before their message is handled.
+func (ca *cephReceiveAdapter) middlewares() []cehttp.Middleware {
+	return []cehttp.Middleware{
+		ca.withRequestID,
+		requirePost,
+		ca.withAuth,
+		ca.withSignature,
+		ca.withSenders,
+	}
+}
+
+// withRequestID tags the request, its response and its logs with the ID of
+// the push.
+func (ca *cephReceiveAdapter) withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestID(r)
+		logger := ca.logger.With(zap.String("requestId", id))
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithLogger(withRequestID(r.Context(), id), logger)))
+	})
+}
+
+func requirePost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", "POST")
+		if r.Method != http.MethodPost {
+			logging.FromContext(r.Context()).Infof("%s method not allowed", r.Method)
+			http.Error(w, "405 Method Not Allowed", http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (ca *cephReceiveAdapter) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ca.authorized(r) {
+			logging.FromContext(r.Context()).Infof("Unauthorized request to %s", r.URL.Path)
+			http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withSignature rejects the requests not signed with the configured access
+// keys, if any.
+func (ca *cephReceiveAdapter) withSignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ca.signatures != nil {
+			if err := ca.signatures.verify(r); err != nil {
+				logging.FromContext(r.Context()).Infof("Rejecting unsigned request to %s: %s", r.URL.Path, err.Error())
+				http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (ca *cephReceiveAdapter) withSenders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ca.senders != nil {
+			if err := ca.senders.verifyRequest(r); err != nil {
+				logging.FromContext(r.Context()).Infof("Rejecting notifications: %s", err.Error())
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// receive handles the messages of p concurrently until ctx is done.
+func (ca *cephReceiveAdapter) receive(ctx context.Context, p *cehttp.Protocol) {
+	for {
+		msg, respond, err := p.Respond(ctx)
+		if errors.Is(err, io.EOF) {
+			return
+		} else if err != nil {
+			ca.logger.Warnw("Failed to receive message", zap.Error(err))
+			if respond != nil {
+				respond(ctx, nil, cehttp.NewResult(http.StatusBadRequest, "%w", err))
+			}
+			continue
+		}
+		go func() {
+			status, err := ca.handleMessage(msg)
+			var result protocol.Result
+			if err != nil {
+				result = cehttp.NewResult(status, "%w", err)
+			} else if ca.successStatus != 0 && ca.successStatus != http.StatusOK {
+				result = cehttp.NewResult(ca.successStatus, "")
+			}
+			if err := respond(msg.(binding.MessageContext).Context(), nil, result); err != nil {
+				ca.logger.Warnw("Failed to respond", zap.Error(err))
+			}
+		}()
+	}
+}
+
+// handleMessage parses the bucket notifications of a message and posts them,
+// returning the status to respond on failure.
+func (ca *cephReceiveAdapter) handleMessage(msg binding.Message) (int, error) {
+	ctx := msg.(binding.MessageContext).Context()
+	logger := logging.FromContext(ctx)
+	if ca.idle != nil {
+		defer ca.idle.begin()()
+	}
+
+	body, err := notificationBody(ctx, msg)
+	if err != nil {
+		logger.Infof("Error reading message body: %s", err.Error())
+		return http.StatusBadRequest, err
+	}
+
+	if ca.raw {
+		return ca.handleRaw(ctx, body)
+	}
+
+	records, err := ceph2ce.ParseRecords(ca.format, body)
+	if err != nil {
+		logger.Infof("Failed to parse JSON: %s", err.Error())
+		return http.StatusBadRequest, err
+	}
+	logger.Debugw("Received bucket notifications", zap.Int("records", len(records)))
+	if len(records) == 0 {
+		return ca.handleEmpty(ctx, body)
+	}
+	if ca.senders != nil {
+		for _, record := range records {
+			if err := ca.senders.verifyRecord(record.BucketNotification); err != nil {
+				logger.Infow("Rejecting notifications", append(recordFields(record.BucketNotification), zap.Error(err))...)
+				return http.StatusForbidden, err
+			}
+		}
+	}
+	for _, record := range records {
+		recordLogger := logger.With(recordFields(record.BucketNotification)...)
+		recordLogger.Debug("Received Ceph bucket notification")
+		if err := ca.postRecord(logging.WithLogger(ctx, recordLogger), record); err != nil {
+			return ca.failureStatusCode(err), err
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// notificationBody returns the notifications of a message: the data of a
+// structured CloudEvent, e.g. of a proxy wrapping the RGW push, else the
+// body of the request.
+func notificationBody(ctx context.Context, msg binding.Message) ([]byte, error) {
+	if msg.ReadEncoding() == binding.EncodingStructured {
+		event, err := binding.ToEvent(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		return event.Data(), nil
+	}
+	m, ok := msg.(*cehttp.Message)
+	if !ok || m.BodyReader == nil {
+		return nil, nil
+	}
+	defer m.BodyReader.Close()
+	return ioutil.ReadAll(m.BodyReader)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+// serveNotification serves a notification request with the notification
+// handler of ca.
+func serveNotification(t *testing.T, ca *cephReceiveAdapter, w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h, err := ca.notificationHandler(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.ServeHTTP(w, r)
+}
+
+func TestNotificationHandler(t *testing.T) {
+	records, err := json.Marshal(ceph.BucketNotifications{Records: []ceph.BucketNotification{notification1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	structured := cloudevents.NewEvent()
+	structured.SetID("push-1")
+	structured.SetSource("rgw-proxy")
+	structured.SetType("ceph.push")
+	if err := structured.SetData(cloudevents.ApplicationJSON, json.RawMessage(records)); err != nil {
+		t.Fatal(err)
+	}
+	structuredBody, err := json.Marshal(structured)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		method      string
+		contentType string
+		body        []byte
+		status      int
+		sent        int
+	}{
+		"rgw push": {
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        records,
+			status:      http.StatusOK,
+			sent:        1,
+		},
+		"structured cloudevent": {
+			method:      http.MethodPost,
+			contentType: "application/cloudevents+json",
+			body:        structuredBody,
+			status:      http.StatusOK,
+			sent:        1,
+		},
+		"invalid json": {
+			method:      http.MethodPost,
+			contentType: "application/json",
+			body:        []byte("{"),
+			status:      http.StatusBadRequest,
+		},
+		"put": {
+			method: http.MethodPut,
+			body:   records,
+			status: http.StatusBadRequest,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			client := adaptertest.NewTestClient()
+			ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), client: client}
+
+			r := httptest.NewRequest(tc.method, "/", bytes.NewReader(tc.body))
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			w := httptest.NewRecorder()
+			serveNotification(t, ca, w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("Unexpected status: got %d, want %d", w.Code, tc.status)
+			}
+			if sent := client.Sent(); len(sent) != tc.sent {
+				t.Errorf("Unexpected number of events sent: got %d, want %d", len(sent), tc.sent)
+			}
+		})
+	}
+}
+
+func TestNotificationHandlerSuccessStatus(t *testing.T) {
+	for _, status := range []int{http.StatusAccepted, http.StatusNoContent} {
+		ca := &cephReceiveAdapter{
+			logger:        zap.NewNop().Sugar(),
+			client:        adaptertest.NewTestClient(),
+			successStatus: status,
+		}
+		records, err := json.Marshal(ceph.BucketNotifications{Records: []ceph.BucketNotification{notification1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(records))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		serveNotification(t, ca, w, r)
+		if w.Code != status {
+			t.Errorf("Unexpected status: got %d, want %d", w.Code, status)
+		}
+	}
+}
+
+func TestNotificationHandlerUnknownFields(t *testing.T) {
+	client := adaptertest.NewTestClient()
+	ca := &cephReceiveAdapter{
+		logger:  zap.NewNop().Sugar(),
+		client:  client,
+		filters: makeFilters(v1alpha1.CephSourceFilter{EventNames: []string{"s3:ObjectCreated:*"}}),
+	}
+	body := `{"Records":[{"eventName":"s3:ObjectCreated:Put","s3":{"bucket":{"name":"fishbucket"},"object":{"key":"fish9.jpg","checksum":"crc32"}},"opaqueData":"team-a"}]}`
+	r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	serveNotification(t, ca, w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", w.Code)
+	}
+	sent := client.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("Unexpected number of events sent: %d", len(sent))
+	}
+	var data struct {
+		OpaqueData string `json:"opaqueData"`
+		S3         struct {
+			Object struct {
+				Checksum string `json:"checksum"`
+			} `json:"object"`
+		} `json:"s3"`
+	}
+	if err := sent[0].DataAs(&data); err != nil {
+		t.Fatal(err)
+	}
+	if data.OpaqueData != "team-a" || data.S3.Object.Checksum != "crc32" {
+		t.Errorf("Unknown fields not kept: %s", sent[0].Data())
+	}
+}
+
+func TestNotificationHandlerUnauthorized(t *testing.T) {
+	ca := &cephReceiveAdapter{
+		logger: zap.NewNop().Sugar(),
+		client: adaptertest.NewTestClient(),
+		tokens: map[string]string{"/": "secret"},
+	}
+	w := httptest.NewRecorder()
+	serveNotification(t, ca, w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte("{}"))))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status: %d", w.Code)
+	}
+	if w.Header().Get(requestIDHeader) == "" {
+		t.Error("Missing request ID header")
+	}
+}
+
+func TestNotificationHandlerEmpty(t *testing.T) {
+	testCases := map[string]struct {
+		policy string
+		raw    bool
+		events int
+	}{
+		"ignore":        {policy: v1alpha1.EmptyNotificationsIgnore},
+		"warn":          {policy: v1alpha1.EmptyNotificationsWarn},
+		"event":         {policy: v1alpha1.EmptyNotificationsEvent, events: 1},
+		"event on fast": {policy: v1alpha1.EmptyNotificationsEvent, raw: true, events: 1},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			client := adaptertest.NewTestClient()
+			ca := &cephReceiveAdapter{
+				logger:      zap.NewNop().Sugar(),
+				client:      client,
+				emptyPolicy: tc.policy,
+				raw:         tc.raw,
+			}
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(`{"Records":[]}`)))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set(requestIDHeader, "push-1")
+			w := httptest.NewRecorder()
+			serveNotification(t, ca, w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Unexpected status: %d", w.Code)
+			}
+			sent := client.Sent()
+			if len(sent) != tc.events {
+				t.Fatalf("Unexpected number of events sent: got %d, want %d", len(sent), tc.events)
+			}
+			if tc.events == 0 {
+				return
+			}
+			if event := sent[0]; event.Type() != v1alpha1.EmptyNotificationEventType || event.ID() != "push-1" {
+				t.Errorf("Unexpected event: %s", event)
+			}
+		})
+	}
+}
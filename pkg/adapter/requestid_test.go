@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+)
+
+func TestRequestID(t *testing.T) {
+	body, err := json.Marshal(jsonData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		header string
+	}{
+		"honored": {
+			header: "rgw-push-1",
+		},
+		"generated": {},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			client := adaptertest.NewTestClient()
+			ca := &cephReceiveAdapter{
+				logger: zap.NewNop().Sugar(),
+				client: client,
+			}
+
+			r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+			if tc.header != "" {
+				r.Header.Set(requestIDHeader, tc.header)
+			}
+			w := httptest.NewRecorder()
+			serveNotification(t, ca, w, r)
+
+			id := w.Header().Get(requestIDHeader)
+			if id == "" || (tc.header != "" && id != tc.header) {
+				t.Fatalf("Unexpected request ID header: %q", id)
+			}
+			sent := client.Sent()
+			if len(sent) != 1 {
+				t.Fatalf("Unexpected number of events sent: %d", len(sent))
+			}
+			if got := sent[0].Extensions()[requestIDExtension]; got != id {
+				t.Errorf("Unexpected request ID extension: got %v, want %q", got, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/eventing/pkg/adapter/v2"
+	"knative.dev/pkg/logging"
+)
+
+const failedEventSuffix = ".json"
+
+// failedEventStore retains the events that could not be delivered to the
+// sink in a directory, one file per event, dropping the oldest ones beyond
+// max events. It is the "file" SpoolStore, and the queue of the dispatcher.
+type failedEventStore struct {
+	dir string
+	max int
+
+	mu sync.Mutex
+}
+
+func newFailedEventStore(dir string, max int) (*failedEventStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &failedEventStore{dir: dir, max: max}, nil
+}
+
+// Store implements SpoolStore, persisting an event, then dropping the
+// oldest events if needed.
+func (s *failedEventStore) Store(event cloudevents.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.write(event, data); err != nil {
+		return err
+	}
+
+	names, err := s.names()
+	if err != nil {
+		return err
+	}
+	for len(names) > s.max {
+		if err := os.Remove(filepath.Join(s.dir, names[0])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// StoreUnlessFull persists an event unless max events are already stored,
+// returning errQueueFull then rather than dropping the oldest events, which
+// may have been acknowledged already.
+func (s *failedEventStore) StoreUnlessFull(event cloudevents.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names, err := s.names()
+	if err != nil {
+		return err
+	}
+	if len(names) >= s.max {
+		return errQueueFull
+	}
+	return s.write(event, data)
+}
+
+// write writes the file of an event, with s.mu held.
+func (s *failedEventStore) write(event cloudevents.Event, data []byte) error {
+	// Names sort by failure time, the event ID keeping them unique.
+	name := fmt.Sprintf("%020d-%s%s", time.Now().UnixNano(), sanitizeFileName(event.ID()), failedEventSuffix)
+	tmp := filepath.Join(s.dir, "."+name)
+	if err := ioutil.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, name))
+}
+
+// names returns the names of the retained events, oldest first.
+func (s *failedEventStore) names() ([]string, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && !strings.HasPrefix(e.Name(), ".") && strings.HasSuffix(e.Name(), failedEventSuffix) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Count implements SpoolStore.
+func (s *failedEventStore) Count() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names, err := s.names()
+	return len(names), err
+}
+
+// Redrive implements SpoolStore, sending the retained events, oldest first,
+// and removing the delivered ones.
+func (s *failedEventStore) Redrive(ctx context.Context, send func(context.Context, cloudevents.Event) error) (int, error) {
+	s.mu.Lock()
+	names, err := s.names()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, name := range names {
+		path := filepath.Join(s.dir, name)
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			// Dropped meanwhile to make room for newer events.
+			continue
+		} else if err != nil {
+			return sent, err
+		}
+		var event cloudevents.Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			return sent, fmt.Errorf("failed to read retained event %s: %w", name, err)
+		}
+		if err := send(ctx, event); err != nil {
+			return sent, err
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// retain persists an event that could not be delivered. It returns false if
+// the event is not retained, and the failure is to be reported to Ceph.
+func (ca *cephReceiveAdapter) retain(ctx context.Context, event cloudevents.Event, err error) bool {
+	if ca.retention == nil || ca.failureStatusCode(err) == http.StatusServiceUnavailable {
+		// Ceph retries the notification itself.
+		return false
+	}
+	logger := logging.FromContext(ctx)
+	if serr := ca.spool(ctx, event, spoolReasonFailure); serr != nil {
+		logger.Errorw("Failed to retain undelivered event", zap.String("id", event.ID()), zap.Error(serr))
+		return false
+	}
+	logger.Warnw("Retained undelivered event", zap.String("id", event.ID()), zap.Error(err))
+	return true
+}
+
+// redriveResult is the response of the re-drive endpoint.
+type redriveResult struct {
+	Redriven  int    `json:"redriven"`
+	Remaining int    `json:"remaining"`
+	Error     string `json:"error,omitempty"`
+}
+
+// redriveHandler serves the retained events count on GET, and re-drives
+// them to the sink on POST.
+func (ca *cephReceiveAdapter) redriveHandler(w http.ResponseWriter, r *http.Request) {
+	if !ca.authorizedAdmin(r) {
+		http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if ca.retention == nil {
+		http.Error(w, "failed event retention is not enabled", http.StatusNotFound)
+		return
+	}
+
+	var result redriveResult
+	status := http.StatusOK
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		ctx := adapter.ContextWithMetricTag(r.Context(), ca.metricTag())
+		n, err := ca.retention.Redrive(ctx, ca.redriveSpooled)
+		result.Redriven = n
+		if err != nil {
+			result.Error = err.Error()
+			status = http.StatusBadGateway
+		}
+		ca.logger.Infow("Re-drove retained events", zap.Int("count", n), zap.Error(err))
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "405 Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	remaining, err := ca.retention.Count()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result.Remaining = remaining
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
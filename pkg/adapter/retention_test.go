@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+)
+
+func testEvent(id string) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID(id)
+	event.SetSource("ceph:s3.us-east-1.fishbucket")
+	event.SetType("com.amazonaws.s3.s3:ObjectCreated:Put")
+	event.SetData(cloudevents.ApplicationJSON, map[string]string{"key": id})
+	return event
+}
+
+func newTestStore(t *testing.T, max int) *failedEventStore {
+	dir, err := ioutil.TempDir("", "failed-events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	store, err := newFailedEventStore(dir, max)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestFailedEventStoreRotation(t *testing.T) {
+	store := newTestStore(t, 3)
+	for i := 0; i < 5; i++ {
+		if err := store.Store(testEvent(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	if _, err := store.Redrive(context.Background(), func(_ context.Context, event cloudevents.Event) error {
+		got = append(got, event.ID())
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"2", "3", "4"}, got); diff != "" {
+		t.Errorf("Unexpected re-driven events (-want, +got): %s", diff)
+	}
+	if n, err := store.Count(); err != nil || n != 0 {
+		t.Errorf("Unexpected remaining events: %d, %v", n, err)
+	}
+}
+
+func TestFailedEventStoreRedriveStopsOnFailure(t *testing.T) {
+	store := newTestStore(t, 10)
+	for i := 0; i < 3; i++ {
+		if err := store.Store(testEvent(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sent, err := store.Redrive(context.Background(), func(_ context.Context, event cloudevents.Event) error {
+		if event.ID() == "1" {
+			return errors.New("sink down")
+		}
+		return nil
+	})
+	if err == nil || sent != 1 {
+		t.Errorf("Unexpected re-drive: sent %d, error %v", sent, err)
+	}
+	if n, _ := store.Count(); n != 2 {
+		t.Errorf("Unexpected remaining events: got %d, want 2", n)
+	}
+}
+
+func TestRetainUndeliveredEvents(t *testing.T) {
+	client := adaptertest.NewClient()
+	client.Enqueue(adaptertest.NACK(http.StatusInternalServerError))
+	ca := &cephReceiveAdapter{
+		logger:     zap.NewNop().Sugar(),
+		client:     client,
+		retention:  newTestStore(t, 10),
+		adminToken: "admin",
+	}
+	if err := ca.postMessage(context.Background(), notification1); err != nil {
+		t.Fatal("Undelivered event should be retained:", err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/admin/failed-events", nil)
+	r.Header.Set("Authorization", "Bearer admin")
+	ca.redriveHandler(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d %s", w.Code, w.Body.String())
+	}
+	var result redriveResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Redriven != 1 || result.Remaining != 0 {
+		t.Errorf("Unexpected re-drive result: %+v", result)
+	}
+	if sent := client.Sent(); len(sent) != 1 {
+		t.Errorf("Unexpected number of events sent: %d", len(sent))
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"knative.dev/eventing-ceph/pkg/sigv4"
+)
+
+// rgwClient sends signed requests to the S3 and admin APIs of an RGW.
+type rgwClient struct {
+	endpoint *url.URL
+	region   string
+	creds    *s3Credentials
+	client   *http.Client
+}
+
+func newRGWClient(endpoint, region, accessKeyID, secretAccessKey string) (*rgwClient, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if region == "" {
+		region = defaultVerifyRegion
+	}
+	return &rgwClient{
+		endpoint: u,
+		region:   region,
+		creds:    newS3Credentials(accessKeyID, secretAccessKey),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// get sends a signed GET request for path with query, returning the body of
+// the response, which must be successful.
+func (c *rgwClient) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	resp, body, err := c.do(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("GET %s responded %s", path, resp.Status)
+	}
+	return body, nil
+}
+
+// do sends a signed request of the given method for path with query and
+// body, returning the response, whatever its status, and its body.
+func (c *rgwClient) do(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, []byte, error) {
+	u := *c.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = ""
+	u.RawQuery = query.Encode()
+	payloadHash := sigv4.EmptyPayloadHash
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+
+	var respBody []byte
+	resp, err := c.creds.send(func(creds sigv4.Credentials) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+		sigv4.Sign(req, payloadHash, creds, c.region, "s3", time.Now())
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		respBody = b
+		return resp, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, respBody, nil
+}
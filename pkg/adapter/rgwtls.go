@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// newRGWTransport returns the transport of the clients of the RGW, trusting
+// the certificate authorities of the PEM caBundle instead of the system ones
+// if set, and requiring the verified chain of the certificate of the RGW to
+// contain a certificate whose subject public key info has one of the base64
+// SHA-256 hashes of pins, if any. It returns nil, the default transport, if
+// neither is set.
+func newRGWTransport(caBundle string, pins []string) (http.RoundTripper, error) {
+	if caBundle == "" && len(pins) == 0 {
+		return nil, nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caBundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+			return nil, errors.New("no certificate in the CA bundle")
+		}
+		config.RootCAs = pool
+	}
+	if len(pins) > 0 {
+		pinned := make(map[string]struct{}, len(pins))
+		for _, pin := range pins {
+			if hash, err := base64.StdEncoding.DecodeString(pin); err != nil || len(hash) != sha256.Size {
+				return nil, fmt.Errorf("invalid pinned key %q", pin)
+			}
+			pinned[pin] = struct{}{}
+		}
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPinnedKeys(cs.VerifiedChains, pinned)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	return transport, nil
+}
+
+// verifyPinnedKeys checks that one of the verified chains contains a
+// certificate whose key is pinned.
+func verifyPinnedKeys(chains [][]*x509.Certificate, pinned map[string]struct{}) error {
+	for _, chain := range chains {
+		for _, cert := range chain {
+			hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			if _, ok := pinned[base64.StdEncoding.EncodeToString(hash[:])]; ok {
+				return nil
+			}
+		}
+	}
+	return errors.New("no pinned key in the certificate chain of the RGW")
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRGWTransport(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	caBundle := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	hash := sha256.Sum256(srv.Certificate().RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+
+	testCases := map[string]struct {
+		caBundle string
+		pins     []string
+		wantErr  bool
+	}{
+		"trusted certificate authority": {
+			caBundle: caBundle,
+		},
+		"pinned key": {
+			caBundle: caBundle,
+			pins:     []string{otherPin, pin},
+		},
+		"key not pinned": {
+			caBundle: caBundle,
+			pins:     []string{otherPin},
+			wantErr:  true,
+		},
+		"untrusted certificate authority": {
+			pins:    []string{pin},
+			wantErr: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			transport, err := newRGWTransport(tc.caBundle, tc.pins)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rgw, err := newRGWClient(srv.URL, "", "access", "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rgw.client.Transport = transport
+			_, err = rgw.get(context.Background(), "/", nil)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRGWTransportInvalid(t *testing.T) {
+	if transport, err := newRGWTransport("", nil); transport != nil || err != nil {
+		t.Errorf("Unexpected transport without settings: %v, %v", transport, err)
+	}
+	if _, err := newRGWTransport("not a certificate", nil); err == nil {
+		t.Error("Expected an invalid CA bundle to fail")
+	}
+	if _, err := newRGWTransport("", []string{"not a hash"}); err == nil {
+		t.Error("Expected an invalid pinned key to fail")
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+)
+
+// routesReloadInterval is how often the routing table is checked for
+// changes, ConfigMap volumes being updated in place.
+const routesReloadInterval = 10 * time.Second
+
+// route sends the events of the keys starting with Prefix to Sink.
+type route struct {
+	Prefix string `json:"prefix"`
+	Sink   string `json:"sink"`
+}
+
+// parseRoutes parses a YAML list of routes, sorted by decreasing prefix
+// length for the longest matching prefix to win.
+func parseRoutes(data []byte) ([]route, error) {
+	var routes []route
+	if err := yaml.UnmarshalStrict(data, &routes); err != nil {
+		return nil, err
+	}
+	for _, r := range routes {
+		u, err := url.Parse(r.Sink)
+		if err != nil || !u.IsAbs() {
+			return nil, fmt.Errorf("invalid sink %q of prefix %q", r.Sink, r.Prefix)
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].Prefix) > len(routes[j].Prefix)
+	})
+	return routes, nil
+}
+
+// routingTable holds the routes read from a file, reloaded on change.
+type routingTable struct {
+	path   string
+	logger *zap.SugaredLogger
+
+	mu     sync.RWMutex
+	data   []byte
+	routes []route
+}
+
+func newRoutingTable(logger *zap.SugaredLogger, path string) *routingTable {
+	t := &routingTable{path: path, logger: logger}
+	t.reload()
+	return t
+}
+
+// sink returns the sink of the longest prefix of key, empty if none.
+func (t *routingTable) sink(key string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, r := range t.routes {
+		if strings.HasPrefix(key, r.Prefix) {
+			return r.Sink
+		}
+	}
+	return ""
+}
+
+// reload reads the routes again if the file changed, keeping the current
+// ones if it is invalid.
+func (t *routingTable) reload() {
+	data, err := ioutil.ReadFile(t.path)
+	if err != nil {
+		t.logger.Errorw("Failed to read the routing table", zap.Error(err))
+		return
+	}
+	t.mu.RLock()
+	unchanged := bytes.Equal(data, t.data)
+	t.mu.RUnlock()
+	if unchanged {
+		return
+	}
+	routes, err := parseRoutes(data)
+	if err != nil {
+		t.logger.Errorw("Invalid routing table, keeping the current routes", zap.Error(err))
+		return
+	}
+	t.mu.Lock()
+	t.data, t.routes = data, routes
+	t.mu.Unlock()
+	t.logger.Infow("Loaded the routing table", zap.Int("routes", len(routes)))
+}
+
+// watch reloads the routes every interval until ctx is done.
+func (t *routingTable) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.reload()
+		}
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+)
+
+const testRoutes = `
+- prefix: raw/
+  sink: http://broker-a.example.com
+- prefix: raw/images/
+  sink: http://images.example.com
+- prefix: processed/
+  sink: http://broker-b.example.com
+`
+
+func writeRoutes(t *testing.T, path, routes string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(routes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoutingTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	writeRoutes(t, path, testRoutes)
+	table := newRoutingTable(zap.NewNop().Sugar(), path)
+
+	for key, want := range map[string]string{
+		"raw/a.csv":           "http://broker-a.example.com",
+		"raw/images/cat.png":  "http://images.example.com",
+		"processed/a.parquet": "http://broker-b.example.com",
+		"other/a.csv":         "",
+	} {
+		if got := table.sink(key); got != want {
+			t.Errorf("Unexpected sink of %q: got %q, want %q", key, got, want)
+		}
+	}
+
+	writeRoutes(t, path, "- prefix: raw/\n  sink: not a URL\n")
+	table.reload()
+	if got := table.sink("raw/a.csv"); got != "http://broker-a.example.com" {
+		t.Errorf("Invalid routing table replaced the routes: got %q", got)
+	}
+
+	writeRoutes(t, path, "- prefix: raw/\n  sink: http://broker-c.example.com\n")
+	table.reload()
+	if got := table.sink("raw/images/cat.png"); got != "http://broker-c.example.com" {
+		t.Errorf("Routing table not reloaded: got %q", got)
+	}
+}
+
+func TestParseRoutesInvalid(t *testing.T) {
+	for n, routes := range map[string]string{
+		"not a list":     "prefix: raw/",
+		"unknown field":  "- prefix: raw/\n  target: http://broker-a.example.com\n",
+		"relative sink":  "- prefix: raw/\n  sink: /broker-a\n",
+		"malformed yaml": "- prefix: [",
+	} {
+		t.Run(n, func(t *testing.T) {
+			if _, err := parseRoutes([]byte(routes)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestSendRoutedEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	writeRoutes(t, path, testRoutes)
+
+	targets := make(map[string]string)
+	client := adaptertest.NewClient()
+	client.RespondWith(func(ctx context.Context, event cloudevents.Event) protocol.Result {
+		if target := cloudevents.TargetFromContext(ctx); target != nil {
+			targets[event.Subject()] = target.String()
+		}
+		return adaptertest.ACK()
+	})
+	ca := &cephReceiveAdapter{
+		logger: zap.NewNop().Sugar(),
+		client: client,
+		routes: newRoutingTable(zap.NewNop().Sugar(), path),
+	}
+
+	for _, key := range []string{"raw/a.csv", "other/a.csv"} {
+		event := cloudevents.NewEvent()
+		event.SetID(key)
+		event.SetType("com.amazonaws.ObjectCreated:Put")
+		event.SetSource("ceph:s3.us-east-1.fish")
+		event.SetSubject(key)
+		if err := ca.sendCloudEvent(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := targets["raw/a.csv"]; got != "http://broker-a.example.com" {
+		t.Errorf("Unexpected target of the routed event: %q", got)
+	}
+	if got, ok := targets["other/a.csv"]; ok {
+		t.Errorf("Unexpected target of the unrouted event: %q", got)
+	}
+}
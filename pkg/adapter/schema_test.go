@@ -0,0 +1,60 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestSchemaHandler(t *testing.T) {
+	ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar()}
+
+	testCases := map[string]struct {
+		method string
+		path   string
+		status int
+	}{
+		"record":         {method: http.MethodGet, path: "/schemas/record.json", status: http.StatusOK},
+		"cdevents":       {method: http.MethodGet, path: "/schemas/cdevents.json", status: http.StatusOK},
+		"unknown schema": {method: http.MethodGet, path: "/schemas/nope.json", status: http.StatusNotFound},
+		"post":           {method: http.MethodPost, path: "/schemas/record.json", status: http.StatusMethodNotAllowed},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ca.schemaHandler(w, httptest.NewRequest(tc.method, tc.path, nil))
+			if w.Code != tc.status {
+				t.Fatalf("Unexpected status: got %d, want %d", w.Code, tc.status)
+			}
+			if tc.status != http.StatusOK {
+				return
+			}
+			var schema map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &schema); err != nil {
+				t.Fatal("Invalid schema:", err)
+			}
+			if schema["type"] != "object" {
+				t.Errorf("Unexpected schema type: %v", schema["type"])
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/util/sets"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+// senderVerifier checks that notifications come from the expected Ceph
+// clusters. Empty criteria match any sender.
+type senderVerifier struct {
+	eventSources sets.String
+	regions      sets.String
+	networks     []*net.IPNet
+}
+
+// newSenderVerifier returns a verifier for the configured criteria, or nil if
+// none is configured. Addresses that cannot be parsed are ignored, which only
+// narrows the set of accepted senders.
+func newSenderVerifier(logger *zap.SugaredLogger, eventSources, regions, addresses []string) *senderVerifier {
+	if len(eventSources) == 0 && len(regions) == 0 && len(addresses) == 0 {
+		return nil
+	}
+	v := &senderVerifier{
+		eventSources: sets.NewString(eventSources...),
+		regions:      sets.NewString(regions...),
+	}
+	for _, address := range addresses {
+		network, err := parseNetwork(address)
+		if err != nil {
+			logger.Warnw("Ignoring invalid sender address", zap.Error(err))
+			continue
+		}
+		v.networks = append(v.networks, network)
+	}
+	if len(addresses) > 0 && len(v.networks) == 0 {
+		// All addresses were invalid, reject every sender rather than none.
+		v.networks = []*net.IPNet{}
+	}
+	return v
+}
+
+// parseNetwork parses an IP address or a CIDR.
+func parseNetwork(address string) (*net.IPNet, error) {
+	if !strings.Contains(address, "/") {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", address)
+		}
+		bits := 8 * len(ip.To16())
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(address)
+	return network, err
+}
+
+// verifyRequest checks the address the request comes from.
+func (v *senderVerifier) verifyRequest(r *http.Request) error {
+	if v.networks == nil {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range v.networks {
+			if network.Contains(ip) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unexpected sender address %q", host)
+}
+
+// verifyRecord checks the event source and region of a notification.
+func (v *senderVerifier) verifyRecord(notification ceph.BucketNotification) error {
+	if v.eventSources.Len() > 0 && !v.eventSources.Has(notification.EventSource) {
+		return fmt.Errorf("unexpected event source %q", notification.EventSource)
+	}
+	if v.regions.Len() > 0 && !v.regions.Has(notification.AwsRegion) {
+		return fmt.Errorf("unexpected region %q", notification.AwsRegion)
+	}
+	return nil
+}
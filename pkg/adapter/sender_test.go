@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestSenderVerifier(t *testing.T) {
+	testCases := map[string]struct {
+		eventSources []string
+		regions      []string
+		addresses    []string
+		remoteAddr   string
+		wantRequest  bool
+		wantRecord   bool
+	}{
+		"matching sender": {
+			eventSources: []string{"ceph:s3"},
+			regions:      []string{"tenantA", "tenantB"},
+			addresses:    []string{"10.0.0.0/8"},
+			remoteAddr:   "10.1.2.3:4567",
+			wantRequest:  true,
+			wantRecord:   true,
+		},
+		"matching single address": {
+			addresses:   []string{"192.168.1.5"},
+			remoteAddr:  "192.168.1.5:4567",
+			wantRequest: true,
+			wantRecord:  true,
+		},
+		"unexpected address": {
+			addresses:  []string{"10.0.0.0/8"},
+			remoteAddr: "192.168.1.5:4567",
+			wantRecord: true,
+		},
+		"invalid addresses reject all": {
+			addresses:  []string{"not-an-ip"},
+			remoteAddr: "10.1.2.3:4567",
+			wantRecord: true,
+		},
+		"unexpected event source": {
+			eventSources: []string{"aws:s3"},
+			remoteAddr:   "10.1.2.3:4567",
+			wantRequest:  true,
+		},
+		"unexpected region": {
+			regions:     []string{"tenantB"},
+			remoteAddr:  "10.1.2.3:4567",
+			wantRequest: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			v := newSenderVerifier(zap.NewNop().Sugar(), tc.eventSources, tc.regions, tc.addresses)
+			r := httptest.NewRequest("POST", "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if err := v.verifyRequest(r); (err == nil) != tc.wantRequest {
+				t.Errorf("Unexpected request verification: %v", err)
+			}
+			if err := v.verifyRecord(notification1); (err == nil) != tc.wantRecord {
+				t.Errorf("Unexpected record verification: %v", err)
+			}
+		})
+	}
+}
+
+func TestSenderVerifierDisabled(t *testing.T) {
+	if v := newSenderVerifier(zap.NewNop().Sugar(), nil, nil, nil); v != nil {
+		t.Errorf("Expected no verifier without criteria, got %+v", v)
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/metrics"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+)
+
+const (
+	defaultSheddingPeriod = time.Minute
+
+	// defaultPriorityClass is the class of the notifications matching no
+	// class, of high priority.
+	defaultPriorityClass = "default"
+)
+
+var (
+	// eventShedM counts the notifications shed, and loadSheddingM whether
+	// they are.
+	eventShedM = stats.Int64(
+		"event_shed_count",
+		"Number of notifications of low priority shed while the sink is slow",
+		stats.UnitDimensionless,
+	)
+	loadSheddingM = stats.Int64(
+		"load_shedding",
+		"Whether the notifications of low priority are shed",
+		stats.UnitDimensionless,
+	)
+
+	priorityClassKey = tag.MustNewKey("priority_class")
+
+	registerSheddingOnce sync.Once
+)
+
+func registerSheddingViews() error {
+	var err error
+	registerSheddingOnce.Do(func() {
+		tagKeys := []tag.Key{namespaceKey, nameKey, resourceGroupKey}
+		err = view.Register(&view.View{
+			Description: eventShedM.Description(),
+			Measure:     eventShedM,
+			Aggregation: view.Count(),
+			TagKeys:     append(tagKeys, priorityClassKey),
+		}, &view.View{
+			Description: loadSheddingM.Description(),
+			Measure:     loadSheddingM,
+			Aggregation: view.LastValue(),
+			TagKeys:     tagKeys,
+		})
+	})
+	return err
+}
+
+// priorityClass is a class of notifications, shed while the sink is slow if
+// of low priority.
+type priorityClass struct {
+	name    string
+	low     bool
+	filters []ceph2ce.Filter
+}
+
+// parsePriorityClasses parses classes from a JSON array of
+// v1alpha1.CephSourcePriorityClass.
+func parsePriorityClasses(s string) ([]priorityClass, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var specs []v1alpha1.CephSourcePriorityClass
+	if err := json.Unmarshal([]byte(s), &specs); err != nil {
+		return nil, err
+	}
+	classes := make([]priorityClass, 0, len(specs))
+	for _, spec := range specs {
+		class := priorityClass{name: spec.Name, low: spec.Priority != v1alpha1.PriorityHigh}
+		if len(spec.EventNames) > 0 {
+			class.filters = append(class.filters, ceph2ce.EventNames(spec.EventNames...))
+		}
+		if len(spec.Buckets) > 0 {
+			class.filters = append(class.filters, ceph2ce.Buckets(spec.Buckets...))
+		}
+		classes = append(classes, class)
+	}
+	return classes, nil
+}
+
+// loadShedder tracks whether the sink takes longer than a threshold to
+// respond for a sustained period, for the notifications of low priority to
+// be shed meanwhile.
+type loadShedder struct {
+	threshold time.Duration
+	period    time.Duration
+	classes   []priorityClass
+
+	mu       sync.Mutex
+	shedding bool
+	// since is when the sink started exceeding the threshold while not
+	// shedding, or being within it while shedding, and last when the
+	// latest response was observed.
+	since time.Time
+	last  time.Time
+}
+
+func newLoadShedder(threshold, period time.Duration, classes []priorityClass) *loadShedder {
+	if period <= 0 {
+		period = defaultSheddingPeriod
+	}
+	return &loadShedder{threshold: threshold, period: period, classes: classes}
+}
+
+// observe records the time the sink took to respond at now, returning
+// whether notifications are shed and whether that changed.
+func (s *loadShedder) observe(latency time.Duration, now time.Time) (shedding, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = now
+	if (latency > s.threshold) == s.shedding {
+		s.since = time.Time{}
+		return s.shedding, false
+	}
+	if s.since.IsZero() {
+		s.since = now
+	}
+	if now.Sub(s.since) < s.period {
+		return s.shedding, false
+	}
+	s.shedding, s.since = !s.shedding, time.Time{}
+	return s.shedding, true
+}
+
+// active reports whether notifications are shed at now, and whether that
+// changed. Shedding stops once no response was observed for the period, the
+// notifications of high priority being too few to tell whether the sink is
+// still slow.
+func (s *loadShedder) active(now time.Time) (shedding, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shedding && now.Sub(s.last) >= s.period {
+		s.shedding, s.since = false, time.Time{}
+		return false, true
+	}
+	return s.shedding, false
+}
+
+// classify returns the class of a notification, the first it matches, and
+// whether it is of low priority.
+func (s *loadShedder) classify(notification ceph.BucketNotification) (string, bool) {
+	for _, class := range s.classes {
+		if ceph2ce.Match(notification, class.filters...) {
+			return class.name, class.low
+		}
+	}
+	return defaultPriorityClass, false
+}
+
+// shed reports whether a notification is to be shed, being of low priority
+// while the sink is slow.
+func (ca *cephReceiveAdapter) shed(ctx context.Context, notification ceph.BucketNotification) bool {
+	if ca.shedder == nil {
+		return false
+	}
+	shedding, changed := ca.shedder.active(time.Now())
+	if changed {
+		ca.recordShedding(ctx, shedding)
+	}
+	if !shedding {
+		return false
+	}
+	class, low := ca.shedder.classify(notification)
+	if !low {
+		return false
+	}
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(priorityClassKey, class))
+	if err == nil {
+		metrics.Record(ctx, eventShedM.M(1))
+	}
+	return true
+}
+
+// observeSinkLatency records the time the sink took to respond to an event,
+// and whether notifications are shed since.
+func (ca *cephReceiveAdapter) observeSinkLatency(ctx context.Context, latency time.Duration) {
+	ca.recordDispatchLatency(ctx, latency)
+	if ca.shedder == nil {
+		return
+	}
+	if shedding, changed := ca.shedder.observe(latency, time.Now()); changed {
+		ca.recordShedding(ctx, shedding)
+	}
+}
+
+// recordShedding records and logs the start or stop of the shedding.
+func (ca *cephReceiveAdapter) recordShedding(ctx context.Context, shedding bool) {
+	fields := []interface{}{zap.Duration("threshold", ca.shedder.threshold), zap.Duration("period", ca.shedder.period)}
+	value := int64(0)
+	if shedding {
+		value = 1
+		ca.logger.Warnw("The sink responds slower than the threshold, shedding the notifications of low priority", fields...)
+	} else {
+		ca.logger.Infow("Stopped shedding the notifications of low priority", fields...)
+	}
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, loadSheddingM.M(value))
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+func TestLoadShedder(t *testing.T) {
+	s := newLoadShedder(time.Second, time.Minute, nil)
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		after    time.Duration
+		latency  time.Duration
+		shedding bool
+		changed  bool
+	}{
+		{after: 0, latency: 100 * time.Millisecond},
+		// A slow response alone does not start the shedding.
+		{after: 10 * time.Second, latency: 2 * time.Second},
+		{after: 20 * time.Second, latency: 100 * time.Millisecond},
+		{after: 30 * time.Second, latency: 2 * time.Second},
+		{after: 80 * time.Second, latency: 2 * time.Second},
+		// Slow for the whole period.
+		{after: 90 * time.Second, latency: 2 * time.Second, shedding: true, changed: true},
+		{after: 100 * time.Second, latency: 100 * time.Millisecond, shedding: true},
+		// Fast again for the whole period.
+		{after: 160 * time.Second, latency: 100 * time.Millisecond, changed: true},
+	}
+	for i, step := range steps {
+		shedding, changed := s.observe(step.latency, start.Add(step.after))
+		if shedding != step.shedding || changed != step.changed {
+			t.Errorf("Step %d: got shedding %v, changed %v, want %v, %v", i, shedding, changed, step.shedding, step.changed)
+		}
+	}
+}
+
+func TestLoadShedderStopsWithoutResponses(t *testing.T) {
+	s := newLoadShedder(time.Second, time.Minute, nil)
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.observe(2*time.Second, start)
+	if shedding, _ := s.observe(2*time.Second, start.Add(time.Minute)); !shedding {
+		t.Fatal("Expected shedding after a slow period")
+	}
+	if shedding, changed := s.active(start.Add(90 * time.Second)); !shedding || changed {
+		t.Errorf("Unexpected shedding within the period: %v, changed %v", shedding, changed)
+	}
+	if shedding, changed := s.active(start.Add(2 * time.Minute)); shedding || !changed {
+		t.Errorf("Unexpected shedding without response for the period: %v, changed %v", shedding, changed)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	classes, err := parsePriorityClasses(`[
+		{"name": "billing", "priority": "high", "buckets": ["fishbucket"], "eventNames": ["s3:ObjectRemoved:*"]},
+		{"name": "uploads", "eventNames": ["s3:ObjectCreated:*"]}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newLoadShedder(time.Second, 0, classes)
+	if s.period != defaultSheddingPeriod {
+		t.Errorf("Unexpected period: got %v, want %v", s.period, defaultSheddingPeriod)
+	}
+
+	removed := notification1
+	removed.EventName = "s3:ObjectRemoved:Delete"
+	otherBucket := removed
+	otherBucket.S3.Bucket.Name = "catbucket"
+	testCases := map[string]struct {
+		notification ceph.BucketNotification
+		class        string
+		low          bool
+	}{
+		"low":               {notification: notification1, class: "uploads", low: true},
+		"high":              {notification: removed, class: "billing"},
+		"matching no class": {notification: otherBucket, class: defaultPriorityClass},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			class, low := s.classify(tc.notification)
+			if class != tc.class || low != tc.low {
+				t.Errorf("Unexpected class: got %q, low %v, want %q, %v", class, low, tc.class, tc.low)
+			}
+		})
+	}
+}
+
+func TestShedLowPriority(t *testing.T) {
+	classes, err := parsePriorityClasses(`[{"name": "uploads", "eventNames": ["s3:ObjectCreated:*"]}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{
+		logger:  zap.NewNop().Sugar(),
+		client:  client,
+		shedder: newLoadShedder(time.Second, time.Minute, classes),
+	}
+	now := time.Now()
+	ca.shedder.observe(2*time.Second, now.Add(-time.Minute))
+	ca.shedder.observe(2*time.Second, now)
+
+	if err := ca.postMessage(context.Background(), notification1); err != nil {
+		t.Fatal(err)
+	}
+	removed := notification1
+	removed.EventName = "s3:ObjectRemoved:Delete"
+	if err := ca.postMessage(context.Background(), removed); err != nil {
+		t.Fatal(err)
+	}
+	sent := client.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("Unexpected number of events sent: got %d, want 1", len(sent))
+	}
+	if got, want := sent[0].Type(), "com.amazonaws.s3:ObjectRemoved:Delete"; got != want {
+		t.Errorf("Unexpected event sent: got type %q, want %q", got, want)
+	}
+}
+
+func TestShedPushedNotifications(t *testing.T) {
+	classes, err := parsePriorityClasses(`[{"name": "uploads", "eventNames": ["s3:ObjectCreated:*"]}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(jsonData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := adaptertest.NewClient()
+	// Only the shedding is configured, which the fast path does not
+	// support.
+	ca := &cephReceiveAdapter{
+		logger:  zap.NewNop().Sugar(),
+		client:  client,
+		shedder: newLoadShedder(time.Second, time.Minute, classes),
+	}
+	ca.raw = ca.rawRecords()
+	now := time.Now()
+	ca.shedder.observe(2*time.Second, now.Add(-time.Minute))
+	ca.shedder.observe(2*time.Second, now)
+
+	w := httptest.NewRecorder()
+	serveNotification(t, ca, w, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Unexpected status: %d", w.Code)
+	}
+	if sent := client.Sent(); len(sent) != 0 {
+		t.Errorf("Unexpected events sent while shedding: %d", len(sent))
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"knative.dev/eventing-ceph/pkg/sigv4"
+)
+
+// signatureVerifier verifies the AWS Signature Version 4 of the requests
+// against the configured access keys.
+type signatureVerifier struct {
+	secrets         map[string]string
+	maxSkew         time.Duration
+	unsignedPayload bool
+}
+
+// newSignatureVerifier returns the verifier of the given access key IDs, the
+// secret access key of the i-th being read from the <prefix><i> variable, or
+// nil if none.
+func newSignatureVerifier(prefix string, accessKeyIDs []string, maxSkew time.Duration, unsignedPayload bool) *signatureVerifier {
+	if len(accessKeyIDs) == 0 {
+		return nil
+	}
+	secrets := make(map[string]string, len(accessKeyIDs))
+	for i, id := range accessKeyIDs {
+		secrets[id] = os.Getenv(fmt.Sprintf("%s%d", prefix, i))
+	}
+	return &signatureVerifier{secrets: secrets, maxSkew: maxSkew, unsignedPayload: unsignedPayload}
+}
+
+func (v *signatureVerifier) secret(accessKeyID string) (string, bool) {
+	secret, ok := v.secrets[accessKeyID]
+	return secret, ok && secret != ""
+}
+
+// verify verifies the signature of r, whose body is read and replaced.
+func (v *signatureVerifier) verify(r *http.Request) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	sum := sha256.Sum256(body)
+	_, err = sigv4.Verify(r, hex.EncodeToString(sum[:]), v.secret, time.Now(), v.maxSkew, v.unsignedPayload)
+	return err
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/sigv4"
+)
+
+func TestWithSignature(t *testing.T) {
+	os.Setenv("SIGV4_SECRET_ACCESS_KEY_0", "secret-a")
+	defer os.Unsetenv("SIGV4_SECRET_ACCESS_KEY_0")
+	body := `{"Records":[]}`
+	sum := sha256.Sum256([]byte(body))
+
+	testCases := map[string]struct {
+		creds *sigv4.Credentials
+		want  int
+	}{
+		"signed": {
+			creds: &sigv4.Credentials{AccessKeyID: "rgw-a", SecretAccessKey: "secret-a"},
+			want:  http.StatusOK,
+		},
+		"wrong secret": {
+			creds: &sigv4.Credentials{AccessKeyID: "rgw-a", SecretAccessKey: "secret-b"},
+			want:  http.StatusUnauthorized,
+		},
+		"unknown access key": {
+			creds: &sigv4.Credentials{AccessKeyID: "rgw-b", SecretAccessKey: "secret-a"},
+			want:  http.StatusUnauthorized,
+		},
+		"not signed": {
+			want: http.StatusUnauthorized,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			ca := &cephReceiveAdapter{
+				logger:     zap.NewNop().Sugar(),
+				signatures: newSignatureVerifier("SIGV4_SECRET_ACCESS_KEY_", []string{"rgw-a"}, 5*time.Minute, false),
+			}
+			r := httptest.NewRequest(http.MethodPost, "/?topic=photos", strings.NewReader(body))
+			if tc.creds != nil {
+				sigv4.Sign(r, hex.EncodeToString(sum[:]), *tc.creds, "default", "sns", time.Now())
+			}
+			var got string
+			w := httptest.NewRecorder()
+			ca.withSignature(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := ioutil.ReadAll(r.Body)
+				got = string(b)
+			})).ServeHTTP(w, r)
+			if w.Code != tc.want {
+				t.Errorf("Unexpected status: got %d, want %d", w.Code, tc.want)
+			}
+			if tc.want == http.StatusOK && got != body {
+				t.Errorf("Unexpected body: got %q, want %q", got, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/metrics"
+)
+
+// truncatedExtension holds the size of the events sent without data for
+// exceeding the maximum size.
+const truncatedExtension = "truncated"
+
+var (
+	// errEventTooLarge is the failure of the events exceeding the maximum
+	// size, reported as such to the dead letter sink.
+	errEventTooLarge = cehttp.NewResult(http.StatusRequestEntityTooLarge, "event larger than the maximum size")
+
+	// oversizeEventM counts the events exceeding the maximum size, by
+	// policy.
+	oversizeEventM = stats.Int64(
+		"oversize_event_count",
+		"Number of events larger than the maximum size",
+		stats.UnitDimensionless,
+	)
+
+	policyKey = tag.MustNewKey("policy")
+
+	registerSizeOnce sync.Once
+)
+
+func registerSizeViews() error {
+	var err error
+	registerSizeOnce.Do(func() {
+		err = view.Register(&view.View{
+			Description: oversizeEventM.Description(),
+			Measure:     oversizeEventM,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey, policyKey},
+		})
+	})
+	return err
+}
+
+// eventSize returns the size of an event in the JSON format of the
+// structured mode.
+func eventSize(event cloudevents.Event) (int, error) {
+	data, err := json.Marshal(event)
+	return len(data), err
+}
+
+// limitSize applies the size policy to an event larger than the maximum
+// size, returning the event to forward, nil if there is none.
+func (ca *cephReceiveAdapter) limitSize(ctx context.Context, event cloudevents.Event) (*cloudevents.Event, error) {
+	if ca.maxEventSize <= 0 {
+		return &event, nil
+	}
+	logger := logging.FromContext(ctx).With(zap.String("id", event.ID()))
+	size, err := eventSize(event)
+	if err != nil {
+		logger.Warnw("Failed to measure the event size", zap.Error(err))
+		return &event, nil
+	}
+	if size <= ca.maxEventSize {
+		return &event, nil
+	}
+	logger = logger.With(zap.Int("size", size))
+	ca.recordOversize(ctx)
+
+	if ca.sizePolicy == v1alpha1.EventSizeDeadLetter {
+		if ca.delivery.deadLetterSink == "" {
+			logger.Warn("Failing event larger than the maximum size")
+			return nil, errEventTooLarge
+		}
+		return nil, ca.sendDeadLetter(ctx, event, errEventTooLarge)
+	}
+
+	truncated := event.Clone()
+	truncated.DataEncoded = nil
+	truncated.DataBase64 = false
+	truncated.SetDataContentType("")
+	truncated.SetDataSchema("")
+	truncated.SetExtension(truncatedExtension, size)
+	if size, err := eventSize(truncated); err != nil || size > ca.maxEventSize {
+		logger.Warn("Failing event larger than the maximum size even without data")
+		return nil, errEventTooLarge
+	}
+	logger.Info("Sending event larger than the maximum size without data")
+	return &truncated, nil
+}
+
+// recordOversize records an event larger than the maximum size.
+func (ca *cephReceiveAdapter) recordOversize(ctx context.Context) {
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(policyKey, ca.sizePolicy))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, oversizeEventM.M(1))
+}
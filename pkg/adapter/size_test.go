@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func sizedEvent(t *testing.T, dataSize int) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID("1")
+	event.SetType("com.amazonaws.ObjectCreated:Put")
+	event.SetSource("ceph:s3.us-east-1.fish")
+	event.SetSubject("fish9.jpg")
+	event.SetDataSchema("http://schemas.example.com/record.json")
+	if err := event.SetData(cloudevents.ApplicationJSON, map[string]string{"metadata": strings.Repeat("x", dataSize)}); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestLimitSize(t *testing.T) {
+	testCases := map[string]struct {
+		maxEventSize   int
+		policy         string
+		deadLetterSink string
+		dataSize       int
+		wantErr        error
+		wantData       bool
+		wantTruncated  bool
+		wantDeadLetter bool
+	}{
+		"not bounded": {
+			dataSize: 10000,
+			wantData: true,
+		},
+		"within limit": {
+			maxEventSize: 2000,
+			policy:       v1alpha1.EventSizeTruncate,
+			dataSize:     1000,
+			wantData:     true,
+		},
+		"truncated": {
+			maxEventSize:  2000,
+			policy:        v1alpha1.EventSizeTruncate,
+			dataSize:      10000,
+			wantTruncated: true,
+		},
+		"too large even without data": {
+			maxEventSize: 100,
+			policy:       v1alpha1.EventSizeTruncate,
+			dataSize:     10000,
+			wantErr:      errEventTooLarge,
+		},
+		"dead lettered": {
+			maxEventSize:   2000,
+			policy:         v1alpha1.EventSizeDeadLetter,
+			deadLetterSink: "http://dls.example.com",
+			dataSize:       10000,
+			wantDeadLetter: true,
+		},
+		"without dead letter sink": {
+			maxEventSize: 2000,
+			policy:       v1alpha1.EventSizeDeadLetter,
+			dataSize:     10000,
+			wantErr:      errEventTooLarge,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			client := adaptertest.NewClient()
+			ca := &cephReceiveAdapter{
+				logger:       zap.NewNop().Sugar(),
+				client:       client,
+				maxEventSize: tc.maxEventSize,
+				sizePolicy:   tc.policy,
+				delivery:     deliverySettings{deadLetterSink: tc.deadLetterSink},
+			}
+
+			err := ca.forward(context.Background(), sizedEvent(t, tc.dataSize))
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Unexpected error: got %v, want %v", err, tc.wantErr)
+			}
+			sent := client.Sent()
+			if tc.wantErr != nil {
+				if len(sent) != 0 {
+					t.Errorf("Unexpected events sent: %v", sent)
+				}
+				return
+			}
+			if len(sent) != 1 {
+				t.Fatalf("Unexpected number of events sent: %d", len(sent))
+			}
+			event := sent[0]
+			if got := len(event.Data()) > 0; got != tc.wantData && !tc.wantDeadLetter {
+				t.Errorf("Unexpected data: %s", event.Data())
+			}
+			if _, got := event.Extensions()[truncatedExtension]; got != tc.wantTruncated {
+				t.Errorf("Unexpected truncated extension: %v", event.Extensions())
+			}
+			if tc.wantTruncated && (event.DataContentType() != "" || event.DataSchema() != "") {
+				t.Errorf("Unexpected data attributes of the truncated event: %v", event)
+			}
+			if _, got := event.Extensions()[errorCodeExtension]; got != tc.wantDeadLetter {
+				t.Errorf("Unexpected error code extension: %v", event.Extensions())
+			}
+		})
+	}
+}
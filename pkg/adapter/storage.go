@@ -0,0 +1,236 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	// StoreFile and StoreMemory are the names of the stores built in the
+	// adapter.
+	StoreFile   = v1alpha1.StoreFile
+	StoreMemory = v1alpha1.StoreMemory
+)
+
+// SpoolStore retains the events the adapter could not deliver, or spooled
+// during maintenance windows, to re-drive them later. Implementations are
+// registered with RegisterSpoolStore, and selected by name with the
+// SPOOL_STORE variable, "file" by default.
+type SpoolStore interface {
+	// Store retains an event, dropping the oldest ones beyond the capacity
+	// of the store.
+	Store(event cloudevents.Event) error
+
+	// Count returns the number of retained events.
+	Count() (int, error)
+
+	// IDs returns the IDs of the retained events.
+	IDs() (map[string]struct{}, error)
+
+	// Redrive sends the retained events, oldest first, forgetting those
+	// sent. It stops at the first failure, the sink being likely still
+	// down, returning the number of events sent.
+	Redrive(ctx context.Context, send func(context.Context, cloudevents.Event) error) (int, error)
+}
+
+// DedupStore remembers the notifications received, by key, to detect those
+// the RGW pushes again and whether they were delivered. Implementations are
+// registered with RegisterDedupStore, and selected by name with the
+// DEDUP_STORE variable, "memory" by default.
+type DedupStore interface {
+	// Seen records a notification, reporting whether it was already
+	// recorded.
+	Seen(key string) (bool, error)
+
+	// Delivered reports whether a notification was delivered.
+	Delivered(key string) (bool, error)
+
+	// MarkDelivered records that a notification was delivered.
+	MarkDelivered(key string) error
+}
+
+// StoreOptions configure the stores.
+type StoreOptions struct {
+	// Dir is the directory of the volume of spec.retention, for the stores
+	// persisting their state on it.
+	Dir string
+
+	// MaxEvents is the capacity of a SpoolStore, and Window the number of
+	// notifications a DedupStore remembers at least.
+	MaxEvents int
+	Window    int
+}
+
+// SpoolStoreFactory creates a SpoolStore.
+type SpoolStoreFactory func(StoreOptions) (SpoolStore, error)
+
+// DedupStoreFactory creates a DedupStore.
+type DedupStoreFactory func(StoreOptions) (DedupStore, error)
+
+var (
+	storesMu sync.RWMutex
+
+	spoolStores = map[string]SpoolStoreFactory{
+		StoreFile: func(opts StoreOptions) (SpoolStore, error) {
+			return newFailedEventStore(opts.Dir, opts.MaxEvents)
+		},
+		StoreMemory: func(opts StoreOptions) (SpoolStore, error) {
+			return newMemorySpoolStore(opts.MaxEvents), nil
+		},
+	}
+	dedupStores = map[string]DedupStoreFactory{
+		StoreMemory: func(opts StoreOptions) (DedupStore, error) {
+			return newSeenNotifications(opts.Window), nil
+		},
+	}
+)
+
+// RegisterSpoolStore registers a SpoolStore under a name, for downstream
+// builds to add backends, replacing any store of the same name. The name is
+// added to v1alpha1.SpoolStores, for the webhook to accept it. It is meant
+// to be called from init functions.
+func RegisterSpoolStore(name string, factory SpoolStoreFactory) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	spoolStores[name] = factory
+	v1alpha1.SpoolStores.Insert(name)
+}
+
+// RegisterDedupStore registers a DedupStore under a name, for downstream
+// builds to add backends, replacing any store of the same name. The name is
+// added to v1alpha1.DedupStores, for the webhook to accept it. It is meant
+// to be called from init functions.
+func RegisterDedupStore(name string, factory DedupStoreFactory) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	dedupStores[name] = factory
+	v1alpha1.DedupStores.Insert(name)
+}
+
+// newSpoolStore creates the SpoolStore of the given name.
+func newSpoolStore(name string, opts StoreOptions) (SpoolStore, error) {
+	storesMu.RLock()
+	factory, ok := spoolStores[name]
+	storesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown spool store %q", name)
+	}
+	return factory(opts)
+}
+
+// newDedupStore creates the DedupStore of the given name.
+func newDedupStore(name string, opts StoreOptions) (DedupStore, error) {
+	storesMu.RLock()
+	factory, ok := dedupStores[name]
+	storesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown dedup store %q", name)
+	}
+	return factory(opts)
+}
+
+// memorySpoolStore retains events in memory, dropping the oldest ones beyond
+// max events. They are lost on restart.
+type memorySpoolStore struct {
+	max int
+
+	mu     sync.Mutex
+	events *list.List
+}
+
+// spooledEvent is an event retained by memorySpoolStore.
+type spooledEvent struct {
+	event cloudevents.Event
+	// removed is set once the event is delivered or dropped.
+	removed bool
+}
+
+var _ SpoolStore = (*memorySpoolStore)(nil)
+
+func newMemorySpoolStore(max int) *memorySpoolStore {
+	return &memorySpoolStore{max: max, events: list.New()}
+}
+
+// Store implements SpoolStore.
+func (s *memorySpoolStore) Store(event cloudevents.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events.PushBack(&spooledEvent{event: event.Clone()})
+	for s.events.Len() > s.max {
+		s.events.Remove(s.events.Front()).(*spooledEvent).removed = true
+	}
+	return nil
+}
+
+// Count implements SpoolStore.
+func (s *memorySpoolStore) Count() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events.Len(), nil
+}
+
+// IDs implements SpoolStore.
+func (s *memorySpoolStore) IDs() (map[string]struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make(map[string]struct{}, s.events.Len())
+	for e := s.events.Front(); e != nil; e = e.Next() {
+		ids[e.Value.(*spooledEvent).event.ID()] = struct{}{}
+	}
+	return ids, nil
+}
+
+// Redrive implements SpoolStore.
+func (s *memorySpoolStore) Redrive(ctx context.Context, send func(context.Context, cloudevents.Event) error) (int, error) {
+	s.mu.Lock()
+	var pending []*list.Element
+	for e := s.events.Front(); e != nil; e = e.Next() {
+		pending = append(pending, e)
+	}
+	s.mu.Unlock()
+
+	sent := 0
+	for _, e := range pending {
+		spooled := e.Value.(*spooledEvent)
+		s.mu.Lock()
+		removed := spooled.removed
+		s.mu.Unlock()
+		if removed {
+			// Dropped meanwhile to make room for newer events.
+			continue
+		}
+		if err := send(ctx, spooled.event); err != nil {
+			return sent, err
+		}
+		s.mu.Lock()
+		if !spooled.removed {
+			s.events.Remove(e)
+			spooled.removed = true
+		}
+		s.mu.Unlock()
+		sent++
+	}
+	return sent, nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/go-cmp/cmp"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func TestMemorySpoolStoreRotation(t *testing.T) {
+	store := newMemorySpoolStore(3)
+	for i := 0; i < 5; i++ {
+		if err := store.Store(testEvent(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := store.IDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]struct{}{"2": {}, "3": {}, "4": {}}, ids); diff != "" {
+		t.Errorf("Unexpected IDs (-want, +got): %s", diff)
+	}
+
+	var got []string
+	if _, err := store.Redrive(context.Background(), func(_ context.Context, event cloudevents.Event) error {
+		got = append(got, event.ID())
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"2", "3", "4"}, got); diff != "" {
+		t.Errorf("Unexpected re-driven events (-want, +got): %s", diff)
+	}
+	if n, err := store.Count(); err != nil || n != 0 {
+		t.Errorf("Unexpected remaining events: %d, %v", n, err)
+	}
+}
+
+func TestMemorySpoolStoreRedriveStopsOnFailure(t *testing.T) {
+	store := newMemorySpoolStore(10)
+	for i := 0; i < 3; i++ {
+		if err := store.Store(testEvent(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sent, err := store.Redrive(context.Background(), func(_ context.Context, event cloudevents.Event) error {
+		if event.ID() == "1" {
+			return errors.New("sink down")
+		}
+		return nil
+	})
+	if err == nil || sent != 1 {
+		t.Errorf("Unexpected re-drive: sent %d, error %v", sent, err)
+	}
+	if n, _ := store.Count(); n != 2 {
+		t.Errorf("Unexpected remaining events: got %d, want 2", n)
+	}
+}
+
+// windowDedupStore is a DedupStore registered by the tests, recording the
+// window it was created with.
+type windowDedupStore struct {
+	*seenNotifications
+	window int
+}
+
+func TestStoreRegistry(t *testing.T) {
+	if _, err := newSpoolStore("sqlite", StoreOptions{MaxEvents: 10}); err == nil {
+		t.Error("Expected an error creating an unknown spool store")
+	}
+	if _, err := newDedupStore("window", StoreOptions{Window: 10}); err == nil {
+		t.Error("Expected an error creating an unknown dedup store")
+	}
+
+	spool, err := newSpoolStore(StoreFile, StoreOptions{Dir: t.TempDir(), MaxEvents: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spool.(*failedEventStore); !ok {
+		t.Errorf("Unexpected file spool store: %T", spool)
+	}
+	if spool, err = newSpoolStore(StoreMemory, StoreOptions{MaxEvents: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spool.(*memorySpoolStore); !ok {
+		t.Errorf("Unexpected memory spool store: %T", spool)
+	}
+
+	RegisterDedupStore("window", func(opts StoreOptions) (DedupStore, error) {
+		return windowDedupStore{newSeenNotifications(opts.Window), opts.Window}, nil
+	})
+	t.Cleanup(func() {
+		storesMu.Lock()
+		delete(dedupStores, "window")
+		v1alpha1.DedupStores.Delete("window")
+		storesMu.Unlock()
+	})
+	if !v1alpha1.DedupStores.Has("window") {
+		t.Error("Registered dedup store should be accepted by the webhook")
+	}
+	dedup, err := newDedupStore("window", StoreOptions{Window: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := dedup.(windowDedupStore); !ok || got.window != 7 {
+		t.Errorf("Unexpected registered dedup store: %#v", dedup)
+	}
+}
+
+func TestDedupStoreDelivered(t *testing.T) {
+	store, err := newDedupStore(StoreMemory, StoreOptions{Window: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered, _ := store.Delivered("fish/a@1"); delivered {
+		t.Error("Unknown notification reported delivered")
+	}
+	if seen, _ := store.Seen("fish/a@1"); seen {
+		t.Error("First notification reported seen")
+	}
+	if delivered, _ := store.Delivered("fish/a@1"); delivered {
+		t.Error("Undelivered notification reported delivered")
+	}
+	if err := store.MarkDelivered("fish/a@1"); err != nil {
+		t.Fatal(err)
+	}
+	if delivered, _ := store.Delivered("fish/a@1"); !delivered {
+		t.Error("Delivered notification not reported delivered")
+	}
+	if seen, _ := store.Seen("fish/a@1"); !seen {
+		t.Error("Notification pushed again not reported seen")
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.uber.org/zap"
+	"knative.dev/pkg/metrics"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+)
+
+var (
+	// trafficHealthyM is whether the notifications of an event name
+	// received over the last period met the expectation.
+	trafficHealthyM = stats.Int64(
+		"traffic_healthy",
+		"Whether the notifications received over the last period met the expectation",
+		stats.UnitDimensionless,
+	)
+
+	eventNameKey = tag.MustNewKey("event_name")
+
+	registerTrafficOnce sync.Once
+)
+
+func registerTrafficViews() error {
+	var err error
+	registerTrafficOnce.Do(func() {
+		err = view.Register(&view.View{
+			Description: trafficHealthyM.Description(),
+			Measure:     trafficHealthyM,
+			Aggregation: view.LastValue(),
+			TagKeys:     []tag.Key{namespaceKey, nameKey, resourceGroupKey, eventNameKey},
+		})
+	})
+	return err
+}
+
+// trafficExpectation counts the notifications of an event name received per
+// period, expecting at least minCount of them.
+type trafficExpectation struct {
+	eventName string
+	match     ceph2ce.Filter
+	minCount  int64
+	period    time.Duration
+
+	count int64
+
+	// healthy is only accessed by the goroutine checking the expectation.
+	healthy bool
+}
+
+// trafficExpectationSpec is the JSON form of the expectations passed to the
+// adapter.
+type trafficExpectationSpec struct {
+	EventName string `json:"eventName"`
+	MinCount  int64  `json:"minCount,omitempty"`
+	Period    string `json:"period"`
+}
+
+// parseTrafficExpectations parses expectations from a JSON array of
+// trafficExpectationSpec.
+func parseTrafficExpectations(s string) ([]*trafficExpectation, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var specs []trafficExpectationSpec
+	if err := json.Unmarshal([]byte(s), &specs); err != nil {
+		return nil, err
+	}
+	expectations := make([]*trafficExpectation, 0, len(specs))
+	for _, spec := range specs {
+		period, err := time.ParseDuration(spec.Period)
+		if err != nil {
+			return nil, err
+		}
+		minCount := spec.MinCount
+		if minCount < 1 {
+			minCount = 1
+		}
+		expectations = append(expectations, &trafficExpectation{
+			eventName: spec.EventName,
+			match:     ceph2ce.EventNames(spec.EventName),
+			minCount:  minCount,
+			period:    period,
+			healthy:   true,
+		})
+	}
+	return expectations, nil
+}
+
+// check returns whether the notifications counted since the last check met
+// the expectation, and whether that changed, restarting the count.
+func (e *trafficExpectation) check() (count int64, healthy, changed bool) {
+	count = atomic.SwapInt64(&e.count, 0)
+	healthy = count >= e.minCount
+	changed = healthy != e.healthy
+	e.healthy = healthy
+	return count, healthy, changed
+}
+
+// countTraffic counts a notification received against the expectations it
+// matches.
+func (ca *cephReceiveAdapter) countTraffic(notification ceph.BucketNotification) {
+	for _, e := range ca.traffic {
+		if e.match(notification) {
+			atomic.AddInt64(&e.count, 1)
+		}
+	}
+}
+
+// watchTraffic checks each expectation at the end of each of its periods
+// until ctx is done, reporting the ones not met. The first period starts
+// with the adapter, so that expectations are not checked before a full
+// period.
+func (ca *cephReceiveAdapter) watchTraffic(ctx context.Context, e *trafficExpectation) {
+	ticker := time.NewTicker(e.period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		count, healthy, changed := e.check()
+		ca.recordTraffic(ctx, e.eventName, healthy)
+		if !changed {
+			continue
+		}
+		fields := []interface{}{
+			zap.String("eventName", e.eventName),
+			zap.Int64("count", count),
+			zap.Int64("minCount", e.minCount),
+			zap.Duration("period", e.period),
+		}
+		if healthy {
+			ca.logger.Infow("The notifications received meet the traffic expectation again", fields...)
+		} else {
+			ca.logger.Warnw("Fewer notifications received than expected, check the notification configuration of the RGW", fields...)
+		}
+	}
+}
+
+// recordTraffic records whether the expectation of an event name was met.
+func (ca *cephReceiveAdapter) recordTraffic(ctx context.Context, eventName string, healthy bool) {
+	ctx, err := tag.New(ctx,
+		tag.Insert(namespaceKey, ca.namespace),
+		tag.Insert(nameKey, ca.name),
+		tag.Insert(resourceGroupKey, resourceGroup),
+		tag.Insert(eventNameKey, eventName))
+	if err != nil {
+		return
+	}
+	var value int64
+	if healthy {
+		value = 1
+	}
+	metrics.Record(ctx, trafficHealthyM.M(value))
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestTrafficExpectations(t *testing.T) {
+	expectations, err := parseTrafficExpectations(`[{"eventName":"s3:ObjectCreated:*","minCount":2,"period":"1h"},{"eventName":"s3:ObjectRemoved:Delete","period":"24h"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expectations) != 2 || expectations[1].minCount != 1 {
+		t.Fatalf("Unexpected expectations: %+v", expectations)
+	}
+	ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), traffic: expectations}
+
+	created := notification1
+	ca.countTraffic(created)
+	if _, healthy, changed := expectations[0].check(); healthy || !changed {
+		t.Errorf("Got healthy %v, changed %v, want unhealthy after one notification", healthy, changed)
+	}
+	ca.countTraffic(created)
+	ca.countTraffic(created)
+	if count, healthy, changed := expectations[0].check(); count != 2 || !healthy || !changed {
+		t.Errorf("Got count %d, healthy %v, changed %v, want healthy after two notifications", count, healthy, changed)
+	}
+	// The count restarts each period.
+	if count, healthy, _ := expectations[0].check(); count != 0 || healthy {
+		t.Errorf("Got count %d, healthy %v, want unhealthy without notifications", count, healthy)
+	}
+	// Other event names are not counted.
+	if count, healthy, changed := expectations[1].check(); count != 0 || healthy || !changed {
+		t.Errorf("Got count %d, healthy %v, changed %v, want no removal counted", count, healthy, changed)
+	}
+
+	if _, err := parseTrafficExpectations(`[{"eventName":"s3:ObjectCreated:*","period":"hourly"}]`); err == nil {
+		t.Error("Expected an error for an invalid period")
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// usagePeriod is the granularity of the usage log of the RGW, which
+	// sums the usage per hour.
+	usagePeriod = time.Hour
+
+	// usageTimeFormat is the format of the start and end of the usage
+	// queried from the admin API.
+	usageTimeFormat = "2006-01-02 15:04:05"
+)
+
+// usagePoller reads the usage of users from the admin API of the RGW.
+type usagePoller struct {
+	rgw      *rgwClient
+	users    []string
+	interval time.Duration
+}
+
+func newUsagePoller(endpoint, region, accessKeyID, secretAccessKey string, users []string, interval time.Duration) (*usagePoller, error) {
+	rgw, err := newRGWClient(endpoint, region, accessKeyID, secretAccessKey)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = usagePeriod
+	}
+	return &usagePoller{
+		rgw:      rgw,
+		users:    users,
+		interval: interval,
+	}, nil
+}
+
+// usageLog is the usage returned by the admin API.
+type usageLog struct {
+	Entries []struct {
+		User    string `json:"user"`
+		Buckets []struct {
+			Bucket     string          `json:"bucket"`
+			Owner      string          `json:"owner"`
+			Epoch      int64           `json:"epoch"`
+			Categories []usageCategory `json:"categories"`
+		} `json:"buckets"`
+	} `json:"entries"`
+}
+
+// usageCategory is the usage of a category of operations, e.g. "put_obj".
+type usageCategory struct {
+	Category      string `json:"category"`
+	BytesSent     uint64 `json:"bytes_sent"`
+	BytesReceived uint64 `json:"bytes_received"`
+	Ops           uint64 `json:"ops"`
+	SuccessfulOps uint64 `json:"successful_ops"`
+}
+
+// usageReport is the data of the events reporting the usage of a bucket by
+// a user over an hour.
+type usageReport struct {
+	User          string          `json:"user"`
+	Bucket        string          `json:"bucket,omitempty"`
+	Owner         string          `json:"owner,omitempty"`
+	Start         time.Time       `json:"start"`
+	End           time.Time       `json:"end"`
+	BytesSent     uint64          `json:"bytesSent"`
+	BytesReceived uint64          `json:"bytesReceived"`
+	Ops           uint64          `json:"ops"`
+	SuccessfulOps uint64          `json:"successfulOps"`
+	Categories    []usageCategory `json:"categories,omitempty"`
+}
+
+// reports returns the usage logged from start to end, of all the users
+// when none is set, per user, bucket and hour.
+func (p *usagePoller) reports(ctx context.Context, start, end time.Time) ([]usageReport, error) {
+	users := p.users
+	if len(users) == 0 {
+		users = []string{""}
+	}
+	var reports []usageReport
+	for _, user := range users {
+		log, err := p.read(ctx, user, start, end)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range log.Entries {
+			for _, b := range entry.Buckets {
+				report := usageReport{
+					User:       entry.User,
+					Bucket:     b.Bucket,
+					Owner:      b.Owner,
+					Start:      time.Unix(b.Epoch, 0).UTC(),
+					End:        time.Unix(b.Epoch, 0).UTC().Add(usagePeriod),
+					Categories: b.Categories,
+				}
+				for _, c := range b.Categories {
+					report.BytesSent += c.BytesSent
+					report.BytesReceived += c.BytesReceived
+					report.Ops += c.Ops
+					report.SuccessfulOps += c.SuccessfulOps
+				}
+				reports = append(reports, report)
+			}
+		}
+	}
+	return reports, nil
+}
+
+// read reads the usage of a user, or of all the users if empty, logged from
+// start to end.
+func (p *usagePoller) read(ctx context.Context, user string, start, end time.Time) (*usageLog, error) {
+	query := url.Values{
+		"format":       {"json"},
+		"show-entries": {"true"},
+		"show-summary": {"false"},
+		"start":        {start.UTC().Format(usageTimeFormat)},
+		"end":          {end.UTC().Format(usageTimeFormat)},
+	}
+	if user != "" {
+		query.Set("uid", user)
+	}
+	body, err := p.rgw.get(ctx, "/admin/usage", query)
+	if err != nil {
+		return nil, err
+	}
+	var log usageLog
+	if err := json.Unmarshal(body, &log); err != nil {
+		return nil, fmt.Errorf("failed to decode the usage: %w", err)
+	}
+	return &log, nil
+}
+
+// pollUsage reports, every interval until ctx is done, the hours of usage
+// completed since the last report. The hours that failed to be reported
+// are reported again on the next poll.
+func (ca *cephReceiveAdapter) pollUsage(ctx context.Context) {
+	next := time.Now().Truncate(usagePeriod)
+	ticker := time.NewTicker(ca.usage.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			end := time.Now().Truncate(usagePeriod)
+			if !end.After(next) {
+				continue
+			}
+			if err := ca.reportUsage(ctx, next, end); err != nil {
+				logging.FromContext(ctx).Warnw("Failed to report the usage", zap.Error(err))
+				continue
+			}
+			next = end
+		}
+	}
+}
+
+// reportUsage sends an event per user, bucket and hour of usage logged from
+// start to end. Their IDs identify the hour of usage, for the consumers to
+// tell those reported again.
+func (ca *cephReceiveAdapter) reportUsage(ctx context.Context, start, end time.Time) error {
+	reports, err := ca.usage.reports(ctx, start, end)
+	if err != nil {
+		return err
+	}
+	for _, report := range reports {
+		event := cloudevents.NewEvent()
+		event.SetID(fmt.Sprintf("%s/%s/%d", report.User, report.Bucket, report.Start.Unix()))
+		event.SetType(v1alpha1.UsageEventType)
+		event.SetSource(ca.sourceURI())
+		event.SetSubject(report.Bucket)
+		event.SetTime(report.Start)
+		if err := event.SetData(cloudevents.ApplicationJSON, report); err != nil {
+			return err
+		}
+		if err := ca.forward(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
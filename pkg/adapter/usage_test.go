@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"knative.dev/eventing-ceph/pkg/adapter/adaptertest"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const usageResponse = `{
+  "entries": [{
+    "user": "tenant-a",
+    "buckets": [{
+      "bucket": "fishbucket",
+      "time": "2021-05-01 10:00:00.000000Z",
+      "epoch": 1619863200,
+      "owner": "tenant-a",
+      "categories": [
+        {"category": "put_obj", "bytes_sent": 0, "bytes_received": 1024, "ops": 2, "successful_ops": 2},
+        {"category": "get_obj", "bytes_sent": 4096, "bytes_received": 0, "ops": 5, "successful_ops": 4}
+      ]
+    }]
+  }],
+  "summary": []
+}`
+
+func TestReportUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/admin/usage" || query.Get("uid") != "tenant-a" {
+			t.Errorf("Unexpected request: %s", r.URL)
+		}
+		if got, want := query.Get("start"), "2021-05-01 10:00:00"; got != want {
+			t.Errorf("Unexpected start: got %q, want %q", got, want)
+		}
+		if got, want := query.Get("end"), "2021-05-01 11:00:00"; got != want {
+			t.Errorf("Unexpected end: got %q, want %q", got, want)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+			t.Errorf("Unexpected Authorization: %s", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(usageResponse))
+	}))
+	defer srv.Close()
+
+	usage, err := newUsagePoller(srv.URL, "", "access", "secret", []string{"tenant-a"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{
+		logger:    zap.NewNop().Sugar(),
+		client:    client,
+		namespace: "ns",
+		name:      "source",
+		usage:     usage,
+	}
+	start := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err := ca.reportUsage(context.Background(), start, start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := client.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("Unexpected number of events sent: got %d, want 1", len(sent))
+	}
+	event := sent[0]
+	if event.Type() != v1alpha1.UsageEventType || event.Subject() != "fishbucket" || event.ID() != "tenant-a/fishbucket/1619863200" {
+		t.Errorf("Unexpected event: %s", event)
+	}
+	if !event.Time().Equal(start) {
+		t.Errorf("Unexpected event time: got %s, want %s", event.Time(), start)
+	}
+	var report usageReport
+	if err := event.DataAs(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.BytesSent != 4096 || report.BytesReceived != 1024 || report.Ops != 7 || report.SuccessfulOps != 6 {
+		t.Errorf("Unexpected totals: %+v", report)
+	}
+	if !report.End.Equal(start.Add(time.Hour)) || len(report.Categories) != 2 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+}
+
+func TestReportUsageFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	usage, err := newUsagePoller(srv.URL, "", "access", "secret", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := adaptertest.NewClient()
+	ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), client: client, usage: usage}
+	start := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	if err := ca.reportUsage(context.Background(), start, start.Add(time.Hour)); err == nil {
+		t.Error("Expected an error")
+	}
+	if sent := client.Sent(); len(sent) != 0 {
+		t.Errorf("Unexpected events sent: %d", len(sent))
+	}
+}
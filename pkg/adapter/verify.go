@@ -0,0 +1,264 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+	"knative.dev/eventing-ceph/pkg/sigv4"
+	"knative.dev/pkg/logging"
+)
+
+const (
+	// verifiedExtension is the CloudEvent extension telling whether the
+	// object still has the eTag of the notification.
+	verifiedExtension = "verified"
+
+	// deleteMarkerHeader tells whether an object version is a delete marker.
+	deleteMarkerHeader = "X-Amz-Delete-Marker"
+
+	defaultVerifyRegion = "us-east-1"
+)
+
+// etagVerifier checks notifications against a HEAD of their object.
+type etagVerifier struct {
+	endpoint *url.URL
+	region   string
+	creds    *s3Credentials
+	client   *http.Client
+}
+
+func newETagVerifier(endpoint, region, accessKeyID, secretAccessKey string) (*etagVerifier, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if region == "" {
+		region = defaultVerifyRegion
+	}
+	return &etagVerifier{
+		endpoint: u,
+		region:   region,
+		creds:    newS3Credentials(accessKeyID, secretAccessKey),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// verify reports whether the object of the given bucket and key, and
+// version if not empty, has the given eTag. A missing object does not.
+func (v *etagVerifier) verify(ctx context.Context, bucket, key, versionID, etag string) (bool, error) {
+	resp, err := v.head(ctx, bucket, key, versionID)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode/100 != 2:
+		return false, fmt.Errorf("HEAD %s/%s responded %s", bucket, key, resp.Status)
+	}
+	return trimETag(resp.Header.Get("ETag")) == trimETag(etag), nil
+}
+
+// isDeleteMarker reports whether the given version of an object is a delete
+// marker, which S3 signals with the x-amz-delete-marker header.
+func (v *etagVerifier) isDeleteMarker(ctx context.Context, bucket, key, versionID string) (bool, error) {
+	resp, err := v.head(ctx, bucket, key, versionID)
+	if err != nil {
+		return false, err
+	}
+	if resp.Header.Get(deleteMarkerHeader) == "true" {
+		return true, nil
+	}
+	if resp.StatusCode != http.StatusNotFound && resp.StatusCode/100 != 2 {
+		return false, fmt.Errorf("HEAD %s/%s responded %s", bucket, key, resp.Status)
+	}
+	return false, nil
+}
+
+// metadata returns the user metadata of an object with the given name, e.g.
+// "x-amz-meta-copy-source".
+func (v *etagVerifier) metadata(ctx context.Context, bucket, key, versionID, name string) (string, error) {
+	header, err := v.headers(ctx, bucket, key, versionID)
+	if err != nil {
+		return "", err
+	}
+	return header.Get(name), nil
+}
+
+// headers returns the headers of a HEAD of an object, holding its user
+// metadata.
+func (v *etagVerifier) headers(ctx context.Context, bucket, key, versionID string) (http.Header, error) {
+	resp, err := v.head(ctx, bucket, key, versionID)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("HEAD %s/%s responded %s", bucket, key, resp.Status)
+	}
+	return resp.Header, nil
+}
+
+// head sends a signed HEAD request for an object, closing the body of the
+// response.
+func (v *etagVerifier) head(ctx context.Context, bucket, key, versionID string) (*http.Response, error) {
+	u := *v.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + bucket + "/" + key
+	u.RawPath = ""
+	if versionID != "" {
+		u.RawQuery = url.Values{"versionId": {versionID}}.Encode()
+	}
+	return v.creds.send(func(creds sigv4.Credentials) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Amz-Content-Sha256", sigv4.EmptyPayloadHash)
+		sigv4.Sign(req, sigv4.EmptyPayloadHash, creds, v.region, "s3", time.Now())
+
+		resp, err := v.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		return resp, nil
+	})
+}
+
+func trimETag(etag string) string {
+	return strings.Trim(etag, `"`)
+}
+
+// detectDeleteMarker turns the removals of object versions that are delete
+// markers into delete marker creations, for the RGW versions notifying both
+// as s3:ObjectRemoved:Delete.
+func (ca *cephReceiveAdapter) detectDeleteMarker(ctx context.Context, notification ceph.BucketNotification) ceph.BucketNotification {
+	if notification.EventName != ceph2ce.EventNameDelete || !ceph2ce.Versioned(notification) {
+		return notification
+	}
+	marker, err := ca.verifier.isDeleteMarker(ctx, notification.S3.Bucket.Name, ca.converter.ObjectKey(notification), notification.S3.Object.VersionID)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to inspect the removed version", zap.Error(err))
+		return notification
+	}
+	if marker {
+		notification.EventName = ceph2ce.EventNameDeleteMarkerCreated
+	}
+	return notification
+}
+
+// resolveMetadata reads the user metadata promoted to extensions from a HEAD
+// of created objects when the notification does not carry it.
+func (ca *cephReceiveAdapter) resolveMetadata(ctx context.Context, notification ceph.BucketNotification) ceph.BucketNotification {
+	if !strings.Contains(notification.EventName, "ObjectCreated") {
+		return notification
+	}
+	present := make(map[string]bool, len(notification.S3.Object.Metadata))
+	for _, m := range notification.S3.Object.Metadata {
+		present[strings.ToLower(m.Key)] = true
+	}
+	var missing []string
+	for _, key := range ca.converter.MetadataExtensions {
+		if key = ceph2ce.MetadataKey(key); !present[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return notification
+	}
+
+	object := notification.S3.Object
+	header, err := ca.verifier.headers(ctx, notification.S3.Bucket.Name, ca.converter.ObjectKey(notification), object.VersionID)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to resolve the object metadata", zap.Error(err))
+		return notification
+	}
+	metadata := append([]ceph.MetadataEntry(nil), object.Metadata...)
+	for _, key := range missing {
+		if values := header.Values(key); len(values) > 0 {
+			metadata = append(metadata, ceph.MetadataEntry{Key: key, Value: values[0]})
+		}
+	}
+	notification.S3.Object.Metadata = metadata
+	return notification
+}
+
+// resolveCopySource reads the copy source of copied objects from a HEAD of
+// the object when the notification does not carry their metadata.
+func (ca *cephReceiveAdapter) resolveCopySource(ctx context.Context, notification ceph.BucketNotification) ceph.BucketNotification {
+	if notification.EventName != ceph2ce.EventNameCopy || notification.S3.Object.CopySource != nil {
+		return notification
+	}
+	for _, m := range notification.S3.Object.Metadata {
+		if strings.EqualFold(m.Key, ceph2ce.CopySourceMetadataKey) {
+			return notification
+		}
+	}
+
+	object := notification.S3.Object
+	value, err := ca.verifier.metadata(ctx, notification.S3.Bucket.Name, ca.converter.ObjectKey(notification), object.VersionID, ceph2ce.CopySourceMetadataKey)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to resolve the copy source", zap.Error(err))
+		return notification
+	}
+	if value == "" {
+		return notification
+	}
+	copySource, err := ceph2ce.ParseCopySource(value)
+	if err != nil {
+		logging.FromContext(ctx).Infow("Failed to parse the copy source", zap.Error(err))
+		return notification
+	}
+	notification.S3.Object.CopySource = copySource
+	return notification
+}
+
+// resolveObject reads the server-side encryption, storage class and object
+// lock of created and transitioned objects from a HEAD of the object when the
+// notification does not carry them.
+func (ca *cephReceiveAdapter) resolveObject(ctx context.Context, notification ceph.BucketNotification) ceph.BucketNotification {
+	if !strings.Contains(notification.EventName, "ObjectCreated") && !ceph2ce.IsTransition(notification) {
+		return notification
+	}
+	object := notification.S3.Object
+	if ceph2ce.Encryption(notification) != nil && object.StorageClass != "" && ceph2ce.ObjectLock(notification) != nil {
+		return notification
+	}
+	header, err := ca.verifier.headers(ctx, notification.S3.Bucket.Name, ca.converter.ObjectKey(notification), object.VersionID)
+	if err != nil {
+		logging.FromContext(ctx).Warnw("Failed to resolve the object encryption, storage class and lock", zap.Error(err))
+		return notification
+	}
+	if ceph2ce.Encryption(notification) == nil {
+		notification.S3.Object.Encryption = ceph2ce.EncryptionFromHeader(header)
+	}
+	if object.StorageClass == "" {
+		notification.S3.Object.StorageClass = ceph2ce.StorageClassFromHeader(header)
+	}
+	if ceph2ce.ObjectLock(notification) == nil {
+		notification.S3.Object.ObjectLock = ceph2ce.ObjectLockFromHeader(header)
+	}
+	return notification
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+	adaptertest "knative.dev/eventing/pkg/adapter/v2/test"
+)
+
+func TestETagVerifier(t *testing.T) {
+	testCases := map[string]struct {
+		status  int
+		etag    string
+		want    bool
+		wantErr bool
+	}{
+		"same etag": {
+			status: http.StatusOK,
+			etag:   `"37b51d194a7513e45b56f6524f2d51f2"`,
+			want:   true,
+		},
+		"overwritten": {
+			status: http.StatusOK,
+			etag:   `"0cc175b9c0f1b6a831c399e269772661"`,
+		},
+		"deleted": {
+			status: http.StatusNotFound,
+		},
+		"forbidden": {
+			status:  http.StatusForbidden,
+			wantErr: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodHead || r.URL.Path != "/fishbucket/fish9.jpg" {
+					t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+				if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+					t.Errorf("Unexpected Authorization: %s", r.Header.Get("Authorization"))
+				}
+				w.Header().Set("ETag", tc.etag)
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			v, err := newETagVerifier(srv.URL, "", "access", "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			object := notification1.S3.Object
+			got, err := v.verify(context.Background(), notification1.S3.Bucket.Name, object.Key, object.VersionID, object.ETag)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Unexpected verification: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifiedExtension(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"`+notification1.S3.Object.ETag+`"`)
+	}))
+	defer srv.Close()
+
+	v, err := newETagVerifier(srv.URL, "", "access", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := adaptertest.NewTestClient()
+	ca := &cephReceiveAdapter{
+		logger:   zap.NewNop().Sugar(),
+		client:   client,
+		verifier: v,
+	}
+	if err := ca.postMessage(context.Background(), notification1); err != nil {
+		t.Fatal(err)
+	}
+	sent := client.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("Unexpected number of events sent: %d", len(sent))
+	}
+	if got := sent[0].Extensions()[verifiedExtension]; got != true {
+		t.Errorf("Unexpected verified extension: %v", got)
+	}
+}
+
+func TestDetectDeleteMarker(t *testing.T) {
+	testCases := map[string]struct {
+		versionID string
+		status    int
+		marker    bool
+		want      string
+	}{
+		"delete marker": {
+			versionID: "RZmvPzs4EWyD4AGmUw-2akPe5bBRQE5",
+			status:    http.StatusMethodNotAllowed,
+			marker:    true,
+			want:      "s3:ObjectRemoved:DeleteMarkerCreated",
+		},
+		"deleted version": {
+			versionID: "RZmvPzs4EWyD4AGmUw-2akPe5bBRQE5",
+			status:    http.StatusNotFound,
+			want:      "s3:ObjectRemoved:Delete",
+		},
+		"unversioned": {
+			status: http.StatusMethodNotAllowed,
+			marker: true,
+			want:   "s3:ObjectRemoved:Delete",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.URL.Query().Get("versionId"); got != tc.versionID {
+					t.Errorf("Unexpected version: %q", got)
+				}
+				if tc.marker {
+					w.Header().Set(deleteMarkerHeader, "true")
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			v, err := newETagVerifier(srv.URL, "", "access", "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ca := &cephReceiveAdapter{
+				logger:   zap.NewNop().Sugar(),
+				verifier: v,
+			}
+			notification := notification1
+			notification.EventName = "s3:ObjectRemoved:Delete"
+			notification.S3.Object.VersionID = tc.versionID
+
+			if got := ca.detectDeleteMarker(context.Background(), notification).EventName; got != tc.want {
+				t.Errorf("Unexpected event name: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveCopySource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Meta-Copy-Source", "/catbucket/cat1.jpg")
+	}))
+	defer srv.Close()
+
+	v, err := newETagVerifier(srv.URL, "", "access", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{
+		logger:   zap.NewNop().Sugar(),
+		verifier: v,
+	}
+	notification := notification1
+	notification.EventName = "s3:ObjectCreated:Copy"
+
+	got := ca.resolveCopySource(context.Background(), notification).S3.Object.CopySource
+	if got == nil || got.Bucket != "catbucket" || got.Key != "cat1.jpg" {
+		t.Errorf("Unexpected copy source: %+v", got)
+	}
+}
+
+func TestResolveMetadata(t *testing.T) {
+	heads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		heads++
+		w.Header().Set("X-Amz-Meta-Team", "storage")
+	}))
+	defer srv.Close()
+
+	v, err := newETagVerifier(srv.URL, "", "access", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{
+		logger:    zap.NewNop().Sugar(),
+		verifier:  v,
+		converter: ceph2ce.Converter{MetadataExtensions: []string{"team", "x-amz-meta-owner"}},
+	}
+
+	notification := notification1
+	notification.EventName = "s3:ObjectCreated:Put"
+	metadata := ca.resolveMetadata(context.Background(), notification).S3.Object.Metadata
+	if n := len(notification1.S3.Object.Metadata); len(metadata) != n+1 || metadata[n] != (ceph.MetadataEntry{Key: "x-amz-meta-team", Value: "storage"}) {
+		t.Errorf("Unexpected metadata: %+v", metadata)
+	}
+
+	notification.S3.Object.Metadata = []ceph.MetadataEntry{
+		{Key: "x-amz-meta-team", Value: "fish"},
+		{Key: "X-Amz-Meta-Owner", Value: "tester"},
+	}
+	ca.resolveMetadata(context.Background(), notification)
+	notification.EventName = "s3:ObjectRemoved:Delete"
+	notification.S3.Object.Metadata = nil
+	ca.resolveMetadata(context.Background(), notification)
+	if heads != 1 {
+		t.Errorf("Unexpected number of HEAD requests: %d", heads)
+	}
+}
+
+func TestResolveObjectLock(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Object-Lock-Mode", "COMPLIANCE")
+		w.Header().Set("X-Amz-Object-Lock-Retain-Until-Date", "2030-01-01T00:00:00Z")
+		w.Header().Set("X-Amz-Object-Lock-Legal-Hold", "OFF")
+	}))
+	defer srv.Close()
+
+	v, err := newETagVerifier(srv.URL, "", "access", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &cephReceiveAdapter{logger: zap.NewNop().Sugar(), verifier: v}
+
+	notification := notification1
+	notification.EventName = "s3:ObjectCreated:Put"
+	lock := ca.resolveObject(context.Background(), notification).S3.Object.ObjectLock
+	want := ceph.ObjectLockSpec{Mode: "COMPLIANCE", RetainUntilDate: "2030-01-01T00:00:00Z"}
+	if lock == nil || *lock != want {
+		t.Errorf("Unexpected object lock: %+v", lock)
+	}
+}
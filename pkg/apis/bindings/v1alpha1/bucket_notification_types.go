@@ -52,6 +52,48 @@ type ObjectSpec struct {
 	VersionID string          `json:"versionId"`
 	Sequencer string          `json:"sequencer"`
 	Metadata  []MetadataEntry `json:"metadata"`
+
+	// CopySource is not sent by Ceph, it is resolved for copied objects
+	// when the source is configured to.
+	CopySource *CopySourceSpec `json:"copySource,omitempty"`
+
+	// Encryption is not sent by Ceph, it is resolved for created objects
+	// when the source verifies them.
+	Encryption *EncryptionSpec `json:"encryption,omitempty"`
+
+	// StorageClass is not sent by Ceph, it is resolved for created and
+	// transitioned objects when the source verifies them.
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// ObjectLock is not sent by Ceph, it is resolved for created objects
+	// when the source verifies them.
+	ObjectLock *ObjectLockSpec `json:"objectLock,omitempty"`
+}
+
+// ObjectLockSpec describes the retention and legal hold of an object.
+type ObjectLockSpec struct {
+	// Mode is the retention mode, "GOVERNANCE" or "COMPLIANCE", if the
+	// object is retained.
+	Mode string `json:"mode,omitempty"`
+	// RetainUntilDate is the RFC 3339 date until which the object is
+	// retained, if it is.
+	RetainUntilDate string `json:"retainUntilDate,omitempty"`
+	// LegalHold tells whether the object is under legal hold.
+	LegalHold bool `json:"legalHold"`
+}
+
+// EncryptionSpec describes the server-side encryption of an object.
+type EncryptionSpec struct {
+	// Type is "none", "SSE-S3", "SSE-KMS" or "SSE-C".
+	Type     string `json:"type"`
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+}
+
+// CopySourceSpec identifies the object a copied object was copied from.
+type CopySourceSpec struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionId,omitempty"`
 }
 
 type S3Spec struct {
@@ -77,3 +119,27 @@ type BucketNotification struct {
 type BucketNotifications struct {
 	Records []BucketNotification `json:"Records"`
 }
+
+type FlatNotification struct {
+	EventName       string            `json:"eventName"`
+	EventTime       string            `json:"eventTime"`
+	EventSource     string            `json:"eventSource"`
+	Region          string            `json:"region"`
+	EventID         string            `json:"eventId"`
+	RequestID       string            `json:"requestId"`
+	PrincipalID     string            `json:"principalId"`
+	SourceIPAddress string            `json:"sourceIPAddress"`
+	ConfigurationID string            `json:"configurationId"`
+	Bucket          string            `json:"bucket"`
+	BucketARN       string            `json:"bucketArn"`
+	Key             string            `json:"key"`
+	Size            uint              `json:"size"`
+	ETag            string            `json:"eTag"`
+	VersionID       string            `json:"versionId,omitempty"`
+	Sequencer       string            `json:"sequencer"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	CopySource      *CopySourceSpec   `json:"copySource,omitempty"`
+	Encryption      *EncryptionSpec   `json:"encryption,omitempty"`
+	StorageClass    string            `json:"storageClass,omitempty"`
+	ObjectLock      *ObjectLockSpec   `json:"objectLock,omitempty"`
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// CDEvent mirrors the data of CDEvents artifact events.
+type CDEvent struct {
+	Context CDEventContext `json:"context"`
+	Subject CDEventSubject `json:"subject"`
+}
+
+type CDEventContext struct {
+	Version   string `json:"version"`
+	ID        string `json:"id"`
+	Source    string `json:"source"`
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+}
+
+type CDEventSubject struct {
+	ID      string                 `json:"id"`
+	Source  string                 `json:"source"`
+	Type    string                 `json:"type"`
+	Content CDEventArtifactContent `json:"content"`
+}
+
+type CDEventArtifactContent struct {
+	User string `json:"user,omitempty"`
+}
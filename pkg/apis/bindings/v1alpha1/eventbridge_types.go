@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// EventBridgeEvent mirrors the envelope of Amazon EventBridge S3 event
+// notifications.
+type EventBridgeEvent struct {
+	Version    string            `json:"version"`
+	ID         string            `json:"id"`
+	DetailType string            `json:"detail-type"`
+	Source     string            `json:"source"`
+	Account    string            `json:"account"`
+	Time       string            `json:"time"`
+	Region     string            `json:"region"`
+	Resources  []string          `json:"resources"`
+	Detail     EventBridgeDetail `json:"detail"`
+}
+
+type EventBridgeBucket struct {
+	Name string `json:"name"`
+}
+
+type EventBridgeObject struct {
+	Key       string `json:"key"`
+	Size      uint   `json:"size"`
+	ETag      string `json:"etag"`
+	VersionID string `json:"version-id,omitempty"`
+	Sequencer string `json:"sequencer"`
+}
+
+type EventBridgeDetail struct {
+	Version         string            `json:"version"`
+	Bucket          EventBridgeBucket `json:"bucket"`
+	Object          EventBridgeObject `json:"object"`
+	RequestID       string            `json:"request-id"`
+	Requester       string            `json:"requester"`
+	SourceIPAddress string            `json:"source-ip-address"`
+	Reason          string            `json:"reason"`
+	DeletionType    string            `json:"deletion-type,omitempty"`
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// AdapterConfigName is the name of the ConfigMap holding the policy of
+	// the adapters of CephSources.
+	AdapterConfigName = "config-ceph-adapter"
+
+	// AdapterConfigKey is the key of the policy in the ConfigMap.
+	AdapterConfigKey = "adapter"
+)
+
+// AdapterPolicies holds the policy of the deployments of the adapters of
+// CephSources, for the whole cluster and per namespace, applied by the
+// controller.
+type AdapterPolicies struct {
+	// ClusterDefault applies to the sources of the namespaces without
+	// policy of their own.
+	ClusterDefault *AdapterPolicy `json:"clusterDefault,omitempty"`
+
+	// NamespacePolicies are the policies of the sources of given
+	// namespaces, keyed by namespace. Their unset fields fall back to
+	// ClusterDefault.
+	NamespacePolicies map[string]*AdapterPolicy `json:"namespacePolicies,omitempty"`
+}
+
+// AdapterPolicy sets the image and the sizing of the adapters of sources.
+type AdapterPolicy struct {
+	// Image overrides the image of the adapters, e.g. to pull it from an
+	// air-gapped registry.
+	Image string `json:"image,omitempty"`
+
+	// ImagePullSecrets are the secrets of the namespace of the sources to
+	// pull the image with.
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Resources are the resources of the adapters of the sources leaving
+	// spec.resources unset.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// NewAdapterConfigFromMap creates AdapterPolicies from the data of a
+// ConfigMap.
+func NewAdapterConfigFromMap(data map[string]string) (*AdapterPolicies, error) {
+	p := &AdapterPolicies{}
+	value, ok := data[AdapterConfigKey]
+	if !ok {
+		return p, nil
+	}
+	if err := yaml.Unmarshal([]byte(value), p); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", AdapterConfigKey, err)
+	}
+	return p, nil
+}
+
+// NewAdapterConfigFromConfigMap creates AdapterPolicies from a ConfigMap.
+func NewAdapterConfigFromConfigMap(config *corev1.ConfigMap) (*AdapterPolicies, error) {
+	return NewAdapterConfigFromMap(config.Data)
+}
+
+// ForNamespace returns the policy of the adapters of a namespace, merging
+// its own policy over the cluster one. Never nil.
+func (p *AdapterPolicies) ForNamespace(namespace string) *AdapterPolicy {
+	merged := &AdapterPolicy{}
+	if p == nil {
+		return merged
+	}
+	if p.ClusterDefault != nil {
+		*merged = *p.ClusterDefault
+	}
+	ns, ok := p.NamespacePolicies[namespace]
+	if !ok || ns == nil {
+		return merged
+	}
+	if ns.Image != "" {
+		merged.Image = ns.Image
+	}
+	if ns.ImagePullSecrets != nil {
+		merged.ImagePullSecrets = ns.ImagePullSecrets
+	}
+	if ns.Resources != nil {
+		merged.Resources = ns.Resources
+	}
+	return merged
+}
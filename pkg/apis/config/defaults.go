@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultsConfigName is the name of the ConfigMap holding the defaults
+	// of CephSources.
+	DefaultsConfigName = "config-ceph-defaults"
+
+	// DefaultsConfigKey is the key of the defaults in the ConfigMap.
+	DefaultsConfigKey = "default-ceph-config"
+)
+
+// Defaults holds the defaults of CephSource fields, for the whole cluster
+// and per namespace.
+type Defaults struct {
+	// ClusterDefault applies to the sources of the namespaces without
+	// defaults of their own.
+	ClusterDefault *SourceDefaults `json:"clusterDefault,omitempty"`
+
+	// NamespaceDefaults are the defaults of the sources of given namespaces,
+	// keyed by namespace. Their unset fields fall back to ClusterDefault.
+	NamespaceDefaults map[string]*SourceDefaults `json:"namespaceDefaults,omitempty"`
+}
+
+// SourceDefaults are the values of the CephSource fields left unset.
+type SourceDefaults struct {
+	ServiceAccountName string                       `json:"serviceAccountName,omitempty"`
+	Profile            string                       `json:"profile,omitempty"`
+	Payload            string                       `json:"payload,omitempty"`
+	Format             string                       `json:"format,omitempty"`
+	Mesh               string                       `json:"mesh,omitempty"`
+	SourceScheme       string                       `json:"sourceScheme,omitempty"`
+	SourceCluster      string                       `json:"sourceCluster,omitempty"`
+	MaxConcurrency     *int32                       `json:"maxConcurrency,omitempty"`
+	Resources          *corev1.ResourceRequirements `json:"resources,omitempty"`
+	TypePrefix         string                       `json:"typePrefix,omitempty"`
+	Delivery           *DeliveryDefaults            `json:"delivery,omitempty"`
+}
+
+// DeliveryDefaults are the values of the retry policy of the CephSource
+// delivery left unset.
+type DeliveryDefaults struct {
+	Retry        *int32           `json:"retry,omitempty"`
+	BackoffDelay *metav1.Duration `json:"backoffDelay,omitempty"`
+}
+
+// NewDefaultsConfigFromMap creates Defaults from the data of a ConfigMap.
+func NewDefaultsConfigFromMap(data map[string]string) (*Defaults, error) {
+	d := &Defaults{}
+	value, ok := data[DefaultsConfigKey]
+	if !ok {
+		return d, nil
+	}
+	if err := yaml.Unmarshal([]byte(value), d); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", DefaultsConfigKey, err)
+	}
+	return d, nil
+}
+
+// NewDefaultsConfigFromConfigMap creates Defaults from a ConfigMap.
+func NewDefaultsConfigFromConfigMap(config *corev1.ConfigMap) (*Defaults, error) {
+	return NewDefaultsConfigFromMap(config.Data)
+}
+
+// ForNamespace returns the defaults of the sources of a namespace, merging
+// its own defaults over the cluster ones. Never nil.
+func (d *Defaults) ForNamespace(namespace string) *SourceDefaults {
+	merged := &SourceDefaults{}
+	if d == nil {
+		return merged
+	}
+	if d.ClusterDefault != nil {
+		*merged = *d.ClusterDefault
+	}
+	ns, ok := d.NamespaceDefaults[namespace]
+	if !ok || ns == nil {
+		return merged
+	}
+	if ns.ServiceAccountName != "" {
+		merged.ServiceAccountName = ns.ServiceAccountName
+	}
+	if ns.Profile != "" {
+		merged.Profile = ns.Profile
+	}
+	if ns.Payload != "" {
+		merged.Payload = ns.Payload
+	}
+	if ns.Format != "" {
+		merged.Format = ns.Format
+	}
+	if ns.Mesh != "" {
+		merged.Mesh = ns.Mesh
+	}
+	if ns.SourceScheme != "" {
+		merged.SourceScheme = ns.SourceScheme
+	}
+	if ns.SourceCluster != "" {
+		merged.SourceCluster = ns.SourceCluster
+	}
+	if ns.MaxConcurrency != nil {
+		merged.MaxConcurrency = ns.MaxConcurrency
+	}
+	if ns.Resources != nil {
+		merged.Resources = ns.Resources
+	}
+	if ns.TypePrefix != "" {
+		merged.TypePrefix = ns.TypePrefix
+	}
+	if ns.Delivery != nil {
+		delivery := DeliveryDefaults{}
+		if merged.Delivery != nil {
+			delivery = *merged.Delivery
+		}
+		if ns.Delivery.Retry != nil {
+			delivery.Retry = ns.Delivery.Retry
+		}
+		if ns.Delivery.BackoffDelay != nil {
+			delivery.BackoffDelay = ns.Delivery.BackoffDelay
+		}
+		merged.Delivery = &delivery
+	}
+	return merged
+}
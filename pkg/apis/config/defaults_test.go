@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const defaultsConfig = `
+clusterDefault:
+  profile: aws-s3
+  maxConcurrency: 20
+  resources:
+    requests:
+      memory: 64Mi
+  typePrefix: com.example.storage.
+  delivery:
+    retry: 3
+    backoffDelay: 2s
+namespaceDefaults:
+  team-a:
+    profile: ceph
+    serviceAccountName: ceph-source
+    delivery:
+      retry: 5
+`
+
+func TestDefaultsConfig(t *testing.T) {
+	d, err := NewDefaultsConfigFromConfigMap(&corev1.ConfigMap{
+		Data: map[string]string{DefaultsConfigKey: defaultsConfig},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teamA := d.ForNamespace("team-a")
+	if teamA.Profile != "ceph" || teamA.ServiceAccountName != "ceph-source" {
+		t.Errorf("Unexpected namespace defaults: %+v", teamA)
+	}
+	if teamA.MaxConcurrency == nil || *teamA.MaxConcurrency != 20 {
+		t.Errorf("Namespace defaults should fall back to the cluster ones: %+v", teamA)
+	}
+	if got := teamA.Resources.Requests.Memory().String(); got != "64Mi" {
+		t.Errorf("Unexpected memory request: %s", got)
+	}
+	if teamA.TypePrefix != "com.example.storage." {
+		t.Errorf("Unexpected type prefix: %q", teamA.TypePrefix)
+	}
+	if d := teamA.Delivery; d == nil || d.Retry == nil || *d.Retry != 5 || d.BackoffDelay == nil || d.BackoffDelay.Duration != 2*time.Second {
+		t.Errorf("Namespace retry policy should fall back to the cluster one field by field: %+v", d)
+	}
+
+	teamB := d.ForNamespace("team-b")
+	if teamB.Profile != "aws-s3" || teamB.ServiceAccountName != "" {
+		t.Errorf("Unexpected cluster defaults: %+v", teamB)
+	}
+	if d := teamB.Delivery; d == nil || d.Retry == nil || *d.Retry != 3 {
+		t.Errorf("Unexpected cluster retry policy: %+v", d)
+	}
+	if got := *d.ClusterDefault.Delivery.Retry; got != 3 {
+		t.Errorf("Merging namespace defaults should leave the cluster ones as is, got retry %d", got)
+	}
+}
+
+func TestDefaultsConfigEmpty(t *testing.T) {
+	d, err := NewDefaultsConfigFromMap(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.ForNamespace("team-a"); *got != (SourceDefaults{}) {
+		t.Errorf("Unexpected defaults: %+v", got)
+	}
+	if got := (*Defaults)(nil).ForNamespace("team-a"); got == nil {
+		t.Error("Defaults should never be nil")
+	}
+}
+
+func TestDefaultsConfigInvalid(t *testing.T) {
+	if _, err := NewDefaultsConfigFromMap(map[string]string{DefaultsConfigKey: "clusterDefault: [profile]"}); err == nil {
+		t.Error("Invalid defaults should fail")
+	}
+}
+
+func TestQuotasConfig(t *testing.T) {
+	q, err := NewQuotasConfigFromMap(map[string]string{QuotasConfigKey: `
+clusterDefault:
+  maxSources: 5
+  allowedEndpoints: ["http://rgw.rook-ceph.svc"]
+namespaceQuotas:
+  team-a:
+    maxSources: 20
+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := q.ForNamespace("team-a"); got.MaxSources == nil || *got.MaxSources != 20 || len(got.AllowedEndpoints) != 0 {
+		t.Errorf("Unexpected namespace quota: %+v", got)
+	}
+	if got := q.ForNamespace("team-b"); got.MaxSources == nil || *got.MaxSources != 5 {
+		t.Errorf("Unexpected cluster quota: %+v", got)
+	}
+
+	if _, err := NewQuotasConfigFromMap(map[string]string{QuotasConfigKey: `clusterDefault: {allowedEndpoints: ["rgw"]}`}); err == nil {
+		t.Error("Endpoint without host should fail")
+	}
+}
+
+func TestAdapterConfig(t *testing.T) {
+	p, err := NewAdapterConfigFromMap(map[string]string{AdapterConfigKey: `
+clusterDefault:
+  image: registry.internal/ceph-receive-adapter
+  imagePullSecrets:
+  - name: registry-internal
+namespacePolicies:
+  team-a:
+    resources:
+      requests:
+        memory: 256Mi
+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	teamA := p.ForNamespace("team-a")
+	if teamA.Image != "registry.internal/ceph-receive-adapter" || len(teamA.ImagePullSecrets) != 1 {
+		t.Errorf("Namespace policy should fall back to the cluster one: %+v", teamA)
+	}
+	if teamA.Resources == nil || teamA.Resources.Requests.Memory().String() != "256Mi" {
+		t.Errorf("Unexpected resources: %+v", teamA.Resources)
+	}
+	if teamB := p.ForNamespace("team-b"); teamB.Resources != nil {
+		t.Errorf("Unexpected cluster policy: %+v", teamB)
+	}
+
+	if got := (*AdapterPolicies)(nil).ForNamespace("team-a"); got == nil {
+		t.Error("Policy should never be nil")
+	}
+	if _, err := NewAdapterConfigFromMap(map[string]string{AdapterConfigKey: "clusterDefault: [image]"}); err == nil {
+		t.Error("Invalid policy should fail")
+	}
+}
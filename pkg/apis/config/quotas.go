@@ -0,0 +1,122 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// QuotasConfigName is the name of the ConfigMap holding the quotas of
+	// CephSources.
+	QuotasConfigName = "config-ceph-quotas"
+
+	// QuotasConfigKey is the key of the quotas in the ConfigMap.
+	QuotasConfigKey = "quotas"
+)
+
+// Quotas holds the limits of the CephSources of namespaces, enforced by the
+// validation webhook.
+type Quotas struct {
+	// ClusterDefault applies to the namespaces without quota of their own.
+	ClusterDefault *NamespaceQuota `json:"clusterDefault,omitempty"`
+
+	// NamespaceQuotas are the quotas of given namespaces, keyed by
+	// namespace. They replace ClusterDefault as a whole.
+	NamespaceQuotas map[string]*NamespaceQuota `json:"namespaceQuotas,omitempty"`
+}
+
+// NamespaceQuota limits the CephSources of a namespace.
+type NamespaceQuota struct {
+	// MaxSources bounds the number of CephSources. Unbounded if unset.
+	MaxSources *int32 `json:"maxSources,omitempty"`
+
+	// AllowedEndpoints lists the RGW endpoints the sources may connect to,
+	// as URLs whose path, if any, is a prefix of the allowed paths, e.g.
+	// "https://rgw.example.com". Any endpoint is allowed if empty.
+	AllowedEndpoints []string `json:"allowedEndpoints,omitempty"`
+}
+
+// NewQuotasConfigFromMap creates Quotas from the data of a ConfigMap.
+func NewQuotasConfigFromMap(data map[string]string) (*Quotas, error) {
+	q := &Quotas{}
+	value, ok := data[QuotasConfigKey]
+	if !ok {
+		return q, nil
+	}
+	if err := yaml.Unmarshal([]byte(value), q); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", QuotasConfigKey, err)
+	}
+	for _, quota := range append([]*NamespaceQuota{q.ClusterDefault}, namespaceQuotas(q)...) {
+		if quota == nil {
+			continue
+		}
+		for _, endpoint := range quota.AllowedEndpoints {
+			if u, err := url.Parse(endpoint); err != nil || u.Host == "" {
+				return nil, fmt.Errorf("invalid allowed endpoint %q", endpoint)
+			}
+		}
+	}
+	return q, nil
+}
+
+func namespaceQuotas(q *Quotas) []*NamespaceQuota {
+	quotas := make([]*NamespaceQuota, 0, len(q.NamespaceQuotas))
+	for _, quota := range q.NamespaceQuotas {
+		quotas = append(quotas, quota)
+	}
+	return quotas
+}
+
+// NewQuotasConfigFromConfigMap creates Quotas from a ConfigMap.
+func NewQuotasConfigFromConfigMap(config *corev1.ConfigMap) (*Quotas, error) {
+	return NewQuotasConfigFromMap(config.Data)
+}
+
+// ForNamespace returns the quota of a namespace, nil if unlimited.
+func (q *Quotas) ForNamespace(namespace string) *NamespaceQuota {
+	if q == nil {
+		return nil
+	}
+	if quota, ok := q.NamespaceQuotas[namespace]; ok {
+		return quota
+	}
+	return q.ClusterDefault
+}
+
+// AllowsEndpoint returns whether the quota allows connecting to an endpoint.
+func (q *NamespaceQuota) AllowsEndpoint(endpoint *url.URL) bool {
+	if q == nil || len(q.AllowedEndpoints) == 0 {
+		return true
+	}
+	for _, allowed := range q.AllowedEndpoints {
+		a, err := url.Parse(allowed)
+		if err != nil {
+			continue
+		}
+		if strings.EqualFold(a.Scheme, endpoint.Scheme) && strings.EqualFold(a.Host, endpoint.Host) &&
+			strings.HasPrefix(endpoint.Path, strings.TrimSuffix(a.Path, "/")) {
+			return true
+		}
+	}
+	return false
+}
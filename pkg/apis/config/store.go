@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+
+	"knative.dev/pkg/configmap"
+)
+
+type cfgKey struct{}
+
+// Config holds the configuration of CephSources.
+type Config struct {
+	Defaults *Defaults
+	Quotas   *Quotas
+	Adapter  *AdapterPolicies
+}
+
+// FromContext returns the Config of a context, nil if none.
+func FromContext(ctx context.Context) *Config {
+	x, ok := ctx.Value(cfgKey{}).(*Config)
+	if ok {
+		return x
+	}
+	return nil
+}
+
+// FromContextOrDefaults returns the Config of a context, or an empty one.
+func FromContextOrDefaults(ctx context.Context) *Config {
+	if cfg := FromContext(ctx); cfg != nil {
+		return cfg
+	}
+	return &Config{Defaults: &Defaults{}, Quotas: &Quotas{}, Adapter: &AdapterPolicies{}}
+}
+
+// ToContext attaches a Config to a context.
+func ToContext(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, c)
+}
+
+// Store is a typed wrapper around configmap.UntypedStore to handle the
+// config-ceph-defaults, config-ceph-quotas and config-ceph-adapter
+// ConfigMaps.
+type Store struct {
+	*configmap.UntypedStore
+}
+
+// NewStore creates a Store watching the configuration of CephSources.
+func NewStore(logger configmap.Logger, onAfterStore ...func(name string, value interface{})) *Store {
+	return &Store{
+		UntypedStore: configmap.NewUntypedStore(
+			"cephsource",
+			logger,
+			configmap.Constructors{
+				DefaultsConfigName: NewDefaultsConfigFromConfigMap,
+				QuotasConfigName:   NewQuotasConfigFromConfigMap,
+				AdapterConfigName:  NewAdapterConfigFromConfigMap,
+			},
+			onAfterStore...,
+		),
+	}
+}
+
+// ToContext attaches the current Config to a context.
+func (s *Store) ToContext(ctx context.Context) context.Context {
+	return ToContext(ctx, s.Load())
+}
+
+// Load returns the current Config.
+func (s *Store) Load() *Config {
+	cfg := &Config{Defaults: &Defaults{}, Quotas: &Quotas{}, Adapter: &AdapterPolicies{}}
+	if d, ok := s.UntypedLoad(DefaultsConfigName).(*Defaults); ok && d != nil {
+		cfg.Defaults = d
+	}
+	if q, ok := s.UntypedLoad(QuotasConfigName).(*Quotas); ok && q != nil {
+		cfg.Quotas = q
+	}
+	if p, ok := s.UntypedLoad(AdapterConfigName).(*AdapterPolicies); ok && p != nil {
+		cfg.Adapter = p
+	}
+	return cfg
+}
@@ -18,12 +18,68 @@ package v1alpha1
 
 import (
 	"context"
+
+	"knative.dev/eventing-ceph/pkg/apis/config"
 )
 
 // SetDefaults mutates CephSource.
 func (s *CephSource) SetDefaults(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	s.Spec.setDefaults(config.FromContextOrDefaults(ctx).Defaults.ForNamespace(s.Namespace))
+
 	//If ServiceAccountName is unspecified, default to the "default" service account.
-	if s != nil && s.Spec.ServiceAccountName == "" {
+	if s.Spec.ServiceAccountName == "" {
 		s.Spec.ServiceAccountName = "default"
 	}
 }
+
+// setDefaults sets the unset fields of the spec to the defaults of the
+// config-ceph-defaults ConfigMap.
+func (sspec *CephSourceSpec) setDefaults(d *config.SourceDefaults) {
+	if sspec.ServiceAccountName == "" {
+		sspec.ServiceAccountName = d.ServiceAccountName
+	}
+	if sspec.Profile == "" {
+		sspec.Profile = d.Profile
+	}
+	if sspec.Payload == "" {
+		sspec.Payload = d.Payload
+	}
+	if sspec.Format == "" {
+		sspec.Format = d.Format
+	}
+	if sspec.Mesh == "" {
+		sspec.Mesh = d.Mesh
+	}
+	if sspec.SourceURI == nil && d.SourceScheme != "" {
+		sspec.SourceURI = &CephSourceSourceURI{Scheme: d.SourceScheme}
+	}
+	if u := sspec.SourceURI; u != nil && u.Scheme == SourceSchemeCeph && u.Cluster == "" {
+		u.Cluster = d.SourceCluster
+	}
+	if sspec.MaxConcurrency == nil && d.MaxConcurrency != nil {
+		maxConcurrency := *d.MaxConcurrency
+		sspec.MaxConcurrency = &maxConcurrency
+	}
+	if sspec.Resources == nil && d.Resources != nil {
+		sspec.Resources = d.Resources.DeepCopy()
+	}
+	if sspec.TypePrefix == "" {
+		sspec.TypePrefix = d.TypePrefix
+	}
+	if dd := d.Delivery; dd != nil && (dd.Retry != nil || dd.BackoffDelay != nil) {
+		if sspec.Delivery == nil {
+			sspec.Delivery = &CephSourceDelivery{}
+		}
+		if sspec.Delivery.Retry == nil && dd.Retry != nil {
+			retry := *dd.Retry
+			sspec.Delivery.Retry = &retry
+		}
+		if sspec.Delivery.BackoffDelay == nil && dd.BackoffDelay != nil {
+			backoffDelay := *dd.BackoffDelay
+			sspec.Delivery.BackoffDelay = &backoffDelay
+		}
+	}
+}
@@ -19,13 +19,48 @@ package v1alpha1
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/ptr"
+
+	"knative.dev/eventing-ceph/pkg/apis/config"
 )
 
 func TestCephSourceDefaults(t *testing.T) {
+	resources := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+	}
+	defaults := &config.Defaults{
+		ClusterDefault: &config.SourceDefaults{
+			Profile:       ProfileAWSS3,
+			SourceCluster: "ceph-eu-1",
+			Resources:     resources,
+			TypePrefix:    "com.example.storage.",
+			Delivery: &config.DeliveryDefaults{
+				Retry:        ptr.Int32(3),
+				BackoffDelay: &metav1.Duration{Duration: 2 * time.Second},
+			},
+		},
+		NamespaceDefaults: map[string]*config.SourceDefaults{
+			"team-a": {
+				ServiceAccountName: "ceph-source",
+				Profile:            ProfileCeph,
+				SourceScheme:       SourceSchemeCeph,
+				MaxConcurrency:     ptr.Int32(10),
+				Delivery:           &config.DeliveryDefaults{Retry: ptr.Int32(5)},
+			},
+		},
+	}
+
 	testCases := map[string]struct {
 		initial  CephSource
+		defaults *config.Defaults
 		expected CephSource
 	}{
 		"nil spec": {
@@ -34,10 +69,82 @@ func TestCephSourceDefaults(t *testing.T) {
 				Spec: CephSourceSpec{ServiceAccountName: "default"},
 			},
 		},
+		"cluster defaults": {
+			initial:  CephSource{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"}},
+			defaults: defaults,
+			expected: CephSource{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"},
+				Spec: CephSourceSpec{
+					ServiceAccountName: "default",
+					Profile:            ProfileAWSS3,
+					Resources:          resources,
+					TypePrefix:         "com.example.storage.",
+					Delivery: &CephSourceDelivery{
+						Retry:        ptr.Int32(3),
+						BackoffDelay: &metav1.Duration{Duration: 2 * time.Second},
+					},
+				},
+			},
+		},
+		"namespace defaults": {
+			initial:  CephSource{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"}},
+			defaults: defaults,
+			expected: CephSource{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec: CephSourceSpec{
+					ServiceAccountName: "ceph-source",
+					Profile:            ProfileCeph,
+					SourceURI:          &CephSourceSourceURI{Scheme: SourceSchemeCeph, Cluster: "ceph-eu-1"},
+					MaxConcurrency:     ptr.Int32(10),
+					Resources:          resources,
+					TypePrefix:         "com.example.storage.",
+					Delivery: &CephSourceDelivery{
+						Retry:        ptr.Int32(5),
+						BackoffDelay: &metav1.Duration{Duration: 2 * time.Second},
+					},
+				},
+			},
+		},
+		"set fields": {
+			initial: CephSource{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec: CephSourceSpec{
+					Profile:        ProfileEventBridge,
+					SourceURI:      &CephSourceSourceURI{Scheme: SourceSchemeARN},
+					MaxConcurrency: ptr.Int32(2),
+					TypePrefix:     "com.example.",
+					Delivery: &CephSourceDelivery{
+						Retry:          ptr.Int32(0),
+						DeadLetterSink: &duckv1.Destination{URI: apis.HTTP("dls.example.com")},
+					},
+				},
+			},
+			defaults: defaults,
+			expected: CephSource{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"},
+				Spec: CephSourceSpec{
+					ServiceAccountName: "ceph-source",
+					Profile:            ProfileEventBridge,
+					SourceURI:          &CephSourceSourceURI{Scheme: SourceSchemeARN},
+					MaxConcurrency:     ptr.Int32(2),
+					Resources:          resources,
+					TypePrefix:         "com.example.",
+					Delivery: &CephSourceDelivery{
+						Retry:          ptr.Int32(0),
+						BackoffDelay:   &metav1.Duration{Duration: 2 * time.Second},
+						DeadLetterSink: &duckv1.Destination{URI: apis.HTTP("dls.example.com")},
+					},
+				},
+			},
+		},
 	}
 	for n, tc := range testCases {
 		t.Run(n, func(t *testing.T) {
-			tc.initial.SetDefaults(context.TODO())
+			ctx := context.TODO()
+			if tc.defaults != nil {
+				ctx = config.ToContext(ctx, &config.Config{Defaults: tc.defaults})
+			}
+			tc.initial.SetDefaults(ctx)
 			if diff := cmp.Diff(tc.expected, tc.initial); diff != "" {
 				t.Fatalf("Unexpected defaults (-want, +got): %s", diff)
 			}
@@ -17,9 +17,14 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+	"strings"
+
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"knative.dev/eventing/pkg/apis/duck"
 	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 )
 
 const (
@@ -31,6 +36,20 @@ const (
 
 	// CephConditionDeployed has status True when the CephSource has had it's deployment created.
 	CephConditionDeployed apis.ConditionType = "Deployed"
+
+	// CephConditionCredentialsRotation has status True while the next RGW
+	// credentials of some features are set, i.e. while their keys are
+	// rotated. It does not tell which key is active, each adapter switching
+	// over on its own without reporting back to the controller. It does not
+	// affect the readiness of the CephSource.
+	CephConditionCredentialsRotation apis.ConditionType = "CredentialsRotation"
+
+	// CephConditionMeshSidecar has status True when the Istio Sidecar
+	// forwarding the notifications to the adapter listening on localhost
+	// is configured, and False, as a warning, when it cannot be, the
+	// adapter then being unreachable through Istio 1.10 and later. It does
+	// not affect the readiness of the CephSource.
+	CephConditionMeshSidecar apis.ConditionType = "MeshSidecar"
 )
 
 var cephCondSet = apis.NewLivingConditionSet(
@@ -80,6 +99,113 @@ func (s *CephSourceStatus) PropagateDeploymentAvailability(d *appsv1.Deployment)
 	}
 }
 
+// MarkCloudEventAttributes sets the CloudEvent attributes the source emits.
+// The CloudEvent source depends on the bucket a notification originates from
+// and is therefore left empty.
+func (s *CephSourceStatus) MarkCloudEventAttributes(types []string) {
+	attrs := make([]duckv1.CloudEventAttributes, 0, len(types))
+	for _, t := range types {
+		attrs = append(attrs, duckv1.CloudEventAttributes{Type: t})
+	}
+	s.CloudEventAttributes = attrs
+}
+
+// MarkSuggestedFilters sets one Trigger filter per event type the source
+// emits to its broker, or clears them when types is empty. As for the
+// CloudEvent attributes, the source attribute depends on the bucket and is
+// not part of the filters.
+func (s *CephSourceStatus) MarkSuggestedFilters(types []string) {
+	if len(types) == 0 {
+		s.SuggestedFilters = nil
+		return
+	}
+	filters := make([]CephSourceTriggerFilter, 0, len(types))
+	for _, t := range types {
+		filters = append(filters, CephSourceTriggerFilter{
+			Attributes: map[string]string{"type": t},
+		})
+	}
+	s.SuggestedFilters = filters
+}
+
+// MarkExternalURL sets the URL the receive adapter is exposed at, or clears
+// it when url is nil.
+func (s *CephSourceStatus) MarkExternalURL(url *apis.URL) {
+	s.ExternalURL = url
+}
+
+// MarkDeadLetterSink sets the resolved URI of the dead letter sink, nil if
+// none.
+func (s *CephSourceStatus) MarkDeadLetterSink(uri *apis.URL) {
+	s.DeadLetterSinkURI = uri
+}
+
+// MarkFailureSink sets the resolved URI of the failure sink, nil if none.
+func (s *CephSourceStatus) MarkFailureSink(uri *apis.URL) {
+	s.FailureSinkURI = uri
+}
+
+// MarkFailoverSinks sets the resolved URIs of the failover sinks, nil if
+// none.
+func (s *CephSourceStatus) MarkFailoverSinks(uris []apis.URL) {
+	s.FailoverSinkURIs = uris
+}
+
+// MarkLifecycleSink sets the resolved URI of the sink of the lifecycle
+// events, nil if none.
+func (s *CephSourceStatus) MarkLifecycleSink(uri *apis.URL) {
+	s.LifecycleSinkURI = uri
+}
+
+// MarkCredentialsRotation sets the condition that the credentials of the
+// given features are rotated, or clears it when features is empty. Each
+// adapter switches over to the next key of a feature once the RGW rejects
+// its current key, which only the rgw_credentials_next_active metric
+// reports, the status not knowing which key is active.
+func (s *CephSourceStatus) MarkCredentialsRotation(features []string) {
+	if len(features) == 0 {
+		_ = cephCondSet.Manage(s).ClearCondition(CephConditionCredentialsRotation)
+		return
+	}
+	cephCondSet.Manage(s).SetCondition(apis.Condition{
+		Type:     CephConditionCredentialsRotation,
+		Status:   corev1.ConditionTrue,
+		Severity: apis.ConditionSeverityInfo,
+		Reason:   "NextCredentialsSet",
+		Message: fmt.Sprintf("The adapters use the next key of %s once the RGW rejects the current one. "+
+			"The active key of each adapter is not reported here, see the rgw_credentials_next_active metric.",
+			strings.Join(features, ", ")),
+	})
+}
+
+// MarkMeshSidecar sets the condition that the Istio Sidecar of the adapter
+// is configured.
+func (s *CephSourceStatus) MarkMeshSidecar() {
+	cephCondSet.Manage(s).SetCondition(apis.Condition{
+		Type:     CephConditionMeshSidecar,
+		Status:   corev1.ConditionTrue,
+		Severity: apis.ConditionSeverityInfo,
+	})
+}
+
+// MarkNoMeshSidecar sets the condition that the Istio Sidecar of the adapter
+// cannot be configured.
+func (s *CephSourceStatus) MarkNoMeshSidecar(reason, messageFormat string, messageA ...interface{}) {
+	cephCondSet.Manage(s).SetCondition(apis.Condition{
+		Type:     CephConditionMeshSidecar,
+		Status:   corev1.ConditionFalse,
+		Severity: apis.ConditionSeverityWarning,
+		Reason:   reason,
+		Message:  fmt.Sprintf(messageFormat, messageA...),
+	})
+}
+
+// ClearMeshSidecar removes the condition of the Istio Sidecar of the
+// adapter, for sources not deployed for Istio.
+func (s *CephSourceStatus) ClearMeshSidecar() {
+	_ = cephCondSet.Manage(s).ClearCondition(CephConditionMeshSidecar)
+}
+
 // IsReady returns true if the resource is ready overall.
 func (s *CephSourceStatus) IsReady() bool {
 	return cephCondSet.Manage(s).IsHappy()
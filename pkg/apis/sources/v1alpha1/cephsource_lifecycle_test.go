@@ -18,6 +18,10 @@ package v1alpha1
 
 import (
 	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
 )
 
 func TestCephSourceLifecycle(t *testing.T) {
@@ -48,6 +52,66 @@ func TestCephSourceLifecycle(t *testing.T) {
 			if cond := tc.source.Status.GetCondition(CephConditionSinkProvided).Status; cond != "False" {
 				t.Fatalf("Unexpected sink condition: %s", cond)
 			}
+			tc.source.Status.MarkCloudEventAttributes(tc.source.Spec.EventTypes())
+			if got, want := len(tc.source.Status.CloudEventAttributes), len(CephEventNames); got != want {
+				t.Fatalf("Unexpected number of CloudEvent attributes: got %d, want %d", got, want)
+			}
+			tc.source.Status.MarkSuggestedFilters(tc.source.Spec.EventTypes())
+			if got, want := len(tc.source.Status.SuggestedFilters), len(CephEventNames); got != want {
+				t.Fatalf("Unexpected number of suggested filters: got %d, want %d", got, want)
+			}
+			if got, want := tc.source.Status.SuggestedFilters[0].Attributes["type"], tc.source.Spec.EventTypes()[0]; got != want {
+				t.Fatalf("Unexpected suggested filter type: got %s, want %s", got, want)
+			}
+			tc.source.Status.MarkSuggestedFilters(nil)
+			if tc.source.Status.SuggestedFilters != nil {
+				t.Fatalf("Unexpected suggested filters: %v", tc.source.Status.SuggestedFilters)
+			}
+			tc.source.Status.MarkExternalURL(ParseURL("https://ceph-source.example.com", t))
+			if got := tc.source.Status.ExternalURL.String(); got != "https://ceph-source.example.com" {
+				t.Fatalf("Unexpected external URL: %s", got)
+			}
+			tc.source.Status.MarkExternalURL(nil)
+			if tc.source.Status.ExternalURL != nil {
+				t.Fatalf("Unexpected external URL: %v", tc.source.Status.ExternalURL)
+			}
+			tc.source.Status.MarkCredentialsRotation([]string{"usage"})
+			if cond := tc.source.Status.GetCondition(CephConditionCredentialsRotation); cond == nil || cond.Status != "True" {
+				t.Fatalf("Unexpected credentials rotation condition: %v", cond)
+			}
+			tc.source.Status.MarkCredentialsRotation(nil)
+			if cond := tc.source.Status.GetCondition(CephConditionCredentialsRotation); cond != nil {
+				t.Fatalf("Unexpected credentials rotation condition: %v", cond)
+			}
 		})
 	}
 }
+
+func TestCephSourceMeshSidecar(t *testing.T) {
+	source := CephSource{}
+	source.Status.InitializeConditions()
+	source.Status.MarkSink(ParseURL("http://hello.world", t))
+	source.Status.PropagateDeploymentAvailability(&appsv1.Deployment{
+		Status: appsv1.DeploymentStatus{
+			Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}},
+		},
+	})
+
+	source.Status.MarkNoMeshSidecar("SidecarUnavailable", "%s", "just testing")
+	cond := source.Status.GetCondition(CephConditionMeshSidecar)
+	if cond == nil || cond.Status != corev1.ConditionFalse || cond.Severity != apis.ConditionSeverityWarning {
+		t.Fatalf("Unexpected mesh sidecar condition: %v", cond)
+	}
+	// A warning rather than a failure of the source.
+	if !source.Status.IsReady() {
+		t.Fatal("The mesh sidecar condition should not affect the readiness")
+	}
+	source.Status.MarkMeshSidecar()
+	if cond := source.Status.GetCondition(CephConditionMeshSidecar); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Fatalf("Unexpected mesh sidecar condition: %v", cond)
+	}
+	source.Status.ClearMeshSidecar()
+	if cond := source.Status.GetCondition(CephConditionMeshSidecar); cond != nil {
+		t.Fatalf("Unexpected mesh sidecar condition: %v", cond)
+	}
+}
@@ -17,9 +17,14 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/apis"
 	"knative.dev/pkg/apis/duck"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
@@ -60,6 +65,8 @@ var (
 	_ resourcesemantics.GenericCRD = (*CephSource)(nil)
 	// Check that CephSource implements the Conditions duck type.
 	_ = duck.VerifyType(&CephSource{}, &duckv1.Conditions{})
+	// Check that CephSource implements the Source duck type.
+	_ = duck.VerifyType(&CephSource{}, &duckv1.Source{})
 	// Check that the type conforms to the duck Knative Resource shape.
 	_ duckv1.KRShaped = (*CephSource)(nil)
 )
@@ -82,6 +89,1474 @@ type CephSourceSpec struct {
 
 	// Port holds the port number on which the adapter is listening on
 	Port string `json:"port"`
+
+	// Profile selects how bucket notifications are mapped to CloudEvents.
+	// Defaults to "ceph". Use "aws-s3" to follow the CloudEvents AWS S3
+	// adapter specification, "eventbridge" for event data shaped like
+	// Amazon EventBridge S3 notifications, or "cdevents" to emit CDEvents
+	// artifact published and deleted events.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// TypePrefix, if set, replaces the prefix of the types of the events of
+	// bucket notifications, "com.amazonaws." with the ceph and eventbridge
+	// profiles and "com.amazonaws.s3." with the aws-s3 one, e.g.
+	// "com.example.storage." for "com.example.storage.s3:ObjectCreated:Put".
+	// The CDEvents types are kept, only transitions taking the prefix.
+	// +optional
+	TypePrefix string `json:"typePrefix,omitempty"`
+
+	// Payload selects the shape of the event data: "record" (the default)
+	// carries the single notification record, "recordEnvelope" wraps it in
+	// a Records array shaped like the original notification but holding
+	// only that record, and "flat" carries a normalized, flattened view of
+	// the record. Not supported with the
+	// eventbridge and cdevents profiles, which define their own data shape.
+	// +optional
+	Payload string `json:"payload,omitempty"`
+
+	// SpecVersion selects the CloudEvents specification version of the
+	// events sent to the sink: "1.0" (the default), or "0.3" for legacy
+	// sinks.
+	// +optional
+	SpecVersion string `json:"specVersion,omitempty"`
+
+	// ContentMode selects how the events are encoded in the requests to the
+	// sink: "binary" (the default) maps their attributes to headers and
+	// their data to the body, "structured" sends them as JSON documents.
+	// +optional
+	ContentMode string `json:"contentMode,omitempty"`
+
+	// Format selects how the notifications pushed to the adapter are parsed:
+	// "ceph" (the default) for RGW notifications, "minio" for MinIO webhook
+	// notifications, whose object metadata is read from their userMetadata,
+	// "generic" for other S3-compatible notifiers, e.g. NooBaa or Scality,
+	// tolerating single records, sizes sent as strings, other time formats
+	// and missing fields, or "swift" for Swift object change notifications,
+	// whose containers and objects are mapped to buckets and keys.
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// Auth lists the tokens expected from the Ceph clusters pushing
+	// notifications to the adapter, one per request path, host, or both.
+	// When set, requests to other paths and hosts, or without the token of
+	// theirs, are rejected.
+	// +optional
+	Auth []CephSourceAuth `json:"auth,omitempty"`
+
+	// SigV4 requires the notifications to be signed with AWS Signature
+	// Version 4 with one of the listed access keys, as pushed by the RGW
+	// builds signing them, instead of a token. Not compatible with Auth,
+	// both using the Authorization header.
+	// +optional
+	SigV4 *CephSourceSigV4 `json:"sigV4,omitempty"`
+
+	// Listeners adds listeners to the one of Port, each with its own port,
+	// TLS, auth and filter, e.g. a TLS port for the production RGW and a
+	// plaintext one bound to localhost for a debugging proxy. The auth and
+	// filter of the spec apply to the listener of Port only.
+	// +optional
+	Listeners []CephSourceListener `json:"listeners,omitempty"`
+
+	// Admin serves the admin endpoints of the adapter, /version and
+	// /admin/failed-events, on a port of their own rather than on Port, for
+	// the notifications of the RGW and the admin requests to be subject to
+	// different network policies. Metrics and profiling are served on ports
+	// of their own regardless.
+	// +optional
+	Admin *CephSourceAdmin `json:"admin,omitempty"`
+
+	// Backpressure makes the adapter respond 503 to Ceph when the sink
+	// signals overload (429 or 503), so that persistent topics retry the
+	// notification later instead of the adapter buffering it.
+	// +optional
+	Backpressure bool `json:"backpressure,omitempty"`
+
+	// SuccessStatus is the status code responded to Ceph for the
+	// notifications handled successfully: 200 (the default), 202 or 204.
+	// Some RGW builds treat any other code than 200 as a failure.
+	// +optional
+	SuccessStatus *int32 `json:"successStatus,omitempty"`
+
+	// EmptyNotifications is what to do with the notifications holding no
+	// record, which an endless stream of usually tells a misconfigured
+	// topic: "ignore" (the default) acknowledges them, "warn" also logs a
+	// warning, and "event" also sends a com.ceph.rgw.notification.empty
+	// event to the sink. They are counted regardless.
+	// +optional
+	EmptyNotifications string `json:"emptyNotifications,omitempty"`
+
+	// SkipDuplicates acknowledges the notifications RGW pushes again once
+	// delivered, among the last ones received, without delivering them
+	// again.
+	// +optional
+	SkipDuplicates bool `json:"skipDuplicates,omitempty"`
+
+	// MaxConcurrency bounds the number of concurrent sends to the sink. The
+	// adapter adapts the actual limit to the throughput the sink sustains,
+	// backing off when it signals congestion. Unbounded if unset.
+	// +optional
+	MaxConcurrency *int32 `json:"maxConcurrency,omitempty"`
+
+	// DecodeKeys URL-decodes the object keys sent by Ceph before using them
+	// in the subject and data of events. The key as sent is kept in the
+	// "rawkey" extension when it differs from the decoded one.
+	// +optional
+	DecodeKeys bool `json:"decodeKeys,omitempty"`
+
+	// Subject makes the subject of events, the object key, safe for the
+	// brokers that cannot take long subjects or some characters in
+	// attributes. The data keeps the full key.
+	// +optional
+	Subject *CephSourceSubject `json:"subject,omitempty"`
+
+	// Senders restricts the Ceph clusters notifications are accepted from.
+	// Notifications from other senders are rejected.
+	// +optional
+	Senders *CephSourceSenders `json:"senders,omitempty"`
+
+	// DebugLogSampling samples the per-event debug logs of the adapter, so
+	// that enabling debug logging on a busy bucket keeps the log volume
+	// bounded. Per-event debug logs are not sampled if unset.
+	// +optional
+	DebugLogSampling *CephSourceLogSampling `json:"debugLogSampling,omitempty"`
+
+	// Verify makes the adapter check the eTag of created objects against a
+	// HEAD of the object, and stamp the result in the "verified" extension,
+	// catching objects overwritten since the notification was sent. The
+	// removed versions of objects are also inspected, so that delete markers
+	// get their own event type even when notified as removals.
+	// +optional
+	Verify *CephSourceVerify `json:"verify,omitempty"`
+
+	// Usage makes the adapter poll the usage the RGW logs per user and
+	// bucket, and send it as events of type UsageEventType, for chargeback
+	// and anomaly detection pipelines. Requires the usage log of the RGW
+	// ("rgw enable usage log").
+	// +optional
+	Usage *CephSourceUsage `json:"usage,omitempty"`
+
+	// ExpiryPreview makes the adapter inspect the lifecycle rules of
+	// buckets and send events of type ExpiryPreviewEventType ahead of the
+	// expiration of their objects, for them to be archived or their owners
+	// warned before the RGW deletes them.
+	// +optional
+	ExpiryPreview *CephSourceExpiryPreview `json:"expiryPreview,omitempty"`
+
+	// Audit mirrors every event the adapter emits, in addition to its
+	// delivery, to daily NDJSON objects of an audit bucket, for an
+	// immutable record of everything the source emitted.
+	// +optional
+	Audit *CephSourceAudit `json:"audit,omitempty"`
+
+	// CredentialsFrom sources the RGW credentials of spec.verify,
+	// spec.usage, spec.expiryPreview and spec.audit from an external secret
+	// manager rather than from Secrets, for no long-lived S3 key to be
+	// stored in etcd. Their accessKeyId and secretAccessKey are then unset.
+	// +optional
+	CredentialsFrom *CephSourceCredentialsFrom `json:"credentialsFrom,omitempty"`
+
+	// RGWTLS restricts the certificates the adapters accept from the https
+	// endpoints of spec.verify, spec.usage, spec.expiryPreview and
+	// spec.audit, against a man-in-the-middle on the storage network. The
+	// certificates signed by the system certificate authorities are
+	// accepted if unset.
+	// +optional
+	RGWTLS *CephSourceRGWTLS `json:"rgwTLS,omitempty"`
+
+	// CopySource resolves the object copied objects were copied from, and
+	// includes it in the event data. S3 does not keep the copy source, which
+	// is read from the "x-amz-meta-copy-source" user metadata that copying
+	// clients set, from the notification or, when spec.verify is set, from
+	// a HEAD of the object.
+	// +optional
+	CopySource bool `json:"copySource,omitempty"`
+
+	// Filter restricts the notifications sent to the sink. All the
+	// notifications are sent if unset.
+	// +optional
+	Filter *CephSourceFilter `json:"filter,omitempty"`
+
+	// Retention retains the events that could not be delivered to the sink
+	// on a volume of the receive adapter, to be re-driven to it once it is
+	// back. Events are not retained if unset.
+	// +optional
+	Retention *CephSourceRetention `json:"retention,omitempty"`
+
+	// Storage selects the stores backing the retention of the events and
+	// the detection of the duplicate notifications, among those built in
+	// the receive adapter, "file" and "memory", or registered by downstream
+	// builds. The defaults are used if unset.
+	// +optional
+	Storage *CephSourceStorage `json:"storage,omitempty"`
+
+	// Checkpoint persists the last notifications processed per bucket and
+	// key prefix, for the notifications RGW pushes again after a restart of
+	// the adapter to be detected, and optionally skipped. Not persisted if
+	// unset.
+	// +optional
+	Checkpoint *CephSourceCheckpoint `json:"checkpoint,omitempty"`
+
+	// Delivery configures the retries of the events the sink failed to
+	// accept, and the dead letter sink of the ones it will not accept.
+	// Failed events are reported to Ceph right away if unset.
+	// +optional
+	Delivery *CephSourceDelivery `json:"delivery,omitempty"`
+
+	// LifecycleEvents sends an event of type LifecycleEventType when each
+	// adapter starts and when it stops, with the counts of the
+	// notifications it received and events it sent, for the consumers to
+	// detect the gaps in the stream caused by the restarts of the source.
+	// Not sent if unset.
+	// +optional
+	LifecycleEvents *CephSourceLifecycleEvents `json:"lifecycleEvents,omitempty"`
+
+	// DNS configures the resolution of the hostnames of the sinks, e.g.
+	// caching their addresses to relieve the cluster DNS at high event
+	// rates. The pod DNS settings are used as is if unset.
+	// +optional
+	DNS *CephSourceDNS `json:"dns,omitempty"`
+
+	// TLSPolicy restricts the TLS versions and cipher suites of the TLS
+	// listeners and of the sink client of the adapter, e.g. for regulated
+	// environments. The defaults of Go are used if unset.
+	// +optional
+	TLSPolicy *CephSourceTLSPolicy `json:"tlsPolicy,omitempty"`
+
+	// SinkHeaders lists the HTTP headers added to every request to the sink,
+	// e.g. the API key or tenant of an external webhook consumer.
+	// +optional
+	SinkHeaders []CephSourceHeader `json:"sinkHeaders,omitempty"`
+
+	// MaxEventSize bounds the size of the events, e.g. to the limit of the
+	// Broker they are sent to, so that larger events are handled by policy
+	// rather than rejected by the sink. Not bounded if unset.
+	// +optional
+	MaxEventSize *CephSourceMaxEventSize `json:"maxEventSize,omitempty"`
+
+	// Dispatcher splits the adapter into a receiver, acknowledging the
+	// notifications once their events are queued, and a dispatcher
+	// delivering the queued events to the sink, so that both can be scaled
+	// and upgraded independently. The adapter delivers the events itself if
+	// unset.
+	// +optional
+	Dispatcher *CephSourceDispatcher `json:"dispatcher,omitempty"`
+
+	// Routes sends the events of the objects whose keys match a prefix to
+	// the sink of that prefix, as listed by a ConfigMap the adapter reloads
+	// on change, so that one bucket can feed several pipelines. Events of
+	// the other keys are sent to the sink. Not routed if unset.
+	// +optional
+	Routes *CephSourceRoutes `json:"routes,omitempty"`
+
+	// ConfigFile is a ConfigMap holding further settings of the adapters,
+	// e.g. of their filters, routing, delivery and enrichment, in a single
+	// file they reload on change. The settings of the spec take
+	// precedence. Not read if unset.
+	// +optional
+	ConfigFile *CephSourceConfigFile `json:"configFile,omitempty"`
+
+	// RateLimits caps the rate of notifications accepted per bucket, so
+	// that a workload writing objects in a tight loop cannot flood the sink.
+	// Notifications beyond the limit are rejected with 503, for persistent
+	// topics to retry them later. Not limited if unset.
+	// +optional
+	RateLimits []CephSourceRateLimit `json:"rateLimits,omitempty"`
+
+	// EventTime bounds the time of events relative to the clock of the
+	// adapter, so that skewed RGW nodes do not silently produce events with
+	// misleading times. Event times are not checked if unset.
+	// +optional
+	EventTime *CephSourceEventTime `json:"eventTime,omitempty"`
+
+	// AgeSLO trips when the age of the notifications received, from the
+	// time of their event to their receipt, exceeds a threshold for a
+	// sustained period, telling a backlog in the RGW or network issues
+	// between it and the adapter rather than issues delivering to the sink.
+	// +optional
+	AgeSLO *CephSourceAgeSLO `json:"ageSLO,omitempty"`
+
+	// TrafficExpectations lists the minimum numbers of notifications of
+	// some event names expected per period, e.g. at least one
+	// s3:ObjectCreated:* per hour, to catch silently broken notification
+	// configurations of the RGW.
+	// +optional
+	TrafficExpectations []CephSourceTrafficExpectation `json:"trafficExpectations,omitempty"`
+
+	// MaintenanceWindows lists the windows, e.g. of planned downstream
+	// maintenance, during which events are not delivered to the sink.
+	// +optional
+	MaintenanceWindows []CephSourceMaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// BucketSources overrides the CloudEvent source of the events of the
+	// listed buckets, keyed by bucket name, e.g. "fishbucket":
+	// "https://storage.example.com/fishbucket", so that each bucket served by
+	// the adapter gets a distinct, stable source. The events of other
+	// buckets keep the source of the profile.
+	// +optional
+	BucketSources map[string]string `json:"bucketSources,omitempty"`
+
+	// SourceURI selects a documented scheme for the CloudEvent source of the
+	// events, for the routing rules of an organization to rely on the same
+	// one across CephSources. Defaults to the source of the profile. The
+	// buckets of BucketSources keep theirs.
+	// +optional
+	SourceURI *CephSourceSourceURI `json:"sourceURI,omitempty"`
+
+	// MetadataExtensions lists the user metadata of objects, e.g.
+	// "x-amz-meta-team", promoted to CloudEvent extensions for Triggers to
+	// filter on. The extensions are named after the metadata without the
+	// "x-amz-meta-" prefix, lowercased and stripped of the characters other
+	// than letters and digits, e.g. "team". The metadata is read from the
+	// notification, else from a HEAD of created objects with spec.verify.
+	// +optional
+	MetadataExtensions []string `json:"metadataExtensions,omitempty"`
+
+	// DataProjection lists JSONPath expressions, e.g. "$.s3.object.key",
+	// selecting the fields of the event data that are sent, at their place
+	// in the data; the others are dropped. Expressions are made of field
+	// names, as ".name" or "['name']", and of "[*]" for all the elements of
+	// arrays. The projected data has no dataschema.
+	// +optional
+	DataProjection []string `json:"dataProjection,omitempty"`
+
+	// DataTemplate is a Go template rendering the JSON of the event data
+	// from the record, after its projection, to rename fields or compute
+	// new ones, e.g. {"key": {{json .s3.object.key}}}. Besides the functions
+	// of text/template, templates can use json, default, lower, upper,
+	// trimPrefix, trimSuffix, replace, split and join. The transformed data
+	// has no dataschema.
+	// +optional
+	DataTemplate string `json:"dataTemplate,omitempty"`
+
+	// Expose has the controller expose the receive adapter outside of the
+	// cluster, for Ceph clusters running outside Kubernetes, and publish its
+	// URL in status.externalURL for their topics to push to. Requires
+	// Admin, for the admin endpoints not to be exposed along.
+	// +optional
+	Expose *CephSourceExpose `json:"expose,omitempty"`
+
+	// Mesh deploys the adapter for a service mesh: "istio" or "linkerd".
+	// The adapter then listens on localhost only and its pod is annotated
+	// for sidecar injection, so that notifications reach it through the
+	// sidecar, with the mTLS of the mesh. The metrics and profiling ports are
+	// excluded from the sidecar to stay scrapable. With Istio, an Istio
+	// Sidecar forwards the notifications to localhost. Requires a fixed
+	// port.
+	// +optional
+	Mesh string `json:"mesh,omitempty"`
+
+	// Resources are the compute resources of the receive adapter
+	// container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// CephSourceExpose describes how the receive adapter is exposed outside of
+// the cluster.
+type CephSourceExpose struct {
+	// Type is the kind of object exposing the adapter: "Ingress" (the
+	// default), "Route" for an OpenShift Route, or "LoadBalancer" for a
+	// Service of that type.
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Host is the external host name of the Ingress or Route. Required for
+	// an Ingress, a Route defaults to the host generated by OpenShift.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// IngressClassName selects the Ingress controller of an Ingress.
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+
+	// TLS terminates TLS in front of the adapter, making its URL https. An
+	// Ingress uses the certificate of TLS.SecretName, a Route the one of the
+	// router, and a LoadBalancer the one configured with the annotations of
+	// the cloud provider.
+	// +optional
+	TLS *CephSourceExposeTLS `json:"tls,omitempty"`
+
+	// Annotations are set on the exposing object, e.g. to configure a cloud
+	// load balancer.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// CephSourceExposeTLS describes the TLS termination of an exposed adapter.
+type CephSourceExposeTLS struct {
+	// SecretName names the kubernetes.io/tls Secret holding the certificate
+	// of an Ingress.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// CephSourceMaintenanceWindow is a recurring window during which events are
+// spooled or dropped rather than delivered.
+type CephSourceMaintenanceWindow struct {
+	// Schedule is the cron schedule the window starts on, e.g. "0 2 * * 6",
+	// optionally prefixed with a time zone, e.g. "CRON_TZ=Europe/Paris".
+	// Defaults to UTC.
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window lasts.
+	Duration metav1.Duration `json:"duration"`
+
+	// Action is what to do with the events during the window: "spool" (the
+	// default) retains them, which requires spec.retention, to be re-driven
+	// to the sink at the end of the window, and "drop" drops them.
+	// +optional
+	Action string `json:"action,omitempty"`
+}
+
+// CephSourceAgeSLO describes the objective of the age of the notifications
+// received.
+type CephSourceAgeSLO struct {
+	// Threshold is the age the notifications are to be received within.
+	Threshold metav1.Duration `json:"threshold"`
+
+	// Period is for how long the notifications received must all exceed
+	// the threshold for the objective to trip, and all be within it for it
+	// to recover. Defaults to five minutes.
+	// +optional
+	Period *metav1.Duration `json:"period,omitempty"`
+}
+
+// CephSourceSourceURI describes the CloudEvent source of the events.
+type CephSourceSourceURI struct {
+	// Scheme is the scheme of the source: "arn" for the bucket ARN, e.g.
+	// "arn:aws:s3:::fishbucket", "ceph" for
+	// "ceph://<cluster>/<zonegroup>/<bucket>", or "concatenation" for the
+	// event source, zonegroup and bucket name joined with dots, e.g.
+	// "ceph:s3.us-east-1.fishbucket", the source of the ceph profile.
+	Scheme string `json:"scheme"`
+
+	// Cluster is the name of the Ceph cluster in the sources of the "ceph"
+	// scheme, e.g. "ceph-eu-1". Required with that scheme.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// CephSourceTrafficExpectation describes the notifications expected per
+// period.
+type CephSourceTrafficExpectation struct {
+	// EventName is the event name of the notifications expected, e.g.
+	// "s3:ObjectCreated:*", matched as the ones of spec.filter.
+	EventName string `json:"eventName"`
+
+	// MinCount is the minimum number of notifications expected per period.
+	// Defaults to 1.
+	// +optional
+	MinCount *int32 `json:"minCount,omitempty"`
+
+	// Period is the period the notifications are counted over, e.g. "1h".
+	Period metav1.Duration `json:"period"`
+}
+
+// CephSourceEventTime describes the tolerated event times and what to do
+// with the events out of them.
+type CephSourceEventTime struct {
+	// MaxFuture is how far in the future the time of an event may be.
+	// Defaults to one minute.
+	// +optional
+	MaxFuture *metav1.Duration `json:"maxFuture,omitempty"`
+
+	// MaxAge is how far in the past the time of an event may be. Unbounded
+	// if unset.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+
+	// Policy is what to do with the events out of the tolerated times:
+	// "flag" (the default) sets the "timeskew" extension to "future" or
+	// "past", "clamp" also replaces their time with the time they were
+	// received, and "reject" fails their notification.
+	// +optional
+	Policy string `json:"policy,omitempty"`
+}
+
+// CephSourceRateLimit is a token bucket limiting the notifications of a
+// bucket.
+type CephSourceRateLimit struct {
+	// Bucket is the bucket the limit applies to. "*" applies the limit to
+	// each bucket without a limit of its own.
+	Bucket string `json:"bucket"`
+
+	// EventsPerSecond is the sustained rate of notifications accepted.
+	EventsPerSecond int32 `json:"eventsPerSecond"`
+
+	// Burst is the number of notifications accepted at once. Defaults to
+	// EventsPerSecond.
+	// +optional
+	Burst *int32 `json:"burst,omitempty"`
+}
+
+// CephSourceRetention describes how the events that could not be delivered
+// are retained.
+type CephSourceRetention struct {
+	// MaxEvents bounds the number of retained events, the oldest ones being
+	// dropped beyond it. Defaults to 1000.
+	// +optional
+	MaxEvents *int32 `json:"maxEvents,omitempty"`
+
+	// Volume is the volume events are retained on. Defaults to an emptyDir,
+	// whose events are lost with the pod.
+	// +optional
+	Volume *corev1.VolumeSource `json:"volume,omitempty"`
+}
+
+// CephSourceCredentialsFrom describes where the adapters read the RGW
+// credentials from: the files "access-key-id" and "secret-access-key" of a
+// directory, or "<feature>-access-key-id" and "<feature>-secret-access-key"
+// for the credentials of a feature only, e.g. "audit-access-key-id". The
+// features are "verify", "usage", "expiry-preview" and "audit". The files
+// prefixed with "next-", e.g. "next-access-key-id", hold the credentials of
+// the key that replaces the current one during its rotation, if any.
+// Exactly one of CSI and Annotations must be set.
+type CephSourceCredentialsFrom struct {
+	// CSI is a volume of a CSI driver holding the files, e.g. of the
+	// Secrets Store CSI driver with a SecretProviderClass.
+	// +optional
+	CSI *corev1.CSIVolumeSource `json:"csi,omitempty"`
+
+	// Annotations are set on the pods of the adapters for an
+	// annotation-driven secret manager, e.g. the Vault Agent injector, to
+	// write the files to Dir.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Dir is the absolute path of the directory the secret manager of
+	// Annotations writes the files to, e.g. "/vault/secrets".
+	// +optional
+	Dir string `json:"dir,omitempty"`
+}
+
+// CephSourceRGWTLS describes the certificates accepted from the RGW. At
+// least one of CABundle and PinnedKeys must be set.
+type CephSourceRGWTLS struct {
+	// CABundle references the ConfigMap key holding the PEM certificates of
+	// the certificate authorities the certificate of the RGW must be signed
+	// by, instead of the system ones.
+	// +optional
+	CABundle *corev1.ConfigMapKeySelector `json:"caBundle,omitempty"`
+
+	// PinnedKeys lists the base64 SHA-256 hashes of the subject public key
+	// info of certificates, of the RGW or of a certificate authority, one
+	// of which the verified chain of the certificate of the RGW must
+	// contain, e.g. "d6qzRu9zOECb90Uez27xWltNsj0e1Md7GkYYkVoZWmM=".
+	// +optional
+	PinnedKeys []string `json:"pinnedKeys,omitempty"`
+}
+
+// CephSourceS3Credentials references the Secret keys holding S3 credentials.
+type CephSourceS3Credentials struct {
+	AccessKeyID     corev1.SecretKeySelector `json:"accessKeyId"`
+	SecretAccessKey corev1.SecretKeySelector `json:"secretAccessKey"`
+}
+
+// CephSourceStorage describes the stores of the receive adapter.
+type CephSourceStorage struct {
+	// Spool is the store the events of spec.retention are retained in, one
+	// of SpoolStores. Defaults to "file", on the volume of spec.retention.
+	// +optional
+	Spool string `json:"spool,omitempty"`
+
+	// Dedup is the store the notifications of spec.skipDuplicates are
+	// remembered in, one of DedupStores. Defaults to "memory", forgotten
+	// with the pod.
+	// +optional
+	Dedup string `json:"dedup,omitempty"`
+}
+
+const (
+	// StoreFile and StoreMemory are the names of the stores built in the
+	// receive adapter.
+	StoreFile   = "file"
+	StoreMemory = "memory"
+)
+
+var (
+	// SpoolStores and DedupStores are the names of the stores spec.storage
+	// may select. The stores registered with the RegisterSpoolStore and
+	// RegisterDedupStore functions of the adapter package are added to
+	// them, for the webhook of a downstream build registering its own stores
+	// to accept them.
+	SpoolStores = sets.NewString(StoreFile, StoreMemory)
+	DedupStores = sets.NewString(StoreMemory)
+)
+
+// CephSourceCheckpoint describes how the processed notifications are
+// checkpointed.
+type CephSourceCheckpoint struct {
+	// PrefixDepth is the number of "/" delimited prefixes of the object
+	// keys sharding the checkpoints of a bucket, e.g. "images/" for
+	// "images/2021/cat.jpg" with 1, the default. Set 0 for a checkpoint per
+	// bucket.
+	// +optional
+	PrefixDepth *int32 `json:"prefixDepth,omitempty"`
+
+	// SkipRedelivered drops the notifications already processed according
+	// to the checkpoint of their shard, which remembers the last 1000
+	// notifications processed, rather than only counting them.
+	// +optional
+	SkipRedelivered bool `json:"skipRedelivered,omitempty"`
+
+	// Volume is the volume checkpoints are persisted on. Defaults to an
+	// emptyDir, whose checkpoints survive restarts of the adapter container
+	// but are lost with the pod.
+	// +optional
+	Volume *corev1.VolumeSource `json:"volume,omitempty"`
+}
+
+// CephSourceDelivery describes how events are delivered to the sink. As per
+// the delivery spec of Knative Eventing, failures without a response and
+// the 404, 408, 409, 429 and 5xx responses are retried, while the other
+// failures are terminal.
+type CephSourceDelivery struct {
+	// Retry is the number of retries of the retryable failures. The wait
+	// before each retry is the one asked by the Retry-After header of the
+	// sink response, if longer than the backoff delay. Defaults to 0.
+	// +optional
+	Retry *int32 `json:"retry,omitempty"`
+
+	// BackoffDelay is the wait before the first retry, doubled before each
+	// next one. Defaults to 1s.
+	// +optional
+	BackoffDelay *metav1.Duration `json:"backoffDelay,omitempty"`
+
+	// DeadLetterSink is where the events are sent on a terminal failure, or
+	// once their retries are exhausted, instead of failing the notification.
+	// +optional
+	DeadLetterSink *duckv1.Destination `json:"deadLetterSink,omitempty"`
+
+	// FailureSink is where an org.ceph.source.delivery-failed event is sent
+	// for each event that failed to be delivered for good, with its id,
+	// type, attempts and last response code, for event-driven alerting.
+	// +optional
+	FailureSink *duckv1.Destination `json:"failureSink,omitempty"`
+
+	// Failover lists the sinks the events fail over to while the sink of
+	// the source is down, e.g. during a Broker outage.
+	// +optional
+	Failover *CephSourceFailover `json:"failover,omitempty"`
+
+	// LoadShedding drops the notifications of low priority while the sink
+	// is slow, to keep delivering those of high priority during partial
+	// outages.
+	// +optional
+	LoadShedding *CephSourceLoadShedding `json:"loadShedding,omitempty"`
+}
+
+// CephSourceFailover describes the chain of sinks the events fail over to.
+// A sink failing to take an event without response or with a 5xx response
+// is deemed down and skipped for the cooldown, after which it is tried
+// again, the events going back to the first sink up.
+type CephSourceFailover struct {
+	// Sinks are the sinks after the sink of the source, in order.
+	Sinks []duckv1.Destination `json:"sinks"`
+
+	// Cooldown is for how long a sink deemed down is skipped. Defaults to
+	// 30s.
+	// +optional
+	Cooldown *metav1.Duration `json:"cooldown,omitempty"`
+}
+
+// CephSourceLoadShedding describes when notifications are shed and which.
+// Shedding starts once the sink takes longer than the threshold to respond
+// to the events for the period, and stops once it responds within it for
+// the period, or no event is sent to it for the period.
+type CephSourceLoadShedding struct {
+	// Threshold is the time the sink may take to respond to an event.
+	Threshold metav1.Duration `json:"threshold"`
+
+	// Period is for how long the sink must respond above or within the
+	// threshold for shedding to start or stop. Defaults to 1m.
+	// +optional
+	Period *metav1.Duration `json:"period,omitempty"`
+
+	// Priorities are the classes of the notifications, the first class a
+	// notification matches applying. The notifications matching no class
+	// are of high priority.
+	Priorities []CephSourcePriorityClass `json:"priorities"`
+}
+
+// CephSourcePriorityClass is a class of notifications of a priority.
+type CephSourcePriorityClass struct {
+	// Name names the class in the metrics.
+	Name string `json:"name"`
+
+	// Priority is "low" (the default), for the notifications of the class
+	// to be shed, or "high".
+	// +optional
+	Priority string `json:"priority,omitempty"`
+
+	// EventNames lists the event names of the notifications of the class,
+	// e.g. "s3:ObjectCreated:Put", a name ending with "*" matching all the
+	// names it prefixes. All the event names match if empty.
+	// +optional
+	EventNames []string `json:"eventNames,omitempty"`
+
+	// Buckets lists the buckets of the notifications of the class. All the
+	// buckets match if empty.
+	// +optional
+	Buckets []string `json:"buckets,omitempty"`
+}
+
+// CephSourceLifecycleEvents describes where the lifecycle events of the
+// adapters are sent.
+type CephSourceLifecycleEvents struct {
+	// Sink is where the lifecycle events are sent, e.g. a monitoring sink,
+	// rather than to the sink of the source.
+	// +optional
+	Sink *duckv1.Destination `json:"sink,omitempty"`
+}
+
+// CephSourceMaxEventSize describes the maximum size of the events and what
+// to do with the larger ones.
+type CephSourceMaxEventSize struct {
+	// Limit is the maximum size of an event in the JSON format of the
+	// structured mode, e.g. "1Mi".
+	Limit resource.Quantity `json:"limit"`
+
+	// Policy is what to do with the larger events: "truncate" (the default)
+	// sends them without data, with their size in the "truncated"
+	// extension, and "deadLetter" sends them to the dead letter sink of
+	// spec.delivery instead of the sink.
+	// +optional
+	Policy string `json:"policy,omitempty"`
+}
+
+// CephSourceDispatcher describes the queue between the receiver and the
+// dispatcher of a source.
+type CephSourceDispatcher struct {
+	// Queue is the volume the receiver queues events on and the dispatcher
+	// reads them from. It must be shared by their pods, e.g. a
+	// PersistentVolumeClaim with the ReadWriteMany access mode.
+	Queue corev1.VolumeSource `json:"queue"`
+
+	// MaxEvents bounds the number of queued events, notifications being
+	// rejected with 503 beyond it for Ceph to retry them later. Defaults to
+	// 10000.
+	// +optional
+	MaxEvents *int32 `json:"maxEvents,omitempty"`
+
+	// Interval is how often the dispatcher checks for queued events.
+	// Defaults to 1s.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// CephSourceRoutes references the routing table of a source, a YAML list of
+// routes with a key prefix and a sink URI, e.g.
+//
+//   - prefix: raw/
+//     sink: http://broker-ingress.knative-eventing.svc.cluster.local/ns/broker-a
+//
+// The longest matching prefix wins.
+type CephSourceRoutes struct {
+	// ConfigMap is the ConfigMap of the namespace of the source holding the
+	// routing table.
+	ConfigMap corev1.LocalObjectReference `json:"configMap"`
+
+	// Key is the key of the routing table in the ConfigMap. Defaults to
+	// "routes.yaml".
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// CephSourceConfigFile references the configuration file of the adapters, a
+// YAML or JSON object of their settings, named after their env vars either
+// as is or as sections and settings in camel case, e.g.
+//
+//	filter:
+//	  eventNames:
+//	    - s3:ObjectCreated:*
+//	delivery:
+//	  retry: 5
+//	MAINTENANCE_WINDOWS:
+//	  - schedule: "0 2 * * 6"
+//	    duration: 2h
+//
+// Files with unknown settings or invalid values are rejected.
+type CephSourceConfigFile struct {
+	// ConfigMap is the ConfigMap of the namespace of the source holding the
+	// file.
+	ConfigMap corev1.LocalObjectReference `json:"configMap"`
+
+	// Key is the key of the file in the ConfigMap. Defaults to
+	// "config.yaml".
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// CephSourceListener describes an additional listener of the adapter.
+type CephSourceListener struct {
+	// Name identifies the listener in the logs of the adapter.
+	Name string `json:"name"`
+
+	// Port is the port number the listener listens on.
+	Port string `json:"port"`
+
+	// BindAddress is the IP address the listener listens on, e.g.
+	// "127.0.0.1". All the addresses if unset.
+	// +optional
+	BindAddress string `json:"bindAddress,omitempty"`
+
+	// TLS serves the listener over TLS. Plaintext if unset.
+	// +optional
+	TLS *CephSourceListenerTLS `json:"tls,omitempty"`
+
+	// Auth lists the tokens expected by the listener, as spec.auth does.
+	// Requests are not authenticated if empty.
+	// +optional
+	Auth []CephSourceAuth `json:"auth,omitempty"`
+
+	// Filter restricts the notifications received by the listener sent to
+	// the sink, as spec.filter does. All of them are sent if unset.
+	// +optional
+	Filter *CephSourceFilter `json:"filter,omitempty"`
+}
+
+// CephSourceSubject describes how the subject of events is made safe.
+type CephSourceSubject struct {
+	// Escape percent-encodes the characters of the subject other than the
+	// unreserved characters of URLs and "/".
+	// +optional
+	Escape bool `json:"escape,omitempty"`
+
+	// MaxLength bounds the length in bytes of the subject, once escaped.
+	// Unbounded if unset.
+	// +optional
+	MaxLength *int32 `json:"maxLength,omitempty"`
+
+	// Overflow selects how subjects longer than MaxLength are shortened:
+	// "truncate" (the default) keeps their longest prefix that fits, and
+	// "hash" replaces them with the hex SHA-256 of the subject, cut to
+	// MaxLength.
+	// +optional
+	Overflow string `json:"overflow,omitempty"`
+}
+
+// CephSourceAdmin describes the port of the admin endpoints of the adapter.
+type CephSourceAdmin struct {
+	// Port is the port number the admin endpoints are served on.
+	Port string `json:"port"`
+
+	// Token is the token the admin verbs require, as bearer token or basic
+	// auth password, rather than any of the tokens of spec.auth.
+	// +optional
+	Token *corev1.SecretKeySelector `json:"token,omitempty"`
+}
+
+// CephSourceListenerTLS describes the TLS of a listener.
+type CephSourceListenerTLS struct {
+	// SecretName names the kubernetes.io/tls Secret holding the certificate
+	// of the listener.
+	SecretName string `json:"secretName"`
+
+	// ClientAuth requires the clients to present a certificate signed by
+	// the "ca.crt" of the Secret, for mutual TLS.
+	// +optional
+	ClientAuth bool `json:"clientAuth,omitempty"`
+}
+
+// CephSourceFilter selects the notifications sent to the sink. Notifications
+// must match all the set criteria.
+type CephSourceFilter struct {
+	// EventNames lists the event names of the notifications to send, e.g.
+	// "s3:ObjectCreated:Put". A name ending with "*" matches all the names
+	// it prefixes, e.g. "s3:ObjectCreated:*".
+	// +optional
+	EventNames []string `json:"eventNames,omitempty"`
+
+	// Versioned sends only the notifications of objects of versioned
+	// buckets when true, and of unversioned buckets when false.
+	// +optional
+	Versioned *bool `json:"versioned,omitempty"`
+
+	// DeleteMarkers selects the notifications of delete markers: "include"
+	// (the default) sends them, "exclude" drops them and "only" drops all
+	// the other notifications.
+	// +optional
+	DeleteMarkers string `json:"deleteMarkers,omitempty"`
+
+	// Encryption sends only the notifications of created objects stored
+	// "encrypted" or "unencrypted" server-side. The encryption of objects is
+	// read from their metadata or, with spec.verify, from a HEAD of them;
+	// notifications of objects of unknown encryption are dropped.
+	// +optional
+	Encryption string `json:"encryption,omitempty"`
+
+	// StorageClasses sends only the notifications of created and
+	// transitioned objects stored in one of the listed storage classes,
+	// e.g. "STANDARD" or "GLACIER", the target class for transitions. The
+	// storage class of objects is read from a HEAD of them, which requires
+	// spec.verify.
+	// +optional
+	StorageClasses []string `json:"storageClasses,omitempty"`
+}
+
+// CephSourceRGWCredentials describes how to reach the RGW, and the S3
+// credentials the requests to it are signed with.
+type CephSourceRGWCredentials struct {
+	// Endpoint is the URL of the RGW, e.g.
+	// "http://rook-ceph-rgw-my-store.rook-ceph.svc".
+	Endpoint *apis.URL `json:"endpoint"`
+
+	// Region is the region requests are signed for. Defaults to "us-east-1".
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// AccessKeyID and SecretAccessKey reference the Secret keys holding the
+	// S3 credentials of the user, unless spec.credentialsFrom is set.
+	AccessKeyID     corev1.SecretKeySelector `json:"accessKeyId"`
+	SecretAccessKey corev1.SecretKeySelector `json:"secretAccessKey"`
+
+	// NextCredentials reference the S3 credentials of the key that
+	// replaces the current one during its rotation, if any.
+	// +optional
+	NextCredentials *CephSourceS3Credentials `json:"nextCredentials,omitempty"`
+}
+
+// CephSourceVerify describes how to reach the objects whose notifications
+// are verified.
+type CephSourceVerify struct {
+	// The RGW serves the S3 API, the user being allowed to read the
+	// objects.
+	CephSourceRGWCredentials `json:",inline"`
+}
+
+// CephSourceUsage describes how to poll the usage of the RGW.
+type CephSourceUsage struct {
+	// The RGW serves the admin API, the user having the "usage=read"
+	// admin capability.
+	CephSourceRGWCredentials `json:",inline"`
+
+	// Users lists the users whose usage is reported. All the users if
+	// empty.
+	// +optional
+	Users []string `json:"users,omitempty"`
+
+	// Interval is how often the hours of usage logged since the last poll
+	// are reported. Defaults to an hour, the granularity of the usage log.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// CephSourceExpiryPreview describes the buckets whose objects are previewed
+// ahead of their expiration.
+type CephSourceExpiryPreview struct {
+	// The RGW serves the S3 API, the user being allowed to read the
+	// lifecycle configuration of the buckets and to list their objects.
+	CephSourceRGWCredentials `json:",inline"`
+
+	// Buckets lists the buckets whose lifecycle rules are inspected.
+	Buckets []string `json:"buckets"`
+
+	// Days is how many days ahead of their expiration objects are
+	// previewed. Defaults to 7.
+	// +optional
+	Days *int32 `json:"days,omitempty"`
+
+	// Interval is how often the buckets are inspected. Defaults to a day.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// CephSourceAudit describes the bucket the events are mirrored to. The
+// events are appended to the appendable object
+// "<prefix><yyyy-mm-dd>/<pod>.ndjson" of the day of their emission, one
+// JSON-encoded CloudEvent per line.
+type CephSourceAudit struct {
+	// The RGW serves the S3 API, the user being allowed to write to the
+	// bucket.
+	CephSourceRGWCredentials `json:",inline"`
+
+	// Bucket is the bucket the events are mirrored to, which should not
+	// notify the source.
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the keys of the objects, e.g. "audit/".
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// FlushInterval is how often the events emitted since the last write
+	// are written. Defaults to 10s.
+	// +optional
+	FlushInterval *metav1.Duration `json:"flushInterval,omitempty"`
+
+	// MaxBatch is the number of events written early, before the flush
+	// interval. Defaults to 1000.
+	// +optional
+	MaxBatch *int32 `json:"maxBatch,omitempty"`
+}
+
+// CephSourceLogSampling describes how repeated log entries are sampled:
+// every second, the first Initial entries with a given message are logged,
+// then every Thereafter-th one.
+type CephSourceLogSampling struct {
+	// Initial is the number of entries logged each second before sampling.
+	Initial int32 `json:"initial"`
+
+	// Thereafter is the sampling rate once Initial entries were logged,
+	// 0 dropping all further entries within the second.
+	// +optional
+	Thereafter int32 `json:"thereafter,omitempty"`
+}
+
+// CephSourceSenders describes the Ceph clusters expected to push
+// notifications. Empty lists match any value.
+type CephSourceSenders struct {
+	// EventSources lists the accepted eventSource of the records, e.g.
+	// "ceph:s3". They must not contain a comma.
+	// +optional
+	EventSources []string `json:"eventSources,omitempty"`
+
+	// Regions lists the accepted awsRegion (zonegroup) of the records. They
+	// must not contain a comma.
+	// +optional
+	Regions []string `json:"regions,omitempty"`
+
+	// Addresses lists the IP addresses or CIDRs of the RGW endpoints
+	// notifications are accepted from.
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// CephSourceAuth holds the token expected from the senders pushing
+// notifications to a given path of the adapter.
+type CephSourceAuth struct {
+	// Path is the request path the senders push to, e.g. "/cluster-a". It
+	// must not contain a comma. Required unless Host is set.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// Host is the host name the senders push to, e.g.
+	// "cluster-a.ceph-source.example.com", for RGW versions mangling the
+	// paths of push endpoints. An exposing Ingress gets a rule per host.
+	// Required unless Path is set.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// Token references the Secret key holding the token expected from the
+	// senders, either as a bearer token or as basic auth password.
+	Token corev1.SecretKeySelector `json:"token"`
+}
+
+// CephSourceSigV4 describes the AWS Signature Version 4 verification of the
+// notifications.
+type CephSourceSigV4 struct {
+	// Keys lists the access keys the notifications may be signed with,
+	// e.g. the current and next keys of a rotation.
+	Keys []CephSourceSigV4Key `json:"keys"`
+
+	// MaxSkew is how far from the time of the adapter the signing time of
+	// a notification may be, bounding the replays of captured requests.
+	// Defaults to 5 minutes.
+	// +optional
+	MaxSkew *metav1.Duration `json:"maxSkew,omitempty"`
+
+	// AllowUnsignedPayload accepts the notifications whose body is not
+	// signed, sent with an X-Amz-Content-Sha256 of UNSIGNED-PAYLOAD.
+	// +optional
+	AllowUnsignedPayload bool `json:"allowUnsignedPayload,omitempty"`
+}
+
+// CephSourceSigV4Key is an access key the notifications may be signed with.
+type CephSourceSigV4Key struct {
+	// AccessKeyID is the access key ID of the key.
+	AccessKeyID string `json:"accessKeyId"`
+
+	// SecretAccessKey references the Secret key holding the secret access
+	// key of the key.
+	SecretAccessKey corev1.SecretKeySelector `json:"secretAccessKey"`
+}
+
+// CephSourceDNS describes how the adapters resolve the sink hostnames.
+type CephSourceDNS struct {
+	// CacheTTL is how long the addresses of a hostname are cached, the
+	// expired ones being still used when their resolution fails. Not cached
+	// if unset.
+	// +optional
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+
+	// Nameservers lists the IP addresses, with an optional port, of the DNS
+	// servers queried in turn instead of the ones of the pod.
+	// +optional
+	Nameservers []string `json:"nameservers,omitempty"`
+}
+
+// CephSourceTLSPolicy describes the TLS versions and cipher suites the
+// adapter accepts and offers.
+type CephSourceTLSPolicy struct {
+	// MinVersion is the minimum TLS version, "1.2" or "1.3".
+	// +optional
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// CipherSuites lists the cipher suites of TLS 1.2, as named by Go, e.g.
+	// "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384". Insecure cipher suites are
+	// rejected. The ones of TLS 1.3 are not configurable.
+	// +optional
+	CipherSuites []string `json:"cipherSuites,omitempty"`
+}
+
+// CephSourceHeader is an HTTP header added to the requests to the sink.
+type CephSourceHeader struct {
+	// Name is the name of the header, e.g. "X-Api-Key".
+	Name string `json:"name"`
+
+	// Value is the value of the header.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// ValueFrom references the Secret key holding the value of the header,
+	// for credentials.
+	// +optional
+	ValueFrom *corev1.SecretKeySelector `json:"valueFrom,omitempty"`
+}
+
+const (
+	// ProfileCeph maps notifications the way the source always has: the type
+	// is prefixed Ceph event name and the source is built from the event
+	// source, region and bucket name.
+	ProfileCeph = "ceph"
+
+	// ProfileAWSS3 maps notifications per the CloudEvents AWS S3 adapter
+	// specification, with the bucket ARN as source.
+	ProfileAWSS3 = "aws-s3"
+
+	// ProfileEventBridge keeps the attributes of the ceph profile but shapes
+	// the event data like an Amazon EventBridge S3 event notification.
+	ProfileEventBridge = "eventbridge"
+
+	// ProfileCDEvents emits the created objects as CDEvents artifact
+	// published events and the removed ones as artifact deleted events.
+	ProfileCDEvents = "cdevents"
+)
+
+const (
+	// SourceSchemeARN sets the bucket ARN as source.
+	SourceSchemeARN = "arn"
+
+	// SourceSchemeCeph sets ceph://<cluster>/<zonegroup>/<bucket> as source.
+	SourceSchemeCeph = "ceph"
+
+	// SourceSchemeConcatenation sets the event source, zonegroup and bucket
+	// name joined with dots as source.
+	SourceSchemeConcatenation = "concatenation"
+)
+
+const (
+	// FormatCeph parses the notifications of the Ceph RGW.
+	FormatCeph = "ceph"
+
+	// FormatMinIO parses the webhook notifications of MinIO.
+	FormatMinIO = "minio"
+
+	// FormatGeneric leniently parses the notifications of S3-compatible
+	// notifiers.
+	FormatGeneric = "generic"
+
+	// FormatSwift parses Swift object change notifications.
+	FormatSwift = "swift"
+)
+
+const (
+	// DeleteMarkersInclude sends the notifications of delete markers.
+	DeleteMarkersInclude = "include"
+
+	// DeleteMarkersExclude drops the notifications of delete markers.
+	DeleteMarkersExclude = "exclude"
+
+	// DeleteMarkersOnly sends only the notifications of delete markers.
+	DeleteMarkersOnly = "only"
+)
+
+const (
+	// EncryptionEncrypted sends only the notifications of objects encrypted
+	// server-side.
+	EncryptionEncrypted = "encrypted"
+
+	// EncryptionUnencrypted sends only the notifications of objects not
+	// encrypted server-side.
+	EncryptionUnencrypted = "unencrypted"
+)
+
+const (
+	// EventTimeFlag flags the events out of the tolerated times.
+	EventTimeFlag = "flag"
+
+	// EventTimeClamp flags the events out of the tolerated times and sets
+	// their time to the time they were received.
+	EventTimeClamp = "clamp"
+
+	// EventTimeReject fails the notifications of the events out of the
+	// tolerated times.
+	EventTimeReject = "reject"
+)
+
+const (
+	// EmptyNotificationsIgnore acknowledges the notifications holding no
+	// record.
+	EmptyNotificationsIgnore = "ignore"
+
+	// EmptyNotificationsWarn logs a warning on the notifications holding no
+	// record.
+	EmptyNotificationsWarn = "warn"
+
+	// EmptyNotificationsEvent sends an event on the notifications holding no
+	// record.
+	EmptyNotificationsEvent = "event"
+)
+
+const (
+	// EventSizeTruncate sends the events larger than the maximum size
+	// without data.
+	EventSizeTruncate = "truncate"
+
+	// EventSizeDeadLetter sends the events larger than the maximum size to
+	// the dead letter sink.
+	EventSizeDeadLetter = "deadLetter"
+)
+
+const (
+	// MeshIstio deploys the adapter with an Istio sidecar.
+	MeshIstio = "istio"
+
+	// MeshLinkerd deploys the adapter with a Linkerd proxy.
+	MeshLinkerd = "linkerd"
+)
+
+const (
+	// ExposeIngress exposes the receive adapter with an Ingress.
+	ExposeIngress = "Ingress"
+
+	// ExposeRoute exposes the receive adapter with an OpenShift Route.
+	ExposeRoute = "Route"
+
+	// ExposeLoadBalancer exposes the receive adapter with a Service of type
+	// LoadBalancer.
+	ExposeLoadBalancer = "LoadBalancer"
+)
+
+const (
+	// MaintenanceSpool retains the events of maintenance windows, to be
+	// re-driven at their end.
+	MaintenanceSpool = "spool"
+
+	// MaintenanceDrop drops the events of maintenance windows.
+	MaintenanceDrop = "drop"
+)
+
+const (
+	// PriorityLow classes notifications to be shed while the sink is slow.
+	PriorityLow = "low"
+
+	// PriorityHigh classes notifications always delivered.
+	PriorityHigh = "high"
+)
+
+const (
+	// SubjectOverflowTruncate truncates the subjects longer than the maximum
+	// length.
+	SubjectOverflowTruncate = "truncate"
+
+	// SubjectOverflowHash replaces the subjects longer than the maximum
+	// length with their hash.
+	SubjectOverflowHash = "hash"
+)
+
+const (
+	// PayloadRecord sets the notification record as event data.
+	PayloadRecord = "record"
+
+	// PayloadRecordEnvelope sets a Records array holding the notification
+	// record as event data. Each event holds one record, even when the
+	// notification pushed by Ceph holds several.
+	PayloadRecordEnvelope = "recordEnvelope"
+
+	// PayloadFlat sets a flattened view of the notification record as event
+	// data.
+	PayloadFlat = "flat"
+)
+
+const (
+	// SpecVersionV1 sends CloudEvents 1.0 events.
+	SpecVersionV1 = "1.0"
+
+	// SpecVersionV03 sends CloudEvents 0.3 events.
+	SpecVersionV03 = "0.3"
+)
+
+const (
+	// ContentModeBinary sends the events in binary content mode.
+	ContentModeBinary = "binary"
+
+	// ContentModeStructured sends the events in structured content mode.
+	ContentModeStructured = "structured"
+)
+
+const (
+	// CephSourceEventTypePrefix is prepended to the name of the bucket
+	// notification to form the CloudEvent type.
+	CephSourceEventTypePrefix = "com.amazonaws."
+
+	// AWSS3EventTypePrefix is prepended to the S3 event name, stripped of
+	// its "s3:" prefix, to form the CloudEvent type of the aws-s3 profile.
+	AWSS3EventTypePrefix = "com.amazonaws.s3."
+
+	// CDEventsArtifactPublished and CDEventsArtifactDeleted are the
+	// CloudEvent types of the cdevents profile.
+	CDEventsArtifactPublished = "dev.cdevents.artifact.published.0.2.0"
+	CDEventsArtifactDeleted   = "dev.cdevents.artifact.deleted.0.1.0"
+
+	// UsageEventType is the CloudEvent type of the usage of a bucket by a
+	// user over an hour, reported with spec.usage.
+	UsageEventType = "com.ceph.rgw.usage"
+
+	// ExpiryPreviewEventType is the CloudEvent type of an object due to be
+	// expired by a lifecycle rule, reported with spec.expiryPreview.
+	ExpiryPreviewEventType = "com.ceph.rgw.object.expiring"
+
+	// EmptyNotificationEventType is the CloudEvent type of a notification
+	// holding no record, reported with spec.emptyNotifications.
+	EmptyNotificationEventType = "com.ceph.rgw.notification.empty"
+
+	// LifecycleEventType is the CloudEvent type of the start or stop of an
+	// adapter, reported with spec.lifecycleEvents.
+	LifecycleEventType = "org.ceph.source.lifecycle"
+)
+
+// CephEventNames lists the bucket notification event names sent by Ceph.
+var CephEventNames = []string{
+	"s3:ObjectCreated:Put",
+	"s3:ObjectCreated:Post",
+	"s3:ObjectCreated:Copy",
+	"s3:ObjectCreated:CompleteMultipartUpload",
+	"s3:ObjectRemoved:Delete",
+	"s3:ObjectRemoved:DeleteMarkerCreated",
+	"s3:ObjectLifecycle:Transition:Current",
+	"s3:ObjectLifecycle:Transition:NonCurrent",
+}
+
+// IsTransition reports whether an event name notifies the transition of an
+// object to another storage class, e.g. by a lifecycle rule.
+func IsTransition(eventName string) bool {
+	return strings.HasPrefix(eventName, "s3:ObjectLifecycle:Transition:") ||
+		strings.HasPrefix(eventName, "s3:LifecycleTransition")
+}
+
+// EventType returns the CloudEvent type of a bucket notification event name
+// for the given profile.
+func EventType(profile, eventName string) string {
+	return EventTypeWithPrefix(profile, "", eventName)
+}
+
+// EventTypeWithPrefix returns the CloudEvent type of a bucket notification
+// event name for the given profile, with prefix replacing the type prefix of
+// the profile unless empty.
+func EventTypeWithPrefix(profile, prefix, eventName string) string {
+	switch profile {
+	case ProfileAWSS3:
+		if prefix == "" {
+			prefix = AWSS3EventTypePrefix
+		}
+		return prefix + strings.TrimPrefix(eventName, "s3:")
+	case ProfileCDEvents:
+		if !IsTransition(eventName) {
+			if strings.HasPrefix(eventName, "s3:ObjectRemoved:") {
+				return CDEventsArtifactDeleted
+			}
+			return CDEventsArtifactPublished
+		}
+		// Transitions neither publish nor delete artifacts.
+	}
+	if prefix == "" {
+		prefix = CephSourceEventTypePrefix
+	}
+	return prefix + eventName
+}
+
+// UserMetadataPrefix is the prefix of the user metadata of objects.
+const UserMetadataPrefix = "x-amz-meta-"
+
+// MetadataExtensionName returns the CloudEvent extension the given user
+// metadata is promoted to, empty if none can be named after it.
+func MetadataExtensionName(key string) string {
+	key = strings.ToLower(key)
+	key = strings.TrimPrefix(key, UserMetadataPrefix)
+	return strings.Map(func(r rune) rune {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return -1
+	}, key)
+}
+
+// EventTypes returns the CloudEvent types emitted by the source, without
+// duplicates.
+func (sspec *CephSourceSpec) EventTypes() []string {
+	types := make([]string, 0, len(CephEventNames))
+	seen := make(map[string]struct{}, len(CephEventNames))
+	for _, name := range CephEventNames {
+		t := EventTypeWithPrefix(sspec.Profile, sspec.TypePrefix, name)
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		types = append(types, t)
+	}
+	if sspec.Usage != nil {
+		types = append(types, UsageEventType)
+	}
+	if sspec.ExpiryPreview != nil {
+		types = append(types, ExpiryPreviewEventType)
+	}
+	if sspec.LifecycleEvents != nil && sspec.LifecycleEvents.Sink == nil {
+		types = append(types, LifecycleEventType)
+	}
+	return types
+}
+
+// RGWFeature is a feature of a source sending requests to the RGW.
+// +k8s:deepcopy-gen=false
+type RGWFeature struct {
+	// Name names the feature, e.g. "expiry-preview".
+	Name string
+	// Field is the field of the feature in the spec, e.g. "expiryPreview".
+	Field string
+	// RGW is how the feature reaches the RGW.
+	RGW *CephSourceRGWCredentials
+}
+
+// RGWFeatures returns the enabled features sending requests to the RGW,
+// among "verify", "usage", "expiry-preview" and "audit".
+func (sspec *CephSourceSpec) RGWFeatures() []RGWFeature {
+	var features []RGWFeature
+	if sspec.Verify != nil {
+		features = append(features, RGWFeature{"verify", "verify", &sspec.Verify.CephSourceRGWCredentials})
+	}
+	if sspec.Usage != nil {
+		features = append(features, RGWFeature{"usage", "usage", &sspec.Usage.CephSourceRGWCredentials})
+	}
+	if sspec.ExpiryPreview != nil {
+		features = append(features, RGWFeature{"expiry-preview", "expiryPreview", &sspec.ExpiryPreview.CephSourceRGWCredentials})
+	}
+	if sspec.Audit != nil {
+		features = append(features, RGWFeature{"audit", "audit", &sspec.Audit.CephSourceRGWCredentials})
+	}
+	return features
+}
+
+// RotatedCredentials returns the features whose next RGW credentials are
+// set, among "verify", "usage", "expiry-preview" and "audit".
+func (sspec *CephSourceSpec) RotatedCredentials() []string {
+	var features []string
+	for _, f := range sspec.RGWFeatures() {
+		if f.RGW.NextCredentials != nil {
+			features = append(features, f.Name)
+		}
+	}
+	return features
 }
 
 const (
@@ -100,6 +1575,41 @@ type CephSourceStatus struct {
 	// * SinkURI - the current active sink URI that has been configured for the
 	//   Source.
 	duckv1.SourceStatus `json:",inline"`
+
+	// SuggestedFilters lists Trigger filters matching the events of the
+	// source, one per event type, when its sink is a Broker.
+	// +optional
+	SuggestedFilters []CephSourceTriggerFilter `json:"suggestedFilters,omitempty"`
+
+	// ExternalURL is the URL the receive adapter is reachable at from
+	// outside of the cluster, when exposed with spec.expose.
+	// +optional
+	ExternalURL *apis.URL `json:"externalURL,omitempty"`
+
+	// DeadLetterSinkURI is the resolved URI of spec.delivery.deadLetterSink.
+	// +optional
+	DeadLetterSinkURI *apis.URL `json:"deadLetterSinkUri,omitempty"`
+
+	// FailureSinkURI is the resolved URI of spec.delivery.failureSink.
+	// +optional
+	FailureSinkURI *apis.URL `json:"failureSinkUri,omitempty"`
+
+	// FailoverSinkURIs are the resolved URIs of
+	// spec.delivery.failover.sinks.
+	// +optional
+	FailoverSinkURIs []apis.URL `json:"failoverSinkUris,omitempty"`
+
+	// LifecycleSinkURI is the resolved URI of spec.lifecycleEvents.sink.
+	// +optional
+	LifecycleSinkURI *apis.URL `json:"lifecycleSinkUri,omitempty"`
+}
+
+// CephSourceTriggerFilter is a Trigger filter suggested to subscribe to the
+// events of a CephSource.
+type CephSourceTriggerFilter struct {
+	// Attributes holds the CloudEvent attributes to filter on, as in the
+	// spec.filter.attributes of a Trigger.
+	Attributes map[string]string `json:"attributes"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
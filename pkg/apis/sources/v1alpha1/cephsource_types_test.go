@@ -38,3 +38,49 @@ func TestCephSource(t *testing.T) {
 		})
 	}
 }
+
+func TestEventTypeWithPrefix(t *testing.T) {
+	testCases := map[string]struct {
+		profile   string
+		prefix    string
+		eventName string
+		want      string
+	}{
+		"ceph profile": {
+			profile:   ProfileCeph,
+			eventName: "s3:ObjectCreated:Put",
+			want:      "com.amazonaws.s3:ObjectCreated:Put",
+		},
+		"ceph profile with prefix": {
+			profile:   ProfileCeph,
+			prefix:    "com.example.",
+			eventName: "s3:ObjectCreated:Put",
+			want:      "com.example.s3:ObjectCreated:Put",
+		},
+		"aws-s3 profile with prefix": {
+			profile:   ProfileAWSS3,
+			prefix:    "com.example.",
+			eventName: "s3:ObjectCreated:Put",
+			want:      "com.example.ObjectCreated:Put",
+		},
+		"cdevents profile with prefix": {
+			profile:   ProfileCDEvents,
+			prefix:    "com.example.",
+			eventName: "s3:ObjectRemoved:Delete",
+			want:      CDEventsArtifactDeleted,
+		},
+		"cdevents transition with prefix": {
+			profile:   ProfileCDEvents,
+			prefix:    "com.example.",
+			eventName: "s3:ObjectLifecycle:Transition:Current",
+			want:      "com.example.s3:ObjectLifecycle:Transition:Current",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if got := EventTypeWithPrefix(tc.profile, tc.prefix, tc.eventName); got != tc.want {
+				t.Errorf("Unexpected type: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
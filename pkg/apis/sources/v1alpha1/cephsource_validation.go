@@ -18,21 +18,89 @@ package v1alpha1
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
 	"strconv"
+	"strings"
 
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing-ceph/pkg/apis/config"
+	"knative.dev/eventing-ceph/pkg/projection"
+	"knative.dev/eventing-ceph/pkg/tlspolicy"
+	"knative.dev/eventing-ceph/pkg/transform"
+)
+
+// reservedAttributes are the CloudEvent attributes, and the extensions set
+// by the adapter, user metadata cannot be promoted to.
+var reservedAttributes = sets.NewString(
+	"specversion", "id", "source", "type", "subject", "time",
+	"datacontenttype", "dataschema", "data", "database64",
+	"versionid", "deletemarker", "rawkey", "verified", "timeskew",
+	"requestid", "truncated", "knativeerrorcode", "encryption",
+	"storageclass", "retentionmode", "retainuntil", "legalhold",
 )
 
+// reservedHeaders are the lowercased headers of the requests to the sink
+// set by the HTTP client or the CloudEvents binding, along with the ce-
+// prefixed ones.
+var reservedHeaders = sets.NewString(
+	"content-type", "content-length", "content-encoding", "host",
+	"transfer-encoding", "connection", "traceparent", "tracestate",
+)
+
+// reservedPorts are the metrics and profiling ports of the adapter.
+var reservedPorts = sets.NewString("9090", "8008")
+
+// validHeaderName returns true if name is an HTTP token (RFC 7230).
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Validate validates CephSource.
 func (s *CephSource) Validate(ctx context.Context) *apis.FieldError {
 	var errs *apis.FieldError
 
 	errs = errs.Also(s.Spec.Validate(ctx).ViaField("spec"))
+	errs = errs.Also(s.validateQuota(ctx))
 
 	//errs is nil if everything is fine.
 	return errs
 }
 
+// validateQuota validates the source against the quota of its namespace in
+// the config-ceph-quotas ConfigMap. The number of sources is enforced by a
+// webhook callback, which can list them.
+func (s *CephSource) validateQuota(ctx context.Context) *apis.FieldError {
+	quota := config.FromContextOrDefaults(ctx).Quotas.ForNamespace(s.Namespace)
+	for _, f := range s.Spec.RGWFeatures() {
+		if endpoint := f.RGW.Endpoint; endpoint != nil && !quota.AllowsEndpoint(endpoint.URL()) {
+			return apis.ErrGeneric("endpoint "+endpoint.String()+" is not allowed in namespace "+s.Namespace, "spec."+f.Field+".endpoint")
+		}
+	}
+	return nil
+}
+
 // Validate validates CephSourceSpec.
 func (sspec *CephSourceSpec) Validate(ctx context.Context) *apis.FieldError {
 	var errs *apis.FieldError
@@ -49,5 +117,756 @@ func (sspec *CephSourceSpec) Validate(ctx context.Context) *apis.FieldError {
 		errs = errs.Also(apis.ErrInvalidValue(sspec.Port, "spec.port"))
 	}
 
+	switch sspec.Profile {
+	case "", ProfileCeph, ProfileAWSS3, ProfileEventBridge, ProfileCDEvents:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(sspec.Profile, "profile"))
+	}
+
+	switch sspec.Payload {
+	case "":
+	case PayloadRecord, PayloadRecordEnvelope, PayloadFlat:
+		if sspec.Profile == ProfileEventBridge || sspec.Profile == ProfileCDEvents {
+			errs = errs.Also(apis.ErrGeneric("payload is not supported with the "+sspec.Profile+" profile", "payload"))
+		}
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(sspec.Payload, "payload"))
+	}
+
+	switch sspec.SpecVersion {
+	case "", SpecVersionV1, SpecVersionV03:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(sspec.SpecVersion, "specVersion"))
+	}
+
+	switch sspec.ContentMode {
+	case "", ContentModeBinary, ContentModeStructured:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(sspec.ContentMode, "contentMode"))
+	}
+
+	switch sspec.Format {
+	case "", FormatCeph, FormatMinIO, FormatGeneric, FormatSwift:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(sspec.Format, "format"))
+	}
+
+	if sspec.MaxConcurrency != nil && *sspec.MaxConcurrency < 1 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(*sspec.MaxConcurrency, 1, math.MaxInt32, "maxConcurrency"))
+	}
+
+	switch sspec.EmptyNotifications {
+	case "", EmptyNotificationsIgnore, EmptyNotificationsWarn, EmptyNotificationsEvent:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(sspec.EmptyNotifications, "emptyNotifications"))
+	}
+
+	if s := sspec.Subject; s != nil {
+		if s.MaxLength != nil && *s.MaxLength < 1 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*s.MaxLength, 1, math.MaxInt32, "subject.maxLength"))
+		}
+		switch s.Overflow {
+		case "", SubjectOverflowTruncate, SubjectOverflowHash:
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(s.Overflow, "subject.overflow"))
+		}
+	}
+
+	if sspec.Senders != nil {
+		// The senders are passed to the adapter as comma separated lists.
+		for i, source := range sspec.Senders.EventSources {
+			if source == "" || strings.Contains(source, ",") {
+				errs = errs.Also(apis.ErrInvalidArrayValue(source, "senders.eventSources", i))
+			}
+		}
+		for i, region := range sspec.Senders.Regions {
+			if region == "" || strings.Contains(region, ",") {
+				errs = errs.Also(apis.ErrInvalidArrayValue(region, "senders.regions", i))
+			}
+		}
+		for i, address := range sspec.Senders.Addresses {
+			if net.ParseIP(address) != nil {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(address); err != nil {
+				errs = errs.Also(apis.ErrInvalidArrayValue(address, "senders.addresses", i))
+			}
+		}
+	}
+
+	if sampling := sspec.DebugLogSampling; sampling != nil {
+		if sampling.Initial < 1 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(sampling.Initial, 1, math.MaxInt32, "debugLogSampling.initial"))
+		}
+		if sampling.Thereafter < 0 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(sampling.Thereafter, 0, math.MaxInt32, "debugLogSampling.thereafter"))
+		}
+	}
+
+	for _, f := range sspec.RGWFeatures() {
+		errs = errs.Also(f.RGW.validate(sspec.CredentialsFrom).ViaField(f.Field))
+	}
+
+	if usage := sspec.Usage; usage != nil {
+		for i, user := range usage.Users {
+			if user == "" {
+				errs = errs.Also(apis.ErrInvalidArrayValue(user, "usage.users", i))
+			}
+		}
+		if usage.Interval != nil && usage.Interval.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(usage.Interval.Duration.String(), "usage.interval"))
+		}
+	}
+
+	if preview := sspec.ExpiryPreview; preview != nil {
+		if len(preview.Buckets) == 0 {
+			errs = errs.Also(apis.ErrMissingField("expiryPreview.buckets"))
+		}
+		for i, bucket := range preview.Buckets {
+			if bucket == "" {
+				errs = errs.Also(apis.ErrInvalidArrayValue(bucket, "expiryPreview.buckets", i))
+			}
+		}
+		if preview.Days != nil && *preview.Days < 1 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*preview.Days, 1, math.MaxInt32, "expiryPreview.days"))
+		}
+		if preview.Interval != nil && preview.Interval.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(preview.Interval.Duration.String(), "expiryPreview.interval"))
+		}
+	}
+
+	if audit := sspec.Audit; audit != nil {
+		if audit.Bucket == "" {
+			errs = errs.Also(apis.ErrMissingField("audit.bucket"))
+		}
+		if audit.FlushInterval != nil && audit.FlushInterval.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(audit.FlushInterval.Duration.String(), "audit.flushInterval"))
+		}
+		if audit.MaxBatch != nil && *audit.MaxBatch < 1 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*audit.MaxBatch, 1, math.MaxInt32, "audit.maxBatch"))
+		}
+	}
+
+	if from := sspec.CredentialsFrom; from != nil {
+		errs = errs.Also(from.validate().ViaField("credentialsFrom"))
+	}
+
+	if rgwTLS := sspec.RGWTLS; rgwTLS != nil {
+		errs = errs.Also(rgwTLS.validate().ViaField("rgwTLS"))
+		for _, f := range sspec.RGWFeatures() {
+			errs = errs.Also(validateTLSEndpoint(f.RGW.Endpoint).ViaField(f.Field))
+		}
+	}
+
+	if filter := sspec.Filter; filter != nil {
+		errs = errs.Also(filter.validate().ViaField("filter"))
+	}
+
+	if retention := sspec.Retention; retention != nil && retention.MaxEvents != nil && *retention.MaxEvents < 1 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(*retention.MaxEvents, 1, math.MaxInt32, "retention.maxEvents"))
+	}
+
+	if storage := sspec.Storage; storage != nil {
+		if storage.Spool != "" {
+			if !SpoolStores.Has(storage.Spool) {
+				errs = errs.Also(apis.ErrInvalidValue(storage.Spool, "storage.spool"))
+			}
+			if sspec.Retention == nil {
+				errs = errs.Also(apis.ErrGeneric("a spool store requires spec.retention", "storage.spool"))
+			}
+		}
+		if storage.Dedup != "" {
+			if !DedupStores.Has(storage.Dedup) {
+				errs = errs.Also(apis.ErrInvalidValue(storage.Dedup, "storage.dedup"))
+			}
+		}
+	}
+
+	if checkpoint := sspec.Checkpoint; checkpoint != nil && checkpoint.PrefixDepth != nil && *checkpoint.PrefixDepth < 0 {
+		errs = errs.Also(apis.ErrOutOfBoundsValue(*checkpoint.PrefixDepth, 0, math.MaxInt32, "checkpoint.prefixDepth"))
+	}
+
+	if delivery := sspec.Delivery; delivery != nil {
+		if delivery.Retry != nil && *delivery.Retry < 0 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*delivery.Retry, 0, math.MaxInt32, "delivery.retry"))
+		}
+		if delivery.BackoffDelay != nil && delivery.BackoffDelay.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(delivery.BackoffDelay.Duration.String(), "delivery.backoffDelay"))
+		}
+		if dls := delivery.DeadLetterSink; dls != nil {
+			errs = errs.Also(dls.Validate(ctx).ViaField("delivery.deadLetterSink"))
+		}
+		if sink := delivery.FailureSink; sink != nil {
+			errs = errs.Also(sink.Validate(ctx).ViaField("delivery.failureSink"))
+		}
+		if failover := delivery.Failover; failover != nil {
+			if len(failover.Sinks) == 0 {
+				errs = errs.Also(apis.ErrMissingField("delivery.failover.sinks"))
+			}
+			for i, sink := range failover.Sinks {
+				errs = errs.Also(sink.Validate(ctx).ViaFieldIndex("sinks", i).ViaField("delivery.failover"))
+			}
+			if failover.Cooldown != nil && failover.Cooldown.Duration <= 0 {
+				errs = errs.Also(apis.ErrInvalidValue(failover.Cooldown.Duration.String(), "delivery.failover.cooldown"))
+			}
+		}
+		if shedding := delivery.LoadShedding; shedding != nil {
+			errs = errs.Also(shedding.validate().ViaField("delivery.loadShedding"))
+			if sspec.Dispatcher != nil {
+				errs = errs.Also(apis.ErrGeneric("load shedding is not supported with spec.dispatcher", "delivery.loadShedding"))
+			}
+		}
+	}
+
+	if lifecycle := sspec.LifecycleEvents; lifecycle != nil && lifecycle.Sink != nil {
+		errs = errs.Also(lifecycle.Sink.Validate(ctx).ViaField("lifecycleEvents.sink"))
+	}
+
+	if size := sspec.MaxEventSize; size != nil {
+		if limit := size.Limit.Value(); limit <= 0 || limit > math.MaxInt32 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(size.Limit.String(), 1, math.MaxInt32, "maxEventSize.limit"))
+		}
+		switch size.Policy {
+		case "", EventSizeTruncate:
+		case EventSizeDeadLetter:
+			if sspec.Delivery == nil || sspec.Delivery.DeadLetterSink == nil {
+				errs = errs.Also(apis.ErrMissingField("delivery.deadLetterSink"))
+			}
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(size.Policy, "maxEventSize.policy"))
+		}
+	}
+
+	if dispatcher := sspec.Dispatcher; dispatcher != nil {
+		if dispatcher.Queue == (corev1.VolumeSource{}) {
+			errs = errs.Also(apis.ErrMissingField("dispatcher.queue"))
+		}
+		if dispatcher.MaxEvents != nil && *dispatcher.MaxEvents < 1 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*dispatcher.MaxEvents, 1, math.MaxInt32, "dispatcher.maxEvents"))
+		}
+		if dispatcher.Interval != nil && dispatcher.Interval.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(dispatcher.Interval.Duration.String(), "dispatcher.interval"))
+		}
+	}
+
+	if routes := sspec.Routes; routes != nil && routes.ConfigMap.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("routes.configMap.name"))
+	}
+
+	if file := sspec.ConfigFile; file != nil && file.ConfigMap.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("configFile.configMap.name"))
+	}
+
+	if eventTime := sspec.EventTime; eventTime != nil {
+		if eventTime.MaxFuture != nil && eventTime.MaxFuture.Duration < 0 {
+			errs = errs.Also(apis.ErrInvalidValue(eventTime.MaxFuture.Duration.String(), "eventTime.maxFuture"))
+		}
+		if eventTime.MaxAge != nil && eventTime.MaxAge.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(eventTime.MaxAge.Duration.String(), "eventTime.maxAge"))
+		}
+		switch eventTime.Policy {
+		case "", EventTimeFlag, EventTimeClamp, EventTimeReject:
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(eventTime.Policy, "eventTime.policy"))
+		}
+	}
+
+	if slo := sspec.AgeSLO; slo != nil {
+		if slo.Threshold.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(slo.Threshold.Duration.String(), "ageSLO.threshold"))
+		}
+		if slo.Period != nil && slo.Period.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(slo.Period.Duration.String(), "ageSLO.period"))
+		}
+	}
+
+	for i, expectation := range sspec.TrafficExpectations {
+		if expectation.EventName == "" {
+			errs = errs.Also(apis.ErrMissingField("eventName").ViaFieldIndex("trafficExpectations", i))
+		}
+		if expectation.MinCount != nil && *expectation.MinCount < 1 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*expectation.MinCount, 1, math.MaxInt32, "minCount").ViaFieldIndex("trafficExpectations", i))
+		}
+		if expectation.Period.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(expectation.Period.Duration.String(), "period").ViaFieldIndex("trafficExpectations", i))
+		}
+	}
+
+	for i, window := range sspec.MaintenanceWindows {
+		if _, err := cron.ParseStandard(window.Schedule); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(window.Schedule, "schedule").ViaFieldIndex("maintenanceWindows", i))
+		}
+		if window.Duration.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(window.Duration.Duration.String(), "duration").ViaFieldIndex("maintenanceWindows", i))
+		}
+		switch window.Action {
+		case "", MaintenanceSpool:
+			if sspec.Retention == nil {
+				errs = errs.Also(apis.ErrGeneric("spooling events requires spec.retention", "action").ViaFieldIndex("maintenanceWindows", i))
+			}
+		case MaintenanceDrop:
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(window.Action, "action").ViaFieldIndex("maintenanceWindows", i))
+		}
+	}
+
+	for bucket, source := range sspec.BucketSources {
+		if bucket == "" {
+			errs = errs.Also(apis.ErrInvalidKeyName(bucket, "bucketSources"))
+		}
+		if _, err := url.Parse(source); err != nil || source == "" {
+			errs = errs.Also(apis.ErrInvalidValue(source, "").ViaFieldKey("bucketSources", bucket))
+		}
+	}
+
+	if u := sspec.SourceURI; u != nil {
+		switch u.Scheme {
+		case SourceSchemeARN, SourceSchemeConcatenation:
+		case SourceSchemeCeph:
+			if u.Cluster == "" {
+				errs = errs.Also(apis.ErrMissingField("sourceURI.cluster"))
+			} else if msgs := validation.IsDNS1123Label(u.Cluster); len(msgs) > 0 {
+				errs = errs.Also(apis.ErrInvalidValue(u.Cluster, "sourceURI.cluster"))
+			}
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(u.Scheme, "sourceURI.scheme"))
+		}
+	}
+
+	extensions := make(map[string]struct{}, len(sspec.MetadataExtensions))
+	for i, key := range sspec.MetadataExtensions {
+		name := MetadataExtensionName(key)
+		_, duplicate := extensions[name]
+		if name == "" || reservedAttributes.Has(name) || duplicate {
+			errs = errs.Also(apis.ErrInvalidArrayValue(key, "metadataExtensions", i))
+		}
+		extensions[name] = struct{}{}
+	}
+
+	for i, expr := range sspec.DataProjection {
+		if _, err := projection.Parse([]string{expr}); err != nil {
+			fieldErr := apis.ErrInvalidArrayValue(expr, "dataProjection", i)
+			fieldErr.Details = err.Error()
+			errs = errs.Also(fieldErr)
+		}
+	}
+
+	if sspec.DataTemplate != "" {
+		if _, err := transform.Parse(sspec.DataTemplate); err != nil {
+			fieldErr := apis.ErrInvalidValue(sspec.DataTemplate, "dataTemplate")
+			fieldErr.Details = err.Error()
+			errs = errs.Also(fieldErr)
+		}
+	}
+
+	switch sspec.Mesh {
+	case "":
+	case MeshIstio, MeshLinkerd:
+		// The sidecar captures the notifications port, which must be known.
+		if sspec.Port == "0" {
+			errs = errs.Also(apis.ErrGeneric("a service mesh requires a fixed port", "mesh", "port"))
+		}
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(sspec.Mesh, "mesh"))
+	}
+
+	if expose := sspec.Expose; expose != nil {
+		errs = errs.Also(expose.Validate(ctx).ViaField("expose"))
+		// The admin endpoints, e.g. the re-drive of the failed events, are
+		// served on the exposed port unless moved to the admin port.
+		if sspec.Admin == nil {
+			errs = errs.Also(apis.ErrGeneric("exposing the adapter requires spec.admin", "expose", "admin"))
+		}
+	}
+
+	buckets := make(map[string]struct{}, len(sspec.RateLimits))
+	for i, limit := range sspec.RateLimits {
+		if limit.Bucket == "" {
+			errs = errs.Also(apis.ErrMissingField("bucket").ViaFieldIndex("rateLimits", i))
+		} else if _, ok := buckets[limit.Bucket]; ok {
+			errs = errs.Also(apis.ErrGeneric("duplicate bucket "+limit.Bucket, "bucket").ViaFieldIndex("rateLimits", i))
+		}
+		buckets[limit.Bucket] = struct{}{}
+		if limit.EventsPerSecond < 1 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(limit.EventsPerSecond, 1, math.MaxInt32, "eventsPerSecond").ViaFieldIndex("rateLimits", i))
+		}
+		if limit.Burst != nil && *limit.Burst < 1 {
+			errs = errs.Also(apis.ErrOutOfBoundsValue(*limit.Burst, 1, math.MaxInt32, "burst").ViaFieldIndex("rateLimits", i))
+		}
+	}
+
+	if status := sspec.SuccessStatus; status != nil {
+		switch *status {
+		case http.StatusOK, http.StatusAccepted, http.StatusNoContent:
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(*status, "successStatus"))
+		}
+	}
+
+	if dns := sspec.DNS; dns != nil {
+		if dns.CacheTTL != nil && dns.CacheTTL.Duration <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(dns.CacheTTL.Duration.String(), "dns.cacheTTL"))
+		}
+		for i, ns := range dns.Nameservers {
+			host := ns
+			if h, _, err := net.SplitHostPort(ns); err == nil {
+				host = h
+			}
+			if net.ParseIP(host) == nil {
+				errs = errs.Also(apis.ErrInvalidArrayValue(ns, "dns.nameservers", i))
+			}
+		}
+	}
+
+	if policy := sspec.TLSPolicy; policy != nil {
+		if _, err := tlspolicy.Parse(policy.MinVersion, policy.CipherSuites); err != nil {
+			fieldErr := apis.ErrGeneric("invalid TLS policy", "tlsPolicy")
+			fieldErr.Details = err.Error()
+			errs = errs.Also(fieldErr)
+		}
+	}
+
+	headers := make(map[string]struct{}, len(sspec.SinkHeaders))
+	for i, header := range sspec.SinkHeaders {
+		name := strings.ToLower(header.Name)
+		switch {
+		case !validHeaderName(name):
+			errs = errs.Also(apis.ErrInvalidValue(header.Name, "name").ViaFieldIndex("sinkHeaders", i))
+		case strings.HasPrefix(name, "ce-") || reservedHeaders.Has(name):
+			errs = errs.Also(apis.ErrGeneric("reserved header "+header.Name, "name").ViaFieldIndex("sinkHeaders", i))
+		default:
+			if _, ok := headers[name]; ok {
+				errs = errs.Also(apis.ErrGeneric("duplicate header "+header.Name, "name").ViaFieldIndex("sinkHeaders", i))
+			}
+			headers[name] = struct{}{}
+		}
+		switch {
+		case header.Value != "" && header.ValueFrom != nil:
+			errs = errs.Also(apis.ErrMultipleOneOf("value", "valueFrom").ViaFieldIndex("sinkHeaders", i))
+		case header.ValueFrom != nil:
+			if header.ValueFrom.Name == "" {
+				errs = errs.Also(apis.ErrMissingField("valueFrom.name").ViaFieldIndex("sinkHeaders", i))
+			}
+			if header.ValueFrom.Key == "" {
+				errs = errs.Also(apis.ErrMissingField("valueFrom.key").ViaFieldIndex("sinkHeaders", i))
+			}
+		case header.Value == "":
+			errs = errs.Also(apis.ErrMissingOneOf("value", "valueFrom").ViaFieldIndex("sinkHeaders", i))
+		}
+	}
+
+	errs = errs.Also(validateAuth(sspec.Auth))
+	if sspec.SigV4 != nil {
+		errs = errs.Also(validateSigV4(sspec.SigV4).ViaField("sigV4"))
+		if len(sspec.Auth) > 0 {
+			errs = errs.Also(apis.ErrMultipleOneOf("auth", "sigV4"))
+		}
+		for i, listener := range sspec.Listeners {
+			if len(listener.Auth) > 0 {
+				errs = errs.Also(apis.ErrGeneric("listener auth is not compatible with sigV4", "auth").ViaFieldIndex("listeners", i))
+			}
+		}
+	}
+
+	ports := map[string]struct{}{sspec.Port: {}}
+	names := make(map[string]struct{}, len(sspec.Listeners))
+	for i, listener := range sspec.Listeners {
+		if listener.Name == "" {
+			errs = errs.Also(apis.ErrMissingField("name").ViaFieldIndex("listeners", i))
+		} else if _, ok := names[listener.Name]; ok {
+			errs = errs.Also(apis.ErrGeneric("duplicate listener "+listener.Name, "name").ViaFieldIndex("listeners", i))
+		}
+		names[listener.Name] = struct{}{}
+		if _, err := strconv.ParseUint(listener.Port, 10, 16); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(listener.Port, "port").ViaFieldIndex("listeners", i))
+		} else if _, ok := ports[listener.Port]; ok {
+			errs = errs.Also(apis.ErrGeneric("duplicate port "+listener.Port, "port").ViaFieldIndex("listeners", i))
+		}
+		ports[listener.Port] = struct{}{}
+		if listener.BindAddress != "" && net.ParseIP(listener.BindAddress) == nil {
+			errs = errs.Also(apis.ErrInvalidValue(listener.BindAddress, "bindAddress").ViaFieldIndex("listeners", i))
+		}
+		if listener.TLS != nil && listener.TLS.SecretName == "" {
+			errs = errs.Also(apis.ErrMissingField("tls.secretName").ViaFieldIndex("listeners", i))
+		}
+		errs = errs.Also(validateAuth(listener.Auth).ViaFieldIndex("listeners", i))
+		if listener.Filter != nil {
+			errs = errs.Also(listener.Filter.validate().ViaField("filter").ViaFieldIndex("listeners", i))
+		}
+	}
+
+	if admin := sspec.Admin; admin != nil {
+		if _, err := strconv.ParseUint(admin.Port, 10, 16); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(admin.Port, "admin.port"))
+		} else if _, ok := ports[admin.Port]; ok || reservedPorts.Has(admin.Port) {
+			errs = errs.Also(apis.ErrGeneric("duplicate port "+admin.Port, "admin.port"))
+		}
+		if admin.Token != nil && admin.Token.Name == "" {
+			errs = errs.Also(apis.ErrMissingField("admin.token.name"))
+		}
+		if admin.Token != nil && admin.Token.Key == "" {
+			errs = errs.Also(apis.ErrMissingField("admin.token.key"))
+		}
+	}
+
+	return errs
+}
+
+// validate validates a CephSourceFilter.
+func (s *CephSourceLoadShedding) validate() *apis.FieldError {
+	var errs *apis.FieldError
+	if s.Threshold.Duration <= 0 {
+		errs = errs.Also(apis.ErrInvalidValue(s.Threshold.Duration.String(), "threshold"))
+	}
+	if s.Period != nil && s.Period.Duration <= 0 {
+		errs = errs.Also(apis.ErrInvalidValue(s.Period.Duration.String(), "period"))
+	}
+	if len(s.Priorities) == 0 {
+		errs = errs.Also(apis.ErrMissingField("priorities"))
+	}
+	names := make(map[string]struct{}, len(s.Priorities))
+	for i, class := range s.Priorities {
+		if class.Name == "" {
+			errs = errs.Also(apis.ErrMissingField("name").ViaFieldIndex("priorities", i))
+		} else if msgs := validation.IsDNS1123Label(class.Name); len(msgs) > 0 {
+			errs = errs.Also(apis.ErrInvalidValue(class.Name, "name").ViaFieldIndex("priorities", i))
+		} else if _, ok := names[class.Name]; ok {
+			errs = errs.Also(apis.ErrGeneric("duplicate class "+class.Name, "name").ViaFieldIndex("priorities", i))
+		}
+		names[class.Name] = struct{}{}
+		switch class.Priority {
+		case "", PriorityLow, PriorityHigh:
+		default:
+			errs = errs.Also(apis.ErrInvalidValue(class.Priority, "priority").ViaFieldIndex("priorities", i))
+		}
+		for j, name := range class.EventNames {
+			if name == "" {
+				errs = errs.Also(apis.ErrInvalidArrayValue(name, "eventNames", j).ViaFieldIndex("priorities", i))
+			}
+		}
+		for j, bucket := range class.Buckets {
+			if bucket == "" {
+				errs = errs.Also(apis.ErrInvalidArrayValue(bucket, "buckets", j).ViaFieldIndex("priorities", i))
+			}
+		}
+	}
+	return errs
+}
+
+func (f *CephSourceFilter) validate() *apis.FieldError {
+	var errs *apis.FieldError
+	switch f.DeleteMarkers {
+	case "", DeleteMarkersInclude, DeleteMarkersExclude, DeleteMarkersOnly:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(f.DeleteMarkers, "deleteMarkers"))
+	}
+	switch f.Encryption {
+	case "", EncryptionEncrypted, EncryptionUnencrypted:
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(f.Encryption, "encryption"))
+	}
+	for i, name := range f.EventNames {
+		if name == "" {
+			errs = errs.Also(apis.ErrInvalidArrayValue(name, "eventNames", i))
+		}
+	}
+	for i, class := range f.StorageClasses {
+		if class == "" {
+			errs = errs.Also(apis.ErrInvalidArrayValue(class, "storageClasses", i))
+		}
+	}
+	return errs
+}
+
+// validateAuth validates the tokens expected by a listener.
+func validateAuth(auths []CephSourceAuth) *apis.FieldError {
+	var errs *apis.FieldError
+	paths := make(map[string]struct{}, len(auths))
+	for i, auth := range auths {
+		switch {
+		case auth.Path == "" && auth.Host == "":
+			errs = errs.Also(apis.ErrMissingOneOf("path", "host").ViaFieldIndex("auth", i))
+		case auth.Path != "" && !strings.HasPrefix(auth.Path, "/"):
+			errs = errs.Also(apis.ErrInvalidValue(auth.Path, "path").ViaFieldIndex("auth", i))
+		case strings.Contains(auth.Path, ","):
+			// The paths are passed to the adapter as a comma separated list.
+			errs = errs.Also(apis.ErrGeneric("path must not contain a comma", "path").ViaFieldIndex("auth", i))
+		case auth.Host != "" && len(validation.IsDNS1123Subdomain(auth.Host)) > 0:
+			errs = errs.Also(apis.ErrInvalidValue(auth.Host, "host").ViaFieldIndex("auth", i))
+		default:
+			// Keyed like the tokens of the adapter.
+			if _, ok := paths[auth.Host+auth.Path]; ok {
+				msg, field := "duplicate path "+auth.Path, "path"
+				if auth.Host != "" {
+					msg, field = "duplicate host "+auth.Host, "host"
+					if auth.Path != "" {
+						msg += " and path " + auth.Path
+					}
+				}
+				errs = errs.Also(apis.ErrGeneric(msg, field).ViaFieldIndex("auth", i))
+			}
+			paths[auth.Host+auth.Path] = struct{}{}
+		}
+		if auth.Token.Name == "" {
+			errs = errs.Also(apis.ErrMissingField("token.name").ViaFieldIndex("auth", i))
+		}
+		if auth.Token.Key == "" {
+			errs = errs.Also(apis.ErrMissingField("token.key").ViaFieldIndex("auth", i))
+		}
+	}
+
+	return errs
+}
+
+func validateSigV4(sigV4 *CephSourceSigV4) *apis.FieldError {
+	var errs *apis.FieldError
+	if len(sigV4.Keys) == 0 {
+		errs = errs.Also(apis.ErrMissingField("keys"))
+	}
+	ids := make(map[string]struct{}, len(sigV4.Keys))
+	for i, key := range sigV4.Keys {
+		switch {
+		case key.AccessKeyID == "":
+			errs = errs.Also(apis.ErrMissingField("accessKeyId").ViaFieldIndex("keys", i))
+		case strings.ContainsAny(key.AccessKeyID, ",/ "):
+			errs = errs.Also(apis.ErrInvalidValue(key.AccessKeyID, "accessKeyId").ViaFieldIndex("keys", i))
+		default:
+			if _, ok := ids[key.AccessKeyID]; ok {
+				errs = errs.Also(apis.ErrGeneric("duplicate access key ID "+key.AccessKeyID, "accessKeyId").ViaFieldIndex("keys", i))
+			}
+			ids[key.AccessKeyID] = struct{}{}
+		}
+		if key.SecretAccessKey.Name == "" {
+			errs = errs.Also(apis.ErrMissingField("secretAccessKey.name").ViaFieldIndex("keys", i))
+		}
+		if key.SecretAccessKey.Key == "" {
+			errs = errs.Also(apis.ErrMissingField("secretAccessKey.key").ViaFieldIndex("keys", i))
+		}
+	}
+	if sigV4.MaxSkew != nil && sigV4.MaxSkew.Duration <= 0 {
+		errs = errs.Also(apis.ErrInvalidValue(sigV4.MaxSkew.Duration.String(), "maxSkew"))
+	}
+	return errs
+}
+
+// Validate validates CephSourceExpose.
+func (e *CephSourceExpose) Validate(context.Context) *apis.FieldError {
+	var errs *apis.FieldError
+
+	switch e.Type {
+	case "", ExposeIngress:
+		if e.Host == "" {
+			errs = errs.Also(apis.ErrMissingField("host"))
+		}
+		if e.TLS != nil && e.TLS.SecretName == "" {
+			errs = errs.Also(apis.ErrMissingField("tls.secretName"))
+		}
+	case ExposeRoute:
+	case ExposeLoadBalancer:
+		if e.Host != "" {
+			errs = errs.Also(apis.ErrDisallowedFields("host"))
+		}
+	default:
+		errs = errs.Also(apis.ErrInvalidValue(e.Type, "type"))
+	}
+
+	if e.Host != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(e.Host) {
+			errs = errs.Also(apis.ErrInvalidValue(e.Host, "host", msg))
+		}
+	}
+	if e.IngressClassName != nil && e.Type != "" && e.Type != ExposeIngress {
+		errs = errs.Also(apis.ErrDisallowedFields("ingressClassName"))
+	}
+	return errs
+}
+
+// validate validates the RGW endpoint and the references to the Secret keys
+// holding the credentials, unless they are sourced from an external secret
+// manager.
+func (c *CephSourceRGWCredentials) validate(from *CephSourceCredentialsFrom) *apis.FieldError {
+	var errs *apis.FieldError
+	if c.Endpoint == nil {
+		errs = errs.Also(apis.ErrMissingField("endpoint"))
+	} else if !c.Endpoint.URL().IsAbs() {
+		errs = errs.Also(apis.ErrInvalidValue(c.Endpoint.String(), "endpoint"))
+	}
+	if from != nil {
+		if c.NextCredentials != nil {
+			errs = errs.Also(apis.ErrDisallowedFields("nextCredentials"))
+		}
+		return errs
+	}
+	errs = errs.Also(validateSecretKeySelector(c.AccessKeyID).ViaField("accessKeyId")).
+		Also(validateSecretKeySelector(c.SecretAccessKey).ViaField("secretAccessKey"))
+	if next := c.NextCredentials; next != nil {
+		errs = errs.Also(validateSecretKeySelector(next.AccessKeyID).ViaField("nextCredentials.accessKeyId")).
+			Also(validateSecretKeySelector(next.SecretAccessKey).ViaField("nextCredentials.secretAccessKey"))
+	}
+	return errs
+}
+
+func (c *CephSourceCredentialsFrom) validate() *apis.FieldError {
+	var errs *apis.FieldError
+	switch {
+	case c.CSI == nil && len(c.Annotations) == 0:
+		errs = errs.Also(apis.ErrMissingOneOf("csi", "annotations"))
+	case c.CSI != nil && len(c.Annotations) > 0:
+		errs = errs.Also(apis.ErrMultipleOneOf("csi", "annotations"))
+	case c.CSI != nil:
+		if c.CSI.Driver == "" {
+			errs = errs.Also(apis.ErrMissingField("csi.driver"))
+		}
+		if c.Dir != "" {
+			errs = errs.Also(apis.ErrDisallowedFields("dir"))
+		}
+	default:
+		if c.Dir == "" {
+			errs = errs.Also(apis.ErrMissingField("dir"))
+		} else if !path.IsAbs(c.Dir) {
+			errs = errs.Also(apis.ErrInvalidValue(c.Dir, "dir"))
+		}
+	}
+	return errs
+}
+
+func (t *CephSourceRGWTLS) validate() *apis.FieldError {
+	var errs *apis.FieldError
+	if t.CABundle == nil && len(t.PinnedKeys) == 0 {
+		errs = errs.Also(apis.ErrMissingOneOf("caBundle", "pinnedKeys"))
+	}
+	if ca := t.CABundle; ca != nil {
+		if ca.Name == "" {
+			errs = errs.Also(apis.ErrMissingField("caBundle.name"))
+		}
+		if ca.Key == "" {
+			errs = errs.Also(apis.ErrMissingField("caBundle.key"))
+		}
+	}
+	for i, pin := range t.PinnedKeys {
+		if hash, err := base64.StdEncoding.DecodeString(pin); err != nil || len(hash) != sha256.Size {
+			errs = errs.Also(apis.ErrInvalidArrayValue(pin, "pinnedKeys", i))
+		}
+	}
+	return errs
+}
+
+// validateTLSEndpoint checks that an RGW endpoint, if set, is reached over
+// TLS for spec.rgwTLS to apply.
+func validateTLSEndpoint(endpoint *apis.URL) *apis.FieldError {
+	if endpoint == nil || endpoint.Scheme == "https" {
+		return nil
+	}
+	return apis.ErrGeneric("spec.rgwTLS requires an https endpoint", "endpoint")
+}
+
+func validateSecretKeySelector(ref corev1.SecretKeySelector) *apis.FieldError {
+	var errs *apis.FieldError
+	if ref.Name == "" {
+		errs = errs.Also(apis.ErrMissingField("name"))
+	}
+	if ref.Key == "" {
+		errs = errs.Also(apis.ErrMissingField("key"))
+	}
 	return errs
 }
@@ -19,9 +19,16 @@ package v1alpha1
 import (
 	"context"
 	"testing"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/pkg/apis"
 	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/ptr"
+
+	"knative.dev/eventing-ceph/pkg/apis/config"
 )
 
 func ParseURL(u string, t *testing.T) (url *apis.URL) {
@@ -33,6 +40,13 @@ func ParseURL(u string, t *testing.T) (url *apis.URL) {
 	return
 }
 
+func tokenSelector(name string) corev1.SecretKeySelector {
+	return corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: name},
+		Key:                  "token",
+	}
+}
+
 func TestCephSourceValidate(t *testing.T) {
 	testCases := map[string]struct {
 		source CephSource
@@ -47,61 +61,2368 @@ func TestCephSourceValidate(t *testing.T) {
 			},
 			},
 		},
-	}
-	for n, tc := range testCases {
-		t.Run(n, func(t *testing.T) {
-			if err := tc.source.Validate(context.TODO()); err != nil {
-				t.Fatalf("Source validation should succeed")
-			}
-		})
-	}
-}
-
-func TestCephSourceValidateFail(t *testing.T) {
-	testCases := map[string]struct {
-		source CephSource
-	}{
-		"missing port": {
+		"validate aws-s3 profile": {
 			source: CephSource{Spec: CephSourceSpec{
 				ServiceAccountName: "default",
+				Port:               "9999",
+				Profile:            ProfileAWSS3,
 				SourceSpec: duckv1.SourceSpec{
 					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
 				},
 			},
 			},
 		},
-		"invalid port number": {
+		"validate cdevents profile": {
 			source: CephSource{Spec: CephSourceSpec{
 				ServiceAccountName: "default",
-				Port:               "12345678",
+				Port:               "9999",
+				Profile:            ProfileCDEvents,
 				SourceSpec: duckv1.SourceSpec{
 					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
 				},
 			},
 			},
 		},
-		"missing sink": {
+		"validate dispatcher": {
 			source: CephSource{Spec: CephSourceSpec{
 				ServiceAccountName: "default",
 				Port:               "9999",
-				SourceSpec:         duckv1.SourceSpec{},
+				Dispatcher: &CephSourceDispatcher{
+					Queue: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "ceph-queue"},
+					},
+					MaxEvents: ptr.Int32(100),
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
 			},
 			},
 		},
-		"missing service": {
+		"validate delivery": {
 			source: CephSource{Spec: CephSourceSpec{
-				Port: "9999",
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					Retry:          ptr.Int32(3),
+					BackoffDelay:   &metav1.Duration{Duration: time.Second},
+					DeadLetterSink: &duckv1.Destination{URI: ParseURL("http://dead.letter", t)},
+					FailureSink:    &duckv1.Destination{URI: ParseURL("http://alerts", t)},
+				},
 				SourceSpec: duckv1.SourceSpec{
 					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
 				},
 			},
 			},
 		},
-	}
-	for n, tc := range testCases {
-		t.Run(n, func(t *testing.T) {
-			if err := tc.source.Validate(context.TODO()); err == nil {
-				t.Fatalf("Source validation should fail")
+		"validate routes": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Routes: &CephSourceRoutes{
+					ConfigMap: corev1.LocalObjectReference{Name: "ceph-routes"},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate max event size": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MaxEventSize: &CephSourceMaxEventSize{
+					Limit:  resource.MustParse("1Mi"),
+					Policy: EventSizeDeadLetter,
+				},
+				Delivery: &CephSourceDelivery{
+					DeadLetterSink: &duckv1.Destination{URI: ParseURL("http://dead.letter", t)},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate metadata extensions": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MetadataExtensions: []string{"x-amz-meta-team", "cost-unit"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate structured 0.3 events": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SpecVersion:        SpecVersionV03,
+				ContentMode:        ContentModeStructured,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate sink headers": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SinkHeaders: []CephSourceHeader{{
+					Name:  "X-Tenant",
+					Value: "tenant-a",
+				}, {
+					Name: "X-Api-Key",
+					ValueFrom: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "sink-api-key"},
+						Key:                  "key",
+					},
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate checkpoint": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Checkpoint:         &CephSourceCheckpoint{PrefixDepth: ptr.Int32(0), SkipRedelivered: true},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate dns": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				DNS:                &CephSourceDNS{CacheTTL: &metav1.Duration{Duration: 30 * time.Second}, Nameservers: []string{"10.96.0.10", "[fd00::10]:5353"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate success status": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SuccessStatus:      ptr.Int32(204),
+				SkipDuplicates:     true,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate listeners": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Listeners: []CephSourceListener{{
+					Name: "production",
+					Port: "9443",
+					TLS:  &CephSourceListenerTLS{SecretName: "adapter-tls", ClientAuth: true},
+					Auth: []CephSourceAuth{{
+						Path:  "/cluster-a",
+						Token: tokenSelector("cluster-a"),
+					}},
+				}, {
+					Name:        "debug",
+					Port:        "9998",
+					BindAddress: "127.0.0.1",
+					Filter:      &CephSourceFilter{EventNames: []string{"s3:ObjectCreated:*"}},
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate encryption filter": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Filter:             &CephSourceFilter{Encryption: EncryptionUnencrypted},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate storage class filter": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Filter:             &CephSourceFilter{StorageClasses: []string{"GLACIER"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate data projection": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				DataProjection:     []string{"$.s3.object.key", "s3.bucket['name']"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate data template": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				DataTemplate:       `{"key": {{json .s3.object.key}}}`,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate admin port": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Admin:              &CephSourceAdmin{Port: "9998", Token: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "admin"}, Key: "token"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate subject": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Subject:            &CephSourceSubject{Escape: true, MaxLength: ptr.Int32(256), Overflow: SubjectOverflowHash},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate empty notifications": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				EmptyNotifications: EmptyNotificationsEvent,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate age SLO": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				AgeSLO:             &CephSourceAgeSLO{Threshold: metav1.Duration{Duration: time.Minute}, Period: &metav1.Duration{Duration: 10 * time.Minute}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate TLS policy": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				TLSPolicy:          &CephSourceTLSPolicy{MinVersion: "1.2", CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate lifecycle events": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				LifecycleEvents:    &CephSourceLifecycleEvents{Sink: &duckv1.Destination{URI: ParseURL("http://monitor", t)}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate traffic expectations": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName:  "default",
+				Port:                "9999",
+				TrafficExpectations: []CephSourceTrafficExpectation{{EventName: "s3:ObjectCreated:*", MinCount: ptr.Int32(10), Period: metav1.Duration{Duration: time.Hour}}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate sigV4": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SigV4: &CephSourceSigV4{
+					Keys: []CephSourceSigV4Key{{
+						AccessKeyID:     "rgw-a",
+						SecretAccessKey: tokenSelector("rgw-a"),
+					}},
+					MaxSkew: &metav1.Duration{Duration: time.Minute},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate ceph source URI": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SourceURI:          &CephSourceSourceURI{Scheme: SourceSchemeCeph, Cluster: "ceph-eu-1"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate failover": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					Failover: &CephSourceFailover{
+						Sinks:    []duckv1.Destination{{URI: ParseURL("http://backup-1", t)}, {URI: ParseURL("http://backup-2", t)}},
+						Cooldown: &metav1.Duration{Duration: time.Minute},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate audit": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "audit"}, Key: "access-key-id"},
+						SecretAccessKey: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "audit"}, Key: "secret-access-key"},
+					},
+					Bucket:        "audit",
+					Prefix:        "events/",
+					FlushInterval: &metav1.Duration{Duration: time.Minute},
+					MaxBatch:      ptr.Int32(100),
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"valid storage": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Retention:          &CephSourceRetention{},
+				Storage:            &CephSourceStorage{Spool: "memory", Dedup: "memory"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate load shedding": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					LoadShedding: &CephSourceLoadShedding{
+						Threshold: metav1.Duration{Duration: 2 * time.Second},
+						Period:    &metav1.Duration{Duration: time.Minute},
+						Priorities: []CephSourcePriorityClass{{
+							Name:     "billing",
+							Priority: PriorityHigh,
+							Buckets:  []string{"invoices"},
+						}, {
+							Name:       "uploads",
+							EventNames: []string{"s3:ObjectCreated:*"},
+						}},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate csi credentials": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint: ParseURL("http://rgw.rook-ceph.svc", t),
+					},
+					Bucket: "audit",
+				},
+				CredentialsFrom: &CephSourceCredentialsFrom{
+					CSI: &corev1.CSIVolumeSource{Driver: "secrets-store.csi.k8s.io"},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate annotation credentials": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint: ParseURL("http://rgw.rook-ceph.svc", t),
+					},
+					Bucket: "audit",
+				},
+				CredentialsFrom: &CephSourceCredentialsFrom{
+					Annotations: map[string]string{"vault.hashicorp.com/agent-inject": "true"},
+					Dir:         "/vault/secrets",
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate next credentials": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Usage: &CephSourceUsage{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+						NextCredentials: &CephSourceS3Credentials{
+							AccessKeyID:     tokenSelector("rgw-user-next"),
+							SecretAccessKey: tokenSelector("rgw-user-next"),
+						},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate rgw tls": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Usage: &CephSourceUsage{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("https://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+					},
+				},
+				RGWTLS: &CephSourceRGWTLS{
+					CABundle: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "rgw-ca"},
+						Key:                  "ca.crt",
+					},
+					PinnedKeys: []string{"d6qzRu9zOECb90Uez27xWltNsj0e1Md7GkYYkVoZWmM="},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate config file": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				ConfigFile: &CephSourceConfigFile{
+					ConfigMap: corev1.LocalObjectReference{Name: "ceph-source-config"},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate minio format": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Format:             FormatMinIO,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate generic format": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Format:             FormatGeneric,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate swift format": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Format:             FormatSwift,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate ingress expose": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Expose:             &CephSourceExpose{Host: "ceph-source.example.com", TLS: &CephSourceExposeTLS{SecretName: "ceph-source-tls"}},
+				Admin:              &CephSourceAdmin{Port: "9998"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate load balancer expose": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Expose:             &CephSourceExpose{Type: ExposeLoadBalancer, TLS: &CephSourceExposeTLS{}},
+				Admin:              &CephSourceAdmin{Port: "9998"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate istio mesh": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Mesh:               MeshIstio,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate auth": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Auth: []CephSourceAuth{{
+					Path:  "/cluster-a",
+					Token: tokenSelector("cluster-a"),
+				}, {
+					Path:  "/cluster-b",
+					Token: tokenSelector("cluster-b"),
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate auth hosts": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Auth: []CephSourceAuth{{
+					Host:  "cluster-a.example.com",
+					Token: tokenSelector("cluster-a"),
+				}, {
+					Host:  "cluster-a.example.com",
+					Path:  "/admin",
+					Token: tokenSelector("cluster-a-admin"),
+				}, {
+					Path:  "/cluster-b",
+					Token: tokenSelector("cluster-b"),
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate verify": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Verify: &CephSourceVerify{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate usage": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Usage: &CephSourceUsage{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-admin"),
+						SecretAccessKey: tokenSelector("rgw-admin"),
+					},
+					Users:    []string{"tenant-a"},
+					Interval: &metav1.Duration{Duration: time.Hour},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate expiry preview": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				ExpiryPreview: &CephSourceExpiryPreview{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+					},
+					Buckets: []string{"fishbucket"},
+					Days:    ptr.Int32(14),
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate retention": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Retention:          &CephSourceRetention{MaxEvents: ptr.Int32(100)},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate rate limits": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				RateLimits: []CephSourceRateLimit{
+					{Bucket: "fishbucket", EventsPerSecond: 10, Burst: ptr.Int32(100)},
+					{Bucket: "*", EventsPerSecond: 100},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate event time": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				EventTime: &CephSourceEventTime{
+					MaxFuture: &metav1.Duration{Duration: 5 * time.Minute},
+					MaxAge:    &metav1.Duration{Duration: 24 * time.Hour},
+					Policy:    EventTimeClamp,
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate maintenance windows": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Retention:          &CephSourceRetention{},
+				MaintenanceWindows: []CephSourceMaintenanceWindow{{
+					Schedule: "CRON_TZ=Europe/Paris 0 2 * * 6",
+					Duration: metav1.Duration{Duration: 2 * time.Hour},
+				}, {
+					Schedule: "30 1 1 * *",
+					Duration: metav1.Duration{Duration: time.Hour},
+					Action:   MaintenanceDrop,
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"validate bucket sources": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				BucketSources: map[string]string{
+					"fishbucket": "https://storage.example.com/fishbucket",
+					"catbucket":  "urn:storage:catbucket",
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if err := tc.source.Validate(context.TODO()); err != nil {
+				t.Fatalf("Source validation should succeed")
+			}
+		})
+	}
+}
+
+func TestCephSourceValidateFail(t *testing.T) {
+	testCases := map[string]struct {
+		source CephSource
+	}{
+		"missing port": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid port number": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "12345678",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"missing sink": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SourceSpec:         duckv1.SourceSpec{},
+			},
+			},
+		},
+		"missing service": {
+			source: CephSource{Spec: CephSourceSpec{
+				Port: "9999",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unknown payload": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Payload:            "full",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"payload with eventbridge profile": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Profile:            ProfileEventBridge,
+				Payload:            PayloadRecordEnvelope,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"payload with cdevents profile": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Profile:            ProfileCDEvents,
+				Payload:            PayloadRecordEnvelope,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"dispatcher without queue": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Dispatcher:         &CephSourceDispatcher{},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"dispatcher max events out of bounds": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Dispatcher: &CephSourceDispatcher{
+					Queue:     corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					MaxEvents: ptr.Int32(0),
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"delivery retry out of bounds": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery:           &CephSourceDelivery{Retry: ptr.Int32(-1)},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"delivery without backoff delay": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery:           &CephSourceDelivery{BackoffDelay: &metav1.Duration{}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"empty failure sink": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery:           &CephSourceDelivery{FailureSink: &duckv1.Destination{}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"empty dead letter sink": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery:           &CephSourceDelivery{DeadLetterSink: &duckv1.Destination{}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"routes without ConfigMap": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Routes:             &CephSourceRoutes{Key: "routes.yaml"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"max event size without limit": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MaxEventSize:       &CephSourceMaxEventSize{},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid max event size policy": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MaxEventSize: &CephSourceMaxEventSize{
+					Limit:  resource.MustParse("1Mi"),
+					Policy: "drop",
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"dead letter max event size without dead letter sink": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MaxEventSize: &CephSourceMaxEventSize{
+					Limit:  resource.MustParse("1Mi"),
+					Policy: EventSizeDeadLetter,
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unnamed metadata extension": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MetadataExtensions: []string{"x-amz-meta-"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"reserved metadata extension": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MetadataExtensions: []string{"x-amz-meta-subject"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"metadata extension clashing with the storage class": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MetadataExtensions: []string{"x-amz-meta-storage-class"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"metadata extension clashing with the object lock": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MetadataExtensions: []string{"x-amz-meta-legal-hold"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"duplicate metadata extension": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MetadataExtensions: []string{"x-amz-meta-cost-unit", "costunit"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid spec version": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SpecVersion:        "2.0",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid content mode": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				ContentMode:        "batched",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid sink header name": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SinkHeaders:        []CephSourceHeader{{Name: "X Tenant", Value: "tenant-a"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"reserved sink header": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SinkHeaders:        []CephSourceHeader{{Name: "Ce-Source", Value: "ceph"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"duplicate sink header": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SinkHeaders:        []CephSourceHeader{{Name: "X-Tenant", Value: "a"}, {Name: "x-tenant", Value: "b"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"sink header without value": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SinkHeaders:        []CephSourceHeader{{Name: "X-Tenant"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"sink header with value and secret": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SinkHeaders: []CephSourceHeader{{
+					Name:      "X-Api-Key",
+					Value:     "s3cr3t",
+					ValueFrom: &corev1.SecretKeySelector{Key: "key"},
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"negative checkpoint prefix depth": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Checkpoint:         &CephSourceCheckpoint{PrefixDepth: ptr.Int32(-1)},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid dns cache ttl": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				DNS:                &CephSourceDNS{CacheTTL: &metav1.Duration{}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid nameserver": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				DNS:                &CephSourceDNS{Nameservers: []string{"kube-dns.kube-system"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid success status": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SuccessStatus:      ptr.Int32(201),
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"listener on the port of the source": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Listeners:          []CephSourceListener{{Name: "debug", Port: "9999"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"duplicate listener": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Listeners:          []CephSourceListener{{Name: "debug", Port: "9998"}, {Name: "debug", Port: "9997"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"listener without tls secret": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Listeners: []CephSourceListener{{
+					Name: "production",
+					Port: "9443",
+					TLS:  &CephSourceListenerTLS{},
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"listener auth without token": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Listeners: []CephSourceListener{{
+					Name: "production",
+					Port: "9443",
+					Auth: []CephSourceAuth{{Path: "/cluster-a"}},
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid encryption filter": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Filter:             &CephSourceFilter{Encryption: "kms"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"empty storage class filter": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Filter:             &CephSourceFilter{StorageClasses: []string{""}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid data projection": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				DataProjection:     []string{"s3.*"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid data template": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				DataTemplate:       `{"key": {{json .s3.object.key}`,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"admin on the notifications port": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Admin:              &CephSourceAdmin{Port: "9999"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"admin on the metrics port": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Admin:              &CephSourceAdmin{Port: "9090"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"admin token without key": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Admin:              &CephSourceAdmin{Port: "9998", Token: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "admin"}}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid subject max length": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Subject:            &CephSourceSubject{MaxLength: ptr.Int32(0)},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid subject overflow": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Subject:            &CephSourceSubject{Overflow: "drop"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid empty notifications": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				EmptyNotifications: "fail",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"missing age SLO threshold": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				AgeSLO:             &CephSourceAgeSLO{},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid age SLO period": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				AgeSLO:             &CephSourceAgeSLO{Threshold: metav1.Duration{Duration: time.Minute}, Period: &metav1.Duration{}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid TLS minimum version": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				TLSPolicy:          &CephSourceTLSPolicy{MinVersion: "1.0"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"insecure cipher suite": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				TLSPolicy:          &CephSourceTLSPolicy{CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid lifecycle sink": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				LifecycleEvents:    &CephSourceLifecycleEvents{Sink: &duckv1.Destination{}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid traffic expectation": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName:  "default",
+				Port:                "9999",
+				TrafficExpectations: []CephSourceTrafficExpectation{{MinCount: ptr.Int32(0)}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"sigV4 without keys": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SigV4:              &CephSourceSigV4{},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"duplicate sigV4 access key": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SigV4: &CephSourceSigV4{
+					Keys: []CephSourceSigV4Key{{
+						AccessKeyID:     "rgw-a",
+						SecretAccessKey: tokenSelector("rgw-a"),
+					}, {
+						AccessKeyID:     "rgw-a",
+						SecretAccessKey: tokenSelector("rgw-b"),
+					}},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"sigV4 key without secret": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SigV4: &CephSourceSigV4{
+					Keys: []CephSourceSigV4Key{{AccessKeyID: "rgw-a"}},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"sigV4 with auth": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SigV4: &CephSourceSigV4{
+					Keys: []CephSourceSigV4Key{{
+						AccessKeyID:     "rgw-a",
+						SecretAccessKey: tokenSelector("rgw-a"),
+					}},
+				},
+				Auth: []CephSourceAuth{{
+					Path:  "/",
+					Token: tokenSelector("cluster-a"),
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"sigV4 with non-positive max skew": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SigV4: &CephSourceSigV4{
+					Keys: []CephSourceSigV4Key{{
+						AccessKeyID:     "rgw-a",
+						SecretAccessKey: tokenSelector("rgw-a"),
+					}},
+					MaxSkew: &metav1.Duration{},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid source URI scheme": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SourceURI:          &CephSourceSourceURI{Scheme: "urn"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"ceph source URI without cluster": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				SourceURI:          &CephSourceSourceURI{Scheme: SourceSchemeCeph},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"failover without sinks": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					Failover: &CephSourceFailover{},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid failover sink": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					Failover: &CephSourceFailover{
+						Sinks: []duckv1.Destination{{}},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid failover cooldown": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					Failover: &CephSourceFailover{
+						Sinks:    []duckv1.Destination{{URI: ParseURL("http://backup-1", t)}},
+						Cooldown: &metav1.Duration{},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"audit without bucket": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "audit"}, Key: "access-key-id"},
+						SecretAccessKey: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "audit"}, Key: "secret-access-key"},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid audit max batch": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "audit"}, Key: "access-key-id"},
+						SecretAccessKey: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "audit"}, Key: "secret-access-key"},
+					},
+					Bucket:   "audit",
+					MaxBatch: ptr.Int32(0),
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"spool store without retention": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Storage:            &CephSourceStorage{Spool: "memory"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid dedup store": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Storage:            &CephSourceStorage{Dedup: "Redis_Cluster"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unregistered dedup store": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Storage:            &CephSourceStorage{Dedup: "redis"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unregistered spool store": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Retention:          &CephSourceRetention{},
+				Storage:            &CephSourceStorage{Spool: "sqlite"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"load shedding without priorities": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					LoadShedding: &CephSourceLoadShedding{
+						Threshold: metav1.Duration{Duration: 2 * time.Second},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid load shedding threshold": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					LoadShedding: &CephSourceLoadShedding{
+						Priorities: []CephSourcePriorityClass{{Name: "uploads"}},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"duplicate priority class": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					LoadShedding: &CephSourceLoadShedding{
+						Threshold:  metav1.Duration{Duration: 2 * time.Second},
+						Priorities: []CephSourcePriorityClass{{Name: "uploads"}, {Name: "uploads", Priority: PriorityHigh}},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid priority": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					LoadShedding: &CephSourceLoadShedding{
+						Threshold:  metav1.Duration{Duration: 2 * time.Second},
+						Priorities: []CephSourcePriorityClass{{Name: "uploads", Priority: "urgent"}},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"load shedding with dispatcher": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Delivery: &CephSourceDelivery{
+					LoadShedding: &CephSourceLoadShedding{
+						Threshold:  metav1.Duration{Duration: 2 * time.Second},
+						Priorities: []CephSourcePriorityClass{{Name: "uploads"}},
+					},
+				},
+				Dispatcher: &CephSourceDispatcher{
+					Queue: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"credentials from nothing": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint: ParseURL("http://rgw.rook-ceph.svc", t),
+					},
+					Bucket: "audit",
+				},
+				CredentialsFrom: &CephSourceCredentialsFrom{},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"credentials from csi and annotations": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint: ParseURL("http://rgw.rook-ceph.svc", t),
+					},
+					Bucket: "audit",
+				},
+				CredentialsFrom: &CephSourceCredentialsFrom{
+					CSI:         &corev1.CSIVolumeSource{Driver: "secrets-store.csi.k8s.io"},
+					Annotations: map[string]string{"vault.hashicorp.com/agent-inject": "true"},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"annotation credentials without dir": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint: ParseURL("http://rgw.rook-ceph.svc", t),
+					},
+					Bucket: "audit",
+				},
+				CredentialsFrom: &CephSourceCredentialsFrom{
+					Annotations: map[string]string{"vault.hashicorp.com/agent-inject": "true"},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"relative credentials dir": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint: ParseURL("http://rgw.rook-ceph.svc", t),
+					},
+					Bucket: "audit",
+				},
+				CredentialsFrom: &CephSourceCredentialsFrom{
+					Annotations: map[string]string{"vault.hashicorp.com/agent-inject": "true"},
+					Dir:         "vault/secrets",
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"audit without credentials": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint: ParseURL("http://rgw.rook-ceph.svc", t),
+					},
+					Bucket: "audit",
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"next credentials without key": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Usage: &CephSourceUsage{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+						NextCredentials: &CephSourceS3Credentials{
+							AccessKeyID: tokenSelector("rgw-user-next"),
+						},
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"next credentials with credentials from": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Audit: &CephSourceAudit{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint: ParseURL("http://rgw.rook-ceph.svc", t),
+						NextCredentials: &CephSourceS3Credentials{
+							AccessKeyID:     tokenSelector("rgw-user-next"),
+							SecretAccessKey: tokenSelector("rgw-user-next"),
+						},
+					},
+					Bucket: "audit",
+				},
+				CredentialsFrom: &CephSourceCredentialsFrom{
+					CSI: &corev1.CSIVolumeSource{Driver: "secrets-store.csi.k8s.io"},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"rgw tls without ca bundle nor pinned keys": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Usage: &CephSourceUsage{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("https://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+					},
+				},
+				RGWTLS: &CephSourceRGWTLS{},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"rgw tls with invalid pinned key": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Usage: &CephSourceUsage{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("https://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+					},
+				},
+				RGWTLS: &CephSourceRGWTLS{
+					PinnedKeys: []string{"c2hvcnQ="},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"rgw tls with http endpoint": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Usage: &CephSourceUsage{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+					},
+				},
+				RGWTLS: &CephSourceRGWTLS{
+					PinnedKeys: []string{"d6qzRu9zOECb90Uez27xWltNsj0e1Md7GkYYkVoZWmM="},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"config file without configmap": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				ConfigFile: &CephSourceConfigFile{
+					Key: "config.json",
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"auth path with a comma": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Auth: []CephSourceAuth{{
+					Path:  "/cluster-a,b",
+					Token: tokenSelector("cluster-a"),
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"expose without admin port": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Expose:             &CephSourceExpose{Host: "ceph-source.example.com"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"duplicate auth path": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Auth: []CephSourceAuth{{
+					Path:  "/cluster-a",
+					Token: tokenSelector("cluster-a"),
+				}, {
+					Path:  "/cluster-a",
+					Token: tokenSelector("cluster-b"),
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"duplicate auth host": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Auth: []CephSourceAuth{{
+					Host:  "cluster-a.example.com",
+					Token: tokenSelector("cluster-a"),
+				}, {
+					Host:  "cluster-a.example.com",
+					Token: tokenSelector("cluster-b"),
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid auth host": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Auth: []CephSourceAuth{{
+					Host:  "Cluster_A",
+					Token: tokenSelector("cluster-a"),
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"auth without path nor host": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Auth: []CephSourceAuth{{
+					Token: tokenSelector("cluster-a"),
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"auth without token key": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Auth: []CephSourceAuth{{
+					Path: "/cluster-a",
+					Token: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "cluster-a"},
+					},
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid sender address": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Senders: &CephSourceSenders{
+					Addresses: []string{"10.0.0.0/8", "rgw.example.com"},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"sender event source with a comma": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Senders: &CephSourceSenders{
+					EventSources: []string{"ceph:s3,aws:s3"},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"sender region with a comma": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Senders: &CephSourceSenders{
+					Regions: []string{"us-east-1,eu-west-1"},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid debug log sampling": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				DebugLogSampling:   &CephSourceLogSampling{Initial: 0, Thereafter: 100},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"verify without credentials": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Verify: &CephSourceVerify{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:    ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID: tokenSelector("rgw-user"),
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"usage without endpoint": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Usage: &CephSourceUsage{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						AccessKeyID:     tokenSelector("rgw-admin"),
+						SecretAccessKey: tokenSelector("rgw-admin"),
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"zero usage interval": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Usage: &CephSourceUsage{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-admin"),
+						SecretAccessKey: tokenSelector("rgw-admin"),
+					},
+					Interval: &metav1.Duration{},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"expiry preview without buckets": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				ExpiryPreview: &CephSourceExpiryPreview{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+					},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"zero expiry preview days": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				ExpiryPreview: &CephSourceExpiryPreview{
+					CephSourceRGWCredentials: CephSourceRGWCredentials{
+						Endpoint:        ParseURL("http://rgw.rook-ceph.svc", t),
+						AccessKeyID:     tokenSelector("rgw-user"),
+						SecretAccessKey: tokenSelector("rgw-user"),
+					},
+					Buckets: []string{"fishbucket"},
+					Days:    ptr.Int32(0),
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"no retained events": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Retention:          &CephSourceRetention{MaxEvents: ptr.Int32(0)},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"duplicate rate limit": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				RateLimits: []CephSourceRateLimit{
+					{Bucket: "fishbucket", EventsPerSecond: 10},
+					{Bucket: "fishbucket", EventsPerSecond: 20},
+				},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"zero rate limit": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				RateLimits:         []CephSourceRateLimit{{Bucket: "*"}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unknown event time policy": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				EventTime:          &CephSourceEventTime{Policy: "drop"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"zero event max age": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				EventTime:          &CephSourceEventTime{MaxAge: &metav1.Duration{}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid maintenance schedule": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MaintenanceWindows: []CephSourceMaintenanceWindow{{
+					Schedule: "every saturday",
+					Duration: metav1.Duration{Duration: time.Hour},
+					Action:   MaintenanceDrop,
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"spooling without retention": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				MaintenanceWindows: []CephSourceMaintenanceWindow{{
+					Schedule: "0 2 * * 6",
+					Duration: metav1.Duration{Duration: time.Hour},
+				}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"empty bucket source": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				BucketSources:      map[string]string{"fishbucket": ""},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"ingress expose without host": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Expose:             &CephSourceExpose{Type: ExposeIngress},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"ingress expose without tls secret": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Expose:             &CephSourceExpose{Host: "ceph-source.example.com", TLS: &CephSourceExposeTLS{}},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"invalid expose host": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Expose:             &CephSourceExpose{Type: ExposeRoute, Host: "Ceph_Source"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"load balancer expose with host": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Expose:             &CephSourceExpose{Type: ExposeLoadBalancer, Host: "ceph-source.example.com"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unknown expose type": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Expose:             &CephSourceExpose{Type: "NodePort"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unknown mesh": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Mesh:               "consul",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"mesh with an ephemeral port": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "0",
+				Mesh:               MeshIstio,
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unknown format": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Format:             "gcs",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unknown delete markers filter": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Filter:             &CephSourceFilter{DeleteMarkers: "some"},
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+		"unknown profile": {
+			source: CephSource{Spec: CephSourceSpec{
+				ServiceAccountName: "default",
+				Port:               "9999",
+				Profile:            "gcs",
+				SourceSpec: duckv1.SourceSpec{
+					Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+				},
+			},
+			},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if err := tc.source.Validate(context.TODO()); err == nil {
+				t.Fatalf("Source validation should fail")
+			}
+		})
+	}
+}
+
+func TestCephSourceValidateQuota(t *testing.T) {
+	ctx := config.ToContext(context.TODO(), &config.Config{
+		Defaults: &config.Defaults{},
+		Quotas: &config.Quotas{
+			ClusterDefault: &config.NamespaceQuota{AllowedEndpoints: []string{"http://rgw.rook-ceph.svc"}},
+			NamespaceQuotas: map[string]*config.NamespaceQuota{
+				"team-a": {},
+			},
+		},
+	})
+
+	testCases := map[string]struct {
+		namespace string
+		endpoint  string
+		wantErr   bool
+	}{
+		"allowed endpoint":       {namespace: "team-b", endpoint: "http://rgw.rook-ceph.svc/"},
+		"other endpoint":         {namespace: "team-b", endpoint: "http://rgw.example.com", wantErr: true},
+		"lookalike endpoint":     {namespace: "team-b", endpoint: "http://rgw.rook-ceph.svc.example.com", wantErr: true},
+		"unrestricted namespace": {namespace: "team-a", endpoint: "http://rgw.example.com"},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			source := CephSource{
+				ObjectMeta: metav1.ObjectMeta{Namespace: tc.namespace},
+				Spec: CephSourceSpec{
+					ServiceAccountName: "default",
+					Port:               "9999",
+					Verify: &CephSourceVerify{
+						CephSourceRGWCredentials: CephSourceRGWCredentials{
+							Endpoint:        ParseURL(tc.endpoint, t),
+							AccessKeyID:     tokenSelector("rgw-user"),
+							SecretAccessKey: tokenSelector("rgw-user"),
+						},
+					},
+					SourceSpec: duckv1.SourceSpec{
+						Sink: duckv1.Destination{URI: ParseURL("http://hello.world", t)},
+					},
+				},
+			}
+			if err := source.Validate(ctx); (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected validation error: %v", err)
 			}
 		})
 	}
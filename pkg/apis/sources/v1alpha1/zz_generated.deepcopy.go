@@ -22,7 +22,11 @@ limitations under the License.
 package v1alpha1
 
 import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	apis "knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -53,43 +57,1097 @@ func (in *CephSource) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceAdmin) DeepCopyInto(out *CephSourceAdmin) {
+	*out = *in
+	if in.Token != nil {
+		in, out := &in.Token, &out.Token
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceAdmin.
+func (in *CephSourceAdmin) DeepCopy() *CephSourceAdmin {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceAdmin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceAgeSLO) DeepCopyInto(out *CephSourceAgeSLO) {
+	*out = *in
+	out.Threshold = in.Threshold
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceAgeSLO.
+func (in *CephSourceAgeSLO) DeepCopy() *CephSourceAgeSLO {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceAgeSLO)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceAudit) DeepCopyInto(out *CephSourceAudit) {
+	*out = *in
+	in.CephSourceRGWCredentials.DeepCopyInto(&out.CephSourceRGWCredentials)
+	if in.FlushInterval != nil {
+		in, out := &in.FlushInterval, &out.FlushInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxBatch != nil {
+		in, out := &in.MaxBatch, &out.MaxBatch
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceAudit.
+func (in *CephSourceAudit) DeepCopy() *CephSourceAudit {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceAudit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceAuth) DeepCopyInto(out *CephSourceAuth) {
+	*out = *in
+	in.Token.DeepCopyInto(&out.Token)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceAuth.
+func (in *CephSourceAuth) DeepCopy() *CephSourceAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceCheckpoint) DeepCopyInto(out *CephSourceCheckpoint) {
+	*out = *in
+	if in.PrefixDepth != nil {
+		in, out := &in.PrefixDepth, &out.PrefixDepth
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Volume != nil {
+		in, out := &in.Volume, &out.Volume
+		*out = new(v1.VolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceCheckpoint.
+func (in *CephSourceCheckpoint) DeepCopy() *CephSourceCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceConfigFile) DeepCopyInto(out *CephSourceConfigFile) {
+	*out = *in
+	out.ConfigMap = in.ConfigMap
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceConfigFile.
+func (in *CephSourceConfigFile) DeepCopy() *CephSourceConfigFile {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceConfigFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceCredentialsFrom) DeepCopyInto(out *CephSourceCredentialsFrom) {
+	*out = *in
+	if in.CSI != nil {
+		in, out := &in.CSI, &out.CSI
+		*out = new(v1.CSIVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceCredentialsFrom.
+func (in *CephSourceCredentialsFrom) DeepCopy() *CephSourceCredentialsFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceCredentialsFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceDNS) DeepCopyInto(out *CephSourceDNS) {
+	*out = *in
+	if in.CacheTTL != nil {
+		in, out := &in.CacheTTL, &out.CacheTTL
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceDNS.
+func (in *CephSourceDNS) DeepCopy() *CephSourceDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceDelivery) DeepCopyInto(out *CephSourceDelivery) {
+	*out = *in
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BackoffDelay != nil {
+		in, out := &in.BackoffDelay, &out.BackoffDelay
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.DeadLetterSink != nil {
+		in, out := &in.DeadLetterSink, &out.DeadLetterSink
+		*out = new(duckv1.Destination)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailureSink != nil {
+		in, out := &in.FailureSink, &out.FailureSink
+		*out = new(duckv1.Destination)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Failover != nil {
+		in, out := &in.Failover, &out.Failover
+		*out = new(CephSourceFailover)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LoadShedding != nil {
+		in, out := &in.LoadShedding, &out.LoadShedding
+		*out = new(CephSourceLoadShedding)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceDelivery.
+func (in *CephSourceDelivery) DeepCopy() *CephSourceDelivery {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceDelivery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceDispatcher) DeepCopyInto(out *CephSourceDispatcher) {
+	*out = *in
+	in.Queue.DeepCopyInto(&out.Queue)
+	if in.MaxEvents != nil {
+		in, out := &in.MaxEvents, &out.MaxEvents
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceDispatcher.
+func (in *CephSourceDispatcher) DeepCopy() *CephSourceDispatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceDispatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceEventTime) DeepCopyInto(out *CephSourceEventTime) {
+	*out = *in
+	if in.MaxFuture != nil {
+		in, out := &in.MaxFuture, &out.MaxFuture
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxAge != nil {
+		in, out := &in.MaxAge, &out.MaxAge
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceEventTime.
+func (in *CephSourceEventTime) DeepCopy() *CephSourceEventTime {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceEventTime)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceExpiryPreview) DeepCopyInto(out *CephSourceExpiryPreview) {
+	*out = *in
+	in.CephSourceRGWCredentials.DeepCopyInto(&out.CephSourceRGWCredentials)
+	if in.Buckets != nil {
+		in, out := &in.Buckets, &out.Buckets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceExpiryPreview.
+func (in *CephSourceExpiryPreview) DeepCopy() *CephSourceExpiryPreview {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceExpiryPreview)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceExpose) DeepCopyInto(out *CephSourceExpose) {
+	*out = *in
+	if in.IngressClassName != nil {
+		in, out := &in.IngressClassName, &out.IngressClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(CephSourceExposeTLS)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceExpose.
+func (in *CephSourceExpose) DeepCopy() *CephSourceExpose {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceExpose)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceExposeTLS) DeepCopyInto(out *CephSourceExposeTLS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceExposeTLS.
+func (in *CephSourceExposeTLS) DeepCopy() *CephSourceExposeTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceExposeTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceFailover) DeepCopyInto(out *CephSourceFailover) {
+	*out = *in
+	if in.Sinks != nil {
+		in, out := &in.Sinks, &out.Sinks
+		*out = make([]duckv1.Destination, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Cooldown != nil {
+		in, out := &in.Cooldown, &out.Cooldown
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceFailover.
+func (in *CephSourceFailover) DeepCopy() *CephSourceFailover {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceFailover)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceFilter) DeepCopyInto(out *CephSourceFilter) {
+	*out = *in
+	if in.EventNames != nil {
+		in, out := &in.EventNames, &out.EventNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Versioned != nil {
+		in, out := &in.Versioned, &out.Versioned
+		*out = new(bool)
+		**out = **in
+	}
+	if in.StorageClasses != nil {
+		in, out := &in.StorageClasses, &out.StorageClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceFilter.
+func (in *CephSourceFilter) DeepCopy() *CephSourceFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceHeader) DeepCopyInto(out *CephSourceHeader) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceHeader.
+func (in *CephSourceHeader) DeepCopy() *CephSourceHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceLifecycleEvents) DeepCopyInto(out *CephSourceLifecycleEvents) {
+	*out = *in
+	if in.Sink != nil {
+		in, out := &in.Sink, &out.Sink
+		*out = new(duckv1.Destination)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceLifecycleEvents.
+func (in *CephSourceLifecycleEvents) DeepCopy() *CephSourceLifecycleEvents {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceLifecycleEvents)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CephSourceList) DeepCopyInto(out *CephSourceList) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]CephSource, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceList.
+func (in *CephSourceList) DeepCopy() *CephSourceList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephSourceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceListener) DeepCopyInto(out *CephSourceListener) {
+	*out = *in
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(CephSourceListenerTLS)
+		**out = **in
+	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = make([]CephSourceAuth, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(CephSourceFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceListener.
+func (in *CephSourceListener) DeepCopy() *CephSourceListener {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceListener)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceListenerTLS) DeepCopyInto(out *CephSourceListenerTLS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceListenerTLS.
+func (in *CephSourceListenerTLS) DeepCopy() *CephSourceListenerTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceListenerTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceLoadShedding) DeepCopyInto(out *CephSourceLoadShedding) {
+	*out = *in
+	out.Threshold = in.Threshold
+	if in.Period != nil {
+		in, out := &in.Period, &out.Period
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Priorities != nil {
+		in, out := &in.Priorities, &out.Priorities
+		*out = make([]CephSourcePriorityClass, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceLoadShedding.
+func (in *CephSourceLoadShedding) DeepCopy() *CephSourceLoadShedding {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceLoadShedding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceLogSampling) DeepCopyInto(out *CephSourceLogSampling) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceLogSampling.
+func (in *CephSourceLogSampling) DeepCopy() *CephSourceLogSampling {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceLogSampling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceMaintenanceWindow) DeepCopyInto(out *CephSourceMaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceMaintenanceWindow.
+func (in *CephSourceMaintenanceWindow) DeepCopy() *CephSourceMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceMaxEventSize) DeepCopyInto(out *CephSourceMaxEventSize) {
+	*out = *in
+	out.Limit = in.Limit.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceMaxEventSize.
+func (in *CephSourceMaxEventSize) DeepCopy() *CephSourceMaxEventSize {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceMaxEventSize)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourcePriorityClass) DeepCopyInto(out *CephSourcePriorityClass) {
+	*out = *in
+	if in.EventNames != nil {
+		in, out := &in.EventNames, &out.EventNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Buckets != nil {
+		in, out := &in.Buckets, &out.Buckets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourcePriorityClass.
+func (in *CephSourcePriorityClass) DeepCopy() *CephSourcePriorityClass {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourcePriorityClass)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceRGWCredentials) DeepCopyInto(out *CephSourceRGWCredentials) {
+	*out = *in
+	if in.Endpoint != nil {
+		in, out := &in.Endpoint, &out.Endpoint
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	in.AccessKeyID.DeepCopyInto(&out.AccessKeyID)
+	in.SecretAccessKey.DeepCopyInto(&out.SecretAccessKey)
+	if in.NextCredentials != nil {
+		in, out := &in.NextCredentials, &out.NextCredentials
+		*out = new(CephSourceS3Credentials)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceRGWCredentials.
+func (in *CephSourceRGWCredentials) DeepCopy() *CephSourceRGWCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceRGWCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceRGWTLS) DeepCopyInto(out *CephSourceRGWTLS) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PinnedKeys != nil {
+		in, out := &in.PinnedKeys, &out.PinnedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceRGWTLS.
+func (in *CephSourceRGWTLS) DeepCopy() *CephSourceRGWTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceRGWTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceRateLimit) DeepCopyInto(out *CephSourceRateLimit) {
+	*out = *in
+	if in.Burst != nil {
+		in, out := &in.Burst, &out.Burst
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceRateLimit.
+func (in *CephSourceRateLimit) DeepCopy() *CephSourceRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceRetention) DeepCopyInto(out *CephSourceRetention) {
+	*out = *in
+	if in.MaxEvents != nil {
+		in, out := &in.MaxEvents, &out.MaxEvents
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Volume != nil {
+		in, out := &in.Volume, &out.Volume
+		*out = new(v1.VolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceRetention.
+func (in *CephSourceRetention) DeepCopy() *CephSourceRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceRoutes) DeepCopyInto(out *CephSourceRoutes) {
+	*out = *in
+	out.ConfigMap = in.ConfigMap
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceRoutes.
+func (in *CephSourceRoutes) DeepCopy() *CephSourceRoutes {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceRoutes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceS3Credentials) DeepCopyInto(out *CephSourceS3Credentials) {
+	*out = *in
+	in.AccessKeyID.DeepCopyInto(&out.AccessKeyID)
+	in.SecretAccessKey.DeepCopyInto(&out.SecretAccessKey)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceS3Credentials.
+func (in *CephSourceS3Credentials) DeepCopy() *CephSourceS3Credentials {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceS3Credentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceSenders) DeepCopyInto(out *CephSourceSenders) {
+	*out = *in
+	if in.EventSources != nil {
+		in, out := &in.EventSources, &out.EventSources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Regions != nil {
+		in, out := &in.Regions, &out.Regions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceSenders.
+func (in *CephSourceSenders) DeepCopy() *CephSourceSenders {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceSenders)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceSigV4) DeepCopyInto(out *CephSourceSigV4) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]CephSourceSigV4Key, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MaxSkew != nil {
+		in, out := &in.MaxSkew, &out.MaxSkew
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceList.
-func (in *CephSourceList) DeepCopy() *CephSourceList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceSigV4.
+func (in *CephSourceSigV4) DeepCopy() *CephSourceSigV4 {
 	if in == nil {
 		return nil
 	}
-	out := new(CephSourceList)
+	out := new(CephSourceSigV4)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CephSourceList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceSigV4Key) DeepCopyInto(out *CephSourceSigV4Key) {
+	*out = *in
+	in.SecretAccessKey.DeepCopyInto(&out.SecretAccessKey)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceSigV4Key.
+func (in *CephSourceSigV4Key) DeepCopy() *CephSourceSigV4Key {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(CephSourceSigV4Key)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceSourceURI) DeepCopyInto(out *CephSourceSourceURI) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceSourceURI.
+func (in *CephSourceSourceURI) DeepCopy() *CephSourceSourceURI {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceSourceURI)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CephSourceSpec) DeepCopyInto(out *CephSourceSpec) {
 	*out = *in
 	in.SourceSpec.DeepCopyInto(&out.SourceSpec)
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = make([]CephSourceAuth, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SigV4 != nil {
+		in, out := &in.SigV4, &out.SigV4
+		*out = new(CephSourceSigV4)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Listeners != nil {
+		in, out := &in.Listeners, &out.Listeners
+		*out = make([]CephSourceListener, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Admin != nil {
+		in, out := &in.Admin, &out.Admin
+		*out = new(CephSourceAdmin)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SuccessStatus != nil {
+		in, out := &in.SuccessStatus, &out.SuccessStatus
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxConcurrency != nil {
+		in, out := &in.MaxConcurrency, &out.MaxConcurrency
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Subject != nil {
+		in, out := &in.Subject, &out.Subject
+		*out = new(CephSourceSubject)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Senders != nil {
+		in, out := &in.Senders, &out.Senders
+		*out = new(CephSourceSenders)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DebugLogSampling != nil {
+		in, out := &in.DebugLogSampling, &out.DebugLogSampling
+		*out = new(CephSourceLogSampling)
+		**out = **in
+	}
+	if in.Verify != nil {
+		in, out := &in.Verify, &out.Verify
+		*out = new(CephSourceVerify)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(CephSourceUsage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExpiryPreview != nil {
+		in, out := &in.ExpiryPreview, &out.ExpiryPreview
+		*out = new(CephSourceExpiryPreview)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(CephSourceAudit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CredentialsFrom != nil {
+		in, out := &in.CredentialsFrom, &out.CredentialsFrom
+		*out = new(CephSourceCredentialsFrom)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RGWTLS != nil {
+		in, out := &in.RGWTLS, &out.RGWTLS
+		*out = new(CephSourceRGWTLS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(CephSourceFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(CephSourceRetention)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(CephSourceStorage)
+		**out = **in
+	}
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		*out = new(CephSourceCheckpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Delivery != nil {
+		in, out := &in.Delivery, &out.Delivery
+		*out = new(CephSourceDelivery)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LifecycleEvents != nil {
+		in, out := &in.LifecycleEvents, &out.LifecycleEvents
+		*out = new(CephSourceLifecycleEvents)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(CephSourceDNS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLSPolicy != nil {
+		in, out := &in.TLSPolicy, &out.TLSPolicy
+		*out = new(CephSourceTLSPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SinkHeaders != nil {
+		in, out := &in.SinkHeaders, &out.SinkHeaders
+		*out = make([]CephSourceHeader, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MaxEventSize != nil {
+		in, out := &in.MaxEventSize, &out.MaxEventSize
+		*out = new(CephSourceMaxEventSize)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Dispatcher != nil {
+		in, out := &in.Dispatcher, &out.Dispatcher
+		*out = new(CephSourceDispatcher)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = new(CephSourceRoutes)
+		**out = **in
+	}
+	if in.ConfigFile != nil {
+		in, out := &in.ConfigFile, &out.ConfigFile
+		*out = new(CephSourceConfigFile)
+		**out = **in
+	}
+	if in.RateLimits != nil {
+		in, out := &in.RateLimits, &out.RateLimits
+		*out = make([]CephSourceRateLimit, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EventTime != nil {
+		in, out := &in.EventTime, &out.EventTime
+		*out = new(CephSourceEventTime)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AgeSLO != nil {
+		in, out := &in.AgeSLO, &out.AgeSLO
+		*out = new(CephSourceAgeSLO)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TrafficExpectations != nil {
+		in, out := &in.TrafficExpectations, &out.TrafficExpectations
+		*out = make([]CephSourceTrafficExpectation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]CephSourceMaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.BucketSources != nil {
+		in, out := &in.BucketSources, &out.BucketSources
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SourceURI != nil {
+		in, out := &in.SourceURI, &out.SourceURI
+		*out = new(CephSourceSourceURI)
+		**out = **in
+	}
+	if in.MetadataExtensions != nil {
+		in, out := &in.MetadataExtensions, &out.MetadataExtensions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DataProjection != nil {
+		in, out := &in.DataProjection, &out.DataProjection
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(CephSourceExpose)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -107,6 +1165,40 @@ func (in *CephSourceSpec) DeepCopy() *CephSourceSpec {
 func (in *CephSourceStatus) DeepCopyInto(out *CephSourceStatus) {
 	*out = *in
 	in.SourceStatus.DeepCopyInto(&out.SourceStatus)
+	if in.SuggestedFilters != nil {
+		in, out := &in.SuggestedFilters, &out.SuggestedFilters
+		*out = make([]CephSourceTriggerFilter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExternalURL != nil {
+		in, out := &in.ExternalURL, &out.ExternalURL
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeadLetterSinkURI != nil {
+		in, out := &in.DeadLetterSinkURI, &out.DeadLetterSinkURI
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailureSinkURI != nil {
+		in, out := &in.FailureSinkURI, &out.FailureSinkURI
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailoverSinkURIs != nil {
+		in, out := &in.FailoverSinkURIs, &out.FailoverSinkURIs
+		*out = make([]apis.URL, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LifecycleSinkURI != nil {
+		in, out := &in.LifecycleSinkURI, &out.LifecycleSinkURI
+		*out = new(apis.URL)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -119,3 +1211,150 @@ func (in *CephSourceStatus) DeepCopy() *CephSourceStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceStorage) DeepCopyInto(out *CephSourceStorage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceStorage.
+func (in *CephSourceStorage) DeepCopy() *CephSourceStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceSubject) DeepCopyInto(out *CephSourceSubject) {
+	*out = *in
+	if in.MaxLength != nil {
+		in, out := &in.MaxLength, &out.MaxLength
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceSubject.
+func (in *CephSourceSubject) DeepCopy() *CephSourceSubject {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceSubject)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceTLSPolicy) DeepCopyInto(out *CephSourceTLSPolicy) {
+	*out = *in
+	if in.CipherSuites != nil {
+		in, out := &in.CipherSuites, &out.CipherSuites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceTLSPolicy.
+func (in *CephSourceTLSPolicy) DeepCopy() *CephSourceTLSPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceTLSPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceTrafficExpectation) DeepCopyInto(out *CephSourceTrafficExpectation) {
+	*out = *in
+	if in.MinCount != nil {
+		in, out := &in.MinCount, &out.MinCount
+		*out = new(int32)
+		**out = **in
+	}
+	out.Period = in.Period
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceTrafficExpectation.
+func (in *CephSourceTrafficExpectation) DeepCopy() *CephSourceTrafficExpectation {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceTrafficExpectation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceTriggerFilter) DeepCopyInto(out *CephSourceTriggerFilter) {
+	*out = *in
+	if in.Attributes != nil {
+		in, out := &in.Attributes, &out.Attributes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceTriggerFilter.
+func (in *CephSourceTriggerFilter) DeepCopy() *CephSourceTriggerFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceTriggerFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceUsage) DeepCopyInto(out *CephSourceUsage) {
+	*out = *in
+	in.CephSourceRGWCredentials.DeepCopyInto(&out.CephSourceRGWCredentials)
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceUsage.
+func (in *CephSourceUsage) DeepCopy() *CephSourceUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephSourceVerify) DeepCopyInto(out *CephSourceVerify) {
+	*out = *in
+	in.CephSourceRGWCredentials.DeepCopyInto(&out.CephSourceRGWCredentials)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephSourceVerify.
+func (in *CephSourceVerify) DeepCopy() *CephSourceVerify {
+	if in == nil {
+		return nil
+	}
+	out := new(CephSourceVerify)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"net/url"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+// cdEventsSpecVersion is the version of the CDEvents specification of the
+// events of the cdevents profile.
+const cdEventsSpecVersion = "0.4.1"
+
+// toCDEvent converts a bucket notification to the data of a CDEvents artifact
+// event, repeating the attributes of the CloudEvent in its context.
+func toCDEvent(notification ceph.BucketNotification, event cloudevents.Event) ceph.CDEvent {
+	return ceph.CDEvent{
+		Context: ceph.CDEventContext{
+			Version:   cdEventsSpecVersion,
+			ID:        event.ID(),
+			Source:    event.Source(),
+			Type:      event.Type(),
+			Timestamp: event.Time().UTC().Format(time.RFC3339Nano),
+		},
+		Subject: ceph.CDEventSubject{
+			ID:     artifactPURL(notification.S3.Bucket.Name, notification.S3.Object),
+			Source: event.Source(),
+			Type:   "artifact",
+			Content: ceph.CDEventArtifactContent{
+				User: notification.UserIdentity.PrincipalID,
+			},
+		},
+	}
+}
+
+// artifactPURL returns the package URL identifying an object as a CDEvents
+// artifact, e.g. "pkg:generic/fish9.jpg@v1?bucket=fishbucket".
+func artifactPURL(bucket string, object ceph.ObjectSpec) string {
+	purl := "pkg:generic/" + url.PathEscape(object.Key)
+	if object.VersionID != "" {
+		purl += "@" + url.PathEscape(object.VersionID)
+	}
+	qualifiers := url.Values{"bucket": {bucket}}
+	if object.ETag != "" {
+		qualifiers.Set("etag", object.ETag)
+	}
+	return purl + "?" + qualifiers.Encode()
+}
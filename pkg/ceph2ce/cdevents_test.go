@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"testing"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func TestToCDEvent(t *testing.T) {
+	testCases := map[string]struct {
+		eventName string
+		typ       string
+	}{
+		"put":           {eventName: "s3:ObjectCreated:Put", typ: v1alpha1.CDEventsArtifactPublished},
+		"copy":          {eventName: "s3:ObjectCreated:Copy", typ: v1alpha1.CDEventsArtifactPublished},
+		"delete":        {eventName: "s3:ObjectRemoved:Delete", typ: v1alpha1.CDEventsArtifactDeleted},
+		"delete marker": {eventName: "s3:ObjectRemoved:DeleteMarkerCreated", typ: v1alpha1.CDEventsArtifactDeleted},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			notification := record1
+			notification.EventName = tc.eventName
+
+			event, err := Converter{Profile: ProfileCDEvents}.ToCloudEvent(notification)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Type() != tc.typ {
+				t.Errorf("Unexpected type: got %q, want %q", event.Type(), tc.typ)
+			}
+			var data ceph.CDEvent
+			if err := event.DataAs(&data); err != nil {
+				t.Fatal(err)
+			}
+			if data.Context.Type != tc.typ || data.Context.ID != event.ID() || data.Context.Source != event.Source() {
+				t.Errorf("Unexpected context: %+v", data.Context)
+			}
+			if want := "pkg:generic/fish9.jpg?bucket=fishbucket&etag=37b51d194a7513e45b56f6524f2d51f2"; data.Subject.ID != want {
+				t.Errorf("Unexpected subject id: got %q, want %q", data.Subject.ID, want)
+			}
+			if data.Subject.Type != "artifact" {
+				t.Errorf("Unexpected subject type: %q", data.Subject.Type)
+			}
+		})
+	}
+}
+
+func TestArtifactPURL(t *testing.T) {
+	got := artifactPURL("fishbucket", ceph.ObjectSpec{Key: "photos/fish 9.jpg", VersionID: "v1"})
+	if want := "pkg:generic/photos%2Ffish%209.jpg@v1?bucket=fishbucket"; got != want {
+		t.Errorf("Unexpected purl: got %q, want %q", got, want)
+	}
+}
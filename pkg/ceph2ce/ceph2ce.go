@@ -0,0 +1,355 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ceph2ce converts Ceph bucket notifications to CloudEvents. It holds
+// the mapping used by the CephSource receive adapter so that other programs
+// can produce the exact same events.
+package ceph2ce
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/projection"
+	"knative.dev/eventing-ceph/pkg/transform"
+)
+
+const (
+	// ProfileCeph, ProfileAWSS3, ProfileEventBridge and ProfileCDEvents
+	// select how notifications are mapped to CloudEvents, see the CephSource
+	// profile.
+	ProfileCeph        = v1alpha1.ProfileCeph
+	ProfileAWSS3       = v1alpha1.ProfileAWSS3
+	ProfileEventBridge = v1alpha1.ProfileEventBridge
+	ProfileCDEvents    = v1alpha1.ProfileCDEvents
+
+	// PayloadRecord, PayloadRecordEnvelope and PayloadFlat select the shape
+	// of the event data, see the CephSource payload.
+	PayloadRecord         = v1alpha1.PayloadRecord
+	PayloadRecordEnvelope = v1alpha1.PayloadRecordEnvelope
+	PayloadFlat           = v1alpha1.PayloadFlat
+
+	// SourceSchemeARN, SourceSchemeCeph and SourceSchemeConcatenation
+	// select the scheme of the event source, see the CephSource sourceURI.
+	SourceSchemeARN           = v1alpha1.SourceSchemeARN
+	SourceSchemeCeph          = v1alpha1.SourceSchemeCeph
+	SourceSchemeConcatenation = v1alpha1.SourceSchemeConcatenation
+)
+
+// Converter converts bucket notifications to CloudEvents. The zero value
+// converts them like a CephSource with the default settings.
+type Converter struct {
+	// Profile selects how notifications are mapped to CloudEvents.
+	// Defaults to ProfileCeph.
+	Profile string
+
+	// TypePrefix, if set, replaces the type prefix of the profile, see
+	// EventTypeWithPrefix.
+	TypePrefix string
+
+	// Payload selects the shape of the event data. Defaults to PayloadRecord.
+	Payload string
+
+	// DecodeKeys URL-decodes object keys, keeping the key as sent in the
+	// RawKeyExtension when it differs.
+	DecodeKeys bool
+
+	// CopySource resolves the copy source of copied objects from their
+	// CopySourceMetadataKey metadata, unless the record already has one.
+	CopySource bool
+
+	// Sources overrides the event source of the records of the buckets it
+	// lists, keyed by bucket name.
+	Sources map[string]string
+
+	// SourceScheme, if set, selects the scheme of the event source rather
+	// than the one of the profile, with Cluster the name of the Ceph
+	// cluster in the sources of SourceSchemeCeph.
+	SourceScheme string
+	Cluster      string
+
+	// MetadataExtensions lists the user metadata of objects promoted to
+	// extensions, see MetadataExtensionName.
+	MetadataExtensions []string
+
+	// DataSchema, if set, is the URI of the JSON schema of the event data,
+	// set as the dataschema attribute.
+	DataSchema string
+
+	// EscapeSubject percent-encodes the characters of subjects other than
+	// the unreserved characters of URLs and "/".
+	EscapeSubject bool
+
+	// MaxSubjectLength, if positive, bounds the length in bytes of
+	// subjects, the longer ones being shortened as SubjectOverflow says.
+	MaxSubjectLength int
+
+	// SubjectOverflow selects how subjects longer than MaxSubjectLength are
+	// shortened. Defaults to SubjectOverflowTruncate.
+	SubjectOverflow string
+
+	// Projection, if set, keeps only the fields of the event data it
+	// selects.
+	Projection *projection.Projection
+
+	// Transform, if set, renders the event data from the data kept by the
+	// projection.
+	Transform *transform.Transform
+
+	// Logger, if set, logs the fallbacks taken on malformed notifications.
+	Logger *zap.SugaredLogger
+}
+
+// ToCloudEvent converts a bucket notification record to a CloudEvent with
+// the default settings.
+func ToCloudEvent(record ceph.BucketNotification) (cloudevents.Event, error) {
+	return Converter{}.ToCloudEvent(record)
+}
+
+// EventType returns the CloudEvent type of a Ceph event name, e.g.
+// "s3:ObjectCreated:Put", for the given profile.
+func EventType(profile, eventName string) string {
+	return v1alpha1.EventType(profile, eventName)
+}
+
+// EventTypeWithPrefix returns the CloudEvent type of a Ceph event name for
+// the given profile, with prefix replacing the type prefix of the profile
+// unless empty.
+func EventTypeWithPrefix(profile, prefix, eventName string) string {
+	return v1alpha1.EventTypeWithPrefix(profile, prefix, eventName)
+}
+
+// ToCloudEvent converts a bucket notification record to a CloudEvent.
+func (c Converter) ToCloudEvent(record ceph.BucketNotification) (cloudevents.Event, error) {
+	event, err := c.toCloudEvent(record)
+	if err != nil {
+		return event, err
+	}
+	return c.reshape(event)
+}
+
+// toCloudEvent converts a bucket notification record to a CloudEvent, with
+// its data neither projected nor transformed.
+func (c Converter) toCloudEvent(record ceph.BucketNotification) (cloudevents.Event, error) {
+	logger := c.Logger
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	profile := c.Profile
+	if profile == "" {
+		profile = ProfileCeph
+	}
+
+	rawKey := record.S3.Object.Key
+	if c.DecodeKeys {
+		key, err := decodeKey(rawKey)
+		if err != nil {
+			logger.Infow("Failed to decode object key, using it as is", zap.Error(err))
+		}
+		record.S3.Object.Key = key
+	}
+
+	if c.CopySource && record.S3.Object.CopySource == nil {
+		copySource, err := copySourceFromMetadata(record)
+		if err != nil {
+			logger.Infow("Failed to parse the copy source", zap.Error(err))
+		}
+		record.S3.Object.CopySource = copySource
+	}
+
+	event := c.newEvent(logger, record, profile, rawKey)
+	setMetadataExtensions(&event, record, c.MetadataExtensions)
+	var data interface{}
+	switch {
+	case profile == ProfileEventBridge:
+		data = toEventBridge(record)
+	case profile == ProfileCDEvents:
+		data = toCDEvent(record, event)
+	case c.Payload == PayloadRecordEnvelope:
+		data = ceph.BucketNotifications{Records: []ceph.BucketNotification{record}}
+	case c.Payload == PayloadFlat:
+		data = flatten(record)
+	default:
+		data = record
+	}
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return event, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+	if c.DataSchema != "" {
+		event.SetDataSchema(c.DataSchema)
+	}
+	return event, nil
+}
+
+// reshape keeps the fields of the data of event selected by the projection
+// of the converter, if any, then transforms them.
+func (c Converter) reshape(event cloudevents.Event) (cloudevents.Event, error) {
+	if c.Projection == nil && c.Transform == nil {
+		return event, nil
+	}
+	data := event.Data()
+	if c.Projection != nil {
+		var err error
+		if data, err = c.Projection.Apply(data); err != nil {
+			return event, fmt.Errorf("failed to project event data: %w", err)
+		}
+	}
+	if c.Transform != nil {
+		var err error
+		if data, err = c.Transform.Apply(data); err != nil {
+			return event, fmt.Errorf("failed to transform event data: %w", err)
+		}
+	}
+	if err := event.SetData(cloudevents.ApplicationJSON, json.RawMessage(data)); err != nil {
+		return event, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+	return event, nil
+}
+
+// newEvent returns the CloudEvent of a record, without data.
+func (c Converter) newEvent(logger *zap.SugaredLogger, record ceph.BucketNotification, profile, rawKey string) cloudevents.Event {
+	eventTime, err := time.Parse(time.RFC3339, record.EventTime)
+	if err != nil {
+		logger.Infow("Failed to parse event timestamp, using local time", zap.Error(err))
+		eventTime = time.Now()
+	}
+
+	event := cloudevents.NewEvent()
+	switch profile {
+	case ProfileAWSS3:
+		event.SetID(record.ResponseElements.XAmzRequestID + "." + record.ResponseElements.XAmzID2)
+		event.SetSource(bucketARN(record.S3.Bucket))
+	default:
+		event.SetID(record.ResponseElements.XAmzRequestID + record.ResponseElements.XAmzID2)
+		event.SetSource(record.EventSource + "." + record.AwsRegion + "." + record.S3.Bucket.Name)
+	}
+	if record.ResponseElements.XAmzRequestID == "" && record.ResponseElements.XAmzID2 == "" {
+		// S3-compatible notifiers may omit the response elements.
+		event.SetID(fallbackID(record))
+	}
+	if c.SourceScheme != "" {
+		event.SetSource(c.source(record))
+	}
+	if source, ok := c.Sources[record.S3.Bucket.Name]; ok {
+		event.SetSource(source)
+	}
+	event.SetType(eventType(profile, c.TypePrefix, record.EventName))
+	event.SetSubject(c.subject(record.S3.Object.Key))
+	if record.S3.Object.Key != rawKey {
+		event.SetExtension(RawKeyExtension, rawKey)
+	}
+	event.SetTime(eventTime)
+	setVersionExtensions(&event, record)
+	if e := Encryption(record); e != nil {
+		event.SetExtension(EncryptionExtension, e.Type)
+	}
+	if class := record.S3.Object.StorageClass; class != "" {
+		event.SetExtension(StorageClassExtension, class)
+	}
+	setObjectLockExtensions(&event, record)
+	return event
+}
+
+// fallbackID returns the event ID of a record without response elements: its
+// eventId if any, else a hash of the fields identifying the object change,
+// stable across retries of the notification.
+func fallbackID(record ceph.BucketNotification) string {
+	if record.EventID != "" {
+		return record.EventID
+	}
+	h := sha256.New()
+	for _, field := range []string{
+		record.EventName,
+		record.EventTime,
+		record.S3.Bucket.Name,
+		record.S3.Object.Key,
+		record.S3.Object.VersionID,
+		record.S3.Object.ETag,
+		record.S3.Object.Sequencer,
+	} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// source returns the event source of a record in the scheme of the
+// converter.
+func (c Converter) source(record ceph.BucketNotification) string {
+	switch c.SourceScheme {
+	case SourceSchemeARN:
+		return bucketARN(record.S3.Bucket)
+	case SourceSchemeCeph:
+		zonegroup := record.AwsRegion
+		if zonegroup == "" {
+			zonegroup = "default"
+		}
+		return "ceph://" + c.Cluster + "/" + url.PathEscape(zonegroup) + "/" + url.PathEscape(record.S3.Bucket.Name)
+	default:
+		return record.EventSource + "." + record.AwsRegion + "." + record.S3.Bucket.Name
+	}
+}
+
+// bucketARN returns the ARN of the bucket, deriving it from the bucket name
+// when the notification does not carry one.
+func bucketARN(bucket ceph.BucketSpec) string {
+	if bucket.Arn != "" {
+		return bucket.Arn
+	}
+	return "arn:aws:s3:::" + bucket.Name
+}
+
+// flatten converts a bucket notification to its flattened form.
+func flatten(notification ceph.BucketNotification) ceph.FlatNotification {
+	var metadata map[string]string
+	if len(notification.S3.Object.Metadata) > 0 {
+		metadata = make(map[string]string, len(notification.S3.Object.Metadata))
+		for _, m := range notification.S3.Object.Metadata {
+			metadata[m.Key] = m.Value
+		}
+	}
+	return ceph.FlatNotification{
+		EventName:       notification.EventName,
+		EventTime:       notification.EventTime,
+		EventSource:     notification.EventSource,
+		Region:          notification.AwsRegion,
+		EventID:         notification.EventID,
+		RequestID:       notification.ResponseElements.XAmzRequestID,
+		PrincipalID:     notification.UserIdentity.PrincipalID,
+		SourceIPAddress: notification.RequestParameters.SourceIPAddress,
+		ConfigurationID: notification.S3.ConfigurationID,
+		Bucket:          notification.S3.Bucket.Name,
+		BucketARN:       bucketARN(notification.S3.Bucket),
+		Key:             notification.S3.Object.Key,
+		Size:            notification.S3.Object.Size,
+		ETag:            notification.S3.Object.ETag,
+		VersionID:       notification.S3.Object.VersionID,
+		Sequencer:       notification.S3.Object.Sequencer,
+		Metadata:        metadata,
+		CopySource:      notification.S3.Object.CopySource,
+		Encryption:      Encryption(notification),
+		StorageClass:    notification.S3.Object.StorageClass,
+		ObjectLock:      ObjectLock(notification),
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"encoding/json"
+	"testing"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/projection"
+	"knative.dev/eventing-ceph/pkg/transform"
+)
+
+var record1 = ceph.BucketNotification{
+	EventVersion: "2.1",
+	EventSource:  "ceph:s3",
+	AwsRegion:    "tenantA",
+	EventTime:    "2019-11-22T13:47:35.124724Z",
+	EventName:    "s3:ObjectCreated:Put",
+	UserIdentity: ceph.UserIdentitySpec{
+		PrincipalID: "tester",
+	},
+	RequestParameters: ceph.RequestParametersSpec{
+		SourceIPAddress: "",
+	},
+	ResponseElements: ceph.ResponseElementsSpec{
+		XAmzRequestID: "503a4c37-85eb-47cd-8681-2817e80b4281.5330.903595",
+		XAmzID2:       "14d2-a1-a",
+	},
+	S3: ceph.S3Spec{
+		S3SchemaVersion: "1.0",
+		ConfigurationID: "fishbucket_notifications",
+		Bucket: ceph.BucketSpec{
+			Name: "fishbucket",
+			OwnerIdentity: ceph.OwnerIdentitySpec{
+				PrincipalID: "tester",
+			},
+			Arn: "arn:aws:s3:::fishbucket",
+			ID:  "503a4c37-85eb-47cd-8681-2817e80b4281.5332.38",
+		},
+		Object: ceph.ObjectSpec{
+			Key:       "fish9.jpg",
+			Size:      1024,
+			ETag:      "37b51d194a7513e45b56f6524f2d51f2",
+			VersionID: "",
+			Sequencer: "F7E6D75DC742D108",
+			Metadata: []ceph.MetadataEntry{
+				{Key: "x-amz-meta-meta1", Value: "This is my metadata value"},
+				{Key: "x-amz-meta-meta2", Value: "This is another metadata value"},
+			},
+		},
+	},
+	EventID: "1575221657.102001.80dad3aad8584778352c68ab06250327",
+}
+
+func TestToCloudEvent(t *testing.T) {
+	testCases := map[string]struct {
+		profile string
+		prefix  string
+		sources map[string]string
+		scheme  string
+		id      string
+		source  string
+		typ     string
+	}{
+		"ceph profile": {
+			profile: ProfileCeph,
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.90359514d2-a1-a",
+			source:  "ceph:s3.tenantA.fishbucket",
+			typ:     "com.amazonaws.s3:ObjectCreated:Put",
+		},
+		"aws-s3 profile": {
+			profile: ProfileAWSS3,
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.903595.14d2-a1-a",
+			source:  "arn:aws:s3:::fishbucket",
+			typ:     "com.amazonaws.s3.ObjectCreated:Put",
+		},
+		"bucket source": {
+			profile: ProfileAWSS3,
+			sources: map[string]string{"fishbucket": "https://storage.example.com/fishbucket"},
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.903595.14d2-a1-a",
+			source:  "https://storage.example.com/fishbucket",
+			typ:     "com.amazonaws.s3.ObjectCreated:Put",
+		},
+		"arn source scheme": {
+			profile: ProfileCeph,
+			scheme:  SourceSchemeARN,
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.90359514d2-a1-a",
+			source:  "arn:aws:s3:::fishbucket",
+			typ:     "com.amazonaws.s3:ObjectCreated:Put",
+		},
+		"ceph source scheme": {
+			profile: ProfileAWSS3,
+			scheme:  SourceSchemeCeph,
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.903595.14d2-a1-a",
+			source:  "ceph://ceph-eu-1/tenantA/fishbucket",
+			typ:     "com.amazonaws.s3.ObjectCreated:Put",
+		},
+		"concatenation source scheme": {
+			profile: ProfileAWSS3,
+			scheme:  SourceSchemeConcatenation,
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.903595.14d2-a1-a",
+			source:  "ceph:s3.tenantA.fishbucket",
+			typ:     "com.amazonaws.s3.ObjectCreated:Put",
+		},
+		"bucket source over scheme": {
+			profile: ProfileCeph,
+			sources: map[string]string{"fishbucket": "https://storage.example.com/fishbucket"},
+			scheme:  SourceSchemeCeph,
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.90359514d2-a1-a",
+			source:  "https://storage.example.com/fishbucket",
+			typ:     "com.amazonaws.s3:ObjectCreated:Put",
+		},
+		"ceph profile type prefix": {
+			profile: ProfileCeph,
+			prefix:  "com.example.storage.",
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.90359514d2-a1-a",
+			source:  "ceph:s3.tenantA.fishbucket",
+			typ:     "com.example.storage.s3:ObjectCreated:Put",
+		},
+		"aws-s3 profile type prefix": {
+			profile: ProfileAWSS3,
+			prefix:  "com.example.storage.",
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.903595.14d2-a1-a",
+			source:  "arn:aws:s3:::fishbucket",
+			typ:     "com.example.storage.ObjectCreated:Put",
+		},
+		"other bucket source": {
+			profile: ProfileCeph,
+			sources: map[string]string{"catbucket": "https://storage.example.com/catbucket"},
+			id:      "503a4c37-85eb-47cd-8681-2817e80b4281.5330.90359514d2-a1-a",
+			source:  "ceph:s3.tenantA.fishbucket",
+			typ:     "com.amazonaws.s3:ObjectCreated:Put",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			c := Converter{
+				Profile:      tc.profile,
+				TypePrefix:   tc.prefix,
+				Sources:      tc.sources,
+				SourceScheme: tc.scheme,
+				Cluster:      "ceph-eu-1",
+			}
+			event, err := c.ToCloudEvent(record1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.ID() != tc.id {
+				t.Errorf("Unexpected id: got %q, want %q", event.ID(), tc.id)
+			}
+			if event.Source() != tc.source {
+				t.Errorf("Unexpected source: got %q, want %q", event.Source(), tc.source)
+			}
+			if event.Type() != tc.typ {
+				t.Errorf("Unexpected type: got %q, want %q", event.Type(), tc.typ)
+			}
+			if event.Subject() != record1.S3.Object.Key {
+				t.Errorf("Unexpected subject: got %q, want %q", event.Subject(), record1.S3.Object.Key)
+			}
+		})
+	}
+}
+
+func TestToCloudEventPayload(t *testing.T) {
+	testCases := map[string]struct {
+		payload string
+		key     func(t *testing.T, data []byte) string
+	}{
+		"record": {
+			payload: PayloadRecord,
+			key: func(t *testing.T, data []byte) string {
+				var record ceph.BucketNotification
+				if err := json.Unmarshal(data, &record); err != nil {
+					t.Fatal(err)
+				}
+				return record.S3.Object.Key
+			},
+		},
+		"record envelope": {
+			payload: PayloadRecordEnvelope,
+			key: func(t *testing.T, data []byte) string {
+				var envelope ceph.BucketNotifications
+				if err := json.Unmarshal(data, &envelope); err != nil {
+					t.Fatal(err)
+				}
+				if len(envelope.Records) != 1 {
+					t.Fatalf("Unexpected number of records: %d", len(envelope.Records))
+				}
+				return envelope.Records[0].S3.Object.Key
+			},
+		},
+		"flat": {
+			payload: PayloadFlat,
+			key: func(t *testing.T, data []byte) string {
+				var flat ceph.FlatNotification
+				if err := json.Unmarshal(data, &flat); err != nil {
+					t.Fatal(err)
+				}
+				if flat.Metadata["x-amz-meta-meta1"] != "This is my metadata value" {
+					t.Errorf("Unexpected metadata: %v", flat.Metadata)
+				}
+				return flat.Key
+			},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			c := Converter{
+				Profile: ProfileCeph,
+				Payload: tc.payload,
+			}
+			event, err := c.ToCloudEvent(record1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key := tc.key(t, event.Data()); key != record1.S3.Object.Key {
+				t.Errorf("Unexpected key: got %q, want %q", key, record1.S3.Object.Key)
+			}
+		})
+	}
+}
+
+func TestToCloudEventProjection(t *testing.T) {
+	p, err := projection.Parse([]string{"s3.object.key", "eventName"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Converter{Projection: p}
+	if c.Raw() {
+		t.Error("A projecting converter cannot forward the records as is")
+	}
+	event, err := c.ToCloudEvent(record1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(event.Data()), `{"eventName":"s3:ObjectCreated:Put","s3":{"object":{"key":"fish9.jpg"}}}`; got != want {
+		t.Errorf("Unexpected data: got %s, want %s", got, want)
+	}
+	if event.DataSchema() != "" {
+		t.Errorf("Unexpected dataschema of projected data: %s", event.DataSchema())
+	}
+}
+
+func TestToCloudEventTransform(t *testing.T) {
+	p, err := projection.Parse([]string{"s3.bucket.name", "s3.object.key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := transform.Parse(`{"url": {{printf "s3://%s/%s" .s3.bucket.name .s3.object.key | json}}, "eventName": {{json .eventName}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Converter{Projection: p, Transform: tr}
+	if c.Raw() {
+		t.Error("A transforming converter cannot forward the records as is")
+	}
+	event, err := c.ToCloudEvent(record1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The template is executed on the projected data.
+	if got, want := string(event.Data()), `{"url":"s3://fishbucket/fish9.jpg","eventName":null}`; got != want {
+		t.Errorf("Unexpected data: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+const (
+	// EventNameCopy notifies the creation of an object by copy.
+	EventNameCopy = "s3:ObjectCreated:Copy"
+
+	// CopySourceMetadataKey is the user metadata recording the copy source
+	// of an object, in the format of the x-amz-copy-source header. S3 does
+	// not keep the copy source, so copying clients have to set it.
+	CopySourceMetadataKey = "x-amz-meta-copy-source"
+)
+
+// ParseCopySource parses a copy source in the format of the
+// x-amz-copy-source header, "[/]bucket/key[?versionId=version]", with the key
+// possibly URL-encoded.
+func ParseCopySource(s string) (*ceph.CopySourceSpec, error) {
+	path, query := s, ""
+	if i := strings.Index(s, "?"); i >= 0 {
+		path, query = s[:i], s[i+1:]
+	}
+	path, err := url.PathUnescape(strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid copy source %q", s)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return &ceph.CopySourceSpec{
+		Bucket:    parts[0],
+		Key:       parts[1],
+		VersionID: values.Get("versionId"),
+	}, nil
+}
+
+// copySourceFromMetadata returns the copy source recorded in the metadata of
+// a copied object, if any.
+func copySourceFromMetadata(record ceph.BucketNotification) (*ceph.CopySourceSpec, error) {
+	if record.EventName != EventNameCopy {
+		return nil, nil
+	}
+	for _, m := range record.S3.Object.Metadata {
+		if strings.EqualFold(m.Key, CopySourceMetadataKey) {
+			return ParseCopySource(m.Value)
+		}
+	}
+	return nil, nil
+}
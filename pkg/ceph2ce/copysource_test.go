@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+func TestParseCopySource(t *testing.T) {
+	testCases := map[string]struct {
+		copySource string
+		want       *ceph.CopySourceSpec
+		wantErr    bool
+	}{
+		"bucket and key": {
+			copySource: "fishbucket/dir/fish9.jpg",
+			want:       &ceph.CopySourceSpec{Bucket: "fishbucket", Key: "dir/fish9.jpg"},
+		},
+		"leading slash, encoded key and version": {
+			copySource: "/fishbucket/my%20fish.jpg?versionId=TLXfrjXbH2PtfsrE6vwyyM.Y2ElNkl8",
+			want:       &ceph.CopySourceSpec{Bucket: "fishbucket", Key: "my fish.jpg", VersionID: "TLXfrjXbH2PtfsrE6vwyyM.Y2ElNkl8"},
+		},
+		"missing key": {
+			copySource: "fishbucket",
+			wantErr:    true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			got, err := ParseCopySource(tc.copySource)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected copy source (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestCopySource(t *testing.T) {
+	record := record1
+	record.EventName = EventNameCopy
+	record.S3.Object.Metadata = append([]ceph.MetadataEntry{{Key: "X-Amz-Meta-Copy-Source", Value: "catbucket/cat1.jpg"}}, record.S3.Object.Metadata...)
+
+	event, err := Converter{CopySource: true, Payload: PayloadFlat}.ToCloudEvent(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flat ceph.FlatNotification
+	if err := json.Unmarshal(event.Data(), &flat); err != nil {
+		t.Fatal(err)
+	}
+	want := &ceph.CopySourceSpec{Bucket: "catbucket", Key: "cat1.jpg"}
+	if diff := cmp.Diff(want, flat.CopySource); diff != "" {
+		t.Errorf("Unexpected copy source (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"net/http"
+	"strings"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+// EncryptionExtension is the CloudEvent extension holding the type of the
+// server-side encryption of the object, when known.
+const EncryptionExtension = "encryption"
+
+const (
+	// EncryptionNone, EncryptionSSES3, EncryptionSSEKMS and EncryptionSSEC
+	// are the types of server-side encryption of objects.
+	EncryptionNone   = "none"
+	EncryptionSSES3  = "SSE-S3"
+	EncryptionSSEKMS = "SSE-KMS"
+	EncryptionSSEC   = "SSE-C"
+)
+
+// The headers, or metadata, telling the server-side encryption of objects.
+const (
+	sseHeader                  = "X-Amz-Server-Side-Encryption"
+	sseKMSKeyIDHeader          = "X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"
+	sseCustomerAlgorithmHeader = "X-Amz-Server-Side-Encryption-Customer-Algorithm"
+)
+
+// EncryptionFromHeader returns the server-side encryption of an object from
+// the headers of a HEAD of it.
+func EncryptionFromHeader(header http.Header) *ceph.EncryptionSpec {
+	switch sse := header.Get(sseHeader); {
+	case header.Get(sseCustomerAlgorithmHeader) != "":
+		return &ceph.EncryptionSpec{Type: EncryptionSSEC}
+	case sse == "aws:kms":
+		return &ceph.EncryptionSpec{Type: EncryptionSSEKMS, KMSKeyID: header.Get(sseKMSKeyIDHeader)}
+	case sse != "":
+		return &ceph.EncryptionSpec{Type: EncryptionSSES3}
+	default:
+		return &ceph.EncryptionSpec{Type: EncryptionNone}
+	}
+}
+
+// Encryption returns the server-side encryption of the object of a record,
+// resolved or carried by its metadata, or nil if unknown.
+func Encryption(record ceph.BucketNotification) *ceph.EncryptionSpec {
+	if e := record.S3.Object.Encryption; e != nil {
+		return e
+	}
+	header := make(http.Header)
+	for _, m := range record.S3.Object.Metadata {
+		if strings.HasPrefix(strings.ToLower(m.Key), "x-amz-server-side-encryption") {
+			header.Add(m.Key, m.Value)
+		}
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	return EncryptionFromHeader(header)
+}
+
+// Encrypted reports whether the object of a record is known to be encrypted
+// server-side. It can be used as a Filter.
+func Encrypted(record ceph.BucketNotification) bool {
+	e := Encryption(record)
+	return e != nil && e.Type != EncryptionNone
+}
+
+// Unencrypted reports whether the object of a record is known not to be
+// encrypted server-side. It can be used as a Filter.
+func Unencrypted(record ceph.BucketNotification) bool {
+	e := Encryption(record)
+	return e != nil && e.Type == EncryptionNone
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+func TestEncryptionFromHeader(t *testing.T) {
+	testCases := map[string]struct {
+		header http.Header
+		want   ceph.EncryptionSpec
+	}{
+		"none": {
+			header: http.Header{},
+			want:   ceph.EncryptionSpec{Type: EncryptionNone},
+		},
+		"sse-s3": {
+			header: http.Header{sseHeader: {"AES256"}},
+			want:   ceph.EncryptionSpec{Type: EncryptionSSES3},
+		},
+		"sse-kms": {
+			header: http.Header{sseHeader: {"aws:kms"}, sseKMSKeyIDHeader: {"testkey-1"}},
+			want:   ceph.EncryptionSpec{Type: EncryptionSSEKMS, KMSKeyID: "testkey-1"},
+		},
+		"sse-c": {
+			header: http.Header{sseCustomerAlgorithmHeader: {"AES256"}},
+			want:   ceph.EncryptionSpec{Type: EncryptionSSEC},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if diff := cmp.Diff(&tc.want, EncryptionFromHeader(tc.header)); diff != "" {
+				t.Errorf("Unexpected encryption (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	record := record1
+	if Encryption(record) != nil || Encrypted(record) || Unencrypted(record) {
+		t.Error("Expected the encryption of the record to be unknown")
+	}
+	event, err := ToCloudEvent(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := event.Extensions()[EncryptionExtension]; ok {
+		t.Errorf("Unexpected %s extension", EncryptionExtension)
+	}
+
+	record.S3.Object.Metadata = append(record.S3.Object.Metadata, ceph.MetadataEntry{Key: "x-amz-server-side-encryption", Value: "aws:kms"})
+	if !Encrypted(record) || Unencrypted(record) {
+		t.Error("Expected the record to be encrypted per its metadata")
+	}
+	event, err = ToCloudEvent(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := event.Extensions()[EncryptionExtension]; got != EncryptionSSEKMS {
+		t.Errorf("Unexpected %s extension: %v", EncryptionExtension, got)
+	}
+
+	record.S3.Object.Encryption = &ceph.EncryptionSpec{Type: EncryptionNone}
+	if Encrypted(record) || !Unencrypted(record) {
+		t.Error("Expected the resolved encryption to take precedence")
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"strings"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+type eventBridgeReason struct {
+	detailType   string
+	reason       string
+	deletionType string
+}
+
+// eventBridgeReasons maps Ceph event names to the detail-type, reason and
+// deletion-type of the equivalent EventBridge notification.
+var eventBridgeReasons = map[string]eventBridgeReason{
+	"s3:ObjectCreated:Put":                     {"Object Created", "PutObject", ""},
+	"s3:ObjectCreated:Post":                    {"Object Created", "POST Object", ""},
+	"s3:ObjectCreated:Copy":                    {"Object Created", "CopyObject", ""},
+	"s3:ObjectCreated:CompleteMultipartUpload": {"Object Created", "CompleteMultipartUpload", ""},
+	"s3:ObjectRemoved:Delete":                  {"Object Deleted", "DeleteObject", "Permanently Deleted"},
+	"s3:ObjectRemoved:DeleteMarkerCreated":     {"Object Deleted", "DeleteObject", "Delete Marker Created"},
+}
+
+// toEventBridge converts a bucket notification to the shape of an Amazon
+// EventBridge S3 event notification.
+func toEventBridge(notification ceph.BucketNotification) ceph.EventBridgeEvent {
+	r, ok := eventBridgeReasons[notification.EventName]
+	if !ok {
+		// Fall back to splitting unknown names such as "s3:ObjectCreated:Foo"
+		// into "Object Created" and "Foo".
+		parts := strings.Split(strings.TrimPrefix(notification.EventName, "s3:"), ":")
+		r.detailType = strings.Replace(parts[0], "Object", "Object ", 1)
+		r.reason = parts[len(parts)-1]
+	}
+
+	return ceph.EventBridgeEvent{
+		Version:    "0",
+		ID:         notification.EventID,
+		DetailType: r.detailType,
+		Source:     "aws.s3",
+		Account:    notification.S3.Bucket.OwnerIdentity.PrincipalID,
+		Time:       notification.EventTime,
+		Region:     notification.AwsRegion,
+		Resources:  []string{bucketARN(notification.S3.Bucket)},
+		Detail: ceph.EventBridgeDetail{
+			Version: "0",
+			Bucket: ceph.EventBridgeBucket{
+				Name: notification.S3.Bucket.Name,
+			},
+			Object: ceph.EventBridgeObject{
+				Key:       notification.S3.Object.Key,
+				Size:      notification.S3.Object.Size,
+				ETag:      notification.S3.Object.ETag,
+				VersionID: notification.S3.Object.VersionID,
+				Sequencer: notification.S3.Object.Sequencer,
+			},
+			RequestID:       notification.ResponseElements.XAmzRequestID,
+			Requester:       notification.UserIdentity.PrincipalID,
+			SourceIPAddress: notification.RequestParameters.SourceIPAddress,
+			Reason:          r.reason,
+			DeletionType:    r.deletionType,
+		},
+	}
+}
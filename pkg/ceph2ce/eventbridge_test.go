@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"testing"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+func TestToEventBridge(t *testing.T) {
+	testCases := map[string]struct {
+		eventName    string
+		detailType   string
+		reason       string
+		deletionType string
+	}{
+		"put": {
+			eventName:  "s3:ObjectCreated:Put",
+			detailType: "Object Created",
+			reason:     "PutObject",
+		},
+		"delete marker": {
+			eventName:    "s3:ObjectRemoved:DeleteMarkerCreated",
+			detailType:   "Object Deleted",
+			reason:       "DeleteObject",
+			deletionType: "Delete Marker Created",
+		},
+		"unknown event name": {
+			eventName:  "s3:ObjectRestore:Completed",
+			detailType: "Object Restore",
+			reason:     "Completed",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			notification := record1
+			notification.EventName = tc.eventName
+
+			got := toEventBridge(notification)
+			if got.DetailType != tc.detailType {
+				t.Errorf("Unexpected detail-type: got %q, want %q", got.DetailType, tc.detailType)
+			}
+			if got.Detail.Reason != tc.reason {
+				t.Errorf("Unexpected reason: got %q, want %q", got.Detail.Reason, tc.reason)
+			}
+			if got.Detail.DeletionType != tc.deletionType {
+				t.Errorf("Unexpected deletion-type: got %q, want %q", got.Detail.DeletionType, tc.deletionType)
+			}
+			want := ceph.EventBridgeObject{
+				Key:       "fish9.jpg",
+				Size:      1024,
+				ETag:      "37b51d194a7513e45b56f6524f2d51f2",
+				Sequencer: "F7E6D75DC742D108",
+			}
+			if got.Detail.Object != want {
+				t.Errorf("Unexpected object: got %+v, want %+v", got.Detail.Object, want)
+			}
+			if len(got.Resources) != 1 || got.Resources[0] != "arn:aws:s3:::fishbucket" {
+				t.Errorf("Unexpected resources: %v", got.Resources)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"encoding/json"
+	"errors"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"go.uber.org/zap"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+// cephEventTypes are the event types of the Ceph event names with the ceph
+// profile, computed once rather than per event.
+var cephEventTypes = func() map[string]string {
+	types := make(map[string]string, len(v1alpha1.CephEventNames))
+	for _, name := range v1alpha1.CephEventNames {
+		types[name] = EventType(ProfileCeph, name)
+	}
+	return types
+}()
+
+// eventType returns the event type of an event name, precomputed for the
+// Ceph event names with the ceph profile and its own prefix.
+func eventType(profile, prefix, eventName string) string {
+	if profile == ProfileCeph && prefix == "" {
+		if t, ok := cephEventTypes[eventName]; ok {
+			return t
+		}
+	}
+	return EventTypeWithPrefix(profile, prefix, eventName)
+}
+
+// RawRecord is a record of a Ceph notification along with its JSON as
+// pushed. Only the fields of the record the CloudEvent attributes are made
+// of are parsed.
+type RawRecord struct {
+	ceph.BucketNotification
+	JSON json.RawMessage
+}
+
+// recordAttributes are the fields of a record the CloudEvent attributes are
+// made of, parsed rather than the whole record.
+type recordAttributes struct {
+	EventSource      string                    `json:"eventSource"`
+	AwsRegion        string                    `json:"awsRegion"`
+	EventTime        string                    `json:"eventTime"`
+	EventName        string                    `json:"eventName"`
+	ResponseElements ceph.ResponseElementsSpec `json:"responseElements"`
+	S3               struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key       string `json:"key"`
+			ETag      string `json:"eTag"`
+			VersionID string `json:"versionId"`
+			Sequencer string `json:"sequencer"`
+		} `json:"object"`
+	} `json:"s3"`
+	EventID string `json:"eventId"`
+}
+
+// notification returns the record holding the attributes.
+func (a *recordAttributes) notification() ceph.BucketNotification {
+	var n ceph.BucketNotification
+	n.EventSource = a.EventSource
+	n.AwsRegion = a.AwsRegion
+	n.EventTime = a.EventTime
+	n.EventName = a.EventName
+	n.ResponseElements = a.ResponseElements
+	n.S3.Bucket.Name = a.S3.Bucket.Name
+	n.S3.Object.Key = a.S3.Object.Key
+	n.S3.Object.ETag = a.S3.Object.ETag
+	n.S3.Object.VersionID = a.S3.Object.VersionID
+	n.S3.Object.Sequencer = a.S3.Object.Sequencer
+	n.EventID = a.EventID
+	return n
+}
+
+// ParseRawNotifications parses a Ceph notification, keeping the JSON of its
+// records for ToCloudEventRaw. Only the records are validated.
+func ParseRawNotifications(body []byte) ([]RawRecord, error) {
+	raws, err := splitRecords(body)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]RawRecord, len(raws))
+	for i, raw := range raws {
+		var attributes recordAttributes
+		if err := json.Unmarshal(raw, &attributes); err != nil {
+			return nil, err
+		}
+		records[i] = RawRecord{BucketNotification: attributes.notification(), JSON: raw}
+	}
+	return records, nil
+}
+
+// errMalformed is returned for notifications whose structure is not a JSON
+// object, the records being validated when parsed.
+var errMalformed = errors.New("malformed notification")
+
+// splitRecords returns the JSON of the elements of the Records array of a
+// notification, without decoding them as encoding/json would to skip them.
+func splitRecords(body []byte) ([]json.RawMessage, error) {
+	i := skipSpace(body, 0)
+	if i == len(body) || body[i] != '{' {
+		return nil, errMalformed
+	}
+	var records []json.RawMessage
+	i = skipSpace(body, i+1)
+	if i < len(body) && body[i] == '}' {
+		return records, nil
+	}
+	for i < len(body) {
+		keyStart := i
+		if i = skipValue(body, i); i < 0 || body[keyStart] != '"' {
+			return nil, errMalformed
+		}
+		key := body[keyStart:i]
+		if i = skipSpace(body, i); i == len(body) || body[i] != ':' {
+			return nil, errMalformed
+		}
+		i = skipSpace(body, i+1)
+		if string(key) == `"Records"` && i < len(body) && body[i] == '[' {
+			records = records[:0]
+			i = skipSpace(body, i+1)
+			for i < len(body) && body[i] != ']' {
+				start := i
+				if i = skipValue(body, i); i < 0 {
+					return nil, errMalformed
+				}
+				records = append(records, body[start:i])
+				if i = skipSpace(body, i); i < len(body) && body[i] == ',' {
+					i = skipSpace(body, i+1)
+				}
+			}
+			if i == len(body) {
+				return nil, errMalformed
+			}
+			i++
+		} else if i = skipValue(body, i); i < 0 {
+			return nil, errMalformed
+		}
+		if i = skipSpace(body, i); i == len(body) {
+			return nil, errMalformed
+		}
+		switch body[i] {
+		case '}':
+			return records, nil
+		case ',':
+			i = skipSpace(body, i+1)
+		default:
+			return nil, errMalformed
+		}
+	}
+	return nil, errMalformed
+}
+
+// skipSpace returns the index of the first non-space byte of data from i.
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// skipValue returns the index following the JSON value of data at i, -1 if
+// it does not end.
+func skipValue(data []byte, i int) int {
+	depth := 0
+	for ; i < len(data); i++ {
+		switch data[i] {
+		case '"':
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			if i == len(data) {
+				return -1
+			}
+		case '{', '[':
+			depth++
+			continue
+		case '}', ']':
+			depth--
+			if depth < 0 {
+				return i
+			}
+		case ',', ' ', '\t', '\n', '\r':
+			if depth == 0 {
+				return i
+			}
+			continue
+		default:
+			continue
+		}
+		if depth == 0 {
+			return i + 1
+		}
+	}
+	if depth == 0 {
+		return i
+	}
+	return -1
+}
+
+// Raw reports whether the converter maps records with the default profile
+// and payload, without transforming them, so that ToCloudEventRaw applies.
+func (c Converter) Raw() bool {
+	return (c.Profile == "" || c.Profile == ProfileCeph) &&
+		(c.Payload == "" || c.Payload == PayloadRecord) &&
+		!c.DecodeKeys && !c.CopySource && len(c.MetadataExtensions) == 0 &&
+		c.Projection == nil && c.Transform == nil
+}
+
+// ToCloudEventRaw converts a record like ToCloudEvent, using its JSON as
+// pushed as event data rather than marshaling the record again. Unlike
+// ToCloudEvent, the data keeps the fields of the record the adapter does not
+// model. The converter must be Raw.
+func (c Converter) ToCloudEventRaw(record RawRecord) (cloudevents.Event, error) {
+	if !c.Raw() {
+		return cloudevents.Event{}, errors.New("the converter transforms records")
+	}
+	logger := c.Logger
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+
+	event := c.newEvent(logger, record.BucketNotification, ProfileCeph, record.S3.Object.Key)
+	// Set as is: SetData would flag bytes as binary data.
+	event.SetDataContentType(cloudevents.ApplicationJSON)
+	event.DataEncoded = record.JSON
+	if c.DataSchema != "" {
+		event.SetDataSchema(c.DataSchema)
+	}
+	return event, nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+func rawNotification(t testing.TB) []byte {
+	body, err := json.Marshal(ceph.BucketNotifications{Records: []ceph.BucketNotification{record1, record1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestToCloudEventRaw(t *testing.T) {
+	records, err := ParseRawNotifications(rawNotification(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Unexpected number of records: %d", len(records))
+	}
+
+	c := Converter{Sources: map[string]string{"fishbucket": "fish"}, DataSchema: "http://schemas.example.com/record"}
+	want, err := c.ToCloudEvent(record1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.ToCloudEventRaw(records[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want.Context, got.Context); diff != "" {
+		t.Errorf("Unexpected event context (-want, +got): %s", diff)
+	}
+	if !bytes.Equal(want.Data(), got.Data()) {
+		t.Errorf("Unexpected event data: got %s, want %s", got.Data(), want.Data())
+	}
+	if got.DataBase64 {
+		t.Error("Event data flagged as binary")
+	}
+}
+
+func TestToCloudEventRawUnknownFields(t *testing.T) {
+	records, err := ParseRawNotifications([]byte(`{"Records":[{"eventName":"s3:ObjectCreated:Put","s3":{"bucket":{"name":"fish"},"object":{"key":"a"}},"opaqueData":"x"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, err := Converter{}.ToCloudEventRaw(records[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(event.Data(), &data); err != nil {
+		t.Fatal(err)
+	}
+	if data["opaqueData"] != "x" {
+		t.Errorf("Unknown field not kept: %s", event.Data())
+	}
+	if event.Subject() != "a" || event.Type() != EventType(ProfileCeph, "s3:ObjectCreated:Put") {
+		t.Errorf("Unexpected event: %v", event)
+	}
+}
+
+func TestSplitRecords(t *testing.T) {
+	testCases := map[string]struct {
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		"compact": {
+			body: `{"Records":[{"a":1},{"b":"}"}]}`,
+			want: []string{`{"a":1}`, `{"b":"}"}`},
+		},
+		"indented": {
+			body: "{\n  \"Records\": [\n    {\"a\": [1, 2]} ,\n    {\"b\": \"\\\"]\"}\n  ]\n}\n",
+			want: []string{`{"a": [1, 2]}`, `{"b": "\"]"}`},
+		},
+		"other keys": {
+			body: `{"version":"1","nested":{"Records":[{"x":1}]},"Records":[{"a":1}],"tail":[true,null]}`,
+			want: []string{`{"a":1}`},
+		},
+		"last Records wins": {
+			body: `{"Records":[{"a":1}],"Records":[{"b":2}]}`,
+			want: []string{`{"b":2}`},
+		},
+		"no records": {
+			body: `{"Records":[]}`,
+		},
+		"empty object": {
+			body: `{}`,
+		},
+		"not an object": {
+			body:    `[{"a":1}]`,
+			wantErr: true,
+		},
+		"unterminated": {
+			body:    `{"Records":[{"a":1}`,
+			wantErr: true,
+		},
+		"unterminated string": {
+			body:    `{"Records":[{"a":"1}]}`,
+			wantErr: true,
+		},
+		"missing colon": {
+			body:    `{"Records" [{"a":1}]}`,
+			wantErr: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			records, err := splitRecords([]byte(tc.body))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := make([]string, 0, len(records))
+			for _, r := range records {
+				got = append(got, string(r))
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Unexpected records (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestParseRawNotificationsInvalidRecord(t *testing.T) {
+	if _, err := ParseRawNotifications([]byte(`{"Records":[{"eventName":}]}`)); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestConverterRaw(t *testing.T) {
+	testCases := map[string]struct {
+		converter Converter
+		want      bool
+	}{
+		"defaults":                {converter: Converter{}, want: true},
+		"ceph record":             {converter: Converter{Profile: ProfileCeph, Payload: PayloadRecord, Sources: map[string]string{"a": "b"}}, want: true},
+		"aws profile":             {converter: Converter{Profile: ProfileAWSS3}},
+		"record envelope payload": {converter: Converter{Payload: PayloadRecordEnvelope}},
+		"decoded keys":            {converter: Converter{DecodeKeys: true}},
+		"copy source":             {converter: Converter{CopySource: true}},
+		"eventbridge":             {converter: Converter{Profile: ProfileEventBridge}},
+		"cdevents":                {converter: Converter{Profile: ProfileCDEvents}},
+		"flat ceph payload":       {converter: Converter{Payload: PayloadFlat}},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if got := tc.converter.Raw(); got != tc.want {
+				t.Errorf("Raw() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func BenchmarkToCloudEvent(b *testing.B) {
+	body := rawNotification(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		records, err := ParseNotifications(FormatCeph, body)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, record := range records {
+			if _, err := ToCloudEvent(record); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkToCloudEventRaw(b *testing.B) {
+	body := rawNotification(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		records, err := ParseRawNotifications(body)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, record := range records {
+			if _, err := (Converter{}).ToCloudEventRaw(record); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
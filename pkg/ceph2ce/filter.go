@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"strings"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+// Filter reports whether a bucket notification record should be converted.
+type Filter func(record ceph.BucketNotification) bool
+
+// Match reports whether a record passes all the filters.
+func Match(record ceph.BucketNotification, filters ...Filter) bool {
+	for _, f := range filters {
+		if !f(record) {
+			return false
+		}
+	}
+	return true
+}
+
+// EventNames matches the records with one of the given event names. As in S3
+// notification configurations, a name ending with "*" matches all the names
+// it prefixes, e.g. "s3:ObjectCreated:*".
+func EventNames(names ...string) Filter {
+	return func(record ceph.BucketNotification) bool {
+		for _, name := range names {
+			if prefix := strings.TrimSuffix(name, "*"); prefix != name {
+				if strings.HasPrefix(record.EventName, prefix) {
+					return true
+				}
+			} else if record.EventName == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// KeyPrefix matches the records whose object key starts with prefix.
+func KeyPrefix(prefix string) Filter {
+	return func(record ceph.BucketNotification) bool {
+		return strings.HasPrefix(record.S3.Object.Key, prefix)
+	}
+}
+
+// KeySuffix matches the records whose object key ends with suffix.
+func KeySuffix(suffix string) Filter {
+	return func(record ceph.BucketNotification) bool {
+		return strings.HasSuffix(record.S3.Object.Key, suffix)
+	}
+}
+
+// Buckets matches the records of the given buckets.
+func Buckets(names ...string) Filter {
+	return func(record ceph.BucketNotification) bool {
+		for _, name := range names {
+			if record.S3.Bucket.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+}
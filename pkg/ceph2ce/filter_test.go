@@ -0,0 +1,67 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	testCases := map[string]struct {
+		filters []Filter
+		want    bool
+	}{
+		"no filter": {
+			want: true,
+		},
+		"event name": {
+			filters: []Filter{EventNames("s3:ObjectRemoved:Delete", "s3:ObjectCreated:Put")},
+			want:    true,
+		},
+		"event name wildcard": {
+			filters: []Filter{EventNames("s3:ObjectCreated:*")},
+			want:    true,
+		},
+		"other event name": {
+			filters: []Filter{EventNames("s3:ObjectRemoved:*")},
+			want:    false,
+		},
+		"key prefix and suffix": {
+			filters: []Filter{KeyPrefix("fish"), KeySuffix(".jpg")},
+			want:    true,
+		},
+		"other key suffix": {
+			filters: []Filter{KeyPrefix("fish"), KeySuffix(".png")},
+			want:    false,
+		},
+		"bucket": {
+			filters: []Filter{Buckets("catbucket", "fishbucket")},
+			want:    true,
+		},
+		"other bucket": {
+			filters: []Filter{Buckets("catbucket")},
+			want:    false,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if got := Match(record1, tc.filters...); got != tc.want {
+				t.Errorf("Unexpected match: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+// genericTimeLayouts are the event time layouts accepted from S3-compatible
+// notifiers besides RFC 3339.
+var genericTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+// parseGeneric leniently parses the notifications of S3-compatible
+// notifiers. The body may hold a Records array, an array of records or a
+// single record, and the records may miss fields or type some differently.
+func parseGeneric(body []byte) ([]ceph.BucketNotification, error) {
+	raws, err := genericRecords(body)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]ceph.BucketNotification, 0, len(raws))
+	for _, raw := range raws {
+		normalizeGeneric(raw)
+		b, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		var record ceph.BucketNotification
+		if err := json.Unmarshal(b, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// genericRecords returns the raw records of a body.
+func genericRecords(body []byte) ([]map[string]interface{}, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var records []map[string]interface{}
+		err := json.Unmarshal(body, &records)
+		return records, err
+	}
+
+	var notification map[string]interface{}
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, err
+	}
+	if value, ok := lookup(notification, "Records"); ok {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.New("records are not an array")
+		}
+		records := make([]map[string]interface{}, 0, len(list))
+		for _, item := range list {
+			record, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("record is not an object")
+			}
+			records = append(records, record)
+		}
+		return records, nil
+	}
+	if _, ok := lookup(notification, "eventName"); ok {
+		return []map[string]interface{}{notification}, nil
+	}
+	return nil, nil
+}
+
+// normalizeGeneric turns the fields of a raw record that S3-compatible
+// notifiers type differently into the types of the RGW model.
+func normalizeGeneric(record map[string]interface{}) {
+	if value, ok := lookup(record, "eventTime"); ok {
+		if t, ok := genericTime(value); ok {
+			record["eventTime"] = t.UTC().Format(time.RFC3339Nano)
+		}
+	}
+
+	s3, _ := lookupObject(record, "s3")
+	object, _ := lookupObject(s3, "object")
+	if value, ok := lookup(object, "size"); ok {
+		object["size"] = genericSize(value)
+	}
+	for _, name := range []string{"key", "eTag", "versionId", "sequencer"} {
+		if value, ok := lookup(object, name); ok {
+			if _, ok := value.(string); !ok {
+				object[name] = stringify(value)
+			}
+		}
+	}
+}
+
+// genericTime parses an event time as RFC 3339, one of the other accepted
+// layouts, or Unix seconds or milliseconds.
+func genericTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		for _, layout := range genericTimeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return unixTime(n), true
+		}
+	case float64:
+		return unixTime(v), true
+	}
+	return time.Time{}, false
+}
+
+// unixTime returns the time of Unix seconds, or of milliseconds for values
+// too large to be seconds, to the microsecond as floats are not more precise.
+func unixTime(n float64) time.Time {
+	if n > 1e11 {
+		return time.Unix(0, int64(n*float64(time.Millisecond))).Round(time.Microsecond)
+	}
+	sec, frac := math.Modf(n)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))).Round(time.Microsecond)
+}
+
+// genericSize returns a size sent as number or string as a number, 0 if not
+// a size.
+func genericSize(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		if v >= 0 {
+			return math.Trunc(v)
+		}
+	case string:
+		if n, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64); err == nil {
+			return float64(n)
+		}
+	}
+	return 0
+}
+
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// lookup returns the field of an object, matching its name case
+// insensitively like encoding/json.
+func lookup(object map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	for k, value := range object {
+		if strings.EqualFold(k, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func lookupObject(object map[string]interface{}, name string) (map[string]interface{}, bool) {
+	value, _ := lookup(object, name)
+	o, ok := value.(map[string]interface{})
+	return o, ok
+}
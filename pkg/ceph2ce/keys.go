@@ -0,0 +1,52 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+// RawKeyExtension is the CloudEvent extension holding the object key as sent
+// by Ceph when it differs from the decoded key.
+const RawKeyExtension = "rawkey"
+
+// ObjectKey returns the object key of a record, URL-decoded if DecodeKeys is
+// set and the key decodes.
+func (c Converter) ObjectKey(record ceph.BucketNotification) string {
+	if !c.DecodeKeys {
+		return record.S3.Object.Key
+	}
+	key, _ := decodeKey(record.S3.Object.Key)
+	return key
+}
+
+// decodeKey URL-decodes an object key, turning "+" into spaces, and checks
+// that the result is valid UTF-8.
+func decodeKey(key string) (string, error) {
+	decoded, err := url.QueryUnescape(key)
+	if err != nil {
+		return key, err
+	}
+	if !utf8.ValidString(decoded) {
+		return key, fmt.Errorf("decoded key %q is not valid UTF-8", decoded)
+	}
+	return decoded, nil
+}
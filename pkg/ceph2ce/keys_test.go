@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"testing"
+)
+
+func TestDecodeKeys(t *testing.T) {
+	testCases := map[string]struct {
+		key    string
+		want   string
+		rawKey interface{}
+	}{
+		"plain key": {
+			key:  "fish9.jpg",
+			want: "fish9.jpg",
+		},
+		"plus as space": {
+			key:    "my+fish.jpg",
+			want:   "my fish.jpg",
+			rawKey: "my+fish.jpg",
+		},
+		"percent encoded": {
+			key:    "dir%2Fmy%20fish%C3%A9.jpg",
+			want:   "dir/my fishé.jpg",
+			rawKey: "dir%2Fmy%20fish%C3%A9.jpg",
+		},
+		"invalid escape": {
+			key:  "fish%zz.jpg",
+			want: "fish%zz.jpg",
+		},
+		"invalid utf-8": {
+			key:  "fish%ff.jpg",
+			want: "fish%ff.jpg",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			c := Converter{
+				DecodeKeys: true,
+			}
+			notification := record1
+			notification.S3.Object.Key = tc.key
+
+			event, err := c.ToCloudEvent(notification)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Subject() != tc.want {
+				t.Errorf("Unexpected subject: got %q, want %q", event.Subject(), tc.want)
+			}
+			if got := event.Extensions()[RawKeyExtension]; got != tc.rawKey {
+				t.Errorf("Unexpected raw key: got %v, want %v", got, tc.rawKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+// MetadataExtensionName returns the CloudEvent extension the given user
+// metadata, e.g. "x-amz-meta-team", is promoted to, e.g. "team".
+func MetadataExtensionName(key string) string {
+	return v1alpha1.MetadataExtensionName(key)
+}
+
+// MetadataKey returns the full key of user metadata, with the x-amz-meta-
+// prefix, lowercased as in notifications.
+func MetadataKey(key string) string {
+	key = strings.ToLower(key)
+	if !strings.HasPrefix(key, v1alpha1.UserMetadataPrefix) {
+		key = v1alpha1.UserMetadataPrefix + key
+	}
+	return key
+}
+
+// setMetadataExtensions promotes the given user metadata of a record to
+// extensions of event.
+func setMetadataExtensions(event *cloudevents.Event, record ceph.BucketNotification, keys []string) {
+	for _, key := range keys {
+		name := MetadataExtensionName(key)
+		if name == "" {
+			continue
+		}
+		key = MetadataKey(key)
+		for _, m := range record.S3.Object.Metadata {
+			if strings.EqualFold(m.Key, key) {
+				event.SetExtension(name, m.Value)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMetadataExtensionName(t *testing.T) {
+	for key, want := range map[string]string{
+		"x-amz-meta-team":      "team",
+		"X-Amz-Meta-Cost_Unit": "costunit",
+		"owner":                "owner",
+		"x-amz-meta-":          "",
+		"x-amz-meta-é":         "",
+	} {
+		if got := MetadataExtensionName(key); got != want {
+			t.Errorf("MetadataExtensionName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestMetadataExtensions(t *testing.T) {
+	c := Converter{MetadataExtensions: []string{"x-amz-meta-meta1", "META2", "x-amz-meta-missing"}}
+	if c.Raw() {
+		t.Error("Converter promoting metadata takes the fast path")
+	}
+	event, err := c.ToCloudEvent(record1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"meta1": "This is my metadata value",
+		"meta2": "This is another metadata value",
+	}
+	got := event.Extensions()
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			delete(got, name)
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected extensions (-want, +got): %s", diff)
+	}
+}
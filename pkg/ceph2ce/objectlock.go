@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+const (
+	// RetentionModeExtension is the CloudEvent extension holding the
+	// retention mode of the object, "GOVERNANCE" or "COMPLIANCE", and
+	// RetainUntilExtension the date until which it is retained, when it is.
+	RetentionModeExtension = "retentionmode"
+	RetainUntilExtension   = "retainuntil"
+
+	// LegalHoldExtension is the CloudEvent extension telling whether the
+	// object is under legal hold, when resolved.
+	LegalHoldExtension = "legalhold"
+)
+
+const (
+	objectLockModeHeader        = "X-Amz-Object-Lock-Mode"
+	objectLockRetainUntilHeader = "X-Amz-Object-Lock-Retain-Until-Date"
+	objectLockLegalHoldHeader   = "X-Amz-Object-Lock-Legal-Hold"
+)
+
+// ObjectLockFromHeader returns the retention and legal hold of an object
+// from the headers of a HEAD of it.
+func ObjectLockFromHeader(header http.Header) *ceph.ObjectLockSpec {
+	return &ceph.ObjectLockSpec{
+		Mode:            header.Get(objectLockModeHeader),
+		RetainUntilDate: header.Get(objectLockRetainUntilHeader),
+		LegalHold:       strings.EqualFold(header.Get(objectLockLegalHoldHeader), "ON"),
+	}
+}
+
+// ObjectLock returns the retention and legal hold of the object of a
+// record, resolved or carried by its metadata, or nil if unknown.
+func ObjectLock(record ceph.BucketNotification) *ceph.ObjectLockSpec {
+	if l := record.S3.Object.ObjectLock; l != nil {
+		return l
+	}
+	header := make(http.Header)
+	for _, m := range record.S3.Object.Metadata {
+		if strings.HasPrefix(strings.ToLower(m.Key), "x-amz-object-lock-") {
+			header.Add(m.Key, m.Value)
+		}
+	}
+	if len(header) == 0 {
+		return nil
+	}
+	return ObjectLockFromHeader(header)
+}
+
+// Locked reports whether the object of a record is known to be retained at
+// now or under legal hold, and so cannot be deleted or overwritten.
+func Locked(record ceph.BucketNotification, now time.Time) bool {
+	l := ObjectLock(record)
+	if l == nil {
+		return false
+	}
+	if l.LegalHold {
+		return true
+	}
+	until, err := time.Parse(time.RFC3339, l.RetainUntilDate)
+	return l.Mode != "" && err == nil && until.After(now)
+}
+
+func setObjectLockExtensions(event *cloudevents.Event, record ceph.BucketNotification) {
+	l := ObjectLock(record)
+	if l == nil {
+		return
+	}
+	if l.Mode != "" {
+		event.SetExtension(RetentionModeExtension, l.Mode)
+	}
+	if l.RetainUntilDate != "" {
+		event.SetExtension(RetainUntilExtension, l.RetainUntilDate)
+	}
+	event.SetExtension(LegalHoldExtension, l.LegalHold)
+}
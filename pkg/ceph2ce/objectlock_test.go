@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+func TestObjectLock(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		header     http.Header
+		metadata   []ceph.MetadataEntry
+		locked     bool
+		extensions map[string]interface{}
+	}{
+		"unknown": {
+			extensions: map[string]interface{}{},
+		},
+		"not locked": {
+			header:     http.Header{},
+			extensions: map[string]interface{}{LegalHoldExtension: false},
+		},
+		"retained": {
+			header: http.Header{
+				objectLockModeHeader:        {"COMPLIANCE"},
+				objectLockRetainUntilHeader: {"2030-01-01T00:00:00Z"},
+			},
+			locked: true,
+			extensions: map[string]interface{}{
+				RetentionModeExtension: "COMPLIANCE",
+				RetainUntilExtension:   "2030-01-01T00:00:00Z",
+				LegalHoldExtension:     false,
+			},
+		},
+		"retention expired": {
+			header: http.Header{
+				objectLockModeHeader:        {"GOVERNANCE"},
+				objectLockRetainUntilHeader: {"2021-01-01T00:00:00Z"},
+			},
+			extensions: map[string]interface{}{
+				RetentionModeExtension: "GOVERNANCE",
+				RetainUntilExtension:   "2021-01-01T00:00:00Z",
+				LegalHoldExtension:     false,
+			},
+		},
+		"legal hold in metadata": {
+			metadata: []ceph.MetadataEntry{{Key: "x-amz-object-lock-legal-hold", Value: "ON"}},
+			locked:   true,
+			extensions: map[string]interface{}{
+				LegalHoldExtension: true,
+			},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			record := record1
+			record.S3.Object.Metadata = tc.metadata
+			if tc.header != nil {
+				record.S3.Object.ObjectLock = ObjectLockFromHeader(tc.header)
+			}
+			if got := Locked(record, now); got != tc.locked {
+				t.Errorf("Locked() = %t, want %t", got, tc.locked)
+			}
+			event, err := ToCloudEvent(record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{RetentionModeExtension, RetainUntilExtension, LegalHoldExtension} {
+				got, ok := event.Extensions()[name]
+				want, wantOK := tc.extensions[name]
+				if ok != wantOK || got != want {
+					t.Errorf("Unexpected %s extension: got %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
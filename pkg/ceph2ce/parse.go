@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	// FormatCeph, FormatMinIO, FormatGeneric and FormatSwift select how
+	// notifications are parsed, see the CephSource format.
+	FormatCeph    = v1alpha1.FormatCeph
+	FormatMinIO   = v1alpha1.FormatMinIO
+	FormatGeneric = v1alpha1.FormatGeneric
+	FormatSwift   = v1alpha1.FormatSwift
+)
+
+// ParseNotifications parses the bucket notification records of a pushed
+// body in the given format. Defaults to FormatCeph.
+func ParseNotifications(format string, body []byte) ([]ceph.BucketNotification, error) {
+	switch format {
+	case "", FormatCeph:
+		var notifications ceph.BucketNotifications
+		if err := json.Unmarshal(body, &notifications); err != nil {
+			return nil, err
+		}
+		return notifications.Records, nil
+	case FormatMinIO:
+		return parseMinIO(body)
+	case FormatGeneric:
+		return parseGeneric(body)
+	case FormatSwift:
+		return parseSwift(body)
+	default:
+		return nil, fmt.Errorf("unknown notification format %q", format)
+	}
+}
+
+// minioNotification holds the fields of MinIO webhook notifications that
+// differ from the RGW ones. MinIO repeats the EventName and "bucket/key" Key
+// of the record at the top level, and sends the object metadata as a
+// userMetadata map.
+type minioNotification struct {
+	EventName string `json:"EventName"`
+	Records   []struct {
+		S3 struct {
+			Object struct {
+				UserMetadata map[string]string `json:"userMetadata"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+// parseMinIO parses a MinIO webhook notification into the records of the
+// RGW model.
+func parseMinIO(body []byte) ([]ceph.BucketNotification, error) {
+	var notifications ceph.BucketNotifications
+	if err := json.Unmarshal(body, &notifications); err != nil {
+		return nil, err
+	}
+	var minio minioNotification
+	if err := json.Unmarshal(body, &minio); err != nil {
+		return nil, err
+	}
+
+	for i := range notifications.Records {
+		if i >= len(minio.Records) {
+			break
+		}
+		record := &notifications.Records[i]
+		if record.EventName == "" {
+			record.EventName = minio.EventName
+		}
+		userMetadata := minio.Records[i].S3.Object.UserMetadata
+		if len(userMetadata) == 0 || len(record.S3.Object.Metadata) > 0 {
+			continue
+		}
+		metadata := make([]ceph.MetadataEntry, 0, len(userMetadata))
+		for k, v := range userMetadata {
+			metadata = append(metadata, ceph.MetadataEntry{Key: strings.ToLower(k), Value: v})
+		}
+		sort.Slice(metadata, func(i, j int) bool { return metadata[i].Key < metadata[j].Key })
+		record.S3.Object.Metadata = metadata
+	}
+	return notifications.Records, nil
+}
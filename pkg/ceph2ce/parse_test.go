@@ -0,0 +1,202 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+const minioBody = `{
+  "EventName": "s3:ObjectCreated:Put",
+  "Key": "fishbucket/fish9.jpg",
+  "Records": [{
+    "eventVersion": "2.0",
+    "eventSource": "minio:s3",
+    "awsRegion": "",
+    "eventTime": "2021-11-02T10:12:51.112Z",
+    "eventName": "s3:ObjectCreated:Put",
+    "userIdentity": {"principalId": "minioadmin"},
+    "requestParameters": {"principalId": "minioadmin", "region": "", "sourceIPAddress": "10.0.0.1"},
+    "responseElements": {"content-length": "0", "x-amz-request-id": "16B3A8D6A9A11D8E", "x-minio-deployment-id": "8c9b0f6e", "x-minio-origin-endpoint": "http://10.0.0.2:9000"},
+    "s3": {
+      "s3SchemaVersion": "1.0",
+      "configurationId": "Config",
+      "bucket": {"name": "fishbucket", "ownerIdentity": {"principalId": "minioadmin"}, "arn": "arn:aws:s3:::fishbucket"},
+      "object": {
+        "key": "fish9.jpg",
+        "size": 1024,
+        "eTag": "37b51d194a7513e45b56f6524f2d51f2",
+        "contentType": "image/jpeg",
+        "userMetadata": {"X-Amz-Meta-Copy-Source": "/catbucket/cat1.jpg", "content-type": "image/jpeg"},
+        "sequencer": "16B3A8D6A9B3D6C2"
+      }
+    },
+    "source": {"host": "10.0.0.1", "port": "", "userAgent": "MinIO (linux; amd64) minio-go/v7.0.15"}
+  }]
+}`
+
+func TestParseNotificationsMinIO(t *testing.T) {
+	records, err := ParseNotifications(FormatMinIO, []byte(minioBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Unexpected number of records: %d", len(records))
+	}
+	record := records[0]
+	if record.EventName != "s3:ObjectCreated:Put" || record.S3.Bucket.Name != "fishbucket" || record.S3.Object.Key != "fish9.jpg" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+	want := []ceph.MetadataEntry{
+		{Key: "content-type", Value: "image/jpeg"},
+		{Key: "x-amz-meta-copy-source", Value: "/catbucket/cat1.jpg"},
+	}
+	if diff := cmp.Diff(want, record.S3.Object.Metadata); diff != "" {
+		t.Errorf("Unexpected metadata (-want, +got): %s", diff)
+	}
+}
+
+func TestParseNotificationsCeph(t *testing.T) {
+	body, err := json.Marshal(ceph.BucketNotifications{Records: []ceph.BucketNotification{record1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := ParseNotifications("", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]ceph.BucketNotification{record1}, records); diff != "" {
+		t.Errorf("Unexpected records (-want, +got): %s", diff)
+	}
+}
+
+func TestParseNotificationsGeneric(t *testing.T) {
+	testCases := map[string]string{
+		"records": `{"Records": [{"eventName": "s3:ObjectCreated:Put", "eventTime": "2021-11-02 10:12:51", "s3": {"bucket": {"name": "fishbucket"}, "object": {"key": "fish9.jpg", "size": "1024"}}}]}`,
+		"array":   `[{"eventName": "s3:ObjectCreated:Put", "eventTime": 1635847971, "s3": {"bucket": {"name": "fishbucket"}, "object": {"key": "fish9.jpg", "size": 1024}}}]`,
+		"single":  `{"eventName": "s3:ObjectCreated:Put", "eventTime": 1635847971000, "extra": true, "s3": {"bucket": {"name": "fishbucket"}, "object": {"key": "fish9.jpg", "size": 1024.0}}}`,
+	}
+	for n, body := range testCases {
+		t.Run(n, func(t *testing.T) {
+			records, err := ParseNotifications(FormatGeneric, []byte(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 1 {
+				t.Fatalf("Unexpected number of records: %d", len(records))
+			}
+			record := records[0]
+			if record.EventName != "s3:ObjectCreated:Put" || record.S3.Bucket.Name != "fishbucket" || record.S3.Object.Key != "fish9.jpg" {
+				t.Errorf("Unexpected record: %+v", record)
+			}
+			if record.S3.Object.Size != 1024 {
+				t.Errorf("Unexpected size: %d", record.S3.Object.Size)
+			}
+			if record.EventTime != "2021-11-02T10:12:51Z" {
+				t.Errorf("Unexpected event time: %s", record.EventTime)
+			}
+
+			event, err := ToCloudEvent(record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := event.Validate(); err != nil {
+				t.Errorf("Event should be valid without response elements: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseNotificationsGenericInvalid(t *testing.T) {
+	for _, body := range []string{`{"Records": {}}`, `{"Records": [1]}`, `"fish"`} {
+		if _, err := ParseNotifications(FormatGeneric, []byte(body)); err == nil {
+			t.Errorf("Parsing %s should fail", body)
+		}
+	}
+}
+
+func TestParseNotificationsUnknownFormat(t *testing.T) {
+	if _, err := ParseNotifications("gcs", []byte(`{"Records":[]}`)); err == nil {
+		t.Error("Unknown format should fail")
+	}
+}
+
+const swiftBody = `{
+  "notifications": [{
+    "account": "AUTH_tenantA",
+    "container": "fishbucket",
+    "object": "fish9.jpg",
+    "method": "PUT",
+    "timestamp": "1635847971.11200",
+    "etag": "37b51d194a7513e45b56f6524f2d51f2",
+    "bytes": 1024,
+    "transaction_id": "tx16b3a8d6a9a11d8e-00618110a3",
+    "region": "us-east-1",
+    "metadata": {"X-Object-Meta-Color": "red"}
+  }, {
+    "account": "AUTH_tenantA",
+    "container": "fishbucket",
+    "object": "fish1.jpg",
+    "method": "DELETE",
+    "timestamp": "1635847972.00000",
+    "transaction_id": "tx26b3a8d6a9a11d8e-00618110a4",
+    "region": "us-east-1"
+  }]
+}`
+
+func TestParseNotificationsSwift(t *testing.T) {
+	records, err := ParseNotifications(FormatSwift, []byte(swiftBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Unexpected number of records: %d", len(records))
+	}
+
+	record := records[0]
+	if record.EventName != "s3:ObjectCreated:Put" || record.S3.Bucket.Name != "fishbucket" || record.S3.Object.Key != "fish9.jpg" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+	if record.EventTime != "2021-11-02T10:12:51.112Z" {
+		t.Errorf("Unexpected event time: %s", record.EventTime)
+	}
+	want := []ceph.MetadataEntry{{Key: "x-amz-meta-color", Value: "red"}}
+	if diff := cmp.Diff(want, record.S3.Object.Metadata); diff != "" {
+		t.Errorf("Unexpected metadata (-want, +got): %s", diff)
+	}
+	if records[1].EventName != "s3:ObjectRemoved:Delete" {
+		t.Errorf("Unexpected event name: %s", records[1].EventName)
+	}
+
+	event, err := ToCloudEvent(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.ID() != "tx16b3a8d6a9a11d8e-00618110a3" || event.Source() != "ceph:swift.us-east-1.fishbucket" {
+		t.Errorf("Unexpected event: %v", event)
+	}
+}
+
+func TestParseNotificationsSwiftUnknownMethod(t *testing.T) {
+	if _, err := ParseNotifications(FormatSwift, []byte(`[{"container": "fishbucket", "object": "fish9.jpg", "method": "HEAD"}]`)); err == nil {
+		t.Error("Unknown method should fail")
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+const (
+	// SchemaPath is the path the receive adapter serves the JSON schemas of
+	// the event data under, followed by the schema name and ".json".
+	SchemaPath = "/schemas/"
+
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+)
+
+// dataTypes are the Go types of the event data, keyed by schema name.
+var dataTypes = map[string]reflect.Type{
+	PayloadRecord:         reflect.TypeOf(ceph.BucketNotification{}),
+	PayloadRecordEnvelope: reflect.TypeOf(ceph.BucketNotifications{}),
+	PayloadFlat:           reflect.TypeOf(ceph.FlatNotification{}),
+	ProfileEventBridge:    reflect.TypeOf(ceph.EventBridgeEvent{}),
+	ProfileCDEvents:       reflect.TypeOf(ceph.CDEvent{}),
+}
+
+// SchemaName returns the name of the schema of the event data of the given
+// profile and payload.
+func SchemaName(profile, payload string) string {
+	switch {
+	case profile == ProfileEventBridge || profile == ProfileCDEvents:
+		return profile
+	case payload == PayloadRecordEnvelope || payload == PayloadFlat:
+		return payload
+	default:
+		return PayloadRecord
+	}
+}
+
+// Schema returns the JSON schema of the event data of the given schema name,
+// generated from its Go type, or nil if the name is unknown.
+func Schema(name string) []byte {
+	t, ok := dataTypes[name]
+	if !ok {
+		return nil
+	}
+	schema := jsonSchema(t)
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = t.Name()
+	b, _ := json.Marshal(schema)
+	return b
+}
+
+// jsonSchema returns the JSON schema of the JSON encoding of a Go type. The
+// fields of structs without omitempty are required.
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]interface{}, t.NumField())
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if f.PkgPath != "" || tag == "-" {
+				continue
+			}
+			name := f.Name
+			opts := strings.Split(tag, ",")
+			if opts[0] != "" {
+				name = opts[0]
+			}
+			properties[name] = jsonSchema(f.Type)
+			if !hasOption(opts[1:], "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	default:
+		// Any value, e.g. of an interface{} field.
+		return map[string]interface{}{}
+	}
+}
+
+func hasOption(opts []string, option string) bool {
+	for _, o := range opts {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
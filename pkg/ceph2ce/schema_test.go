@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSchemaName(t *testing.T) {
+	testCases := map[string]struct {
+		profile string
+		payload string
+		want    string
+	}{
+		"default":              {want: PayloadRecord},
+		"record envelope":      {payload: PayloadRecordEnvelope, want: PayloadRecordEnvelope},
+		"flat with aws-s3":     {profile: ProfileAWSS3, payload: PayloadFlat, want: PayloadFlat},
+		"eventbridge":          {profile: ProfileEventBridge, want: ProfileEventBridge},
+		"cdevents":             {profile: ProfileCDEvents, want: ProfileCDEvents},
+		"cdevents with record": {profile: ProfileCDEvents, payload: PayloadRecord, want: ProfileCDEvents},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if got := SchemaName(tc.profile, tc.payload); got != tc.want {
+				t.Errorf("Unexpected schema name: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	if Schema("nope") != nil {
+		t.Error("Unexpected schema of an unknown name")
+	}
+
+	var schema struct {
+		Schema     string `json:"$schema"`
+		Title      string `json:"title"`
+		Required   []string
+		Properties map[string]struct {
+			Type       string
+			Properties map[string]struct {
+				Type       string
+				Properties map[string]struct {
+					Type    string
+					Minimum *int
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(Schema(PayloadRecord), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Schema != jsonSchemaDraft || schema.Title != "BucketNotification" {
+		t.Errorf("Unexpected schema header: %q %q", schema.Schema, schema.Title)
+	}
+	if len(schema.Required) != len(schema.Properties) {
+		t.Errorf("Unexpected required fields: %v", schema.Required)
+	}
+	size := schema.Properties["s3"].Properties["object"].Properties["size"]
+	if size.Type != "integer" || size.Minimum == nil || *size.Minimum != 0 {
+		t.Errorf("Unexpected size schema: %+v", size)
+	}
+
+	// Optional fields are not required.
+	var flat struct{ Required []string }
+	if err := json.Unmarshal(Schema(PayloadFlat), &flat); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range flat.Required {
+		if r == "versionId" || r == "metadata" || r == "copySource" {
+			t.Errorf("Unexpected required field %q", r)
+		}
+	}
+}
+
+func TestDataSchemaAttribute(t *testing.T) {
+	event, err := Converter{DataSchema: "https://ceph.example.com/schemas/record.json"}.ToCloudEvent(record1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := event.DataSchema(); got != "https://ceph.example.com/schemas/record.json" {
+		t.Errorf("Unexpected dataschema: %q", got)
+	}
+
+	if event, _ = ToCloudEvent(record1); event.DataSchema() != "" {
+		t.Errorf("Unexpected dataschema: %q", event.DataSchema())
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"net/http"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+// StorageClassExtension is the CloudEvent extension holding the storage
+// class of the object, when resolved.
+const StorageClassExtension = "storageclass"
+
+const (
+	// StorageClassStandard is the storage class of objects stored without
+	// one, which S3 does not return.
+	StorageClassStandard = "STANDARD"
+
+	storageClassHeader = "X-Amz-Storage-Class"
+)
+
+// StorageClassFromHeader returns the storage class of an object from the
+// headers of a HEAD of it.
+func StorageClassFromHeader(header http.Header) string {
+	if class := header.Get(storageClassHeader); class != "" {
+		return class
+	}
+	return StorageClassStandard
+}
+
+// IsTransition reports whether a record notifies the transition of an object
+// to another storage class. It can be used as a Filter.
+func IsTransition(record ceph.BucketNotification) bool {
+	return v1alpha1.IsTransition(record.EventName)
+}
+
+// StorageClasses matches the records of objects stored in one of the given
+// storage classes. Records of objects of unknown storage class do not match.
+func StorageClasses(classes ...string) Filter {
+	return func(record ceph.BucketNotification) bool {
+		for _, class := range classes {
+			if record.S3.Object.StorageClass == class {
+				return true
+			}
+		}
+		return false
+	}
+}
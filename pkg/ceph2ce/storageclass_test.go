@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTransition(t *testing.T) {
+	record := record1
+	record.EventName = "s3:ObjectLifecycle:Transition:Current"
+	if !IsTransition(record) || IsTransition(record1) {
+		t.Error("Unexpected transition detection")
+	}
+	if Match(record, StorageClasses("GLACIER")) {
+		t.Error("Expected an unknown storage class not to match")
+	}
+
+	record.S3.Object.StorageClass = StorageClassFromHeader(http.Header{storageClassHeader: {"GLACIER"}})
+	if !Match(record, StorageClasses("STANDARD_IA", "GLACIER")) {
+		t.Error("Expected the target storage class to match")
+	}
+
+	for profile, want := range map[string]string{
+		ProfileCeph:        "com.amazonaws.s3:ObjectLifecycle:Transition:Current",
+		ProfileAWSS3:       "com.amazonaws.s3.ObjectLifecycle:Transition:Current",
+		ProfileCDEvents:    "com.amazonaws.s3:ObjectLifecycle:Transition:Current",
+		ProfileEventBridge: "com.amazonaws.s3:ObjectLifecycle:Transition:Current",
+	} {
+		converter := Converter{Profile: profile}
+		event, err := converter.ToCloudEvent(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Type() != want {
+			t.Errorf("Unexpected type with the %s profile: got %s, want %s", profile, event.Type(), want)
+		}
+		if got := event.Extensions()[StorageClassExtension]; got != "GLACIER" {
+			t.Errorf("Unexpected %s extension: %v", StorageClassExtension, got)
+		}
+	}
+
+	if class := StorageClassFromHeader(http.Header{}); class != StorageClassStandard {
+		t.Errorf("Unexpected default storage class: %s", class)
+	}
+}
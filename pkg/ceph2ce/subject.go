@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	// SubjectOverflowTruncate and SubjectOverflowHash select how subjects
+	// longer than the maximum length are shortened, see the CephSource
+	// subject.
+	SubjectOverflowTruncate = v1alpha1.SubjectOverflowTruncate
+	SubjectOverflowHash     = v1alpha1.SubjectOverflowHash
+)
+
+// subject returns the subject of the events of an object key, escaped and
+// shortened as configured.
+func (c Converter) subject(key string) string {
+	if c.EscapeSubject {
+		key = escapeSubject(key)
+	}
+	if c.MaxSubjectLength <= 0 || len(key) <= c.MaxSubjectLength {
+		return key
+	}
+	if c.SubjectOverflow == SubjectOverflowHash {
+		sum := sha256.Sum256([]byte(key))
+		hash := hex.EncodeToString(sum[:])
+		if len(hash) > c.MaxSubjectLength {
+			hash = hash[:c.MaxSubjectLength]
+		}
+		return hash
+	}
+	return truncateSubject(key, c.MaxSubjectLength, c.EscapeSubject)
+}
+
+// escapeSubject percent-encodes the bytes of a key other than the unreserved
+// characters of URLs (RFC 3986) and "/".
+func escapeSubject(key string) string {
+	const hexDigits = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0xF])
+		}
+	}
+	return b.String()
+}
+
+// truncateSubject returns the longest prefix of s of at most n bytes that
+// does not split a UTF-8 character, nor a percent-encoded byte if escaped.
+func truncateSubject(s string, n int, escaped bool) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	s = s[:n]
+	if i := strings.LastIndexByte(s, '%'); escaped && i >= 0 && i >= len(s)-2 {
+		s = s[:i]
+	}
+	return s
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSubject(t *testing.T) {
+	testCases := map[string]struct {
+		converter Converter
+		key       string
+		want      string
+	}{
+		"defaults": {
+			key:  "photos/été 2021/fish #9.jpg",
+			want: "photos/été 2021/fish #9.jpg",
+		},
+		"escape": {
+			converter: Converter{EscapeSubject: true},
+			key:       "photos/été 2021/fish #9.jpg",
+			want:      "photos/%C3%A9t%C3%A9%202021/fish%20%239.jpg",
+		},
+		"short enough": {
+			converter: Converter{MaxSubjectLength: 9},
+			key:       "fish9.jpg",
+			want:      "fish9.jpg",
+		},
+		"truncate": {
+			converter: Converter{MaxSubjectLength: 6},
+			key:       "photos/fish9.jpg",
+			want:      "photos",
+		},
+		"truncate within a character": {
+			converter: Converter{MaxSubjectLength: 8},
+			key:       "photos/été",
+			want:      "photos/",
+		},
+		"truncate within an escaped byte": {
+			converter: Converter{EscapeSubject: true, MaxSubjectLength: 9},
+			key:       "photos/été",
+			want:      "photos/",
+		},
+		"truncate an unescaped percent sign": {
+			converter: Converter{MaxSubjectLength: 9},
+			key:       "photos/%25",
+			want:      "photos/%2",
+		},
+		"hash": {
+			converter: Converter{MaxSubjectLength: 64, SubjectOverflow: SubjectOverflowHash},
+			key:       strings.Repeat("a", 65),
+			want:      "635361c48bb9eab14198e76ea8ab7f1a41685d6ad62aa9146d301d4f17eb0ae0",
+		},
+		"hash cut": {
+			converter: Converter{MaxSubjectLength: 8, SubjectOverflow: SubjectOverflowHash},
+			key:       strings.Repeat("a", 65),
+			want:      "635361c4",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			record := record1
+			record.S3.Object.Key = tc.key
+			event, err := tc.converter.ToCloudEvent(record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if event.Subject() != tc.want {
+				t.Errorf("Unexpected subject: got %q, want %q", event.Subject(), tc.want)
+			}
+			// The data keeps the full key.
+			var data struct {
+				S3 struct {
+					Object struct {
+						Key string `json:"key"`
+					} `json:"object"`
+				} `json:"s3"`
+			}
+			if err := event.DataAs(&data); err != nil {
+				t.Fatal(err)
+			}
+			if data.S3.Object.Key != tc.key {
+				t.Errorf("Unexpected key in data: got %q, want %q", data.S3.Object.Key, tc.key)
+			}
+		})
+	}
+}
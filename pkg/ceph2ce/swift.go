@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+const (
+	// SwiftEventSource is the event source of the records parsed from Swift
+	// notifications.
+	SwiftEventSource = "ceph:swift"
+
+	swiftMetaPrefix = "x-object-meta-"
+	amzMetaPrefix   = "x-amz-meta-"
+)
+
+// swiftEventNames maps the Swift request methods to the S3 event names.
+var swiftEventNames = map[string]string{
+	"PUT":    "s3:ObjectCreated:Put",
+	"POST":   "s3:ObjectCreated:Post",
+	"COPY":   "s3:ObjectCreated:Copy",
+	"DELETE": "s3:ObjectRemoved:Delete",
+}
+
+// swiftNotification is an object change of the Swift API.
+type swiftNotification struct {
+	Account       string            `json:"account"`
+	Container     string            `json:"container"`
+	Object        string            `json:"object"`
+	Method        string            `json:"method"`
+	Timestamp     interface{}       `json:"timestamp"`
+	ETag          string            `json:"etag"`
+	Bytes         uint              `json:"bytes"`
+	TransactionID string            `json:"transaction_id"`
+	Region        string            `json:"region"`
+	UserID        string            `json:"user_id"`
+	ClientIP      string            `json:"client_ip"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+// parseSwift parses Swift object change notifications into the records of
+// the RGW model, mapping containers to buckets and objects to keys. The body
+// may hold a notifications array, an array of notifications or a single one.
+func parseSwift(body []byte) ([]ceph.BucketNotification, error) {
+	var notifications []swiftNotification
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &notifications); err != nil {
+			return nil, err
+		}
+	} else {
+		var wrapper struct {
+			Notifications []swiftNotification `json:"notifications"`
+		}
+		if err := json.Unmarshal(body, &wrapper); err != nil {
+			return nil, err
+		}
+		notifications = wrapper.Notifications
+		if notifications == nil {
+			var single swiftNotification
+			if err := json.Unmarshal(body, &single); err != nil {
+				return nil, err
+			}
+			if single.Container != "" {
+				notifications = []swiftNotification{single}
+			}
+		}
+	}
+
+	records := make([]ceph.BucketNotification, 0, len(notifications))
+	for _, n := range notifications {
+		record, err := n.toRecord()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// toRecord maps a Swift notification to a record of the RGW model.
+func (n swiftNotification) toRecord() (ceph.BucketNotification, error) {
+	eventName, ok := swiftEventNames[strings.ToUpper(n.Method)]
+	if !ok {
+		return ceph.BucketNotification{}, fmt.Errorf("unknown Swift method %q", n.Method)
+	}
+	record := ceph.BucketNotification{
+		EventVersion:      "2.2",
+		EventSource:       SwiftEventSource,
+		AwsRegion:         n.Region,
+		EventName:         eventName,
+		UserIdentity:      ceph.UserIdentitySpec{PrincipalID: n.UserID},
+		RequestParameters: ceph.RequestParametersSpec{SourceIPAddress: n.ClientIP},
+		ResponseElements:  ceph.ResponseElementsSpec{XAmzRequestID: n.TransactionID},
+		S3: ceph.S3Spec{
+			Bucket: ceph.BucketSpec{
+				Name:          n.Container,
+				OwnerIdentity: ceph.OwnerIdentitySpec{PrincipalID: n.Account},
+			},
+			Object: ceph.ObjectSpec{
+				Key:  n.Object,
+				Size: n.Bytes,
+				ETag: n.ETag,
+			},
+		},
+	}
+	if t, ok := genericTime(n.Timestamp); ok {
+		record.EventTime = t.UTC().Format(time.RFC3339Nano)
+	}
+
+	// Swift object metadata is exposed to S3 clients as x-amz-meta-*.
+	for k, v := range n.Metadata {
+		key := strings.ToLower(k)
+		if strings.HasPrefix(key, swiftMetaPrefix) {
+			key = amzMetaPrefix + strings.TrimPrefix(key, swiftMetaPrefix)
+		}
+		record.S3.Object.Metadata = append(record.S3.Object.Metadata, ceph.MetadataEntry{Key: key, Value: v})
+	}
+	sort.Slice(record.S3.Object.Metadata, func(i, j int) bool {
+		return record.S3.Object.Metadata[i].Key < record.S3.Object.Metadata[j].Key
+	})
+	return record, nil
+}
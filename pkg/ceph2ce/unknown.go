@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"encoding/json"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// ParseRecords parses the records of a pushed body like ParseNotifications,
+// keeping the JSON of the records of Ceph notifications for
+// ToCloudEventKeepUnknown. The records of other formats have no JSON.
+func ParseRecords(format string, body []byte) ([]RawRecord, error) {
+	if format != "" && format != FormatCeph {
+		notifications, err := ParseNotifications(format, body)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]RawRecord, len(notifications))
+		for i, notification := range notifications {
+			records[i] = RawRecord{BucketNotification: notification}
+		}
+		return records, nil
+	}
+
+	raws, err := splitRecords(body)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]RawRecord, len(raws))
+	for i, raw := range raws {
+		records[i].JSON = raw
+		if err := json.Unmarshal(raw, &records[i].BucketNotification); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// ToCloudEventKeepUnknown converts a record like ToCloudEvent, keeping in
+// the event data the fields of its JSON the model lacks, such as those added
+// by newer Ceph releases. The fields of the model take precedence, so that
+// the transformations of the converter apply. Only the record and record envelope
+// payloads of the Ceph and AWS S3 profiles keep them, the other payloads
+// having a shape of their own.
+func (c Converter) ToCloudEventKeepUnknown(record RawRecord) (cloudevents.Event, error) {
+	event, err := c.toCloudEvent(record.BucketNotification)
+	if err != nil {
+		return event, err
+	}
+	if len(record.JSON) == 0 || c.Profile == ProfileEventBridge || c.Profile == ProfileCDEvents || c.Payload == PayloadFlat {
+		return c.reshape(event)
+	}
+
+	data := json.RawMessage(event.Data())
+	if c.Payload == PayloadRecordEnvelope {
+		var envelope struct {
+			Records []json.RawMessage `json:"Records"`
+		}
+		if err := json.Unmarshal(data, &envelope); err != nil || len(envelope.Records) != 1 {
+			return c.reshape(event)
+		}
+		envelope.Records[0] = keepUnknown(record.JSON, envelope.Records[0])
+		err = event.SetData(cloudevents.ApplicationJSON, envelope)
+	} else {
+		err = event.SetData(cloudevents.ApplicationJSON, keepUnknown(record.JSON, data))
+	}
+	if err != nil {
+		return event, err
+	}
+	return c.reshape(event)
+}
+
+// keepUnknown returns known with the fields of raw it lacks, recursively in
+// the objects of both. Values other than objects are taken from known.
+func keepUnknown(raw, known json.RawMessage) json.RawMessage {
+	var rawFields, knownFields map[string]json.RawMessage
+	if json.Unmarshal(raw, &rawFields) != nil || json.Unmarshal(known, &knownFields) != nil {
+		return known
+	}
+	for name, value := range rawFields {
+		if k, ok := knownFields[name]; ok {
+			knownFields[name] = keepUnknown(value, k)
+		} else {
+			knownFields[name] = value
+		}
+	}
+	merged, err := json.Marshal(knownFields)
+	if err != nil {
+		return known
+	}
+	return merged
+}
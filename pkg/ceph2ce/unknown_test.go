@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// unknownBody is a notification of a newer Ceph release, with fields the
+// model lacks at the top level and in the object.
+const unknownBody = `{"Records":[{
+	"eventVersion": "2.2",
+	"eventSource": "ceph:s3",
+	"eventTime": "2019-11-22T13:47:35.124724Z",
+	"eventName": "s3:ObjectCreated:Put",
+	"s3": {
+		"bucket": {"name": "fishbucket"},
+		"object": {"key": "fish%209.jpg", "size": 1024, "checksum": {"crc32c": "yZRlqg=="}}
+	},
+	"eventId": "1575035255.123",
+	"opaqueData": "team-a"
+}]}`
+
+func TestParseRecords(t *testing.T) {
+	records, err := ParseRecords(FormatCeph, []byte(unknownBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].S3.Object.Size != 1024 || len(records[0].JSON) == 0 {
+		t.Fatalf("Unexpected records: %+v", records)
+	}
+
+	records, err = ParseRecords(FormatMinIO, []byte(unknownBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].JSON != nil {
+		t.Errorf("Unexpected MinIO records: %+v", records)
+	}
+}
+
+func TestToCloudEventKeepUnknown(t *testing.T) {
+	records, err := ParseRecords(FormatCeph, []byte(unknownBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		converter Converter
+		record    func(data map[string]interface{}) map[string]interface{}
+		keeps     bool
+	}{
+		"record": {
+			record: func(data map[string]interface{}) map[string]interface{} { return data },
+			keeps:  true,
+		},
+		"record envelope": {
+			converter: Converter{Payload: PayloadRecordEnvelope},
+			record: func(data map[string]interface{}) map[string]interface{} {
+				return data["Records"].([]interface{})[0].(map[string]interface{})
+			},
+			keeps: true,
+		},
+		"flat": {
+			converter: Converter{Payload: PayloadFlat},
+			record:    func(data map[string]interface{}) map[string]interface{} { return data },
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			tc.converter.DecodeKeys = true
+			event, err := tc.converter.ToCloudEventKeepUnknown(records[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data map[string]interface{}
+			if err := json.Unmarshal(event.Data(), &data); err != nil {
+				t.Fatal(err)
+			}
+			record := tc.record(data)
+			if _, ok := record["opaqueData"]; ok != tc.keeps {
+				t.Errorf("Unexpected opaqueData in %s", event.Data())
+			}
+			if !tc.keeps {
+				return
+			}
+			object := record["s3"].(map[string]interface{})["object"].(map[string]interface{})
+			if _, ok := object["checksum"]; !ok {
+				t.Errorf("Missing checksum in %s", event.Data())
+			}
+			// The fields of the model are transformed.
+			if object["key"] != "fish 9.jpg" {
+				t.Errorf("Unexpected key %v", object["key"])
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+const (
+	// VersionIDExtension is the CloudEvent extension holding the version of
+	// the object in versioned buckets.
+	VersionIDExtension = "versionid"
+
+	// DeleteMarkerExtension is the CloudEvent extension telling whether the
+	// notification is about the creation of a delete marker, rather than of
+	// an object version or the removal of an object.
+	DeleteMarkerExtension = "deletemarker"
+)
+
+const (
+	// EventNameDelete notifies the removal of an object, or of an object
+	// version in versioned buckets.
+	EventNameDelete = "s3:ObjectRemoved:Delete"
+
+	// EventNameDeleteMarkerCreated notifies the creation of a delete marker
+	// in a versioned bucket.
+	EventNameDeleteMarkerCreated = "s3:ObjectRemoved:DeleteMarkerCreated"
+)
+
+// IsDeleteMarker reports whether a record notifies the creation of a delete
+// marker in a versioned bucket. It can be used as a Filter.
+func IsDeleteMarker(record ceph.BucketNotification) bool {
+	return record.EventName == EventNameDeleteMarkerCreated
+}
+
+// Versioned reports whether a record is about an object of a versioned
+// bucket. It can be used as a Filter.
+func Versioned(record ceph.BucketNotification) bool {
+	return record.S3.Object.VersionID != ""
+}
+
+// Not negates a filter.
+func Not(f Filter) Filter {
+	return func(record ceph.BucketNotification) bool {
+		return !f(record)
+	}
+}
+
+// setVersionExtensions sets the version information of a record on event.
+// Notifications do not tell whether the version is the latest, which is
+// therefore not part of it.
+func setVersionExtensions(event *cloudevents.Event, record ceph.BucketNotification) {
+	if Versioned(record) {
+		event.SetExtension(VersionIDExtension, record.S3.Object.VersionID)
+	}
+	if Versioned(record) || IsDeleteMarker(record) {
+		event.SetExtension(DeleteMarkerExtension, IsDeleteMarker(record))
+	}
+}
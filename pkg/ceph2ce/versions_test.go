@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph2ce
+
+import (
+	"testing"
+)
+
+func TestVersionExtensions(t *testing.T) {
+	testCases := map[string]struct {
+		eventName    string
+		versionID    string
+		deleteMarker interface{}
+	}{
+		"unversioned": {
+			eventName: "s3:ObjectCreated:Put",
+		},
+		"new version": {
+			eventName:    "s3:ObjectCreated:Put",
+			versionID:    "TLXfrjXbH2PtfsrE6vwyyM.Y2ElNkl8",
+			deleteMarker: false,
+		},
+		"delete marker": {
+			eventName:    "s3:ObjectRemoved:DeleteMarkerCreated",
+			versionID:    "RZmvPzs4EWyD4AGmUw-2akPe5bBRQE5",
+			deleteMarker: true,
+		},
+		"delete marker without version": {
+			eventName:    "s3:ObjectRemoved:DeleteMarkerCreated",
+			deleteMarker: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			record := record1
+			record.EventName = tc.eventName
+			record.S3.Object.VersionID = tc.versionID
+
+			event, err := ToCloudEvent(record)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, ok := event.Extensions()[VersionIDExtension]; ok != (tc.versionID != "") || (ok && got != tc.versionID) {
+				t.Errorf("Unexpected version ID extension: %v", got)
+			}
+			if got := event.Extensions()[DeleteMarkerExtension]; got != tc.deleteMarker {
+				t.Errorf("Unexpected delete marker extension: got %v, want %v", got, tc.deleteMarker)
+			}
+			if got, want := Match(record, Not(IsDeleteMarker)), tc.deleteMarker != true; got != want {
+				t.Errorf("Unexpected delete marker filtering: got %v, want %v", got, want)
+			}
+			if got, want := Match(record, Versioned), tc.versionID != ""; got != want {
+				t.Errorf("Unexpected versioned filtering: got %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,391 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package otlp pushes the OpenCensus metrics of a process to an
+// OpenTelemetry collector, using the JSON encoding of OTLP over HTTP.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/metric/metricexport"
+	"go.opencensus.io/resource"
+	"go.opencensus.io/trace"
+)
+
+const (
+	// scopeName is the instrumentation scope of the exported metrics.
+	scopeName = "knative.dev/eventing-ceph"
+
+	// temporalityCumulative is the OTLP AGGREGATION_TEMPORALITY_CUMULATIVE.
+	temporalityCumulative = 2
+)
+
+// Exporter exports OpenCensus metrics to an OTLP/HTTP endpoint.
+type Exporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+var _ metricexport.Exporter = (*Exporter)(nil)
+
+// NewExporter returns an Exporter posting to the given metrics endpoint,
+// e.g. "http://otel-collector:4318/v1/metrics", with the given headers.
+func NewExporter(endpoint string, headers map[string]string) *Exporter {
+	return &Exporter{
+		endpoint: endpoint,
+		headers:  headers,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Start reads all the metrics of the process and exports them every
+// interval, until the returned function is called.
+func Start(e *Exporter, interval time.Duration) (func(), error) {
+	ir, err := metricexport.NewIntervalReader(metricexport.NewReader(), e)
+	if err != nil {
+		return nil, err
+	}
+	ir.ReportingInterval = interval
+	if err := ir.Start(); err != nil {
+		return nil, err
+	}
+	return ir.Stop, nil
+}
+
+// ParseHeaders parses headers formatted as in the OTEL_EXPORTER_OTLP_HEADERS
+// variable: a comma separated list of key=value pairs, whose keys and values
+// are percent-encoded. A "+" is kept as is rather than decoded as a space,
+// as in base64 tokens.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid header %q", pair)
+		}
+		key, err := url.PathUnescape(strings.TrimSpace(kv[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid header %q: %w", pair, err)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid header %q: %w", pair, err)
+		}
+		headers[key] = value
+	}
+	return headers, nil
+}
+
+// ExportMetrics implements metricexport.Exporter.
+func (e *Exporter) ExportMetrics(ctx context.Context, metrics []*metricdata.Metric) error {
+	req := makeRequest(metrics)
+	if len(req.ResourceMetrics) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		r.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("OTLP endpoint responded %s", resp.Status)
+	}
+	return nil
+}
+
+// The types below follow the JSON mapping of the OTLP
+// ExportMetricsServiceRequest, 64 bits integers being encoded as strings.
+
+type exportRequest struct {
+	ResourceMetrics []*resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     otlpResource   `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []attribute `json:"attributes,omitempty"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type metric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Unit        string     `json:"unit,omitempty"`
+	Gauge       *gauge     `json:"gauge,omitempty"`
+	Sum         *sum       `json:"sum,omitempty"`
+	Histogram   *histogram `json:"histogram,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type numberDataPoint struct {
+	Attributes        []attribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string      `json:"timeUnixNano"`
+	AsInt             *string     `json:"asInt,omitempty"`
+	AsDouble          *float64    `json:"asDouble,omitempty"`
+}
+
+type histogramDataPoint struct {
+	Attributes        []attribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string      `json:"timeUnixNano"`
+	Count             string      `json:"count"`
+	Sum               float64     `json:"sum"`
+	BucketCounts      []string    `json:"bucketCounts,omitempty"`
+	ExplicitBounds    []float64   `json:"explicitBounds,omitempty"`
+	Exemplars         []exemplar  `json:"exemplars,omitempty"`
+}
+
+type exemplar struct {
+	TimeUnixNano string  `json:"timeUnixNano"`
+	AsDouble     float64 `json:"asDouble"`
+	SpanID       string  `json:"spanId"`
+	TraceID      string  `json:"traceId"`
+}
+
+type attribute struct {
+	Key   string         `json:"key"`
+	Value attributeValue `json:"value"`
+}
+
+type attributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// makeRequest converts metrics to an OTLP request, grouping them by resource.
+// Summaries have no OTLP equivalent the collector accepts and are skipped.
+func makeRequest(metrics []*metricdata.Metric) *exportRequest {
+	req := &exportRequest{}
+	byResource := make(map[string]*resourceMetrics)
+	for _, m := range metrics {
+		converted, ok := convert(m)
+		if !ok {
+			continue
+		}
+		key := resourceKey(m.Resource)
+		rm := byResource[key]
+		if rm == nil {
+			rm = &resourceMetrics{
+				Resource:     makeResource(m.Resource),
+				ScopeMetrics: []scopeMetrics{{Scope: scope{Name: scopeName}}},
+			}
+			byResource[key] = rm
+			req.ResourceMetrics = append(req.ResourceMetrics, rm)
+		}
+		rm.ScopeMetrics[0].Metrics = append(rm.ScopeMetrics[0].Metrics, converted)
+	}
+	return req
+}
+
+func convert(m *metricdata.Metric) (metric, bool) {
+	out := metric{
+		Name:        m.Descriptor.Name,
+		Description: m.Descriptor.Description,
+		Unit:        string(m.Descriptor.Unit),
+	}
+	switch m.Descriptor.Type {
+	case metricdata.TypeGaugeInt64, metricdata.TypeGaugeFloat64:
+		out.Gauge = &gauge{DataPoints: numberDataPoints(m)}
+	case metricdata.TypeCumulativeInt64, metricdata.TypeCumulativeFloat64:
+		out.Sum = &sum{
+			DataPoints:             numberDataPoints(m),
+			AggregationTemporality: temporalityCumulative,
+			IsMonotonic:            true,
+		}
+	case metricdata.TypeGaugeDistribution, metricdata.TypeCumulativeDistribution:
+		out.Histogram = &histogram{
+			DataPoints:             histogramDataPoints(m),
+			AggregationTemporality: temporalityCumulative,
+		}
+	default:
+		return metric{}, false
+	}
+	return out, true
+}
+
+func numberDataPoints(m *metricdata.Metric) []numberDataPoint {
+	var points []numberDataPoint
+	for _, ts := range m.TimeSeries {
+		attrs := attributes(m.Descriptor.LabelKeys, ts.LabelValues)
+		for _, p := range ts.Points {
+			dp := numberDataPoint{
+				Attributes:        attrs,
+				StartTimeUnixNano: unixNano(ts.StartTime),
+				TimeUnixNano:      unixNano(p.Time),
+			}
+			switch v := p.Value.(type) {
+			case int64:
+				s := strconv.FormatInt(v, 10)
+				dp.AsInt = &s
+			case float64:
+				dp.AsDouble = &v
+			default:
+				continue
+			}
+			points = append(points, dp)
+		}
+	}
+	return points
+}
+
+func histogramDataPoints(m *metricdata.Metric) []histogramDataPoint {
+	var points []histogramDataPoint
+	for _, ts := range m.TimeSeries {
+		attrs := attributes(m.Descriptor.LabelKeys, ts.LabelValues)
+		for _, p := range ts.Points {
+			d, ok := p.Value.(*metricdata.Distribution)
+			if !ok {
+				continue
+			}
+			dp := histogramDataPoint{
+				Attributes:        attrs,
+				StartTimeUnixNano: unixNano(ts.StartTime),
+				TimeUnixNano:      unixNano(p.Time),
+				Count:             strconv.FormatInt(d.Count, 10),
+				Sum:               d.Sum,
+			}
+			if d.BucketOptions != nil && len(d.Buckets) == len(d.BucketOptions.Bounds)+1 {
+				dp.ExplicitBounds = d.BucketOptions.Bounds
+				for _, b := range d.Buckets {
+					dp.BucketCounts = append(dp.BucketCounts, strconv.FormatInt(b.Count, 10))
+					if e, ok := makeExemplar(b.Exemplar); ok {
+						dp.Exemplars = append(dp.Exemplars, e)
+					}
+				}
+			}
+			points = append(points, dp)
+		}
+	}
+	return points
+}
+
+// makeExemplar converts the exemplar of a bucket, kept only if it links to
+// the trace of the measurement.
+func makeExemplar(e *metricdata.Exemplar) (exemplar, bool) {
+	if e == nil {
+		return exemplar{}, false
+	}
+	sc, ok := e.Attachments[metricdata.AttachmentKeySpanContext].(trace.SpanContext)
+	if !ok {
+		return exemplar{}, false
+	}
+	return exemplar{
+		TimeUnixNano: unixNano(e.Timestamp),
+		AsDouble:     e.Value,
+		SpanID:       hex.EncodeToString(sc.SpanID[:]),
+		TraceID:      hex.EncodeToString(sc.TraceID[:]),
+	}, true
+}
+
+func attributes(keys []metricdata.LabelKey, values []metricdata.LabelValue) []attribute {
+	var attrs []attribute
+	for i, k := range keys {
+		if i >= len(values) || !values[i].Present {
+			continue
+		}
+		attrs = append(attrs, attribute{Key: k.Key, Value: attributeValue{StringValue: values[i].Value}})
+	}
+	return attrs
+}
+
+func makeResource(r *resource.Resource) otlpResource {
+	if r == nil {
+		return otlpResource{}
+	}
+	keys := make([]string, 0, len(r.Labels))
+	for k := range r.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var res otlpResource
+	if r.Type != "" {
+		res.Attributes = append(res.Attributes, attribute{Key: "opencensus.resourcetype", Value: attributeValue{StringValue: r.Type}})
+	}
+	for _, k := range keys {
+		res.Attributes = append(res.Attributes, attribute{Key: k, Value: attributeValue{StringValue: r.Labels[k]}})
+	}
+	return res
+}
+
+func resourceKey(r *resource.Resource) string {
+	if r == nil {
+		return ""
+	}
+	b, _ := json.Marshal(makeResource(r))
+	return string(b)
+}
+
+func unixNano(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/resource"
+	"go.opencensus.io/trace"
+)
+
+var now = time.Unix(1600000000, 0)
+
+var metrics = []*metricdata.Metric{{
+	Descriptor: metricdata.Descriptor{
+		Name:      "event_count",
+		Type:      metricdata.TypeCumulativeInt64,
+		LabelKeys: []metricdata.LabelKey{{Key: "event_type"}, {Key: "response_code"}},
+	},
+	Resource: &resource.Resource{Type: "knative_source", Labels: map[string]string{"name": "my-source"}},
+	TimeSeries: []*metricdata.TimeSeries{{
+		LabelValues: []metricdata.LabelValue{metricdata.NewLabelValue("com.amazonaws.ObjectCreated:Put"), {}},
+		Points:      []metricdata.Point{metricdata.NewInt64Point(now, 3)},
+		StartTime:   now.Add(-time.Minute),
+	}},
+}, {
+	Descriptor: metricdata.Descriptor{
+		Name: "event_latencies",
+		Unit: metricdata.UnitMilliseconds,
+		Type: metricdata.TypeCumulativeDistribution,
+	},
+	Resource: &resource.Resource{Type: "knative_source", Labels: map[string]string{"name": "my-source"}},
+	TimeSeries: []*metricdata.TimeSeries{{
+		Points: []metricdata.Point{metricdata.NewDistributionPoint(now, &metricdata.Distribution{
+			Count:         3,
+			Sum:           12,
+			BucketOptions: &metricdata.BucketOptions{Bounds: []float64{5}},
+			Buckets: []metricdata.Bucket{{Count: 2}, {Count: 1, Exemplar: &metricdata.Exemplar{
+				Value:     7,
+				Timestamp: now,
+				Attachments: metricdata.Attachments{metricdata.AttachmentKeySpanContext: trace.SpanContext{
+					TraceID: trace.TraceID{0x0a, 15: 0x01},
+					SpanID:  trace.SpanID{0x0b, 7: 0x02},
+				}},
+			}}},
+		})},
+	}},
+}, {
+	Descriptor: metricdata.Descriptor{
+		Name: "build_info",
+		Type: metricdata.TypeGaugeInt64,
+	},
+	TimeSeries: []*metricdata.TimeSeries{{
+		Points: []metricdata.Point{metricdata.NewInt64Point(now, 1)},
+	}},
+}, {
+	Descriptor: metricdata.Descriptor{
+		Name: "summary",
+		Type: metricdata.TypeSummary,
+	},
+}}
+
+func TestExportMetrics(t *testing.T) {
+	var got exportRequest
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	e := NewExporter(srv.URL+"/v1/metrics", map[string]string{"Authorization": "Bearer token"})
+	if err := e.ExportMetrics(context.Background(), metrics); err != nil {
+		t.Fatal(err)
+	}
+
+	if auth != "Bearer token" {
+		t.Errorf("Expected the configured headers, got Authorization %q", auth)
+	}
+	three, one := "3", "1"
+	want := exportRequest{ResourceMetrics: []*resourceMetrics{{
+		Resource: otlpResource{Attributes: []attribute{
+			{Key: "opencensus.resourcetype", Value: attributeValue{StringValue: "knative_source"}},
+			{Key: "name", Value: attributeValue{StringValue: "my-source"}},
+		}},
+		ScopeMetrics: []scopeMetrics{{
+			Scope: scope{Name: scopeName},
+			Metrics: []metric{{
+				Name: "event_count",
+				Sum: &sum{
+					DataPoints: []numberDataPoint{{
+						Attributes:        []attribute{{Key: "event_type", Value: attributeValue{StringValue: "com.amazonaws.ObjectCreated:Put"}}},
+						StartTimeUnixNano: "1599999940000000000",
+						TimeUnixNano:      "1600000000000000000",
+						AsInt:             &three,
+					}},
+					AggregationTemporality: temporalityCumulative,
+					IsMonotonic:            true,
+				},
+			}, {
+				Name: "event_latencies",
+				Unit: "ms",
+				Histogram: &histogram{
+					DataPoints: []histogramDataPoint{{
+						TimeUnixNano:   "1600000000000000000",
+						Count:          "3",
+						Sum:            12,
+						BucketCounts:   []string{"2", "1"},
+						ExplicitBounds: []float64{5},
+						Exemplars: []exemplar{{
+							TimeUnixNano: "1600000000000000000",
+							AsDouble:     7,
+							SpanID:       "0b00000000000002",
+							TraceID:      "0a000000000000000000000000000001",
+						}},
+					}},
+					AggregationTemporality: temporalityCumulative,
+				},
+			}},
+		}},
+	}, {
+		ScopeMetrics: []scopeMetrics{{
+			Scope: scope{Name: scopeName},
+			Metrics: []metric{{
+				Name: "build_info",
+				Gauge: &gauge{DataPoints: []numberDataPoint{{
+					TimeUnixNano: "1600000000000000000",
+					AsInt:        &one,
+				}}},
+			}},
+		}},
+	}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected request (-want, +got): %s", diff)
+	}
+}
+
+func TestExportMetricsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if err := NewExporter(srv.URL, nil).ExportMetrics(context.Background(), metrics); err == nil {
+		t.Error("Expected an error when the collector fails")
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	testCases := map[string]struct {
+		headers string
+		want    map[string]string
+		wantErr bool
+	}{
+		"empty": {
+			want: map[string]string{},
+		},
+		"pairs": {
+			headers: "Authorization=Bearer token, X-Scope-OrgID=ceph",
+			want:    map[string]string{"Authorization": "Bearer token", "X-Scope-OrgID": "ceph"},
+		},
+		"percent-encoded": {
+			headers: "Authorization=Basic%20dXNlcjpw+c3M%3D,X-Tags=a%2Cb%3Dc",
+			want:    map[string]string{"Authorization": "Basic dXNlcjpw+c3M=", "X-Tags": "a,b=c"},
+		},
+		"missing value": {
+			headers: "Authorization",
+			wantErr: true,
+		},
+		"invalid encoding": {
+			headers: "Authorization=Bearer%zz",
+			wantErr: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			got, err := ParseHeaders(tc.headers)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !tc.wantErr && !cmp.Equal(tc.want, got) {
+				t.Errorf("Unexpected headers: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package projection keeps the fields of JSON documents selected by JSONPath
+// expressions, at their place in the document. The expressions are made of
+// field names, as ".name" or "['name']", and of "[*]" for all the elements
+// of arrays, optionally starting with "$", e.g. "$.s3.object.key" or
+// "s3.object.metadata[*].key".
+package projection
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// step is a step of a path: a field name, or all the elements of an array.
+type step struct {
+	name     string
+	wildcard bool
+}
+
+// Projection keeps the fields selected by a list of paths.
+type Projection struct {
+	paths [][]step
+}
+
+// Parse returns the projection of the given JSONPath expressions.
+func Parse(exprs []string) (*Projection, error) {
+	p := &Projection{paths: make([][]step, 0, len(exprs))}
+	for _, expr := range exprs {
+		path, err := parsePath(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", expr, err)
+		}
+		p.paths = append(p.paths, path)
+	}
+	return p, nil
+}
+
+// parsePath returns the steps of a JSONPath expression.
+func parsePath(expr string) ([]step, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	if s == "" {
+		return nil, errors.New("no field selected")
+	}
+	if s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+	var path []step
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, "[*]"):
+			path = append(path, step{wildcard: true})
+			s = s[len("[*]"):]
+		case strings.HasPrefix(s, "['"):
+			end := strings.Index(s, "']")
+			if end < 0 {
+				return nil, errors.New("unterminated field name")
+			}
+			if end == len("['") {
+				return nil, errors.New("empty field name")
+			}
+			path = append(path, step{name: s[len("['"):end]})
+			s = s[end+len("']"):]
+		case s[0] == '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if name := s[:end]; name == "" || name == "*" {
+				return nil, fmt.Errorf("unsupported field name %q", name)
+			}
+			path = append(path, step{name: s[:end]})
+			s = s[end:]
+		default:
+			return nil, fmt.Errorf("unsupported syntax at %q", s)
+		}
+	}
+	return path, nil
+}
+
+// Apply returns the JSON document with only the fields selected by the
+// projection, an empty object if none. The elements of arrays stay at their
+// index, null when none of their fields is selected.
+func (p *Projection) Apply(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	var projected interface{}
+	for _, path := range p.paths {
+		if picked, ok := pick(document, path, projected); ok {
+			projected = picked
+		}
+	}
+	if projected == nil {
+		projected = map[string]interface{}{}
+	}
+	return json.Marshal(projected)
+}
+
+// pick adds the value of src at path to dst, returning the result and
+// whether src has a value at path.
+func pick(src interface{}, path []step, dst interface{}) (interface{}, bool) {
+	if len(path) == 0 {
+		return src, true
+	}
+	s, rest := path[0], path[1:]
+	if s.wildcard {
+		elements, ok := src.([]interface{})
+		if !ok {
+			return dst, false
+		}
+		d, _ := dst.([]interface{})
+		if d == nil {
+			d = make([]interface{}, len(elements))
+		}
+		found := false
+		for i, element := range elements {
+			if picked, ok := pick(element, rest, d[i]); ok {
+				d[i], found = picked, true
+			}
+		}
+		if !found {
+			return dst, false
+		}
+		return d, true
+	}
+
+	fields, ok := src.(map[string]interface{})
+	if !ok {
+		return dst, false
+	}
+	value, ok := fields[s.name]
+	if !ok {
+		return dst, false
+	}
+	d, _ := dst.(map[string]interface{})
+	picked, ok := pick(value, rest, d[s.name])
+	if !ok {
+		return dst, false
+	}
+	if d == nil {
+		d = make(map[string]interface{})
+	}
+	d[s.name] = picked
+	return d, true
+}
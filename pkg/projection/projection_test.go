@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package projection
+
+import (
+	"testing"
+)
+
+const record = `{
+	"eventName": "s3:ObjectCreated:Put",
+	"responseElements": {"x-amz-request-id": "req-1", "x-amz-id-2": "id-2"},
+	"s3": {
+		"bucket": {"name": "fishbucket"},
+		"object": {
+			"key": "fish9.jpg",
+			"size": 12345678901234567890,
+			"metadata": [{"key": "x-amz-meta-team", "value": "a"}, {"key": "x-amz-meta-cost", "value": "b"}]
+		}
+	}
+}`
+
+func TestApply(t *testing.T) {
+	testCases := map[string]struct {
+		exprs []string
+		want  string
+	}{
+		"fields": {
+			exprs: []string{"$.eventName", "s3.object.key", ".s3.object.size"},
+			want:  `{"eventName":"s3:ObjectCreated:Put","s3":{"object":{"key":"fish9.jpg","size":12345678901234567890}}}`,
+		},
+		"bracket names": {
+			exprs: []string{"$.responseElements['x-amz-request-id']"},
+			want:  `{"responseElements":{"x-amz-request-id":"req-1"}}`,
+		},
+		"array elements": {
+			exprs: []string{"s3.object.metadata[*].key", "s3.bucket"},
+			want:  `{"s3":{"bucket":{"name":"fishbucket"},"object":{"metadata":[{"key":"x-amz-meta-team"},{"key":"x-amz-meta-cost"}]}}}`,
+		},
+		"missing fields": {
+			exprs: []string{"s3.object.versionId", "s3.object.key.length"},
+			want:  `{}`,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			p, err := Parse(tc.exprs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.Apply([]byte(record))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("Unexpected projection: got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "$", "s3..key", "s3.*", "s3.object.metadata[0]", "responseElements['x-amz-request-id"} {
+		if _, err := Parse([]string{expr}); err == nil {
+			t.Errorf("Expected an error for %q", expr)
+		}
+	}
+}
@@ -18,14 +18,26 @@ package ceph
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/pkg/resolver"
 	"knative.dev/pkg/tracker"
 
+	eventingv1 "knative.dev/eventing/pkg/apis/eventing/v1"
 	reconcilersource "knative.dev/eventing/pkg/reconciler/source"
 
+	"knative.dev/eventing-ceph/pkg/apis/config"
 	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
 	reconcilercephsource "knative.dev/eventing-ceph/pkg/client/injection/reconciler/sources/v1alpha1/cephsource"
 	"knative.dev/eventing-ceph/pkg/reconciler"
@@ -36,8 +48,23 @@ import (
 type Reconciler struct {
 	ReceiveAdapterImage string `envconfig:"CEPH_SOURCE_RA_IMAGE" required:"true"`
 
+	// OTLP settings propagated to the receive adapters so that they push
+	// their metrics to an OpenTelemetry collector. As the headers usually
+	// carry credentials, the adapters read them from the key of the Secret
+	// named OTLPHeadersSecret in their namespace rather than from their
+	// Deployment.
+	OTLPMetricsEndpoint string `envconfig:"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT"`
+	OTLPHeadersSecret   string `envconfig:"OTEL_EXPORTER_OTLP_HEADERS_SECRET"`
+	OTLPHeadersKey      string `envconfig:"OTEL_EXPORTER_OTLP_HEADERS_KEY" default:"headers"`
+	OTLPExportInterval  string `envconfig:"OTEL_METRIC_EXPORT_INTERVAL"`
+
 	dr  *reconciler.DeploymentReconciler
 	sbr *reconciler.SinkBindingReconciler
+	etr *reconciler.EventTypeReconciler
+	er  *reconciler.ExposeReconciler
+	mr  *reconciler.MeshReconciler
+
+	sinkResolver *resolver.URIResolver
 
 	configAccessor reconcilersource.ConfigAccessor
 }
@@ -47,12 +74,23 @@ var _ reconcilercephsource.Interface = (*Reconciler)(nil)
 
 // ReconcileKind implements Interface.ReconcileKind.
 func (r *Reconciler) ReconcileKind(ctx context.Context, src *v1alpha1.CephSource) pkgreconciler.Event {
-	ra, event := r.dr.ReconcileDeployment(ctx, src, resources.MakeReceiveAdapter(&resources.ReceiveAdapterArgs{
-		Image:          r.ReceiveAdapterImage,
-		Source:         src,
-		Labels:         resources.Labels(src.Name),
-		AdditionalEnvs: r.configAccessor.ToEnvVars(), // Grab config envs for tracing/logging/metrics
-	}))
+	src.Status.MarkCloudEventAttributes(src.Spec.EventTypes())
+	src.Status.MarkCredentialsRotation(src.Spec.RotatedCredentials())
+
+	if err := r.resolveDeadLetterSink(ctx, src); err != nil {
+		return err
+	}
+	if err := r.resolveFailureSink(ctx, src); err != nil {
+		return err
+	}
+	if err := r.resolveLifecycleSink(ctx, src); err != nil {
+		return err
+	}
+	if err := r.resolveFailoverSinks(ctx, src); err != nil {
+		return err
+	}
+
+	ra, event := r.dr.ReconcileDeployment(ctx, src, resources.MakeReceiveAdapter(r.adapterArgs(ctx, src, resources.Labels(src.Name))))
 	if ra != nil {
 		src.Status.PropagateDeploymentAvailability(ra)
 	}
@@ -78,5 +116,313 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, src *v1alpha1.CephSource
 		}
 	}
 
+	if event := r.reconcileDispatcher(ctx, src); event != nil {
+		return event
+	}
+
+	if event := r.reconcileExpose(ctx, src); event != nil {
+		return event
+	}
+
+	if event := r.reconcileSidecar(ctx, src); event != nil {
+		return event
+	}
+
+	return r.reconcileEventTypes(ctx, src)
+}
+
+// resolveDeadLetterSink resolves the URI of spec.delivery.deadLetterSink,
+// passed to the adapters.
+func (r *Reconciler) resolveDeadLetterSink(ctx context.Context, src *v1alpha1.CephSource) error {
+	if src.Spec.Delivery == nil || src.Spec.Delivery.DeadLetterSink == nil {
+		src.Status.MarkDeadLetterSink(nil)
+		return nil
+	}
+	uri, err := r.resolveDestination(ctx, src, src.Spec.Delivery.DeadLetterSink)
+	if err != nil {
+		src.Status.MarkDeadLetterSink(nil)
+		return fmt.Errorf("failed to resolve the dead letter sink: %w", err)
+	}
+	src.Status.MarkDeadLetterSink(uri)
+	return nil
+}
+
+// resolveFailureSink resolves the URI of spec.delivery.failureSink, passed
+// to the adapters.
+func (r *Reconciler) resolveFailureSink(ctx context.Context, src *v1alpha1.CephSource) error {
+	if src.Spec.Delivery == nil || src.Spec.Delivery.FailureSink == nil {
+		src.Status.MarkFailureSink(nil)
+		return nil
+	}
+	uri, err := r.resolveDestination(ctx, src, src.Spec.Delivery.FailureSink)
+	if err != nil {
+		src.Status.MarkFailureSink(nil)
+		return fmt.Errorf("failed to resolve the failure sink: %w", err)
+	}
+	src.Status.MarkFailureSink(uri)
+	return nil
+}
+
+// resolveLifecycleSink resolves the URI of spec.lifecycleEvents.sink,
+// passed to the adapters.
+func (r *Reconciler) resolveLifecycleSink(ctx context.Context, src *v1alpha1.CephSource) error {
+	if src.Spec.LifecycleEvents == nil || src.Spec.LifecycleEvents.Sink == nil {
+		src.Status.MarkLifecycleSink(nil)
+		return nil
+	}
+	uri, err := r.resolveDestination(ctx, src, src.Spec.LifecycleEvents.Sink)
+	if err != nil {
+		src.Status.MarkLifecycleSink(nil)
+		return fmt.Errorf("failed to resolve the lifecycle sink: %w", err)
+	}
+	src.Status.MarkLifecycleSink(uri)
+	return nil
+}
+
+// resolveFailoverSinks resolves the URIs of spec.delivery.failover.sinks,
+// passed to the adapters.
+func (r *Reconciler) resolveFailoverSinks(ctx context.Context, src *v1alpha1.CephSource) error {
+	if src.Spec.Delivery == nil || src.Spec.Delivery.Failover == nil {
+		src.Status.MarkFailoverSinks(nil)
+		return nil
+	}
+	sinks := src.Spec.Delivery.Failover.Sinks
+	uris := make([]apis.URL, 0, len(sinks))
+	for i := range sinks {
+		uri, err := r.resolveDestination(ctx, src, &sinks[i])
+		if err != nil {
+			src.Status.MarkFailoverSinks(nil)
+			return fmt.Errorf("failed to resolve the failover sink %d: %w", i, err)
+		}
+		uris = append(uris, *uri)
+	}
+	src.Status.MarkFailoverSinks(uris)
+	return nil
+}
+
+// resolveDestination resolves the URI of a destination of a source, whose
+// reference defaults to the namespace of the source.
+func (r *Reconciler) resolveDestination(ctx context.Context, src *v1alpha1.CephSource, destination *duckv1.Destination) (*apis.URL, error) {
+	dest := *destination.DeepCopy()
+	if dest.Ref != nil && dest.Ref.Namespace == "" {
+		dest.Ref.Namespace = src.Namespace
+	}
+	return r.sinkResolver.URIFromDestinationV1(ctx, dest, src)
+}
+
+// adapterArgs returns the arguments of the deployments of the adapters of a
+// source, applying the adapter policy of config-ceph-adapter for its
+// namespace.
+func (r *Reconciler) adapterArgs(ctx context.Context, src *v1alpha1.CephSource, labels map[string]string) *resources.ReceiveAdapterArgs {
+	policy := config.FromContextOrDefaults(ctx).Adapter.ForNamespace(src.Namespace)
+	image := r.ReceiveAdapterImage
+	if policy.Image != "" {
+		image = policy.Image
+	}
+	return &resources.ReceiveAdapterArgs{
+		Image:            image,
+		Source:           src,
+		Labels:           labels,
+		AdditionalEnvs:   r.additionalEnvs(),
+		ImagePullSecrets: policy.ImagePullSecrets,
+		Resources:        policy.Resources,
+	}
+}
+
+// additionalEnvs returns the config envs for tracing, logging and metrics of
+// the adapters.
+func (r *Reconciler) additionalEnvs() []corev1.EnvVar {
+	return append(r.configAccessor.ToEnvVars(), r.otlpEnvVars()...)
+}
+
+// reconcileDispatcher runs the dispatcher delivering the events queued by
+// the receive adapter, bound to the sink, as requested by spec.dispatcher,
+// deleting it otherwise.
+func (r *Reconciler) reconcileDispatcher(ctx context.Context, src *v1alpha1.CephSource) pkgreconciler.Event {
+	name := resources.DispatcherName(src)
+	if src.Spec.Dispatcher == nil {
+		if err := r.sbr.DeleteSinkBinding(ctx, src, name); err != nil {
+			return err
+		}
+		return r.dr.DeleteDeployment(ctx, src, name)
+	}
+
+	d, event := r.dr.ReconcileDeployment(ctx, src, resources.MakeDispatcher(r.adapterArgs(ctx, src, resources.DispatcherLabels(src.Name))))
+	if !isNormalEvent(event) {
+		return event
+	}
+	recordNormalEvent(ctx, src, event)
+
+	_, event = r.sbr.ReconcileSinkBinding(ctx, src, src.Spec.SourceSpec, tracker.Reference{
+		APIVersion: appsv1.SchemeGroupVersion.String(),
+		Kind:       "Deployment",
+		Namespace:  d.Namespace,
+		Name:       d.Name,
+	})
+	if !isNormalEvent(event) {
+		return event
+	}
+	recordNormalEvent(ctx, src, event)
+	return nil
+}
+
+// exposeRequeueDelay is how long to wait before checking again for the
+// external address of an exposed adapter, Services and Routes not being
+// watched.
+const exposeRequeueDelay = 10 * time.Second
+
+// reconcileExpose exposes the receive adapter outside of the cluster as
+// requested by spec.expose, deleting the objects of other types, and
+// publishes its external URL.
+func (r *Reconciler) reconcileExpose(ctx context.Context, src *v1alpha1.CephSource) pkgreconciler.Event {
+	name := resources.ExposeName(src)
+	labels := resources.Labels(src.Name)
+	typ := resources.ExposeType(src)
+
+	if typ == "" {
+		src.Status.MarkExternalURL(nil)
+		if err := r.er.DeleteService(ctx, src, name); err != nil {
+			return err
+		}
+	} else {
+		svc, event := r.er.ReconcileService(ctx, src, resources.MakeService(src, labels))
+		if svc == nil {
+			return event
+		}
+		recordNormalEvent(ctx, src, event)
+		if typ == v1alpha1.ExposeLoadBalancer {
+			src.Status.MarkExternalURL(resources.ServiceURL(src, svc))
+		}
+	}
+
+	if typ == v1alpha1.ExposeIngress {
+		ing, event := r.er.ReconcileIngress(ctx, src, resources.MakeIngress(src, labels))
+		if ing == nil {
+			return event
+		}
+		recordNormalEvent(ctx, src, event)
+		src.Status.MarkExternalURL(resources.IngressURL(src))
+	} else if err := r.er.DeleteIngress(ctx, src, name); err != nil {
+		return err
+	}
+
+	if typ == v1alpha1.ExposeRoute {
+		route, event := r.er.ReconcileRoute(ctx, src, resources.RouteGVR, resources.MakeRoute(src, labels))
+		if route == nil {
+			return event
+		}
+		recordNormalEvent(ctx, src, event)
+		src.Status.MarkExternalURL(resources.RouteURL(src, route))
+	} else if err := r.er.DeleteRoute(ctx, src, resources.RouteGVR, name); err != nil {
+		return err
+	}
+
+	if typ != "" && src.Status.ExternalURL == nil {
+		logging.FromContext(ctx).Infow("Waiting for the external address of the receive adapter", zap.String("type", typ))
+		return controller.NewRequeueAfter(exposeRequeueDelay)
+	}
+	return nil
+}
+
+// reconcileSidecar configures the Istio Sidecar forwarding the notifications
+// to the adapter of a source deployed for Istio, deleting it otherwise, and
+// warns in status when it cannot.
+func (r *Reconciler) reconcileSidecar(ctx context.Context, src *v1alpha1.CephSource) pkgreconciler.Event {
+	if src.Spec.Mesh != v1alpha1.MeshIstio {
+		src.Status.ClearMeshSidecar()
+		return r.mr.DeleteSidecar(ctx, src, resources.SidecarGVR, resources.SidecarName(src))
+	}
+	sidecar, event := r.mr.ReconcileSidecar(ctx, src, resources.SidecarGVR, resources.MakeSidecar(src, resources.Labels(src.Name)))
+	if errors.Is(event, reconciler.ErrSidecarUnavailable) {
+		src.Status.MarkNoMeshSidecar("SidecarUnavailable",
+			"The Sidecar resource of Istio is not served, the adapter listening on localhost is unreachable through Istio 1.10 and later.")
+		return nil
+	}
+	if sidecar == nil {
+		src.Status.MarkNoMeshSidecar("SidecarFailed", "%v", event)
+		return event
+	}
+	recordNormalEvent(ctx, src, event)
+	src.Status.MarkMeshSidecar()
 	return nil
 }
+
+// isNormalEvent returns whether a reconciler event is nil or of type Normal,
+// e.g. of an object being created, as opposed to a failure.
+func isNormalEvent(event pkgreconciler.Event) bool {
+	var re *pkgreconciler.ReconcilerEvent
+	return event == nil || (pkgreconciler.EventAs(event, &re) && re.EventType == corev1.EventTypeNormal)
+}
+
+// recordNormalEvent records a Normal reconciler event, e.g. of an object
+// being created, so that the reconciliation can go on instead of returning
+// it.
+func recordNormalEvent(ctx context.Context, src *v1alpha1.CephSource, event pkgreconciler.Event) {
+	var re *pkgreconciler.ReconcilerEvent
+	if event == nil || !pkgreconciler.EventAs(event, &re) || re.EventType != corev1.EventTypeNormal {
+		return
+	}
+	if recorder := controller.GetEventRecorder(ctx); recorder != nil {
+		recorder.Eventf(src, re.EventType, re.Reason, re.Format, re.Args...)
+	}
+}
+
+// reconcileEventTypes registers the event types of the source with the
+// broker it sends to, and suggests the Trigger filters matching them.
+func (r *Reconciler) reconcileEventTypes(ctx context.Context, src *v1alpha1.CephSource) pkgreconciler.Event {
+	broker := sinkBroker(src)
+	if broker == "" {
+		src.Status.MarkSuggestedFilters(nil)
+	} else {
+		src.Status.MarkSuggestedFilters(src.Spec.EventTypes())
+	}
+	return r.etr.ReconcileEventTypes(ctx, src, broker, src.Spec.EventTypes(),
+		resources.DataSchemaURL(src), resources.DataSchema(src), resources.Labels(src.Name))
+}
+
+// sinkBroker returns the name of the Broker the source sends to, if its sink
+// is a Broker of its namespace.
+func sinkBroker(src *v1alpha1.CephSource) string {
+	ref := src.Spec.Sink.Ref
+	if ref == nil || ref.Kind != "Broker" || !strings.HasPrefix(ref.APIVersion, eventingv1.SchemeGroupVersion.Group+"/") {
+		return ""
+	}
+	if ref.Namespace != "" && ref.Namespace != src.Namespace {
+		return ""
+	}
+	return ref.Name
+}
+
+// otlpEnvVars returns the OTLP settings of the controller to pass on to the
+// receive adapters.
+func (r *Reconciler) otlpEnvVars() []corev1.EnvVar {
+	if r.OTLPMetricsEndpoint == "" {
+		return nil
+	}
+	env := []corev1.EnvVar{{
+		Name:  "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT",
+		Value: r.OTLPMetricsEndpoint,
+	}}
+	if r.OTLPHeadersSecret != "" {
+		// Optional so that the adapters of the namespaces without the Secret
+		// still run, pushing without the headers.
+		optional := true
+		env = append(env, corev1.EnvVar{
+			Name: "OTEL_EXPORTER_OTLP_HEADERS",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: r.OTLPHeadersSecret},
+					Key:                  r.OTLPHeadersKey,
+					Optional:             &optional,
+				},
+			},
+		})
+	}
+	if r.OTLPExportInterval != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "OTEL_METRIC_EXPORT_INTERVAL",
+			Value: r.OTLPExportInterval,
+		})
+	}
+	return env
+}
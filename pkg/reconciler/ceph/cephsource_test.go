@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func TestOTLPEnvVars(t *testing.T) {
+	optional := true
+	for name, tc := range map[string]struct {
+		r    *Reconciler
+		want []corev1.EnvVar
+	}{
+		"disabled": {
+			r: &Reconciler{OTLPHeadersSecret: "otlp-headers", OTLPHeadersKey: "headers"},
+		},
+		"without headers": {
+			r: &Reconciler{OTLPMetricsEndpoint: "http://collector:4318/v1/metrics", OTLPExportInterval: "10000"},
+			want: []corev1.EnvVar{
+				{Name: "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", Value: "http://collector:4318/v1/metrics"},
+				{Name: "OTEL_METRIC_EXPORT_INTERVAL", Value: "10000"},
+			},
+		},
+		"headers from a secret": {
+			r: &Reconciler{OTLPMetricsEndpoint: "http://collector:4318/v1/metrics", OTLPHeadersSecret: "otlp-headers", OTLPHeadersKey: "headers"},
+			want: []corev1.EnvVar{
+				{Name: "OTEL_EXPORTER_OTLP_METRICS_ENDPOINT", Value: "http://collector:4318/v1/metrics"},
+				{Name: "OTEL_EXPORTER_OTLP_HEADERS", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "otlp-headers"},
+						Key:                  "headers",
+						Optional:             &optional,
+					},
+				}},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.r.otlpEnvVars()); diff != "" {
+				t.Errorf("Unexpected env (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestSinkBroker(t *testing.T) {
+	for name, tc := range map[string]struct {
+		sink duckv1.Destination
+		want string
+	}{
+		"broker": {
+			sink: duckv1.Destination{Ref: &duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Name: "default"}},
+			want: "default",
+		},
+		"broker of the namespace": {
+			sink: duckv1.Destination{Ref: &duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Namespace: "ns", Name: "default"}},
+			want: "default",
+		},
+		"broker of another namespace": {
+			sink: duckv1.Destination{Ref: &duckv1.KReference{APIVersion: "eventing.knative.dev/v1", Kind: "Broker", Namespace: "other", Name: "default"}},
+		},
+		"broker of another group": {
+			sink: duckv1.Destination{Ref: &duckv1.KReference{APIVersion: "example.com/v1", Kind: "Broker", Name: "default"}},
+		},
+		"service": {
+			sink: duckv1.Destination{Ref: &duckv1.KReference{APIVersion: "serving.knative.dev/v1", Kind: "Service", Name: "event-display"}},
+		},
+		"uri": {
+			sink: duckv1.Destination{URI: apis.HTTP("event-display.ns.svc")},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src := &v1alpha1.CephSource{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "source"},
+				Spec:       v1alpha1.CephSourceSpec{SourceSpec: duckv1.SourceSpec{Sink: tc.sink}},
+			}
+			if got := sinkBroker(src); got != tc.want {
+				t.Errorf("Unexpected broker: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -21,16 +21,21 @@ import (
 
 	reconcilersource "knative.dev/eventing/pkg/reconciler/source"
 
+	"knative.dev/eventing-ceph/pkg/apis/config"
 	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
 
 	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
 	"k8s.io/client-go/tools/cache"
 
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/resolver"
 
 	"knative.dev/eventing-ceph/pkg/reconciler"
+	"knative.dev/eventing-ceph/pkg/reconciler/ceph/resources"
+	"knative.dev/eventing-ceph/pkg/version"
 
 	cephsourceinformer "knative.dev/eventing-ceph/pkg/client/injection/informers/sources/v1alpha1/cephsource"
 	"knative.dev/eventing-ceph/pkg/client/injection/reconciler/sources/v1alpha1/cephsource"
@@ -38,6 +43,7 @@ import (
 	brokerinformer "knative.dev/eventing/pkg/client/injection/informers/eventing/v1/broker"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	deploymentinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
+	"knative.dev/pkg/injection/clients/dynamicclient"
 )
 
 // NewController initializes the controller and is called by the generated code
@@ -51,8 +57,17 @@ func NewController(
 	cephSourceInformer := cephsourceinformer.Get(ctx)
 
 	r := &Reconciler{
-		dr:  &reconciler.DeploymentReconciler{KubeClientSet: kubeclient.Get(ctx)},
+		dr: &reconciler.DeploymentReconciler{
+			KubeClientSet:             kubeclient.Get(ctx),
+			ManagedAnnotationPrefixes: resources.MeshAnnotationPrefixes,
+		},
 		sbr: &reconciler.SinkBindingReconciler{EventingClientSet: eventingclient.Get(ctx)},
+		etr: &reconciler.EventTypeReconciler{EventingClientSet: eventingclient.Get(ctx)},
+		er: &reconciler.ExposeReconciler{
+			KubeClientSet:    kubeclient.Get(ctx),
+			DynamicClientSet: dynamicclient.Get(ctx),
+		},
+		mr: &reconciler.MeshReconciler{DynamicClientSet: dynamicclient.Get(ctx)},
 		// Config accessor takes care of tracing/config/logging config propagation to the receive adapter
 		configAccessor: reconcilersource.WatchConfigurations(ctx, "cephsource", cmw),
 	}
@@ -60,7 +75,25 @@ func NewController(
 		logging.FromContext(ctx).Panicf("required environment variable is not defined: %v", err)
 	}
 
-	impl := cephsource.NewImpl(ctx, r)
+	if err := version.RecordBuildInfo(ctx); err != nil {
+		logging.FromContext(ctx).Warnw("Failed to record build info", zap.Error(err))
+	}
+
+	var impl *controller.Impl
+	configStore := config.NewStore(logging.FromContext(ctx).Named("config-store"), func(name string, _ interface{}) {
+		// Roll the adapters of all the sources out on changes of their
+		// policy.
+		if name == config.AdapterConfigName && impl != nil {
+			impl.GlobalResync(cephSourceInformer.Informer())
+		}
+	})
+	configStore.WatchConfigs(cmw)
+
+	impl = cephsource.NewImpl(ctx, r, func(impl *controller.Impl) controller.Options {
+		return controller.Options{ConfigStore: configStore}
+	})
+
+	r.sinkResolver = resolver.NewURIResolverFromTracker(ctx, impl.Tracker)
 
 	logging.FromContext(ctx).Info("Setting up event handlers")
 
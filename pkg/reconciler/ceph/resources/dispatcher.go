@@ -0,0 +1,179 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/kmeta"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	// roleReceiver and roleDispatcher are the roles of the adapters of a
+	// source with a dispatcher.
+	roleReceiver   = "receiver"
+	roleDispatcher = "dispatcher"
+)
+
+// DispatcherName returns the name of the dispatcher Deployment of a source.
+func DispatcherName(source *v1alpha1.CephSource) string {
+	return kmeta.ChildName(fmt.Sprintf("cephsource-%s-dispatcher-", source.Name), string(source.GetUID()))
+}
+
+// DispatcherLabels returns the labels of the dispatcher of a source, distinct
+// from the ones of its receive adapter so that their selectors do not overlap.
+func DispatcherLabels(name string) map[string]string {
+	return map[string]string{
+		"knative-eventing-source":      controllerAgentName + "-" + roleDispatcher,
+		"knative-eventing-source-name": name,
+	}
+}
+
+// MakeDispatcher generates (but does not insert into K8s) the dispatcher
+// Deployment delivering the events queued by the receive adapter of a source
+// with a dispatcher.
+func MakeDispatcher(args *ReceiveAdapterArgs) *v1.Deployment {
+	replicas := int32(1)
+	deployment := &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: args.Source.Namespace,
+			Name:      DispatcherName(args.Source),
+			Labels:    args.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				*kmeta.NewControllerRef(args.Source),
+			},
+		},
+		Spec: v1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: args.Labels,
+			},
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      args.Labels,
+					Annotations: credentialsAnnotations(args.Source),
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: args.Source.Spec.ServiceAccountName,
+					ImagePullSecrets:   args.ImagePullSecrets,
+					Containers: []corev1.Container{
+						{
+							Name:  "dispatcher",
+							Image: args.Image,
+							Env: append(
+								makeDispatcherEnv(args.Source),
+								args.AdditionalEnvs...,
+							),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if resources := args.resources(); resources != nil {
+		deployment.Spec.Template.Spec.Containers[0].Resources = *resources
+	}
+
+	mountRetention(&deployment.Spec.Template.Spec, args.Source)
+	mountVolume(&deployment.Spec.Template.Spec, queueVolumeName, args.Source.Spec.Dispatcher.Queue, QueueDir)
+	mountRoutes(&deployment.Spec.Template.Spec, args.Source)
+	mountConfigFile(&deployment.Spec.Template.Spec, args.Source)
+	mountCredentials(&deployment.Spec.Template.Spec, args.Source)
+
+	return deployment
+}
+
+func makeDispatcherEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	env := []corev1.EnvVar{{
+		Name:  "NAMESPACE",
+		Value: source.Namespace,
+	}, {
+		Name:  "NAME",
+		Value: source.Name,
+	}, {
+		Name:  "METRICS_DOMAIN",
+		Value: "knative.dev/eventing",
+	}}
+	env = append(env, queueEnv(source, roleDispatcher)...)
+
+	if source.Spec.Backpressure {
+		env = append(env, corev1.EnvVar{
+			Name:  "BACKPRESSURE",
+			Value: "true",
+		})
+	}
+
+	if source.Spec.MaxConcurrency != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "MAX_CONCURRENCY",
+			Value: strconv.Itoa(int(*source.Spec.MaxConcurrency)),
+		})
+	}
+
+	env = append(env, encodingEnv(source)...)
+	env = append(env, deliveryEnv(source)...)
+	env = append(env, tlsPolicyEnv(source)...)
+	// The dispatcher mirrors the events to the audit bucket, but sends no
+	// other requests to the RGW.
+	for _, f := range source.Spec.RGWFeatures() {
+		if f.Name == "audit" {
+			env = append(env, rgwEnv(source, f)...)
+		}
+	}
+	env = append(env, auditEnv(source)...)
+	env = append(env, credentialsEnv(source)...)
+	env = append(env, rgwTLSEnv(source)...)
+	env = append(env, sinkHeadersEnv(source)...)
+	env = append(env, dnsEnv(source)...)
+	env = append(env, routesEnv(source)...)
+	env = append(env, configFileEnv(source)...)
+	env = append(env, storageEnv(source)...)
+	return append(env, retentionEnv(source)...)
+}
+
+// queueEnv returns the env vars of the queue between the receive adapter and
+// the dispatcher of a source, for the adapter of the given role.
+func queueEnv(source *v1alpha1.CephSource, role string) []corev1.EnvVar {
+	dispatcher := source.Spec.Dispatcher
+	env := []corev1.EnvVar{{
+		Name:  "ROLE",
+		Value: role,
+	}, {
+		Name:  "QUEUE_DIR",
+		Value: QueueDir,
+	}}
+	if dispatcher.MaxEvents != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "QUEUE_MAX_EVENTS",
+			Value: strconv.Itoa(int(*dispatcher.MaxEvents)),
+		})
+	}
+	if dispatcher.Interval != nil && role == roleDispatcher {
+		env = append(env, corev1.EnvVar{
+			Name:  "DISPATCH_INTERVAL",
+			Value: dispatcher.Interval.Duration.String(),
+		})
+	}
+	return env
+}
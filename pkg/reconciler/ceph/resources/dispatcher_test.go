@@ -0,0 +1,92 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func rgwCredentials() v1alpha1.CephSourceRGWCredentials {
+	return v1alpha1.CephSourceRGWCredentials{
+		Endpoint: apis.HTTP("rgw.rook-ceph.svc"),
+		AccessKeyID: corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "rgw"}, Key: "access-key-id",
+		},
+		SecretAccessKey: corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "rgw"}, Key: "secret-access-key",
+		},
+	}
+}
+
+func TestMakeDispatcherEnv(t *testing.T) {
+	src := &v1alpha1.CephSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "source"},
+		Spec: v1alpha1.CephSourceSpec{
+			Dispatcher: &v1alpha1.CephSourceDispatcher{},
+			Audit: &v1alpha1.CephSourceAudit{
+				CephSourceRGWCredentials: rgwCredentials(),
+				Bucket:                   "audit",
+			},
+			Usage: &v1alpha1.CephSourceUsage{
+				CephSourceRGWCredentials: rgwCredentials(),
+			},
+			Storage: &v1alpha1.CephSourceStorage{Spool: "file", Dedup: "memory"},
+			TLSPolicy: &v1alpha1.CephSourceTLSPolicy{
+				MinVersion:   "1.2",
+				CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			},
+		},
+	}
+	env := make(map[string]corev1.EnvVar)
+	for _, e := range makeDispatcherEnv(src) {
+		env[e.Name] = e
+	}
+
+	if got := env["AUDIT_ENDPOINT"].Value; got != "http://rgw.rook-ceph.svc" {
+		t.Errorf("Unexpected AUDIT_ENDPOINT: %q", got)
+	}
+	if ref := env["AUDIT_ACCESS_KEY_ID"].ValueFrom; ref == nil || ref.SecretKeyRef.Name != "rgw" {
+		t.Errorf("Unexpected AUDIT_ACCESS_KEY_ID: %+v", ref)
+	}
+	if got := env["AUDIT_BUCKET"].Value; got != "audit" {
+		t.Errorf("Unexpected AUDIT_BUCKET: %q", got)
+	}
+	// The dispatcher delivers to the sink within the TLS policy.
+	if got := env["TLS_MIN_VERSION"].Value; got != "1.2" {
+		t.Errorf("Unexpected TLS_MIN_VERSION: %q", got)
+	}
+	if got := env["TLS_CIPHER_SUITES"].Value; got != "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" {
+		t.Errorf("Unexpected TLS_CIPHER_SUITES: %q", got)
+	}
+	// The dispatcher retains the events it delivers in the same spool store.
+	if got := env["SPOOL_STORE"].Value; got != "file" {
+		t.Errorf("Unexpected SPOOL_STORE: %q", got)
+	}
+	if got := env["DEDUP_STORE"].Value; got != "memory" {
+		t.Errorf("Unexpected DEDUP_STORE: %q", got)
+	}
+	// The usage is polled by the receive adapter only.
+	if e, ok := env["USAGE_ENDPOINT"]; ok {
+		t.Errorf("Unexpected USAGE_ENDPOINT: %q", e.Value)
+	}
+}
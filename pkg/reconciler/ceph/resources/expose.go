@@ -0,0 +1,233 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	exposePortName = "http"
+	exposePort     = 80
+	exposeTLSPort  = 443
+)
+
+// RouteGVR is the resource of OpenShift Routes.
+var RouteGVR = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+
+// ExposeName returns the name of the Service, Ingress or Route exposing the
+// receive adapter of a source.
+func ExposeName(source *v1alpha1.CephSource) string {
+	return receiveAdapterName(source)
+}
+
+// ExposeType returns the type of object exposing the receive adapter of a
+// source, or "" if it is not exposed.
+func ExposeType(source *v1alpha1.CephSource) string {
+	expose := source.Spec.Expose
+	switch {
+	case expose == nil:
+		return ""
+	case expose.Type == "":
+		return v1alpha1.ExposeIngress
+	default:
+		return expose.Type
+	}
+}
+
+func exposeMeta(source *v1alpha1.CephSource, labels map[string]string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace:   source.Namespace,
+		Name:        ExposeName(source),
+		Labels:      labels,
+		Annotations: source.Spec.Expose.Annotations,
+		OwnerReferences: []metav1.OwnerReference{
+			*kmeta.NewControllerRef(source),
+		},
+	}
+}
+
+// MakeService generates (but does not insert into K8s) the Service in front
+// of the receive adapter of an exposed source: a LoadBalancer for the
+// LoadBalancer type, else the backend of the Ingress or Route.
+func MakeService(source *v1alpha1.CephSource, labels map[string]string) *corev1.Service {
+	targetPort, _ := strconv.Atoi(source.Spec.Port)
+	svc := &corev1.Service{
+		ObjectMeta: exposeMeta(source, labels),
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: labels,
+			Ports: []corev1.ServicePort{{
+				Name:       exposePortName,
+				Protocol:   corev1.ProtocolTCP,
+				Port:       exposePort,
+				TargetPort: intstr.FromInt(targetPort),
+			}},
+		},
+	}
+	if ExposeType(source) == v1alpha1.ExposeLoadBalancer {
+		svc.Spec.Type = corev1.ServiceTypeLoadBalancer
+		if source.Spec.Expose.TLS != nil {
+			svc.Spec.Ports[0].Port = exposeTLSPort
+		}
+	} else {
+		// The annotations configure the Ingress or Route.
+		svc.Annotations = nil
+	}
+	return svc
+}
+
+// MakeIngress generates (but does not insert into K8s) the Ingress exposing
+// the receive adapter of a source through its Service.
+func MakeIngress(source *v1alpha1.CephSource, labels map[string]string) *networkingv1.Ingress {
+	expose := source.Spec.Expose
+	pathType := networkingv1.PathTypePrefix
+	hosts := append([]string{expose.Host}, AuthHosts(source)...)
+	ing := &networkingv1.Ingress{
+		ObjectMeta: exposeMeta(source, labels),
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: expose.IngressClassName,
+		},
+	}
+	for _, host := range hosts {
+		ing.Spec.Rules = append(ing.Spec.Rules, networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: ExposeName(source),
+								Port: networkingv1.ServiceBackendPort{Name: exposePortName},
+							},
+						},
+					}},
+				},
+			},
+		})
+	}
+	if expose.TLS != nil {
+		ing.Spec.TLS = []networkingv1.IngressTLS{{
+			Hosts:      hosts,
+			SecretName: expose.TLS.SecretName,
+		}}
+	}
+	return ing
+}
+
+// AuthHosts returns the distinct hosts of the tokens of a source other than
+// its exposed host, in order.
+func AuthHosts(source *v1alpha1.CephSource) []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	if source.Spec.Expose != nil {
+		seen[source.Spec.Expose.Host] = true
+	}
+	for _, auth := range source.Spec.Auth {
+		if auth.Host != "" && !seen[auth.Host] {
+			seen[auth.Host] = true
+			hosts = append(hosts, auth.Host)
+		}
+	}
+	return hosts
+}
+
+// MakeRoute generates (but does not insert into K8s) the OpenShift Route
+// exposing the receive adapter of a source through its Service.
+func MakeRoute(source *v1alpha1.CephSource, labels map[string]string) *unstructured.Unstructured {
+	expose := source.Spec.Expose
+	spec := map[string]interface{}{
+		"to": map[string]interface{}{
+			"kind": "Service",
+			"name": ExposeName(source),
+		},
+		"port": map[string]interface{}{
+			"targetPort": exposePortName,
+		},
+	}
+	if expose.Host != "" {
+		spec["host"] = expose.Host
+	}
+	if expose.TLS != nil {
+		spec["tls"] = map[string]interface{}{
+			"termination":                   "edge",
+			"insecureEdgeTerminationPolicy": "Redirect",
+		}
+	}
+
+	route := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	route.SetAPIVersion(RouteGVR.GroupVersion().String())
+	route.SetKind("Route")
+	meta := exposeMeta(source, labels)
+	route.SetNamespace(meta.Namespace)
+	route.SetName(meta.Name)
+	route.SetLabels(meta.Labels)
+	route.SetAnnotations(meta.Annotations)
+	route.SetOwnerReferences(meta.OwnerReferences)
+	return route
+}
+
+// ServiceURL returns the external URL of a LoadBalancer Service, nil until
+// its load balancer is provisioned.
+func ServiceURL(source *v1alpha1.CephSource, svc *corev1.Service) *apis.URL {
+	for _, ing := range svc.Status.LoadBalancer.Ingress {
+		host := ing.Hostname
+		if host == "" {
+			host = ing.IP
+		}
+		if host != "" {
+			return exposeURL(source, host)
+		}
+	}
+	return nil
+}
+
+// IngressURL returns the external URL of an Ingress.
+func IngressURL(source *v1alpha1.CephSource) *apis.URL {
+	return exposeURL(source, source.Spec.Expose.Host)
+}
+
+// RouteURL returns the external URL of a Route, nil until OpenShift admits
+// it when its host is generated.
+func RouteURL(source *v1alpha1.CephSource, route *unstructured.Unstructured) *apis.URL {
+	host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+	if host == "" {
+		return nil
+	}
+	return exposeURL(source, host)
+}
+
+func exposeURL(source *v1alpha1.CephSource, host string) *apis.URL {
+	scheme := "http"
+	if source.Spec.Expose.TLS != nil {
+		scheme = "https"
+	}
+	return &apis.URL{Scheme: scheme, Host: host}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func exposedSource(expose *v1alpha1.CephSourceExpose) *v1alpha1.CephSource {
+	return &v1alpha1.CephSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "source", UID: "1234"},
+		Spec: v1alpha1.CephSourceSpec{
+			Port:   "8888",
+			Admin:  &v1alpha1.CephSourceAdmin{Port: "8890"},
+			Expose: expose,
+		},
+	}
+}
+
+func TestExposeType(t *testing.T) {
+	for want, expose := range map[string]*v1alpha1.CephSourceExpose{
+		"":                          nil,
+		v1alpha1.ExposeIngress:      {},
+		v1alpha1.ExposeRoute:        {Type: v1alpha1.ExposeRoute},
+		v1alpha1.ExposeLoadBalancer: {Type: v1alpha1.ExposeLoadBalancer},
+	} {
+		if got := ExposeType(exposedSource(expose)); got != want {
+			t.Errorf("Unexpected type of %+v: got %q, want %q", expose, got, want)
+		}
+	}
+}
+
+func TestMakeService(t *testing.T) {
+	labels := Labels("source")
+	annotations := map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"}
+	for name, tc := range map[string]struct {
+		expose   *v1alpha1.CephSourceExpose
+		wantType corev1.ServiceType
+		wantPort int32
+		wantAnn  map[string]string
+	}{
+		"ingress backend": {
+			expose:   &v1alpha1.CephSourceExpose{Host: "ceph.example.com", Annotations: annotations},
+			wantType: corev1.ServiceTypeClusterIP,
+			wantPort: 80,
+		},
+		"load balancer": {
+			expose:   &v1alpha1.CephSourceExpose{Type: v1alpha1.ExposeLoadBalancer, Annotations: annotations},
+			wantType: corev1.ServiceTypeLoadBalancer,
+			wantPort: 80,
+			wantAnn:  annotations,
+		},
+		"load balancer with tls": {
+			expose:   &v1alpha1.CephSourceExpose{Type: v1alpha1.ExposeLoadBalancer, TLS: &v1alpha1.CephSourceExposeTLS{}},
+			wantType: corev1.ServiceTypeLoadBalancer,
+			wantPort: 443,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			src := exposedSource(tc.expose)
+			svc := MakeService(src, labels)
+			if svc.Namespace != "ns" || svc.Name != ExposeName(src) || !metav1.IsControlledBy(svc, src) {
+				t.Errorf("Unexpected metadata: %+v", svc.ObjectMeta)
+			}
+			want := corev1.ServiceSpec{
+				Type:     tc.wantType,
+				Selector: labels,
+				Ports: []corev1.ServicePort{{
+					Name:       "http",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       tc.wantPort,
+					TargetPort: intstr.FromInt(8888),
+				}},
+			}
+			if diff := cmp.Diff(want, svc.Spec); diff != "" {
+				t.Errorf("Unexpected spec (-want, +got): %s", diff)
+			}
+			if diff := cmp.Diff(tc.wantAnn, svc.Annotations); diff != "" {
+				t.Errorf("Unexpected annotations (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestMakeIngress(t *testing.T) {
+	className := "nginx"
+	src := exposedSource(&v1alpha1.CephSourceExpose{
+		Host:             "ceph.example.com",
+		IngressClassName: &className,
+		TLS:              &v1alpha1.CephSourceExposeTLS{SecretName: "ceph-tls"},
+	})
+	src.Spec.Auth = []v1alpha1.CephSourceAuth{
+		{Host: "cluster-a.example.com"},
+		{Host: "ceph.example.com"},
+		{Path: "/cluster-b"},
+		{Host: "cluster-a.example.com", Path: "/a"},
+	}
+
+	ing := MakeIngress(src, Labels("source"))
+	if ing.Name != ExposeName(src) || !metav1.IsControlledBy(ing, src) {
+		t.Errorf("Unexpected metadata: %+v", ing.ObjectMeta)
+	}
+	if ing.Spec.IngressClassName == nil || *ing.Spec.IngressClassName != "nginx" {
+		t.Errorf("Unexpected ingress class: %v", ing.Spec.IngressClassName)
+	}
+	var hosts []string
+	for _, rule := range ing.Spec.Rules {
+		hosts = append(hosts, rule.Host)
+		paths := rule.HTTP.Paths
+		if len(paths) != 1 || paths[0].Path != "/" || *paths[0].PathType != networkingv1.PathTypePrefix ||
+			paths[0].Backend.Service.Name != ExposeName(src) || paths[0].Backend.Service.Port.Name != "http" {
+			t.Errorf("Unexpected paths of %s: %+v", rule.Host, paths)
+		}
+	}
+	wantHosts := []string{"ceph.example.com", "cluster-a.example.com"}
+	if diff := cmp.Diff(wantHosts, hosts); diff != "" {
+		t.Errorf("Unexpected hosts (-want, +got): %s", diff)
+	}
+	wantTLS := []networkingv1.IngressTLS{{Hosts: wantHosts, SecretName: "ceph-tls"}}
+	if diff := cmp.Diff(wantTLS, ing.Spec.TLS); diff != "" {
+		t.Errorf("Unexpected TLS (-want, +got): %s", diff)
+	}
+	if got := IngressURL(src); got.String() != "https://ceph.example.com" {
+		t.Errorf("Unexpected URL: %s", got)
+	}
+}
+
+func TestMakeRoute(t *testing.T) {
+	src := exposedSource(&v1alpha1.CephSourceExpose{Type: v1alpha1.ExposeRoute, TLS: &v1alpha1.CephSourceExposeTLS{}})
+	route := MakeRoute(src, Labels("source"))
+	if route.GetKind() != "Route" || route.GetAPIVersion() != "route.openshift.io/v1" || route.GetName() != ExposeName(src) {
+		t.Errorf("Unexpected route: %+v", route.Object)
+	}
+	want := map[string]interface{}{
+		"to":   map[string]interface{}{"kind": "Service", "name": ExposeName(src)},
+		"port": map[string]interface{}{"targetPort": "http"},
+		"tls": map[string]interface{}{
+			"termination":                   "edge",
+			"insecureEdgeTerminationPolicy": "Redirect",
+		},
+	}
+	if diff := cmp.Diff(want, route.Object["spec"]); diff != "" {
+		t.Errorf("Unexpected spec (-want, +got): %s", diff)
+	}
+
+	// The host is generated by OpenShift when not set.
+	if got := RouteURL(src, route); got != nil {
+		t.Errorf("Unexpected URL before admission: %s", got)
+	}
+	if err := unstructured.SetNestedField(route.Object, "source-ns.apps.example.com", "spec", "host"); err != nil {
+		t.Fatal(err)
+	}
+	if got := RouteURL(src, route); got.String() != "https://source-ns.apps.example.com" {
+		t.Errorf("Unexpected URL: %s", got)
+	}
+}
+
+func TestServiceURL(t *testing.T) {
+	src := exposedSource(&v1alpha1.CephSourceExpose{Type: v1alpha1.ExposeLoadBalancer})
+	for name, tc := range map[string]struct {
+		ingress []corev1.LoadBalancerIngress
+		want    *apis.URL
+	}{
+		"not provisioned": {},
+		"ip": {
+			ingress: []corev1.LoadBalancerIngress{{IP: "192.0.2.1"}},
+			want:    &apis.URL{Scheme: "http", Host: "192.0.2.1"},
+		},
+		"hostname": {
+			ingress: []corev1.LoadBalancerIngress{{Hostname: "lb.example.com", IP: "192.0.2.1"}},
+			want:    &apis.URL{Scheme: "http", Host: "lb.example.com"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			svc := &corev1.Service{}
+			svc.Status.LoadBalancer.Ingress = tc.ingress
+			if diff := cmp.Diff(tc.want, ServiceURL(src, svc)); diff != "" {
+				t.Errorf("Unexpected URL (-want, +got): %s", diff)
+			}
+		})
+	}
+}
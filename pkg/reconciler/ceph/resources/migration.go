@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+const (
+	// MigrateLabel labels the receive adapter Deployments deployed by hand
+	// to migrate to a CephSource, when set to "true".
+	MigrateLabel = "ceph.sources.knative.dev/migrate"
+
+	// MigratedFromAnnotation is set on the CephSources migrated from a
+	// Deployment, to its name.
+	MigratedFromAnnotation = "ceph.sources.knative.dev/migrated-from"
+
+	// UnmigratedEnvAnnotation lists the environment variables of the
+	// Deployment that have no equivalent in the spec of the CephSource
+	// migrated from it, for them to be reviewed.
+	UnmigratedEnvAnnotation = "ceph.sources.knative.dev/unmigrated-env"
+)
+
+// ignoredEnv are the environment variables of the adapter that are not part
+// of its configuration, or set by the controller regardless of the spec.
+var ignoredEnv = map[string]bool{
+	"NAMESPACE":                true,
+	"NAME":                     true,
+	"METRICS_DOMAIN":           true,
+	"K_SINK":                   true,
+	"K_LOGGING_CONFIG":         true,
+	"K_METRICS_CONFIG":         true,
+	"K_TRACING_CONFIG":         true,
+	"K_LEADER_ELECTION_CONFIG": true,
+}
+
+// MakeSourceFromDeployment returns the CephSource equivalent to a receive
+// adapter deployed by hand, from the environment of its first container,
+// and the environment variables it has no equivalent for.
+func MakeSourceFromDeployment(d *v1.Deployment) (*v1alpha1.CephSource, []string, error) {
+	containers := d.Spec.Template.Spec.Containers
+	if len(containers) == 0 {
+		return nil, nil, errors.New("the deployment has no container")
+	}
+
+	env := make(map[string]corev1.EnvVar, len(containers[0].Env))
+	for _, e := range containers[0].Env {
+		env[e.Name] = e
+	}
+	migrated := make(map[string]bool, len(env))
+	value := func(name string) string {
+		e, ok := env[name]
+		if !ok || e.ValueFrom != nil {
+			return ""
+		}
+		migrated[name] = true
+		return e.Value
+	}
+	list := func(name string) []string {
+		if v := value(name); v != "" {
+			return strings.Split(v, ",")
+		}
+		return nil
+	}
+	flag := func(name string) bool {
+		s := value(name)
+		if s == "" {
+			return false
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			delete(migrated, name)
+		}
+		return b
+	}
+
+	sink, err := apis.ParseURL(value("K_SINK"))
+	if err != nil || sink == nil {
+		return nil, nil, errors.New("the deployment has no K_SINK to migrate")
+	}
+
+	src := &v1alpha1.CephSource{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   d.Namespace,
+			Name:        d.Name,
+			Annotations: map[string]string{MigratedFromAnnotation: d.Name},
+		},
+		Spec: v1alpha1.CephSourceSpec{
+			ServiceAccountName: d.Spec.Template.Spec.ServiceAccountName,
+			Port:               value("PORT"),
+			Profile:            value("PROFILE"),
+			Payload:            value("PAYLOAD"),
+			SpecVersion:        value("SPEC_VERSION"),
+			ContentMode:        value("CONTENT_MODE"),
+			Format:             value("FORMAT"),
+			Backpressure:       flag("BACKPRESSURE"),
+			SkipDuplicates:     flag("SKIP_DUPLICATES"),
+			DecodeKeys:         flag("DECODE_KEYS"),
+			CopySource:         flag("COPY_SOURCE"),
+		},
+	}
+	src.Spec.Sink = duckv1.Destination{URI: sink}
+	// The overrides, e.g. injected by a SinkBinding, are set on the events
+	// of the CephSource by its own SinkBinding.
+	if s := value("K_CE_OVERRIDES"); s != "" {
+		var overrides duckv1.CloudEventOverrides
+		if err := json.Unmarshal([]byte(s), &overrides); err == nil {
+			src.Spec.CloudEventOverrides = &overrides
+		} else {
+			delete(migrated, "K_CE_OVERRIDES")
+		}
+	}
+
+	if s := value("SUCCESS_STATUS"); s != "" {
+		if status, err := strconv.Atoi(s); err == nil {
+			src.Spec.SuccessStatus = int32Ptr(status)
+		} else {
+			delete(migrated, "SUCCESS_STATUS")
+		}
+	}
+	if s := value("MAX_CONCURRENCY"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil {
+			src.Spec.MaxConcurrency = int32Ptr(n)
+		} else {
+			delete(migrated, "MAX_CONCURRENCY")
+		}
+	}
+
+	filter := &v1alpha1.CephSourceFilter{
+		EventNames:    list("FILTER_EVENT_NAMES"),
+		DeleteMarkers: value("FILTER_DELETE_MARKERS"),
+	}
+	if s := value("FILTER_VERSIONED"); s != "" {
+		if versioned, err := strconv.ParseBool(s); err == nil {
+			filter.Versioned = &versioned
+		} else {
+			delete(migrated, "FILTER_VERSIONED")
+		}
+	}
+	if len(filter.EventNames) > 0 || filter.Versioned != nil || filter.DeleteMarkers != "" {
+		src.Spec.Filter = filter
+	}
+
+	// The tokens are referenced from the Secrets of the Deployment, by
+	// the index of their path and host. The auth is migrated as a whole or
+	// not at all.
+	paths, hosts := list("AUTH_PATHS"), list("AUTH_HOSTS")
+	auths := make([]v1alpha1.CephSourceAuth, 0, len(paths))
+	for i, path := range paths {
+		token, ok := env[fmt.Sprintf("AUTH_TOKEN_%d", i)]
+		if !ok || token.ValueFrom == nil || token.ValueFrom.SecretKeyRef == nil {
+			break
+		}
+		auth := v1alpha1.CephSourceAuth{Path: path, Token: *token.ValueFrom.SecretKeyRef}
+		if i < len(hosts) {
+			auth.Host = hosts[i]
+		}
+		auths = append(auths, auth)
+	}
+	switch {
+	case len(auths) < len(paths):
+		delete(migrated, "AUTH_PATHS")
+		delete(migrated, "AUTH_HOSTS")
+	case len(auths) > 0:
+		for i := range auths {
+			migrated[fmt.Sprintf("AUTH_TOKEN_%d", i)] = true
+		}
+		src.Spec.Auth = auths
+	}
+
+	var unmigrated []string
+	for name := range env {
+		if !migrated[name] && !ignoredEnv[name] {
+			unmigrated = append(unmigrated, name)
+		}
+	}
+	sort.Strings(unmigrated)
+	if len(unmigrated) > 0 {
+		src.Annotations[UnmigratedEnvAnnotation] = strings.Join(unmigrated, ",")
+	}
+	return src, unmigrated, nil
+}
+
+func int32Ptr(i int) *int32 {
+	i32 := int32(i)
+	return &i32
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/apis"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func migratedDeployment(env ...corev1.EnvVar) *v1.Deployment {
+	return &v1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "adapter"},
+		Spec: v1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ServiceAccountName: "ceph",
+					Containers:         []corev1.Container{{Name: "adapter", Env: env}},
+				},
+			},
+		},
+	}
+}
+
+func migratedSource(annotations map[string]string, spec func(*v1alpha1.CephSourceSpec)) *v1alpha1.CephSource {
+	a := map[string]string{MigratedFromAnnotation: "adapter"}
+	for k, v := range annotations {
+		a[k] = v
+	}
+	src := &v1alpha1.CephSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "adapter", Annotations: a},
+		Spec: v1alpha1.CephSourceSpec{
+			ServiceAccountName: "ceph",
+			SourceSpec: duckv1.SourceSpec{
+				Sink: duckv1.Destination{URI: apis.HTTP("sink.ns.svc")},
+			},
+		},
+	}
+	if spec != nil {
+		spec(&src.Spec)
+	}
+	return src
+}
+
+func secretEnv(name, secret string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: secret}, Key: "token"},
+		},
+	}
+}
+
+func TestMakeSourceFromDeployment(t *testing.T) {
+	sink := corev1.EnvVar{Name: "K_SINK", Value: "http://sink.ns.svc"}
+	versioned := true
+	for name, tc := range map[string]struct {
+		env        []corev1.EnvVar
+		want       *v1alpha1.CephSource
+		unmigrated []string
+	}{
+		"sink only": {
+			env:  []corev1.EnvVar{sink, {Name: "NAMESPACE", Value: "ns"}, {Name: "K_METRICS_CONFIG", Value: "{}"}},
+			want: migratedSource(nil, nil),
+		},
+		"settings": {
+			env: []corev1.EnvVar{sink,
+				{Name: "PORT", Value: "8080"},
+				{Name: "FORMAT", Value: "minio"},
+				{Name: "BACKPRESSURE", Value: "true"},
+				{Name: "SKIP_DUPLICATES", Value: "yes"},
+				{Name: "SUCCESS_STATUS", Value: "202"},
+				{Name: "MAX_CONCURRENCY", Value: "8"},
+				{Name: "K_CE_OVERRIDES", Value: `{"extensions":{"team":"storage"}}`},
+			},
+			want: migratedSource(map[string]string{UnmigratedEnvAnnotation: "SKIP_DUPLICATES"}, func(spec *v1alpha1.CephSourceSpec) {
+				spec.Port = "8080"
+				spec.Format = "minio"
+				spec.Backpressure = true
+				spec.SuccessStatus = int32Ptr(202)
+				spec.MaxConcurrency = int32Ptr(8)
+				spec.CloudEventOverrides = &duckv1.CloudEventOverrides{Extensions: map[string]string{"team": "storage"}}
+			}),
+			unmigrated: []string{"SKIP_DUPLICATES"},
+		},
+		"filter": {
+			env: []corev1.EnvVar{sink,
+				{Name: "FILTER_EVENT_NAMES", Value: "s3:ObjectCreated:Put,s3:ObjectRemoved:Delete"},
+				{Name: "FILTER_VERSIONED", Value: "true"},
+			},
+			want: migratedSource(nil, func(spec *v1alpha1.CephSourceSpec) {
+				spec.Filter = &v1alpha1.CephSourceFilter{
+					EventNames: []string{"s3:ObjectCreated:Put", "s3:ObjectRemoved:Delete"},
+					Versioned:  &versioned,
+				}
+			}),
+		},
+		"invalid values": {
+			env: []corev1.EnvVar{sink,
+				{Name: "SUCCESS_STATUS", Value: "ok"},
+				{Name: "FILTER_VERSIONED", Value: "maybe"},
+				{Name: "K_CE_OVERRIDES", Value: "{"},
+			},
+			want:       migratedSource(map[string]string{UnmigratedEnvAnnotation: "FILTER_VERSIONED,K_CE_OVERRIDES,SUCCESS_STATUS"}, nil),
+			unmigrated: []string{"FILTER_VERSIONED", "K_CE_OVERRIDES", "SUCCESS_STATUS"},
+		},
+		"auth": {
+			env: []corev1.EnvVar{sink,
+				{Name: "AUTH_PATHS", Value: "/a,/b"},
+				{Name: "AUTH_HOSTS", Value: "a.example.com"},
+				secretEnv("AUTH_TOKEN_0", "token-a"),
+				secretEnv("AUTH_TOKEN_1", "token-b"),
+			},
+			want: migratedSource(nil, func(spec *v1alpha1.CephSourceSpec) {
+				spec.Auth = []v1alpha1.CephSourceAuth{{
+					Path:  "/a",
+					Host:  "a.example.com",
+					Token: *secretEnv("", "token-a").ValueFrom.SecretKeyRef,
+				}, {
+					Path:  "/b",
+					Token: *secretEnv("", "token-b").ValueFrom.SecretKeyRef,
+				}}
+			}),
+		},
+		"auth without a token": {
+			env: []corev1.EnvVar{sink,
+				{Name: "AUTH_PATHS", Value: "/a,/b"},
+				secretEnv("AUTH_TOKEN_0", "token-a"),
+			},
+			want:       migratedSource(map[string]string{UnmigratedEnvAnnotation: "AUTH_PATHS,AUTH_TOKEN_0"}, nil),
+			unmigrated: []string{"AUTH_PATHS", "AUTH_TOKEN_0"},
+		},
+		"secret values": {
+			env:        []corev1.EnvVar{sink, secretEnv("FORMAT", "format")},
+			want:       migratedSource(map[string]string{UnmigratedEnvAnnotation: "FORMAT"}, nil),
+			unmigrated: []string{"FORMAT"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, unmigrated, err := MakeSourceFromDeployment(migratedDeployment(tc.env...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Error("Unexpected source (-want, +got):", diff)
+			}
+			if diff := cmp.Diff(tc.unmigrated, unmigrated); diff != "" {
+				t.Error("Unexpected unmigrated env (-want, +got):", diff)
+			}
+		})
+	}
+}
+
+func TestMakeSourceFromDeploymentInvalid(t *testing.T) {
+	for name, d := range map[string]*v1.Deployment{
+		"no container": {ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "adapter"}},
+		"no sink":      migratedDeployment(corev1.EnvVar{Name: "PORT", Value: "8080"}),
+		"secret sink":  migratedDeployment(secretEnv("K_SINK", "sink")),
+	} {
+		if _, _, err := MakeSourceFromDeployment(d); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
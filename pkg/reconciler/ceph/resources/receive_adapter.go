@@ -17,16 +17,117 @@ limitations under the License.
 package resources
 
 import (
+	"encoding/json"
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
 
 	v1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"knative.dev/pkg/kmeta"
 
 	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
 )
 
+const (
+	retentionVolumeName   = "failed-events"
+	queueVolumeName       = "queue"
+	routesVolumeName      = "routes"
+	configFileVolumeName  = "config-file"
+	checkpointVolumeName  = "checkpoints"
+	credentialsVolumeName = "credentials"
+
+	// defaultRoutesKey is the key of the routing table in its ConfigMap,
+	// unless set otherwise.
+	defaultRoutesKey = "routes.yaml"
+
+	// defaultConfigFileKey is the key of the configuration file in its
+	// ConfigMap, unless set.
+	defaultConfigFileKey = "config.yaml"
+
+	// RetentionDir is where the receive adapter retains the events that
+	// could not be delivered.
+	RetentionDir = "/var/lib/ceph-source/failed-events"
+
+	// CheckpointDir is where the receive adapter persists the checkpoints of
+	// the processed notifications.
+	CheckpointDir = "/var/lib/ceph-source/checkpoints"
+
+	// QueueDir is where the receiver queues the events delivered by the
+	// dispatcher.
+	QueueDir = "/var/lib/ceph-source/queue"
+
+	// RoutesDir is where the ConfigMap of the routing table is mounted.
+	RoutesDir = "/etc/ceph-source/routes"
+
+	// ConfigFileDir is where the ConfigMap of the configuration file is
+	// mounted.
+	ConfigFileDir = "/etc/ceph-source/config"
+
+	// ListenersDir is where the TLS Secrets of the listeners are mounted,
+	// in a directory named after each listener.
+	ListenersDir = "/etc/ceph-source/listeners"
+
+	// CredentialsDir is where the CSI volume holding the RGW credentials is
+	// mounted.
+	CredentialsDir = "/etc/ceph-source/credentials"
+)
+
+const (
+	// meshBindAddress is the address the adapter listens on behind a
+	// service mesh sidecar.
+	meshBindAddress = "127.0.0.1"
+
+	// meshExcludedPorts are the metrics and profiling ports of the adapter,
+	// kept out of the sidecar.
+	meshExcludedPorts = "9090,8008"
+)
+
+// MeshAnnotationPrefixes are the prefixes of the pod annotations set for
+// service meshes, which are removed from the adapter when no longer set.
+var MeshAnnotationPrefixes = []string{
+	"sidecar.istio.io/",
+	"traffic.sidecar.istio.io/",
+	"linkerd.io/",
+	"config.linkerd.io/",
+}
+
+// maintenanceWindow is the form of the maintenance windows passed to the
+// receive adapter.
+type maintenanceWindow struct {
+	Schedule string `json:"schedule"`
+	Duration string `json:"duration"`
+	Action   string `json:"action,omitempty"`
+}
+
+// trafficExpectation is the form of the traffic expectations passed to the
+// receive adapter.
+type trafficExpectation struct {
+	EventName string `json:"eventName"`
+	MinCount  int32  `json:"minCount,omitempty"`
+	Period    string `json:"period"`
+}
+
+// listener is the form of the additional listeners passed to the receive
+// adapter, whose tokens are read from LISTENER_<i>_AUTH_TOKEN_<j>.
+type listener struct {
+	Name                 string   `json:"name"`
+	Port                 string   `json:"port"`
+	BindAddress          string   `json:"bindAddress,omitempty"`
+	TLSDir               string   `json:"tlsDir,omitempty"`
+	ClientAuth           bool     `json:"clientAuth,omitempty"`
+	AuthPaths            []string `json:"authPaths,omitempty"`
+	AuthHosts            []string `json:"authHosts,omitempty"`
+	FilterEventNames     []string `json:"filterEventNames,omitempty"`
+	FilterVersioned      *bool    `json:"filterVersioned,omitempty"`
+	FilterDeleteMarkers  string   `json:"filterDeleteMarkers,omitempty"`
+	FilterEncryption     string   `json:"filterEncryption,omitempty"`
+	FilterStorageClasses []string `json:"filterStorageClasses,omitempty"`
+}
+
 // ReceiveAdapterArgs are the arguments needed to create a Ceph Source Receive Adapter.
 // Every field is required.
 type ReceiveAdapterArgs struct {
@@ -34,16 +135,22 @@ type ReceiveAdapterArgs struct {
 	Labels         map[string]string
 	Source         *v1alpha1.CephSource
 	AdditionalEnvs []corev1.EnvVar
+
+	// ImagePullSecrets and Resources, if any, are the pull secrets of the
+	// image and the resources of the sources leaving spec.resources unset,
+	// from the adapter policy of their namespace.
+	ImagePullSecrets []corev1.LocalObjectReference
+	Resources        *corev1.ResourceRequirements
 }
 
 // MakeReceiveAdapter generates (but does not insert into K8s) the Receive Adapter Deployment for
 // Ceph sources.
 func MakeReceiveAdapter(args *ReceiveAdapterArgs) *v1.Deployment {
 	replicas := int32(1)
-	return &v1.Deployment{
+	deployment := &v1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: args.Source.Namespace,
-			Name:      kmeta.ChildName(fmt.Sprintf("cephsource-%s-", args.Source.Name), string(args.Source.GetUID())),
+			Name:      receiveAdapterName(args.Source),
 			Labels:    args.Labels,
 			OwnerReferences: []metav1.OwnerReference{
 				*kmeta.NewControllerRef(args.Source),
@@ -56,46 +163,1144 @@ func MakeReceiveAdapter(args *ReceiveAdapterArgs) *v1.Deployment {
 			Replicas: &replicas,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: args.Labels,
+					Labels:      args.Labels,
+					Annotations: podAnnotations(args.Source),
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: args.Source.Spec.ServiceAccountName,
+					ImagePullSecrets:   args.ImagePullSecrets,
 					Containers: []corev1.Container{
 						{
 							Name:  "receive-adapter",
 							Image: args.Image,
 							Env: append(
-								makeEnv(&args.Source.Spec),
+								makeEnv(args.Source),
 								args.AdditionalEnvs...,
 							),
+							ReadinessProbe: readinessProbe(args.Source),
 						},
 					},
 				},
 			},
 		},
 	}
+
+	if resources := args.resources(); resources != nil {
+		deployment.Spec.Template.Spec.Containers[0].Resources = *resources
+	}
+
+	mountRetention(&deployment.Spec.Template.Spec, args.Source)
+	mountRoutes(&deployment.Spec.Template.Spec, args.Source)
+	mountConfigFile(&deployment.Spec.Template.Spec, args.Source)
+	mountCheckpoints(&deployment.Spec.Template.Spec, args.Source)
+	mountListeners(&deployment.Spec.Template.Spec, args.Source)
+	mountCredentials(&deployment.Spec.Template.Spec, args.Source)
+	if dispatcher := args.Source.Spec.Dispatcher; dispatcher != nil {
+		mountVolume(&deployment.Spec.Template.Spec, queueVolumeName, dispatcher.Queue, QueueDir)
+	}
+
+	return deployment
 }
 
-func makeEnv(spec *v1alpha1.CephSourceSpec) []corev1.EnvVar {
+// readinessProbe returns the probe of /ready, served on the admin port if
+// any, which fails until the adapter listens on its ports, e.g. when one is
+// in use and PORT_FALLBACK bound an ephemeral port instead. The defaults of
+// the API server are set for the probe to compare equal once created.
+func readinessProbe(source *v1alpha1.CephSource) *corev1.Probe {
+	port := source.Spec.Port
+	if admin := source.Spec.Admin; admin != nil {
+		port = admin.Port
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p == 0 {
+		return nil
+	}
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   "/ready",
+				Port:   intstr.FromInt(p),
+				Scheme: corev1.URISchemeHTTP,
+			},
+		},
+		TimeoutSeconds:   1,
+		PeriodSeconds:    10,
+		SuccessThreshold: 1,
+		FailureThreshold: 3,
+	}
+}
+
+// resources returns the resources of the containers, the ones of the source
+// if set.
+func (args *ReceiveAdapterArgs) resources() *corev1.ResourceRequirements {
+	if args.Source.Spec.Resources != nil {
+		return args.Source.Spec.Resources
+	}
+	return args.Resources
+}
+
+// mountRetention mounts the volume events are retained on, if enabled.
+func mountRetention(spec *corev1.PodSpec, source *v1alpha1.CephSource) {
+	retention := source.Spec.Retention
+	if retention == nil {
+		return
+	}
+	volume := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	if retention.Volume != nil {
+		volume = *retention.Volume
+	}
+	mountVolume(spec, retentionVolumeName, volume, RetentionDir)
+}
+
+// mountCheckpoints mounts the volume checkpoints are persisted on, if
+// enabled.
+func mountCheckpoints(spec *corev1.PodSpec, source *v1alpha1.CephSource) {
+	checkpoint := source.Spec.Checkpoint
+	if checkpoint == nil {
+		return
+	}
+	volume := corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	if checkpoint.Volume != nil {
+		volume = *checkpoint.Volume
+	}
+	mountVolume(spec, checkpointVolumeName, volume, CheckpointDir)
+}
+
+// mountCredentials mounts the CSI volume holding the RGW credentials, if
+// they are sourced from one.
+func mountCredentials(spec *corev1.PodSpec, source *v1alpha1.CephSource) {
+	from := source.Spec.CredentialsFrom
+	if from == nil || from.CSI == nil {
+		return
+	}
+	mountVolume(spec, credentialsVolumeName, corev1.VolumeSource{CSI: from.CSI}, CredentialsDir)
+}
+
+// credentialsEnv returns the env var of the directory the RGW credentials
+// are read from, if sourced from an external secret manager.
+func credentialsEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	from := source.Spec.CredentialsFrom
+	if from == nil {
+		return nil
+	}
+	dir := from.Dir
+	if from.CSI != nil {
+		dir = CredentialsDir
+	}
 	return []corev1.EnvVar{{
-		Name: "NAMESPACE",
-		ValueFrom: &corev1.EnvVarSource{
-			FieldRef: &corev1.ObjectFieldSelector{
-				FieldPath: "metadata.namespace",
+		Name:  "CREDENTIALS_DIR",
+		Value: dir,
+	}}
+}
+
+// rgwTLSEnv returns the env vars of the certificates accepted from the RGW,
+// if restricted.
+func rgwTLSEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	rgwTLS := source.Spec.RGWTLS
+	if rgwTLS == nil {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if rgwTLS.CABundle != nil {
+		env = append(env, corev1.EnvVar{
+			Name: "RGW_CA_BUNDLE",
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: rgwTLS.CABundle,
 			},
+		})
+	}
+	if len(rgwTLS.PinnedKeys) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "RGW_PINNED_KEYS",
+			Value: strings.Join(rgwTLS.PinnedKeys, ","),
+		})
+	}
+	return env
+}
+
+// rgwEnv returns the env vars of the RGW endpoint and credentials of a
+// feature sending requests to the RGW, prefixed with its name, e.g.
+// EXPIRY_PREVIEW_ENDPOINT.
+func rgwEnv(source *v1alpha1.CephSource, f v1alpha1.RGWFeature) []corev1.EnvVar {
+	prefix := strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+	env := []corev1.EnvVar{{
+		Name:  prefix + "_ENDPOINT",
+		Value: f.RGW.Endpoint.String(),
+	}, {
+		Name:  prefix + "_REGION",
+		Value: f.RGW.Region,
+	}}
+	return append(env, s3CredentialsEnv(source, prefix, f.RGW)...)
+}
+
+// s3CredentialsEnv returns the env vars of the RGW credentials of a feature,
+// of the given prefix, referencing their Secret keys unless they are sourced
+// from an external secret manager, and those of the next key, if any.
+func s3CredentialsEnv(source *v1alpha1.CephSource, prefix string, rgw *v1alpha1.CephSourceRGWCredentials) []corev1.EnvVar {
+	if source.Spec.CredentialsFrom != nil {
+		return nil
+	}
+	env := []corev1.EnvVar{{
+		Name: prefix + "_ACCESS_KEY_ID",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &rgw.AccessKeyID,
 		},
 	}, {
-		Name: "NAME",
+		Name: prefix + "_SECRET_ACCESS_KEY",
 		ValueFrom: &corev1.EnvVarSource{
-			FieldRef: &corev1.ObjectFieldSelector{
-				FieldPath: "metadata.name",
-			},
+			SecretKeyRef: &rgw.SecretAccessKey,
 		},
+	}}
+	if next := rgw.NextCredentials; next != nil {
+		env = append(env, corev1.EnvVar{
+			Name: prefix + "_NEXT_ACCESS_KEY_ID",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &next.AccessKeyID,
+			},
+		}, corev1.EnvVar{
+			Name: prefix + "_NEXT_SECRET_ACCESS_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &next.SecretAccessKey,
+			},
+		})
+	}
+	return env
+}
+
+// checkpointEnv returns the env vars enabling the checkpoints of the
+// processed notifications, if any.
+func checkpointEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	checkpoint := source.Spec.Checkpoint
+	if checkpoint == nil {
+		return nil
+	}
+	env := []corev1.EnvVar{{
+		Name:  "CHECKPOINT_DIR",
+		Value: CheckpointDir,
+	}}
+	if checkpoint.PrefixDepth != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "CHECKPOINT_PREFIX_DEPTH",
+			Value: strconv.Itoa(int(*checkpoint.PrefixDepth)),
+		})
+	}
+	if checkpoint.SkipRedelivered {
+		env = append(env, corev1.EnvVar{
+			Name:  "CHECKPOINT_SKIP",
+			Value: "true",
+		})
+	}
+	return env
+}
+
+// mountListeners mounts the TLS Secrets of the listeners, if any.
+func mountListeners(spec *corev1.PodSpec, source *v1alpha1.CephSource) {
+	for i, l := range source.Spec.Listeners {
+		if l.TLS == nil {
+			continue
+		}
+		mountVolume(spec, fmt.Sprintf("listener-tls-%d", i), corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: l.TLS.SecretName},
+		}, path.Join(ListenersDir, l.Name))
+	}
+}
+
+// listenersEnv returns the env vars of the additional listeners, if any.
+func listenersEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	if len(source.Spec.Listeners) == 0 {
+		return nil
+	}
+	var env []corev1.EnvVar
+	listeners := make([]listener, 0, len(source.Spec.Listeners))
+	for i, l := range source.Spec.Listeners {
+		ln := listener{
+			Name:        l.Name,
+			Port:        l.Port,
+			BindAddress: l.BindAddress,
+		}
+		if l.TLS != nil {
+			ln.TLSDir = path.Join(ListenersDir, l.Name)
+			ln.ClientAuth = l.TLS.ClientAuth
+		}
+		for j, auth := range l.Auth {
+			ln.AuthPaths = append(ln.AuthPaths, auth.Path)
+			ln.AuthHosts = append(ln.AuthHosts, auth.Host)
+			token := auth.Token
+			env = append(env, corev1.EnvVar{
+				Name: fmt.Sprintf("LISTENER_%d_AUTH_TOKEN_%d", i, j),
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &token,
+				},
+			})
+		}
+		if filter := l.Filter; filter != nil {
+			ln.FilterEventNames = filter.EventNames
+			ln.FilterVersioned = filter.Versioned
+			ln.FilterDeleteMarkers = filter.DeleteMarkers
+			ln.FilterEncryption = filter.Encryption
+			ln.FilterStorageClasses = filter.StorageClasses
+		}
+		listeners = append(listeners, ln)
+	}
+	value, _ := json.Marshal(listeners)
+	return append(env, corev1.EnvVar{
+		Name:  "LISTENERS",
+		Value: string(value),
+	})
+}
+
+// mountRoutes mounts the ConfigMap of the routing table, if any.
+func mountRoutes(spec *corev1.PodSpec, source *v1alpha1.CephSource) {
+	routes := source.Spec.Routes
+	if routes == nil {
+		return
+	}
+	mountVolume(spec, routesVolumeName, corev1.VolumeSource{
+		ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: routes.ConfigMap},
+	}, RoutesDir)
+}
+
+// routesEnv returns the env var of the routing table, if any.
+func routesEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	routes := source.Spec.Routes
+	if routes == nil {
+		return nil
+	}
+	key := routes.Key
+	if key == "" {
+		key = defaultRoutesKey
+	}
+	return []corev1.EnvVar{{
+		Name:  "ROUTES_FILE",
+		Value: path.Join(RoutesDir, key),
+	}}
+}
+
+// mountConfigFile mounts the ConfigMap of the configuration file, if any.
+func mountConfigFile(spec *corev1.PodSpec, source *v1alpha1.CephSource) {
+	file := source.Spec.ConfigFile
+	if file == nil {
+		return
+	}
+	mountVolume(spec, configFileVolumeName, corev1.VolumeSource{
+		ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: file.ConfigMap},
+	}, ConfigFileDir)
+}
+
+// configFileEnv returns the env var of the configuration file, if any.
+func configFileEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	file := source.Spec.ConfigFile
+	if file == nil {
+		return nil
+	}
+	key := file.Key
+	if key == "" {
+		key = defaultConfigFileKey
+	}
+	return []corev1.EnvVar{{
+		Name:  "CONFIG_FILE",
+		Value: path.Join(ConfigFileDir, key),
+	}}
+}
+
+// mountVolume adds a volume to a pod, mounted at path in its container.
+func mountVolume(spec *corev1.PodSpec, name string, volume corev1.VolumeSource, path string) {
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name:         name,
+		VolumeSource: volume,
+	})
+	spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:      name,
+		MountPath: path,
+	})
+}
+
+// podAnnotations returns the pod annotations of the receive adapter, of its
+// service mesh and external secret manager.
+func podAnnotations(source *v1alpha1.CephSource) map[string]string {
+	annotations := meshAnnotations(source)
+	for k, v := range credentialsAnnotations(source) {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// credentialsAnnotations returns the pod annotations having an external
+// secret manager write the RGW credentials, if any.
+func credentialsAnnotations(source *v1alpha1.CephSource) map[string]string {
+	from := source.Spec.CredentialsFrom
+	if from == nil || len(from.Annotations) == 0 {
+		return nil
+	}
+	annotations := make(map[string]string, len(from.Annotations))
+	for k, v := range from.Annotations {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// meshAnnotations returns the pod annotations injecting the sidecar of the
+// service mesh of a source, capturing the notifications port only.
+func meshAnnotations(source *v1alpha1.CephSource) map[string]string {
+	excluded := meshExcludedPorts
+	if source.Spec.Admin != nil {
+		excluded += "," + source.Spec.Admin.Port
+	}
+	switch source.Spec.Mesh {
+	case v1alpha1.MeshIstio:
+		return map[string]string{
+			"sidecar.istio.io/inject":                      "true",
+			"traffic.sidecar.istio.io/includeInboundPorts": source.Spec.Port,
+			"traffic.sidecar.istio.io/excludeInboundPorts": excluded,
+		}
+	case v1alpha1.MeshLinkerd:
+		return map[string]string{
+			"linkerd.io/inject":                    "enabled",
+			"config.linkerd.io/skip-inbound-ports": excluded,
+		}
+	default:
+		return nil
+	}
+}
+
+// receiveAdapterName returns the name of the receive adapter Deployment, and
+// of the objects exposing it.
+func receiveAdapterName(source *v1alpha1.CephSource) string {
+	return kmeta.ChildName(fmt.Sprintf("cephsource-%s-", source.Name), string(source.GetUID()))
+}
+
+func makeEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	env := []corev1.EnvVar{{
+		// NAMESPACE and NAME identify the owning CephSource so that metrics
+		// and logs of the adapter are attributed to it rather than to the pod.
+		Name:  "NAMESPACE",
+		Value: source.Namespace,
+	}, {
+		Name:  "NAME",
+		Value: source.Name,
 	}, {
 		Name:  "PORT",
-		Value: spec.Port,
+		Value: source.Spec.Port,
 	}, {
 		Name:  "METRICS_DOMAIN",
 		Value: "knative.dev/eventing",
 	}}
+
+	if source.Spec.Mesh != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "BIND_ADDRESS",
+			Value: meshBindAddress,
+		})
+	}
+
+	if source.Spec.Profile != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "PROFILE",
+			Value: source.Spec.Profile,
+		})
+	}
+
+	if source.Spec.TypePrefix != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "TYPE_PREFIX",
+			Value: source.Spec.TypePrefix,
+		})
+	}
+
+	if source.Spec.Payload != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "PAYLOAD",
+			Value: source.Spec.Payload,
+		})
+	}
+
+	env = append(env, encodingEnv(source)...)
+
+	if u := DataSchemaURL(source); u != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "DATA_SCHEMA",
+			Value: u.String(),
+		})
+	}
+
+	if source.Spec.Format != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "FORMAT",
+			Value: source.Spec.Format,
+		})
+	}
+
+	if len(source.Spec.Auth) > 0 {
+		paths := make([]string, 0, len(source.Spec.Auth))
+		hosts := make([]string, 0, len(source.Spec.Auth))
+		byHost := false
+		for i, auth := range source.Spec.Auth {
+			paths = append(paths, auth.Path)
+			hosts = append(hosts, auth.Host)
+			byHost = byHost || auth.Host != ""
+			token := auth.Token
+			env = append(env, corev1.EnvVar{
+				Name: fmt.Sprintf("AUTH_TOKEN_%d", i),
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &token,
+				},
+			})
+		}
+		env = append(env, corev1.EnvVar{
+			Name:  "AUTH_PATHS",
+			Value: strings.Join(paths, ","),
+		})
+		if byHost {
+			env = append(env, corev1.EnvVar{
+				Name:  "AUTH_HOSTS",
+				Value: strings.Join(hosts, ","),
+			})
+		}
+	}
+
+	if sigV4 := source.Spec.SigV4; sigV4 != nil {
+		ids := make([]string, 0, len(sigV4.Keys))
+		for i, key := range sigV4.Keys {
+			ids = append(ids, key.AccessKeyID)
+			secret := key.SecretAccessKey
+			env = append(env, corev1.EnvVar{
+				Name: fmt.Sprintf("SIGV4_SECRET_ACCESS_KEY_%d", i),
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &secret,
+				},
+			})
+		}
+		env = append(env, corev1.EnvVar{
+			Name:  "SIGV4_ACCESS_KEY_IDS",
+			Value: strings.Join(ids, ","),
+		})
+		if sigV4.MaxSkew != nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "SIGV4_MAX_SKEW",
+				Value: sigV4.MaxSkew.Duration.String(),
+			})
+		}
+		if sigV4.AllowUnsignedPayload {
+			env = append(env, corev1.EnvVar{
+				Name:  "SIGV4_ALLOW_UNSIGNED_PAYLOAD",
+				Value: "true",
+			})
+		}
+	}
+
+	if subject := source.Spec.Subject; subject != nil {
+		if subject.Escape {
+			env = append(env, corev1.EnvVar{
+				Name:  "SUBJECT_ESCAPE",
+				Value: "true",
+			})
+		}
+		if subject.MaxLength != nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "SUBJECT_MAX_LENGTH",
+				Value: strconv.Itoa(int(*subject.MaxLength)),
+			})
+		}
+		if subject.Overflow != "" {
+			env = append(env, corev1.EnvVar{
+				Name:  "SUBJECT_OVERFLOW",
+				Value: subject.Overflow,
+			})
+		}
+	}
+
+	if admin := source.Spec.Admin; admin != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "ADMIN_PORT",
+			Value: admin.Port,
+		})
+		if admin.Token != nil {
+			env = append(env, corev1.EnvVar{
+				Name: "ADMIN_TOKEN",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: admin.Token.DeepCopy(),
+				},
+			})
+		}
+	}
+
+	if source.Spec.Backpressure {
+		env = append(env, corev1.EnvVar{
+			Name:  "BACKPRESSURE",
+			Value: "true",
+		})
+	}
+
+	if source.Spec.SuccessStatus != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "SUCCESS_STATUS",
+			Value: strconv.Itoa(int(*source.Spec.SuccessStatus)),
+		})
+	}
+
+	if source.Spec.EmptyNotifications != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "EMPTY_NOTIFICATIONS",
+			Value: source.Spec.EmptyNotifications,
+		})
+	}
+
+	if source.Spec.SkipDuplicates {
+		env = append(env, corev1.EnvVar{
+			Name:  "SKIP_DUPLICATES",
+			Value: "true",
+		})
+	}
+
+	if source.Spec.MaxConcurrency != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "MAX_CONCURRENCY",
+			Value: strconv.Itoa(int(*source.Spec.MaxConcurrency)),
+		})
+	}
+
+	if source.Spec.DecodeKeys {
+		env = append(env, corev1.EnvVar{
+			Name:  "DECODE_KEYS",
+			Value: "true",
+		})
+	}
+
+	if source.Spec.CopySource {
+		env = append(env, corev1.EnvVar{
+			Name:  "COPY_SOURCE",
+			Value: "true",
+		})
+	}
+
+	if senders := source.Spec.Senders; senders != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "SENDER_EVENT_SOURCES",
+			Value: strings.Join(senders.EventSources, ","),
+		}, corev1.EnvVar{
+			Name:  "SENDER_REGIONS",
+			Value: strings.Join(senders.Regions, ","),
+		}, corev1.EnvVar{
+			Name:  "SENDER_ADDRESSES",
+			Value: strings.Join(senders.Addresses, ","),
+		})
+	}
+
+	if sampling := source.Spec.DebugLogSampling; sampling != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "DEBUG_LOG_SAMPLING_INITIAL",
+			Value: strconv.Itoa(int(sampling.Initial)),
+		}, corev1.EnvVar{
+			Name:  "DEBUG_LOG_SAMPLING_THEREAFTER",
+			Value: strconv.Itoa(int(sampling.Thereafter)),
+		})
+	}
+
+	if filter := source.Spec.Filter; filter != nil {
+		if len(filter.EventNames) > 0 {
+			env = append(env, corev1.EnvVar{
+				Name:  "FILTER_EVENT_NAMES",
+				Value: strings.Join(filter.EventNames, ","),
+			})
+		}
+		if filter.Versioned != nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "FILTER_VERSIONED",
+				Value: strconv.FormatBool(*filter.Versioned),
+			})
+		}
+		if filter.DeleteMarkers != "" {
+			env = append(env, corev1.EnvVar{
+				Name:  "FILTER_DELETE_MARKERS",
+				Value: filter.DeleteMarkers,
+			})
+		}
+		if filter.Encryption != "" {
+			env = append(env, corev1.EnvVar{
+				Name:  "FILTER_ENCRYPTION",
+				Value: filter.Encryption,
+			})
+		}
+		if len(filter.StorageClasses) > 0 {
+			env = append(env, corev1.EnvVar{
+				Name:  "FILTER_STORAGE_CLASSES",
+				Value: strings.Join(filter.StorageClasses, ","),
+			})
+		}
+	}
+
+	env = append(env, retentionEnv(source)...)
+	env = append(env, storageEnv(source)...)
+	env = append(env, deliveryEnv(source)...)
+	env = append(env, loadSheddingEnv(source)...)
+	env = append(env, sinkHeadersEnv(source)...)
+	env = append(env, dnsEnv(source)...)
+	env = append(env, tlsPolicyEnv(source)...)
+	if source.Spec.LifecycleEvents != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "LIFECYCLE_EVENTS",
+			Value: "true",
+		})
+		if uri := source.Status.LifecycleSinkURI; uri != nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "LIFECYCLE_SINK",
+				Value: uri.String(),
+			})
+		}
+	}
+	env = append(env, checkpointEnv(source)...)
+	for _, f := range source.Spec.RGWFeatures() {
+		env = append(env, rgwEnv(source, f)...)
+	}
+	env = append(env, usageEnv(source)...)
+	env = append(env, expiryPreviewEnv(source)...)
+	env = append(env, auditEnv(source)...)
+	env = append(env, credentialsEnv(source)...)
+	env = append(env, rgwTLSEnv(source)...)
+	env = append(env, listenersEnv(source)...)
+	env = append(env, routesEnv(source)...)
+	env = append(env, configFileEnv(source)...)
+	if size := source.Spec.MaxEventSize; size != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "MAX_EVENT_SIZE",
+			Value: strconv.FormatInt(size.Limit.Value(), 10),
+		})
+		if size.Policy != "" {
+			env = append(env, corev1.EnvVar{
+				Name:  "EVENT_SIZE_POLICY",
+				Value: size.Policy,
+			})
+		}
+	}
+	if source.Spec.Dispatcher != nil {
+		env = append(env, queueEnv(source, roleReceiver)...)
+	}
+
+	if eventTime := source.Spec.EventTime; eventTime != nil {
+		policy := eventTime.Policy
+		if policy == "" {
+			policy = v1alpha1.EventTimeFlag
+		}
+		env = append(env, corev1.EnvVar{
+			Name:  "EVENT_TIME_POLICY",
+			Value: policy,
+		})
+		if eventTime.MaxFuture != nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "EVENT_TIME_MAX_FUTURE",
+				Value: eventTime.MaxFuture.Duration.String(),
+			})
+		}
+		if eventTime.MaxAge != nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "EVENT_TIME_MAX_AGE",
+				Value: eventTime.MaxAge.Duration.String(),
+			})
+		}
+	}
+
+	if slo := source.Spec.AgeSLO; slo != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "AGE_SLO_THRESHOLD",
+			Value: slo.Threshold.Duration.String(),
+		})
+		if slo.Period != nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "AGE_SLO_PERIOD",
+				Value: slo.Period.Duration.String(),
+			})
+		}
+	}
+
+	if len(source.Spec.TrafficExpectations) > 0 {
+		expectations := make([]trafficExpectation, 0, len(source.Spec.TrafficExpectations))
+		for _, e := range source.Spec.TrafficExpectations {
+			expectation := trafficExpectation{
+				EventName: e.EventName,
+				Period:    e.Period.Duration.String(),
+			}
+			if e.MinCount != nil {
+				expectation.MinCount = *e.MinCount
+			}
+			expectations = append(expectations, expectation)
+		}
+		value, _ := json.Marshal(expectations)
+		env = append(env, corev1.EnvVar{
+			Name:  "TRAFFIC_EXPECTATIONS",
+			Value: string(value),
+		})
+	}
+
+	if len(source.Spec.MaintenanceWindows) > 0 {
+		windows := make([]maintenanceWindow, 0, len(source.Spec.MaintenanceWindows))
+		for _, w := range source.Spec.MaintenanceWindows {
+			windows = append(windows, maintenanceWindow{
+				Schedule: w.Schedule,
+				Duration: w.Duration.Duration.String(),
+				Action:   w.Action,
+			})
+		}
+		// Schedules may contain commas, so the windows are passed as JSON.
+		value, _ := json.Marshal(windows)
+		env = append(env, corev1.EnvVar{
+			Name:  "MAINTENANCE_WINDOWS",
+			Value: string(value),
+		})
+	}
+
+	if len(source.Spec.MetadataExtensions) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "METADATA_EXTENSIONS",
+			Value: strings.Join(source.Spec.MetadataExtensions, ","),
+		})
+	}
+
+	if len(source.Spec.DataProjection) > 0 {
+		// Expressions may contain commas, so they are passed as JSON.
+		value, _ := json.Marshal(source.Spec.DataProjection)
+		env = append(env, corev1.EnvVar{
+			Name:  "DATA_PROJECTION",
+			Value: string(value),
+		})
+	}
+
+	if source.Spec.DataTemplate != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "DATA_TEMPLATE",
+			Value: source.Spec.DataTemplate,
+		})
+	}
+
+	if len(source.Spec.BucketSources) > 0 {
+		// Sources may contain commas, so they are passed as JSON.
+		value, _ := json.Marshal(source.Spec.BucketSources)
+		env = append(env, corev1.EnvVar{
+			Name:  "BUCKET_SOURCES",
+			Value: string(value),
+		})
+	}
+
+	if u := source.Spec.SourceURI; u != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "SOURCE_SCHEME",
+			Value: u.Scheme,
+		})
+		if u.Cluster != "" {
+			env = append(env, corev1.EnvVar{
+				Name:  "SOURCE_CLUSTER",
+				Value: u.Cluster,
+			})
+		}
+	}
+
+	if len(source.Spec.RateLimits) > 0 {
+		limits := make([]string, 0, len(source.Spec.RateLimits))
+		for _, limit := range source.Spec.RateLimits {
+			burst := limit.EventsPerSecond
+			if limit.Burst != nil {
+				burst = *limit.Burst
+			}
+			limits = append(limits, fmt.Sprintf("%s=%d:%d", limit.Bucket, limit.EventsPerSecond, burst))
+		}
+		env = append(env, corev1.EnvVar{
+			Name:  "RATE_LIMITS",
+			Value: strings.Join(limits, ","),
+		})
+	}
+
+	return env
+}
+
+// encodingEnv returns the env vars of the CloudEvents version and content
+// mode of the events sent to the sink, if any.
+func encodingEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if source.Spec.SpecVersion != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "SPEC_VERSION",
+			Value: source.Spec.SpecVersion,
+		})
+	}
+	if source.Spec.ContentMode != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "CONTENT_MODE",
+			Value: source.Spec.ContentMode,
+		})
+	}
+	return env
+}
+
+// deliveryEnv returns the env vars of the retries, dead letter and failover
+// sinks of the events, if any.
+func deliveryEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	delivery := source.Spec.Delivery
+	if delivery == nil {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if delivery.Retry != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "DELIVERY_RETRY",
+			Value: strconv.Itoa(int(*delivery.Retry)),
+		})
+	}
+	if delivery.BackoffDelay != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "DELIVERY_BACKOFF_DELAY",
+			Value: delivery.BackoffDelay.Duration.String(),
+		})
+	}
+	if uri := source.Status.DeadLetterSinkURI; uri != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "DEAD_LETTER_SINK",
+			Value: uri.String(),
+		})
+	}
+	if uri := source.Status.FailureSinkURI; uri != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "FAILURE_SINK",
+			Value: uri.String(),
+		})
+	}
+	if uris := source.Status.FailoverSinkURIs; len(uris) > 0 {
+		sinks := make([]string, 0, len(uris))
+		for _, uri := range uris {
+			sinks = append(sinks, uri.String())
+		}
+		// As JSON, the URIs possibly holding commas in their query.
+		value, _ := json.Marshal(sinks)
+		env = append(env, corev1.EnvVar{
+			Name:  "FAILOVER_SINKS",
+			Value: string(value),
+		})
+		if failover := delivery.Failover; failover != nil && failover.Cooldown != nil {
+			env = append(env, corev1.EnvVar{
+				Name:  "FAILOVER_COOLDOWN",
+				Value: failover.Cooldown.Duration.String(),
+			})
+		}
+	}
+	return env
+}
+
+// loadSheddingEnv returns the env vars of the shedding of the notifications
+// of low priority, if any.
+func loadSheddingEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	if source.Spec.Delivery == nil || source.Spec.Delivery.LoadShedding == nil {
+		return nil
+	}
+	shedding := source.Spec.Delivery.LoadShedding
+	// The classes hold lists, so they are passed as JSON.
+	value, _ := json.Marshal(shedding.Priorities)
+	env := []corev1.EnvVar{{
+		Name:  "LOAD_SHEDDING_THRESHOLD",
+		Value: shedding.Threshold.Duration.String(),
+	}, {
+		Name:  "LOAD_SHEDDING_PRIORITIES",
+		Value: string(value),
+	}}
+	if shedding.Period != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "LOAD_SHEDDING_PERIOD",
+			Value: shedding.Period.Duration.String(),
+		})
+	}
+	return env
+}
+
+// dnsEnv returns the env vars of the resolution of the sink hostnames, if
+// any.
+func dnsEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	dns := source.Spec.DNS
+	if dns == nil {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if dns.CacheTTL != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "DNS_CACHE_TTL",
+			Value: dns.CacheTTL.Duration.String(),
+		})
+	}
+	if len(dns.Nameservers) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "DNS_NAMESERVERS",
+			Value: strings.Join(dns.Nameservers, ","),
+		})
+	}
+	return env
+}
+
+// tlsPolicyEnv returns the env vars of the TLS policy of the listeners and
+// sink client, if any.
+func tlsPolicyEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	policy := source.Spec.TLSPolicy
+	if policy == nil {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if policy.MinVersion != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "TLS_MIN_VERSION",
+			Value: policy.MinVersion,
+		})
+	}
+	if len(policy.CipherSuites) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "TLS_CIPHER_SUITES",
+			Value: strings.Join(policy.CipherSuites, ","),
+		})
+	}
+	return env
+}
+
+// usageEnv returns the env vars of the polling of the usage of the RGW, if
+// any.
+func usageEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	usage := source.Spec.Usage
+	if usage == nil {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if len(usage.Users) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "USAGE_USERS",
+			Value: strings.Join(usage.Users, ","),
+		})
+	}
+	if usage.Interval != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "USAGE_INTERVAL",
+			Value: usage.Interval.Duration.String(),
+		})
+	}
+	return env
+}
+
+// expiryPreviewEnv returns the env vars of the preview of the expiration of
+// objects, if any.
+func expiryPreviewEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	preview := source.Spec.ExpiryPreview
+	if preview == nil {
+		return nil
+	}
+	env := []corev1.EnvVar{{
+		Name:  "EXPIRY_PREVIEW_BUCKETS",
+		Value: strings.Join(preview.Buckets, ","),
+	}}
+	if preview.Days != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "EXPIRY_PREVIEW_DAYS",
+			Value: strconv.Itoa(int(*preview.Days)),
+		})
+	}
+	if preview.Interval != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "EXPIRY_PREVIEW_INTERVAL",
+			Value: preview.Interval.Duration.String(),
+		})
+	}
+	return env
+}
+
+// auditEnv returns the env vars of the mirroring of the events to an audit
+// bucket, if any.
+func auditEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	audit := source.Spec.Audit
+	if audit == nil {
+		return nil
+	}
+	env := []corev1.EnvVar{{
+		Name:  "AUDIT_BUCKET",
+		Value: audit.Bucket,
+	}, {
+		Name:  "AUDIT_PREFIX",
+		Value: audit.Prefix,
+	}}
+	if audit.FlushInterval != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "AUDIT_FLUSH_INTERVAL",
+			Value: audit.FlushInterval.Duration.String(),
+		})
+	}
+	if audit.MaxBatch != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "AUDIT_MAX_BATCH",
+			Value: strconv.Itoa(int(*audit.MaxBatch)),
+		})
+	}
+	return env
+}
+
+// sinkHeadersEnv returns the env vars of the headers added to the requests to
+// the sink, if any.
+func sinkHeadersEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	if len(source.Spec.SinkHeaders) == 0 {
+		return nil
+	}
+	env := make([]corev1.EnvVar, 0, len(source.Spec.SinkHeaders)+1)
+	names := make([]string, 0, len(source.Spec.SinkHeaders))
+	for i, header := range source.Spec.SinkHeaders {
+		names = append(names, header.Name)
+		v := corev1.EnvVar{
+			Name:  fmt.Sprintf("SINK_HEADER_%d", i),
+			Value: header.Value,
+		}
+		if header.ValueFrom != nil {
+			v.ValueFrom = &corev1.EnvVarSource{
+				SecretKeyRef: header.ValueFrom.DeepCopy(),
+			}
+		}
+		env = append(env, v)
+	}
+	return append(env, corev1.EnvVar{
+		Name:  "SINK_HEADERS",
+		Value: strings.Join(names, ","),
+	})
+}
+
+// storageEnv returns the env vars selecting the stores of the adapter, if
+// any.
+func storageEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	storage := source.Spec.Storage
+	if storage == nil {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if storage.Spool != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "SPOOL_STORE",
+			Value: storage.Spool,
+		})
+	}
+	if storage.Dedup != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "DEDUP_STORE",
+			Value: storage.Dedup,
+		})
+	}
+	return env
+}
+
+// retentionEnv returns the env vars enabling the retention of the events
+// that could not be delivered, if any.
+func retentionEnv(source *v1alpha1.CephSource) []corev1.EnvVar {
+	retention := source.Spec.Retention
+	if retention == nil {
+		return nil
+	}
+	env := []corev1.EnvVar{{
+		Name:  "RETENTION_DIR",
+		Value: RetentionDir,
+	}}
+	if retention.MaxEvents != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  "RETENTION_MAX_EVENTS",
+			Value: strconv.Itoa(int(*retention.MaxEvents)),
+		})
+	}
+	return env
 }
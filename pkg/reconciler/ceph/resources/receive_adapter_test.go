@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func TestReadinessProbe(t *testing.T) {
+	for name, tc := range map[string]struct {
+		spec v1alpha1.CephSourceSpec
+		port int
+	}{
+		"port":           {spec: v1alpha1.CephSourceSpec{Port: "8080"}, port: 8080},
+		"admin port":     {spec: v1alpha1.CephSourceSpec{Port: "8080", Admin: &v1alpha1.CephSourceAdmin{Port: "9998"}}, port: 9998},
+		"ephemeral port": {spec: v1alpha1.CephSourceSpec{Port: "0"}},
+	} {
+		probe := readinessProbe(&v1alpha1.CephSource{Spec: tc.spec})
+		switch {
+		case tc.port == 0 && probe != nil:
+			t.Errorf("%s: unexpected probe %+v", name, probe)
+		case tc.port == 0:
+		case probe == nil || probe.HTTPGet == nil:
+			t.Errorf("%s: expected an HTTP probe", name)
+		case probe.HTTPGet.Path != "/ready" || probe.HTTPGet.Port != intstr.FromInt(tc.port):
+			t.Errorf("%s: unexpected probe of %s on %s", name, probe.HTTPGet.Path, probe.HTTPGet.Port.String())
+		}
+	}
+}
+
+func TestDeliveryEnvFailoverSinks(t *testing.T) {
+	source := &v1alpha1.CephSource{
+		Spec: v1alpha1.CephSourceSpec{Delivery: &v1alpha1.CephSourceDelivery{}},
+		Status: v1alpha1.CephSourceStatus{
+			FailoverSinkURIs: []apis.URL{
+				{Scheme: "http", Host: "backup-1", RawQuery: "tags=a,b"},
+				{Scheme: "http", Host: "backup-2"},
+			},
+		},
+	}
+	var sinks []string
+	for _, env := range deliveryEnv(source) {
+		if env.Name == "FAILOVER_SINKS" {
+			if err := json.Unmarshal([]byte(env.Value), &sinks); err != nil {
+				t.Fatalf("FAILOVER_SINKS is not JSON: %v", err)
+			}
+		}
+	}
+	want := []string{"http://backup-1?tags=a,b", "http://backup-2"}
+	if !reflect.DeepEqual(sinks, want) {
+		t.Errorf("Unexpected failover sinks: got %q, want %q", sinks, want)
+	}
+}
+
+func TestMeshAnnotations(t *testing.T) {
+	for name, tc := range map[string]struct {
+		spec v1alpha1.CephSourceSpec
+		want map[string]string
+	}{
+		"no mesh": {
+			spec: v1alpha1.CephSourceSpec{Port: "8080"},
+		},
+		"istio": {
+			spec: v1alpha1.CephSourceSpec{Port: "8080", Mesh: v1alpha1.MeshIstio},
+			want: map[string]string{
+				"sidecar.istio.io/inject":                      "true",
+				"traffic.sidecar.istio.io/includeInboundPorts": "8080",
+				"traffic.sidecar.istio.io/excludeInboundPorts": "9090,8008",
+			},
+		},
+		"istio with admin port": {
+			spec: v1alpha1.CephSourceSpec{Port: "8080", Mesh: v1alpha1.MeshIstio, Admin: &v1alpha1.CephSourceAdmin{Port: "8090"}},
+			want: map[string]string{
+				"sidecar.istio.io/inject":                      "true",
+				"traffic.sidecar.istio.io/includeInboundPorts": "8080",
+				"traffic.sidecar.istio.io/excludeInboundPorts": "9090,8008,8090",
+			},
+		},
+		"linkerd": {
+			spec: v1alpha1.CephSourceSpec{Port: "8080", Mesh: v1alpha1.MeshLinkerd},
+			want: map[string]string{
+				"linkerd.io/inject":                    "enabled",
+				"config.linkerd.io/skip-inbound-ports": "9090,8008",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := meshAnnotations(&v1alpha1.CephSource{Spec: tc.spec})
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Unexpected annotations: got %v, want %v", got, tc.want)
+			}
+			for k := range got {
+				if !managedByMesh(k) {
+					t.Errorf("Annotation %s not removed once the mesh is unset", k)
+				}
+			}
+		})
+	}
+}
+
+func managedByMesh(key string) bool {
+	for _, prefix := range MeshAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMakeEnvBindAddress(t *testing.T) {
+	for name, tc := range map[string]struct {
+		mesh string
+		want string
+	}{
+		"no mesh": {},
+		"istio":   {mesh: v1alpha1.MeshIstio, want: "127.0.0.1"},
+		"linkerd": {mesh: v1alpha1.MeshLinkerd, want: "127.0.0.1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			for _, env := range makeEnv(&v1alpha1.CephSource{Spec: v1alpha1.CephSourceSpec{Port: "8080", Mesh: tc.mesh}}) {
+				if env.Name == "BIND_ADDRESS" {
+					got = env.Value
+				}
+			}
+			if got != tc.want {
+				t.Errorf("Unexpected BIND_ADDRESS: got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"knative.dev/pkg/apis"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/ceph2ce"
+)
+
+// DataSchema returns the JSON schema of the event data of a source, empty
+// when its data is projected or transformed.
+func DataSchema(source *v1alpha1.CephSource) string {
+	if reshaped(source) {
+		return ""
+	}
+	return string(ceph2ce.Schema(ceph2ce.SchemaName(source.Spec.Profile, source.Spec.Payload)))
+}
+
+// DataSchemaURL returns the URL the receive adapter of a source serves the
+// JSON schema of its event data at, nil unless the adapter is exposed and
+// its data neither projected nor transformed.
+func DataSchemaURL(source *v1alpha1.CephSource) *apis.URL {
+	if source.Status.ExternalURL == nil || reshaped(source) {
+		return nil
+	}
+	u := *source.Status.ExternalURL
+	u.Path = ceph2ce.SchemaPath + ceph2ce.SchemaName(source.Spec.Profile, source.Spec.Payload) + ".json"
+	return &u
+}
+
+// reshaped returns whether the event data of a source is projected or
+// transformed, and no longer matches its schema.
+func reshaped(source *v1alpha1.CephSource) bool {
+	return len(source.Spec.DataProjection) > 0 || source.Spec.DataTemplate != ""
+}
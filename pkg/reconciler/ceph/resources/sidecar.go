@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"knative.dev/pkg/kmeta"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+// SidecarGVR is the resource of Istio Sidecars.
+var SidecarGVR = schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "sidecars"}
+
+// SidecarName returns the name of the Istio Sidecar of the receive adapter
+// of a source.
+func SidecarName(source *v1alpha1.CephSource) string {
+	return receiveAdapterName(source)
+}
+
+// MakeSidecar generates (but does not insert into K8s) the Istio Sidecar of
+// the receive adapter of a source. As Istio 1.10 and later forward inbound
+// traffic to the pod IP, it forwards the notifications port to the adapter
+// listening on localhost.
+func MakeSidecar(source *v1alpha1.CephSource, labels map[string]string) *unstructured.Unstructured {
+	port, _ := strconv.ParseInt(source.Spec.Port, 10, 64)
+	selector := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		selector[k] = v
+	}
+	spec := map[string]interface{}{
+		"workloadSelector": map[string]interface{}{
+			"labels": selector,
+		},
+		"ingress": []interface{}{
+			map[string]interface{}{
+				"port": map[string]interface{}{
+					"number":   port,
+					"protocol": "HTTP",
+					"name":     "http",
+				},
+				"defaultEndpoint": meshBindAddress + ":" + source.Spec.Port,
+			},
+		},
+		// The default egress of the mesh, which Sidecars must set.
+		"egress": []interface{}{
+			map[string]interface{}{
+				"hosts": []interface{}{"*/*"},
+			},
+		},
+	}
+
+	sidecar := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	sidecar.SetAPIVersion(SidecarGVR.GroupVersion().String())
+	sidecar.SetKind("Sidecar")
+	sidecar.SetNamespace(source.Namespace)
+	sidecar.SetName(SidecarName(source))
+	sidecar.SetLabels(labels)
+	sidecar.SetOwnerReferences([]metav1.OwnerReference{*kmeta.NewControllerRef(source)})
+	return sidecar
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+func TestMakeSidecar(t *testing.T) {
+	source := &v1alpha1.CephSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "source", UID: "1234"},
+		Spec:       v1alpha1.CephSourceSpec{Port: "8080", Mesh: v1alpha1.MeshIstio},
+	}
+	sidecar := MakeSidecar(source, map[string]string{"app": "source"})
+
+	if sidecar.GetKind() != "Sidecar" || sidecar.GetAPIVersion() != "networking.istio.io/v1beta1" {
+		t.Errorf("Unexpected kind: %s %s", sidecar.GetAPIVersion(), sidecar.GetKind())
+	}
+	if sidecar.GetNamespace() != "ns" || sidecar.GetName() != SidecarName(source) {
+		t.Errorf("Unexpected name: %s/%s", sidecar.GetNamespace(), sidecar.GetName())
+	}
+	if refs := sidecar.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != "1234" {
+		t.Errorf("Unexpected owner references: %+v", refs)
+	}
+	selector, _, _ := unstructured.NestedStringMap(sidecar.Object, "spec", "workloadSelector", "labels")
+	if diff := cmp.Diff(map[string]string{"app": "source"}, selector); diff != "" {
+		t.Errorf("Unexpected workload selector (-want, +got): %s", diff)
+	}
+	ingress, _, _ := unstructured.NestedSlice(sidecar.Object, "spec", "ingress")
+	if len(ingress) != 1 {
+		t.Fatalf("Unexpected ingress: %v", ingress)
+	}
+	listener := ingress[0].(map[string]interface{})
+	if got := listener["defaultEndpoint"]; got != "127.0.0.1:8080" {
+		t.Errorf("Unexpected default endpoint: %v", got)
+	}
+	if got, _, _ := unstructured.NestedInt64(listener, "port", "number"); got != 8080 {
+		t.Errorf("Unexpected port: %d", got)
+	}
+}
@@ -19,10 +19,12 @@ package reconciler
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"go.uber.org/zap"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -53,6 +55,11 @@ func newDeploymentUpdated(namespace, name string) pkgreconciler.Event {
 
 type DeploymentReconciler struct {
 	KubeClientSet kubernetes.Interface
+
+	// ManagedAnnotationPrefixes are the prefixes of the pod annotations
+	// owned by the reconciler, removed when no longer expected. Other pod
+	// annotations, e.g. set by kubectl rollout restart, are kept.
+	ManagedAnnotationPrefixes []string
 }
 
 func (r *DeploymentReconciler) ReconcileDeployment(ctx context.Context, owner kmeta.OwnerRefable, expected *appsv1.Deployment) (*appsv1.Deployment, pkgreconciler.Event) {
@@ -69,7 +76,8 @@ func (r *DeploymentReconciler) ReconcileDeployment(ctx context.Context, owner km
 	} else if !metav1.IsControlledBy(ra, owner.GetObjectMeta()) {
 		return nil, fmt.Errorf("deployment %q is not owned by %s %q",
 			ra.Name, owner.GetGroupVersionKind().Kind, owner.GetObjectMeta().GetName())
-	} else if r.podSpecImageSync(expected.Spec.Template.Spec, ra.Spec.Template.Spec) {
+	} else if annotationsDirty, specDirty := r.podAnnotationsSync(expected.Spec.Template.Annotations, &ra.Spec.Template.ObjectMeta),
+		r.podSpecSync(expected.Spec.Template.Spec, &ra.Spec.Template.Spec); annotationsDirty || specDirty {
 		if ra, err = r.KubeClientSet.AppsV1().Deployments(namespace).Update(ctx, ra, metav1.UpdateOptions{}); err != nil {
 			return ra, err
 		}
@@ -105,12 +113,50 @@ func getContainer(name string, spec corev1.PodSpec) (int, *corev1.Container) {
 	return -1, nil
 }
 
-// Returns false if an update is needed.
-func (r *DeploymentReconciler) podSpecImageSync(expected corev1.PodSpec, now corev1.PodSpec) bool {
+// Returns true if an update is needed.
+func (r *DeploymentReconciler) podAnnotationsSync(expected map[string]string, now *metav1.ObjectMeta) bool {
+	dirty := false
+	for k := range now.Annotations {
+		if _, ok := expected[k]; !ok && r.managedAnnotation(k) {
+			delete(now.Annotations, k)
+			dirty = true
+		}
+	}
+	for k, v := range expected {
+		if cur, ok := now.Annotations[k]; !ok || cur != v {
+			if now.Annotations == nil {
+				now.Annotations = make(map[string]string, len(expected))
+			}
+			now.Annotations[k] = v
+			dirty = true
+		}
+	}
+	return dirty
+}
+
+func (r *DeploymentReconciler) managedAnnotation(key string) bool {
+	for _, prefix := range r.ManagedAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if an update is needed.
+func (r *DeploymentReconciler) podSpecSync(expected corev1.PodSpec, now *corev1.PodSpec) bool {
 	// got needs all of the containers that want as, but it is allowed to have more.
 	dirty := false
+	if !equality.Semantic.DeepEqual(now.Volumes, expected.Volumes) {
+		now.Volumes = expected.Volumes
+		dirty = true
+	}
+	if !equality.Semantic.DeepEqual(now.ImagePullSecrets, expected.ImagePullSecrets) {
+		now.ImagePullSecrets = expected.ImagePullSecrets
+		dirty = true
+	}
 	for _, ec := range expected.Containers {
-		n, nc := getContainer(ec.Name, now)
+		n, nc := getContainer(ec.Name, *now)
 		if nc == nil {
 			now.Containers = append(now.Containers, ec)
 			dirty = true
@@ -120,6 +166,35 @@ func (r *DeploymentReconciler) podSpecImageSync(expected corev1.PodSpec, now cor
 			now.Containers[n].Image = ec.Image
 			dirty = true
 		}
+		if !equality.Semantic.DeepEqual(nc.Env, ec.Env) {
+			now.Containers[n].Env = ec.Env
+			dirty = true
+		}
+		if !equality.Semantic.DeepEqual(nc.Resources, ec.Resources) {
+			now.Containers[n].Resources = ec.Resources
+			dirty = true
+		}
+		if !equality.Semantic.DeepEqual(nc.VolumeMounts, ec.VolumeMounts) {
+			now.Containers[n].VolumeMounts = ec.VolumeMounts
+			dirty = true
+		}
+		if !equality.Semantic.DeepEqual(nc.ReadinessProbe, ec.ReadinessProbe) {
+			now.Containers[n].ReadinessProbe = ec.ReadinessProbe
+			dirty = true
+		}
 	}
 	return dirty
 }
+
+// DeleteDeployment deletes the Deployment of the given name if owner
+// controls it.
+func (r *DeploymentReconciler) DeleteDeployment(ctx context.Context, owner kmeta.OwnerRefable, name string) error {
+	client := r.KubeClientSet.AppsV1().Deployments(owner.GetObjectMeta().GetNamespace())
+	d, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil || !metav1.IsControlledBy(d, owner.GetObjectMeta()) {
+		return err
+	}
+	return ignoreNotFound(client.Delete(ctx, name, metav1.DeleteOptions{}))
+}
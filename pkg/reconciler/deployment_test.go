@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodAnnotationsSync(t *testing.T) {
+	r := &DeploymentReconciler{ManagedAnnotationPrefixes: []string{"sidecar.istio.io/", "linkerd.io/"}}
+	restartedAt := "kubectl.kubernetes.io/restartedAt"
+
+	testCases := map[string]struct {
+		expected  map[string]string
+		now       map[string]string
+		want      map[string]string
+		wantDirty bool
+	}{
+		"unchanged": {
+			expected: map[string]string{"sidecar.istio.io/inject": "true"},
+			now:      map[string]string{"sidecar.istio.io/inject": "true"},
+			want:     map[string]string{"sidecar.istio.io/inject": "true"},
+		},
+		"added": {
+			expected:  map[string]string{"sidecar.istio.io/inject": "true"},
+			want:      map[string]string{"sidecar.istio.io/inject": "true"},
+			wantDirty: true,
+		},
+		"changed": {
+			expected:  map[string]string{"linkerd.io/inject": "enabled"},
+			now:       map[string]string{"linkerd.io/inject": "disabled"},
+			want:      map[string]string{"linkerd.io/inject": "enabled"},
+			wantDirty: true,
+		},
+		"unmanaged kept": {
+			expected: map[string]string{"sidecar.istio.io/inject": "true"},
+			now:      map[string]string{"sidecar.istio.io/inject": "true", restartedAt: "2021-06-01T12:00:00Z"},
+			want:     map[string]string{"sidecar.istio.io/inject": "true", restartedAt: "2021-06-01T12:00:00Z"},
+		},
+		"stale managed removed": {
+			expected:  map[string]string{"linkerd.io/inject": "enabled"},
+			now:       map[string]string{"sidecar.istio.io/inject": "true", restartedAt: "2021-06-01T12:00:00Z"},
+			want:      map[string]string{"linkerd.io/inject": "enabled", restartedAt: "2021-06-01T12:00:00Z"},
+			wantDirty: true,
+		},
+		"mesh unset": {
+			now:       map[string]string{"sidecar.istio.io/inject": "true"},
+			want:      map[string]string{},
+			wantDirty: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			now := &metav1.ObjectMeta{Annotations: tc.now}
+			if dirty := r.podAnnotationsSync(tc.expected, now); dirty != tc.wantDirty {
+				t.Errorf("Unexpected dirty: got %v, want %v", dirty, tc.wantDirty)
+			}
+			if diff := cmp.Diff(tc.want, now.Annotations); diff != "" {
+				t.Errorf("Unexpected annotations (-want, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"knative.dev/eventing-ceph/pkg/reconciler/resources"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	eventingclient "knative.dev/eventing/pkg/client/clientset/versioned"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+	pkgreconciler "knative.dev/pkg/reconciler"
+)
+
+type EventTypeReconciler struct {
+	EventingClientSet eventingclient.Interface
+}
+
+// ReconcileEventTypes registers the given event types, and the schema of
+// their data, with broker on behalf of owner, creating, updating and deleting
+// the EventTypes labeled with labels as needed. All the EventTypes are
+// deleted when broker is empty.
+func (r *EventTypeReconciler) ReconcileEventTypes(ctx context.Context, owner kmeta.OwnerRefable, broker string, types []string, schema *apis.URL, schemaData string, labels map[string]string) pkgreconciler.Event {
+	var expected []*v1beta1.EventType
+	if broker != "" {
+		expected = resources.MakeEventTypes(owner, broker, types, schema, schemaData, labels)
+	}
+
+	namespace := owner.GetObjectMeta().GetNamespace()
+	client := r.EventingClientSet.EventingV1beta1().EventTypes(namespace)
+
+	list, err := client.List(ctx, metav1.ListOptions{LabelSelector: k8slabels.SelectorFromSet(labels).String()})
+	if err != nil {
+		return fmt.Errorf("error listing EventTypes: %v", err)
+	}
+	existing := make(map[string]v1beta1.EventType, len(list.Items))
+	for _, et := range list.Items {
+		if metav1.IsControlledBy(&et, owner.GetObjectMeta()) {
+			existing[et.Name] = et
+		}
+	}
+
+	for _, want := range expected {
+		et, ok := existing[want.Name]
+		delete(existing, want.Name)
+		if !ok {
+			if _, err := client.Create(ctx, want, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+				return fmt.Errorf("error creating EventType %q: %v", want.Name, err)
+			}
+			continue
+		}
+		if !equality.Semantic.DeepDerivative(want.Spec, et.Spec) {
+			et.Spec = want.Spec
+			if _, err := client.Update(ctx, &et, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("error updating EventType %q: %v", et.Name, err)
+			}
+		}
+	}
+
+	for name := range existing {
+		if err := client.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("error deleting EventType %q: %v", name, err)
+		}
+	}
+	return nil
+}
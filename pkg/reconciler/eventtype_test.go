@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	eventingclient "knative.dev/eventing/pkg/client/clientset/versioned"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/reconciler/resources"
+)
+
+const eventTypesPath = "/apis/eventing.knative.dev/v1beta1/namespaces/ns/eventtypes"
+
+var eventTypeLabels = map[string]string{"knative-eventing-source-name": "source"}
+
+func eventType(owner kmeta.OwnerRefable, broker, t string) *v1beta1.EventType {
+	return resources.MakeEventTypes(owner, broker, []string{t}, nil, "", eventTypeLabels)[0]
+}
+
+func TestReconcileEventTypes(t *testing.T) {
+	owner := exposeOwner()
+	other := &v1alpha1.CephSource{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other", UID: "5678"}}
+	created, removed := "ceph.s3.ObjectCreated:Put", "ceph.s3.ObjectRemoved:Delete"
+
+	stale := eventType(owner, "default", created)
+	stale.Spec.Broker = "previous"
+	otherType := eventType(other, "default", created)
+	otherType.Name = "other-type"
+
+	testCases := map[string]struct {
+		existing    []*v1beta1.EventType
+		broker      string
+		types       []string
+		want        map[string]*v1beta1.EventType
+		wantUpdates int
+	}{
+		"create": {
+			broker: "default",
+			types:  []string{created, removed},
+			want: map[string]*v1beta1.EventType{
+				eventType(owner, "default", created).Name: eventType(owner, "default", created),
+				eventType(owner, "default", removed).Name: eventType(owner, "default", removed),
+			},
+		},
+		"unchanged": {
+			existing: []*v1beta1.EventType{eventType(owner, "default", created)},
+			broker:   "default",
+			types:    []string{created},
+			want: map[string]*v1beta1.EventType{
+				eventType(owner, "default", created).Name: eventType(owner, "default", created),
+			},
+		},
+		"update": {
+			existing: []*v1beta1.EventType{stale},
+			broker:   "default",
+			types:    []string{created},
+			want: map[string]*v1beta1.EventType{
+				eventType(owner, "default", created).Name: eventType(owner, "default", created),
+			},
+			wantUpdates: 1,
+		},
+		"delete stale": {
+			existing: []*v1beta1.EventType{eventType(owner, "default", created), eventType(owner, "default", removed)},
+			broker:   "default",
+			types:    []string{created},
+			want: map[string]*v1beta1.EventType{
+				eventType(owner, "default", created).Name: eventType(owner, "default", created),
+			},
+		},
+		"sink not a broker": {
+			existing: []*v1beta1.EventType{eventType(owner, "default", created)},
+			types:    []string{created},
+			want:     map[string]*v1beta1.EventType{},
+		},
+		"owned by another source": {
+			existing: []*v1beta1.EventType{otherType},
+			types:    []string{created},
+			want:     map[string]*v1beta1.EventType{otherType.Name: otherType},
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			s := &apiServer{objects: make(map[string]map[string]interface{})}
+			for _, et := range tc.existing {
+				b, _ := json.Marshal(et)
+				var obj map[string]interface{}
+				json.Unmarshal(b, &obj)
+				s.objects[eventTypesPath+"/"+et.Name] = obj
+			}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+			r := &EventTypeReconciler{EventingClientSet: eventingclient.NewForConfigOrDie(&rest.Config{Host: srv.URL})}
+
+			if err := r.ReconcileEventTypes(context.Background(), owner, tc.broker, tc.types, nil, "", eventTypeLabels); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]*v1beta1.EventType, len(s.objects))
+			for path, obj := range s.objects {
+				b, _ := json.Marshal(obj)
+				et := &v1beta1.EventType{}
+				if err := json.Unmarshal(b, et); err != nil {
+					t.Fatal(err)
+				}
+				// As set by the client when sending the EventType.
+				et.TypeMeta = metav1.TypeMeta{}
+				got[path[len(eventTypesPath)+1:]] = et
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unexpected EventTypes (-want, +got): %s", diff)
+			}
+			if s.updates != tc.wantUpdates {
+				t.Errorf("Unexpected number of updates: got %d, want %d", s.updates, tc.wantUpdates)
+			}
+		})
+	}
+}
+
+func TestMakeEventTypesOwnerReference(t *testing.T) {
+	owner := exposeOwner()
+	schema := apis.HTTP("source-svc.ns.svc/schemas/record.json")
+	et := resources.MakeEventTypes(owner, "default", []string{"ceph.s3.ObjectCreated:Put"}, schema, "{}", eventTypeLabels)[0]
+
+	refs := et.OwnerReferences
+	if len(refs) != 1 || refs[0].UID != owner.UID || refs[0].Kind != "CephSource" || refs[0].Controller == nil || !*refs[0].Controller {
+		t.Errorf("Unexpected owner references: %+v", refs)
+	}
+	if et.Namespace != "ns" || et.Spec.Broker != "default" || et.Spec.Schema.String() != schema.String() || et.Spec.SchemaData != "{}" {
+		t.Errorf("Unexpected EventType: %+v", et)
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/kmeta"
+	pkgreconciler "knative.dev/pkg/reconciler"
+)
+
+// newExposeCreated makes a new reconciler event with event type Normal, and
+// reason <kind>Created.
+func newExposeCreated(kind, namespace, name string) pkgreconciler.Event {
+	return pkgreconciler.NewEvent(corev1.EventTypeNormal, kind+"Created", "created %s: \"%s/%s\"", kind, namespace, name)
+}
+
+// newExposeFailed makes a new reconciler event with event type Warning, and
+// reason <kind>Failed.
+func newExposeFailed(kind, namespace, name string, err error) pkgreconciler.Event {
+	return pkgreconciler.NewEvent(corev1.EventTypeWarning, kind+"Failed", "failed to create %s: \"%s/%s\", %w", kind, namespace, name, err)
+}
+
+// newExposeUpdated makes a new reconciler event with event type Normal, and
+// reason <kind>Updated.
+func newExposeUpdated(kind, namespace, name string) pkgreconciler.Event {
+	return pkgreconciler.NewEvent(corev1.EventTypeNormal, kind+"Updated", "updated %s: \"%s/%s\"", kind, namespace, name)
+}
+
+// ExposeReconciler reconciles the Service, Ingress or OpenShift Route
+// exposing a receive adapter outside of the cluster.
+type ExposeReconciler struct {
+	KubeClientSet    kubernetes.Interface
+	DynamicClientSet dynamic.Interface
+}
+
+func (r *ExposeReconciler) ReconcileService(ctx context.Context, owner kmeta.OwnerRefable, expected *corev1.Service) (*corev1.Service, pkgreconciler.Event) {
+	client := r.KubeClientSet.CoreV1().Services(expected.Namespace)
+	svc, err := client.Get(ctx, expected.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		svc, err = client.Create(ctx, expected, metav1.CreateOptions{})
+		if err != nil {
+			return nil, newExposeFailed("Service", expected.Namespace, expected.Name, err)
+		}
+		return svc, newExposeCreated("Service", svc.Namespace, svc.Name)
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting service %q: %v", expected.Name, err)
+	} else if !metav1.IsControlledBy(svc, owner.GetObjectMeta()) {
+		return nil, fmt.Errorf("service %q is not owned by %s %q",
+			svc.Name, owner.GetGroupVersionKind().Kind, owner.GetObjectMeta().GetName())
+	}
+
+	// The cluster IP and node ports are allocated by the API server.
+	if svc.Spec.Type == expected.Spec.Type &&
+		equality.Semantic.DeepEqual(svc.Spec.Selector, expected.Spec.Selector) &&
+		equality.Semantic.DeepEqual(svc.Annotations, expected.Annotations) &&
+		servicePortsEqual(svc.Spec.Ports, expected.Spec.Ports) {
+		return svc, nil
+	}
+	svc = svc.DeepCopy()
+	svc.Annotations = expected.Annotations
+	svc.Spec.Type = expected.Spec.Type
+	svc.Spec.Selector = expected.Spec.Selector
+	svc.Spec.Ports = expected.Spec.Ports
+	if svc, err = client.Update(ctx, svc, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return svc, newExposeUpdated("Service", svc.Namespace, svc.Name)
+}
+
+func servicePortsEqual(now, expected []corev1.ServicePort) bool {
+	if len(now) != len(expected) {
+		return false
+	}
+	for i := range now {
+		if now[i].Name != expected[i].Name || now[i].Protocol != expected[i].Protocol ||
+			now[i].Port != expected[i].Port || now[i].TargetPort != expected[i].TargetPort {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ExposeReconciler) ReconcileIngress(ctx context.Context, owner kmeta.OwnerRefable, expected *networkingv1.Ingress) (*networkingv1.Ingress, pkgreconciler.Event) {
+	client := r.KubeClientSet.NetworkingV1().Ingresses(expected.Namespace)
+	ing, err := client.Get(ctx, expected.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		ing, err = client.Create(ctx, expected, metav1.CreateOptions{})
+		if err != nil {
+			return nil, newExposeFailed("Ingress", expected.Namespace, expected.Name, err)
+		}
+		return ing, newExposeCreated("Ingress", ing.Namespace, ing.Name)
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting ingress %q: %v", expected.Name, err)
+	} else if !metav1.IsControlledBy(ing, owner.GetObjectMeta()) {
+		return nil, fmt.Errorf("ingress %q is not owned by %s %q",
+			ing.Name, owner.GetGroupVersionKind().Kind, owner.GetObjectMeta().GetName())
+	}
+
+	if equality.Semantic.DeepEqual(ing.Spec, expected.Spec) &&
+		equality.Semantic.DeepEqual(ing.Annotations, expected.Annotations) {
+		return ing, nil
+	}
+	ing = ing.DeepCopy()
+	ing.Annotations = expected.Annotations
+	ing.Spec = expected.Spec
+	if ing, err = client.Update(ctx, ing, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return ing, newExposeUpdated("Ingress", ing.Namespace, ing.Name)
+}
+
+func (r *ExposeReconciler) ReconcileRoute(ctx context.Context, owner kmeta.OwnerRefable, gvr schema.GroupVersionResource, expected *unstructured.Unstructured) (*unstructured.Unstructured, pkgreconciler.Event) {
+	client := r.DynamicClientSet.Resource(gvr).Namespace(expected.GetNamespace())
+	route, err := client.Get(ctx, expected.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		route, err = client.Create(ctx, expected, metav1.CreateOptions{})
+		if err != nil {
+			return nil, newExposeFailed("Route", expected.GetNamespace(), expected.GetName(), err)
+		}
+		return route, newExposeCreated("Route", route.GetNamespace(), route.GetName())
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting route %q: %v", expected.GetName(), err)
+	} else if !metav1.IsControlledBy(route, owner.GetObjectMeta()) {
+		return nil, fmt.Errorf("route %q is not owned by %s %q",
+			route.GetName(), owner.GetGroupVersionKind().Kind, owner.GetObjectMeta().GetName())
+	}
+
+	// OpenShift defaults some fields of Routes, e.g. the generated host or
+	// the weight of the backend, so only the fields set by the source are
+	// compared and updated.
+	spec, _, _ := unstructured.NestedMap(expected.Object, "spec")
+	now, _, _ := unstructured.NestedMap(route.Object, "spec")
+	_, tls := spec["tls"]
+	if containsFields(now, spec) && tls == (now["tls"] != nil) &&
+		equality.Semantic.DeepEqual(route.GetAnnotations(), expected.GetAnnotations()) {
+		return route, nil
+	}
+	route = route.DeepCopy()
+	route.SetAnnotations(expected.GetAnnotations())
+	if now == nil {
+		now = make(map[string]interface{}, len(spec))
+	}
+	for k, v := range spec {
+		now[k] = v
+	}
+	if !tls {
+		delete(now, "tls")
+	}
+	if err := unstructured.SetNestedMap(route.Object, now, "spec"); err != nil {
+		return nil, err
+	}
+	if route, err = client.Update(ctx, route, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return route, newExposeUpdated("Route", route.GetNamespace(), route.GetName())
+}
+
+// containsFields returns whether the object now holds all the fields of
+// expected, with the same values.
+func containsFields(now, expected map[string]interface{}) bool {
+	for k, v := range expected {
+		if m, ok := v.(map[string]interface{}); ok {
+			n, ok := now[k].(map[string]interface{})
+			if !ok || !containsFields(n, m) {
+				return false
+			}
+		} else if !equality.Semantic.DeepEqual(now[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// DeleteService deletes the Service of the given name if owner controls it.
+func (r *ExposeReconciler) DeleteService(ctx context.Context, owner kmeta.OwnerRefable, name string) error {
+	client := r.KubeClientSet.CoreV1().Services(owner.GetObjectMeta().GetNamespace())
+	svc, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil || !metav1.IsControlledBy(svc, owner.GetObjectMeta()) {
+		return err
+	}
+	return ignoreNotFound(client.Delete(ctx, name, metav1.DeleteOptions{}))
+}
+
+// DeleteIngress deletes the Ingress of the given name if owner controls it.
+func (r *ExposeReconciler) DeleteIngress(ctx context.Context, owner kmeta.OwnerRefable, name string) error {
+	client := r.KubeClientSet.NetworkingV1().Ingresses(owner.GetObjectMeta().GetNamespace())
+	ing, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil || !metav1.IsControlledBy(ing, owner.GetObjectMeta()) {
+		return err
+	}
+	return ignoreNotFound(client.Delete(ctx, name, metav1.DeleteOptions{}))
+}
+
+// DeleteRoute deletes the Route of the given name if owner controls it. Not
+// finding the Route resource, outside of OpenShift, is not an error.
+func (r *ExposeReconciler) DeleteRoute(ctx context.Context, owner kmeta.OwnerRefable, gvr schema.GroupVersionResource, name string) error {
+	client := r.DynamicClientSet.Resource(gvr).Namespace(owner.GetObjectMeta().GetNamespace())
+	route, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil || !metav1.IsControlledBy(route, owner.GetObjectMeta()) {
+		return err
+	}
+	return ignoreNotFound(client.Delete(ctx, name, metav1.DeleteOptions{}))
+}
+
+func ignoreNotFound(err error) error {
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"knative.dev/pkg/kmeta"
+	pkgreconciler "knative.dev/pkg/reconciler"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+)
+
+var routeGVR = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+
+// apiServer is an in-memory API server storing the objects created and
+// updated through it, as JSON, by path, and listing them by label.
+type apiServer struct {
+	mu      sync.Mutex
+	objects map[string]map[string]interface{}
+	updates int
+}
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := r.URL.Path
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		body, _ := ioutil.ReadAll(r.Body)
+		var obj map[string]interface{}
+		if err := json.Unmarshal(body, &obj); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			path += "/" + obj["metadata"].(map[string]interface{})["name"].(string)
+		} else {
+			s.updates++
+		}
+		s.objects[path] = obj
+	}
+	obj, ok := s.objects[path]
+	switch {
+	case r.Method == http.MethodGet && isCollection(path):
+		obj = s.list(path, r.URL.Query().Get("labelSelector"))
+	case r.Method == http.MethodDelete:
+		delete(s.objects, path)
+		obj = map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Success"}
+	case !ok:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": http.StatusNotFound,
+		})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(obj)
+}
+
+// isCollection reports whether path is the one of a collection of
+// namespaced objects, e.g. /api/v1/namespaces/ns/services.
+func isCollection(path string) bool {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if part == "namespaces" {
+			return len(parts) == i+3
+		}
+	}
+	return false
+}
+
+// list returns the list of the objects of the collection at path matching
+// selector.
+func (s *apiServer) list(path, selector string) map[string]interface{} {
+	sel, _ := labels.Parse(selector)
+	items := []interface{}{}
+	for p, obj := range s.objects {
+		if p[:strings.LastIndex(p, "/")] != path {
+			continue
+		}
+		var meta metav1.ObjectMeta
+		b, _ := json.Marshal(obj["metadata"])
+		json.Unmarshal(b, &meta)
+		if sel.Matches(labels.Set(meta.Labels)) {
+			items = append(items, obj)
+		}
+	}
+	return map[string]interface{}{"metadata": map[string]interface{}{}, "items": items}
+}
+
+func newExposeReconciler(t *testing.T) (*ExposeReconciler, *apiServer) {
+	s := &apiServer{objects: make(map[string]map[string]interface{})}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	cfg := &rest.Config{Host: srv.URL}
+	return &ExposeReconciler{
+		KubeClientSet:    kubernetes.NewForConfigOrDie(cfg),
+		DynamicClientSet: dynamic.NewForConfigOrDie(cfg),
+	}, s
+}
+
+func exposeOwner() *v1alpha1.CephSource {
+	return &v1alpha1.CephSource{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "source", UID: "1234"}}
+}
+
+func eventReason(event pkgreconciler.Event) string {
+	var re *pkgreconciler.ReconcilerEvent
+	if !pkgreconciler.EventAs(event, &re) {
+		return ""
+	}
+	return re.Reason
+}
+
+func TestReconcileService(t *testing.T) {
+	r, s := newExposeReconciler(t)
+	owner := exposeOwner()
+	expected := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "ns",
+			Name:            "source-svc",
+			OwnerReferences: []metav1.OwnerReference{*kmeta.NewControllerRef(owner)},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: map[string]string{"app": "source"},
+			Ports:    []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt(8888)}},
+		},
+	}
+	ctx := context.Background()
+
+	if _, event := r.ReconcileService(ctx, owner, expected); eventReason(event) != "ServiceCreated" {
+		t.Fatalf("Unexpected event creating the service: %v", event)
+	}
+	// The node ports allocated by the API server are kept.
+	svc := s.objects["/api/v1/namespaces/ns/services/source-svc"]
+	svc["spec"].(map[string]interface{})["ports"].([]interface{})[0].(map[string]interface{})["nodePort"] = 30080
+	if _, event := r.ReconcileService(ctx, owner, expected); event != nil || s.updates != 0 {
+		t.Fatalf("Unexpected update of an unchanged service: %v", event)
+	}
+
+	expected = expected.DeepCopy()
+	expected.Spec.Ports[0].Port = 443
+	got, event := r.ReconcileService(ctx, owner, expected)
+	if eventReason(event) != "ServiceUpdated" || got.Spec.Ports[0].Port != 443 {
+		t.Fatalf("Unexpected update: %v %+v", event, got)
+	}
+
+	if _, event := r.ReconcileService(ctx, &v1alpha1.CephSource{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other", UID: "5678"}}, expected); event == nil || !strings.Contains(event.Error(), "not owned") {
+		t.Errorf("Expected an error for a service of another owner, got %v", event)
+	}
+
+	if err := r.DeleteService(ctx, owner, "source-svc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.objects["/api/v1/namespaces/ns/services/source-svc"]; ok {
+		t.Error("Service not deleted")
+	}
+	if err := r.DeleteService(ctx, owner, "source-svc"); err != nil {
+		t.Error("Unexpected error deleting a missing service:", err)
+	}
+}
+
+func TestReconcileRoute(t *testing.T) {
+	r, s := newExposeReconciler(t)
+	owner := exposeOwner()
+	expected := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "route.openshift.io/v1",
+		"kind":       "Route",
+		"spec": map[string]interface{}{
+			"to":  map[string]interface{}{"kind": "Service", "name": "source-svc"},
+			"tls": map[string]interface{}{"termination": "edge"},
+		},
+	}}
+	expected.SetNamespace("ns")
+	expected.SetName("source-route")
+	expected.SetOwnerReferences([]metav1.OwnerReference{*kmeta.NewControllerRef(owner)})
+	ctx := context.Background()
+
+	if _, event := r.ReconcileRoute(ctx, owner, routeGVR, expected); eventReason(event) != "RouteCreated" {
+		t.Fatalf("Unexpected event creating the route: %v", event)
+	}
+	// The fields defaulted by OpenShift are not reverted.
+	path := "/apis/route.openshift.io/v1/namespaces/ns/routes/source-route"
+	spec := s.objects[path]["spec"].(map[string]interface{})
+	spec["host"] = "source-ns.apps.example.com"
+	spec["to"].(map[string]interface{})["weight"] = float64(100)
+	if _, event := r.ReconcileRoute(ctx, owner, routeGVR, expected); event != nil || s.updates != 0 {
+		t.Fatalf("Unexpected update of an unchanged route: %v", event)
+	}
+
+	expected = expected.DeepCopy()
+	unstructured.RemoveNestedField(expected.Object, "spec", "tls")
+	got, event := r.ReconcileRoute(ctx, owner, routeGVR, expected)
+	if eventReason(event) != "RouteUpdated" {
+		t.Fatalf("Unexpected event updating the route: %v", event)
+	}
+	if _, ok, _ := unstructured.NestedMap(got.Object, "spec", "tls"); ok {
+		t.Error("TLS not removed from the route")
+	}
+	if host, _, _ := unstructured.NestedString(got.Object, "spec", "host"); host != "source-ns.apps.example.com" {
+		t.Errorf("Generated host not kept: %q", host)
+	}
+}
+
+func TestContainsFields(t *testing.T) {
+	now := map[string]interface{}{
+		"host": "example.com",
+		"to":   map[string]interface{}{"name": "svc", "weight": 100},
+	}
+	for name, tc := range map[string]struct {
+		expected map[string]interface{}
+		want     bool
+	}{
+		"subset":        {expected: map[string]interface{}{"to": map[string]interface{}{"name": "svc"}}, want: true},
+		"other value":   {expected: map[string]interface{}{"to": map[string]interface{}{"name": "other"}}},
+		"missing field": {expected: map[string]interface{}{"tls": map[string]interface{}{"termination": "edge"}}},
+		"not a map":     {expected: map[string]interface{}{"host": map[string]interface{}{}}},
+	} {
+		if got := containsFields(now, tc.expected); got != tc.want {
+			t.Errorf("%s: got %v, want %v", name, got, tc.want)
+		}
+	}
+}
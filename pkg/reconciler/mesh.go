@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"knative.dev/pkg/kmeta"
+	pkgreconciler "knative.dev/pkg/reconciler"
+)
+
+// ErrSidecarUnavailable is returned when the Istio Sidecar resource is not
+// served, e.g. as Istio is not installed.
+var ErrSidecarUnavailable = errors.New("the Sidecar resource of Istio is not served")
+
+// MeshReconciler reconciles the Istio Sidecar routing the inbound traffic
+// of a receive adapter to it.
+type MeshReconciler struct {
+	DynamicClientSet dynamic.Interface
+}
+
+func (r *MeshReconciler) ReconcileSidecar(ctx context.Context, owner kmeta.OwnerRefable, gvr schema.GroupVersionResource, expected *unstructured.Unstructured) (*unstructured.Unstructured, pkgreconciler.Event) {
+	client := r.DynamicClientSet.Resource(gvr).Namespace(expected.GetNamespace())
+	sidecar, err := client.Get(ctx, expected.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		sidecar, err = client.Create(ctx, expected, metav1.CreateOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, ErrSidecarUnavailable
+		} else if err != nil {
+			return nil, newExposeFailed("Sidecar", expected.GetNamespace(), expected.GetName(), err)
+		}
+		return sidecar, newExposeCreated("Sidecar", sidecar.GetNamespace(), sidecar.GetName())
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting sidecar %q: %v", expected.GetName(), err)
+	} else if !metav1.IsControlledBy(sidecar, owner.GetObjectMeta()) {
+		return nil, fmt.Errorf("sidecar %q is not owned by %s %q",
+			sidecar.GetName(), owner.GetGroupVersionKind().Kind, owner.GetObjectMeta().GetName())
+	}
+
+	spec, _, _ := unstructured.NestedMap(expected.Object, "spec")
+	now, _, _ := unstructured.NestedMap(sidecar.Object, "spec")
+	if equality.Semantic.DeepEqual(now, spec) {
+		return sidecar, nil
+	}
+	sidecar = sidecar.DeepCopy()
+	if err := unstructured.SetNestedMap(sidecar.Object, spec, "spec"); err != nil {
+		return nil, err
+	}
+	if sidecar, err = client.Update(ctx, sidecar, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	return sidecar, newExposeUpdated("Sidecar", sidecar.GetNamespace(), sidecar.GetName())
+}
+
+// DeleteSidecar deletes the Sidecar of the given name if owner controls it.
+// Not finding the Sidecar resource, without Istio, is not an error.
+func (r *MeshReconciler) DeleteSidecar(ctx context.Context, owner kmeta.OwnerRefable, gvr schema.GroupVersionResource, name string) error {
+	client := r.DynamicClientSet.Resource(gvr).Namespace(owner.GetObjectMeta().GetNamespace())
+	sidecar, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil || !metav1.IsControlledBy(sidecar, owner.GetObjectMeta()) {
+		return err
+	}
+	return ignoreNotFound(client.Delete(ctx, name, metav1.DeleteOptions{}))
+}
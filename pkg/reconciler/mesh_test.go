@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"knative.dev/pkg/kmeta"
+)
+
+var sidecarGVR = schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "sidecars"}
+
+func expectedSidecar(owner kmeta.OwnerRefable, port int64) *unstructured.Unstructured {
+	sidecar := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "Sidecar",
+		"spec": map[string]interface{}{
+			"ingress": []interface{}{
+				map[string]interface{}{
+					"port":            map[string]interface{}{"number": port, "protocol": "HTTP", "name": "http"},
+					"defaultEndpoint": "127.0.0.1:8080",
+				},
+			},
+		},
+	}}
+	sidecar.SetNamespace("ns")
+	sidecar.SetName("source-sidecar")
+	sidecar.SetOwnerReferences([]metav1.OwnerReference{*kmeta.NewControllerRef(owner)})
+	return sidecar
+}
+
+func TestReconcileSidecar(t *testing.T) {
+	s := &apiServer{objects: make(map[string]map[string]interface{})}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	r := &MeshReconciler{DynamicClientSet: dynamic.NewForConfigOrDie(&rest.Config{Host: srv.URL})}
+	owner := exposeOwner()
+	ctx := context.Background()
+	path := "/apis/networking.istio.io/v1beta1/namespaces/ns/sidecars/source-sidecar"
+
+	if _, event := r.ReconcileSidecar(ctx, owner, sidecarGVR, expectedSidecar(owner, 8080)); eventReason(event) != "SidecarCreated" {
+		t.Fatalf("Unexpected event creating the sidecar: %v", event)
+	}
+	if _, event := r.ReconcileSidecar(ctx, owner, sidecarGVR, expectedSidecar(owner, 8080)); event != nil || s.updates != 0 {
+		t.Fatalf("Unexpected update of an unchanged sidecar: %v", event)
+	}
+	if _, event := r.ReconcileSidecar(ctx, owner, sidecarGVR, expectedSidecar(owner, 9090)); eventReason(event) != "SidecarUpdated" || s.updates != 1 {
+		t.Fatalf("Unexpected event updating the sidecar: %v", event)
+	}
+
+	if err := r.DeleteSidecar(ctx, owner, sidecarGVR, "source-sidecar"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.objects[path]; ok {
+		t.Error("Sidecar not deleted")
+	}
+}
+
+func TestReconcileSidecarUnavailable(t *testing.T) {
+	// Without Istio, the Sidecar resource is not found.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	r := &MeshReconciler{DynamicClientSet: dynamic.NewForConfigOrDie(&rest.Config{Host: srv.URL})}
+	owner := exposeOwner()
+
+	if _, event := r.ReconcileSidecar(context.Background(), owner, sidecarGVR, expectedSidecar(owner, 8080)); !errors.Is(event, ErrSidecarUnavailable) {
+		t.Errorf("Unexpected event: %v", event)
+	}
+	if err := r.DeleteSidecar(context.Background(), owner, sidecarGVR, "source-sidecar"); err != nil {
+		t.Error("Unexpected error deleting without Istio:", err)
+	}
+}
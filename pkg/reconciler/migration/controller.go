@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+	pkgreconciler "knative.dev/pkg/reconciler"
+
+	"knative.dev/eventing-ceph/pkg/reconciler/ceph/resources"
+
+	cephclient "knative.dev/eventing-ceph/pkg/client/injection/client"
+	cephsourceinformer "knative.dev/eventing-ceph/pkg/client/injection/informers/sources/v1alpha1/cephsource"
+	deploymentinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
+)
+
+// NewController initializes the controller migrating the receive adapters
+// deployed by hand to CephSources.
+func NewController(
+	ctx context.Context,
+	cmw configmap.Watcher,
+) *controller.Impl {
+	deploymentInformer := deploymentinformer.Get(ctx)
+
+	r := &Reconciler{
+		client:           cephclient.Get(ctx),
+		deploymentLister: deploymentInformer.Lister(),
+		cephSourceLister: cephsourceinformer.Get(ctx).Lister(),
+	}
+	impl := controller.NewContext(ctx, r, controller.ControllerOptions{
+		WorkQueueName: "CephSourceMigrations",
+		Logger:        logging.FromContext(ctx),
+	})
+
+	logging.FromContext(ctx).Info("Setting up event handlers")
+
+	deploymentInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: pkgreconciler.LabelFilterFunc(resources.MigrateLabel, "true", false),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	return impl
+}
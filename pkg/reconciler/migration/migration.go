@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/controller"
+	"knative.dev/pkg/logging"
+
+	"knative.dev/eventing-ceph/pkg/client/clientset/versioned"
+	listers "knative.dev/eventing-ceph/pkg/client/listers/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/reconciler/ceph/resources"
+)
+
+// Reconciler creates a CephSource of the same name for each receive adapter
+// Deployment labeled for migration. The Deployment is left as is, to be
+// deleted once the adapter of the CephSource serves the notifications.
+type Reconciler struct {
+	client           versioned.Interface
+	deploymentLister appsv1listers.DeploymentLister
+	cephSourceLister listers.CephSourceLister
+}
+
+// Check that our Reconciler implements controller.Reconciler
+var _ controller.Reconciler = (*Reconciler)(nil)
+
+// Reconcile implements controller.Reconciler.
+func (r *Reconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", "key", key)
+		return nil
+	}
+
+	d, err := r.deploymentLister.Deployments(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if d.Labels[resources.MigrateLabel] != "true" {
+		return nil
+	}
+
+	existing, err := r.cephSourceLister.CephSources(namespace).Get(name)
+	switch {
+	case err == nil && existing.Annotations[resources.MigratedFromAnnotation] == name:
+		return nil
+	case err == nil:
+		logger.Warnf("Not migrating the deployment %s: a CephSource of the same name exists", key)
+		return nil
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+
+	src, unmigrated, err := resources.MakeSourceFromDeployment(d)
+	if err != nil {
+		return controller.NewPermanentError(fmt.Errorf("failed to migrate the deployment %s: %w", key, err))
+	}
+	if _, err := r.client.SourcesV1alpha1().CephSources(namespace).Create(ctx, src, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	if len(unmigrated) > 0 {
+		logger.Warnf("Migrated the deployment %s without the environment variables %v", key, unmigrated)
+	} else {
+		logger.Infof("Migrated the deployment %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1listers "k8s.io/client-go/listers/apps/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"knative.dev/pkg/controller"
+
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/client/clientset/versioned"
+	listers "knative.dev/eventing-ceph/pkg/client/listers/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/reconciler/ceph/resources"
+)
+
+// apiServer is an API server recording the CephSources created through it,
+// replying to the creations with status, if set.
+type apiServer struct {
+	mu      sync.Mutex
+	created []v1alpha1.CephSource
+	status  int
+}
+
+func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, _ := ioutil.ReadAll(r.Body)
+	var src v1alpha1.CephSource
+	if r.Method != http.MethodPost || json.Unmarshal(body, &src) != nil {
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	s.created = append(s.created, src)
+	w.Header().Set("Content-Type", "application/json")
+	if s.status != 0 {
+		reason := "InternalError"
+		if s.status == http.StatusConflict {
+			reason = "AlreadyExists"
+		}
+		w.WriteHeader(s.status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": reason, "code": s.status,
+		})
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write(body)
+}
+
+func deployment(labels map[string]string, env ...corev1.EnvVar) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "adapter", Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "adapter", Env: env}},
+				},
+			},
+		},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	migrate := map[string]string{resources.MigrateLabel: "true"}
+	sink := corev1.EnvVar{Name: "K_SINK", Value: "http://sink.ns.svc"}
+	for name, tc := range map[string]struct {
+		deployment *appsv1.Deployment
+		source     *v1alpha1.CephSource
+		status     int
+		created    bool
+		permanent  bool
+		fails      bool
+	}{
+		"no deployment": {},
+		"not labeled": {
+			deployment: deployment(map[string]string{resources.MigrateLabel: "false"}, sink),
+		},
+		"migrated": {
+			deployment: deployment(migrate, sink),
+			created:    true,
+		},
+		"already migrated": {
+			deployment: deployment(migrate, sink),
+			source: &v1alpha1.CephSource{ObjectMeta: metav1.ObjectMeta{
+				Namespace: "ns", Name: "adapter",
+				Annotations: map[string]string{resources.MigratedFromAnnotation: "adapter"},
+			}},
+		},
+		"source of the same name": {
+			deployment: deployment(migrate, sink),
+			source:     &v1alpha1.CephSource{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "adapter"}},
+		},
+		"no sink": {
+			deployment: deployment(migrate),
+			permanent:  true,
+			fails:      true,
+		},
+		"created concurrently": {
+			deployment: deployment(migrate, sink),
+			status:     http.StatusConflict,
+			created:    true,
+		},
+		"creation failure": {
+			deployment: deployment(migrate, sink),
+			status:     http.StatusInternalServerError,
+			created:    true,
+			fails:      true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := &apiServer{status: tc.status}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			deployments := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if tc.deployment != nil {
+				deployments.Add(tc.deployment)
+			}
+			sources := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			if tc.source != nil {
+				sources.Add(tc.source)
+			}
+			r := &Reconciler{
+				client:           versioned.NewForConfigOrDie(&rest.Config{Host: srv.URL}),
+				deploymentLister: appsv1listers.NewDeploymentLister(deployments),
+				cephSourceLister: listers.NewCephSourceLister(sources),
+			}
+
+			err := r.Reconcile(context.Background(), "ns/adapter")
+			if got := err != nil; got != tc.fails {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if got := controller.IsPermanentError(err); got != tc.permanent {
+				t.Errorf("Unexpected permanence of the error: %v", err)
+			}
+			if got := len(s.created) > 0; got != tc.created {
+				t.Fatalf("Unexpected creations: %+v", s.created)
+			}
+			if tc.created {
+				src := s.created[0]
+				if src.Name != "adapter" || src.Annotations[resources.MigratedFromAnnotation] != "adapter" || src.Spec.Sink.URI.String() != sink.Value {
+					t.Errorf("Unexpected source: %+v", src)
+				}
+			}
+		})
+	}
+}
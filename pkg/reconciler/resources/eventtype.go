@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/eventing/pkg/apis/eventing/v1beta1"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/kmeta"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// EventTypeName returns the name of the EventType registered by a source for
+// the given CloudEvent type.
+func EventTypeName(source, eventType string) string {
+	suffix := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(eventType), "-"), "-.")
+	return kmeta.ChildName(fmt.Sprintf("%s-", source), suffix)
+}
+
+// MakeEventTypes generates (but does not insert into K8s) the EventTypes a
+// source registers with the broker it sends the given CloudEvent types to,
+// along with the URL and content of the schema of their data if any.
+func MakeEventTypes(owner kmeta.OwnerRefable, broker string, types []string, schema *apis.URL, schemaData string, labels map[string]string) []*v1beta1.EventType {
+	eventTypes := make([]*v1beta1.EventType, 0, len(types))
+	for _, t := range types {
+		eventTypes = append(eventTypes, &v1beta1.EventType{
+			ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{
+					*kmeta.NewControllerRef(owner),
+				},
+				Name:      EventTypeName(owner.GetObjectMeta().GetName(), t),
+				Namespace: owner.GetObjectMeta().GetNamespace(),
+				Labels:    labels,
+			},
+			Spec: v1beta1.EventTypeSpec{
+				Type:        t,
+				Broker:      broker,
+				Description: "Ceph bucket notification",
+				Schema:      schema,
+				SchemaData:  schemaData,
+			},
+		})
+	}
+	return eventTypes
+}
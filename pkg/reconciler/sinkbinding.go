@@ -88,3 +88,18 @@ func (r *SinkBindingReconciler) ReconcileSinkBinding(ctx context.Context, owner
 func (r *SinkBindingReconciler) specChanged(oldSpec v1.SinkBindingSpec, newSpec v1.SinkBindingSpec) bool {
 	return !equality.Semantic.DeepDerivative(newSpec, oldSpec)
 }
+
+// DeleteSinkBinding deletes the SinkBinding of owner binding the subject of
+// the given name, if owner controls it.
+func (r *SinkBindingReconciler) DeleteSinkBinding(ctx context.Context, owner kmeta.OwnerRefable, subject string) error {
+	namespace := owner.GetObjectMeta().GetNamespace()
+	name := resources.SinkBindingName(owner.GetObjectMeta().GetName(), subject)
+	client := r.EventingClientSet.SourcesV1().SinkBindings(namespace)
+	sb, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil || !metav1.IsControlledBy(sb, owner.GetObjectMeta()) {
+		return err
+	}
+	return ignoreNotFound(client.Delete(ctx, name, metav1.DeleteOptions{}))
+}
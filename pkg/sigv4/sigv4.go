@@ -0,0 +1,257 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sigv4 implements the AWS Signature Version 4 signing of HTTP
+// requests, as accepted by the S3 API of Ceph RGW, and its verification, as
+// of the notifications pushed by the RGW builds signing them.
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Algorithm is the signing algorithm of the Authorization header.
+	Algorithm = "AWS4-HMAC-SHA256"
+
+	// EmptyPayloadHash is the hex SHA-256 of an empty payload.
+	EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	dateHeader          = "X-Amz-Date"
+	contentHashHeader   = "X-Amz-Content-Sha256"
+	unsignedPayloadHash = "UNSIGNED-PAYLOAD"
+	timeFormat          = "20060102T150405Z"
+	dateFormat          = "20060102"
+	requestScope        = "aws4_request"
+)
+
+// Credentials are the keys requests are signed with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// Sign signs req for the given region and service at time now, setting its
+// X-Amz-Date and Authorization headers. The host and X-Amz-* headers of the
+// request are signed. payloadHash is the hex SHA-256 of the request body.
+func Sign(req *http.Request, payloadHash string, creds Credentials, region, service string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set(dateHeader, now.Format(timeFormat))
+
+	names := []string{"host"}
+	for name := range req.Header {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-amz-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	scope := strings.Join([]string{now.Format(dateFormat), region, service, requestScope}, "/")
+	signature := signature(req, payloadHash, names, creds.SecretAccessKey, now, region, service)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		Algorithm, creds.AccessKeyID, scope, strings.Join(names, ";"), signature))
+}
+
+// Verify verifies the signature of req, whose body has the given hex
+// SHA-256, against the secret key returned by secret for its access key ID,
+// returning the access key ID. The request must have been signed at most
+// maxSkew away from now, and its payload signed unless unsignedPayload.
+func Verify(req *http.Request, payloadHash string, secret func(accessKeyID string) (string, bool), now time.Time, maxSkew time.Duration, unsignedPayload bool) (string, error) {
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, Algorithm+" ") {
+		return "", errors.New("the request is not signed with " + Algorithm)
+	}
+	fields := make(map[string]string, 3)
+	for _, f := range strings.Split(strings.TrimPrefix(auth, Algorithm+" "), ",") {
+		if kv := strings.SplitN(strings.TrimSpace(f), "=", 2); len(kv) == 2 {
+			fields[kv[0]] = kv[1]
+		}
+	}
+	credential := strings.Split(fields["Credential"], "/")
+	if len(credential) != 5 || credential[4] != requestScope {
+		return "", errors.New("invalid credential scope")
+	}
+	accessKeyID, date, region, service := credential[0], credential[1], credential[2], credential[3]
+	names := strings.Split(fields["SignedHeaders"], ";")
+	if !contains(names, "host") || !contains(names, strings.ToLower(dateHeader)) {
+		return "", errors.New("the host and date of the request are not signed")
+	}
+
+	t, err := time.Parse(timeFormat, req.Header.Get(dateHeader))
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", dateHeader, err)
+	}
+	if t.Format(dateFormat) != date {
+		return "", errors.New("the date of the credential scope is not the one of the request")
+	}
+	if skew := now.Sub(t); skew > maxSkew || skew < -maxSkew {
+		return "", fmt.Errorf("the request was signed at %s, more than %s away", t.Format(timeFormat), maxSkew)
+	}
+
+	switch contentHash := req.Header.Get(contentHashHeader); {
+	case contentHash == unsignedPayloadHash && unsignedPayload:
+		payloadHash = unsignedPayloadHash
+	case contentHash == unsignedPayloadHash:
+		return "", errors.New("the payload of the request is not signed")
+	case contentHash != "" && contentHash != payloadHash:
+		return "", fmt.Errorf("the %s of the request does not match its body", contentHashHeader)
+	}
+
+	key, ok := secret(accessKeyID)
+	if !ok {
+		return "", fmt.Errorf("unknown access key ID %q", accessKeyID)
+	}
+	expected := signature(req, payloadHash, names, key, t, region, service)
+	if !hmac.Equal([]byte(expected), []byte(fields["Signature"])) {
+		return "", errors.New("signature mismatch")
+	}
+	return accessKeyID, nil
+}
+
+// signature returns the signature of req, signing the headers of the given
+// sorted lowercase names, with secret at time t.
+func signature(req *http.Request, payloadHash string, names []string, secret string, t time.Time, region, service string) string {
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders(req, names),
+		strings.Join(names, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{t.Format(dateFormat), region, service, requestScope}, "/")
+	stringToSign := strings.Join([]string{
+		Algorithm,
+		t.Format(timeFormat),
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := signingKey(secret, t.Format(dateFormat), region, service)
+	return hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+}
+
+// canonicalHeaders returns the canonical headers of req of the given sorted
+// lowercase names.
+func canonicalHeaders(req *http.Request, names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		var value string
+		if name == "host" {
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		} else {
+			values := req.Header.Values(name)
+			trimmed := make([]string, 0, len(values))
+			for _, v := range values {
+				trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+			}
+			value = strings.Join(trimmed, ",")
+		}
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(value)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalURI encodes each segment of the path once, as S3 expects.
+func canonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = Escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, Escape(k)+"="+Escape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// Escape percent-encodes s as SigV4 expects, leaving only the unreserved
+// characters of RFC 3986 as is.
+func Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(date))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+	return hmacSHA256(key, []byte(requestScope))
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sigv4
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCredentials = Credentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+// The expected signatures come from the AWS Signature Version 4 test suite.
+func TestSign(t *testing.T) {
+	testCases := map[string]struct {
+		url  string
+		want string
+	}{
+		"get-vanilla": {
+			url:  "https://example.amazonaws.com/",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		"get-vanilla-query-order-key-case": {
+			url:  "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			Sign(req, EmptyPayloadHash, testCredentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+			if got := req.Header.Get("Authorization"); got != tc.want {
+				t.Errorf("Unexpected Authorization:\ngot  %s\nwant %s", got, tc.want)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("Unexpected X-Amz-Date: %s", got)
+			}
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got, want := Escape("my fish/é~.jpg"), "my%20fish%2F%C3%A9~.jpg"; got != want {
+		t.Errorf("Unexpected escaping: got %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signedAt := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	body := []byte(`{"Records":[]}`)
+	secret := func(id string) (string, bool) {
+		if id != testCredentials.AccessKeyID {
+			return "", false
+		}
+		return testCredentials.SecretAccessKey, true
+	}
+	signed := func(modify func(*http.Request)) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "http://adapter.default.svc:8888/?topic=photos", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Amz-Content-Sha256", hexSHA256(body))
+		Sign(req, hexSHA256(body), testCredentials, "default", "sns", signedAt)
+		if modify != nil {
+			modify(req)
+		}
+		return req
+	}
+
+	testCases := map[string]struct {
+		req      *http.Request
+		now      time.Time
+		unsigned bool
+		wantErr  bool
+	}{
+		"valid": {
+			req: signed(nil),
+			now: signedAt.Add(time.Minute),
+		},
+		"unknown access key": {
+			req: signed(func(req *http.Request) {
+				req.Header.Set("Authorization", strings.Replace(req.Header.Get("Authorization"), "AKIDEXAMPLE", "AKIDOTHER", 1))
+			}),
+			now:     signedAt,
+			wantErr: true,
+		},
+		"not signed": {
+			req: signed(func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer token")
+			}),
+			now:     signedAt,
+			wantErr: true,
+		},
+		"skewed": {
+			req:     signed(nil),
+			now:     signedAt.Add(6 * time.Minute),
+			wantErr: true,
+		},
+		"tampered query": {
+			req: signed(func(req *http.Request) {
+				req.URL.RawQuery = "topic=videos"
+			}),
+			now:     signedAt,
+			wantErr: true,
+		},
+		"tampered body": {
+			req: signed(func(req *http.Request) {
+				req.Header.Set("X-Amz-Content-Sha256", EmptyPayloadHash)
+			}),
+			now:     signedAt,
+			wantErr: true,
+		},
+		"unsigned payload": {
+			req: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodPost, "http://adapter.default.svc:8888/", bytes.NewReader(body))
+				req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+				Sign(req, "UNSIGNED-PAYLOAD", testCredentials, "default", "sns", signedAt)
+				return req
+			}(),
+			now:      signedAt,
+			unsigned: true,
+		},
+		"unsigned payload not allowed": {
+			req: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodPost, "http://adapter.default.svc:8888/", bytes.NewReader(body))
+				req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+				Sign(req, "UNSIGNED-PAYLOAD", testCredentials, "default", "sns", signedAt)
+				return req
+			}(),
+			now:     signedAt,
+			wantErr: true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			id, err := Verify(tc.req, hexSHA256(body), secret, tc.now, 5*time.Minute, tc.unsigned)
+			if tc.wantErr {
+				if err == nil {
+					t.Error("Verify() = nil, wanted an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() = %v", err)
+			}
+			if id != testCredentials.AccessKeyID {
+				t.Errorf("Unexpected access key ID: %s", id)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tlspolicy restricts the TLS versions and cipher suites of the
+// listeners and the sink client of the adapter, e.g. for regulated
+// environments. Binaries built with GOEXPERIMENT=boringcrypto are further
+// restricted to the FIPS-approved settings.
+package tlspolicy
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+)
+
+// Versions maps the minimum versions a policy can set to the ones of
+// crypto/tls.
+var Versions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Policy is the minimum version and cipher suites of a TLS configuration.
+type Policy struct {
+	MinVersion   uint16
+	CipherSuites []uint16
+}
+
+// Parse returns the Policy of a minimum version, "1.2" or "1.3", and of the
+// names of cipher suites, as named by crypto/tls, e.g.
+// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". The defaults of crypto/tls are
+// kept for what is unset. Insecure cipher suites are rejected, and so are
+// cipher suites with TLS 1.3, whose are not configurable.
+func Parse(minVersion string, cipherSuites []string) (*Policy, error) {
+	p := &Policy{}
+	if minVersion != "" {
+		v, ok := Versions[minVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version %q", minVersion)
+		}
+		p.MinVersion = v
+	}
+	if len(cipherSuites) == 0 {
+		return p, nil
+	}
+	if p.MinVersion == tls.VersionTLS13 {
+		return nil, errors.New("the cipher suites of TLS 1.3 are not configurable")
+	}
+	ids := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		ids[s.Name] = s.ID
+	}
+	for _, name := range cipherSuites {
+		id, ok := ids[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported or insecure cipher suite %q", name)
+		}
+		p.CipherSuites = append(p.CipherSuites, id)
+	}
+	return p, nil
+}
+
+// Apply returns a copy of config, a new configuration if nil, restricted to
+// the policy.
+func (p *Policy) Apply(config *tls.Config) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	} else {
+		config = config.Clone()
+	}
+	if p.MinVersion != 0 {
+		config.MinVersion = p.MinVersion
+	}
+	if len(p.CipherSuites) > 0 {
+		config.CipherSuites = p.CipherSuites
+	}
+	return config
+}
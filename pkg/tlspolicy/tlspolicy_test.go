@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tlspolicy
+
+import (
+	"crypto/tls"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := map[string]struct {
+		minVersion   string
+		cipherSuites []string
+		want         *Policy
+		wantErr      bool
+	}{
+		"defaults": {
+			want: &Policy{},
+		},
+		"TLS 1.3": {
+			minVersion: "1.3",
+			want:       &Policy{MinVersion: tls.VersionTLS13},
+		},
+		"cipher suites": {
+			minVersion:   "1.2",
+			cipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"},
+			want: &Policy{
+				MinVersion:   tls.VersionTLS12,
+				CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
+			},
+		},
+		"TLS 1.1": {
+			minVersion: "1.1",
+			wantErr:    true,
+		},
+		"insecure cipher suite": {
+			cipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"},
+			wantErr:      true,
+		},
+		"cipher suites with TLS 1.3": {
+			minVersion:   "1.3",
+			cipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+			wantErr:      true,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			got, err := Parse(tc.minVersion, tc.cipherSuites)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Unexpected policy: got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	p := &Policy{MinVersion: tls.VersionTLS13}
+	base := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: "sink"}
+	got := p.Apply(base)
+	if got.MinVersion != tls.VersionTLS13 || got.ServerName != "sink" {
+		t.Errorf("Unexpected config: %+v", got)
+	}
+	if base.MinVersion != tls.VersionTLS12 {
+		t.Error("The base config was modified")
+	}
+	if got := p.Apply(nil); got.MinVersion != tls.VersionTLS13 {
+		t.Errorf("Unexpected config: %+v", got)
+	}
+}
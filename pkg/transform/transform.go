@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package transform reshapes JSON documents with Go templates rendering the
+// JSON of the transformed document, e.g.
+//
+//	{"bucket": {{json .s3.bucket.name}}, "key": {{json .s3.object.key}}}
+//
+// The templates are executed on the decoded document, and can use, besides
+// the functions of text/template, the functions of Funcs.
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Funcs are the functions available to the templates.
+var Funcs = template.FuncMap{
+	// json returns the JSON of a value, null for missing fields.
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	// default returns the value, or def when it is missing or empty.
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":       func(sep string, elems []string) string { return strings.Join(elems, sep) },
+}
+
+// Transform is a parsed transformation template.
+type Transform struct {
+	template *template.Template
+}
+
+// Parse returns the transformation of a template.
+func Parse(text string) (*Transform, error) {
+	t, err := template.New("transform").Funcs(Funcs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Transform{template: t}, nil
+}
+
+// Apply returns the compacted JSON rendered by the template for the JSON
+// document data, failing if the template does not render valid JSON.
+func (t *Transform) Apply(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	var rendered bytes.Buffer
+	if err := t.template.Execute(&rendered, document); err != nil {
+		return nil, err
+	}
+	var transformed bytes.Buffer
+	if err := json.Compact(&transformed, rendered.Bytes()); err != nil {
+		return nil, fmt.Errorf("the template did not render JSON: %w", err)
+	}
+	return transformed.Bytes(), nil
+}
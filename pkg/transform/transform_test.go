@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transform
+
+import (
+	"testing"
+)
+
+const record = `{
+	"eventName": "s3:ObjectCreated:Put",
+	"s3": {
+		"bucket": {"name": "fishbucket"},
+		"object": {
+			"key": "images/fish9.jpg",
+			"size": 12345678901234567890,
+			"metadata": [{"key": "x-amz-meta-team", "value": "a"}]
+		}
+	}
+}`
+
+func TestApply(t *testing.T) {
+	testCases := map[string]struct {
+		template string
+		want     string
+	}{
+		"rename fields": {
+			template: `{"bucket": {{json .s3.bucket.name}}, "key": {{json .s3.object.key}}}`,
+			want:     `{"bucket":"fishbucket","key":"images/fish9.jpg"}`,
+		},
+		"computed fields": {
+			template: `{
+				"url": {{printf "s3://%s/%s" .s3.bucket.name .s3.object.key | json}},
+				"folder": {{index (split "/" .s3.object.key) 0 | json}},
+				"created": {{json (eq .eventName "s3:ObjectCreated:Put")}}
+			}`,
+			want: `{"url":"s3://fishbucket/images/fish9.jpg","folder":"images","created":true}`,
+		},
+		"numbers": {
+			template: `{"size": {{json .s3.object.size}}}`,
+			want:     `{"size":12345678901234567890}`,
+		},
+		"missing fields": {
+			template: `{"etag": {{json .s3.object.eTag}}, "team": {{default "none" .s3.object.team | json}}}`,
+			want:     `{"etag":null,"team":"none"}`,
+		},
+		"ranges": {
+			template: `[{{range $i, $m := .s3.object.metadata}}{{if $i}},{{end}}{{json (trimPrefix "x-amz-meta-" $m.key)}}{{end}}]`,
+			want:     `["team"]`,
+		},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			transform, err := Parse(tc.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := transform.Apply([]byte(record))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("Unexpected transformation: got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	for _, text := range []string{
+		`{{json .s3.bucket.name`,
+		`{{unknown .s3}}`,
+	} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Expected an error parsing %q", text)
+		}
+	}
+
+	transform, err := Parse(`{"key": {{.s3.object.key}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transform.Apply([]byte(record)); err == nil {
+		t.Error("Expected an error for a template not rendering JSON")
+	}
+}
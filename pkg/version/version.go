@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package version exposes the version of the running binary through an HTTP
+// handler and a build_info metric. The version and commit are set at build
+// time with:
+//
+//   -ldflags "-X knative.dev/eventing-ceph/pkg/version.Version=<version> \
+//             -X knative.dev/eventing-ceph/pkg/version.Commit=<commit>"
+//
+// When the commit is not set, the one recorded by ko in the kodata directory
+// is used.
+//
+// Binaries built with GOEXPERIMENT=boringcrypto report FIPS mode.
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"strconv"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/pkg/changeset"
+	"knative.dev/pkg/metrics"
+)
+
+var (
+	// Version is the version of the binary.
+	Version = "devel"
+
+	// Commit is the git commit the binary was built from.
+	Commit = ""
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"goVersion"`
+	FIPS      bool   `json:"fips"`
+}
+
+// Get returns the Info of the running binary.
+func Get() Info {
+	commit := Commit
+	if commit == "" {
+		commit, _ = changeset.Get()
+	}
+	return Info{
+		Version:   Version,
+		Commit:    commit,
+		GoVersion: runtime.Version(),
+		FIPS:      fips,
+	}
+}
+
+// Handler serves the Info of the running binary as JSON.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Get())
+}
+
+var (
+	// buildInfoM is a gauge, always set to 1, whose tags describe the running binary.
+	buildInfoM = stats.Int64(
+		"build_info",
+		"Build information of the running binary",
+		stats.UnitDimensionless,
+	)
+
+	versionKey   = tag.MustNewKey("version")
+	commitKey    = tag.MustNewKey("commit")
+	goVersionKey = tag.MustNewKey("go_version")
+	fipsKey      = tag.MustNewKey("fips")
+
+	// registerErr is the error registering the build_info view, if any.
+	registerErr = view.Register(&view.View{
+		Description: buildInfoM.Description(),
+		Measure:     buildInfoM,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{versionKey, commitKey, goVersionKey, fipsKey},
+	})
+)
+
+// RecordBuildInfo records the build_info metric of the running binary, or
+// returns the error registering it.
+func RecordBuildInfo(ctx context.Context) error {
+	if registerErr != nil {
+		return registerErr
+	}
+
+	info := Get()
+	ctx, err := tag.New(ctx,
+		tag.Insert(versionKey, info.Version),
+		tag.Insert(commitKey, info.Commit),
+		tag.Insert(goVersionKey, info.GoVersion),
+		tag.Insert(fipsKey, strconv.FormatBool(info.FIPS)))
+	if err != nil {
+		return err
+	}
+	metrics.Record(ctx, buildInfoM.M(1))
+	return nil
+}
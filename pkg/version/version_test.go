@@ -0,0 +1,62 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package version
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	Version, Commit = "v0.1.0", "0123456"
+	defer func() { Version, Commit = "devel", "" }()
+
+	w := httptest.NewRecorder()
+	Handler(w, httptest.NewRequest("GET", "/version", nil))
+
+	var got Info
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := Info{Version: "v0.1.0", Commit: "0123456", GoVersion: runtime.Version(), FIPS: fips}
+	if got != want {
+		t.Errorf("Unexpected version info: got %+v, want %+v", got, want)
+	}
+}
+
+func TestRecordBuildInfo(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		if err := RecordBuildInfo(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRecordBuildInfoRegisterError(t *testing.T) {
+	registerErr = errors.New("conflicting view")
+	defer func() { registerErr = nil }()
+
+	for i := 0; i < 2; i++ {
+		if err := RecordBuildInfo(context.Background()); err != registerErr {
+			t.Errorf("Unexpected error of call %d: got %v, want %v", i, err, registerErr)
+		}
+	}
+}
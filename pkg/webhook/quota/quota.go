@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package quota enforces the number of CephSources allowed per namespace by
+// the config-ceph-quotas ConfigMap, as a validation webhook callback.
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/pkg/webhook"
+	"knative.dev/pkg/webhook/resourcesemantics/validation"
+
+	"knative.dev/eventing-ceph/pkg/apis/config"
+	"knative.dev/eventing-ceph/pkg/client/clientset/versioned"
+)
+
+// NewCallback returns a validation callback rejecting the creation of
+// CephSources beyond the maxSources quota of their namespace.
+func NewCallback(client versioned.Interface) validation.Callback {
+	return validation.NewCallback(func(ctx context.Context, u *unstructured.Unstructured) error {
+		return checkMaxSources(ctx, client, u.GetNamespace())
+	}, webhook.Create)
+}
+
+func checkMaxSources(ctx context.Context, client versioned.Interface, namespace string) error {
+	quota := config.FromContextOrDefaults(ctx).Quotas.ForNamespace(namespace)
+	if quota == nil || quota.MaxSources == nil {
+		return nil
+	}
+	sources, err := client.SourcesV1alpha1().CephSources(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to count the CephSources of namespace %s: %w", namespace, err)
+	}
+	if len(sources.Items) >= int(*quota.MaxSources) {
+		return fmt.Errorf("namespace %s reached its quota of %d CephSources", namespace, *quota.MaxSources)
+	}
+	return nil
+}
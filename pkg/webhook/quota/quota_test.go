@@ -0,0 +1,67 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/ptr"
+
+	"knative.dev/eventing-ceph/pkg/apis/config"
+	"knative.dev/eventing-ceph/pkg/apis/sources/v1alpha1"
+	"knative.dev/eventing-ceph/pkg/client/clientset/versioned/fake"
+)
+
+func TestCheckMaxSources(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1alpha1.CephSource{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "fish"}},
+		&v1alpha1.CephSource{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "fish"}},
+	)
+	ctx := config.ToContext(context.Background(), &config.Config{
+		Defaults: &config.Defaults{},
+		Quotas: &config.Quotas{
+			ClusterDefault: &config.NamespaceQuota{MaxSources: ptr.Int32(1)},
+			NamespaceQuotas: map[string]*config.NamespaceQuota{
+				"team-b": {MaxSources: ptr.Int32(2)},
+				"team-c": {},
+			},
+		},
+	})
+
+	testCases := map[string]struct {
+		namespace string
+		wantErr   bool
+	}{
+		"quota reached":     {namespace: "team-a", wantErr: true},
+		"under quota":       {namespace: "team-b"},
+		"empty namespace":   {namespace: "team-d"},
+		"unlimited sources": {namespace: "team-c"},
+	}
+	for n, tc := range testCases {
+		t.Run(n, func(t *testing.T) {
+			if err := checkMaxSources(ctx, client, tc.namespace); (err != nil) != tc.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	if err := checkMaxSources(context.Background(), client, "team-a"); err != nil {
+		t.Errorf("Sources should be unlimited without quotas: %v", err)
+	}
+}
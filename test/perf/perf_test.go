@@ -0,0 +1,345 @@
+//go:build perf
+// +build perf
+
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package perf runs replicas of the receive adapter under synthetic RGW
+// load, killing them and the sink intermittently, and checks that every
+// notification is delivered at least once and that the adapters send no
+// more events than the RGW pushes notifications. Run it with:
+//
+//	go test -tags perf ./test/perf -v -perf.replicas=5 -perf.notifications=100000
+package perf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/kelseyhightower/envconfig"
+	"go.uber.org/zap"
+	"knative.dev/pkg/logging"
+
+	cephadapter "knative.dev/eventing-ceph/pkg/adapter"
+	ceph "knative.dev/eventing-ceph/pkg/apis/bindings/v1alpha1"
+)
+
+var (
+	replicas      = flag.Int("perf.replicas", 3, "Number of adapter replicas")
+	notifications = flag.Int("perf.notifications", 20000, "Number of notifications pushed")
+	pushers       = flag.Int("perf.pushers", 16, "Number of concurrent RGW pushers")
+	chaosInterval = flag.Duration("perf.chaos-interval", 300*time.Millisecond, "Mean interval between two kills")
+	downtime      = flag.Duration("perf.downtime", 100*time.Millisecond, "Time a killed adapter or sink stays down")
+	deadline      = flag.Duration("perf.deadline", 2*time.Minute, "Time for all the notifications to be acknowledged")
+)
+
+func TestChaos(t *testing.T) {
+	sink := newSink(t)
+	defer sink.stop()
+
+	os.Setenv("NAMESPACE", "perf")
+	os.Setenv("NAME", "perf")
+	os.Setenv("SKIP_DUPLICATES", "true")
+	defer func() {
+		for _, name := range []string{"NAMESPACE", "NAME", "SKIP_DUPLICATES", "PORT"} {
+			os.Unsetenv(name)
+		}
+	}()
+	adapters := make([]*replica, *replicas)
+	for i := range adapters {
+		adapters[i] = startReplica(t, freePort(t), sink.url())
+	}
+	defer func() {
+		for _, r := range adapters {
+			r.stop()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *deadline)
+	defer cancel()
+
+	// The RGW pushes each notification to a replica, as the Service of the
+	// adapter, retrying it until acknowledged as persistent topics do.
+	attempts := make([]int, *notifications)
+	var acked int64
+	work := make(chan int)
+	var pushing sync.WaitGroup
+	for p := 0; p < *pushers; p++ {
+		pushing.Add(1)
+		go func() {
+			defer pushing.Done()
+			for i := range work {
+				if attempts[i] = push(ctx, adapters, notification(i)); ctx.Err() == nil {
+					atomic.AddInt64(&acked, 1)
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(work)
+		for i := 0; i < *notifications; i++ {
+			select {
+			case work <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		pushing.Wait()
+		close(done)
+	}()
+
+	// Kills and restarts an adapter or the sink at random intervals until
+	// all the notifications are pushed.
+	kills := 0
+chaos:
+	for {
+		select {
+		case <-done:
+			break chaos
+		case <-time.After(time.Duration(rand.Int63n(int64(2 * *chaosInterval)))):
+		}
+		kills++
+		if i := rand.Intn(len(adapters) + 1); i < len(adapters) {
+			port := adapters[i].port
+			adapters[i].stop()
+			time.Sleep(*downtime)
+			adapters[i] = startReplica(t, port, sink.url())
+		} else {
+			sink.stop()
+			time.Sleep(*downtime)
+			sink.start(t)
+		}
+	}
+
+	if got := int(atomic.LoadInt64(&acked)); got != *notifications {
+		t.Fatalf("Only %d of %d notifications were acknowledged within %v", got, *notifications, *deadline)
+	}
+	received := sink.counts()
+	pushes, duplicates := 0, 0
+	for i, n := range attempts {
+		pushes += n
+		got := received[key(i)]
+		switch {
+		case got == 0:
+			t.Errorf("Notification %s was acknowledged but not delivered", key(i))
+		case got > n:
+			t.Errorf("Notification %s was delivered %d times for %d pushes", key(i), got, n)
+		case got > 1:
+			duplicates += got - 1
+		}
+	}
+	t.Logf("%d notifications, %d pushes, %d kills, %d duplicate events (%.2f%%)",
+		*notifications, pushes, kills, duplicates, 100*float64(duplicates)/float64(*notifications))
+}
+
+// key returns the object key of the i-th notification, the subject of its
+// event.
+func key(i int) string {
+	return fmt.Sprintf("perf/%06d", i)
+}
+
+// notification returns the body of the i-th notification.
+func notification(i int) []byte {
+	body, _ := json.Marshal(ceph.BucketNotifications{Records: []ceph.BucketNotification{{
+		EventVersion: "2.2",
+		EventSource:  "ceph:s3",
+		AwsRegion:    "perf",
+		EventTime:    time.Now().UTC().Format(time.RFC3339Nano),
+		EventName:    "s3:ObjectCreated:Put",
+		ResponseElements: ceph.ResponseElementsSpec{
+			XAmzRequestID: fmt.Sprintf("perf.%d", i),
+			XAmzID2:       "perf",
+		},
+		S3: ceph.S3Spec{
+			S3SchemaVersion: "1.0",
+			ConfigurationID: "perf",
+			Bucket:          ceph.BucketSpec{Name: "perf", Arn: "arn:aws:s3:::perf"},
+			Object: ceph.ObjectSpec{
+				Key:       key(i),
+				Size:      1024,
+				ETag:      "37b51d194a7513e45b56f6524f2d51f2",
+				Sequencer: fmt.Sprintf("%016X", i),
+			},
+		},
+		EventID: fmt.Sprintf("perf.%d", i),
+	}}})
+	return body
+}
+
+// push pushes a notification to random replicas until one acknowledges it
+// or ctx is done, returning the number of attempts.
+func push(ctx context.Context, adapters []*replica, body []byte) int {
+	for attempt := 1; ; attempt++ {
+		url := fmt.Sprintf("http://127.0.0.1:%s/", adapters[rand.Intn(len(adapters))].port)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return attempt
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if resp, err := http.DefaultClient.Do(req); err == nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return attempt
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return attempt
+		case <-time.After(time.Duration(10+rand.Intn(40)) * time.Millisecond):
+		}
+	}
+}
+
+// replica is a running receive adapter.
+type replica struct {
+	port   string
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// startReplica starts a receive adapter listening on port and sending to
+// sink, as a new pod would.
+func startReplica(t *testing.T, port, sink string) *replica {
+	t.Helper()
+	os.Setenv("PORT", port)
+	env := cephadapter.NewEnvConfig()
+	if err := envconfig.Process("", env); err != nil {
+		t.Fatal(err)
+	}
+	client, err := cloudevents.NewClientHTTP(cloudevents.WithTarget(sink))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(logging.WithLogger(context.Background(), zap.NewNop().Sugar()))
+	r := &replica{port: port, cancel: cancel, done: make(chan error, 1)}
+	a := cephadapter.NewAdapter(ctx, env, client)
+	go func() {
+		r.done <- a.Start(ctx)
+	}()
+	waitListening(t, port)
+	return r
+}
+
+// stop shuts the adapter down, as on the termination of its pod.
+func (r *replica) stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+	r.cancel = nil
+}
+
+// sink counts the events received per subject, and can be killed and
+// restarted on the same address.
+type sink struct {
+	addr string
+
+	mu       sync.Mutex
+	server   *http.Server
+	received map[string]int
+}
+
+func newSink(t *testing.T) *sink {
+	s := &sink{addr: net.JoinHostPort("127.0.0.1", freePort(t)), received: make(map[string]int)}
+	s.start(t)
+	return s
+}
+
+func (s *sink) url() string {
+	return "http://" + s.addr
+}
+
+func (s *sink) start(t *testing.T) {
+	t.Helper()
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: s}
+	s.mu.Lock()
+	s.server = server
+	s.mu.Unlock()
+	go server.Serve(ln)
+}
+
+// stop kills the sink, dropping the requests being served.
+func (s *sink) stop() {
+	s.mu.Lock()
+	server := s.server
+	s.mu.Unlock()
+	server.Close()
+}
+
+func (s *sink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	io.Copy(ioutil.Discard, r.Body)
+	s.mu.Lock()
+	s.received[r.Header.Get("Ce-Subject")]++
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *sink) counts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int, len(s.received))
+	for k, v := range s.received {
+		counts[k] = v
+	}
+	return counts
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	return port
+}
+
+func waitListening(t *testing.T, port string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", port))
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("The adapter did not listen on port %s", port)
+}